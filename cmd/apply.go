@@ -0,0 +1,217 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"runtime"
+
+	"github.com/renstrom/dedent"
+	"github.com/spf13/cobra"
+	"github.com/wso2/update-creator-tool/constant"
+	"github.com/wso2/update-creator-tool/util"
+)
+
+// Values used to print help command.
+var (
+	applyCmdUse       = "apply <update_loc> <dist_dir> | apply --recover <dist_dir>"
+	applyCmdShortDesc = "Apply an update to an extracted distribution"
+	applyCmdLongDesc  = dedent.Dedent(`
+		This command will apply the given update to the given extracted distribution the same way
+		wum-client applies it, so the update can be tested locally. Added and modified files of the
+		update are copied to the distribution, binary deltas are reconstructed from the files of the
+		distribution and the removed files declared in the update-descriptor3.yaml are deleted.
+		Files of the distribution which are overwritten or deleted are backed up to a rollback zip in
+		'updates/wum-uc-backups/<update_name>' in the distribution, along with a backup manifest
+		which records the changes of the update. The update can be undone with 'wum-uc rollback'
+		using the rollback zip. An update which is already applied is not applied again.
+		Placeholders of the templated files are substituted with the variables given with
+		'--variable <name>=<value>'. OS variants are only applied if they are installed in the
+		operating system given with '--os' (the current operating system by default). The owners and
+		the groups recorded in the update-descriptor3.yaml are applied if '--apply-ownership' is
+		given, after mapping them with '--ownership-mapping <mapping_file>'. If the distribution the
+		directory is extracted from is given with '--distribution', modified files of which the
+		content matches neither the distribution nor the update are detected as locally customized
+		files, and applying fails unless the policy for them is given with '--keep-custom',
+		'--overwrite' or '--merge-prompt'. Give '--product-checksums' to verify the payload files
+		against the checksum manifests shipped by the product before applying the update.
+		If '--transactional' is given, the files of the update are staged and verified before the
+		distribution is changed, and the staged files are swapped with the files of the distribution
+		by renaming them. A journal is kept in the backup directory of the update until it is applied,
+		so that an interrupted apply (eg: by a crash) is rolled back with
+		'wum-uc apply --recover <dist_dir>'.`)
+)
+
+// applyCmd represents the apply command.
+var applyCmd = &cobra.Command{
+	Use:   applyCmdUse,
+	Short: applyCmdShortDesc,
+	Long:  applyCmdLongDesc,
+	Run:   initializeApplyCommand,
+}
+
+var isApplyTransactional bool
+var isInterruptedApplyRecovered bool
+
+// This function will be called first and this will add flags to the command.
+func init() {
+	RootCmd.AddCommand(applyCmd)
+
+	applyCmd.Flags().BoolVarP(&isDebugLogsEnabled, "debug", "d", util.EnableDebugLogs, "Enable debug logs")
+	applyCmd.Flags().BoolVarP(&isTraceLogsEnabled, "trace", "t", util.EnableTraceLogs, "Enable trace logs")
+	applyCmd.Flags().StringVar(&decryptionKeyPath, "decryption-key", "", "OpenPGP secret key used to "+
+		"decrypt an encrypted update")
+	applyCmd.Flags().StringSliceVar(&templateVariables, "variable", []string{}, "Variable referred by the "+
+		"substitutions of the templated files, as <name>=<value>")
+	applyCmd.Flags().StringVar(&targetOS, "os", runtime.GOOS, "Operating system of the distribution, used to "+
+		"select the OS variants of the payload")
+	applyCmd.Flags().BoolVar(&isOwnershipApplied, "apply-ownership", false, "Apply the owners and the groups "+
+		"recorded in the update to the applied files")
+	applyCmd.Flags().StringVar(&ownershipMappingPath, "ownership-mapping", "", "File which maps the recorded "+
+		"owners and groups to the users and groups of this system")
+	applyCmd.Flags().StringVar(&extractDistributionPath, "distribution", "", "Distribution the directory is "+
+		"extracted from, used to detect local customizations of the modified files")
+	applyCmd.Flags().BoolVar(&isProductChecksumsVerified, "product-checksums", false, "Verify the payload "+
+		"files against the checksum manifests shipped by the product (eg: a.jar.md5 next to a.jar)")
+	applyCmd.Flags().BoolVar(&isApplyTransactional, "transactional", false, "Stage and verify the files of the "+
+		"update before changing the distribution, and keep a journal to recover an interrupted apply")
+	applyCmd.Flags().BoolVar(&isInterruptedApplyRecovered, "recover", false, "Roll back the updates of which "+
+		"applying to the distribution is interrupted")
+	addCustomizationPolicyFlags(applyCmd)
+}
+
+// This function will be called when the apply command is called.
+func initializeApplyCommand(cmd *cobra.Command, args []string) {
+	if isInterruptedApplyRecovered {
+		if len(args) != 1 {
+			util.HandleErrorAndExit(util.NewInputError(errors.New("'--recover' requires the distribution directory " +
+				"only. Run 'wum-uc apply --help' to view help")))
+		}
+		setLogLevel()
+		logger.Debug("[apply] command called to recover")
+		recoverInterruptedUpdates(args[0])
+		return
+	}
+	if len(args) != 2 {
+		util.HandleErrorAndExit(util.NewInputError(errors.New("invalid number of arguments. Run 'wum-uc apply " +
+			"--help' to view help")))
+	}
+	setLogLevel()
+	logger.Debug("[apply] command called")
+	applyUpdate(args[0], args[1], getCustomizationPolicy(extractDistributionPath))
+}
+
+// This function applies the given update to the given distribution directory. Encrypted and tar.zst updates are
+// converted to a zip in a temporary directory before applying. Locally customized files are detected when the
+// distribution the directory is extracted from is given.
+func applyUpdate(updateFilePath, distributionDirectory, customizationPolicy string) {
+	exists, err := util.IsFileExists(updateFilePath)
+	util.HandleErrorAndExit(err, "")
+	if !exists {
+		util.HandleErrorAndExit(util.NewInputError(errors.New(fmt.Sprintf("Entered update file does not exist at '%s'.",
+			updateFilePath))))
+	}
+	exists, err = util.IsDirectoryExists(distributionDirectory)
+	util.HandleErrorAndExit(err, "")
+	if !exists {
+		util.HandleErrorAndExit(util.NewInputError(errors.New(fmt.Sprintf("Entered distribution directory does "+
+			"not exist at '%s'.", distributionDirectory))))
+	}
+	updateZipPath := updateFilePath
+	if util.IsEncryptedFile(updateZipPath) {
+		updateZipPath = decryptUpdate(updateZipPath, decryptionKeyPath)
+		defer util.CleanUpDirectory(filepath.Dir(updateZipPath))
+	}
+	if util.IsTarZstFile(updateZipPath) {
+		updateZipPath = convertToUpdateZip(updateZipPath)
+		defer util.CleanUpDirectory(filepath.Dir(updateZipPath))
+	}
+	updateDescriptorV3 := readUpdateDescriptorV3OfZip(updateZipPath)
+	if len(updateDescriptorV3.UpdateNumber) == 0 {
+		util.HandleErrorAndExit(util.NewInputError(errors.New(fmt.Sprintf("'%s' not found in '%s'.",
+			constant.UPDATE_DESCRIPTOR_V3_FILE, updateFilePath))))
+	}
+	excludedFiles := getExcludedOSVariants(updateDescriptorV3, targetOS)
+	// Checks whether the payload files match the checksum manifests of the product after applying the update
+	if isProductChecksumsVerified {
+		err = checkProductChecksums(updateZipPath, distributionDirectory)
+		util.HandleErrorAndExit(util.NewValidationError(err))
+	}
+	var customizedFiles map[string]string
+	if len(extractDistributionPath) != 0 {
+		customizedFiles = getCustomizedFiles(updateZipPath, distributionDirectory, extractDistributionPath,
+			customizationPolicy, excludedFiles)
+	}
+
+	backupManifest, err := util.ApplyUpdateZip(updateZipPath, distributionDirectory, updateDescriptorV3,
+		customizedFiles, excludedFiles, isApplyTransactional)
+	if err != nil && backupManifest != nil {
+		util.PrintWarning(fmt.Sprintf("'%s' is partially applied to '%s'. Run 'wum-uc rollback %s %s' to undo "+
+			"it.", updateFilePath, distributionDirectory, util.GetRollbackZipPath(distributionDirectory,
+			backupManifest.UpdateName), distributionDirectory))
+	}
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while applying '%s' to '%s'.", updateFilePath,
+		distributionDirectory))
+	substituteTemplatedFiles(updateDescriptorV3, distributionDirectory, customizedFiles, excludedFiles)
+	if isOwnershipApplied || len(ownershipMappingPath) != 0 {
+		applyFileOwnerships(updateDescriptorV3, distributionDirectory, ownershipMappingPath, excludedFiles)
+	}
+	printAppliedChanges(backupManifest, updateDescriptorV3, distributionDirectory, excludedFiles)
+}
+
+// This function rolls back the updates of which applying to the given distribution directory is interrupted.
+func recoverInterruptedUpdates(distributionDirectory string) {
+	exists, err := util.IsDirectoryExists(distributionDirectory)
+	util.HandleErrorAndExit(err, "")
+	if !exists {
+		util.HandleErrorAndExit(util.NewInputError(errors.New(fmt.Sprintf("Entered distribution directory does "+
+			"not exist at '%s'.", distributionDirectory))))
+	}
+	recoveredUpdates, err := util.RecoverInterruptedUpdates(distributionDirectory)
+	for _, updateName := range recoveredUpdates {
+		util.PrintInfo(fmt.Sprintf("Interrupted '%s' rolled back from '%s'.", updateName, distributionDirectory))
+	}
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while recovering '%s'.", distributionDirectory))
+	if len(recoveredUpdates) == 0 {
+		util.PrintInfo(fmt.Sprintf("No interrupted updates found in '%s'.", distributionDirectory))
+	}
+}
+
+// This function prints the changes of the given update applied to the given distribution directory. Removed files
+// declared in the update which are not found in the distribution are printed as warnings.
+func printAppliedChanges(backupManifest *util.BackupManifest, updateDescriptorV3 *util.UpdateDescriptorV3,
+	distributionDirectory string, excludedFiles map[string]bool) {
+	deletedFiles := make(map[string]bool)
+	for _, removedFile := range backupManifest.RemovedFiles {
+		deletedFiles[removedFile] = true
+	}
+	for _, removedFile := range util.GetRemovedFiles(updateDescriptorV3) {
+		if !excludedFiles[removedFile] && !deletedFiles[removedFile] {
+			util.PrintWarning(fmt.Sprintf("Removed file '%s' not found in '%s'.", removedFile,
+				distributionDirectory))
+		}
+	}
+	util.PrintInfo(fmt.Sprintf("'%s' applied to '%s'. %d file(s) added, %d file(s) modified and %d file(s) "+
+		"removed.", backupManifest.UpdateName, distributionDirectory, len(backupManifest.AddedFiles),
+		len(backupManifest.ModifiedFiles), len(backupManifest.RemovedFiles)))
+	util.PrintInfo(fmt.Sprintf("Replaced files are backed up to '%s'.",
+		util.GetRollbackZipPath(distributionDirectory, backupManifest.UpdateName)))
+}
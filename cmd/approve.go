@@ -0,0 +1,131 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/renstrom/dedent"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/wso2/update-creator-tool/constant"
+	"github.com/wso2/update-creator-tool/util"
+)
+
+// Values used to print help command.
+var (
+	approveCmdUse       = "approve <update_loc>"
+	approveCmdShortDesc = "Approve an update for publishing"
+	approveCmdLongDesc  = dedent.Dedent(`
+		This command will record the approval of the given update zip by the reviewer given with
+		'--as <reviewer>'. The approval is bound to the content of the update zip by its sha256
+		checksum and is stored next to it in '<update_loc>.approvals'. The approval is signed with
+		the private key of the reviewer given with '--signing-key <private_key.pem>'. If
+		APPROVALS.REQUIRED is set in the config, the update is only published by
+		'wum-uc create --continue' once it is approved by that many distinct reviewers other than
+		its author, whose public keys are listed under APPROVALS.REVIEWER_KEYS.`)
+)
+
+// approveCmd represents the approve command.
+var approveCmd = &cobra.Command{
+	Use:   approveCmdUse,
+	Short: approveCmdShortDesc,
+	Long:  approveCmdLongDesc,
+	Run:   initializeApproveCommand,
+}
+
+var approvingReviewer string
+var approvalSigningKeyPath string
+
+// This function will be called first and this will add flags to the command.
+func init() {
+	RootCmd.AddCommand(approveCmd)
+
+	approveCmd.Flags().BoolVarP(&isDebugLogsEnabled, "debug", "d", util.EnableDebugLogs, "Enable debug logs")
+	approveCmd.Flags().BoolVarP(&isTraceLogsEnabled, "trace", "t", util.EnableTraceLogs, "Enable trace logs")
+	approveCmd.Flags().StringVar(&approvingReviewer, "as", "", "Reviewer who approves the update")
+	approveCmd.Flags().StringVar(&approvalSigningKeyPath, "signing-key", "", "RSA private key (PEM) of the "+
+		"reviewer used to sign the approval (required)")
+}
+
+// This function will be called when the approve command is called.
+func initializeApproveCommand(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		util.HandleErrorAndExit(util.NewInputError(errors.New("invalid number of arguments. Run 'wum-uc approve " +
+			"--help' to view help")))
+	}
+	if len(approvingReviewer) == 0 {
+		util.HandleErrorAndExit(util.NewInputError(errors.New("reviewer is not given. Run 'wum-uc approve " +
+			"<update_loc> --as <reviewer>'")))
+	}
+	if len(approvalSigningKeyPath) == 0 {
+		util.HandleErrorAndExit(util.NewInputError(errors.New("signing key is not given. Run 'wum-uc approve " +
+			"<update_loc> --as <reviewer> --signing-key <private_key.pem>'")))
+	}
+	setLogLevel()
+	logger.Debug("[approve] command called")
+	approveUpdate(args[0], approvingReviewer, approvalSigningKeyPath)
+}
+
+// This function records the approval of the given update by the given reviewer.
+func approveUpdate(updateFilePath, reviewer, signingKeyPath string) {
+	exists, err := util.IsFileExists(updateFilePath)
+	util.HandleErrorAndExit(err, "")
+	if !exists {
+		util.HandleErrorAndExit(util.NewInputError(errors.New(fmt.Sprintf("Entered update file does not exist at '%s'.",
+			updateFilePath))))
+	}
+	approval, err := util.ApproveArtifact(updateFilePath, reviewer, signingKeyPath)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while approving '%s'.", updateFilePath))
+	// Record the approval in the audit log
+	recordAuditEvent(constant.AUDIT_OPERATION_APPROVE, updateFilePath)
+	util.PrintInfo(fmt.Sprintf("'%s' (sha256: %s) approved by '%s'. The approval is written to '%s'.",
+		updateFilePath, approval.SHA256, approval.Reviewer, util.GetApprovalsPath(updateFilePath)))
+}
+
+// This function checks whether the given update artifact is approved by the number of distinct reviewers required by
+// the config before it is published. Approvals by the given author of the update are not counted. Only the signed
+// approvals of the reviewers listed in the config are counted, so publishing is refused if no reviewers are listed.
+func checkApprovals(artifactPath, author string) {
+	required := viper.GetInt(constant.APPROVALS_REQUIRED)
+	if required <= 0 {
+		return
+	}
+	reviewerKeys := viper.GetStringMapString(constant.APPROVALS_REVIEWER_KEYS)
+	if len(reviewerKeys) == 0 {
+		util.HandleErrorAndExit(util.NewInputError(errors.New(fmt.Sprintf("'%s' is required to be approved by %d "+
+			"reviewer(s), but the public keys of the reviewers are not listed under '%s' in the config file. Signed "+
+			"approvals cannot be verified without them.", artifactPath, required,
+			constant.APPROVALS_REVIEWER_KEYS))))
+	}
+	approvers, rejections, err := util.GetApprovers(artifactPath, reviewerKeys, author)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while reading the approvals of '%s'.", artifactPath))
+	for _, rejection := range rejections {
+		util.PrintInfo(rejection)
+	}
+	if len(approvers) < required {
+		util.HandleErrorAndExit(util.NewValidationError(errors.New(fmt.Sprintf("'%s' is approved by %d of the %d "+
+			"required reviewer(s). Run 'wum-uc approve %s --as <reviewer> --signing-key <private_key.pem>' and "+
+			"run 'wum-uc create --continue' again to publish it.", artifactPath, len(approvers), required,
+			artifactPath))))
+	}
+	util.PrintInfo(fmt.Sprintf("'%s' is approved by %s.", artifactPath, strings.Join(approvers, ", ")))
+}
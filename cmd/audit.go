@@ -0,0 +1,111 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/renstrom/dedent"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/wso2/update-creator-tool/constant"
+	"github.com/wso2/update-creator-tool/util"
+)
+
+// Values used to print help command.
+var (
+	auditCmdUse       = "audit"
+	auditCmdShortDesc = "Manage the audit log of the operations on updates"
+	auditCmdLongDesc  = dedent.Dedent(`
+		This command will manage the audit log which records every update creation, catalog signing
+		and update publishing operation with the sha256 checksum of the artifact. The audit log is
+		kept in the wum-uc home directory and each record contains the hash of the previous record,
+		keyed with the audit key (AUDIT.KEY, '~/.wum-uc/audit.key' by default). The last record is
+		also referred by the head of the log in '<audit_log>.head', so modified, removed or
+		reordered records can be detected with 'wum-uc audit verify'.`)
+)
+
+// auditCmd represents the audit command.
+var auditCmd = &cobra.Command{
+	Use:   auditCmdUse,
+	Short: auditCmdShortDesc,
+	Long:  auditCmdLongDesc,
+}
+
+var auditVerifyCmd = &cobra.Command{
+	Use:   "verify [audit_log]",
+	Short: "Verify the hash chain of the audit log",
+	Run:   initializeAuditVerifyCommand,
+}
+
+// This function will be called first and this will add flags to the command.
+func init() {
+	RootCmd.AddCommand(auditCmd)
+	auditCmd.AddCommand(auditVerifyCmd)
+
+	auditVerifyCmd.Flags().BoolVarP(&isDebugLogsEnabled, "debug", "d", util.EnableDebugLogs, "Enable debug logs")
+	auditVerifyCmd.Flags().BoolVarP(&isTraceLogsEnabled, "trace", "t", util.EnableTraceLogs, "Enable trace logs")
+}
+
+// This function will be called when the audit verify command is called.
+func initializeAuditVerifyCommand(cmd *cobra.Command, args []string) {
+	if len(args) > 1 {
+		util.HandleErrorAndExit(util.NewInputError(errors.New("invalid number of arguments. Run 'wum-uc audit verify " +
+			"--help' to view help")))
+	}
+	setLogLevel()
+	logger.Debug("[audit verify] command called")
+	auditLogPath := getAuditLogPath()
+	if len(args) == 1 {
+		auditLogPath = args[0]
+	}
+	key, err := util.ReadAuditKey(getAuditKeyPath(), false)
+	util.HandleErrorAndExit(util.NewInputError(err), "Error occurred while reading the audit key.")
+	verifiedRecords, err := util.VerifyAuditLog(auditLogPath, key)
+	util.HandleErrorAndExit(util.NewValidationError(err), fmt.Sprintf("'%s' is tampered. %d record(s) verified "+
+		"before the first tampered record.", auditLogPath, verifiedRecords))
+	util.PrintInfo(fmt.Sprintf("All %d record(s) of '%s' verified.", verifiedRecords, auditLogPath))
+}
+
+// This function records the given operation on the given artifact in the audit log.
+func recordAuditEvent(operation, artifactPath string) {
+	// Audit key is created when the first operation is recorded
+	key, err := util.ReadAuditKey(getAuditKeyPath(), true)
+	util.HandleErrorAndExit(err, "Error occurred while reading the audit key.")
+	err = util.AppendAuditRecord(getAuditLogPath(), key, operation, artifactPath)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while recording the %s operation of '%s' in the "+
+		"audit log.", operation, artifactPath))
+	logger.Debug(fmt.Sprintf("%s operation of %s recorded in the audit log", operation, artifactPath))
+}
+
+// This function returns the location of the audit log in the wum-uc home directory.
+func getAuditLogPath() string {
+	return filepath.Join(WUMUCHome, constant.WUMUC_AUDIT_LOG_FILE)
+}
+
+// This function returns the location of the audit key given in the config, or the default location of it in the
+// wum-uc home directory.
+func getAuditKeyPath() string {
+	if keyPath := viper.GetString(constant.AUDIT_KEY); len(keyPath) != 0 {
+		return keyPath
+	}
+	return filepath.Join(WUMUCHome, constant.WUMUC_AUDIT_KEY_FILE)
+}
@@ -0,0 +1,312 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/viper"
+	"github.com/wso2/update-creator-tool/constant"
+	"github.com/wso2/update-creator-tool/util"
+	"gopkg.in/yaml.v2"
+)
+
+// This struct represents the batch manifest which lists the updates to be created.
+type BatchManifest struct {
+	Updates []BatchUpdate `yaml:"updates"`
+}
+
+// This struct represents a single update in the batch manifest.
+type BatchUpdate struct {
+	UpdateDirectory string `yaml:"update-directory"`
+	Distribution    string `yaml:"distribution"`
+	// File which contains the answers for the prompts
+	Answers string `yaml:"answers"`
+	// Optional update-descriptor3.yaml which is copied to the update directory before packaging the update
+	UpdateDescriptor string `yaml:"update-descriptor"`
+}
+
+// This struct represents an answer file.
+type AnswerFile struct {
+	Answers []Answer `yaml:"answers"`
+}
+
+// This struct represents an answer for the prompts matching the given regular expression. An answer is only used
+// once unless it is marked to be repeated.
+type Answer struct {
+	Prompt string `yaml:"prompt"`
+	Answer string `yaml:"answer"`
+	Repeat bool   `yaml:"repeat"`
+	used   bool
+	regex  *regexp.Regexp
+}
+
+// This struct holds the result of creating a single update in the batch.
+type BatchResult struct {
+	UpdateDirectory string         `yaml:"update-directory"`
+	Distribution    string         `yaml:"distribution"`
+	Status          string         `yaml:"status"`
+	Reason          string         `yaml:"reason,omitempty"`
+	Duration        string         `yaml:"duration"`
+	Warnings        []util.Warning `yaml:"warnings,omitempty"`
+}
+
+// This function creates and validates all the updates listed in the given batch manifest sequentially. Updates are
+// created one at a time because the resume state of the update creation is shared.
+func createBatch(batchManifestPath, batchReportPath string) {
+	setLogLevel()
+	logger.Debug(fmt.Sprintf("Creating updates listed in %s", batchManifestPath))
+	batchManifest := BatchManifest{}
+	data, err := ioutil.ReadFile(batchManifestPath)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while reading '%s'.", batchManifestPath))
+	err = yaml.Unmarshal(data, &batchManifest)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while parsing '%s'.", batchManifestPath))
+	if len(batchManifest.Updates) == 0 {
+		util.HandleErrorAndExit(errors.New(fmt.Sprintf("no updates found in '%s'", batchManifestPath)))
+	}
+	// Relative paths in the manifest are resolved against the directory of the manifest
+	manifestDirectory := filepath.Dir(batchManifestPath)
+
+	var results []BatchResult
+	failed := 0
+	for index, batchUpdate := range batchManifest.Updates {
+		batchUpdate = resolveBatchUpdatePaths(batchUpdate, manifestDirectory)
+		util.PrintInfo(fmt.Sprintf("[%d/%d] Creating the update in '%s'", index+1, len(batchManifest.Updates),
+			batchUpdate.UpdateDirectory))
+		startTime := time.Now()
+		warnings, err := createBatchUpdate(&batchUpdate)
+		result := BatchResult{
+			UpdateDirectory: batchUpdate.UpdateDirectory,
+			Distribution:    batchUpdate.Distribution,
+			Status:          constant.BATCH_STATUS_PASSED,
+			Duration:        time.Since(startTime).Round(time.Second).String(),
+			Warnings:        warnings,
+		}
+		if err != nil {
+			failed++
+			result.Status = constant.BATCH_STATUS_FAILED
+			result.Reason = err.Error()
+			util.PrintError(fmt.Sprintf("Creating the update in '%s' failed: %v", batchUpdate.UpdateDirectory, err))
+			// Clean the resume state so that the next update can be created from scratch
+			util.CleanUpDirectory(constant.TEMP_DIR)
+			util.CleanUpFile(filepath.Join(WUMUCHome, constant.WUMUC_RESUME_FILE))
+		}
+		results = append(results, result)
+	}
+	printBatchResults(results)
+	if len(batchReportPath) != 0 {
+		data, err := yaml.Marshal(results)
+		util.HandleErrorAndExit(err, "Error occurred while marshalling the batch report.")
+		err = ioutil.WriteFile(batchReportPath, data, 0644)
+		util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while writing the batch report to '%s'.",
+			batchReportPath))
+		util.PrintInfo(fmt.Sprintf("Batch report written to '%s'.", batchReportPath))
+	}
+	if failed != 0 {
+		util.HandleErrorAndExit(errors.New(fmt.Sprintf("%d of %d update(s) failed", failed, len(results))))
+	}
+}
+
+// This function resolves the relative paths of the given batch update against the given directory.
+func resolveBatchUpdatePaths(batchUpdate BatchUpdate, directory string) BatchUpdate {
+	resolve := func(location string) string {
+		if len(location) == 0 || filepath.IsAbs(location) {
+			return location
+		}
+		return filepath.Join(directory, location)
+	}
+	batchUpdate.UpdateDirectory = resolve(batchUpdate.UpdateDirectory)
+	batchUpdate.Distribution = resolve(batchUpdate.Distribution)
+	batchUpdate.Answers = resolve(batchUpdate.Answers)
+	batchUpdate.UpdateDescriptor = resolve(batchUpdate.UpdateDescriptor)
+	return batchUpdate
+}
+
+// This function creates a single update by running 'wum-uc create' and 'wum-uc create --continue' in JSON IO mode
+// and answering the prompts using the answer file. Warnings raised by both the commands are returned.
+func createBatchUpdate(batchUpdate *BatchUpdate) ([]util.Warning, error) {
+	answerFile, err := loadAnswerFile(batchUpdate.Answers)
+	if err != nil {
+		return nil, err
+	}
+	warnings, err := runCreateCommand(answerFile, "create", batchUpdate.UpdateDirectory, batchUpdate.Distribution)
+	if err != nil {
+		return warnings, err
+	}
+	if len(batchUpdate.UpdateDescriptor) != 0 {
+		err = util.CopyFile(batchUpdate.UpdateDescriptor, filepath.Join(batchUpdate.UpdateDirectory,
+			constant.UPDATE_DESCRIPTOR_V3_FILE))
+		if err != nil {
+			return warnings, err
+		}
+	}
+	continueWarnings, err := runCreateCommand(answerFile, "create", "--continue")
+	return append(warnings, continueWarnings...), err
+}
+
+// This function loads the answer file at the given location. An empty answer file is returned if the location is
+// empty.
+func loadAnswerFile(answerFilePath string) (*AnswerFile, error) {
+	answerFile := AnswerFile{}
+	if len(answerFilePath) == 0 {
+		return &answerFile, nil
+	}
+	data, err := ioutil.ReadFile(answerFilePath)
+	if err != nil {
+		return nil, err
+	}
+	if err = yaml.Unmarshal(data, &answerFile); err != nil {
+		return nil, errors.New(fmt.Sprintf("error occurred while parsing '%s': %v", answerFilePath, err))
+	}
+	for i := range answerFile.Answers {
+		answerFile.Answers[i].regex, err = regexp.Compile(answerFile.Answers[i].Prompt)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("invalid prompt '%s' in '%s': %v", answerFile.Answers[i].Prompt,
+				answerFilePath, err))
+		}
+	}
+	return &answerFile, nil
+}
+
+// This function returns the answer for the given prompt. The first unused answer matching the prompt is used.
+func (answerFile *AnswerFile) getAnswer(prompt string) (string, bool) {
+	for i := range answerFile.Answers {
+		answer := &answerFile.Answers[i]
+		if answer.used || !answer.regex.MatchString(prompt) {
+			continue
+		}
+		answer.used = !answer.Repeat
+		return answer.Answer, true
+	}
+	return "", false
+}
+
+// This function runs wum-uc with the given arguments in JSON IO mode and answers the prompts using the given answer
+// file. The error messages printed by the command are returned as the error if the command fails.
+func runCreateCommand(answerFile *AnswerFile, args ...string) ([]util.Warning, error) {
+	executablePath, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	args = append(args, "--json-io")
+	if viper.GetBool(constant.STRICT_MODE) {
+		args = append(args, "--strict")
+	}
+	logger.Debug(fmt.Sprintf("Running %s %s", executablePath, strings.Join(args, " ")))
+	command := exec.Command(executablePath, args...)
+	command.Stderr = os.Stderr
+	stdin, err := command.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := command.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err = command.Start(); err != nil {
+		return nil, err
+	}
+	lastError, warnings, err := handleCommandOutput(answerFile, stdout, stdin)
+	stdin.Close()
+	if err != nil {
+		command.Process.Kill()
+		command.Wait()
+		return warnings, err
+	}
+	if err = command.Wait(); err != nil {
+		if len(lastError) != 0 {
+			return warnings, errors.New(lastError)
+		}
+		return warnings, err
+	}
+	return warnings, nil
+}
+
+// This function reads the JSON messages printed by the command and answers the prompts. The last error message
+// printed by the command is returned along with the warnings in the warning summary of the command.
+func handleCommandOutput(answerFile *AnswerFile, stdout io.Reader, stdin io.Writer) (string, []util.Warning, error) {
+	lastError := ""
+	var warnings []util.Warning
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		message := util.JSONIOMessage{}
+		if err := json.Unmarshal(scanner.Bytes(), &message); err != nil {
+			// Not a JSON IO message (eg: logs)
+			logger.Debug(scanner.Text())
+			continue
+		}
+		switch message.Type {
+		case constant.JSON_IO_PROMPT, constant.JSON_IO_PASSWORD:
+			answer, found := answerFile.getAnswer(message.Message)
+			if !found {
+				return lastError, warnings, errors.New(fmt.Sprintf("no answer found for the prompt '%s'",
+					message.Message))
+			}
+			logger.Debug(fmt.Sprintf("Answering '%s'", message.Message))
+			data, err := json.Marshal(util.JSONIOAnswer{ID: message.ID, Answer: answer})
+			if err != nil {
+				return lastError, warnings, err
+			}
+			if _, err = stdin.Write(append(data, '\n')); err != nil {
+				return lastError, warnings, err
+			}
+		case constant.JSON_IO_ERROR:
+			lastError = message.Message
+			logger.Debug(message.Message)
+		case constant.JSON_IO_WARNING_SUMMARY:
+			warnings = append(warnings, message.Warnings...)
+			logger.Debug(message.Message)
+		default:
+			logger.Debug(message.Message)
+		}
+	}
+	return lastError, warnings, scanner.Err()
+}
+
+// This function prints the consolidated results of the batch.
+func printBatchResults(results []BatchResult) {
+	util.PrintInBold("\nBatch summary:\n")
+	if util.IsJSONIOEnabled() {
+		for _, result := range results {
+			util.PrintInfo(fmt.Sprintf("[%s] %s %s", result.Status, result.UpdateDirectory, result.Reason))
+		}
+		return
+	}
+	resultsTable := tablewriter.NewWriter(os.Stdout)
+	resultsTable.SetAlignment(tablewriter.ALIGN_LEFT)
+	resultsTable.SetHeader([]string{"Update Directory", "Distribution", "Result", "Duration", "Reason"})
+	for _, result := range results {
+		resultsTable.Append([]string{result.UpdateDirectory, filepath.Base(result.Distribution), result.Status,
+			result.Duration, result.Reason})
+	}
+	resultsTable.Render()
+}
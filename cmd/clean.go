@@ -0,0 +1,152 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/renstrom/dedent"
+	"github.com/spf13/cobra"
+	"github.com/wso2/update-creator-tool/constant"
+	"github.com/wso2/update-creator-tool/util"
+)
+
+// Values used to print help command.
+var (
+	cleanCmdUse       = "clean"
+	cleanCmdShortDesc = "Remove stale caches, temporary directories, logs and workspaces"
+	cleanCmdLongDesc  = dedent.Dedent(`
+		This command will remove the distributions downloaded or re-rooted by wum-uc, the temporary
+		directories left behind by interrupted runs, the log files and the abandoned workspaces which
+		are not modified for the number of days given with '--older-than' (7 by default), and reports
+		the reclaimed space. Use '--cache', '--temp', '--logs' or '--workspaces' to only remove some of
+		them, and '--dry-run' to only list what would be removed.`)
+)
+
+// cleanCmd represents the clean command.
+var cleanCmd = &cobra.Command{
+	Use:   cleanCmdUse,
+	Short: cleanCmdShortDesc,
+	Long:  cleanCmdLongDesc,
+	Run:   initializeCleanCommand,
+}
+
+var isCacheCleaned bool
+var isTempCleaned bool
+var isLogsCleaned bool
+var isWorkspacesCleaned bool
+var cleanOlderThanDays int
+var isCleanDryRun bool
+
+// This function will be called first and this will add flags to the command.
+func init() {
+	RootCmd.AddCommand(cleanCmd)
+
+	cleanCmd.Flags().BoolVarP(&isDebugLogsEnabled, "debug", "d", util.EnableDebugLogs, "Enable debug logs")
+	cleanCmd.Flags().BoolVarP(&isTraceLogsEnabled, "trace", "t", util.EnableTraceLogs, "Enable trace logs")
+	cleanCmd.Flags().BoolVar(&isCacheCleaned, "cache", false, "Remove the downloaded and re-rooted distributions")
+	cleanCmd.Flags().BoolVar(&isTempCleaned, "temp", false, "Remove the temporary directories of previous runs")
+	cleanCmd.Flags().BoolVar(&isLogsCleaned, "logs", false, "Remove the log files")
+	cleanCmd.Flags().BoolVar(&isWorkspacesCleaned, "workspaces", false, "Remove the abandoned workspaces")
+	cleanCmd.Flags().IntVar(&cleanOlderThanDays, "older-than", util.CleanOlderThanDays, "Only remove the files "+
+		"which are not modified for the given number of days")
+	cleanCmd.Flags().BoolVar(&isCleanDryRun, "dry-run", false, "List the files which would be removed without "+
+		"removing them")
+}
+
+// This function will be called when the clean command is called.
+func initializeCleanCommand(cmd *cobra.Command, args []string) {
+	if len(args) != 0 {
+		util.HandleErrorAndExit(util.NewInputError(errors.New("invalid number of arguments. Run 'wum-uc clean " +
+			"--help' to view help")))
+	}
+	if cleanOlderThanDays < 0 {
+		util.HandleErrorAndExit(util.NewInputError(errors.New(fmt.Sprintf("'--older-than' should not be "+
+			"negative, found %d", cleanOlderThanDays))))
+	}
+	setLogLevel()
+	logger.Debug("[clean] command called")
+	// Everything is cleaned if no category is given
+	if !isCacheCleaned && !isTempCleaned && !isLogsCleaned && !isWorkspacesCleaned {
+		isCacheCleaned, isTempCleaned, isLogsCleaned, isWorkspacesCleaned = true, true, true, true
+	}
+	modifiedBefore := time.Now().Add(-time.Duration(cleanOlderThanDays) * constant.HOURS_PER_DAY * time.Hour)
+	cleanStaleEntries(findStaleEntries(modifiedBefore))
+}
+
+// This function finds the stale entries of the selected categories which are not modified after the given time.
+func findStaleEntries(modifiedBefore time.Time) []util.StaleEntry {
+	var staleEntries []util.StaleEntry
+	if isCacheCleaned {
+		for _, cacheDirectory := range []string{constant.WUMUC_ARTIFACTS_DIRECTORY,
+			constant.WUMUC_REROOTED_DISTRIBUTIONS_DIRECTORY} {
+			entries, err := util.FindStaleEntries(constant.CLEAN_CACHE, filepath.Join(WUMUCHome, cacheDirectory), "",
+				modifiedBefore)
+			util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while reading '%s'.", cacheDirectory))
+			staleEntries = append(staleEntries, entries...)
+		}
+	}
+	if isTempCleaned {
+		entries, err := util.FindStaleEntries(constant.CLEAN_TEMP, os.TempDir(), constant.TEMP_DIRECTORY_PREFIX,
+			modifiedBefore)
+		util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while reading '%s'.", os.TempDir()))
+		staleEntries = append(staleEntries, entries...)
+	}
+	if isLogsCleaned {
+		// Log file of the current session is kept
+		logsDirectory := filepath.Join(WUMUCHome, constant.WUMUC_LOGS_DIRECTORY)
+		entries, err := util.FindStaleEntries(constant.CLEAN_LOGS, logsDirectory, constant.LOG_FILE_PREFIX,
+			modifiedBefore, util.GetLogFilePath())
+		util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while reading '%s'.", logsDirectory))
+		staleEntries = append(staleEntries, entries...)
+	}
+	if isWorkspacesCleaned {
+		entries, err := util.FindStaleWorkspaces(WUMUCHome, modifiedBefore)
+		util.HandleErrorAndExit(err, "Error occurred while reading the workspaces.")
+		staleEntries = append(staleEntries, entries...)
+	}
+	return staleEntries
+}
+
+// This function removes the given stale entries and reports the reclaimed space. Nothing is removed in a dry run.
+func cleanStaleEntries(staleEntries []util.StaleEntry) {
+	if len(staleEntries) == 0 {
+		util.PrintInfo(fmt.Sprintf("Nothing to clean. No files are older than %d day(s).", cleanOlderThanDays))
+		return
+	}
+	var totalSize int64
+	for _, staleEntry := range staleEntries {
+		totalSize += staleEntry.Size
+		util.PrintInfo(fmt.Sprintf("[%s] %s (%s, last modified on %s)", staleEntry.Category, staleEntry.Path,
+			util.FormatSize(staleEntry.Size), staleEntry.LastModified.Format("2006-01-02")))
+	}
+	if isCleanDryRun {
+		util.PrintInfo(fmt.Sprintf("%d item(s) would be removed, reclaiming %s.", len(staleEntries),
+			util.FormatSize(totalSize)))
+		return
+	}
+	reclaimedSpace, err := util.RemoveStaleEntries(staleEntries)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while cleaning. Reclaimed %s before the error.",
+		util.FormatSize(reclaimedSpace)))
+	util.PrintInfo(fmt.Sprintf("%d item(s) removed, reclaimed %s.", len(staleEntries), util.FormatSize(reclaimedSpace)))
+}
@@ -0,0 +1,183 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/renstrom/dedent"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/wso2/update-creator-tool/constant"
+	"github.com/wso2/update-creator-tool/util"
+)
+
+// Values used to print help command.
+var (
+	configCmdUse       = "config"
+	configCmdShortDesc = "Export and import the configuration of wum-uc"
+	configCmdLongDesc  = dedent.Dedent(`
+		This command will export the configuration of wum-uc to an encrypted bundle and import it,
+		so that new team members and build agents can be provisioned identically. The bundle
+		contains the config file and the files referred by it (OPA policies and public keys). Access
+		tokens are not exported, and private keys (eg: signing keys) are kept as references which
+		should be provisioned separately. The bundle is signed with the GPG key of the exporter, and
+		it is imported only if it is signed with one of the keys given with '--trusted-key <file>',
+		as the config decides the validators which are executed and the keys which are trusted.`)
+)
+
+// configCmd represents the config command.
+var configCmd = &cobra.Command{
+	Use:   configCmdUse,
+	Short: configCmdShortDesc,
+	Long:  configCmdLongDesc,
+}
+
+var configExportCmd = &cobra.Command{
+	Use:   "export <bundle>",
+	Short: "Export the configuration to an encrypted bundle",
+	Run:   initializeConfigExportCommand,
+}
+
+var configImportCmd = &cobra.Command{
+	Use:   "import <bundle>",
+	Short: "Import the configuration from an encrypted bundle",
+	Run:   initializeConfigImportCommand,
+}
+
+var configBundleRecipients []string
+var configBundleSigningKeyPath string
+var configBundleDecryptionKeyPath string
+var configBundleTrustedKeyPath string
+
+// This function will be called first and this will add flags to the command.
+func init() {
+	RootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configExportCmd, configImportCmd)
+
+	configExportCmd.Flags().StringSliceVar(&configBundleRecipients, "encrypt-for", []string{}, "Encrypt the "+
+		"bundle for the given recipients (names in "+constant.ENCRYPTION_RECIPIENTS+")")
+	configExportCmd.Flags().StringVar(&configBundleSigningKeyPath, "signing-key", "", "Exported GPG secret key "+
+		"used to sign the bundle, instead of "+constant.GPG_KEYRING)
+	configImportCmd.Flags().StringVar(&configBundleDecryptionKeyPath, "decryption-key", "", "OpenPGP secret "+
+		"key used to decrypt the bundle")
+	configImportCmd.Flags().StringVar(&configBundleTrustedKeyPath, "trusted-key", "", "GPG public keys of the "+
+		"exporters whose bundles are trusted")
+}
+
+// This function will be called when the config export command is called.
+func initializeConfigExportCommand(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		util.HandleErrorAndExit(util.NewInputError(errors.New("invalid number of arguments. Run 'wum-uc config " +
+			"export --help' to view help")))
+	}
+	logger.Debug("[config export] command called")
+	signingKeyPath := configBundleSigningKeyPath
+	if len(signingKeyPath) == 0 {
+		signingKeyPath = viper.GetString(constant.GPG_KEYRING)
+	}
+	if len(signingKeyPath) == 0 {
+		util.HandleErrorAndExit(util.NewInputError(errors.New(fmt.Sprintf("GPG key to sign the bundle is not "+
+			"given. Use '--signing-key <file>' or set '%s' in the config", constant.GPG_KEYRING))))
+	}
+	exportConfigBundle(args[0], configBundleRecipients, signingKeyPath, viper.GetString(constant.GPG_KEY_ID))
+}
+
+// This function will be called when the config import command is called.
+func initializeConfigImportCommand(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		util.HandleErrorAndExit(util.NewInputError(errors.New("invalid number of arguments. Run 'wum-uc config " +
+			"import --help' to view help")))
+	}
+	logger.Debug("[config import] command called")
+	if len(configBundleTrustedKeyPath) == 0 {
+		util.HandleErrorAndExit(util.NewInputError(errors.New("GPG public keys of the trusted exporters are not " +
+			"given. Use '--trusted-key <file>' to give them")))
+	}
+	importConfigBundle(args[0], configBundleDecryptionKeyPath, configBundleTrustedKeyPath)
+}
+
+// This function exports the config file in use to a bundle encrypted for the given recipients and signed with the GPG
+// key with the given id in the given keyring.
+func exportConfigBundle(bundlePath string, recipientNames []string, keyringPath, keyID string) {
+	if len(recipientNames) == 0 {
+		util.HandleErrorAndExit(errors.New("recipients of the bundle not given. Use '--encrypt-for' to give " +
+			"the recipients"))
+	}
+	if !strings.HasSuffix(bundlePath, constant.ENCRYPTED_FILE_EXTENSION) {
+		bundlePath += constant.ENCRYPTED_FILE_EXTENSION
+	}
+	configPath := viper.ConfigFileUsed()
+	if len(configPath) == 0 {
+		configPath = filepath.Join(WUMUCHome, constant.WUMUC_CONFIG_FILE)
+	}
+	recipients := getEncryptionRecipients(recipientNames)
+	signer := readGPGSigningKey(keyringPath, keyID)
+
+	tempDirectory, err := ioutil.TempDir("", "wum-uc")
+	util.HandleErrorAndExit(err, "Error occurred while creating a temporary directory")
+	defer util.CleanUpDirectory(tempDirectory)
+	bundleZipPath := filepath.Join(tempDirectory, strings.TrimSuffix(filepath.Base(bundlePath),
+		constant.ENCRYPTED_FILE_EXTENSION))
+	bundledFiles, err := util.ExportConfigBundle(configPath, bundleZipPath)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while exporting '%s'.", configPath))
+	for _, bundledFile := range bundledFiles {
+		logger.Debug(fmt.Sprintf("%s added to the bundle", bundledFile))
+	}
+	err = util.EncryptFile(bundleZipPath, bundlePath, recipients, signer)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while encrypting '%s'.", bundlePath))
+	util.PrintInfo(fmt.Sprintf("'%s' and %d file(s) referred by it exported to '%s' for %s, signed with the GPG "+
+		"key %s.", configPath, len(bundledFiles), bundlePath, strings.Join(recipientNames, ", "),
+		util.GetGPGKeyDescription(signer)))
+}
+
+// This function imports the given encrypted bundle to the config file of the wum-uc home, if it is signed with one of
+// the trusted GPG keys at the given location. The existing config file is backed up before it is replaced.
+func importConfigBundle(bundlePath, privateKeyPath, trustedKeyPath string) {
+	exists, err := util.IsFileExists(bundlePath)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while reading '%s'.", bundlePath))
+	if !exists {
+		util.HandleErrorAndExit(errors.New(fmt.Sprintf("bundle '%s' does not exist", bundlePath)))
+	}
+	trustedKeys, err := util.ReadGPGKeyring(trustedKeyPath)
+	util.HandleErrorAndExit(util.NewInputError(err), "Error occurred while reading the trusted keys.")
+	bundleZipPath := decryptToTemporaryDirectory(bundlePath, privateKeyPath, trustedKeys)
+	defer util.CleanUpDirectory(filepath.Dir(bundleZipPath))
+
+	configPath := filepath.Join(WUMUCHome, constant.WUMUC_CONFIG_FILE)
+	exists, err = util.IsFileExists(configPath)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while reading '%s'.", configPath))
+	if exists {
+		err = util.CopyFile(configPath, configPath+constant.BACKUP_FILE_EXTENSION)
+		util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while backing up '%s'.", configPath))
+		util.PrintInfo(fmt.Sprintf("Existing config backed up to '%s'.", configPath+constant.BACKUP_FILE_EXTENSION))
+	}
+	filesDirectory := filepath.Join(WUMUCHome, constant.WUMUC_CONFIG_BUNDLE_FILES_DIRECTORY)
+	err = os.RemoveAll(filesDirectory)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while removing '%s'.", filesDirectory))
+	err = util.ImportConfigBundle(bundleZipPath, configPath, filesDirectory)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while importing '%s'.", bundlePath))
+	util.PrintInfo(fmt.Sprintf("'%s' imported to '%s'.", bundlePath, configPath))
+}
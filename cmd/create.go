@@ -21,6 +21,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"io/ioutil"
 	"os"
 	"os/signal"
@@ -33,14 +34,13 @@ import (
 	"bytes"
 	"github.com/olekukonko/tablewriter"
 	"github.com/renstrom/dedent"
+	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/wso2/update-creator-tool/constant"
 	"github.com/wso2/update-creator-tool/util"
-	"golang.org/x/crypto/ssh/terminal"
 	"gopkg.in/yaml.v2"
 	"os/exec"
-	"regexp"
 	"syscall"
 	time2 "time"
 )
@@ -50,7 +50,11 @@ type data struct {
 	name         string
 	isDir        bool
 	relativePath string
-	md5          string
+	absolutePath string
+	size         int64
+	// Checksums are calculated only when they are needed to compare the file with a file in the distribution
+	crc32 *uint32
+	hash  string
 }
 
 // This struct used to store directory structure of the distribution.
@@ -60,19 +64,43 @@ type node struct {
 	relativeLocation string
 	parent           *node
 	childNodes       map[string]*node
-	md5Hash          string
+	hash             string
+	// Size and CRC32 checksum of the file which are read from the zip entry. These are used to skip calculating the
+	// hash of the update file when it is obvious that the files are different.
+	size     int64
+	crc32    uint32
+	hasCRC32 bool
+	// This is true if the node is a nested archive (war, car, etc) which was read when building the tree. Content
+	// of the archive will be in the childNodes.
+	isArchive bool
 }
 
 // This struct is used for resuming the update creation using `wum-uc create -- continue`
 type ResumeFile struct {
-	ExplodedUpdateDirectoryPath string `yaml:"exploded-update-directory-path"`
-	Developer                   string `yaml:"developer"`
-	UpdateName                  string `yaml:"update-name"`
-	ResourceDirectoryPath       string `yaml:"resource-directory-path"`
-	DistributionPath            string `yaml:"distribution-path"`
-	PlatformName                string `yaml:"platform-name"`
-	UpdateNumber                string `yaml:"update-number"`
-	IsUpdateZipCreated          bool   `yaml:"is-update-zip-created"`
+	ExplodedUpdateDirectoryPath string   `yaml:"exploded-update-directory-path"`
+	Developer                   string   `yaml:"developer"`
+	UpdateName                  string   `yaml:"update-name"`
+	ResourceDirectoryPath       string   `yaml:"resource-directory-path"`
+	UpdateDirectoryPath         string   `yaml:"update-directory-path"`
+	IsReadOnlyInputs            bool     `yaml:"is-read-only-inputs"`
+	DistributionPath            string   `yaml:"distribution-path"`
+	PlatformName                string   `yaml:"platform-name"`
+	PlatformVersion             string   `yaml:"platform-version"`
+	UpdateNumber                string   `yaml:"update-number"`
+	IsUpdateZipCreated          bool     `yaml:"is-update-zip-created"`
+	IsBinaryDeltaEnabled        bool     `yaml:"is-binary-delta-enabled"`
+	IsScanEnabled               bool     `yaml:"is-scan-enabled"`
+	Format                      string   `yaml:"format"`
+	EncryptionRecipients        []string `yaml:"encryption-recipients"`
+	BuildCommand                []string `yaml:"build-command"`
+	BuildStartedOn              string   `yaml:"build-started-on"`
+	PreviewExpires              string   `yaml:"preview-expires"`
+	PayloadDirectory            string   `yaml:"payload-directory"`
+	ExecutableFiles             []string `yaml:"executable-files"`
+	// Distributions of the products of a platform-wide update given with '--dist-list'
+	ListedDistributions []util.ListedDistribution `yaml:"listed-distributions,omitempty"`
+	// Files of the update directory which are not copied to the update
+	SkippedFiles []util.SkippedFile `yaml:"skipped-files"`
 }
 
 // This is used to create a new node which will initialize the childNodes map.
@@ -89,7 +117,7 @@ var (
 	createCmdLongDesc  = dedent.Dedent(`
 		This command will create a new update zip file from the files in the
 		given directory. To generate the directory structure, it requires the
-		product distribution zip file path as input.`)
+		product distribution (zip, tar.gz or directory) path as input.`)
 )
 
 // createCmd represents the create command.
@@ -101,6 +129,47 @@ var createCmd = &cobra.Command{
 }
 
 var isContinueEnabled = false
+var isResumeEnabled = false
+var batchManifestPath string
+var batchReportPath string
+var isUpdateFormatGiven = false
+var encryptionRecipients []string
+var isAutoModeEnabled = false
+var isOwnershipRecorded = false
+var isAutoNumberEnabled = false
+var distributionListPath string
+
+// Distributions of the products of a platform-wide update, read from the distribution list given with '--dist-list'.
+// Files are matched against the first distribution and then checked against each distribution.
+var listedDistributions []util.ListedDistribution
+var appliedUpdatesDirectory string
+
+// Owners and groups of the files copied to the update. These are recorded in the update-descriptor3.yaml if
+// '--record-ownership' is given.
+var recordedFileOwnerships []util.FileOwnership
+
+// Root level directories and files of the update directory of which the files are matched, and whether the partial
+// update descriptor has been saved to the temp directory. These are used to resume an aborted session.
+var matchedEntries []string
+var isPartialUpdateDescriptorSaved = false
+
+// Decisions which were taken without prompting the user in the auto mode. These are printed as a summary after
+// matching the files.
+var automaticDecisions []string
+
+// Files of the update directory which are not copied to the update. These are written to the skipped files manifest
+// next to the update zip.
+var skippedFiles []util.SkippedFile
+
+// Paths declared as non-updatable in the ignore manifest of the distribution
+var distributionIgnoredPaths []string
+
+// Size of the files copied to the payload of the update and whether it has exceeded the size budget of the platform
+var copiedPayloadSize uint64
+var isPayloadSizeBudgetExceeded = false
+
+// Template store synced from the Git repository of the team (see 'wum-uc templates sync')
+var templateStore = &util.TemplateStore{}
 
 // This function will be called first and this will add flags to the command.
 func init() {
@@ -109,19 +178,122 @@ func init() {
 	createCmd.Flags().BoolVarP(&isDebugLogsEnabled, "debug", "d", util.EnableDebugLogs, "Enable debug logs")
 	createCmd.Flags().BoolVarP(&isTraceLogsEnabled, "trace", "t", util.EnableTraceLogs, "Enable trace logs")
 	createCmd.Flags().BoolVar(&isContinueEnabled, "continue", false, "Continue resumed update creation")
+	createCmd.Flags().BoolVar(&isResumeEnabled, "resume", false, "Resume an aborted session from the files "+
+		"already matched in the temp directory")
+	createCmd.Flags().BoolVar(&isAutoModeEnabled, "auto", false, "Resolve single matches and MD5 identical "+
+		"files silently and only prompt when there is no match or multiple matches")
+	createCmd.Flags().BoolVar(&isOwnershipRecorded, "record-ownership", false, "Record the owners and the groups "+
+		"of the files in the update directory in the update-descriptor3.yaml")
+	createCmd.Flags().BoolVar(&isAutoNumberEnabled, "auto-number", false, "Use the update number reserved with "+
+		"'wum-uc reserve-number' for the platform version of the update")
+	createCmd.Flags().StringVar(&appliedUpdatesDirectory, "applied-updates", "", "Apply the released update zips "+
+		"in the given directory to the distribution before matching, so that the update is incremental over them")
+	createCmd.Flags().StringVar(&batchManifestPath, "batch", "", "Create all the updates listed in the given "+
+		"batch manifest")
+	createCmd.Flags().StringVar(&batchReportPath, "batch-report", "", "Write the results of the batch to the "+
+		"given file")
+	createCmd.Flags().StringVar(&workspaceName, "workspace", "", "Create the update of the given workspace and "+
+		"record the answers in it")
+	createCmd.Flags().StringVar(&distributionListPath, "dist-list", "", "Create a platform-wide update for the "+
+		"products listed with their distributions in the given file, instead of a single distribution")
 
 	createCmd.Flags().BoolP("md5", "m", util.CheckMd5Disabled, "Disable checking MD5 sum")
 	viper.BindPFlag(constant.CHECK_MD5_DISABLED, createCmd.Flags().Lookup("md5"))
+	createCmd.Flags().String("hash", util.HashAlgorithm, "Hash algorithm used to compare the files of the update "+
+		"with the files of the distribution, 'md5' or 'sha256'")
+	viper.BindPFlag(constant.HASH_ALGORITHM, createCmd.Flags().Lookup("hash"))
+
+	createCmd.Flags().String("check-update-number", util.UpdateNumberUniquenessCheck, "Check whether the "+
+		"update number is already used, against the local 'ledger' or the WUM 'api'")
+	viper.BindPFlag(constant.UPDATE_NUMBER_UNIQUENESS_CHECK, createCmd.Flags().Lookup("check-update-number"))
+
+	createCmd.Flags().Bool("nested-archives", util.DescendNestedArchives, "Descend into nested archives "+
+		"(wars, cars, etc) in the distribution when matching files")
+	viper.BindPFlag(constant.NESTED_ARCHIVES_DESCEND, createCmd.Flags().Lookup("nested-archives"))
+
+	createCmd.Flags().Int("workers", util.DistributionWorkers, "Number of workers used to hash the files of the "+
+		"distribution, a worker per CPU if not positive")
+	viper.BindPFlag(constant.DISTRIBUTION_WORKERS, createCmd.Flags().Lookup("workers"))
+	createCmd.Flags().Int64("max-memory", int64(util.DistributionMaxMemory), "Maximum memory (in MB) used to "+
+		"buffer a nested archive of the distribution, larger archives are buffered in temporary files")
+	viper.BindPFlag(constant.DISTRIBUTION_MAX_MEMORY, createCmd.Flags().Lookup("max-memory"))
+
+	createCmd.Flags().Bool("events", util.EventsConsole, "Print the events of the update creation to the console")
+	viper.BindPFlag(constant.EVENTS_CONSOLE, createCmd.Flags().Lookup("events"))
+	createCmd.Flags().String("events-file", util.EventsFile, "Append the events of the update creation to the "+
+		"given file as JSON lines")
+	viper.BindPFlag(constant.EVENTS_FILE, createCmd.Flags().Lookup("events-file"))
+
+	createCmd.Flags().Bool("binary-delta", util.BinaryDeltaEnabled, "Store large modified files as binary deltas "+
+		"against the distribution")
+	viper.BindPFlag(constant.BINARY_DELTA_ENABLED, createCmd.Flags().Lookup("binary-delta"))
+
+	createCmd.Flags().Bool("scan", util.ScanEnabled, "Scan the payload of the update for malware before zipping")
+	viper.BindPFlag(constant.SCAN_ENABLED, createCmd.Flags().Lookup("scan"))
+
+	createCmd.Flags().Bool("read-only-inputs", util.ReadOnlyInputs, "Never write into the update directory or the "+
+		"distribution, the files generated for the update are written to the wum-uc home instead")
+	viper.BindPFlag(constant.READ_ONLY_INPUTS, createCmd.Flags().Lookup("read-only-inputs"))
+
+	createCmd.Flags().String("format", util.UpdateFormat, "Format of the update archive, 'zip' or 'tar.zst'")
+	viper.BindPFlag(constant.UPDATE_FORMAT, createCmd.Flags().Lookup("format"))
+
+	createCmd.Flags().StringSliceVar(&encryptionRecipients, "encrypt-for", []string{}, "Encrypt the update for "+
+		"the given recipients (names in ENCRYPTION.RECIPIENTS)")
+
+	createCmd.Flags().String("against-catalog", util.UpdateCatalogLocation, "Check the update against the given "+
+		"catalog of released updates")
+	viper.BindPFlag(constant.UPDATE_CATALOG_LOCATION, createCmd.Flags().Lookup("against-catalog"))
 }
 
 // This function will be called when the create command is called.
 func initializeCreateCommand(cmd *cobra.Command, args []string) {
-
+	// Initialize the sinks which receive the events of the update creation
+	err := util.InitEventSinks(viper.GetBool(constant.EVENTS_CONSOLE), viper.GetString(constant.EVENTS_FILE),
+		viper.GetStringSlice(constant.EVENTS_WEBHOOKS))
+	util.HandleErrorAndExit(err, "Error occurred while initializing the event sinks.")
+	defer util.CloseEventSinks()
+
+	// Check whether the format of the update archive is supported
+	_, err = util.GetUpdateArchiveExtension(viper.GetString(constant.UPDATE_FORMAT))
+	util.HandleErrorAndExit(err)
+	isUpdateFormatGiven = cmd.Flags().Changed("format")
+	// Check whether the hash algorithm is supported
+	err = util.ValidateChecksumAlgorithms([]string{viper.GetString(constant.HASH_ALGORITHM)})
+	util.HandleErrorAndExit(util.NewInputError(err))
+
+	args = initializeWorkspace(args)
+
+	// Check for creating a batch of updates
+	if len(batchManifestPath) != 0 {
+		if len(args) != 0 || isContinueEnabled {
+			util.HandleErrorAndExit(util.NewInputError(errors.New("'--batch' cannot be used with arguments or " +
+				"'--continue'. Run 'wum-uc create --help' to view help")))
+		}
+		createBatch(batchManifestPath, batchReportPath)
+		return
+	}
+	if isResumeEnabled && isContinueEnabled {
+		util.HandleErrorAndExit(util.NewInputError(errors.New("'--resume' cannot be used with '--continue'. Run " +
+			"'wum-uc create --help' to view help")))
+	}
+	// Check for creating a platform-wide update for the products in the distribution list
+	if len(distributionListPath) != 0 {
+		if len(args) != 1 || isContinueEnabled {
+			util.HandleErrorAndExit(util.NewInputError(errors.New("'--dist-list' requires the update directory " +
+				"only and cannot be used with '--continue'. Run 'wum-uc create --help' to view help")))
+		}
+		listedDistributions, err = util.LoadDistributionList(distributionListPath)
+		util.HandleErrorAndExit(util.NewInputError(err), fmt.Sprintf("Error occurred while reading '%s'.",
+			distributionListPath))
+		createUpdate(args[0], listedDistributions[0].Distribution)
+		return
+	}
 	// Check for resuming the update creation or creating the update from scratch
 	if !isContinueEnabled {
 		if len(args) != 2 {
-			util.HandleErrorAndExit(errors.New("invalid number of arguments. Run 'wum-uc create --help' to " +
-				"view help"))
+			util.HandleErrorAndExit(util.NewInputError(errors.New("invalid number of arguments. Run 'wum-uc create " +
+				"--help' to view help")))
 		}
 		createUpdate(args[0], args[1])
 	} else {
@@ -136,6 +308,17 @@ func createUpdate(updateDirectoryPath, distributionPath string) {
 	setLogLevel()
 	logger.Debug("[create] command called")
 	logger.Debug("Creating the update from scratch")
+	buildStartedOn := time2.Now().UTC().Format(time2.RFC3339)
+	templateStore = loadTemplateStore()
+	// Distributions given as remote URIs are kept in the wum-uc home as they are used until the update is created
+	distributionPath = downloadArtifact(distributionPath, filepath.Join(WUMUCHome, constant.WUMUC_ARTIFACTS_DIRECTORY))
+
+	// Guard the update directory and the distribution against any modification if the inputs are read only
+	isReadOnlyInputs := viper.GetBool(constant.READ_ONLY_INPUTS)
+	if isReadOnlyInputs {
+		util.SetReadOnlyInputs(updateDirectoryPath, distributionPath)
+		defer util.SetReadOnlyInputs()
+	}
 
 	// Flow - First check whether the given locations exist and required files exist,
 	// create them if they are not available. Then start processing.
@@ -145,13 +328,16 @@ func createUpdate(updateDirectoryPath, distributionPath string) {
 	exists, err := util.IsDirectoryExists(updateDirectoryPath)
 	util.HandleErrorAndExit(err, "Error occurred while reading the update directory")
 	logger.Debug(fmt.Sprintf("Directory %s exists: %v", updateDirectoryPath, exists))
+	if !exists && isReadOnlyInputs {
+		util.HandleErrorAndExit(errors.New(fmt.Sprintf("'%s' does not exist. It is not created as the inputs "+
+			"are read only", updateDirectoryPath)))
+	}
 	if !exists {
 		// If the directory does not exists, prompt the user
 	userInputLoop:
 		for {
-			util.PrintInBold(fmt.Sprintf("'%s'does not exists. Do you want to create '%s' directory?"+
-				"[Y/n]: ", updateDirectoryPath, updateDirectoryPath))
-			preference, err := util.GetUserInput()
+			preference, err := util.PromptUser(fmt.Sprintf("'%s'does not exists. Do you want to create '%s' "+
+				"directory?[Y/n]: ", updateDirectoryPath, updateDirectoryPath))
 			util.HandleErrorAndExit(err, "Error occurred while getting input from the user.")
 			if len(preference) == 0 {
 				preference = "y"
@@ -168,15 +354,16 @@ func createUpdate(updateDirectoryPath, distributionPath string) {
 				logger.Debug(fmt.Sprintf("'%s' directory created.", updateDirectoryPath))
 				break userInputLoop
 			case constant.NO:
-				util.HandleErrorAndExit(errors.New("directory creation skipped. Please enter a valid directory"))
+				util.HandleErrorAndExit(util.NewUserAbortError(errors.New("directory creation skipped. Please " +
+					"enter a valid directory")))
 			default:
 				util.PrintError("Invalid preference. Enter Y for Yes or N for No.")
 			}
 		}
 		util.PrintInBold(fmt.Sprintf("Directory created. Please copy updated files to '%s' and rerun 'wum-uc create'", updateDirectoryPath))
-		os.Exit(1)
+		os.Exit(constant.EXIT_CODE_INPUT_ERROR)
 	}
-	updateRoot := strings.TrimSuffix(updateDirectoryPath, constant.PATH_SEPARATOR)
+	updateRoot := strings.TrimSuffix(filepath.FromSlash(updateDirectoryPath), constant.PATH_SEPARATOR)
 	logger.Debug(fmt.Sprintf("updateRoot: %s\n", updateRoot))
 	viper.Set(constant.UPDATE_ROOT, updateRoot)
 
@@ -186,16 +373,15 @@ func createUpdate(updateDirectoryPath, distributionPath string) {
 
 	//2) Process the README.txt file if it exists
 	readMeDataString := processReadMe(updateDirectoryPath, &updateDescriptorV2)
-
-	//3) Check whether the given distribution exists
-	exists, err = util.IsFileExists(distributionPath)
-	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while checking '%s'", distributionPath))
-	if !exists {
-		util.HandleErrorAndExit(errors.New(fmt.Sprintf("File does not exist at '%s'. Distribution must "+
-			"be a zip file.", distributionPath)))
+	// Use the update number reserved in WUM for the platform version instead of the given update number
+	if isAutoNumberEnabled {
+		setReservedUpdateNumber(updateDirectoryPath, &updateDescriptorV2)
 	}
-	// Checks whether the given distribution is a zip file
-	util.IsZipFile(constant.DISTRIBUTION, distributionPath)
+
+	//3) Check whether the given distribution can be read
+	err = util.CheckDistribution(distributionPath)
+	util.HandleErrorAndExit(util.NewInputError(err), fmt.Sprintf("Error occurred while reading '%s'. Distribution "+
+		"must be a zip file, a tar.gz file or a directory.", distributionPath))
 
 	//4) Set the update name
 	updateName := getUpdateName(&updateDescriptorV2, constant.UPDATE_NAME_PREFIX)
@@ -204,13 +390,29 @@ func createUpdate(updateDirectoryPath, distributionPath string) {
 	//5) Validate UpdateDescriptorV2 for basic details of update-descriptor.yaml
 	err = util.ValidateBasicDetailsOfUpdateDescriptorV2(&updateDescriptorV2)
 	util.HandleErrorAndExit(err, fmt.Sprintf("'%s' format is incorrect.", constant.UPDATE_DESCRIPTOR_V2_FILE))
+	// Check whether the update number has already been used for the platform version before doing any processing
+	absUpdateDirectoryPath, err := filepath.Abs(updateDirectoryPath)
+	if err != nil {
+		absUpdateDirectoryPath = updateDirectoryPath
+	}
+	isOnline, err := util.RunOnlineStep(constant.ONLINE_SERVICE_WUM, func() error {
+		return util.CheckUpdateNumberUniqueness(updateDescriptorV2.UpdateNumber, updateDescriptorV2.PlatformVersion,
+			absUpdateDirectoryPath, WUMUCHome)
+	})
+	util.HandleErrorAndExit(err, "Error occurred while checking the uniqueness of the update number.")
+	if !isOnline {
+		util.PrintWarning(fmt.Sprintf("Uniqueness of the update number '%s' is not checked. Please make sure that "+
+			"it is not used for platform version '%s'.", updateDescriptorV2.UpdateNumber,
+			updateDescriptorV2.PlatformVersion))
+	}
+	// Files generated for the update are written to the resource directory
+	resourceDirectoryPath := getResourceDirectoryPath(updateDirectoryPath, updateName)
+	logger.Debug(fmt.Sprintf("resourceDirectoryPath: %s", resourceDirectoryPath))
 
 	//6) Download mandatory files
-	// Download the LICENSE.txt
-	downloadFile(updateDirectoryPath, constant.LICENSE_URL, constant.LICENSE_DOWNLOAD_URL, constant.LICENSE_FILE)
-	// Download the NOT_A_CONTRIBUTION.txt
-	downloadFile(updateDirectoryPath, constant.NOT_A_CONTRIBUTION_URL, constant.NOT_A_CONTRIBUTION_DOWNLOAD_URL,
-		constant.NOT_A_CONTRIBUTION_FILE)
+	// Download the LICENSE.txt and the NOT_A_CONTRIBUTION.txt required by the update
+	securityAdvisory := getSecurityAdvisory(readMeDataString)
+	downloadLegalFiles(resourceDirectoryPath, securityAdvisory)
 
 	// Get ignored files. These files wont be stored in the data structure. So matches will not be searched for
 	// these files
@@ -218,6 +420,7 @@ func createUpdate(updateDirectoryPath, distributionPath string) {
 	logger.Debug(fmt.Sprintf("Ignored files: %v", ignoredFiles))
 
 	//7) Traverse and read the update
+	util.PublishStageStarted(constant.STAGE_READ_UPDATE)
 
 	// allFilesMap - Map which contains details of all files in the directory. Key will be relativePath of the file.
 	// rootLevelDirectoriesMap - Map which have all directories in the root of the given directory. Key will be the
@@ -229,21 +432,39 @@ func createUpdate(updateDirectoryPath, distributionPath string) {
 	logger.Debug(fmt.Sprintf("allFilesMap: %v\n", allFilesMap))
 	logger.Debug(fmt.Sprintf("rootLevelDirectoriesMap: %v\n", rootLevelDirectoriesMap))
 	logger.Debug(fmt.Sprintf("rootLevelFilesMap: %v\n", rootLevelFilesMap))
+	// updateRootNode - Tree of the update directory which is used to find the files in the matched directories
+	updateRootNode := getUpdateRootNode(allFilesMap)
+	util.PublishStageFinished(constant.STAGE_READ_UPDATE)
 
 	// rootNode is what we use as the root of the distribution when we populate tree like structure.
 	rootNode := createNewNode()
 
 	// Get the product name from the distribution path and set it as a viper config
-	paths := strings.Split(distributionPath, constant.PATH_SEPARATOR)
-	distributionName := strings.TrimSuffix(paths[len(paths)-1], ".zip")
+	distributionName := util.GetDistributionName(distributionPath)
 	viper.Set(constant.PRODUCT_NAME, distributionName)
+	// Get the payload directory of the product line of the update (carbon.home by default)
+	payloadDirectory := util.GetProductLinePayloadDirectory(viper.GetStringMapString(constant.PAYLOAD_DIRECTORIES),
+		updateDescriptorV2.PlatformName, util.GetDistributionProductName(distributionName))
+	viper.Set(constant.PAYLOAD_DIRECTORY, payloadDirectory)
+	logger.Debug(fmt.Sprintf("payloadDirectory: %s", payloadDirectory))
+
+	// Check whether the entries of a distribution zip are inside its root folder
+	if zipPath, anomaly := checkDistribution(distributionPath); anomaly != nil {
+		distributionPath = rerootDistribution(zipPath, anomaly)
+	}
 
-	// Read the distribution zip file
-	logger.Debug("Reading zip")
-	fmt.Println(fmt.Sprintf("\nReading %s. Please wait...\n", distributionName))
-	rootNode, err = readZip(distributionPath)
+	// Read the distribution
+	logger.Debug("Reading distribution")
+	util.PublishStageStarted(constant.STAGE_READ_DISTRIBUTION)
+	util.PrintMessage(fmt.Sprintf("\nReading %s. Please wait...\n", distributionName))
+	rootNode, err = readDistribution(distributionPath)
 	util.HandleErrorAndExit(err)
-	logger.Debug("Reading zip finished")
+	logger.Debug("Reading distribution finished")
+	// Apply the released updates so that files are matched against the current state of the distribution
+	if len(appliedUpdatesDirectory) != 0 {
+		applyReleasedUpdates(&rootNode, appliedUpdatesDirectory, &updateDescriptorV2)
+	}
+	util.PublishStageFinished(constant.STAGE_READ_DISTRIBUTION)
 
 	logger.Trace("Top level nodes ---------------------")
 	for name, node := range rootNode.childNodes {
@@ -251,33 +472,55 @@ func createUpdate(updateDirectoryPath, distributionPath string) {
 	}
 	logger.Trace("-------------------------------------")
 
+	// Restore the files matched in the aborted session if the session is resumed
+	matchedEntries = restorePartialUpdateDescriptor(updateName, &updateDescriptorV2)
+
 	wumucResumeFilePath := filepath.Join(WUMUCHome, constant.WUMUC_RESUME_FILE)
 	// Create an interrupt handler
 	cleanupChannel := util.HandleInterrupts(func() {
-		util.CleanUpDirectory(constant.TEMP_DIR)
+		// The matched files are kept in the temp directory so that the session can be resumed
+		if isPartialUpdateDescriptorSaved {
+			util.PrintInfo(fmt.Sprintf("Matched files are saved to '%s'. Run 'wum-uc create %s %s --resume' to "+
+				"resume the session.", constant.TEMP_DIR, updateDirectoryPath, distributionPath))
+		} else {
+			util.CleanUpDirectory(constant.TEMP_DIR)
+		}
 		util.CleanUpFile(wumucResumeFilePath)
 	})
 
 	//todo: save the selected location to generate the final summary map
 	//8) Find matches
+	util.PublishStageStarted(constant.STAGE_MATCH_FILES)
 	// This will be used to store all the matches (matching locations in for the given directory)
 	matches := make(map[string]*node)
 	// Find matches in the distribution for all directories in the root level of the update directory
 	logger.Debug("Checking Directories:")
 	for directoryName := range rootLevelDirectoriesMap {
+		if util.IsStringIsInSlice(directoryName, matchedEntries) {
+			logger.Debug(fmt.Sprintf("Directory %s is matched in the aborted session", directoryName))
+			continue
+		}
 		matches = make(map[string]*node)
 		// Find all matching locations for the directory
 		logger.Debug(fmt.Sprintf("DirectoryName: %s", directoryName))
 		FindMatches(&rootNode, directoryName, true, matches)
 		logger.Debug(fmt.Sprintf("matches: %v", matches))
+		archiveMatches := removeNestedArchiveMatches(directoryName, matches)
+		publishFileMatchedEvent(directoryName, true, matches)
 
 		// Now we can act according to the number of matches we found
 		switch len(matches) {
-		// No match found in the distribution for the given directory
+		// Only matches inside nested archives found in the distribution for the given directory
 		case 0:
-			// Handle the no match situation
+			if len(archiveMatches) != 0 {
+				err := handleNestedArchiveMatches(directoryName, true, archiveMatches, allFilesMap, &updateRootNode,
+					&rootNode)
+				util.HandleErrorAndExit(err)
+				break
+			}
+			// No match found in the distribution for the given directory
 			logger.Debug("\nNo match found\n")
-			err := handleNoMatch(directoryName, true, allFilesMap, &rootNode, &updateDescriptorV2)
+			err := handleNoMatch(directoryName, true, allFilesMap, &updateRootNode, &rootNode, &updateDescriptorV2)
 			util.HandleErrorAndExit(err)
 			// Single match found in the distribution for the given directory
 		case 1:
@@ -289,34 +532,48 @@ func createUpdate(updateDirectoryPath, distributionPath string) {
 			for _, node := range matches {
 				match = node
 			}
-			err := handleSingleMatch(directoryName, match, true, allFilesMap, &rootNode, &updateDescriptorV2)
+			err := handleSingleMatch(directoryName, match, true, allFilesMap, &updateRootNode, &rootNode,
+				&updateDescriptorV2)
 			util.HandleErrorAndExit(err)
 			// Multiple matches found in the distribution for the given directory
 		default:
 			// Handle the multiple matches situation
 			logger.Debug("\nMultiple matches found\n")
-			err := handleMultipleMatches(directoryName, true, matches, allFilesMap, &rootNode,
+			err := handleMultipleMatches(directoryName, true, matches, allFilesMap, &updateRootNode, &rootNode,
 				&updateDescriptorV2)
 			util.HandleErrorAndExit(err)
 		}
+		recordMatchedEntry(directoryName, &updateDescriptorV2)
 	}
 
 	// Find matches in the distribution for all files in the root level of the update directory
 	logger.Debug("Checking Files:")
 	for fileName := range rootLevelFilesMap {
+		if util.IsStringIsInSlice(fileName, matchedEntries) {
+			logger.Debug(fmt.Sprintf("File %s is matched in the aborted session", fileName))
+			continue
+		}
 		matches = make(map[string]*node)
 		// Find all matching locations for the file
 		logger.Debug(fmt.Sprintf("FileName: %s", fileName))
 		FindMatches(&rootNode, fileName, false, matches)
 		logger.Debug(fmt.Sprintf("matches: %v", matches))
+		archiveMatches := removeNestedArchiveMatches(fileName, matches)
+		publishFileMatchedEvent(fileName, false, matches)
 
 		// Now we can act according to the number of matches we found
 		switch len(matches) {
-		// No match found in the distribution for the given file
+		// Only matches inside nested archives found in the distribution for the given file
 		case 0:
-			// Handle the no match situation
+			if len(archiveMatches) != 0 {
+				err := handleNestedArchiveMatches(fileName, false, archiveMatches, allFilesMap, &updateRootNode,
+					&rootNode)
+				util.HandleErrorAndExit(err)
+				break
+			}
+			// No match found in the distribution for the given file
 			logger.Debug("No match found\n")
-			err := handleNoMatch(fileName, false, allFilesMap, &rootNode, &updateDescriptorV2)
+			err := handleNoMatch(fileName, false, allFilesMap, &updateRootNode, &rootNode, &updateDescriptorV2)
 			util.HandleErrorAndExit(err)
 			// Single match found in the distribution for the given file
 		case 1:
@@ -328,24 +585,38 @@ func createUpdate(updateDirectoryPath, distributionPath string) {
 			for _, node := range matches {
 				match = node
 			}
-			err := handleSingleMatch(fileName, match, false, allFilesMap, &rootNode, &updateDescriptorV2)
+			err := handleSingleMatch(fileName, match, false, allFilesMap, &updateRootNode, &rootNode,
+				&updateDescriptorV2)
 			util.HandleErrorAndExit(err)
 			// Multiple matches found in the distribution for the given file
 		default:
 			// Handle the multiple matches situation
 			logger.Debug("Multiple matches found\n")
-			err := handleMultipleMatches(fileName, false, matches, allFilesMap, &rootNode, &updateDescriptorV2)
+			err := handleMultipleMatches(fileName, false, matches, allFilesMap, &updateRootNode, &rootNode,
+				&updateDescriptorV2)
 			util.HandleErrorAndExit(err)
 		}
+		recordMatchedEntry(fileName, &updateDescriptorV2)
 	}
 
+	util.PublishStageFinished(constant.STAGE_MATCH_FILES)
+	printAutomaticDecisions()
+
 	//9) Request the user to add removed files as they can't be identified by comparing.
+	// Updates without payload files only remove files, so the removed files are requested directly
+	isRemoveOnlyUpdate := len(rootLevelDirectoriesMap) == 0 && len(rootLevelFilesMap) == 0
+	if isRemoveOnlyUpdate {
+		util.PrintInfo(fmt.Sprintf("No payload files found in '%s'. The update will only remove files from %s.",
+			updateDirectoryPath, distributionName))
+		appendRemovedFilesToUpdateDescriptor(&updateDescriptorV2)
+		err = checkRemovedFiles(&rootNode, updateDescriptorV2.FileChanges.RemovedFiles)
+		util.HandleErrorAndExit(err, "Error occurred while creating an update which only removes files.")
+	}
 removedFilesInputLoop:
-	for {
-		util.PrintInBold(fmt.Sprintf("\nAre the existing files in %s removed from this update? [y"+
+	for !isRemoveOnlyUpdate {
+		preference, err := util.PromptUser(fmt.Sprintf("\nAre the existing files in %s removed from this update? [y"+
 			"/n]: ",
 			distributionName))
-		preference, err := util.GetUserInput()
 		util.HandleErrorAndExit(err, "Error occurred while getting input from the user.")
 		userPreference := util.ProcessUserPreference(preference)
 		switch userPreference {
@@ -360,7 +631,22 @@ removedFilesInputLoop:
 	}
 
 	// Get partial updated file changes
-	partialUpdatedFileResponse := util.GetPartialUpdatedFiles(&updateDescriptorV2)
+	util.PublishStageStarted(constant.STAGE_CREATE_DESCRIPTORS)
+	var partialUpdatedFileResponse *util.PartialUpdatedFileResponse
+	if len(listedDistributions) != 0 {
+		// Applicable products of a platform-wide update are found using the listed distributions instead of WUM
+		listedDistributions[0].Distribution = distributionPath
+		partialUpdatedFileResponse = getListedProductsChanges(&updateDescriptorV2, &rootNode)
+	} else {
+		isOnline, _ := util.RunOnlineStep(constant.ONLINE_SERVICE_WUM, func() error {
+			partialUpdatedFileResponse = util.GetPartialUpdatedFiles(&updateDescriptorV2)
+			return nil
+		})
+		// The distribution is used as the only compatible product if the applicable products cannot be found
+		if !isOnline {
+			partialUpdatedFileResponse = getPlaceholderProductsChanges(&updateDescriptorV2, distributionName)
+		}
+	}
 	if partialUpdatedFileResponse.BackwardCompatible {
 		// Create update-descriptor.yaml
 		if len(readMeDataString) != 0 {
@@ -368,7 +654,11 @@ removedFilesInputLoop:
 		} else {
 			setRemainingValuesInUpdateDescriptorsV2(&updateDescriptorV2)
 		}
-		createUpdateDescriptorV2(updateDirectoryPath, &updateDescriptorV2)
+		// Resolve the environment variables referred in the README.txt or the user inputs (eg: build numbers)
+		err = util.ResolveUpdateDescriptorV2EnvPlaceholders(&updateDescriptorV2)
+		util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while resolving the placeholders in '%s'.",
+			constant.UPDATE_DESCRIPTOR_V2_FILE))
+		createUpdateDescriptorV2(resourceDirectoryPath, &updateDescriptorV2)
 		data, err := marshalUpdateDescriptor(&updateDescriptorV2)
 		util.HandleErrorAndExit(err, "Error occurred while marshalling the update-descriptorV2.")
 		// Save the updated update-descriptor.yaml with newly added, modified and removed files to the temp directory
@@ -383,10 +673,19 @@ removedFilesInputLoop:
 	updateDescriptorV3.PlatformVersion = partialUpdatedFileResponse.PlatformVersion
 	updateDescriptorV3.Description = constant.DEFAULT_DESCRIPTION
 	updateDescriptorV3.Instructions = constant.DEFAULT_INSTRUCTIONS
+	// Instructions of the descriptor template of the team are used instead of the default instructions
+	if templateStore.DescriptorTemplate != nil && len(templateStore.DescriptorTemplate.Instructions) != 0 {
+		updateDescriptorV3.Instructions = templateStore.DescriptorTemplate.Instructions
+	}
 	defaultBugFixes := map[string]string{
 		constant.DEFAULT_JIRA_KEY: constant.DEFAULT_JIRA_SUMMARY,
 	}
 	updateDescriptorV3.BugFixes = defaultBugFixes
+	updateDescriptorV3.FileOwnerships = recordedFileOwnerships
+	updateDescriptorV3.SecurityAdvisory = securityAdvisory
+	if payloadDirectory != constant.CARBON_HOME {
+		updateDescriptorV3.PayloadDirectory = payloadDirectory
+	}
 
 	for _, partialUpdatedProducts := range partialUpdatedFileResponse.CompatibleProducts {
 		productChanges := setProductChangesInUpdateDescriptorV3(&partialUpdatedProducts)
@@ -399,6 +698,8 @@ removedFilesInputLoop:
 
 	// Generate md5sum for the content generated by wum-uc tool
 	updateDescriptorV3.Md5sum = util.GenerateMd5sumForGeneratedContent(&updateDescriptorV3)
+	// Declare the checksum algorithm of the update so that catalogs use it for the update zip
+	updateDescriptorV3.ChecksumAlgorithm = viper.GetString(constant.CHECKSUM_ALGORITHM)
 
 	// Set values to compatible products slice for displaying purpose
 	var compatibleProducts []string
@@ -419,22 +720,21 @@ removedFilesInputLoop:
 		notifyProducts = append(notifyProducts, productId)
 	}
 
+	// Generate the instructions for the changed config files if the developer has not given instructions
+	generateInstructionsSkeleton(updateDirectoryPath, resourceDirectoryPath, distributionPath, &updateDescriptorV2)
+
 	//10) Copy resource files (LICENSE.txt, etc) to temp directory
-	resourceFiles := getResourceFiles()
-	err = copyResourceFilesToTempDir(resourceFiles)
+	resourceFiles := getResourceFiles(&updateDescriptorV3)
+	err = copyResourceFilesToTempDir(resourceDirectoryPath, resourceFiles)
 	util.HandleErrorAndExit(err, errors.New("error occurred while copying resource files"))
-	// Create update-descriptor3.yaml in user given update directory
-	createUpdateDescriptorV3(updateDirectoryPath, &updateDescriptorV3)
+	// Create update-descriptor3.yaml in the resource directory
+	createUpdateDescriptorV3(resourceDirectoryPath, &updateDescriptorV3)
+	util.PublishStageFinished(constant.STAGE_CREATE_DESCRIPTORS)
 
-	explodedUpdateDirectory := path.Join(constant.TEMP_DIR, updateName)
-	explodedUpdateDirectory = strings.Replace(explodedUpdateDirectory, "/", constant.PATH_SEPARATOR, -1)
+	explodedUpdateDirectory := filepath.Join(constant.TEMP_DIR, updateName)
 
 	logger.Debug(fmt.Sprintf("Exploded update directory: %s", explodedUpdateDirectory))
 	WUMUCConfig := util.GetWUMUCConfigs()
-	absUpdateDirectoryPath, err := filepath.Abs(updateDirectoryPath)
-	if err != nil {
-		absUpdateDirectoryPath = updateDirectoryPath
-	}
 
 	// Set details of the update to resumeFile struct for resuming update creation
 	logger.Debug(fmt.Sprintf("Setting values to %s for resuming update creation", constant.WUMUC_RESUME_FILE))
@@ -442,13 +742,33 @@ removedFilesInputLoop:
 	resumeFile.ExplodedUpdateDirectoryPath = explodedUpdateDirectory
 	resumeFile.UpdateName = updateName
 	resumeFile.DistributionPath = distributionPath
-	resumeFile.ResourceDirectoryPath = absUpdateDirectoryPath
+	absResourceDirectoryPath, err := filepath.Abs(resourceDirectoryPath)
+	if err != nil {
+		absResourceDirectoryPath = resourceDirectoryPath
+	}
+	resumeFile.ResourceDirectoryPath = absResourceDirectoryPath
+	resumeFile.UpdateDirectoryPath = absUpdateDirectoryPath
+	resumeFile.IsReadOnlyInputs = isReadOnlyInputs
+	resumeFile.PayloadDirectory = payloadDirectory
 	resumeFile.Developer = WUMUCConfig.Username
 	resumeFile.PlatformName = updateDescriptorV3.PlatformName
+	resumeFile.PlatformVersion = updateDescriptorV3.PlatformVersion
 	resumeFile.UpdateNumber = updateDescriptorV3.UpdateNumber
+	resumeFile.IsBinaryDeltaEnabled = viper.GetBool(constant.BINARY_DELTA_ENABLED)
+	resumeFile.IsScanEnabled = viper.GetBool(constant.SCAN_ENABLED)
+	resumeFile.Format = viper.GetString(constant.UPDATE_FORMAT)
+	resumeFile.EncryptionRecipients = encryptionRecipients
+	resumeFile.BuildCommand = os.Args
+	resumeFile.BuildStartedOn = buildStartedOn
+	resumeFile.ListedDistributions = listedDistributions
+	resumeFile.SkippedFiles = skippedFiles
+	// Skipped files of the next update of a batch should not be mixed with these
+	skippedFiles = nil
 
 	// Write resumeFile struct to a file
 	saveResumeFile(&resumeFile, wumucResumeFilePath)
+	// The session cannot be resumed after the update descriptors are created
+	util.CleanUpFile(filepath.Join(constant.TEMP_DIR, constant.PARTIAL_UPDATE_DESCRIPTOR_FILE))
 
 	// clean un temp file
 	signal.Stop(cleanupChannel)
@@ -460,8 +780,150 @@ removedFilesInputLoop:
 
 	util.PrintInBold(fmt.Sprintf("Manually fill the `description`,"+
 		"`instructions` and `bug_fixes` fields for above products in the update-descriptor3."+
-		"yaml located inside %s directory\n", updateDirectoryPath))
+		"yaml located inside %s directory\n", resourceDirectoryPath))
 	util.PrintInBold(fmt.Sprintf("\nWhen done please run 'wum-uc create --continue' to resume the update creation.\n"))
+	setWorkspaceStatus(constant.WORKSPACE_STATUS_PENDING)
+}
+
+// This function checks the files of the update, which are matched against the first listed distribution, against the
+// distributions of all the listed products and returns the changes of each product in the same form as the applicable
+// products given by WUM. Distributions given as remote URIs are kept in the wum-uc home.
+func getListedProductsChanges(updateDescriptorV2 *util.UpdateDescriptorV2,
+	rootNode *node) *util.PartialUpdatedFileResponse {
+	partialUpdatedFileResponse := &util.PartialUpdatedFileResponse{
+		UpdateNumber:       updateDescriptorV2.UpdateNumber,
+		PlatformVersion:    updateDescriptorV2.PlatformVersion,
+		PlatformName:       updateDescriptorV2.PlatformName,
+		BackwardCompatible: true,
+	}
+	// Paths declared as non-updatable in the first distribution are overwritten when reading the other distributions
+	matchedIgnoredPaths := distributionIgnoredPaths
+	defer func() {
+		distributionIgnoredPaths = matchedIgnoredPaths
+	}()
+	for index := range listedDistributions {
+		listedDistribution := &listedDistributions[index]
+		distributionRootNode := rootNode
+		if index != 0 {
+			distributionRootNode = readListedDistribution(listedDistribution, updateDescriptorV2)
+		}
+		productChanges, isCompatible := util.GetListedProductChanges(listedDistribution, updateDescriptorV2,
+			func(relativePath string) bool {
+				return PathExists(distributionRootNode, relativePath, false)
+			})
+		if isCompatible {
+			partialUpdatedFileResponse.CompatibleProducts = append(partialUpdatedFileResponse.CompatibleProducts,
+				*productChanges)
+			continue
+		}
+		util.PrintInfo(fmt.Sprintf("'%s' is partially applicable as some of the modified or removed files are not "+
+			"in its distribution.", listedDistribution.GetProductId()))
+		partialUpdatedFileResponse.PartiallyApplicableProducts = append(
+			partialUpdatedFileResponse.PartiallyApplicableProducts, *productChanges)
+	}
+	return partialUpdatedFileResponse
+}
+
+// This function returns the changes of the update with the product of the given distribution as the only compatible
+// product. This is used as a placeholder when the applicable products cannot be found in WUM.
+func getPlaceholderProductsChanges(updateDescriptorV2 *util.UpdateDescriptorV2,
+	distributionName string) *util.PartialUpdatedFileResponse {
+	productName := util.GetDistributionProductName(distributionName)
+	util.PrintWarning(fmt.Sprintf("Applicable products of the update could not be found. '%s' is added as the only "+
+		"compatible product. Please correct the products in the '%s' manually.", distributionName,
+		constant.UPDATE_DESCRIPTOR_V3_FILE))
+	return &util.PartialUpdatedFileResponse{
+		UpdateNumber:       updateDescriptorV2.UpdateNumber,
+		PlatformVersion:    updateDescriptorV2.PlatformVersion,
+		PlatformName:       updateDescriptorV2.PlatformName,
+		BackwardCompatible: true,
+		CompatibleProducts: []util.PartialUpdatedProducts{{
+			ProductName:   productName,
+			BaseVersion:   strings.TrimPrefix(distributionName, productName+"-"),
+			AddedFiles:    updateDescriptorV2.FileChanges.AddedFiles,
+			ModifiedFiles: updateDescriptorV2.FileChanges.ModifiedFiles,
+			RemovedFiles:  updateDescriptorV2.FileChanges.RemovedFiles,
+		}},
+	}
+}
+
+// This function reads the distribution of the given listed product and returns its tree. The location of the
+// distribution is replaced with the local file if it is downloaded or re-rooted.
+func readListedDistribution(listedDistribution *util.ListedDistribution,
+	updateDescriptorV2 *util.UpdateDescriptorV2) *node {
+	distributionPath := downloadArtifact(listedDistribution.Distribution, filepath.Join(WUMUCHome,
+		constant.WUMUC_ARTIFACTS_DIRECTORY))
+	distributionName := util.GetDistributionName(distributionPath)
+	if zipPath, anomaly := checkDistribution(distributionPath); anomaly != nil {
+		distributionPath = rerootDistribution(zipPath, anomaly)
+	}
+	listedDistribution.Distribution = distributionPath
+
+	util.PrintMessage(fmt.Sprintf("\nReading %s. Please wait...\n", distributionName))
+	rootNode, err := readDistribution(distributionPath)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while reading the distribution of '%s'.",
+		listedDistribution.GetProductId()))
+	// Released updates are applied to all the distributions so that the products are compared in the same state
+	if len(appliedUpdatesDirectory) != 0 {
+		applyReleasedUpdates(&rootNode, appliedUpdatesDirectory, updateDescriptorV2)
+	}
+	return &rootNode
+}
+
+// This function requests the user to re-root the given distribution of which the entries are not inside its root
+// folder. The re-rooted distribution is saved in the wum-uc home as it is used until the update is created.
+func rerootDistribution(distributionPath string, anomaly *util.RootFolderAnomaly) string {
+	util.PrintWarning(fmt.Sprintf("%s '%s' is invalid, %s.", constant.DISTRIBUTION, distributionPath,
+		anomaly.Error()))
+	for {
+		preference, err := util.PromptUser(fmt.Sprintf("Do you want to re-root the distribution under '%s'? "+
+			"[Y/n]: ", anomaly.ExpectedRootFolder))
+		util.HandleErrorAndExit(err, "Error occurred while getting input from the user.")
+		if len(preference) == 0 {
+			preference = "y"
+		}
+		switch util.ProcessUserPreference(preference) {
+		case constant.YES:
+			rerootedDirectory := filepath.Join(WUMUCHome, constant.WUMUC_REROOTED_DISTRIBUTIONS_DIRECTORY)
+			err = util.CreateDirectory(rerootedDirectory)
+			util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while creating '%s'.", rerootedDirectory))
+			rerootedDistributionPath := filepath.Join(rerootedDirectory, filepath.Base(distributionPath))
+			logger.Debug(fmt.Sprintf("Re-rooting %s to %s", distributionPath, rerootedDistributionPath))
+			err = util.RerootZip(distributionPath, rerootedDistributionPath, anomaly)
+			util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while re-rooting '%s'.", distributionPath))
+			util.PrintInfo(fmt.Sprintf("Re-rooted distribution saved to '%s'.", rerootedDistributionPath))
+			return rerootedDistributionPath
+		case constant.NO:
+			util.HandleErrorAndExit(util.NewUserAbortError(errors.New("re-rooting skipped. Please enter a " +
+				"distribution of which the entries are inside its root folder")))
+		default:
+			util.PrintError("Invalid preference. Enter Y for Yes or N for No.")
+		}
+	}
+}
+
+// This function returns the directory to which the files generated for the update (LICENSE.txt, update descriptors,
+// etc) are written. This is the update directory unless the inputs are read only, in which case a directory of the
+// update in the wum-uc home is used.
+func getResourceDirectoryPath(updateDirectoryPath, updateName string) string {
+	if !viper.GetBool(constant.READ_ONLY_INPUTS) {
+		return updateDirectoryPath
+	}
+	resourceDirectoryPath := filepath.Join(WUMUCHome, constant.WUMUC_RESOURCES_DIRECTORY, updateName)
+	err := util.CreateDirectory(resourceDirectoryPath)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while creating '%s'.", resourceDirectoryPath))
+	util.PrintInfo(fmt.Sprintf("Inputs are read only. Files generated for the update are written to '%s'.",
+		resourceDirectoryPath))
+	return resourceDirectoryPath
+}
+
+// This function returns the update directory of the given resume file. Resume files written before the update
+// directory was recorded have the update directory as the resource directory.
+func getUpdateDirectoryPath(resumeFile *ResumeFile) string {
+	if len(resumeFile.UpdateDirectoryPath) != 0 {
+		return resumeFile.UpdateDirectoryPath
+	}
+	return resumeFile.ResourceDirectoryPath
 }
 
 // This function will process the README.txt file and extract basic details of the update to populate the update
@@ -471,7 +933,7 @@ func processReadMe(updateDirectoryPath string, updateDescriptorV2 *util.UpdateDe
 	logger.Debug("Processing README.txt started for filling in `update_number`," +
 		"`platform_name` and `platform_version` in update-descriptor.yaml")
 	// Construct the README.txt path
-	readMePath := path.Join(updateDirectoryPath, constant.README_FILE)
+	readMePath := filepath.Join(updateDirectoryPath, constant.README_FILE)
 	logger.Debug(fmt.Sprintf("README.txt Path: %v", readMePath))
 	// Check whether the README.txt file exists
 	_, err := os.Stat(readMePath)
@@ -492,41 +954,38 @@ func processReadMe(updateDirectoryPath string, updateDescriptorV2 *util.UpdateDe
 	}
 	// Convert the byte array to a string
 	readMeDataString := string(data)
+	// Select the parser of the format of the README.txt
+	parser := util.GetReadMeParser(readMeDataString)
+	if parser == nil {
+		logger.Debug("Format of README.txt is not supported")
+		setBasicValuesInUpdateDescriptorV2(updateDescriptorV2)
+		return ""
+	}
 	logger.Debug("Processing README started")
-	// Compile the regex
-	regex, err := regexp.Compile(constant.PATCH_ID_REGEX)
-	if err == nil {
-		result := regex.FindStringSubmatch(readMeDataString)
-		logger.Trace(fmt.Sprintf("PATCH_ID_REGEX result: %v", result))
-		// Since the regex has 2 capturing groups, the result size will be 3 (because there is the full match)
-		// If not match found, the size will be 0. We check whether the result size is not 0 to make sure both
-		// capturing groups are identified.
-		if len(result) != 0 {
-			// Extract details
-			updateDescriptorV2.UpdateNumber = result[2]
-			updateDescriptorV2.PlatformVersion = result[1]
-			platformsMap := viper.GetStringMapString(constant.PLATFORM_VERSIONS)
-			logger.Trace(fmt.Sprintf("Platform Map: %v", platformsMap))
-			// Get the platform details from the map
-			platformName, found := platformsMap[result[1]]
-			if found {
-				logger.Debug("Platform name found in configs")
-				updateDescriptorV2.PlatformName = platformName
-			} else {
-				//If the platform name is not found, request the user
-				logger.Debug("No matching platform name found for:", result[1])
-				util.PrintInBold("Enter platform name for platform version :", result[1])
-				platformName, err := util.GetUserInput()
-				util.HandleErrorAndExit(err, "Error occurred while getting input from the user.")
-				updateDescriptorV2.PlatformName = platformName
-			}
+	readMeDetails := parser.Parse(readMeDataString)
+	logger.Trace(fmt.Sprintf("README details: %v", readMeDetails))
+	if len(readMeDetails.UpdateNumber) != 0 && len(readMeDetails.PlatformVersion) != 0 {
+		// Extract details
+		updateDescriptorV2.UpdateNumber = readMeDetails.UpdateNumber
+		updateDescriptorV2.PlatformVersion = readMeDetails.PlatformVersion
+		platformsMap := viper.GetStringMapString(constant.PLATFORM_VERSIONS)
+		logger.Trace(fmt.Sprintf("Platform Map: %v", platformsMap))
+		// Get the platform details from the map
+		platformName, found := platformsMap[readMeDetails.PlatformVersion]
+		if found {
+			logger.Debug("Platform name found in configs")
+			updateDescriptorV2.PlatformName = platformName
 		} else {
-			logger.Debug("PATCH_ID_REGEX results incorrect:", result)
-			setBasicValuesInUpdateDescriptorV2(updateDescriptorV2)
+			//If the platform name is not found, request the user
+			logger.Debug("No matching platform name found for:", readMeDetails.PlatformVersion)
+			platformName, err := util.PromptUser(fmt.Sprintf("Enter platform name for platform version : %s ",
+				readMeDetails.PlatformVersion))
+			util.HandleErrorAndExit(err, "Error occurred while getting input from the user.")
+			updateDescriptorV2.PlatformName = platformName
 		}
 	} else {
-		//If error occurred, set default values
-		logger.Debug(fmt.Sprintf("Error occurred while processing PATCH_ID_REGEX: %v", err))
+		logger.Debug(fmt.Sprintf("Update number and platform version not found in the %s README.txt",
+			parser.Name()))
 		setBasicValuesInUpdateDescriptorV2(updateDescriptorV2)
 	}
 	return readMeDataString
@@ -537,83 +996,75 @@ func setBasicValuesInUpdateDescriptorV2(updateDescriptorV2 *util.UpdateDescripto
 	logger.Debug("Setting values for `update_number`," +
 		"`platform_version` and `platform_name` fields in update-descriptor." +
 		"yaml")
-	setUpdateNumber(updateDescriptorV2)
+	// Update number is taken from the reservations in the auto number mode
+	if !isAutoNumberEnabled {
+		setUpdateNumber(updateDescriptorV2)
+	}
 	setPlatformNameAndVersion(updateDescriptorV2)
 }
 
+// Sets the update number in update-descriptor.yaml from the update numbers reserved in WUM for the platform version.
+func setReservedUpdateNumber(updateDirectoryPath string, updateDescriptorV2 *util.UpdateDescriptorV2) {
+	absUpdateDirectoryPath, err := filepath.Abs(updateDirectoryPath)
+	if err != nil {
+		absUpdateDirectoryPath = updateDirectoryPath
+	}
+	reservation, err := util.ConsumeUpdateNumberReservation(WUMUCHome, updateDescriptorV2.PlatformVersion,
+		absUpdateDirectoryPath, time2.Now())
+	util.HandleErrorAndExit(err)
+	if len(updateDescriptorV2.UpdateNumber) != 0 && updateDescriptorV2.UpdateNumber != reservation.UpdateNumber {
+		util.PrintWarning(fmt.Sprintf("Update number '%s' given in the %s is replaced with the reserved update "+
+			"number '%s'.", updateDescriptorV2.UpdateNumber, constant.README_FILE, reservation.UpdateNumber))
+	}
+	updateDescriptorV2.UpdateNumber = reservation.UpdateNumber
+	util.PrintInfo(fmt.Sprintf("Using the update number '%s' reserved for platform version '%s' until %s.",
+		reservation.UpdateNumber, reservation.PlatformVersion, reservation.ExpiresAt))
+}
+
 // Process readme data for filling in remaining details of update-descriptor.yaml
 func processReadMeData(readMeDataString *string, updateDescriptorV2 *util.UpdateDescriptorV2) {
 	logger.Debug("Processing README.txt started for filling in `applies_to`," +
 		"`bug_fixes` and `description` in update-descriptor.yaml")
 
-	// Compile the regex
-	regex, err := regexp.Compile(constant.APPLIES_TO_REGEX)
-	if err == nil {
-		result := regex.FindStringSubmatch(*readMeDataString)
-		logger.Trace(fmt.Sprintf("APPLIES_TO_REGEX result: %v", result))
-		// In the README, Associated Jiras section might not appear. If it does appear, result size will be 2.
-		// If it does not appear, result size will be 3.
-		if len(result) == 2 {
-			// If the result size is 2, we know that 1st index contains the 1st capturing group.
-			updateDescriptorV2.AppliesTo = util.ProcessString(result[1], ", ", true)
-		} else if len(result) == 3 {
-			// If the result size is 3, 1st or 2nd string might contain the match. So we concat them
-			// together and trim the spaces. If one field has an empty string, it will be trimmed.
-			updateDescriptorV2.AppliesTo = util.ProcessString(strings.TrimSpace(result[1]+result[2]), ", ",
-				true)
-		} else {
-			logger.Debug("No matching results found for APPLIES_TO_REGEX:", result)
-			setAppliesTo(updateDescriptorV2)
-		}
+	// processReadMe returns the README.txt only if its format is supported
+	readMeDetails := util.GetReadMeParser(*readMeDataString).Parse(*readMeDataString)
+	if len(readMeDetails.AppliesTo) != 0 {
+		updateDescriptorV2.AppliesTo = readMeDetails.AppliesTo
 	} else {
-		// If error occurred, request user to fill in
-		logger.Debug(fmt.Sprintf("Error occurred while processing APPLIES_TO_REGEX: %v", err))
+		logger.Debug("Applies to not found in README.txt")
 		setAppliesTo(updateDescriptorV2)
 	}
 
-	// Compile the regex
-	regex, err = regexp.Compile(constant.ASSOCIATED_JIRAS_REGEX)
-	if err == nil {
-		// Get all matches because there might be multiple Jiras.
-		allResult := regex.FindAllStringSubmatch(*readMeDataString, -1)
-		logger.Trace(fmt.Sprintf("APPLIES_TO_REGEX result: %v", allResult))
-		updateDescriptorV2.BugFixes = make(map[string]string)
-		// If no Jiras found, set 'N/A: N/A' as the value
-		if len(allResult) == 0 {
-			logger.Debug("No matching results found for ASSOCIATED_JIRAS_REGEX.")
-			setBugFixes(updateDescriptorV2)
-		} else {
-			// If Jiras found, get summary for all Jiras
-			logger.Debug("Matching results found for ASSOCIATED_JIRAS_REGEX")
-			for i, match := range allResult {
-				// Regex has a one capturing group. So the jira ID will be in the 1st index.
-				logger.Debug(fmt.Sprintf("%d: %s", i, match[1]))
-				logger.Debug(fmt.Sprintf("ASSOCIATED_JIRAS_REGEX results is correct: %v", match))
-				updateDescriptorV2.BugFixes[match[1]] = util.GetJiraSummary(match[1])
+	updateDescriptorV2.BugFixes = make(map[string]string)
+	// If no Jiras found, set 'N/A: N/A' as the value
+	if len(readMeDetails.BugFixes) == 0 {
+		logger.Debug("No Jiras found in README.txt")
+		setBugFixes(updateDescriptorV2)
+	} else {
+		// If Jiras found, get summary for all Jiras
+		isJiraReachable := true
+		for i, jiraKey := range readMeDetails.BugFixes {
+			logger.Debug(fmt.Sprintf("%d: %s", i, jiraKey))
+			jiraSummary := constant.JIRA_SUMMARY_DEFAULT
+			// JIRA is not requested again once it is found to be unreachable
+			if isJiraReachable {
+				isJiraReachable, _ = util.RunOnlineStep(constant.ONLINE_SERVICE_JIRA, func() error {
+					jiraSummary = util.GetJiraSummary(jiraKey)
+					return nil
+				})
 			}
+			if jiraSummary == constant.JIRA_SUMMARY_DEFAULT {
+				util.PrintWarning(fmt.Sprintf("Summary of '%s' could not be found. Please add the summary "+
+					"to the '%s' manually.", jiraKey, constant.UPDATE_DESCRIPTOR_V2_FILE))
+			}
+			updateDescriptorV2.BugFixes[jiraKey] = jiraSummary
 		}
-	} else {
-		// If error occurred, request user to fill in
-		logger.Debug(fmt.Sprintf("Error occurred while processing ASSOCIATED_JIRAS_REGEX: %v", err))
-		setBugFixes(updateDescriptorV2)
 	}
 
-	// Compile the regex
-	regex, err = regexp.Compile(constant.DESCRIPTION_REGEX)
-	if err == nil {
-		// Get the match
-		result := regex.FindStringSubmatch(*readMeDataString)
-		logger.Trace(fmt.Sprintf("DESCRIPTION_REGEX result: %v", result))
-		// If there is a match, process it and store it
-		if len(result) != 0 {
-			updateDescriptorV2.Description = util.ProcessString(result[1], "\n", false)
-		} else {
-			logger.Debug(fmt.Sprintf("No matching results found for DESCRIPTION_REGEX: %v", result))
-			setDescription(updateDescriptorV2)
-		}
+	if len(readMeDetails.Description) != 0 {
+		updateDescriptorV2.Description = readMeDetails.Description
 	} else {
-		// If error occurred, request user to fill in
-		logger.Debug(fmt.Sprintf("Error occurred while processing DESCRIPTION_REGEX: %v", err))
+		logger.Debug("Description not found in README.txt")
 		setDescription(updateDescriptorV2)
 	}
 	logger.Debug("Processing README finished")
@@ -632,16 +1083,14 @@ func setRemainingValuesInUpdateDescriptorsV2(updateDescriptorV2 *util.UpdateDesc
 func setUpdateNumber(updateDescriptorV2 *util.UpdateDescriptorV2) {
 	var updateNumber string
 	for {
-		util.PrintInBold("Enter 'update number': ")
-		updateNum, err := util.GetUserInput()
+		updateNum, err := util.PromptUser("Enter 'update number': ")
 		util.HandleErrorAndExit(err, "Error occurred while getting input from the user.")
 		if len(updateNum) == 0 {
 			util.PrintError(fmt.Sprintf("'update number' is empty"))
 			continue
 		}
-		if !util.ValidateUpdateNumber(updateNum) {
-			util.PrintError(fmt.Sprintf("'update number' is not valid. It should match '%s'.",
-				constant.UPDATE_NUMBER_REGEX))
+		if err := util.ValidateUpdateNumberFormat(updateNum); err != nil {
+			util.PrintError(err.Error())
 			continue
 		}
 		updateNumber = updateNum
@@ -657,8 +1106,8 @@ userInputLoop:
 		util.PrintInBold(fmt.Sprintf("Select the platform name and version from following: \n"))
 		util.PrintInBold(fmt.Sprintf("\t1. wilkes \t 4.4.0\n"))
 		util.PrintInBold(fmt.Sprintf("\t2. hamming \t 5.0.0\n"))
-		util.PrintInBold(fmt.Sprintf("Enter your preference [1/2]: "))
-		userInput, err := util.GetUserInput()
+		userInput, err := util.PromptUserWithOptions(fmt.Sprintf("Enter your preference [1/2]: "),
+			[]string{"1", "2"})
 		if err != nil {
 			util.HandleErrorAndExit(err, "Error occurred while getting input from the user.")
 		}
@@ -670,12 +1119,12 @@ userInputLoop:
 		case 1:
 			updateDescriptorV2.PlatformName = "wilkes"
 			updateDescriptorV2.PlatformVersion = "4.4.0"
-			fmt.Println(fmt.Sprintf("platform name: 'wilkes' and platform version: '4.4.0' selected\n"))
+			util.PrintMessage(fmt.Sprintf("platform name: 'wilkes' and platform version: '4.4.0' selected\n"))
 			break userInputLoop
 		case 2:
 			updateDescriptorV2.PlatformName = "hamming"
 			updateDescriptorV2.PlatformVersion = "5.0.0"
-			fmt.Println(fmt.Sprintf("platform name: 'hamming' and platform version: '5.0.0' selected\n"))
+			util.PrintMessage(fmt.Sprintf("platform name: 'hamming' and platform version: '5.0.0' selected\n"))
 			break userInputLoop
 		default:
 			util.PrintError("Invalid input")
@@ -686,17 +1135,15 @@ userInputLoop:
 
 // Sets the applies to in update-descriptor.yaml
 func setAppliesTo(updateDescriptorV2 *util.UpdateDescriptorV2) {
-	util.PrintInBold(fmt.Sprintf("\nEnter applies to: "))
-	appliesTo, err := util.GetUserInput()
+	appliesTo, err := util.PromptUser(fmt.Sprintf("\nEnter applies to: "))
 	util.HandleErrorAndExit(err, "Error occurred while getting input from the user.")
 	updateDescriptorV2.AppliesTo = appliesTo
 }
 
 // Sets the description in update-descriptor.yaml
 func setDescription(updateDescriptorV2 *util.UpdateDescriptorV2) {
-	util.PrintInBold(fmt.Sprintf("\nEnter the description: "))
-	description, err := util.GetUserInput()
-	fmt.Println()
+	description, err := util.PromptUser(fmt.Sprintf("\nEnter the description: "))
+	util.PrintMessage()
 	util.HandleErrorAndExit(err, "Error occurred while getting input from the user.")
 	updateDescriptorV2.Description = description
 }
@@ -704,20 +1151,19 @@ func setDescription(updateDescriptorV2 *util.UpdateDescriptorV2) {
 // Sets the bug fixes in update-descriptor.yaml
 func setBugFixes(updateDescriptorV2 *util.UpdateDescriptorV2) {
 	util.PrintInBold("Enter Bug fixes,")
-	fmt.Println()
+	util.PrintMessage()
 	bugFixes := make(map[string]string)
 userInputLoop:
 	for {
-		util.PrintInBold(fmt.Sprintf("\tEnter JIRA_KEY/GITHUB ISSUE URL: "))
-		jiraKey, err := util.GetUserInput()
+		jiraKey, err := util.PromptUser(fmt.Sprintf("\tEnter JIRA_KEY/GITHUB ISSUE URL: "))
 		util.HandleErrorAndExit(err, "Error occurred while getting input from the user.")
 		if jiraKey == "" {
 			if len(bugFixes) == 0 {
 				util.PrintErrorWithTab("Empty input detected, please enter a valid JIRA_KEY/GITHUB ISSUE URL")
 				continue
 			}
-			util.PrintInBold(fmt.Sprintf("\tEmpty input detected, are you done with adding bug fixes? [y/n]: "))
-			preference, err := util.GetUserInput()
+			preference, err := util.PromptUser(fmt.Sprintf("\tEmpty input detected, are you done with adding bug " +
+				"fixes? [y/n]: "))
 			util.HandleErrorAndExit(err, "Error occurred while getting input from the user.")
 			userPreference := util.ProcessUserPreference(preference)
 			switch userPreference {
@@ -741,8 +1187,8 @@ userInputLoop:
 func getJiraSummary(jiraKey string) string {
 	var jiraSummary string
 	for {
-		util.PrintInBold(fmt.Sprintf("\tEnter JIRA_KEY_SUMMARY/GITHUB_ISSUE_SUMMARY for '%s': ", jiraKey))
-		jiraSum, err := util.GetUserInput()
+		jiraSum, err := util.PromptUser(fmt.Sprintf("\tEnter JIRA_KEY_SUMMARY/GITHUB_ISSUE_SUMMARY for '%s': ",
+			jiraKey))
 		util.HandleErrorAndExit(err, "Error occurred while getting input from the user.")
 		if jiraSum == "" {
 			util.PrintErrorWithTab(fmt.Sprintf("Empty input detected, "+
@@ -773,7 +1219,7 @@ func createUpdateDescriptorV2(updateDirectoryPath string, updateDescriptorV2 *ut
 
 	// Save update descriptor
 	absDestinationV2 := saveUpdateDescriptorInDestination(updateDescriptorFileV2, dataStringV2, updateDirectoryPath)
-	fmt.Println(fmt.Sprintf("'%s' has been successfully created in '%s'.", constant.UPDATE_DESCRIPTOR_V2_FILE,
+	util.PrintMessage(fmt.Sprintf("'%s' has been successfully created in '%s'.", constant.UPDATE_DESCRIPTOR_V2_FILE,
 		absDestinationV2))
 }
 
@@ -794,7 +1240,7 @@ func createUpdateDescriptorV3(updateDirectoryPath string, updateDescriptorV3 *ut
 
 	// Save update descriptors
 	absDestinationV3 := saveUpdateDescriptorInDestination(updateDescriptorFileV3, dataStringV3, updateDirectoryPath)
-	fmt.Println(fmt.Sprintf("'%s' has been successfully created in '%s'.", constant.UPDATE_DESCRIPTOR_V3_FILE,
+	util.PrintMessage(fmt.Sprintf("'%s' has been successfully created in '%s'.", constant.UPDATE_DESCRIPTOR_V3_FILE,
 		absDestinationV3))
 }
 
@@ -822,24 +1268,72 @@ func downloadFile(directory, urlName, downloadUrl, fileName string) {
 		logger.Debug(fmt.Sprintf("Environment variable '%s' is not set. Getting file from: %s",
 			urlName, downloadUrl))
 	}
-	err := util.DownloadFile(path.Join(directory, fileName), url)
+	err := util.DownloadFile(filepath.Join(directory, fileName), url)
 	if err != nil {
 		util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while getting the file '%v' "+
 			"from: %s.", fileName, url))
 	}
 }
 
+// This function returns the security advisory declared in the given README.txt. An empty string is returned if the
+// README.txt is not a security advisory.
+func getSecurityAdvisory(readMeDataString string) string {
+	if len(readMeDataString) == 0 {
+		return ""
+	}
+	parser := util.GetReadMeParser(readMeDataString)
+	if parser == nil {
+		return ""
+	}
+	return parser.Parse(readMeDataString).SecurityAdvisory
+}
+
+// This function returns the legal resource files required by an update with the given security advisory.
+func getLegalFiles(securityAdvisory string) *util.LegalFiles {
+	return util.GetLegalFiles(securityAdvisory, viper.GetString(constant.LEGAL_FILES_LICENSE_URL),
+		viper.GetString(constant.LEGAL_FILES_SECURITY_LICENSE_URL))
+}
+
+// This function downloads the legal resource files required by an update with the given security advisory to the
+// given directory. A NOT_A_CONTRIBUTION.txt which is not required is removed from the directory.
+func downloadLegalFiles(directory, securityAdvisory string) {
+	legalFiles := getLegalFiles(securityAdvisory)
+	downloadFile(directory, constant.LICENSE_URL, legalFiles.LicenseURL, constant.LICENSE_FILE)
+	if legalFiles.IsNotAContributionRequired {
+		downloadFile(directory, constant.NOT_A_CONTRIBUTION_URL, constant.NOT_A_CONTRIBUTION_DOWNLOAD_URL,
+			constant.NOT_A_CONTRIBUTION_FILE)
+		return
+	}
+	util.CleanUpFile(filepath.Join(directory, constant.NOT_A_CONTRIBUTION_FILE))
+	logger.Debug(fmt.Sprintf("%s is not required as the update is a security update (%s)",
+		constant.NOT_A_CONTRIBUTION_FILE, securityAdvisory))
+}
+
+// This function regenerates the legal resource files in the exploded update directory according to the security
+// advisory declared in the developer edited update-descriptor3.yaml, and checks them against the policy.
+func generateLegalFiles(resumeFile *ResumeFile, updateDescriptorV3 *util.UpdateDescriptorV3) {
+	downloadLegalFiles(resumeFile.ExplodedUpdateDirectoryPath, updateDescriptorV3.SecurityAdvisory)
+	isLicenseFound, err := util.IsFileExists(filepath.Join(resumeFile.ExplodedUpdateDirectoryPath,
+		constant.LICENSE_FILE))
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while checking '%s'.", constant.LICENSE_FILE))
+	isNotAContributionFound, err := util.IsFileExists(filepath.Join(resumeFile.ExplodedUpdateDirectoryPath,
+		constant.NOT_A_CONTRIBUTION_FILE))
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while checking '%s'.",
+		constant.NOT_A_CONTRIBUTION_FILE))
+	err = getLegalFiles(updateDescriptorV3.SecurityAdvisory).Check(isLicenseFound, isNotAContributionFound)
+	util.HandleErrorAndExit(err, "Legal resource files of the update are invalid.")
+}
+
 // This function will handle no match found for a file situations. User input is required and based on the user input,
 // this function will decide how to proceed.
-func handleNoMatch(filename string, isDir bool, allFilesMap map[string]data, rootNode *node,
+func handleNoMatch(filename string, isDir bool, allFilesMap map[string]data, updateRootNode, rootNode *node,
 	updateDescriptor *util.UpdateDescriptorV2) error {
 	//todo: Check OSGi bundles in the plugins directory
 	logger.Debug(fmt.Sprintf("[NO MATCH] %s", filename))
 	util.PrintInBold(fmt.Sprintf("'%s' not found in distribution. ", filename))
 	for {
 		// Get the user preference
-		util.PrintInBold("Do you want to add it as a new file? [Y/n]: ")
-		preference, err := util.GetUserInput()
+		preference, err := util.PromptUser("Do you want to add it as a new file? [Y/n]: ")
 		if len(preference) == 0 {
 			preference = "y"
 		}
@@ -850,12 +1344,13 @@ func handleNoMatch(filename string, isDir bool, allFilesMap map[string]data, roo
 		switch userPreference {
 		case constant.YES:
 			// Handle the file/directory as new
-			err = handleNewFile(filename, isDir, rootNode, allFilesMap, updateDescriptor)
+			err = handleNewFile(filename, isDir, rootNode, updateRootNode, updateDescriptor)
 			util.HandleErrorAndExit(err)
 			//If no error, return nil
 			return nil
 		case constant.NO:
 			util.PrintWarning(fmt.Sprintf("Skipping copying: %s", filename))
+			recordSkippedFiles(filename, isDir, updateRootNode, constant.SKIP_REASON_USER_DECLINED, "")
 			return nil
 		default:
 			util.PrintError("Invalid preference. Enter Y for Yes or N for No.")
@@ -865,22 +1360,49 @@ func handleNoMatch(filename string, isDir bool, allFilesMap map[string]data, roo
 
 // This function will handle the situations where the user want to add a file as a new file which was not found in the
 // distribution.
-func handleNewFile(filename string, isDir bool, rootNode *node, allFilesMap map[string]data,
+func handleNewFile(filename string, isDir bool, rootNode, updateRootNode *node,
 	updateDescriptor *util.UpdateDescriptorV2) error {
 	logger.Debug(fmt.Sprintf("[HANDLE NEW] %s", filename))
 
+	// Destination mapped to the file in the template store is used if the user does not enter a destination
+	mappedDestination, isMapped := templateStore.GetDestination(filename)
+	prompt := "Enter destination directory relative to PRODUCT_HOME: "
+	if isMapped {
+		prompt = fmt.Sprintf("Enter destination directory relative to PRODUCT_HOME [%s]: ", mappedDestination)
+	}
+
 readDestinationLoop:
 	for {
 		// Get user preference
-		util.PrintInBold("Enter destination directory relative to PRODUCT_HOME: ")
-		relativeLocationInDistribution, err := util.GetUserInput()
-		// Trim the path separators at the beginning and the end of the path if present.
-		relativeLocationInDistribution = strings.TrimPrefix(relativeLocationInDistribution,
-			constant.PATH_SEPARATOR)
-		relativeLocationInDistribution = strings.TrimSuffix(relativeLocationInDistribution,
-			constant.PATH_SEPARATOR)
+		relativeLocationInDistribution, err := util.PromptUser(prompt)
+		if isMapped && len(strings.TrimSpace(relativeLocationInDistribution)) == 0 {
+			relativeLocationInDistribution = mappedDestination
+		}
+		// Locations in the distribution are separated by '/'. Trim the path separators at the beginning and the
+		// end of the path if present.
+		relativeLocationInDistribution = strings.Trim(filepath.ToSlash(relativeLocationInDistribution), "/")
 		util.HandleErrorAndExit(err, "Error occurred while getting input from the user.")
 		logger.Debug("relativePath:", relativeLocationInDistribution)
+		// Entered location should not point outside of the PRODUCT_HOME
+		if !util.IsPathInRoot(".", relativeLocationInDistribution) {
+			util.PrintError(fmt.Sprintf("Entered path '%s' is outside of PRODUCT_HOME.",
+				relativeLocationInDistribution))
+			continue
+		}
+		if len(relativeLocationInDistribution) > 0 {
+			relativeLocationInDistribution = path.Clean(relativeLocationInDistribution)
+			if relativeLocationInDistribution == "." {
+				relativeLocationInDistribution = ""
+			}
+		}
+		// Files inside nested archives cannot be updated in place
+		destinationNode := getNode(rootNode, strings.Split(relativeLocationInDistribution, "/"))
+		if destinationNode != nil && getEnclosingArchive(destinationNode) != nil {
+			util.PrintError(fmt.Sprintf("Entered path '%s' is inside the nested archive '%s'. Add the updated "+
+				"archive to the update instead.", relativeLocationInDistribution,
+				getEnclosingArchive(destinationNode).relativeLocation))
+			continue
+		}
 
 		// Get the update root from the viper configs.
 		updateRoot := viper.GetString(constant.UPDATE_ROOT)
@@ -910,7 +1432,7 @@ readDestinationLoop:
 			if isDir {
 				// Get all matching files. By matching files, we mean all the files which are in the
 				// directory and subdirectories.
-				allMatchingFiles := getAllMatchingFiles(filename, allFilesMap)
+				allMatchingFiles := getAllMatchingFiles(filename, updateRootNode)
 				logger.Debug(fmt.Sprintf("All matches: %v", allMatchingFiles))
 				// Copy all matching files to the temp directory
 				for _, match := range allMatchingFiles {
@@ -935,8 +1457,7 @@ readDestinationLoop:
 			util.PrintInBold("Entered relative path does not exist in the distribution. ")
 			for {
 				// Prompt the user
-				util.PrintInBold("Copy anyway? [y/n/R]: ")
-				preference, err := util.GetUserInput()
+				preference, err := util.PromptUser("Copy anyway? [y/n/R]: ")
 				if len(preference) == 0 {
 					preference = "r"
 				}
@@ -948,7 +1469,7 @@ readDestinationLoop:
 					updateRoot := viper.GetString(constant.UPDATE_ROOT)
 					// Get all matching files. By matching files, we mean all the files which are
 					// in the directory and subdirectories.
-					allMatchingFiles := getAllMatchingFiles(filename, allFilesMap)
+					allMatchingFiles := getAllMatchingFiles(filename, updateRootNode)
 					logger.Debug(fmt.Sprintf("Copying all matches:\n%s", allMatchingFiles))
 					// Copy all matching files to the temp directory
 					for _, match := range allMatchingFiles {
@@ -961,6 +1482,8 @@ readDestinationLoop:
 					break readDestinationLoop
 				case constant.NO:
 					util.PrintWarning("Skipping copying", filename)
+					recordSkippedFiles(filename, isDir, updateRootNode, constant.SKIP_REASON_USER_DECLINED,
+						relativeLocationInDistribution)
 					return nil
 				case constant.REENTER:
 					continue readDestinationLoop
@@ -974,7 +1497,7 @@ readDestinationLoop:
 			updateRoot := viper.GetString(constant.UPDATE_ROOT)
 			// Get all matching files. By matching files, we mean all the files which are in the directory
 			// and subdirectories.
-			allMatchingFiles := getAllMatchingFiles(filename, allFilesMap)
+			allMatchingFiles := getAllMatchingFiles(filename, updateRootNode)
 			logger.Debug(fmt.Sprintf("Copying all matches:\n%s", allMatchingFiles))
 			// Copy all matching files to the temp directory
 			for _, match := range allMatchingFiles {
@@ -991,14 +1514,14 @@ readDestinationLoop:
 }
 
 // This function will situations where a single match is found in the distribution.
-func handleSingleMatch(filename string, matchingNode *node, isDir bool, allFilesMap map[string]data, rootNode *node,
-	updateDescriptor *util.UpdateDescriptorV2) error {
+func handleSingleMatch(filename string, matchingNode *node, isDir bool, allFilesMap map[string]data,
+	updateRootNode, rootNode *node, updateDescriptor *util.UpdateDescriptorV2) error {
 	logger.Debug(fmt.Sprintf("[SINGLE MATCH] %s ; match: %s", filename, matchingNode.relativeLocation))
 	updateRoot := viper.GetString(constant.UPDATE_ROOT)
 	if isDir {
 		// If we are processing a directory, get all matching files. By matching files, we mean all the files
 		// which are in the directory and subdirectories.
-		allMatchingFiles := getAllMatchingFiles(filename, allFilesMap)
+		allMatchingFiles := getAllMatchingFiles(filename, updateRootNode)
 		logger.Debug(fmt.Sprintf("All matches: %s", allMatchingFiles))
 		// Copy all matching files to the temp directory
 		for _, match := range allMatchingFiles {
@@ -1006,13 +1529,14 @@ func handleSingleMatch(filename string, matchingNode *node, isDir bool, allFiles
 			// Check md5 only if the md5 checking is not disabled
 			if !viper.GetBool(constant.CHECK_MD5_DISABLED) {
 				logger.Debug(fmt.Sprintf("Checking md5: %v", filename))
-				data := allFilesMap[match]
 				// Check whether the md5 matches or not
 				fileLocation := path.Join(matchingNode.relativeLocation, match)
-				md5Matches := CheckMD5(rootNode, strings.Split(fileLocation, "/"), data.md5)
+				md5Matches, err := checkUpdateFileMD5(rootNode, strings.Split(fileLocation, "/"), allFilesMap, match)
+				util.HandleErrorAndExit(err)
 				if md5Matches {
-					util.PrintInfo(fmt.Sprintf("File '%v' not copied because MD5 matches with "+
+					recordAutomaticDecision(fmt.Sprintf("File '%v' not copied because MD5 matches with "+
 						"the already existing file.", match))
+					recordSkippedFile(match, constant.SKIP_REASON_MD5_MATCHES, matchingNode.relativeLocation)
 					logger.Debug("MD5 matches. Ignoring file.")
 					continue
 				} else {
@@ -1022,6 +1546,10 @@ func handleSingleMatch(filename string, matchingNode *node, isDir bool, allFiles
 			// Copy the file to temp directory
 			logger.Debug(fmt.Sprintf("[Copy] %s ; From: %s ; To: %s", match, updateRoot,
 				matchingNode.relativeLocation))
+			if isAutoModeEnabled {
+				recordAutomaticDecision(fmt.Sprintf("File '%v' copied to '%v' which is the only match.", match,
+					matchingNode.relativeLocation))
+			}
 			err := copyFile(match, updateRoot, matchingNode.relativeLocation, rootNode, updateDescriptor)
 			util.HandleErrorAndExit(err)
 		}
@@ -1029,13 +1557,15 @@ func handleSingleMatch(filename string, matchingNode *node, isDir bool, allFiles
 		// Check md5 only if the md5 checking is not disabled
 		if !viper.GetBool(constant.CHECK_MD5_DISABLED) {
 			logger.Debug(fmt.Sprintf("Checking md5: %v", filename))
-			data := allFilesMap[filename]
 			// Check whether the md5 matches or not
 			fileLocation := path.Join(matchingNode.relativeLocation, filename)
-			md5Matches := CheckMD5(rootNode, strings.Split(fileLocation, "/"), data.md5)
+			md5Matches, err := checkUpdateFileMD5(rootNode, strings.Split(fileLocation, "/"), allFilesMap,
+				filename)
+			util.HandleErrorAndExit(err)
 			if md5Matches {
-				util.PrintInfo(fmt.Sprintf("File '%v' not copied because MD5 matches with the "+
+				recordAutomaticDecision(fmt.Sprintf("File '%v' not copied because MD5 matches with the "+
 					"already existing file.", filename))
+				recordSkippedFile(filename, constant.SKIP_REASON_MD5_MATCHES, matchingNode.relativeLocation)
 				logger.Debug("MD5 matches. Ignoring file.")
 				// If md5 does not match, return
 				return nil
@@ -1046,6 +1576,10 @@ func handleSingleMatch(filename string, matchingNode *node, isDir bool, allFiles
 		// Copy the file to temp directory
 		logger.Debug(fmt.Sprintf("[Copy] %s ; From: %s ; To: %s", filename, updateRoot,
 			matchingNode.relativeLocation))
+		if isAutoModeEnabled {
+			recordAutomaticDecision(fmt.Sprintf("File '%v' copied to '%v' which is the only match.", filename,
+				matchingNode.relativeLocation))
+		}
 		err := copyFile(filename, updateRoot, matchingNode.relativeLocation, rootNode,
 			updateDescriptor)
 		util.HandleErrorAndExit(err)
@@ -1053,23 +1587,83 @@ func handleSingleMatch(filename string, matchingNode *node, isDir bool, allFiles
 	return nil
 }
 
+// This function records a decision which was taken without prompting the user. In the auto mode, the decision is
+// printed in the summary after matching the files. Otherwise it is printed immediately.
+func recordAutomaticDecision(decision string) {
+	if isAutoModeEnabled {
+		automaticDecisions = append(automaticDecisions, decision)
+		return
+	}
+	util.PrintInfo(decision)
+}
+
+// This function records the given file of the update directory, which is not copied to the update, with the reason.
+// The location is the location in the distribution which the file was matched with, if any.
+func recordSkippedFile(filename, reason, location string) {
+	logger.Debug(fmt.Sprintf("[SKIPPED] %s ; reason: %s ; location: %s", filename, reason, location))
+	skippedFiles = append(skippedFiles, util.SkippedFile{
+		Path:     filename,
+		Reason:   reason,
+		Location: location,
+	})
+}
+
+// This function records the given file, or all files in the given directory, of the update directory as skipped.
+func recordSkippedFiles(filename string, isDir bool, updateRootNode *node, reason, location string) {
+	if !isDir {
+		recordSkippedFile(filename, reason, location)
+		return
+	}
+	for _, match := range getAllMatchingFiles(filename, updateRootNode) {
+		recordSkippedFile(match, reason, location)
+	}
+}
+
+// This function prints the summary of the decisions which were taken without prompting the user in the auto mode.
+func printAutomaticDecisions() {
+	if !isAutoModeEnabled {
+		return
+	}
+	util.PrintInBold(fmt.Sprintf("\n%d decision(s) were taken automatically:\n", len(automaticDecisions)))
+	for _, decision := range automaticDecisions {
+		util.PrintMessage("\t" + decision)
+	}
+	util.PrintMessage()
+	// Decisions of the next update of a batch should not be mixed with these
+	automaticDecisions = nil
+}
+
+// This function publishes the file matched event with the number of matches found in the distribution.
+func publishFileMatchedEvent(name string, isDir bool, matches map[string]*node) {
+	util.PublishEvent(constant.EVENT_FILE_MATCHED, constant.STAGE_MATCH_FILES, name, map[string]string{
+		"isDir":   strconv.FormatBool(isDir),
+		"matches": strconv.Itoa(len(matches)),
+	})
+}
+
 // This function will handle multiple match situations. In here user input is required.
 func handleMultipleMatches(filename string, isDir bool, matches map[string]*node, allFilesMap map[string]data,
-	rootNode *node, updateDescriptor *util.UpdateDescriptorV2) error {
+	updateRootNode, rootNode *node, updateDescriptor *util.UpdateDescriptorV2) error {
 
 	util.PrintInfo(fmt.Sprintf("Multiple matches found for '%s' in the distribution.", filename))
 
 	logger.Debug(fmt.Sprintf("[MULTIPLE MATCHES] %s", filename))
 	locationTable, indexMap := generateLocationTable(filename, matches)
-	locationTable.Render()
+	// In JSON IO mode, the locations are sent as the options of the prompt instead of rendering the table
+	var options []string
+	if util.IsJSONIOEnabled() {
+		options = getLocationOptions(filename, indexMap)
+	} else {
+		locationTable.Render()
+	}
 	logger.Debug(fmt.Sprintf("indexMap: %s", indexMap))
 	skipCopying := false
 	var selectedIndices []string
 	// Loop while user enter valid preference or enter 0 to exit
 	for {
 		// Get user preference
-		util.PrintInBold("Enter preference(s)[Multiple selections separated by commas, 0 to skip copying]: ")
-		preferences, err := util.GetUserInput()
+		preferences, err := util.PromptUserWithOptions("Enter preference(s)[Multiple selections separated by "+
+			"commas, 0 to skip copying]: ", options)
 		util.HandleErrorAndExit(err)
 		logger.Debug(fmt.Sprintf("preferences: %s", preferences))
 		// Remove the new line at the end
@@ -1103,6 +1697,7 @@ func handleMultipleMatches(filename string, isDir bool, matches map[string]*node
 	if skipCopying {
 		logger.Debug(fmt.Sprintf("Skipping copying '%s'", filename))
 		util.PrintWarning(fmt.Sprintf("0 entered. Skipping copying '%s'.", filename))
+		recordSkippedFiles(filename, isDir, updateRootNode, constant.SKIP_REASON_USER_DECLINED, "")
 		return nil
 	}
 	updateRoot := viper.GetString(constant.UPDATE_ROOT)
@@ -1114,7 +1709,7 @@ func handleMultipleMatches(filename string, isDir bool, matches map[string]*node
 				pathInDistribution))
 
 			// Get all matching files (files which are in the directory and subdirectories)
-			allMatchingFiles := getAllMatchingFiles(filename, allFilesMap)
+			allMatchingFiles := getAllMatchingFiles(filename, updateRootNode)
 			logger.Debug(fmt.Sprintf("matchingFiles: %s", allMatchingFiles))
 
 			// Copy all the matching files to temp directory
@@ -1122,13 +1717,14 @@ func handleMultipleMatches(filename string, isDir bool, matches map[string]*node
 				logger.Debug(fmt.Sprintf("match: %s", match))
 				// Check md5 if the md5 checking is not disabled
 				if !viper.GetBool(constant.CHECK_MD5_DISABLED) {
-					data := allFilesMap[match]
 					// Check whether the md5 matches or not
 					fileLocation := strings.Split(path.Join(pathInDistribution, match), "/")
-					md5Matches := CheckMD5(rootNode, fileLocation, data.md5)
+					md5Matches, err := checkUpdateFileMD5(rootNode, fileLocation, allFilesMap, match)
+					util.HandleErrorAndExit(err)
 					if md5Matches {
-						util.PrintInfo(fmt.Sprintf("File '%v' not copied because MD5 "+
+						recordAutomaticDecision(fmt.Sprintf("File '%v' not copied because MD5 "+
 							"matches with the already existing file.", match))
+						recordSkippedFile(match, constant.SKIP_REASON_MD5_MATCHES, pathInDistribution)
 						logger.Debug("MD5 matches. Ignoring file.")
 						continue
 					}
@@ -1146,15 +1742,16 @@ func handleMultipleMatches(filename string, isDir bool, matches map[string]*node
 			pathInDistribution := indexMap[selectedIndex]
 			// Check md5 if the md5 checking is not disabled
 			if !viper.GetBool(constant.CHECK_MD5_DISABLED) {
-				data := allFilesMap[filename]
 				// Check whether the md5 matches or not
 				fileLocation := strings.Split(path.Join(pathInDistribution, filename), "/")
-				md5Matches := CheckMD5(rootNode, fileLocation, data.md5)
+				md5Matches, err := checkUpdateFileMD5(rootNode, fileLocation, allFilesMap, filename)
+				util.HandleErrorAndExit(err)
 				if md5Matches {
 					// If md5 matches, print warning msg and continue with the next selected
 					// location
-					util.PrintInfo(fmt.Sprintf("File '%v' not copied because MD5 matches "+
+					recordAutomaticDecision(fmt.Sprintf("File '%v' not copied because MD5 matches "+
 						"with the already existing file.", filename))
+					recordSkippedFile(filename, constant.SKIP_REASON_MD5_MATCHES, pathInDistribution)
 					logger.Debug("MD5 matches. Ignoring file.")
 					continue
 				}
@@ -1173,19 +1770,42 @@ func handleMultipleMatches(filename string, isDir bool, matches map[string]*node
 }
 
 // This function will return all matching files (all files in a directory and subdirectories) of the given filepath.
-func getAllMatchingFiles(path string, allFilesMap map[string]data) []string {
+// Only the subtree of the given directory in the tree of the update directory is traversed, so the cost does not grow
+// with the number of files in the other directories of the update.
+func getAllMatchingFiles(path string, updateRootNode *node) []string {
 	matches := make([]string, 0)
-	for filePath, data := range allFilesMap {
-		// Should not be a directory. Should have the path prefix (identifying that it is in the directory)
-		// filePath != path because it should only return files within the provided directory. otherwise a file
-		// can be matched if it has the same path as the given path.
-		if !data.isDir && strings.HasPrefix(filePath, path) && filePath != path {
-			matches = append(matches, filePath)
-		}
+	directoryNode := getNode(updateRootNode, strings.Split(path, "/"))
+	if directoryNode == nil || !directoryNode.isDir {
+		return matches
 	}
+	matches = getFilesInNode(directoryNode, matches)
+	sort.Strings(matches)
 	return matches
 }
 
+// This function appends the relative locations of all files in the given node and its sub directories to the given
+// slice.
+func getFilesInNode(root *node, files []string) []string {
+	for _, childNode := range root.childNodes {
+		if childNode.isDir {
+			files = getFilesInNode(childNode, files)
+		} else {
+			files = append(files, childNode.relativeLocation)
+		}
+	}
+	return files
+}
+
+// This function creates the tree of the update directory from the given details of all files in the directory. The
+// tree is used to find the files in a directory without scanning all the files.
+func getUpdateRootNode(allFilesMap map[string]data) node {
+	updateRootNode := createNewNode()
+	for relativePath, data := range allFilesMap {
+		AddToRootNode(&updateRootNode, strings.Split(relativePath, "/"), data.isDir, data.hash)
+	}
+	return updateRootNode
+}
+
 // This function will read the directory in the given location and return 3 values and an error if any exists.
 func readDirectory(root string, ignoredFiles map[string]bool) (map[string]data, map[string]bool, map[string]bool,
 	error) {
@@ -1194,7 +1814,8 @@ func readDirectory(root string, ignoredFiles map[string]bool) (map[string]data,
 	rootLevelFilesMap := make(map[string]bool)
 
 	// Walk and read the directory structure
-	filepath.Walk(root, func(absolutePath string, fileInfo os.FileInfo, err error) error {
+	slashRoot := filepath.ToSlash(root)
+	afero.Walk(util.FileSystem, root, func(absolutePath string, fileInfo os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
@@ -1202,22 +1823,25 @@ func readDirectory(root string, ignoredFiles map[string]bool) (map[string]data,
 		absolutePath = filepath.ToSlash(absolutePath)
 
 		//Ignore root directory
-		if root == absolutePath {
+		if slashRoot == absolutePath {
 			return nil
 		}
 		logger.Trace(fmt.Sprintf("[WALK] %s ; %v", absolutePath, fileInfo.IsDir()))
 		//check current file in ignored files map. This is useful to ignore update-descriptor.yaml, etc in
 		// update directory
 		if ignoredFiles != nil {
-			_, found := ignoredFiles[fileInfo.Name()]
-			if found {
+			if isIgnored, isReported := isIgnoredFileInUpdate(fileInfo.Name(), ignoredFiles); isIgnored {
+				if isReported {
+					util.PrintInfo(fmt.Sprintf("'%s' is not added to the update as it is ignored for matching "+
+						"(%s).", absolutePath, constant.IGNORED_FILES_MATCHING))
+				}
 				return nil
 			}
 		}
 		// Get the relative path. This is used as the key of the map
-		trimPattern := root + "/"
-		if strings.HasSuffix(root, "/") {
-			trimPattern = root
+		trimPattern := slashRoot + "/"
+		if strings.HasSuffix(slashRoot, "/") {
+			trimPattern = slashRoot
 		}
 
 		relativePath := strings.TrimPrefix(absolutePath, trimPattern)
@@ -1229,9 +1853,9 @@ func readDirectory(root string, ignoredFiles map[string]bool) (map[string]data,
 		if fileInfo.IsDir() {
 			logger.Trace(fmt.Sprintf("Directory: %s , %s", absolutePath, fileInfo.Name()))
 			info.isDir = true
-			logger.Debug(fmt.Sprintf("Checking: %s == %s", path.Join(root, fileInfo.Name()), absolutePath))
+			logger.Debug(fmt.Sprintf("Checking: %s == %s", path.Join(slashRoot, fileInfo.Name()), absolutePath))
 			// We need to only get the list of directories in the root level. Ignore other directories
-			if path.Join(root, fileInfo.Name()) == absolutePath {
+			if path.Join(slashRoot, fileInfo.Name()) == absolutePath {
 				logger.Debug(fmt.Sprintf("Paths are eqal. Adding %s to rootLevelDirectoriesMap",
 					fileInfo.Name()))
 				// Add the entry to the rootLevelDirectoriesMap
@@ -1239,20 +1863,15 @@ func readDirectory(root string, ignoredFiles map[string]bool) (map[string]data,
 			}
 		} else {
 			// We need to only get the list of files in the root level. Ignore other files
-			if path.Join(root, fileInfo.Name()) == absolutePath {
+			if path.Join(slashRoot, fileInfo.Name()) == absolutePath {
 				rootLevelFilesMap[fileInfo.Name()] = false
 			}
 
-			// We need other information like md5 sum because we are storing details of all files in the
-			// allFilesMap
-			logger.Trace("[MD5] Calculating MD5")
-			//If it is a file, calculate md5 sum
-			md5Sum, err := util.GetMD5(absolutePath)
-			if err != nil {
-				return err
-			}
-			logger.Trace(fmt.Sprintf("%s : %s = %s", absolutePath, fileInfo.Name(), md5Sum))
-			info.md5 = md5Sum
+			// We need other information like the size because we are storing details of all files in the
+			// allFilesMap. The md5 sum is calculated later only if it is needed.
+			logger.Trace(fmt.Sprintf("%s : %s = %d bytes", absolutePath, fileInfo.Name(), fileInfo.Size()))
+			info.absolutePath = absolutePath
+			info.size = fileInfo.Size()
 			info.isDir = false
 		}
 		// Add the entry to the allFilesMap
@@ -1262,66 +1881,305 @@ func readDirectory(root string, ignoredFiles map[string]bool) (map[string]data,
 	return allFilesMap, rootLevelDirectoriesMap, rootLevelFilesMap, nil
 }
 
-// This function will read the zip file in the given location.
-func readZip(location string) (node, error) {
-	rootNode := createNewNode()
-	fileMap := make(map[string]bool)
-	// Create a reader out of the zip archive
-	zipReader, err := zip.OpenReader(location)
+// This function will read the distribution in the given location. The distribution is opened with the distribution
+// source which provides it (zip, directory, etc).
+func readDistribution(location string) (node, error) {
+	distributionFS, closer, err := util.OpenDistribution(location)
 	if err != nil {
-		return rootNode, err
+		return createNewNode(), err
 	}
-	defer zipReader.Close()
+	defer closer.Close()
+	return readDistributionFS(distributionFS)
+}
+
+// This function will build the tree of the distribution in the given file system. Paths of the file system are
+// relative to the distribution root.
+func readDistributionFS(distributionFS fs.FS) (node, error) {
+	rootNode := createNewNode()
 
 	productName := viper.GetString(constant.PRODUCT_NAME)
 	logger.Debug(fmt.Sprintf("productName: %s", productName))
-	// Iterate through each file in the zip file
-	for _, file := range zipReader.Reader.File {
-		zippedFile, err := file.Open()
+	// Read the paths which are declared as non-updatable
+	var err error
+	distributionIgnoredPaths, err = util.ReadDistributionIgnoreManifest(distributionFS)
+	if err != nil {
+		return rootNode, err
+	}
+	// Directories are given the hash of empty content
+	directoryHash, err := getHash(bytes.NewReader(nil))
+	if err != nil {
+		return rootNode, err
+	}
+	// Walk through each file in the distribution. Directories are added to the tree while walking and the files are
+	// hashed afterwards in parallel.
+	var files []string
+	var fileInfos []fs.FileInfo
+	err = fs.WalkDir(distributionFS, ".", func(relativePath string, entry fs.DirEntry, err error) error {
+		if err != nil || relativePath == "." {
+			return err
+		}
+		if util.IsIgnoredPath(relativePath, distributionIgnoredPaths) {
+			logger.Trace(fmt.Sprintf("Ignoring %s as it is declared in %s", relativePath, constant.WUM_IGNORE_FILE))
+			if entry.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		logger.Trace(fmt.Sprintf("relativePath: %s", relativePath))
+		if entry.IsDir() {
+			AddToRootNode(&rootNode, strings.Split(relativePath, "/"), true, directoryHash)
+			return nil
+		}
+		fileInfo, err := entry.Info()
 		if err != nil {
-			return rootNode, err
+			return err
 		}
-		data, err := ioutil.ReadAll(zippedFile)
-		// Don't use defer here because otherwise there will be too many open files and it will cause a panic
-		zippedFile.Close()
+		files = append(files, relativePath)
+		fileInfos = append(fileInfos, fileInfo)
+		return nil
+	})
+	if err != nil {
+		return rootNode, err
+	}
+	// Files are streamed through the hash so that large files are not kept in the memory
+	hashes := make([]string, len(files))
+	workers := viper.GetInt(constant.DISTRIBUTION_WORKERS)
+	errs := util.RunInParallel(len(files), workers, func(index int) error {
+		file, err := distributionFS.Open(files[index])
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		hashes[index], err = getHash(file)
+		return err
+	})
+	if err = util.GetFirstError(errs); err != nil {
+		return rootNode, err
+	}
+	// Files are added to the tree in the order they are walked so that the tree does not depend on the scheduling of
+	// the workers
+	for index, relativePath := range files {
+		AddToRootNode(&rootNode, strings.Split(relativePath, "/"), false, hashes[index])
+		// Sizes and checksums which are available without reading the files (eg: in zip headers) are used to
+		// compare the files quickly
+		if size, checksum, found := util.GetDistributionFileChecksum(fileInfos[index]); found {
+			setEntryChecksum(&rootNode, relativePath, size, checksum)
+		}
+		// Read the content of the nested archive as well if descending into nested archives is enabled. Nested
+		// archives are read again here so that only one of them is buffered at a time.
+		if viper.GetBool(constant.NESTED_ARCHIVES_DESCEND) && isNestedArchive(relativePath) {
+			if err = readNestedArchive(&rootNode, distributionFS, relativePath, fileInfos[index].Size()); err != nil {
+				return rootNode, err
+			}
+		}
+	}
+	return rootNode, nil
+}
 
-		// Calculate the md5 of the file
-		hash := md5.New()
-		hash.Write(data)
-		md5Hash := hex.EncodeToString(hash.Sum(nil))
+// This function buffers the nested archive in the given path of the distribution and adds its content to the tree.
+func readNestedArchive(rootNode *node, distributionFS fs.FS, relativePath string, size int64) error {
+	file, err := distributionFS.Open(relativePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	content, err := util.BufferContent(file, size, getMaxMemory())
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+	return addNestedArchiveToRootNode(rootNode, relativePath, content, content.Size)
+}
 
-		// Get the relative path of the file
-		logger.Trace(fmt.Sprintf("file.Name: %s", file.Name))
+// This function returns the hash of the content read from the given reader using the configured hash algorithm.
+func getHash(reader io.Reader) (string, error) {
+	return util.GetChecksumOfReader(reader, viper.GetString(constant.HASH_ALGORITHM))
+}
 
-		relativePath := util.GetRelativePath(file)
+// This function returns the maximum memory (in bytes) used to buffer a nested archive.
+func getMaxMemory() int64 {
+	return viper.GetInt64(constant.DISTRIBUTION_MAX_MEMORY) * constant.BYTES_PER_MEGA_BYTE
+}
 
-		// Add the file to root node
-		AddToRootNode(&rootNode, strings.Split(relativePath, "/"), file.FileInfo().IsDir(), md5Hash)
-		if !file.FileInfo().IsDir() {
-			fileMap[relativePath] = false
+// This function checks whether the file in the given path matches one of the configured nested archive patterns.
+func isNestedArchive(relativePath string) bool {
+	fileName := path.Base(relativePath)
+	for _, pattern := range viper.GetStringSlice(constant.NESTED_ARCHIVES_PATTERNS) {
+		matches, err := path.Match(pattern, fileName)
+		if err != nil {
+			logger.Debug(fmt.Sprintf("Invalid nested archive pattern '%s': %v", pattern, err))
+			continue
+		}
+		if matches {
+			return true
 		}
 	}
-	return rootNode, nil
+	return false
 }
 
-// This function will add a new node.
-func AddToRootNode(root *node, path []string, isDir bool, md5Hash string) *node {
-	logger.Trace("Checking: %s : %s", path[0], path)
+// This function will read the given nested archive and add its content under the node of the archive. Paths of the
+// inner files will be relative to the distribution root with the archive name as a path element
+// (eg: repository/deployment/server/webapps/api.war/WEB-INF/web.xml). Archives inside the nested archive are also read
+// if they match one of the configured patterns.
+func addNestedArchiveToRootNode(rootNode *node, archivePath string, content io.ReaderAt, size int64) error {
+	logger.Debug(fmt.Sprintf("Reading nested archive: %s", archivePath))
+	zipReader, err := zip.NewReader(content, size)
+	if err != nil {
+		// Some files might have an archive extension without being valid archives. We don't want to fail the whole
+		// process because of that.
+		logger.Debug(fmt.Sprintf("Error occurred while reading nested archive '%s': %v", archivePath, err))
+		return nil
+	}
+	// Mark the node of the archive so that matches will be searched inside it
+	archiveNode := getNode(rootNode, strings.Split(archivePath, "/"))
+	if archiveNode == nil {
+		return errors.New(fmt.Sprintf("node for the nested archive '%s' not found", archivePath))
+	}
+	archiveNode.isArchive = true
+	directoryHash, err := getHash(bytes.NewReader(nil))
+	if err != nil {
+		return err
+	}
 
-	// If the current path element is the last element, add it as a new node.
-	if len(path) == 1 {
-		logger.Trace("End reached")
-		newNode := createNewNode()
-		newNode.name = path[0]
-		newNode.isDir = isDir
-		newNode.md5Hash = md5Hash
-		if len(root.relativeLocation) == 0 {
-			newNode.relativeLocation = path[0]
-		} else {
-			newNode.relativeLocation = root.relativeLocation + "/" + path[0]
+	for _, file := range zipReader.File {
+		innerPath := strings.TrimSuffix(file.Name, "/")
+		if len(innerPath) == 0 {
+			continue
 		}
-		newNode.parent = root
-		root.childNodes[path[0]] = &newNode
+		relativePath := archivePath + "/" + innerPath
+		logger.Trace(fmt.Sprintf("Nested archive entry: %s", relativePath))
+		if file.FileInfo().IsDir() {
+			AddToRootNode(rootNode, strings.Split(relativePath, "/"), true, directoryHash)
+			continue
+		}
+		if err = addNestedArchiveEntryToRootNode(rootNode, relativePath, file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// This function adds the given file of a nested archive to the tree. The file is streamed through the hash unless it
+// is a nested archive itself, in which case it is buffered to read its content as well.
+func addNestedArchiveEntryToRootNode(rootNode *node, relativePath string, file *zip.File) error {
+	zippedFile, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer zippedFile.Close()
+	if !isNestedArchive(relativePath) {
+		fileHash, err := getHash(zippedFile)
+		if err != nil {
+			return err
+		}
+		AddToRootNode(rootNode, strings.Split(relativePath, "/"), false, fileHash)
+		setZipEntryChecksum(rootNode, relativePath, file)
+		return nil
+	}
+	hash, err := util.NewChecksumHash(viper.GetString(constant.HASH_ALGORITHM))
+	if err != nil {
+		return err
+	}
+	content, err := util.BufferContent(io.TeeReader(zippedFile, hash), int64(file.UncompressedSize64),
+		getMaxMemory())
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+	AddToRootNode(rootNode, strings.Split(relativePath, "/"), false, hex.EncodeToString(hash.Sum(nil)))
+	setZipEntryChecksum(rootNode, relativePath, file)
+	return addNestedArchiveToRootNode(rootNode, relativePath, content, content.Size)
+}
+
+// This function sets the size and the CRC32 checksum of the given zip entry, which are available in the zip headers,
+// in the node of the entry.
+func setZipEntryChecksum(rootNode *node, relativePath string, file *zip.File) {
+	setEntryChecksum(rootNode, relativePath, int64(file.UncompressedSize64), file.CRC32)
+}
+
+// This function sets the given size and CRC32 checksum in the node of the file in the given path.
+func setEntryChecksum(rootNode *node, relativePath string, size int64, checksum uint32) {
+	entryNode := getNode(rootNode, strings.Split(relativePath, "/"))
+	if entryNode == nil || entryNode.isDir {
+		return
+	}
+	entryNode.size = size
+	entryNode.crc32 = checksum
+	entryNode.hasCRC32 = true
+}
+
+// This function virtually applies the released updates in the given directory, which are prior to the update being
+// created, to the distribution tree. Added and modified files of the updates replace the files in the tree and the
+// removed files are removed from the tree.
+func applyReleasedUpdates(rootNode *node, appliedUpdatesDirectory string, updateDescriptorV2 *util.UpdateDescriptorV2) {
+	appliedUpdates, err := util.ReadAppliedUpdates(appliedUpdatesDirectory, updateDescriptorV2.PlatformVersion,
+		updateDescriptorV2.UpdateNumber, viper.GetString(constant.HASH_ALGORITHM))
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while reading the updates in '%s'.",
+		appliedUpdatesDirectory))
+	for _, appliedUpdate := range appliedUpdates {
+		util.PrintInfo(fmt.Sprintf("Applying %s to the distribution.", appliedUpdate.UpdateName))
+		applyReleasedUpdate(rootNode, &appliedUpdate)
+	}
+}
+
+// This function applies the changes of the given released update to the distribution tree.
+func applyReleasedUpdate(rootNode *node, appliedUpdate *util.AppliedUpdate) {
+	for relativePath, hash := range appliedUpdate.Files {
+		path := strings.Split(relativePath, "/")
+		// Nodes of nested archives have child nodes, so the existing node is updated instead of being replaced
+		if existingNode := getNode(rootNode, path); existingNode != nil {
+			existingNode.hash = hash
+			// Only the hashes of the files in released updates are known
+			existingNode.hasCRC32 = false
+		} else {
+			AddToRootNode(rootNode, path, false, hash)
+		}
+		logger.Trace(fmt.Sprintf("%s of %s applied", relativePath, appliedUpdate.UpdateName))
+	}
+	for _, removedFile := range appliedUpdate.RemovedFiles {
+		if existingNode := getNode(rootNode, strings.Split(removedFile, "/")); existingNode != nil {
+			delete(existingNode.parent.childNodes, existingNode.name)
+			logger.Trace(fmt.Sprintf("%s removed by %s", removedFile, appliedUpdate.UpdateName))
+		}
+	}
+}
+
+// This function returns the node in the given path. If a node is not found, nil will be returned.
+func getNode(rootNode *node, path []string) *node {
+	childNode, found := rootNode.childNodes[path[0]]
+	if !found {
+		return nil
+	}
+	if len(path) > 1 {
+		return getNode(childNode, path[1:])
+	}
+	return childNode
+}
+
+// This function will add a new node. Nodes of the parent directories are created if they are not in the tree, so the
+// tree is correct even if the zip does not have directory entries or has them after the files in the directory.
+func AddToRootNode(root *node, path []string, isDir bool, hash string) *node {
+	logger.Trace("Checking: %s : %s", path[0], path)
+
+	// If the current path element is the last element, add it as a new node.
+	if len(path) == 1 {
+		logger.Trace("End reached")
+		// If the node is already in the tree as a parent directory, keep it so that its child nodes are not lost
+		if existingNode, contains := root.childNodes[path[0]]; contains && len(existingNode.childNodes) != 0 {
+			logger.Trace(fmt.Sprintf("Directory node already exists: %v", path[0]))
+			return root
+		}
+		newNode := createNewNode()
+		newNode.name = path[0]
+		newNode.isDir = isDir
+		newNode.hash = hash
+		if len(root.relativeLocation) == 0 {
+			newNode.relativeLocation = path[0]
+		} else {
+			newNode.relativeLocation = root.relativeLocation + "/" + path[0]
+		}
+		newNode.parent = root
+		root.childNodes[path[0]] = &newNode
 	} else {
 		// If there are more path elements than 1, that means we are currently processing a directory.
 		logger.Trace(fmt.Sprintf("End not reached. checking: %v", path[0]))
@@ -1340,9 +2198,14 @@ func AddToRootNode(root *node, path []string, isDir bool, md5Hash string) *node
 			newNode.parent = root
 			root.childNodes[path[0]] = &newNode
 			node = &newNode
+		} else if !node.isDir && !node.isArchive {
+			// A node which has child nodes is a directory even if it was added as a file. Nested archives have the
+			// content of the archive in the child nodes, but they are still files of the distribution.
+			node.isDir = true
+			node.hash = ""
 		}
 		// Recursively call the function for the rest of the path elements.
-		AddToRootNode(node, path[1:], isDir, md5Hash)
+		AddToRootNode(node, path[1:], isDir, hash)
 	}
 	return root
 }
@@ -1350,7 +2213,7 @@ func AddToRootNode(root *node, path []string, isDir bool, md5Hash string) *node
 // This function is a helper function which calls NodeExists() and checks whether a node exists in the given path and
 // the type(file/dir) is correct.
 func PathExists(rootNode *node, relativePath string, isDir bool) bool {
-	return NodeExists(rootNode, strings.Split(relativePath, "/"), isDir)
+	return NodeExists(rootNode, strings.Split(strings.TrimSuffix(relativePath, "/"), "/"), isDir)
 }
 
 // This function checks whether a node exists in the given path and the type(file/dir) is correct.
@@ -1374,20 +2237,20 @@ func NodeExists(rootNode *node, path []string, isDir bool) bool {
 	return false
 }
 
-// This function will check the MD5 hash of the file in the provided path in the distribution with the provided hash.
-func CheckMD5(rootNode *node, path []string, md5 string) bool {
+// This function will check the hash of the file in the provided path in the distribution with the provided hash.
+func CheckMD5(rootNode *node, path []string, hash string) bool {
 	logger.Trace(fmt.Sprintf("All: %v", rootNode.childNodes))
 	logger.Trace(fmt.Sprintf("Checking: %s", path[0]))
 	childNode, found := rootNode.childNodes[path[0]]
 	// If the path element is found, that means it is in the tree
 	if found {
 		// If there are more path elements than 1, continue recursively. Otherwise check whether it has the
-		// given md5 or not and return.
+		// given hash or not and return.
 		logger.Trace(fmt.Sprintf("%s found", path[0]))
 		if len(path) > 1 {
-			return CheckMD5(childNode, path[1:], md5)
+			return CheckMD5(childNode, path[1:], hash)
 		} else {
-			return childNode.isDir == false && childNode.md5Hash == md5
+			return childNode.isDir == false && childNode.hash == hash
 		}
 	}
 	// If the path element is not found, return false
@@ -1395,6 +2258,49 @@ func CheckMD5(rootNode *node, path []string, md5 string) bool {
 	return false
 }
 
+// This function will check whether the given file in the update directory has the same hash as the file in the
+// provided path in the distribution. Sizes and CRC32 checksums of the files are compared first if they are available
+// in the distribution and the hash of the update file is calculated only if they match. Calculated checksums are stored
+// in the allFilesMap so that they are not calculated again.
+func checkUpdateFileMD5(rootNode *node, path []string, allFilesMap map[string]data, relativePath string) (bool,
+	error) {
+	distributionNode := getNode(rootNode, path)
+	if distributionNode == nil || distributionNode.isDir {
+		return false, nil
+	}
+	fileData := allFilesMap[relativePath]
+	if distributionNode.hasCRC32 {
+		if distributionNode.size != fileData.size {
+			logger.Trace(fmt.Sprintf("Sizes of %s are different: %d != %d", relativePath, fileData.size,
+				distributionNode.size))
+			return false, nil
+		}
+		if fileData.crc32 == nil {
+			crc32Sum, err := util.GetCRC32(fileData.absolutePath)
+			if err != nil {
+				return false, err
+			}
+			fileData.crc32 = &crc32Sum
+			allFilesMap[relativePath] = fileData
+		}
+		if *fileData.crc32 != distributionNode.crc32 {
+			logger.Trace(fmt.Sprintf("CRC32 checksums of %s are different: %08x != %08x", relativePath,
+				*fileData.crc32, distributionNode.crc32))
+			return false, nil
+		}
+	}
+	if len(fileData.hash) == 0 {
+		logger.Trace(fmt.Sprintf("[HASH] Calculating hash of %s", fileData.absolutePath))
+		fileHash, err := util.GetHash(fileData.absolutePath, viper.GetString(constant.HASH_ALGORITHM))
+		if err != nil {
+			return false, err
+		}
+		fileData.hash = fileHash
+		allFilesMap[relativePath] = fileData
+	}
+	return CheckMD5(rootNode, path, fileData.hash), nil
+}
+
 // This function will find all matches in distribution for the provided name.
 func FindMatches(root *node, name string, isDir bool, matches map[string]*node) {
 	// Check whether the given name is in the child nodes
@@ -1406,45 +2312,183 @@ func FindMatches(root *node, name string, isDir bool, matches map[string]*node)
 			matches[root.relativeLocation] = root
 		}
 	}
-	// Regardless of whether the file is found or not, iterate through all sub directories to find all matches
+	// Regardless of whether the file is found or not, iterate through all sub directories (and nested archives if
+	// they were read) to find all matches
 	for _, childNode := range root.childNodes {
-		if childNode.isDir {
+		if childNode.isDir || childNode.isArchive {
 			FindMatches(childNode, name, isDir, matches)
 		}
 	}
 }
 
-// This will return a map of files which would be ignored when reading the update directory.
+// This function returns the outermost nested archive which encloses the given node of the distribution, or nil if the
+// node is not inside a nested archive. The outermost archive is the file which exists in the distribution.
+func getEnclosingArchive(distributionNode *node) *node {
+	var enclosingArchive *node
+	for currentNode := distributionNode; currentNode != nil; currentNode = currentNode.parent {
+		if currentNode.isArchive {
+			enclosingArchive = currentNode
+		}
+	}
+	return enclosingArchive
+}
+
+// This function removes the matches inside nested archives from the given matches and returns them. Files inside
+// nested archives cannot be updated in place, so these matches are only used to compare the update with the
+// distribution and are never offered as locations to copy the update files.
+func removeNestedArchiveMatches(name string, matches map[string]*node) map[string]*node {
+	archiveMatches := make(map[string]*node)
+	for location, match := range matches {
+		if getEnclosingArchive(match) != nil {
+			archiveMatches[location] = match
+			delete(matches, location)
+		}
+	}
+	if len(archiveMatches) != 0 && len(matches) != 0 {
+		util.PrintInfo(fmt.Sprintf("'%s' also matches %s inside the nested archive(s) %s. Files inside nested "+
+			"archives are not updated in place, so those locations are not offered.", name,
+			getSortedLocations(archiveMatches), getEnclosingArchiveLocations(archiveMatches)))
+	}
+	return archiveMatches
+}
+
+// This function handles the files and directories of the update which only match files inside nested archives. They
+// are skipped if they are identical to the files inside the archives. Otherwise the enclosing archive should be
+// updated instead, as files inside nested archives cannot be updated in place.
+func handleNestedArchiveMatches(filename string, isDir bool, archiveMatches map[string]*node,
+	allFilesMap map[string]data, updateRootNode, rootNode *node) error {
+	logger.Debug(fmt.Sprintf("[NESTED ARCHIVE MATCHES] %s ; matches: %v", filename,
+		getSortedLocations(archiveMatches)))
+	files := []string{filename}
+	if isDir {
+		files = getAllMatchingFiles(filename, updateRootNode)
+	}
+	// Files are skipped only if they are identical to the files inside all the matching archives
+	isUnchanged := !viper.GetBool(constant.CHECK_MD5_DISABLED)
+	for location := range archiveMatches {
+		for i := 0; isUnchanged && i < len(files); i++ {
+			// Paths of the files of a directory include the directory
+			fileLocation := path.Join(location, files[i])
+			md5Matches, err := checkUpdateFileMD5(rootNode, strings.Split(fileLocation, "/"), allFilesMap, files[i])
+			if err != nil {
+				return err
+			}
+			isUnchanged = md5Matches
+		}
+	}
+	if !isUnchanged {
+		return util.NewInputError(errors.New(fmt.Sprintf("'%s' only matches %s inside the nested archive(s) %s. "+
+			"Files inside nested archives cannot be updated in place. Add the updated archive to '%s' instead.",
+			filename, getSortedLocations(archiveMatches), getEnclosingArchiveLocations(archiveMatches),
+			viper.GetString(constant.UPDATE_ROOT))))
+	}
+	for _, file := range files {
+		recordAutomaticDecision(fmt.Sprintf("File '%v' not copied because MD5 matches with the file inside the "+
+			"nested archive(s) %v.", file, getEnclosingArchiveLocations(archiveMatches)))
+		recordSkippedFile(file, constant.SKIP_REASON_MD5_MATCHES, "")
+	}
+	return nil
+}
+
+// This function returns the sorted locations of the given matches.
+func getSortedLocations(matches map[string]*node) []string {
+	var locations []string
+	for location := range matches {
+		locations = append(locations, location)
+	}
+	sort.Strings(locations)
+	return locations
+}
+
+// This function returns the sorted locations of the nested archives which enclose the given matches.
+func getEnclosingArchiveLocations(matches map[string]*node) []string {
+	var locations []string
+	for _, match := range matches {
+		archiveLocation := getEnclosingArchive(match).relativeLocation
+		if !util.IsStringIsInSlice(archiveLocation, locations) {
+			locations = append(locations, archiveLocation)
+		}
+	}
+	sort.Strings(locations)
+	return locations
+}
+
+// This will return a map of files which would be ignored when reading the update directory. Key is the file name or
+// a glob pattern and value is whether the user is informed when the file is ignored. Resource files are copied to the
+// update separately, so only the files which are ignored for matching are reported.
 func getIgnoredFilesInUpdate() map[string]bool {
 	filesMap := make(map[string]bool)
+	// Get the files we are going to skip matching and add to the the map
+	for _, file := range util.GetIgnoredFiles(constant.IGNORED_FILES_CREATE, constant.MATCHING) {
+		filesMap[file] = true
+	}
+	if skippedFiles := viper.GetStringSlice(constant.RESOURCE_FILES_SKIP); len(skippedFiles) != 0 {
+		util.PrintWarning(fmt.Sprintf("'%s' is deprecated. Use '%s' instead.", constant.RESOURCE_FILES_SKIP,
+			constant.IGNORED_FILES_MATCHING))
+		for _, file := range skippedFiles {
+			filesMap[file] = true
+		}
+	}
+	// README.txt is read when creating the update descriptors
+	if _, found := filesMap[constant.README_FILE]; found {
+		filesMap[constant.README_FILE] = false
+	}
 	// Get the mandatory resource files and add to the the map
 	for _, file := range viper.GetStringSlice(constant.RESOURCE_FILES_MANDATORY) {
-		filesMap[file] = true
+		filesMap[file] = false
 	}
 	// Get the mandatory optional files and add to the the map
 	for _, file := range viper.GetStringSlice(constant.RESOURCE_FILES_OPTIONAL) {
-		filesMap[file] = true
+		filesMap[file] = false
 	}
-	// Get the files we are going to skip matching and add to the the map
-	for _, file := range viper.GetStringSlice(constant.RESOURCE_FILES_SKIP) {
-		filesMap[file] = true
+	// Get the resource files of all product families and add to the map as the products are not known yet
+	for _, resourceFileSet := range getProductFamilyResourceFiles() {
+		for _, file := range resourceFileSet.Mandatory {
+			filesMap[file] = false
+		}
+		for _, file := range resourceFileSet.Optional {
+			filesMap[file] = false
+		}
 	}
 	return filesMap
 }
 
+// This function checks whether the given file is ignored when reading the update directory and whether the user
+// should be informed about it.
+func isIgnoredFileInUpdate(name string, ignoredFiles map[string]bool) (bool, bool) {
+	if isReported, found := ignoredFiles[name]; found {
+		return true, isReported
+	}
+	for ignoredFile, isReported := range ignoredFiles {
+		if util.IsIgnoredFile(name, []string{ignoredFile}) {
+			return true, isReported
+		}
+	}
+	return false, false
+}
+
 // This will return a map of files which would be copied to the temp directory before creating the update zip. Key is
-// the file name and value is whether the file is mandatory or not.
-func getResourceFiles() map[string]bool {
-	filesMap := make(map[string]bool)
-	// Get the mandatory resource files and add to the the map
-	for _, file := range viper.GetStringSlice(constant.RESOURCE_FILES_MANDATORY) {
-		filesMap[file] = true
+// the file name and value is whether the file is mandatory or not. Resource files of the product families of the
+// given update are added to the default resource files.
+func getResourceFiles(updateDescriptorV3 *util.UpdateDescriptorV3) map[string]bool {
+	defaultResourceFiles := util.ResourceFileSet{
+		Mandatory: viper.GetStringSlice(constant.RESOURCE_FILES_MANDATORY),
+		Optional:  viper.GetStringSlice(constant.RESOURCE_FILES_OPTIONAL),
 	}
-	// Get the mandatory optional files and add to the the map
-	for _, file := range viper.GetStringSlice(constant.RESOURCE_FILES_OPTIONAL) {
-		filesMap[file] = false
+	return util.GetResourceFiles(defaultResourceFiles, getProductFamilyResourceFiles(), updateDescriptorV3)
+}
+
+// This will return the resource files of each product family given in the config. Key is the product family.
+func getProductFamilyResourceFiles() map[string]util.ResourceFileSet {
+	productFamilyResourceFiles := make(map[string]util.ResourceFileSet)
+	for productFamily := range viper.GetStringMap(constant.RESOURCE_FILES_PRODUCT_FAMILIES) {
+		key := constant.RESOURCE_FILES_PRODUCT_FAMILIES + "." + productFamily
+		productFamilyResourceFiles[strings.ToLower(productFamily)] = util.ResourceFileSet{
+			Mandatory: viper.GetStringSlice(key + "." + constant.MANDATORY),
+			Optional:  viper.GetStringSlice(key + "." + constant.OPTIONAL),
+		}
 	}
-	return filesMap
+	return productFamilyResourceFiles
 }
 
 // This function will marshal the update-descriptor.yaml file.
@@ -1459,10 +2503,10 @@ func marshalUpdateDescriptor(updateDescriptorV2 *util.UpdateDescriptorV2) ([]byt
 // This function will save update descriptor to temp directory after modifying the file_changes section.
 func saveUpdateDescriptor(updateDescriptorFilename string, data []byte) error {
 	updateName := viper.GetString(constant.UPDATE_NAME)
-	destination := path.Join(constant.TEMP_DIR, updateName, updateDescriptorFilename)
+	destination := filepath.Join(constant.TEMP_DIR, updateName, updateDescriptorFilename)
 	// Open a new file for writing only
 	file, err := os.OpenFile(
-		destination,
+		util.GetLongPath(destination),
 		os.O_WRONLY|os.O_TRUNC|os.O_CREATE,
 		0600,
 	)
@@ -1482,16 +2526,20 @@ func saveUpdateDescriptor(updateDescriptorFilename string, data []byte) error {
 }
 
 // This function will copy resource files to the temp directory.
-func copyResourceFilesToTempDir(resourceFilesMap map[string]bool) error {
+func copyResourceFilesToTempDir(resourceDirectoryPath string, resourceFilesMap map[string]bool) error {
 	// Create the directories if they are not available
 	updateName := viper.GetString(constant.UPDATE_NAME)
-	destination := path.Join(constant.TEMP_DIR, updateName, constant.CARBON_HOME)
+	destination := filepath.Join(constant.TEMP_DIR, updateName, getPayloadDirectory())
 	util.CreateDirectory(destination)
 	// Iterate through all resource files
 	for filename, isMandatory := range resourceFilesMap {
-		updateRoot := viper.GetString(constant.UPDATE_ROOT)
-		source := path.Join(updateRoot, filename)
-		destination = path.Join(constant.TEMP_DIR, updateName, filename)
+		// Files generated in the resource directory are preferred over the files in the update directory
+		source := filepath.Join(resourceDirectoryPath, filename)
+		if exists, _ := util.IsFileExists(source); !exists {
+			updateRoot := viper.GetString(constant.UPDATE_ROOT)
+			source = filepath.Join(updateRoot, filename)
+		}
+		destination = filepath.Join(constant.TEMP_DIR, updateName, filename)
 		// Copy the file
 		err := util.CopyFile(source, destination)
 		if err != nil {
@@ -1500,7 +2548,7 @@ func copyResourceFilesToTempDir(resourceFilesMap map[string]bool) error {
 			if isMandatory {
 				return err
 			} else {
-				fmt.Println(fmt.Sprintf("Optional resource file '%s' not copied.", filename))
+				util.PrintWarning(fmt.Sprintf("Optional resource file '%s' not copied.", filename))
 			}
 		}
 	}
@@ -1539,55 +2587,165 @@ func generateLocationTable(filename string, locationsInDistribution map[string]*
 	return locationTable, indexMap
 }
 
+// This function generates the options which are sent with the prompt in JSON IO mode. Each option is in
+// 'index:location' format.
+func getLocationOptions(filename string, indexMap map[string]string) []string {
+	options := make([]string, 0)
+	for index := 1; index <= len(indexMap); index++ {
+		relativePath := path.Join("CARBON_HOME", indexMap[strconv.Itoa(index)])
+		options = append(options, fmt.Sprintf("%d:%s", index, path.Join(relativePath, filename)))
+	}
+	return options
+}
+
 // This function will copy the file/directory from update to temp location.
 func copyFile(filename string, locationInUpdate, relativeLocationInTemp string, rootNode *node,
 	updateDescriptor *util.UpdateDescriptorV2) error {
 	logger.Debug(fmt.Sprintf("[FINAL][COPY ROOT] Name: %s ; IsDir: false ; From: %s ; To: %s", filename,
 		locationInUpdate, relativeLocationInTemp))
+	// Files should not be placed in the paths which are declared as non-updatable by the distribution
+	if util.IsIgnoredPath(path.Join(relativeLocationInTemp, filename), distributionIgnoredPaths) {
+		util.PrintPolicyNotice(fmt.Sprintf("'%s' is not copied as '%s' is declared as non-updatable in '%s' of the "+
+			"distribution.", filename, path.Join(relativeLocationInTemp, filename), constant.WUM_IGNORE_FILE))
+		return nil
+	}
 	updateName := viper.GetString(constant.UPDATE_NAME)
-	source := path.Join(locationInUpdate, filename)
-	carbonHome := path.Join(constant.TEMP_DIR, updateName, constant.CARBON_HOME)
-	destination := path.Join(carbonHome, relativeLocationInTemp)
-
-	//Replace all / with OS specific path separators to handle OSs like Windows
-	destination = strings.Replace(destination, "/", constant.PATH_SEPARATOR, -1)
-
-	fullPath := path.Join(destination, filename)
-	//Replace all / with OS specific path separators to handle OSs like Windows
-	fullPath = strings.Replace(fullPath, "/", constant.PATH_SEPARATOR, -1)
+	// Source should be inside the update root and the destination should be inside the payload in the temp directory
+	_, err := util.GetPathInRoot(locationInUpdate, filename)
+	if err != nil {
+		return errors.New(fmt.Sprintf("File '%s' cannot be copied as it is not inside the update directory: %v.",
+			filename, err))
+	}
+	source := filepath.Join(locationInUpdate, filepath.FromSlash(filename))
+	carbonHome := filepath.Join(constant.TEMP_DIR, updateName, getPayloadDirectory())
+	_, err = util.GetPathInRoot(carbonHome, path.Join(relativeLocationInTemp, filename))
+	if err != nil {
+		return errors.New(fmt.Sprintf("File '%s' cannot be copied to '%s' as it is not inside the payload of the "+
+			"update: %v.", filename, relativeLocationInTemp, err))
+	}
+	// Locations in the distribution are separated by '/'. They are converted to OS specific paths to handle OSs
+	// like Windows
+	fullPath := filepath.Join(carbonHome, filepath.FromSlash(relativeLocationInTemp), filepath.FromSlash(filename))
 
-	parentDirectory := path.Dir(fullPath)
+	parentDirectory := filepath.Dir(fullPath)
 	logger.Debug("parentDirectory:", parentDirectory)
-	err := util.CreateDirectory(parentDirectory)
+	err = util.CreateDirectory(parentDirectory)
 	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while creating '%v' directory.", parentDirectory))
 	logger.Debug(fmt.Sprintf("[FINAL][COPY][TEMP] Name: %s; From: %s; To: %s", filename, source, fullPath))
+	copyStartTime := time2.Now()
 	err = util.CopyFile(source, fullPath)
 	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while copying file. Source: %v, Destination: %v",
 		source, fullPath))
+	util.RecordStepDuration(constant.STAGE_COPY_FILES, time2.Since(copyStartTime))
+	checkPayloadSizeBudget(fullPath, updateDescriptor)
 
-	prefix := carbonHome + "/"
-	// Replace all / characters with the os path separator character. Otherwise errors will occur in OSs like
-	// Windows
-	prefix = strings.Replace(prefix, "/", constant.PATH_SEPARATOR, -1)
+	prefix := carbonHome + constant.PATH_SEPARATOR
 	logger.Debug(fmt.Sprintf("Trimming %s using %s", fullPath, prefix))
-	relativePath := strings.TrimPrefix(fullPath, prefix)
+	// Paths in the update descriptor are separated by '/' in all OSs
+	relativePath := filepath.ToSlash(strings.TrimPrefix(fullPath, prefix))
 	logger.Debug(fmt.Sprintf("relativePath: %s", relativePath))
 	contains := PathExists(rootNode, relativePath, false)
 	logger.Debug(fmt.Sprintf("contains: %v", contains))
-	// If the file already in the distribution, add it as a modified file. Otherwise add it as a new file
-	if contains {
+	if isOwnershipRecorded {
+		ownership, err := util.GetFileOwnership(source)
+		util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while reading the owner of '%s'.", source))
+		ownership.Path = relativePath
+		recordedFileOwnerships = append(recordedFileOwnerships, ownership)
+	}
+	util.PublishEvent(constant.EVENT_FILE_COPIED, constant.STAGE_MATCH_FILES, filename, map[string]string{
+		"source":      source,
+		"destination": relativePath,
+	})
+	// If the file already in the distribution, add it as a modified file. Otherwise add it as a new file. Files of a
+	// resumed session may already be added.
+	if contains && !util.IsStringIsInSlice(relativePath, updateDescriptor.FileChanges.ModifiedFiles) {
 		updateDescriptor.FileChanges.ModifiedFiles = append(updateDescriptor.FileChanges.ModifiedFiles,
 			relativePath)
-	} else {
+	} else if !contains && !util.IsStringIsInSlice(relativePath, updateDescriptor.FileChanges.AddedFiles) {
 		updateDescriptor.FileChanges.AddedFiles = append(updateDescriptor.FileChanges.AddedFiles,
 			relativePath)
 	}
+	savePartialUpdateDescriptor(updateDescriptor)
 	return nil
 }
 
-// This function will create a zip file from the source to the target folder.
-func ZipFile(source, target string) error {
-	zipfile, err := os.Create(target)
+// This function restores the files matched in the aborted session of the given update from the partial update
+// descriptor in the temp directory and returns the matched root level directories and files, if the session is
+// resumed. Otherwise an aborted session in the temp directory is discarded.
+func restorePartialUpdateDescriptor(updateName string, updateDescriptorV2 *util.UpdateDescriptorV2) []string {
+	partialUpdateDescriptor, err := util.LoadPartialUpdateDescriptor(constant.TEMP_DIR)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while reading the aborted session in '%s'.",
+		constant.TEMP_DIR))
+	if !isResumeEnabled {
+		if partialUpdateDescriptor != nil {
+			util.PrintWarning(fmt.Sprintf("Discarding the aborted session of '%s' in '%s'. Run the command with "+
+				"'--resume' to resume it.", partialUpdateDescriptor.UpdateName, constant.TEMP_DIR))
+			util.CleanUpDirectory(constant.TEMP_DIR)
+		}
+		return nil
+	}
+	if partialUpdateDescriptor == nil {
+		util.HandleErrorAndExit(errors.New(fmt.Sprintf("no aborted session found in '%s' to resume. Run the "+
+			"command without '--resume'", constant.TEMP_DIR)))
+	}
+	if partialUpdateDescriptor.UpdateName != updateName {
+		util.HandleErrorAndExit(errors.New(fmt.Sprintf("aborted session in '%s' is of '%s', not '%s'. Run the "+
+			"command without '--resume' to discard it", constant.TEMP_DIR, partialUpdateDescriptor.UpdateName,
+			updateName)))
+	}
+	updateDescriptorV2.FileChanges = partialUpdateDescriptor.FileChanges
+	recordedFileOwnerships = partialUpdateDescriptor.FileOwnerships
+	skippedFiles = partialUpdateDescriptor.SkippedFiles
+	isPartialUpdateDescriptorSaved = true
+	util.PrintInfo(fmt.Sprintf("Resuming the aborted session of '%s'. Files of %d root level directories and "+
+		"files are already matched.", updateName, len(partialUpdateDescriptor.MatchedEntries)))
+	return partialUpdateDescriptor.MatchedEntries
+}
+
+// This function records that the files of the given root level directory or file of the update directory are
+// matched and saves the partial update descriptor.
+func recordMatchedEntry(entry string, updateDescriptorV2 *util.UpdateDescriptorV2) {
+	matchedEntries = append(matchedEntries, entry)
+	savePartialUpdateDescriptor(updateDescriptorV2)
+}
+
+// This function saves the files matched so far to the partial update descriptor in the temp directory, so that the
+// session can be resumed with '--resume' if it is aborted.
+func savePartialUpdateDescriptor(updateDescriptorV2 *util.UpdateDescriptorV2) {
+	partialUpdateDescriptor := util.PartialUpdateDescriptor{
+		UpdateName:         viper.GetString(constant.UPDATE_NAME),
+		UpdateDescriptorV2: *updateDescriptorV2,
+		MatchedEntries:     matchedEntries,
+		FileOwnerships:     recordedFileOwnerships,
+		SkippedFiles:       skippedFiles,
+	}
+	err := util.SavePartialUpdateDescriptor(constant.TEMP_DIR, &partialUpdateDescriptor)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while saving '%s'.",
+		constant.PARTIAL_UPDATE_DESCRIPTOR_FILE))
+	isPartialUpdateDescriptorSaved = true
+}
+
+// This function adds the size of the given file copied to the payload to the size of the payload and warns once the
+// payload grows past the size budget of the platform of the update. The payload is not compressed yet, so the update
+// zip may still fit in the budget, which is checked after creating it.
+func checkPayloadSizeBudget(copiedFilePath string, updateDescriptorV2 *util.UpdateDescriptorV2) {
+	fileInfo, err := util.FileSystem.Stat(copiedFilePath)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while reading '%s'.", copiedFilePath))
+	copiedPayloadSize += uint64(fileInfo.Size())
+	budget, budgetOf := getSizeBudgets().GetSizeBudget(updateDescriptorV2.PlatformName, nil)
+	if budget == 0 || copiedPayloadSize <= budget || isPayloadSizeBudgetExceeded {
+		return
+	}
+	isPayloadSizeBudgetExceeded = true
+	util.PrintPolicyNotice(fmt.Sprintf("Payload of the update has grown to %d bytes which exceeds the size budget of %d "+
+		"bytes of %s. The update zip may be rejected by the delivery infrastructure.", copiedPayloadSize, budget,
+		budgetOf))
+}
+
+// This function will create a zip file from the source to the target folder. The given executable files (paths relative
+// to the source) are marked as executable in the zip.
+func ZipFile(source, target string, executableFiles ...string) error {
+	zipfile, err := util.FileSystem.Create(target)
 	if err != nil {
 		return err
 	}
@@ -1596,7 +2754,7 @@ func ZipFile(source, target string) error {
 	archive := zip.NewWriter(zipfile)
 	defer archive.Close()
 
-	info, err := os.Stat(source)
+	info, err := util.FileSystem.Stat(source)
 	if err != nil {
 		return err
 	}
@@ -1606,7 +2764,7 @@ func ZipFile(source, target string) error {
 		baseDir = filepath.Base(source)
 	}
 
-	filepath.Walk(source, func(path string, info os.FileInfo, err error) error {
+	afero.Walk(util.FileSystem, source, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
@@ -1623,6 +2781,10 @@ func ZipFile(source, target string) error {
 			header.Name += "/"
 		}
 		header.Method = zip.Deflate
+		relativePath := filepath.ToSlash(strings.TrimPrefix(strings.TrimPrefix(path, source), constant.PATH_SEPARATOR))
+		if !info.IsDir() && util.IsStringIsInSlice(relativePath, executableFiles) {
+			header.SetMode(info.Mode() | constant.EXECUTE_PERMISSIONS)
+		}
 
 		//To support archives created under Windows and to be correctly handled in Linux.
 		header.Name = filepath.ToSlash(header.Name)
@@ -1636,7 +2798,7 @@ func ZipFile(source, target string) error {
 			return nil
 		}
 
-		file, err := os.Open(path)
+		file, err := util.FileSystem.Open(util.GetLongPath(path))
 		if err != nil {
 			return err
 		}
@@ -1651,7 +2813,11 @@ func ZipFile(source, target string) error {
 func setProductChangesInUpdateDescriptorV3(partialUpdatedProducts *util.PartialUpdatedProducts) *util.ProductChanges {
 	productChanges := &util.ProductChanges{}
 	productChanges.ProductName = partialUpdatedProducts.ProductName
-	productChanges.ProductVersion = partialUpdatedProducts.BaseVersion + "." + partialUpdatedProducts.Tag
+	productChanges.ProductVersion = partialUpdatedProducts.BaseVersion
+	// Products of the listed distributions are given with their full versions
+	if len(partialUpdatedProducts.Tag) != 0 {
+		productChanges.ProductVersion += "." + partialUpdatedProducts.Tag
+	}
 	productChanges.AddedFiles = partialUpdatedProducts.AddedFiles
 	productChanges.RemovedFiles = partialUpdatedProducts.RemovedFiles
 	productChanges.ModifiedFiles = partialUpdatedProducts.ModifiedFiles
@@ -1662,13 +2828,11 @@ func setProductChangesInUpdateDescriptorV3(partialUpdatedProducts *util.PartialU
 func appendRemovedFilesToUpdateDescriptor(updateDescriptorV2 *util.UpdateDescriptorV2) {
 userInputLoop:
 	for {
-		util.PrintInBold(fmt.Sprintf("Enter the path of a removed file relative to the PRODUCT_HOME, " +
-			"press enter when the path is added\n"))
-		removedFile, err := util.GetUserInput()
+		removedFile, err := util.PromptUser(fmt.Sprintf("Enter the path of a removed file relative to the " +
+			"PRODUCT_HOME, press enter when the path is added\n"))
 		util.HandleErrorAndExit(err, "Error occurred while getting input from the user.")
 		if removedFile == "" {
-			util.PrintInBold("Empty input detected, are you done with adding inputs? [y/n]: ")
-			preference, err := util.GetUserInput()
+			preference, err := util.PromptUser("Empty input detected, are you done with adding inputs? [y/n]: ")
 			util.HandleErrorAndExit(err, "Error occurred while getting input from the user.")
 			userPreference := util.ProcessUserPreference(preference)
 			switch userPreference {
@@ -1685,6 +2849,20 @@ userInputLoop:
 	}
 }
 
+// This function checks whether the removed files of an update which only removes files are given and they are files of
+// the distribution.
+func checkRemovedFiles(rootNode *node, removedFiles []string) error {
+	if len(removedFiles) == 0 {
+		return errors.New("no removed files given. An update without payload files should remove at least one file")
+	}
+	for _, removedFile := range removedFiles {
+		if !PathExists(rootNode, removedFile, false) {
+			return errors.New(fmt.Sprintf("removed file '%s' is not a file of the distribution", removedFile))
+		}
+	}
+	return nil
+}
+
 // This function save '.wum-uc-resume.yaml' file for resuming update creation (wum-uc create --continue) in future.
 func saveResumeFile(resumeFile *ResumeFile, wumucResumeFilePath string) {
 	data, err := yaml.Marshal(resumeFile)
@@ -1701,45 +2879,35 @@ func saveResumeFile(resumeFile *ResumeFile, wumucResumeFilePath string) {
 update-descriptor3.yaml by the Developer.*/
 func continueResumedUpdateCreation() {
 	logger.Debug("Resuming update creation from last state")
-	resumedFile := ResumeFile{}
-	// Check for the existence of 'wum-uc-resume.yaml' file
 	wumucResumeFilePath := filepath.Join(WUMUCHome, constant.WUMUC_RESUME_FILE)
-	logger.Debug(fmt.Sprintf("Location of %s: %s", constant.WUMUC_RESUME_FILE, wumucResumeFilePath))
-	exits, err := util.IsFileExists(wumucResumeFilePath)
-	if err != nil {
-		util.HandleErrorAndExit(err, " error occurred while checking the existence of ", wumucResumeFilePath)
+	resumedFile := readResumeFile(wumucResumeFilePath)
+	if resumedFile.IsReadOnlyInputs {
+		util.SetReadOnlyInputs(getUpdateDirectoryPath(&resumedFile), resumedFile.DistributionPath)
+		defer util.SetReadOnlyInputs()
 	}
-	if !exits {
-		util.HandleErrorAndExit(errors.New(fmt.Sprintf("no trace of a resumed update creation found, " +
-			"please recreate the update.")))
-	}
-	logger.Debug(fmt.Sprintf("%s path exists", wumucResumeFilePath))
-	// Read resumed update creation details
-	logger.Debug(fmt.Sprintf("Reading %s file", wumucResumeFilePath))
-	data, err := ioutil.ReadFile(wumucResumeFilePath)
-	if err != nil {
-		util.HandleErrorAndExit(err, "error occurred while reading the ", wumucResumeFilePath)
-	}
-	err = yaml.Unmarshal(data, &resumedFile)
-	if err != nil {
-		util.HandleErrorAndExit(err, "error occurred while un-marshaling the ", wumucResumeFilePath)
-	}
-	logger.Trace(fmt.Sprintf("Unmarshalling %s file successfully completed", wumucResumeFilePath))
-
 	// Check if the update zip has already being created
 	if resumedFile.IsUpdateZipCreated {
 		commitUpdateToSVN(&resumedFile)
-		logger.Debug(fmt.Sprintf("Update zip %s.zip already created", resumedFile.UpdateName))
+		setWorkspaceStatus(constant.WORKSPACE_STATUS_COMMITTED)
+		logger.Debug(fmt.Sprintf("Update zip %s already created", getUpdateArtifactName(&resumedFile)))
 	} else {
-		logger.Debug(fmt.Sprintf("Creating update zip %s.zip from resume state", resumedFile.UpdateName))
+		logger.Debug(fmt.Sprintf("Creating update zip %s%s from resume state", resumedFile.UpdateName,
+			getUpdateArchiveExtension(&resumedFile)))
 		// Create the update zip from resumed state
+		// Format given when resuming overrides the format given when creating the update
+		if isUpdateFormatGiven {
+			resumedFile.Format = viper.GetString(constant.UPDATE_FORMAT)
+		}
+		if len(encryptionRecipients) != 0 {
+			resumedFile.EncryptionRecipients = encryptionRecipients
+		}
 		// Check if the exploded update directory exists
 		executablePath, err := os.Executable()
 		if err != nil {
 			util.HandleErrorAndExit(err, fmt.Sprintf("error occurred when getting the path of 'wum-uc' executable"))
 		}
 		executableDirPath := filepath.Dir(executablePath)
-		explodedDirPath := path.Join(executableDirPath, resumedFile.ExplodedUpdateDirectoryPath)
+		explodedDirPath := filepath.Join(executableDirPath, resumedFile.ExplodedUpdateDirectoryPath)
 		exists, err := util.IsDirectoryExists(explodedDirPath)
 		if err != nil {
 			logger.Debug(fmt.Sprintf("error occurred in checking the existance of %s exploded update directory", explodedDirPath))
@@ -1755,9 +2923,9 @@ func continueResumedUpdateCreation() {
 				"please recreate the update using 'wum-uc create' command"))
 		}
 		// Copy developer edited `update-descriptor3.yaml` to the temp location for creating the update.
-		source := path.Join(resumedFile.ResourceDirectoryPath, constant.UPDATE_DESCRIPTOR_V3_FILE)
-		destination := path.Join(resumedFile.ExplodedUpdateDirectoryPath, constant.UPDATE_DESCRIPTOR_V3_FILE)
-		updateZipName := resumedFile.UpdateName + ".zip"
+		source := filepath.Join(resumedFile.ResourceDirectoryPath, constant.UPDATE_DESCRIPTOR_V3_FILE)
+		destination := filepath.Join(resumedFile.ExplodedUpdateDirectoryPath, constant.UPDATE_DESCRIPTOR_V3_FILE)
+		updateZipName := resumedFile.UpdateName + getUpdateArchiveExtension(&resumedFile)
 		cleanupChannel := util.HandleInterrupts(func() {
 			util.CleanUpFile(updateZipName)
 			util.CleanUpFile(destination)
@@ -1772,10 +2940,63 @@ func continueResumedUpdateCreation() {
 		}
 		logger.Debug(fmt.Sprintf("Resources required for '%s' successfully generated at %s.", resumedFile.UpdateName,
 			resumedFile.ExplodedUpdateDirectoryPath))
+		// Copy the developer refined instructions.txt to the temp location
+		copyRefinedInstructions(&resumedFile)
+		updateDescriptorV3 := readExplodedUpdateDescriptorV3(&resumedFile)
+		// Resolve the environment variables referred in the developer edited update-descriptor3.yaml
+		resolveExplodedUpdateDescriptorV3EnvPlaceholders(&resumedFile, updateDescriptorV3)
+		// Check whether the developer edited update-descriptor3.yaml is consistent with the update-descriptor.yaml
+		checkExplodedUpdateDescriptorsConsistency(&resumedFile, updateDescriptorV3)
+		// Check whether the payload directory is not changed in the developer edited update-descriptor3.yaml
+		checkPayloadDirectory(&resumedFile, updateDescriptorV3)
+		// Check whether the placeholders of the templated files match their substitution rules
+		validateTemplatedFiles(&resumedFile, updateDescriptorV3)
+		// Check whether the OS variants are in the payload
+		validateOSVariants(&resumedFile, updateDescriptorV3)
+		// Check whether the executable files are in the payload and record them so that they are executable in the zip
+		validateExecutableFiles(&resumedFile, updateDescriptorV3)
+		// Check whether the preview is valid and record it so that the update is published to the preview channel
+		validatePreview(&resumedFile, updateDescriptorV3)
+		// Regenerate the legal resource files as the developer may have declared a security advisory
+		generateLegalFiles(&resumedFile, updateDescriptorV3)
+		// Place the files of the products which declare payload roots in their payload roots
+		placeProductPayloads(&resumedFile, updateDescriptorV3)
+		// Replace large modified files with binary deltas if enabled
+		restoreBinaryDeltaOriginals(&resumedFile)
+		// Scan the payload for malware if enabled
+		if resumedFile.IsScanEnabled || viper.GetBool(constant.SCAN_ENABLED) {
+			scanPayload(&resumedFile)
+		}
+		// Scan the payload for secrets which are not waived if enabled
+		if viper.GetBool(constant.SECRET_SCAN_ENABLED) {
+			scanPayloadForSecrets(&resumedFile, updateDescriptorV3)
+		}
+		// Updates which only remove files do not have a payload to be replaced with binary deltas
+		if (resumedFile.IsBinaryDeltaEnabled || viper.GetBool(constant.BINARY_DELTA_ENABLED)) &&
+			!util.IsRemoveOnlyUpdate(updateDescriptorV3) {
+			createBinaryDeltas(&resumedFile)
+		}
 		// Create the update zip
 		createUpdateZip(&resumedFile)
+		// Write the files which are not copied to the update next to the update zip for the reviewers
+		writeSkippedFilesManifest(&resumedFile)
+		// Check the size of the update zip against the size budget of its platform and products
+		updateZipInfo, err := os.Stat(updateZipName)
+		util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while reading '%s'.", updateZipName))
+		checkUpdateSizeBudget(updateZipName, uint64(updateZipInfo.Size()), updateDescriptorV3)
 		// Validate the created update zip
 		validateUpdate(&resumedFile)
+		setWorkspaceStatus(constant.WORKSPACE_STATUS_VALIDATED)
+		// Encrypt the validated update for the given recipients
+		if len(resumedFile.EncryptionRecipients) != 0 {
+			encryptUpdate(&resumedFile)
+		}
+		// Record the created update in the audit log
+		recordAuditEvent(constant.AUDIT_OPERATION_CREATE, getUpdateArtifactName(&resumedFile))
+		// Attest how the update was built for the downstream consumers
+		if viper.GetBool(constant.PROVENANCE_ENABLED) {
+			generateProvenance(&resumedFile)
+		}
 
 		signal.Stop(cleanupChannel)
 		// Remove the temp directories and files
@@ -1789,45 +3010,865 @@ func continueResumedUpdateCreation() {
 		*/
 		resumedFile.IsUpdateZipCreated = true
 		saveResumeFile(&resumedFile, wumucResumeFilePath)
-		fmt.Println(fmt.Sprintf("'%s'.zip successfully created.\n", resumedFile.UpdateName))
+		// Record the update number in the ledger so that it won't be used again for another update
+		err = util.AddToUpdateNumberLedger(WUMUCHome, util.UpdateNumberLedgerEntry{
+			UpdateNumber:          resumedFile.UpdateNumber,
+			PlatformVersion:       resumedFile.PlatformVersion,
+			UpdateName:            resumedFile.UpdateName,
+			ResourceDirectoryPath: getUpdateDirectoryPath(&resumedFile),
+		})
+		if err != nil {
+			logger.Error(fmt.Sprintf("%v error occurred while recording the update number in %s", err,
+				constant.WUMUC_UPDATE_NUMBER_LEDGER_FILE))
+		}
+		util.PrintMessage(fmt.Sprintf("'%s' successfully created.\n", getUpdateArtifactName(&resumedFile)))
 		logger.Debug(fmt.Sprintf("%s successfully updated with the status of update zip creation", constant.WUMUC_RESUME_FILE))
 
 		commitUpdateToSVN(&resumedFile)
+		setWorkspaceStatus(constant.WORKSPACE_STATUS_COMMITTED)
 
 		// Cleanup the '.wum-uc-resume.yaml' file upon successful committing of the created update zip to the SVN repo
 		util.CleanUpFile(wumucResumeFilePath)
 	}
 }
 
+// This function reads the resume file at the given location. If the resume file does not exist, an error is printed
+// and the tool exits.
+func readResumeFile(wumucResumeFilePath string) ResumeFile {
+	resumedFile := ResumeFile{}
+	// Check for the existence of 'wum-uc-resume.yaml' file
+	logger.Debug(fmt.Sprintf("Location of %s: %s", constant.WUMUC_RESUME_FILE, wumucResumeFilePath))
+	exits, err := util.IsFileExists(wumucResumeFilePath)
+	if err != nil {
+		util.HandleErrorAndExit(err, " error occurred while checking the existence of ", wumucResumeFilePath)
+	}
+	if !exits {
+		util.HandleErrorAndExit(errors.New(fmt.Sprintf("no trace of a resumed update creation found, " +
+			"please recreate the update.")))
+	}
+	logger.Debug(fmt.Sprintf("%s path exists", wumucResumeFilePath))
+	// Read resumed update creation details
+	logger.Debug(fmt.Sprintf("Reading %s file", wumucResumeFilePath))
+	data, err := ioutil.ReadFile(wumucResumeFilePath)
+	if err != nil {
+		util.HandleErrorAndExit(err, "error occurred while reading the ", wumucResumeFilePath)
+	}
+	err = yaml.Unmarshal(data, &resumedFile)
+	if err != nil {
+		util.HandleErrorAndExit(err, "error occurred while un-marshaling the ", wumucResumeFilePath)
+	}
+	logger.Trace(fmt.Sprintf("Unmarshalling %s file successfully completed", wumucResumeFilePath))
+	return resumedFile
+}
+
 // This function will create the update zip.
 func createUpdateZip(resumeFile *ResumeFile) {
+	util.PublishStageStarted(constant.STAGE_CREATE_ZIP)
+	defer util.PublishStageFinished(constant.STAGE_CREATE_ZIP)
 	// Construct the update zip name
-	updateZipName := resumeFile.UpdateName + ".zip"
+	updateZipName := resumeFile.UpdateName + getUpdateArchiveExtension(resumeFile)
 	logger.Debug(fmt.Sprintf("Name of the update zip: %s", updateZipName))
+	// Remove the files which should be excluded from the update zip
+	excludeIgnoredFilesFromZip(resumeFile)
 	logger.Debug(fmt.Sprintf("Creating the update zip %s", updateZipName))
-	err := ZipFile(resumeFile.ExplodedUpdateDirectoryPath, updateZipName)
+	var executableFiles []string
+	for _, executableFile := range resumeFile.ExecutableFiles {
+		executableFiles = append(executableFiles, path.Join(getResumedPayloadDirectory(resumeFile), executableFile))
+	}
+	var err error
+	if resumeFile.Format == constant.UPDATE_FORMAT_TAR_ZST {
+		err = util.TarZstDirectory(resumeFile.ExplodedUpdateDirectoryPath, updateZipName, executableFiles...)
+	} else {
+		err = ZipFile(resumeFile.ExplodedUpdateDirectoryPath, updateZipName, executableFiles...)
+	}
 	if err != nil {
 		util.HandleErrorAndExit(err, "error occurred when compressing the update zip.")
 	}
 	logger.Debug(fmt.Sprintf("Update zip %s created successfully.", updateZipName))
 }
 
+// This function writes the files of the update directory which are not copied to the update, with the reasons, to the
+// skipped files manifest next to the update zip. The manifest is written even if no files are skipped so that the
+// reviewers can confirm that nothing is left out of the update.
+func writeSkippedFilesManifest(resumeFile *ResumeFile) {
+	manifestPath := resumeFile.UpdateName + constant.SKIPPED_FILES_MANIFEST_SUFFIX
+	manifest := util.SkippedFilesManifest{
+		UpdateName:   resumeFile.UpdateName,
+		SkippedFiles: resumeFile.SkippedFiles,
+	}
+	if manifest.SkippedFiles == nil {
+		manifest.SkippedFiles = []util.SkippedFile{}
+	}
+	err := util.SaveSkippedFilesManifest(manifestPath, &manifest)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while writing the skipped files to '%s'.",
+		manifestPath))
+	util.PrintInfo(fmt.Sprintf("%d file(s) not copied to the update are listed in '%s'.",
+		len(manifest.SkippedFiles), manifestPath))
+}
+
+// This function removes the files which match the files excluded from update zips (IGNORED_FILES.ZIP) from the
+// exploded update directory. Each removed file is reported so that files are not dropped from the update silently.
+func excludeIgnoredFilesFromZip(resumeFile *ResumeFile) {
+	excludedFiles := util.GetIgnoredFiles(constant.IGNORED_FILES_CREATE, constant.ZIP)
+	if len(excludedFiles) == 0 {
+		return
+	}
+	err := filepath.Walk(resumeFile.ExplodedUpdateDirectoryPath, func(absolutePath string, fileInfo os.FileInfo,
+		err error) error {
+		if err != nil || fileInfo.IsDir() || !util.IsIgnoredFile(fileInfo.Name(), excludedFiles) {
+			return err
+		}
+		relativePath, err := filepath.Rel(resumeFile.ExplodedUpdateDirectoryPath, absolutePath)
+		if err != nil {
+			return err
+		}
+		util.PrintInfo(fmt.Sprintf("'%s' is excluded from the update zip (%s).", filepath.ToSlash(relativePath),
+			constant.IGNORED_FILES_ZIP))
+		return os.Remove(absolutePath)
+	})
+	util.HandleErrorAndExit(err, "Error occurred while excluding files from the update zip.")
+}
+
+// This function replaces the large modified files in the exploded update directory with binary deltas against the
+// distribution when the delta is smaller than the full file. Originals are moved to a separate directory so that
+// they can be restored if the update zip is recreated. Metadata of the deltas is recorded in the
+// update-descriptor3.yaml in the exploded update directory.
+func createBinaryDeltas(resumeFile *ResumeFile) {
+	carbonHome := filepath.Join(resumeFile.ExplodedUpdateDirectoryPath, getResumedPayloadDirectory(resumeFile))
+	originalsDirectory := filepath.Join(constant.TEMP_DIR, constant.BINARY_DELTA_ORIGINALS_DIRECTORY)
+	minSize := int64(viper.GetInt(constant.BINARY_DELTA_MIN_SIZE))
+	patterns := viper.GetStringSlice(constant.BINARY_DELTA_PATTERNS)
+
+	distributionFS, closer, err := util.OpenDistribution(resumeFile.DistributionPath)
+	if err != nil {
+		util.HandleErrorAndExit(err, fmt.Sprintf("error occurred when reading the distribution %s",
+			resumeFile.DistributionPath))
+	}
+	defer closer.Close()
+
+	var binaryDeltas []util.BinaryDelta
+	err = filepath.Walk(carbonHome, func(absolutePath string, fileInfo os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fileInfo.IsDir() || fileInfo.Size() < minSize || !matchesAnyPattern(fileInfo.Name(), patterns) {
+			return nil
+		}
+		relativePath, err := filepath.Rel(carbonHome, absolutePath)
+		if err != nil {
+			return err
+		}
+		relativePath = filepath.ToSlash(relativePath)
+		baseData, found, err := util.ReadDistributionFile(distributionFS, relativePath)
+		if err != nil {
+			return err
+		}
+		if !found {
+			// New files do not have a base to create the delta against
+			return nil
+		}
+		targetData, err := ioutil.ReadFile(absolutePath)
+		if err != nil {
+			return err
+		}
+		delta := util.CreateDelta(baseData, targetData)
+		if len(delta) >= len(targetData) {
+			logger.Debug(fmt.Sprintf("Binary delta of %s is not smaller than the file, skipping", relativePath))
+			return nil
+		}
+		originalPath := filepath.Join(originalsDirectory, relativePath)
+		if err = util.CreateDirectory(filepath.Dir(originalPath)); err != nil {
+			return err
+		}
+		if err = ioutil.WriteFile(absolutePath+constant.BINARY_DELTA_EXTENSION, delta, fileInfo.Mode()); err != nil {
+			return err
+		}
+		if err = os.Rename(absolutePath, originalPath); err != nil {
+			return err
+		}
+		logger.Debug(fmt.Sprintf("Binary delta of %s created, %d bytes instead of %d bytes", relativePath,
+			len(delta), len(targetData)))
+		binaryDeltas = append(binaryDeltas, util.BinaryDelta{
+			File:       relativePath,
+			Algorithm:  constant.BINARY_DELTA_ALGORITHM,
+			BaseMd5:    fmt.Sprintf("%x", md5.Sum(baseData)),
+			TargetMd5:  fmt.Sprintf("%x", md5.Sum(targetData)),
+			TargetSize: len(targetData),
+			DeltaSize:  len(delta),
+		})
+		return nil
+	})
+	if err != nil {
+		util.HandleErrorAndExit(err, "error occurred when creating binary deltas.")
+	}
+	if len(binaryDeltas) == 0 {
+		return
+	}
+
+	// Record the binary deltas in the update-descriptor3.yaml
+	updateDescriptorV3Path := filepath.Join(resumeFile.ExplodedUpdateDirectoryPath, constant.UPDATE_DESCRIPTOR_V3_FILE)
+	data, err := ioutil.ReadFile(updateDescriptorV3Path)
+	if err != nil {
+		util.HandleErrorAndExit(err, fmt.Sprintf("error occurred when reading %s", updateDescriptorV3Path))
+	}
+	updateDescriptorV3 := util.UpdateDescriptorV3{}
+	if err = util.UnmarshalUpdateDescriptor(data, updateDescriptorV3Path, &updateDescriptorV3); err != nil {
+		util.HandleErrorAndExit(err, fmt.Sprintf("error occurred when unmarshalling %s", updateDescriptorV3Path))
+	}
+	updateDescriptorV3.BinaryDeltas = binaryDeltas
+	data, err = yaml.Marshal(updateDescriptorV3)
+	if err != nil {
+		util.HandleErrorAndExit(err, fmt.Sprintf("error occurred when marshalling %s", updateDescriptorV3Path))
+	}
+	if err = ioutil.WriteFile(updateDescriptorV3Path, data, 0600); err != nil {
+		util.HandleErrorAndExit(err, fmt.Sprintf("error occurred when writing %s", updateDescriptorV3Path))
+	}
+	util.PrintInfo(fmt.Sprintf("%d file(s) stored as binary deltas.", len(binaryDeltas)))
+}
+
+// This function scans the exploded update directory with the configured scanner and records the verdict in the
+// update-descriptor3.yaml in the exploded update directory. The update zip is not created if threats are found.
+func scanPayload(resumeFile *ResumeFile) {
+	var verdict *util.ScanVerdict
+	var err error
+	if icapURL := viper.GetString(constant.SCAN_ICAP_URL); len(icapURL) != 0 {
+		util.PrintInfo(fmt.Sprintf("Scanning the payload of '%s' with '%s'.", resumeFile.UpdateName, icapURL))
+		verdict, err = util.ScanWithICAP(icapURL, resumeFile.ExplodedUpdateDirectoryPath)
+	} else {
+		scannerCommand := viper.GetString(constant.SCAN_COMMAND)
+		util.PrintInfo(fmt.Sprintf("Scanning the payload of '%s' with '%s'.", resumeFile.UpdateName, scannerCommand))
+		verdict, err = util.ScanWithCommand(scannerCommand, resumeFile.ExplodedUpdateDirectoryPath)
+	}
+	util.HandleErrorAndExit(err, "Error occurred while scanning the payload of the update.")
+	updateDescriptorV3 := readExplodedUpdateDescriptorV3(resumeFile)
+	updateDescriptorV3.Scan = verdict
+	updateDescriptorV3Path := filepath.Join(resumeFile.ExplodedUpdateDirectoryPath, constant.UPDATE_DESCRIPTOR_V3_FILE)
+	data, err := yaml.Marshal(updateDescriptorV3)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while marshalling '%s'.", updateDescriptorV3Path))
+	err = ioutil.WriteFile(updateDescriptorV3Path, data, 0600)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while writing '%s'.", updateDescriptorV3Path))
+	if verdict.Verdict != constant.SCAN_VERDICT_CLEAN {
+		util.HandleErrorAndExit(errors.New(fmt.Sprintf("threats found in the payload:\n\t%s",
+			strings.Join(verdict.Findings, "\n\t"))), "Update zip is not created.")
+	}
+	util.PrintInfo(fmt.Sprintf("No threats found in %d files of the payload.", verdict.ScannedFiles))
+}
+
+// This function scans the payload in the exploded update directory for secrets (keys, passwords, tokens). The update
+// zip is not created if a secret is found in a file which is not waived in the given update-descriptor3.yaml.
+func scanPayloadForSecrets(resumeFile *ResumeFile, updateDescriptorV3 *util.UpdateDescriptorV3) {
+	rules, err := util.GetSecretRules(viper.GetStringSlice(constant.SECRET_SCAN_PATTERNS))
+	util.HandleErrorAndExit(util.NewInputError(err), fmt.Sprintf("Invalid '%s'.", constant.SECRET_SCAN_PATTERNS))
+	findings, err := util.FindSecretsInDirectory(resumeFile.ExplodedUpdateDirectoryPath,
+		getResumedPayloadDirectory(resumeFile), rules, viper.GetStringSlice(constant.SECRET_SCAN_ALLOWED_VALUES))
+	util.HandleErrorAndExit(err, "Error occurred while scanning the payload of the update for secrets.")
+	err = reportSecretFindings(findings, updateDescriptorV3.SecretWaivers)
+	util.HandleErrorAndExit(util.NewValidationError(err), "Update zip is not created.")
+}
+
+// This function moves the files replaced by binary deltas back to the exploded update directory and removes the
+// binary deltas.
+func restoreBinaryDeltaOriginals(resumeFile *ResumeFile) {
+	originalsDirectory := filepath.Join(constant.TEMP_DIR, constant.BINARY_DELTA_ORIGINALS_DIRECTORY)
+	exists, err := util.IsDirectoryExists(originalsDirectory)
+	if err != nil || !exists {
+		return
+	}
+	carbonHome := filepath.Join(resumeFile.ExplodedUpdateDirectoryPath, getResumedPayloadDirectory(resumeFile))
+	err = filepath.Walk(originalsDirectory, func(absolutePath string, fileInfo os.FileInfo, err error) error {
+		if err != nil || fileInfo.IsDir() {
+			return err
+		}
+		relativePath, err := filepath.Rel(originalsDirectory, absolutePath)
+		if err != nil {
+			return err
+		}
+		explodedPath := filepath.Join(carbonHome, relativePath)
+		util.CleanUpFile(explodedPath + constant.BINARY_DELTA_EXTENSION)
+		return os.Rename(absolutePath, explodedPath)
+	})
+	if err != nil {
+		util.HandleErrorAndExit(err, "error occurred when restoring the files replaced by binary deltas.")
+	}
+	util.CleanUpDirectory(originalsDirectory)
+}
+
+// This function writes an instructions.txt skeleton to the resource directory which lists the config files added or
+// modified by the update with their diffs against the distribution. Nothing is written if no config files are
+// changed or if the update directory or the resource directory already has an instructions.txt.
+func generateInstructionsSkeleton(updateDirectoryPath, resourceDirectoryPath, distributionPath string,
+	updateDescriptorV2 *util.UpdateDescriptorV2) {
+	instructionsPath := filepath.Join(resourceDirectoryPath, constant.INSTRUCTIONS_FILE)
+	for _, directory := range []string{updateDirectoryPath, resourceDirectoryPath} {
+		existingInstructionsPath := filepath.Join(directory, constant.INSTRUCTIONS_FILE)
+		exists, err := util.IsFileExists(existingInstructionsPath)
+		util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while checking '%s'.", existingInstructionsPath))
+		if exists {
+			return
+		}
+	}
+	prefixes := viper.GetStringSlice(constant.INSTRUCTIONS_CONFIG_PREFIXES)
+	var modifiedConfigFiles, addedConfigFiles []string
+	for _, modifiedFile := range updateDescriptorV2.FileChanges.ModifiedFiles {
+		if util.IsConfigFile(modifiedFile, prefixes) {
+			modifiedConfigFiles = append(modifiedConfigFiles, filepath.ToSlash(modifiedFile))
+		}
+	}
+	for _, addedFile := range updateDescriptorV2.FileChanges.AddedFiles {
+		if util.IsConfigFile(addedFile, prefixes) {
+			addedConfigFiles = append(addedConfigFiles, filepath.ToSlash(addedFile))
+		}
+	}
+	if len(modifiedConfigFiles) == 0 && len(addedConfigFiles) == 0 {
+		return
+	}
+
+	distributionFS, closer, err := util.OpenDistribution(distributionPath)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while reading '%s'.", distributionPath))
+	defer closer.Close()
+	carbonHome := filepath.Join(constant.TEMP_DIR, viper.GetString(constant.UPDATE_NAME), getPayloadDirectory())
+	var changes []util.ConfigFileChange
+	for _, modifiedFile := range modifiedConfigFiles {
+		change := util.ConfigFileChange{Path: modifiedFile}
+		change.OldContent, _, err = util.ReadDistributionFile(distributionFS, modifiedFile)
+		util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while reading '%s' in '%s'.", modifiedFile,
+			distributionPath))
+		change.NewContent, err = ioutil.ReadFile(filepath.Join(carbonHome, filepath.FromSlash(modifiedFile)))
+		util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while reading the updated '%s'.", modifiedFile))
+		changes = append(changes, change)
+	}
+	for _, addedFile := range addedConfigFiles {
+		changes = append(changes, util.ConfigFileChange{Path: addedFile})
+	}
+	err = ioutil.WriteFile(instructionsPath, []byte(util.GetInstructionsSkeleton(changes)), 0644)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while writing '%s'.", instructionsPath))
+	util.PrintInBold(fmt.Sprintf("%d config file(s) are changed by the update. Refine the instructions generated "+
+		"in '%s' before running 'wum-uc create --continue'.\n", len(changes), instructionsPath))
+}
+
+// This function copies the instructions.txt refined by the developer to the exploded update directory. The update
+// creation fails if the generated skeleton has not been refined.
+func copyRefinedInstructions(resumeFile *ResumeFile) {
+	source := filepath.Join(resumeFile.ResourceDirectoryPath, constant.INSTRUCTIONS_FILE)
+	instructions, err := ioutil.ReadFile(source)
+	if os.IsNotExist(err) {
+		// The instructions.txt given in the update directory is not copied to the resource directory
+		source = filepath.Join(getUpdateDirectoryPath(resumeFile), constant.INSTRUCTIONS_FILE)
+		instructions, err = ioutil.ReadFile(source)
+	}
+	if os.IsNotExist(err) {
+		return
+	}
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while reading '%s'.", source))
+	if util.IsInstructionsSkeleton(instructions) {
+		util.HandleErrorAndExit(errors.New(fmt.Sprintf("'%s' contains the generated skeleton. Refine the "+
+			"instructions and remove the line '%s'", source, constant.INSTRUCTIONS_SKELETON_PLACEHOLDER)))
+	}
+	destination := filepath.Join(resumeFile.ExplodedUpdateDirectoryPath, constant.INSTRUCTIONS_FILE)
+	err = ioutil.WriteFile(destination, instructions, 0644)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while copying '%s'.", source))
+}
+
+// This function reads the developer edited update-descriptor3.yaml in the exploded update directory.
+func readExplodedUpdateDescriptorV3(resumeFile *ResumeFile) *util.UpdateDescriptorV3 {
+	updateDescriptorV3Path := filepath.Join(resumeFile.ExplodedUpdateDirectoryPath, constant.UPDATE_DESCRIPTOR_V3_FILE)
+	data, err := ioutil.ReadFile(updateDescriptorV3Path)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while reading '%s'.", updateDescriptorV3Path))
+	updateDescriptorV3 := util.UpdateDescriptorV3{}
+	err = util.UnmarshalUpdateDescriptor(data, updateDescriptorV3Path, &updateDescriptorV3)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while reading '%s'.", updateDescriptorV3Path))
+	return &updateDescriptorV3
+}
+
+// This function compares the update-descriptor.yaml in the exploded update directory with the given
+// update-descriptor3.yaml. The update-descriptor.yaml is only created for backward compatible updates.
+func checkExplodedUpdateDescriptorsConsistency(resumeFile *ResumeFile, updateDescriptorV3 *util.UpdateDescriptorV3) {
+	updateDescriptorV2Path := filepath.Join(resumeFile.ExplodedUpdateDirectoryPath, constant.UPDATE_DESCRIPTOR_V2_FILE)
+	data, err := ioutil.ReadFile(updateDescriptorV2Path)
+	if os.IsNotExist(err) {
+		logger.Debug(fmt.Sprintf("%s not found in %s", constant.UPDATE_DESCRIPTOR_V2_FILE,
+			resumeFile.ExplodedUpdateDirectoryPath))
+		return
+	}
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while reading '%s'.", updateDescriptorV2Path))
+	updateDescriptorV2 := util.UpdateDescriptorV2{}
+	err = util.UnmarshalUpdateDescriptor(data, updateDescriptorV2Path, &updateDescriptorV2)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while reading '%s'.", updateDescriptorV2Path))
+	checkUpdateDescriptorsConsistency(resumeFile.UpdateName, &updateDescriptorV2, updateDescriptorV3)
+}
+
+// This function replaces the ${env:NAME} placeholders in the update-descriptor3.yaml in the exploded update directory
+// with the values of the environment variables.
+func resolveExplodedUpdateDescriptorV3EnvPlaceholders(resumeFile *ResumeFile,
+	updateDescriptorV3 *util.UpdateDescriptorV3) {
+	err := util.ResolveUpdateDescriptorV3EnvPlaceholders(updateDescriptorV3)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while resolving the placeholders in '%s'.",
+		constant.UPDATE_DESCRIPTOR_V3_FILE))
+	updateDescriptorV3Path := filepath.Join(resumeFile.ExplodedUpdateDirectoryPath, constant.UPDATE_DESCRIPTOR_V3_FILE)
+	data, err := yaml.Marshal(updateDescriptorV3)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while marshalling '%s'.", updateDescriptorV3Path))
+	err = ioutil.WriteFile(updateDescriptorV3Path, data, 0600)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while writing '%s'.", updateDescriptorV3Path))
+}
+
+// This function returns the payload directory of the update being created.
+func getPayloadDirectory() string {
+	if payloadDirectory := viper.GetString(constant.PAYLOAD_DIRECTORY); len(payloadDirectory) != 0 {
+		return payloadDirectory
+	}
+	return constant.CARBON_HOME
+}
+
+// This function returns the payload directory of the update of the given resume file. Payload of the updates of resume
+// files written before the payload directory was recorded is in carbon.home.
+func getResumedPayloadDirectory(resumeFile *ResumeFile) string {
+	if len(resumeFile.PayloadDirectory) != 0 {
+		return resumeFile.PayloadDirectory
+	}
+	return constant.CARBON_HOME
+}
+
+// This function checks whether the payload directory declared in the update-descriptor3.yaml is the directory which
+// the payload was copied to when creating the update.
+func checkPayloadDirectory(resumeFile *ResumeFile, updateDescriptorV3 *util.UpdateDescriptorV3) {
+	payloadDirectory := util.GetPayloadDirectory(updateDescriptorV3)
+	if payloadDirectory != getResumedPayloadDirectory(resumeFile) {
+		util.HandleErrorAndExit(errors.New(fmt.Sprintf("'payload_directory' of '%s' should be '%s', found '%s'. "+
+			"Payload directory cannot be changed after creating the update.", constant.UPDATE_DESCRIPTOR_V3_FILE,
+			getResumedPayloadDirectory(resumeFile), payloadDirectory)))
+	}
+}
+
+// This function checks whether each templated file declared in the update-descriptor3.yaml is in the payload and
+// contains the placeholders of its substitution rules.
+func validateTemplatedFiles(resumeFile *ResumeFile, updateDescriptorV3 *util.UpdateDescriptorV3) {
+	carbonHome := filepath.Join(resumeFile.ExplodedUpdateDirectoryPath, getResumedPayloadDirectory(resumeFile))
+	for _, templatedFile := range updateDescriptorV3.TemplatedFiles {
+		templatedFilePath := filepath.Join(carbonHome, filepath.FromSlash(templatedFile.Path))
+		data, err := ioutil.ReadFile(templatedFilePath)
+		util.HandleErrorAndExit(err, fmt.Sprintf("Templated file '%s' is not found in '%s'.", templatedFile.Path,
+			getResumedPayloadDirectory(resumeFile)))
+		err = util.ValidateTemplatedFile(data, &templatedFile)
+		util.HandleErrorAndExit(err, fmt.Sprintf("'%s' is invalid.", constant.UPDATE_DESCRIPTOR_V3_FILE))
+		logger.Debug(fmt.Sprintf("Templated file %s validated", templatedFile.Path))
+	}
+}
+
+// This function checks whether each executable file declared in the update-descriptor3.yaml is valid and is a file in
+// the payload, and records them in the resume file.
+func validateExecutableFiles(resumeFile *ResumeFile, updateDescriptorV3 *util.UpdateDescriptorV3) {
+	err := util.ValidateExecutableFiles(updateDescriptorV3)
+	util.HandleErrorAndExit(err, fmt.Sprintf("'%s' is invalid.", constant.UPDATE_DESCRIPTOR_V3_FILE))
+	carbonHome := filepath.Join(resumeFile.ExplodedUpdateDirectoryPath, getResumedPayloadDirectory(resumeFile))
+	for _, executableFile := range updateDescriptorV3.ExecutableFiles {
+		fileInfo, err := os.Stat(filepath.Join(carbonHome, filepath.FromSlash(executableFile)))
+		if err != nil || fileInfo.IsDir() {
+			util.HandleErrorAndExit(errors.New(fmt.Sprintf("Executable file '%s' is not found in '%s'.",
+				executableFile, getResumedPayloadDirectory(resumeFile))))
+		}
+		logger.Debug(fmt.Sprintf("Executable file %s validated", executableFile))
+	}
+	resumeFile.ExecutableFiles = updateDescriptorV3.ExecutableFiles
+}
+
+// This function checks whether each OS variant declared in the update-descriptor3.yaml is valid and is in the payload.
+func validateOSVariants(resumeFile *ResumeFile, updateDescriptorV3 *util.UpdateDescriptorV3) {
+	err := util.ValidateOSVariants(updateDescriptorV3)
+	util.HandleErrorAndExit(err, fmt.Sprintf("'%s' is invalid.", constant.UPDATE_DESCRIPTOR_V3_FILE))
+	carbonHome := filepath.Join(resumeFile.ExplodedUpdateDirectoryPath, getResumedPayloadDirectory(resumeFile))
+	for _, variant := range updateDescriptorV3.OSVariants {
+		exists, err := util.IsFileExists(filepath.Join(carbonHome, filepath.FromSlash(variant.Path)))
+		util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while checking '%s'.", variant.Path))
+		if !exists {
+			util.HandleErrorAndExit(errors.New(fmt.Sprintf("OS variant '%s' is not found in '%s'.", variant.Path,
+				carbonHome)))
+		}
+		logger.Debug(fmt.Sprintf("OS variant %s of %v validated", variant.Path, variant.OS))
+	}
+}
+
+// This function checks whether the preview declared in the update-descriptor3.yaml is valid and has not expired, and
+// records its expiry date in the resume file.
+func validatePreview(resumeFile *ResumeFile, updateDescriptorV3 *util.UpdateDescriptorV3) {
+	resumeFile.PreviewExpires = ""
+	if updateDescriptorV3.Preview == nil {
+		return
+	}
+	isExpired, err := updateDescriptorV3.Preview.IsExpired(time2.Now().UTC())
+	util.HandleErrorAndExit(err, fmt.Sprintf("'%s' is invalid.", constant.UPDATE_DESCRIPTOR_V3_FILE))
+	if isExpired {
+		util.HandleErrorAndExit(errors.New(fmt.Sprintf("preview of '%s' expired on %s. Update the 'expires' "+
+			"field in '%s'.", resumeFile.UpdateName, updateDescriptorV3.Preview.Expires,
+			constant.UPDATE_DESCRIPTOR_V3_FILE)))
+	}
+	resumeFile.PreviewExpires = updateDescriptorV3.Preview.Expires
+	logger.Debug(fmt.Sprintf("Preview of %s expires on %s", resumeFile.UpdateName, resumeFile.PreviewExpires))
+}
+
+// This function copies the added and modified files of the products which declare a payload root in the
+// update-descriptor3.yaml from the payload directory to their payload roots in the exploded update directory.
+func placeProductPayloads(resumeFile *ResumeFile, updateDescriptorV3 *util.UpdateDescriptorV3) {
+	err := util.ValidatePayloadRoots(updateDescriptorV3)
+	util.HandleErrorAndExit(err, fmt.Sprintf("'%s' is invalid.", constant.UPDATE_DESCRIPTOR_V3_FILE))
+
+	carbonHome := filepath.Join(resumeFile.ExplodedUpdateDirectoryPath, getResumedPayloadDirectory(resumeFile))
+	for payloadRoot, products := range util.GetProductsByPayloadRoot(updateDescriptorV3) {
+		payloadRootPath := filepath.Join(resumeFile.ExplodedUpdateDirectoryPath, filepath.FromSlash(payloadRoot))
+		for _, product := range products {
+			for _, changedFile := range append(append([]string{}, product.AddedFiles...), product.ModifiedFiles...) {
+				source := filepath.Join(carbonHome, filepath.FromSlash(changedFile))
+				exists, err := util.IsFileExists(source)
+				util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while checking '%s'.", source))
+				if !exists {
+					util.HandleErrorAndExit(errors.New(fmt.Sprintf("'%s' of '%s-%s' is not found in '%s'.",
+						changedFile, product.ProductName, product.ProductVersion,
+						getResumedPayloadDirectory(resumeFile))))
+				}
+				destination := filepath.Join(payloadRootPath, filepath.FromSlash(changedFile))
+				err = util.CreateDirectory(filepath.Dir(destination))
+				util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while creating '%s'.",
+					filepath.Dir(destination)))
+				logger.Debug(fmt.Sprintf("Copying %s to %s", source, destination))
+				err = util.CopyFile(source, destination)
+				util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while copying '%s' to '%s'.", source,
+					destination))
+			}
+		}
+		logger.Debug(fmt.Sprintf("Files of %d product(s) placed in %s", len(products), payloadRootPath))
+	}
+}
+
+// This function checks whether the given file name matches one of the given patterns.
+func matchesAnyPattern(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := filepath.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// This function reads the content of the given zip entry.
+func readZipEntry(file *zip.File) ([]byte, error) {
+	zippedFile, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer zippedFile.Close()
+	return ioutil.ReadAll(zippedFile)
+}
+
+// This function returns the file extension of the update archive of the given resume state. Updates created before
+// the format was recorded are zip files.
+func getUpdateArchiveExtension(resumeFile *ResumeFile) string {
+	if resumeFile.Format == "" {
+		return constant.ZIP_FILE_EXTENSION
+	}
+	extension, err := util.GetUpdateArchiveExtension(resumeFile.Format)
+	util.HandleErrorAndExit(err)
+	return extension
+}
+
+// This function returns the name of the update artifact which is committed to the SVN. It is the update zip or the
+// encrypted update zip if the update is encrypted.
+func getUpdateArtifactName(resumeFile *ResumeFile) string {
+	updateArtifactName := resumeFile.UpdateName + getUpdateArchiveExtension(resumeFile)
+	if len(resumeFile.EncryptionRecipients) != 0 {
+		updateArtifactName += constant.ENCRYPTED_FILE_EXTENSION
+	}
+	return updateArtifactName
+}
+
+// This function writes the SLSA provenance attestation of the created update next to it. The attestation is signed if
+// a signing key is configured.
+func generateProvenance(resumeFile *ResumeFile) {
+	artifactPath := getUpdateArtifactName(resumeFile)
+	statement, err := util.NewProvenanceStatement(artifactPath, Version, resumeFile.BuildCommand,
+		resumeFile.BuildStartedOn, resumeFile.DistributionPath, getUpdateDirectoryPath(resumeFile))
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while generating the provenance of '%s'.",
+		artifactPath))
+	signingKeyPath := viper.GetString(constant.PROVENANCE_SIGNING_KEY)
+	data, err := util.GetProvenanceEnvelope(statement, signingKeyPath)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while signing the provenance of '%s'.", artifactPath))
+	provenancePath := artifactPath + constant.PROVENANCE_FILE_EXTENSION
+	err = ioutil.WriteFile(provenancePath, data, 0644)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while writing the provenance to '%s'.",
+		provenancePath))
+	if len(signingKeyPath) == 0 {
+		util.PrintInfo(fmt.Sprintf("Provenance of '%s' written to '%s'. It is not signed as '%s' is not "+
+			"configured.", artifactPath, provenancePath, constant.PROVENANCE_SIGNING_KEY))
+		return
+	}
+	util.PrintInfo(fmt.Sprintf("Signed provenance of '%s' written to '%s'.", artifactPath, provenancePath))
+}
+
+// This function encrypts the update zip for the recipients of the given resume state and removes the update zip.
+// Public keys of the recipients are read from the config file.
+func encryptUpdate(resumeFile *ResumeFile) {
+	recipients := getEncryptionRecipients(resumeFile.EncryptionRecipients)
+	updateZipName := resumeFile.UpdateName + getUpdateArchiveExtension(resumeFile)
+	encryptedUpdateZipName := getUpdateArtifactName(resumeFile)
+	logger.Debug(fmt.Sprintf("Encrypting %s for %v", updateZipName, resumeFile.EncryptionRecipients))
+	err := util.EncryptFile(updateZipName, encryptedUpdateZipName, recipients, nil)
+	if err != nil {
+		util.CleanUpFile(encryptedUpdateZipName)
+		util.HandleErrorAndExit(err, fmt.Sprintf("error occurred when encrypting %s", updateZipName))
+	}
+	util.CleanUpFile(updateZipName)
+	util.PrintInfo(fmt.Sprintf("'%s' encrypted for %s.", updateZipName,
+		strings.Join(resumeFile.EncryptionRecipients, ", ")))
+}
+
+// This function gets the locations of the public keys of the given recipients from the config file.
+func getEncryptionRecipients(names []string) map[string]string {
+	publicKeys := make(map[string]string)
+	for name, publicKeyPath := range viper.GetStringMapString(constant.ENCRYPTION_RECIPIENTS) {
+		// Keys of the maps read from the config file are case insensitive
+		publicKeys[strings.ToLower(name)] = publicKeyPath
+	}
+	recipients := make(map[string]string)
+	for _, name := range names {
+		publicKeyPath, found := publicKeys[strings.ToLower(name)]
+		if !found {
+			util.HandleErrorAndExit(errors.New(fmt.Sprintf("public key of the recipient '%s' is not found in "+
+				"'%s' of the config file", name, constant.ENCRYPTION_RECIPIENTS)))
+		}
+		recipients[name] = publicKeyPath
+	}
+	return recipients
+}
+
 // This function will validate the created update zip before committing it to the pointed SVN.
 func validateUpdate(resumeFile *ResumeFile) {
+	util.PublishStageStarted(constant.STAGE_VALIDATE_ZIP)
+	defer util.PublishStageFinished(constant.STAGE_VALIDATE_ZIP)
 	// Get absolute location of the created update zip
-	updateZipName := resumeFile.UpdateName + ".zip"
+	updateZipName := resumeFile.UpdateName + getUpdateArchiveExtension(resumeFile)
 	updateZipPath, err := filepath.Abs(updateZipName)
 	if err != nil {
 		updateZipPath = updateZipName
 	}
-	startValidation(updateZipPath, resumeFile.DistributionPath)
+	// tar.zst updates are verified using a zip with the same entries
+	if util.IsTarZstFile(updateZipPath) {
+		updateZipPath = convertToUpdateZip(updateZipPath)
+		defer util.CleanUpDirectory(filepath.Dir(updateZipPath))
+	}
+	// Verify the created update zip against the exploded update directory it was created from
+	verificationResults := verifyUpdateZip(updateZipPath, resumeFile.ExplodedUpdateDirectoryPath,
+		resumeFile.UpdateName)
+	printVerificationSummary(updateZipName, verificationResults)
+	for _, result := range verificationResults {
+		if !result.passed {
+			util.HandleErrorAndExit(errors.New(fmt.Sprintf("self-verification of '%s' failed. Please recreate "+
+				"the update using 'wum-uc create --continue'", updateZipName)))
+		}
+	}
+	// Check the update against the catalog of released updates if given
+	if catalogLocation := viper.GetString(constant.UPDATE_CATALOG_LOCATION); len(catalogLocation) != 0 {
+		checkAgainstCatalog(updateZipPath, catalogLocation, viper.GetString(constant.UPDATE_CATALOG_KEY))
+	}
+	// Platform-wide updates are validated against all the listed distributions
+	listedDistributions = resumeFile.ListedDistributions
+	// Policies are only enforced during the update creation in strict mode
+	startValidation(updateZipPath, resumeFile.DistributionPath, viper.GetBool(constant.STRICT_MODE))
+}
+
+// This struct is used to store the result of a single check performed when verifying the created update zip.
+type verificationResult struct {
+	check   string
+	passed  bool
+	details string
+}
+
+// This function re-opens the created update zip and verifies that the entries and their md5 hashes match the
+// exploded update directory (which acts as the placement manifest) and that the update descriptors in the zip can
+// be parsed and validated.
+func verifyUpdateZip(updateZipPath, explodedUpdateDirectoryPath, updateName string) []verificationResult {
+	logger.Debug(fmt.Sprintf("Verifying %s against %s", updateZipPath, explodedUpdateDirectoryPath))
+	var results []verificationResult
+
+	// Get md5 hashes of the files in the exploded update directory
+	expectedEntries, err := getPlacementManifest(explodedUpdateDirectoryPath, updateName)
+	if err != nil {
+		return append(results, verificationResult{"Placement manifest", false, err.Error()})
+	}
+	// Get md5 hashes of the files in the created update zip
+	actualEntries, descriptors, err := readUpdateZipEntries(updateZipPath)
+	if err != nil {
+		return append(results, verificationResult{"Update zip", false, err.Error()})
+	}
+
+	// Check whether the entry lists are the same
+	var missingEntries, unknownEntries, mismatchedEntries []string
+	for entry, expectedMD5 := range expectedEntries {
+		actualMD5, found := actualEntries[entry]
+		if !found {
+			missingEntries = append(missingEntries, entry)
+		} else if actualMD5 != expectedMD5 {
+			mismatchedEntries = append(mismatchedEntries, entry)
+		}
+	}
+	for entry := range actualEntries {
+		if _, found := expectedEntries[entry]; !found {
+			unknownEntries = append(unknownEntries, entry)
+		}
+	}
+	sort.Strings(missingEntries)
+	sort.Strings(unknownEntries)
+	sort.Strings(mismatchedEntries)
+	if len(missingEntries) == 0 && len(unknownEntries) == 0 {
+		results = append(results, verificationResult{"Entry list", true,
+			fmt.Sprintf("%d entries", len(expectedEntries))})
+	} else {
+		results = append(results, verificationResult{"Entry list", false,
+			fmt.Sprintf("missing: %v, unknown: %v", missingEntries, unknownEntries)})
+	}
+	if len(mismatchedEntries) == 0 {
+		results = append(results, verificationResult{"Entry hashes", true, "all md5 hashes match"})
+	} else {
+		results = append(results, verificationResult{"Entry hashes", false,
+			fmt.Sprintf("md5 mismatch: %v", mismatchedEntries)})
+	}
+
+	// Check whether the update descriptors parse and validate
+	updateDescriptorV3Data, found := descriptors[constant.UPDATE_DESCRIPTOR_V3_FILE]
+	if !found {
+		results = append(results, verificationResult{constant.UPDATE_DESCRIPTOR_V3_FILE, false, "not found"})
+	} else {
+		updateDescriptorV3 := util.UpdateDescriptorV3{}
+		err = util.UnmarshalUpdateDescriptor(updateDescriptorV3Data, constant.UPDATE_DESCRIPTOR_V3_FILE,
+			&updateDescriptorV3)
+		if err == nil {
+			err = util.ValidateUpdateDescriptorV3(&updateDescriptorV3)
+		}
+		results = append(results, getDescriptorVerificationResult(constant.UPDATE_DESCRIPTOR_V3_FILE, err))
+	}
+	// update-descriptor.yaml is only available in backward compatible updates
+	if updateDescriptorV2Data, found := descriptors[constant.UPDATE_DESCRIPTOR_V2_FILE]; found {
+		updateDescriptorV2 := util.UpdateDescriptorV2{}
+		err = util.UnmarshalUpdateDescriptor(updateDescriptorV2Data, constant.UPDATE_DESCRIPTOR_V2_FILE,
+			&updateDescriptorV2)
+		if err == nil {
+			err = util.ValidateUpdateDescriptorV2(&updateDescriptorV2)
+		}
+		results = append(results, getDescriptorVerificationResult(constant.UPDATE_DESCRIPTOR_V2_FILE, err))
+	}
+	return results
+}
+
+// This function returns the verification result of an update descriptor.
+func getDescriptorVerificationResult(descriptorName string, err error) verificationResult {
+	if err != nil {
+		return verificationResult{descriptorName, false, err.Error()}
+	}
+	return verificationResult{descriptorName, true, "parsed and validated"}
+}
+
+// This function returns a map of files in the exploded update directory against their md5 hashes. Keys are the entry
+// names which are expected in the update zip.
+func getPlacementManifest(explodedUpdateDirectoryPath, updateName string) (map[string]string, error) {
+	manifest := make(map[string]string)
+	err := filepath.Walk(explodedUpdateDirectoryPath, func(absolutePath string, fileInfo os.FileInfo,
+		err error) error {
+		if err != nil {
+			return err
+		}
+		if fileInfo.IsDir() {
+			return nil
+		}
+		relativePath, err := filepath.Rel(explodedUpdateDirectoryPath, absolutePath)
+		if err != nil {
+			return err
+		}
+		md5Sum, err := util.GetMD5(absolutePath)
+		if err != nil {
+			return err
+		}
+		manifest[filepath.ToSlash(filepath.Join(updateName, relativePath))] = md5Sum
+		return nil
+	})
+	return manifest, err
+}
+
+// This function returns a map of files in the given update zip against their md5 hashes and a map of update
+// descriptors found in the root of the update against their content.
+func readUpdateZipEntries(updateZipPath string) (map[string]string, map[string][]byte, error) {
+	entries := make(map[string]string)
+	descriptors := make(map[string][]byte)
+	zipReader, err := zip.OpenReader(updateZipPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer zipReader.Close()
+	for _, file := range zipReader.Reader.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+		zippedFile, err := file.Open()
+		if err != nil {
+			return nil, nil, err
+		}
+		data, err := ioutil.ReadAll(zippedFile)
+		zippedFile.Close()
+		if err != nil {
+			return nil, nil, err
+		}
+		entries[file.Name] = fmt.Sprintf("%x", md5.Sum(data))
+		// Update descriptors should be in the root directory of the update
+		if strings.Count(file.Name, "/") == 1 {
+			name := getFileName(file.Name)
+			if name == constant.UPDATE_DESCRIPTOR_V2_FILE || name == constant.UPDATE_DESCRIPTOR_V3_FILE {
+				descriptors[name] = data
+			}
+		}
+	}
+	return entries, descriptors, nil
+}
+
+// This function prints the summary of the update zip verification.
+func printVerificationSummary(updateZipName string, results []verificationResult) {
+	util.PrintInBold(fmt.Sprintf("\nSelf-verification summary of '%s':\n", updateZipName))
+	summaryTable := tablewriter.NewWriter(os.Stdout)
+	summaryTable.SetAlignment(tablewriter.ALIGN_LEFT)
+	summaryTable.SetHeader([]string{"Check", "Result", "Details"})
+	allPassed := true
+	for _, result := range results {
+		status := "PASS"
+		if !result.passed {
+			status = "FAIL"
+			allPassed = false
+		}
+		summaryTable.Append([]string{result.check, status, result.details})
+	}
+	if util.IsJSONIOEnabled() {
+		for _, result := range results {
+			if result.passed {
+				util.PrintInfo(fmt.Sprintf("[PASS] %s: %s", result.check, result.details))
+			} else {
+				util.PrintError(fmt.Sprintf("[FAIL] %s: %s", result.check, result.details))
+			}
+		}
+	} else {
+		summaryTable.Render()
+	}
+	if allPassed {
+		util.PrintInfo("Self-verification passed.")
+	} else {
+		util.PrintError("Self-verification failed.")
+	}
 }
 
 // This function will commit the created update zip to the update SVN repo.
 func commitUpdateToSVN(resumeFile *ResumeFile) {
+	util.PublishStageStarted(constant.STAGE_COMMIT)
+	defer util.PublishStageFinished(constant.STAGE_COMMIT)
 	var stdOut, stdErr bytes.Buffer
 
-	fmt.Println(fmt.Sprintf("Committing %s.zip to the update SVN repo started ...", resumeFile.UpdateName))
+	// Updates should be approved by the required number of reviewers other than the developer before publishing
+	checkApprovals(getUpdateArtifactName(resumeFile), resumeFile.Developer)
+
+	util.PrintMessage(fmt.Sprintf("Committing %s to the update SVN repo started ...",
+		getUpdateArtifactName(resumeFile)))
 	// Handle interrupts received during processing
 	cleanupChannel := util.HandleInterrupts(func() {
 		updateDirectory := constant.SVN_UPDATES + resumeFile.UpdateNumber
@@ -1837,14 +3878,19 @@ func commitUpdateToSVN(resumeFile *ResumeFile) {
 
 	// Request password from user for committing created update zip to the SVN
 	var password []byte
-	fmt.Fprintf(os.Stdout, "Enter password for %s for committing the update to the SVN: ", resumeFile.Developer)
-	password, err := terminal.ReadPassword(int(syscall.Stdin))
+	password, err := util.PromptPassword(fmt.Sprintf("Enter password for %s for committing the update to the "+
+		"SVN: ", resumeFile.Developer))
 	if err != nil {
 		util.HandleErrorAndExit(err, constant.UNABLE_TO_READ_YOUR_INPUT_MSG)
 	}
-	fmt.Fprintln(os.Stdout)
 
+	// Preview updates are published to a separate channel
 	SVNURI := constant.SVN_UPDATE_REPO + "/" + resumeFile.PlatformName + "/" + constant.SVN_UPDATES
+	if len(resumeFile.PreviewExpires) != 0 {
+		SVNURI = constant.SVN_UPDATE_REPO + "/" + resumeFile.PlatformName + "/" + constant.SVN_PREVIEW_UPDATES
+		util.PrintInfo(fmt.Sprintf("%s is a preview which expires on %s. It is published to the %s channel.",
+			resumeFile.UpdateName, resumeFile.PreviewExpires, constant.CHANNEL_PREVIEW))
+	}
 	updateSVNURI := SVNURI + "/" + constant.SVN_UPDATE + resumeFile.UpdateNumber
 
 	// First need to checkout whether the given update is already committed to the SVN.
@@ -1885,7 +3931,30 @@ func commitUpdateToSVN(resumeFile *ResumeFile) {
 	}
 	// Stop interrupts being further received to the 'cleanupchannel' as processing completed successfully
 	signal.Stop(cleanupChannel)
-	fmt.Println(fmt.Sprintf("%s committed successfully to the update SVN repo", resumeFile.UpdateName))
+	util.PrintMessage(fmt.Sprintf("%s committed successfully to the update SVN repo", resumeFile.UpdateName))
+	uploadUpdateToPublishLocations(resumeFile)
+	// Record the published update in the audit log
+	recordAuditEvent(constant.AUDIT_OPERATION_PUBLISH, getUpdateArtifactName(resumeFile))
+}
+
+// This function uploads the created update to each of the publish locations given in the config. Locations can be
+// any artifact URI supported by the storage backends.
+func uploadUpdateToPublishLocations(resumeFile *ResumeFile) {
+	updateArtifactName := getUpdateArtifactName(resumeFile)
+	for _, location := range viper.GetStringSlice(constant.PUBLISH_LOCATIONS) {
+		logger.Debug(fmt.Sprintf("Uploading %s to %s", updateArtifactName, util.RedactArtifactURI(location)))
+		err := util.UploadArtifact(updateArtifactName, util.JoinArtifactURI(location, updateArtifactName))
+		util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while uploading '%s' to '%s'.", updateArtifactName,
+			util.RedactArtifactURI(location)))
+		util.PrintInfo(fmt.Sprintf("'%s' uploaded to '%s'.", updateArtifactName, util.RedactArtifactURI(location)))
+		// Approvals are published alongside the update so that they can be audited
+		approvalsPath := util.GetApprovalsPath(updateArtifactName)
+		if exists, _ := util.IsFileExists(approvalsPath); exists {
+			err = util.UploadArtifact(approvalsPath, util.JoinArtifactURI(location, approvalsPath))
+			util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while uploading '%s' to '%s'.", approvalsPath,
+				util.RedactArtifactURI(location)))
+		}
+	}
 }
 
 // This function creates the update directory at SVN and commit the created update zip to the SVN.
@@ -1924,7 +3993,7 @@ func commitNewUpdateToSVN(resumeFile *ResumeFile, updateSVNURI string, password
 	logger.Debug(fmt.Sprintf("SVN checkout completed successfully to %s", WUMUCHome))
 
 	// Copy the created update.zip file to the checkout location
-	updateZipName := resumeFile.UpdateName + ".zip"
+	updateZipName := getUpdateArtifactName(resumeFile)
 	updateDirectory := constant.SVN_UPDATE + resumeFile.UpdateNumber
 	updateDirectoryPath := path.Join(WUMUCHome, updateDirectory)
 	destination := path.Join(updateDirectoryPath, updateZipName)
@@ -2001,7 +4070,7 @@ func commitUpgradedUpdateToSVN(resumeFile *ResumeFile, updateSVNURI string, pass
 
 // This function commit the newly created update zip with preserving the previously committed update zip.
 func commitUpgradedUpdatesWithPreservingPreviousUpdatesAtSVN(resumeFile *ResumeFile, password []byte) {
-	updateZipName := resumeFile.UpdateName + ".zip"
+	updateZipName := getUpdateArtifactName(resumeFile)
 	updateDirectory := constant.SVN_UPDATE + resumeFile.UpdateNumber
 	updateDirectoryPath := path.Join(WUMUCHome, updateDirectory)
 	oldUpdatesDirectoryPath := path.Join(updateDirectoryPath, constant.OLD_UPDATE_DIRECTORY)
@@ -2015,7 +4084,8 @@ func commitUpgradedUpdatesWithPreservingPreviousUpdatesAtSVN(resumeFile *ResumeF
 	nanoSecUTCTime := utcTime.UnixNano()
 	miliSecUTCTime := nanoSecUTCTime / 1000000
 	// Append the current timestamp for previous update zip name
-	newUpdateZipName := resumeFile.UpdateName + "." + strconv.FormatInt(miliSecUTCTime, 10) + ".zip"
+	newUpdateZipName := resumeFile.UpdateName + "." + strconv.FormatInt(miliSecUTCTime, 10) +
+		strings.TrimPrefix(updateZipName, resumeFile.UpdateName)
 	oldUpdateZipNewPath := path.Join(oldUpdatesDirectoryPath, newUpdateZipName)
 	logger.Trace(fmt.Sprintf("Old update zip new path: %s", oldUpdateZipNewPath))
 	performSVNMoveFile(resumeFile, oldUpdateZipCurrentPath, oldUpdateZipNewPath)
@@ -2060,7 +4130,7 @@ func performSVNMoveFile(resumeFile *ResumeFile, source, destination string) {
 // This function add files to the SVN pending change list.
 func performSVNAddCommand(resumeFile *ResumeFile) {
 	var stdOut, stdErr bytes.Buffer
-	updateZipName := resumeFile.UpdateName + ".zip"
+	updateZipName := getUpdateArtifactName(resumeFile)
 	updateDirectory := constant.SVN_UPDATE + resumeFile.UpdateNumber
 	updateDirectoryPath := path.Join(WUMUCHome, updateDirectory)
 
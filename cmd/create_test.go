@@ -15,9 +15,29 @@
 package cmd
 
 import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"regexp"
 	"strings"
 	"testing"
+	"testing/fstest"
 
+	"github.com/spf13/afero"
+	"github.com/spf13/viper"
 	"github.com/wso2/update-creator-tool/constant"
 	"github.com/wso2/update-creator-tool/util"
 )
@@ -67,12 +87,12 @@ func TestAddToRootNode(t *testing.T) {
 		t.Errorf("Test failed, node '%v' not found.", nodeName)
 	}
 
-	if nodeC.md5Hash != hash {
-		t.Errorf("Test failed, expected: %v, actual: %v", hash, nodeC.md5Hash)
+	if nodeC.hash != hash {
+		t.Errorf("Test failed, expected: %v, actual: %v", hash, nodeC.hash)
 	}
 
 	if nodeC.isDir != isDir {
-		t.Errorf("Test failed, expected: %v, actual: %v", hash, nodeC.md5Hash)
+		t.Errorf("Test failed, expected: %v, actual: %v", hash, nodeC.hash)
 	}
 
 	//Add new file
@@ -103,12 +123,12 @@ func TestAddToRootNode(t *testing.T) {
 		t.Errorf("Test failed, node '%v' not found.", nodeName)
 	}
 
-	if nodeD.md5Hash != hash {
-		t.Errorf("Test failed, expected: %v, actual: %v", hash, nodeD.md5Hash)
+	if nodeD.hash != hash {
+		t.Errorf("Test failed, expected: %v, actual: %v", hash, nodeD.hash)
 	}
 
 	if nodeD.isDir != isDir {
-		t.Errorf("Test failed, expected: %v, actual: %v", hash, nodeD.md5Hash)
+		t.Errorf("Test failed, expected: %v, actual: %v", hash, nodeD.hash)
 	}
 
 }
@@ -177,3 +197,649 @@ func TestPathExists(t *testing.T) {
 		t.Errorf("Test failed, expected: %v, actual: %v", expected, exists)
 	}
 }
+
+func TestAddToRootNodeWithoutDirectoryEntries(t *testing.T) {
+	root := createNewNode()
+	// Directory entries are added after the files in the directory or are not added at all
+	AddToRootNode(&root, strings.Split("a/b/c.jar", "/"), false, "hash1")
+	AddToRootNode(&root, strings.Split("a/b", "/"), true, "")
+
+	exists := PathExists(&root, "a/b/c.jar", false)
+	expected := true
+	if expected != exists {
+		t.Errorf("Test failed, expected: %v, actual: %v", expected, exists)
+	}
+
+	exists = PathExists(&root, "a/b/", true)
+	expected = true
+	if expected != exists {
+		t.Errorf("Test failed, expected: %v, actual: %v", expected, exists)
+	}
+
+	exists = PathExists(&root, "a", true)
+	expected = true
+	if expected != exists {
+		t.Errorf("Test failed, expected: %v, actual: %v", expected, exists)
+	}
+}
+
+func TestApplyReleasedUpdate(t *testing.T) {
+	root := createNewNode()
+	AddToRootNode(&root, strings.Split("repository/components/plugins/a.jar", "/"), false, "hash1")
+	AddToRootNode(&root, strings.Split("repository/components/plugins/b.jar", "/"), false, "hash2")
+
+	appliedUpdate := util.AppliedUpdate{
+		UpdateName:   "WSO2-CARBON-UPDATE-4.4.0-0001",
+		Files:        map[string]string{"repository/components/plugins/a.jar": "hash3", "bin/c.sh": "hash4"},
+		RemovedFiles: []string{"repository/components/plugins/b.jar"},
+	}
+	applyReleasedUpdate(&root, &appliedUpdate)
+
+	if !CheckMD5(&root, strings.Split("repository/components/plugins/a.jar", "/"), "hash3") {
+		t.Errorf("Test failed, modified file of the released update is not applied")
+	}
+	if !CheckMD5(&root, strings.Split("bin/c.sh", "/"), "hash4") {
+		t.Errorf("Test failed, added file of the released update is not applied")
+	}
+	if PathExists(&root, "repository/components/plugins/b.jar", false) {
+		t.Errorf("Test failed, removed file of the released update is not removed")
+	}
+}
+
+func TestGetAllMatchingFiles(t *testing.T) {
+	allFilesMap := map[string]data{
+		"lib":             {isDir: true},
+		"lib/a.jar":       {hash: "hash1"},
+		"lib/ext/b.jar":   {hash: "hash2"},
+		"library/c.jar":   {hash: "hash3"},
+		"lib.txt":         {hash: "hash4"},
+		"conf/carbon.xml": {hash: "hash5"},
+	}
+	updateRootNode := getUpdateRootNode(allFilesMap)
+
+	matches := getAllMatchingFiles("lib", &updateRootNode)
+	expected := []string{"lib/a.jar", "lib/ext/b.jar"}
+	if !reflect.DeepEqual(matches, expected) {
+		t.Errorf("Test failed, expected: %v, actual: %v", expected, matches)
+	}
+	// Files are not matched as directories
+	if matches = getAllMatchingFiles("lib.txt", &updateRootNode); len(matches) != 0 {
+		t.Errorf("Test failed, expected no matches for a file, actual: %v", matches)
+	}
+	if matches = getAllMatchingFiles("bin", &updateRootNode); len(matches) != 0 {
+		t.Errorf("Test failed, expected no matches for a missing directory, actual: %v", matches)
+	}
+}
+
+func TestRecordSkippedFiles(t *testing.T) {
+	defer func() {
+		skippedFiles = nil
+	}()
+	allFilesMap := map[string]data{
+		"lib":           {isDir: true},
+		"lib/a.jar":     {},
+		"lib/ext/b.jar": {},
+		"lib.txt":       {},
+	}
+	updateRootNode := getUpdateRootNode(allFilesMap)
+
+	recordSkippedFiles("lib", true, &updateRootNode, constant.SKIP_REASON_USER_DECLINED, "")
+	recordSkippedFiles("lib.txt", false, &updateRootNode, constant.SKIP_REASON_MD5_MATCHES, "repository")
+	expected := []util.SkippedFile{
+		{Path: "lib/a.jar", Reason: constant.SKIP_REASON_USER_DECLINED},
+		{Path: "lib/ext/b.jar", Reason: constant.SKIP_REASON_USER_DECLINED},
+		{Path: "lib.txt", Reason: constant.SKIP_REASON_MD5_MATCHES, Location: "repository"},
+	}
+	if !reflect.DeepEqual(skippedFiles, expected) {
+		t.Errorf("Test failed, expected: %v, actual: %v", expected, skippedFiles)
+	}
+}
+
+func BenchmarkGetAllMatchingFiles(b *testing.B) {
+	// A library refresh with thousands of files in many root level directories
+	allFilesMap := make(map[string]data)
+	for i := 0; i < 100; i++ {
+		for j := 0; j < 100; j++ {
+			allFilesMap[fmt.Sprintf("dir%d/lib/%d.jar", i, j)] = data{hash: "hash"}
+		}
+	}
+	updateRootNode := getUpdateRootNode(allFilesMap)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		for i := 0; i < 100; i++ {
+			getAllMatchingFiles(fmt.Sprintf("dir%d", i), &updateRootNode)
+		}
+	}
+}
+
+func TestAddNestedArchiveToRootNode(t *testing.T) {
+	viper.Set(constant.NESTED_ARCHIVES_PATTERNS, []string{"*.war"})
+
+	// Create a war file in memory
+	buffer := new(bytes.Buffer)
+	zipWriter := zip.NewWriter(buffer)
+	writer, err := zipWriter.Create("WEB-INF/web.xml")
+	if err != nil {
+		t.Fatalf("Test failed, error occurred while creating the archive: %v", err)
+	}
+	writer.Write([]byte("<web-app/>"))
+	zipWriter.Close()
+
+	root := createNewNode()
+	AddToRootNode(&root, strings.Split("webapps/api.war", "/"), false, "hash1")
+	err = addNestedArchiveToRootNode(&root, "webapps/api.war", bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+	if err != nil {
+		t.Errorf("Test failed, unexpected error: %v", err)
+	}
+
+	exists := PathExists(&root, "webapps/api.war/WEB-INF/web.xml", false)
+	if !exists {
+		t.Errorf("Test failed, expected: %v, actual: %v", true, exists)
+	}
+
+	matches := make(map[string]*node)
+	FindMatches(&root, "web.xml", false, matches)
+	expected := "webapps/api.war/WEB-INF"
+	if _, found := matches[expected]; !found || len(matches) != 1 {
+		t.Errorf("Test failed, expected: %v, actual: %v", expected, matches)
+	}
+
+	// Matches inside nested archives are only used for comparison, so they are not offered as locations to copy
+	archiveMatches := removeNestedArchiveMatches("web.xml", matches)
+	if _, found := archiveMatches[expected]; !found || len(matches) != 0 {
+		t.Errorf("Test failed, expected: %v, actual: %v %v", expected, archiveMatches, matches)
+	}
+	// Nested archive is still a file of the distribution which can be updated
+	matches = make(map[string]*node)
+	FindMatches(&root, "api.war", false, matches)
+	if _, found := matches["webapps"]; !found || len(removeNestedArchiveMatches("api.war", matches)) != 0 {
+		t.Errorf("Test failed, expected: %v, actual: %v", "webapps", matches)
+	}
+
+	// Files which are identical to the files inside the archive are skipped, and the others are rejected
+	tempDir, err := ioutil.TempDir("", "wum-uc-test")
+	if err != nil {
+		t.Fatalf("Test failed, error occurred while creating the temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+	skippedFiles = nil
+	defer func() {
+		skippedFiles = nil
+	}()
+	for content, isSkipped := range map[string]bool{"<web-app/>": true, "<web-app>changed</web-app>": false} {
+		webXMLPath := filepath.Join(tempDir, "web.xml")
+		ioutil.WriteFile(webXMLPath, []byte(content), 0644)
+		allFilesMap := map[string]data{"web.xml": {absolutePath: webXMLPath, size: int64(len(content))}}
+		err = handleNestedArchiveMatches("web.xml", false, archiveMatches, allFilesMap, nil, &root)
+		if (err == nil) != isSkipped {
+			t.Errorf("Test failed, expected skipped: %v, actual error: %v", isSkipped, err)
+		}
+	}
+	if len(skippedFiles) != 1 || skippedFiles[0].Path != "web.xml" {
+		t.Errorf("Test failed, expected: %v, actual: %v", "web.xml", skippedFiles)
+	}
+}
+
+func TestCreateUpdateZipInMemory(t *testing.T) {
+	util.FileSystem = afero.NewMemMapFs()
+	defer func() {
+		util.FileSystem = afero.NewOsFs()
+	}()
+	util.FileSystem.MkdirAll("update/lib", 0700)
+	afero.WriteFile(util.FileSystem, "update/LICENSE.txt", []byte("license"), 0600)
+	afero.WriteFile(util.FileSystem, "update/lib/a.jar", []byte("content"), 0600)
+
+	allFilesMap, rootLevelDirectoriesMap, _, err := readDirectory("update", map[string]bool{"LICENSE.txt": true})
+	if err != nil {
+		t.Fatalf("Test failed, unexpected error: %v", err)
+	}
+	if _, found := allFilesMap["LICENSE.txt"]; found || len(allFilesMap) != 2 || !rootLevelDirectoriesMap["lib"] {
+		t.Errorf("Test failed, unexpected files: %v", allFilesMap)
+	}
+	if fileData := allFilesMap["lib/a.jar"]; fileData.size != 7 || len(fileData.hash) != 0 {
+		t.Errorf("Test failed, unexpected details of lib/a.jar: %v", fileData)
+	}
+
+	// The md5 should be calculated only when the size and the CRC32 match
+	distributionRootNode := createNewNode()
+	AddToRootNode(&distributionRootNode, []string{"lib", "a.jar"}, false, "9a0364b9e99bb480dd25e1f0284c8555")
+	distributionNode := getNode(&distributionRootNode, []string{"lib", "a.jar"})
+	distributionNode.size = 8
+	distributionNode.crc32 = crc32.ChecksumIEEE([]byte("content"))
+	distributionNode.hasCRC32 = true
+	matches, err := checkUpdateFileMD5(&distributionRootNode, []string{"lib", "a.jar"}, allFilesMap, "lib/a.jar")
+	if err != nil || matches || allFilesMap["lib/a.jar"].crc32 != nil || len(allFilesMap["lib/a.jar"].hash) != 0 {
+		t.Errorf("Test failed, checksums calculated for a file with a different size: %v", allFilesMap["lib/a.jar"])
+	}
+	distributionNode.size = 7
+	matches, err = checkUpdateFileMD5(&distributionRootNode, []string{"lib", "a.jar"}, allFilesMap, "lib/a.jar")
+	if err != nil || !matches {
+		t.Errorf("Test failed, expected the md5 of lib/a.jar to match: %v", err)
+	}
+	if md5Hash := allFilesMap["lib/a.jar"].hash; md5Hash != "9a0364b9e99bb480dd25e1f0284c8555" {
+		t.Errorf("Test failed, expected: %v, actual: %v", "9a0364b9e99bb480dd25e1f0284c8555", md5Hash)
+	}
+
+	updateName := "WSO2-CARBON-UPDATE-4.4.0-0001"
+	viper.Set(constant.UPDATE_NAME, updateName)
+	viper.Set(constant.UPDATE_ROOT, "update")
+	err = copyResourceFilesToTempDir("update", map[string]bool{"LICENSE.txt": true})
+	if err != nil {
+		t.Fatalf("Test failed, unexpected error: %v", err)
+	}
+	updateDescriptor := util.UpdateDescriptorV2{}
+	err = copyFile("a.jar", "update/lib", "lib", &distributionRootNode, &updateDescriptor)
+	if err != nil {
+		t.Fatalf("Test failed, unexpected error: %v", err)
+	}
+	if modifiedFiles := updateDescriptor.FileChanges.ModifiedFiles; len(modifiedFiles) != 1 ||
+		modifiedFiles[0] != "lib/a.jar" {
+		t.Errorf("Test failed, expected: %v, actual: %v", []string{"lib/a.jar"}, modifiedFiles)
+	}
+	err = ZipFile(filepath.Join(constant.TEMP_DIR, updateName), updateName+".zip")
+	if err != nil {
+		t.Fatalf("Test failed, unexpected error: %v", err)
+	}
+
+	data, err := afero.ReadFile(util.FileSystem, updateName+".zip")
+	if err != nil {
+		t.Fatalf("Test failed, unexpected error: %v", err)
+	}
+	zipReader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Test failed, unexpected error: %v", err)
+	}
+	zippedFiles := make(map[string]bool)
+	for _, file := range zipReader.File {
+		zippedFiles[file.Name] = true
+	}
+	for _, expectedFile := range []string{updateName + "/LICENSE.txt",
+		path.Join(updateName, getPayloadDirectory(), "lib/a.jar")} {
+		if !zippedFiles[expectedFile] {
+			t.Errorf("Test failed, '%s' not found in the update zip", expectedFile)
+		}
+	}
+}
+
+func TestVerifyUpdateZip(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "wum-uc-test")
+	if err != nil {
+		t.Fatalf("Test failed, error occurred while creating the temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	updateName := "WSO2-CARBON-UPDATE-4.4.0-0001"
+	explodedUpdateDirectory := filepath.Join(tempDir, updateName)
+	filePath := filepath.Join(explodedUpdateDirectory, constant.CARBON_HOME, "a.jar")
+	os.MkdirAll(filepath.Dir(filePath), 0700)
+	ioutil.WriteFile(filePath, []byte("content"), 0600)
+	updateZipPath := filepath.Join(tempDir, updateName+".zip")
+	err = ZipFile(explodedUpdateDirectory, updateZipPath)
+	if err != nil {
+		t.Fatalf("Test failed, error occurred while creating the zip: %v", err)
+	}
+
+	results := verifyUpdateZip(updateZipPath, explodedUpdateDirectory, updateName)
+	for _, result := range results[:2] {
+		if !result.passed {
+			t.Errorf("Test failed, expected '%s' to pass: %s", result.check, result.details)
+		}
+	}
+	// update-descriptor3.yaml is not in the zip
+	if results[2].passed {
+		t.Errorf("Test failed, expected '%s' to fail", results[2].check)
+	}
+
+	// Modify the file after creating the zip
+	ioutil.WriteFile(filePath, []byte("modified content"), 0600)
+	results = verifyUpdateZip(updateZipPath, explodedUpdateDirectory, updateName)
+	if results[1].passed {
+		t.Errorf("Test failed, expected '%s' to fail", results[1].check)
+	}
+}
+
+func TestHandleCommandOutput(t *testing.T) {
+	answerFile := AnswerFile{Answers: []Answer{
+		{Prompt: "Enter 'update number'", Answer: "0001"},
+		{Prompt: "Copy anyway", Answer: "y", Repeat: true},
+	}}
+	for i := range answerFile.Answers {
+		answerFile.Answers[i].regex = regexp.MustCompile(answerFile.Answers[i].Prompt)
+	}
+	output := strings.Join([]string{
+		`{"type":"prompt","id":1,"message":"Enter 'update number':"}`,
+		`{"type":"prompt","id":2,"message":"Copy anyway? [y/n/R]:"}`,
+		`not a json io message`,
+		`{"type":"prompt","id":3,"message":"Copy anyway? [y/n/R]:"}`,
+		`{"type":"error","message":"something went wrong"}`,
+		`{"type":"warning-summary","message":"1 warning(s) raised","warnings":[{"group":"policy","message":"m"}]}`,
+	}, "\n")
+	var answers bytes.Buffer
+	lastError, warnings, err := handleCommandOutput(&answerFile, strings.NewReader(output), &answers)
+	if err != nil {
+		t.Fatalf("Test failed, unexpected error: %v", err)
+	}
+	if lastError != "something went wrong" {
+		t.Errorf("Test failed, expected: %s, actual: %s", "something went wrong", lastError)
+	}
+	if len(warnings) != 1 || warnings[0].Group != constant.WARNING_GROUP_POLICY {
+		t.Errorf("Test failed, unexpected warnings: %v", warnings)
+	}
+	expected := `{"id":1,"answer":"0001"}` + "\n" + `{"id":2,"answer":"y"}` + "\n" + `{"id":3,"answer":"y"}` + "\n"
+	if answers.String() != expected {
+		t.Errorf("Test failed, expected: %s, actual: %s", expected, answers.String())
+	}
+	// Answers which are not repeated are only used once
+	_, _, err = handleCommandOutput(&answerFile, strings.NewReader(
+		`{"type":"prompt","id":4,"message":"Enter 'update number':"}`), &answers)
+	if err == nil {
+		t.Errorf("Test failed, expected an error")
+	}
+}
+
+func TestCheckRemoveOnlyUpdate(t *testing.T) {
+	root := createNewNode()
+	AddToRootNode(&root, strings.Split("repository/components/plugins/a.jar", "/"), false, "hash1")
+	if err := checkRemovedFiles(&root, nil); err == nil {
+		t.Errorf("Test failed, expected an error when no removed files are given")
+	}
+	if err := checkRemovedFiles(&root, []string{"repository/components/plugins/b.jar"}); err == nil {
+		t.Errorf("Test failed, expected an error when a removed file is not in the distribution")
+	}
+	if err := checkRemovedFiles(&root, []string{"repository/components/plugins/a.jar"}); err != nil {
+		t.Errorf("Test failed, unexpected error: %v", err)
+	}
+
+	updateName := "WSO2-CARBON-UPDATE-4.4.0-0001"
+	viper.Set(constant.UPDATE_NAME, updateName)
+	updateDescriptorV3 := util.UpdateDescriptorV3{CompatibleProducts: []util.ProductChanges{
+		{ProductName: "wso2am", RemovedFiles: []string{"repository/components/plugins/a.jar"}},
+	}}
+	distributionFileMap := map[string]bool{"repository/components/plugins/a.jar": false}
+	updateFileMap := map[string]bool{updateName + "/README.txt": false}
+	err := checkRemoveOnlyUpdate(updateFileMap, nil, distributionFileMap, &updateDescriptorV3)
+	if err != nil {
+		t.Errorf("Test failed, unexpected error: %v", err)
+	}
+	updateFileMap["repository/components/plugins/b.jar"] = false
+	if err = checkRemoveOnlyUpdate(updateFileMap, nil, distributionFileMap, &updateDescriptorV3); err == nil {
+		t.Errorf("Test failed, expected an error when the update has a payload")
+	}
+	delete(updateFileMap, "repository/components/plugins/b.jar")
+	delete(distributionFileMap, "repository/components/plugins/a.jar")
+	if err = checkRemoveOnlyUpdate(updateFileMap, nil, distributionFileMap, &updateDescriptorV3); err == nil {
+		t.Errorf("Test failed, expected an error when a removed file is not in the distribution")
+	}
+}
+
+func TestExecutableFiles(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "wum-uc-test")
+	if err != nil {
+		t.Fatalf("Test failed, error occurred while creating the temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	updateName := "WSO2-CARBON-UPDATE-4.4.0-0001"
+	explodedUpdateDirectory := filepath.Join(tempDir, updateName)
+	for _, file := range []string{"bin/wso2server.sh", "bin/version.txt"} {
+		filePath := filepath.Join(explodedUpdateDirectory, constant.CARBON_HOME, filepath.FromSlash(file))
+		os.MkdirAll(filepath.Dir(filePath), 0700)
+		ioutil.WriteFile(filePath, []byte("content"), 0600)
+	}
+	updateZipPath := filepath.Join(tempDir, updateName+".zip")
+	err = ZipFile(explodedUpdateDirectory, updateZipPath, constant.CARBON_HOME+"/bin/wso2server.sh")
+	if err != nil {
+		t.Fatalf("Test failed, error occurred while creating the zip: %v", err)
+	}
+	fileModes, err := getUpdateFileModes(updateZipPath, updateName, constant.CARBON_HOME)
+	if err != nil {
+		t.Fatalf("Test failed, error occurred while reading the zip: %v", err)
+	}
+	if len(fileModes) != 2 || fileModes["bin/wso2server.sh"]&constant.EXECUTE_PERMISSIONS == 0 ||
+		fileModes["bin/version.txt"]&constant.EXECUTE_PERMISSIONS != 0 {
+		t.Errorf("Test failed, unexpected file modes: %v", fileModes)
+	}
+
+	updateDescriptorV3 := util.UpdateDescriptorV3{ExecutableFiles: []string{"bin/wso2server.sh"}}
+	if err = checkExecutableFiles(fileModes, &updateDescriptorV3); err != nil {
+		t.Errorf("Test failed, unexpected error: %v", err)
+	}
+	for _, executableFiles := range [][]string{{"bin/version.txt"}, {"bin/wso2server.bat"}, {"../bin/wso2server.sh"},
+		{"bin/wso2server.sh", "bin/wso2server.sh"}} {
+		updateDescriptorV3.ExecutableFiles = executableFiles
+		if err = checkExecutableFiles(fileModes, &updateDescriptorV3); err == nil {
+			t.Errorf("Test failed, expected an error for %v", executableFiles)
+		}
+	}
+}
+
+func TestLongPaths(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "wum-uc-test")
+	if err != nil {
+		t.Fatalf("Test failed, error occurred while creating the temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	// Paths longer than 260 characters cannot be accessed in Windows without the extended-length prefix
+	var directories []string
+	for i := 0; i < 10; i++ {
+		directories = append(directories, fmt.Sprintf("directory-with-a-long-name-%02d", i))
+	}
+	relativePath := strings.Join(directories, "/") + "/a.jar"
+	updateName := "WSO2-CARBON-UPDATE-4.4.0-0001"
+	explodedUpdateDirectory := filepath.Join(tempDir, updateName)
+	filePath := filepath.Join(explodedUpdateDirectory, constant.CARBON_HOME, filepath.FromSlash(relativePath))
+	if len(filePath) <= 260 {
+		t.Fatalf("Test failed, '%s' is not a long path", filePath)
+	}
+	if err = util.CreateDirectory(filepath.Dir(filePath)); err != nil {
+		t.Fatalf("Test failed, error occurred while creating the directory: %v", err)
+	}
+	if err = util.WriteFileToDestination([]byte("content"), filePath); err != nil {
+		t.Fatalf("Test failed, error occurred while writing the file: %v", err)
+	}
+
+	allFilesMap, _, _, err := readDirectory(filepath.Join(explodedUpdateDirectory, constant.CARBON_HOME), nil)
+	if err != nil {
+		t.Fatalf("Test failed, unexpected error: %v", err)
+	}
+	if _, found := allFilesMap[relativePath]; !found {
+		t.Errorf("Test failed, '%s' not found in %v", relativePath, allFilesMap)
+	}
+
+	updateZipPath := filepath.Join(tempDir, updateName+".zip")
+	if err = ZipFile(explodedUpdateDirectory, updateZipPath); err != nil {
+		t.Fatalf("Test failed, error occurred while creating the zip: %v", err)
+	}
+	fileSizes, err := getUpdateFileSizes(updateZipPath, updateName, constant.CARBON_HOME)
+	if err != nil {
+		t.Fatalf("Test failed, error occurred while reading the zip: %v", err)
+	}
+	if len(fileSizes) != 1 || fileSizes[relativePath] != uint64(len("content")) {
+		t.Errorf("Test failed, unexpected file sizes: %v", fileSizes)
+	}
+}
+
+func TestWriteTree(t *testing.T) {
+	root := createNewNode()
+	AddToRootNode(&root, strings.Split("repository/components/plugins/b.jar", "/"), false, "hash2")
+	AddToRootNode(&root, strings.Split("repository/components/plugins/a.jar", "/"), false, "hash1")
+	AddToRootNode(&root, strings.Split("bin/wso2server.sh", "/"), false, "hash3")
+	tree := getTreeNode(getNode(&root, strings.Split("repository/components", "/")))
+
+	var output bytes.Buffer
+	if err := writeTree(&output, tree, constant.OUTPUT_FORMAT_TREE); err != nil {
+		t.Fatalf("Test failed, unexpected error: %v", err)
+	}
+	expected := "plugins/\n  a.jar  hash1\n  b.jar  hash2\n"
+	if output.String() != expected {
+		t.Errorf("Test failed, expected: %s, actual: %s", expected, output.String())
+	}
+
+	output.Reset()
+	if err := writeTree(&output, getTreeNode(&root), constant.OUTPUT_FORMAT_CSV); err != nil {
+		t.Fatalf("Test failed, unexpected error: %v", err)
+	}
+	expected = "path,type,md5\nbin,directory,\nbin/wso2server.sh,file,hash3\nrepository,directory,\n" +
+		"repository/components,directory,\nrepository/components/plugins,directory,\n" +
+		"repository/components/plugins/a.jar,file,hash1\nrepository/components/plugins/b.jar,file,hash2\n"
+	if output.String() != expected {
+		t.Errorf("Test failed, expected: %s, actual: %s", expected, output.String())
+	}
+
+	output.Reset()
+	if err := writeTree(&output, tree, constant.OUTPUT_FORMAT_JSON); err != nil {
+		t.Fatalf("Test failed, unexpected error: %v", err)
+	}
+	exportedTree := TreeNode{}
+	if err := json.Unmarshal(output.Bytes(), &exportedTree); err != nil {
+		t.Fatalf("Test failed, unexpected error: %v", err)
+	}
+	if exportedTree.Path != "repository/components" || len(exportedTree.Children) != 1 ||
+		exportedTree.Children[0].Children[1].MD5 != "hash2" {
+		t.Errorf("Test failed, unexpected tree: %s", output.String())
+	}
+}
+
+func TestReadDistribution(t *testing.T) {
+	files := map[string]string{
+		"bin/wso2server.sh":                   "#!/bin/sh",
+		"repository/components/plugins/a.jar": "a",
+		"repository/logs/wso2carbon.log":      "log",
+		constant.WUM_IGNORE_FILE:              "repository/logs",
+	}
+	// Distribution given as an in-memory file system (eg: an embedded test fixture)
+	distributionFS := fstest.MapFS{}
+	for name, content := range files {
+		distributionFS[name] = &fstest.MapFile{Data: []byte(content)}
+	}
+	// The same distribution given as a zip, as a tarball and as a directory (eg: a mounted ISO)
+	tempDir, err := ioutil.TempDir("", "wum-uc-test")
+	if err != nil {
+		t.Fatalf("Test failed, error occurred while creating the temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+	distributionDirectory := filepath.Join(tempDir, "wso2am-2.1.0")
+	buffer := new(bytes.Buffer)
+	zipWriter := zip.NewWriter(buffer)
+	tarBuffer := new(bytes.Buffer)
+	gzipWriter := gzip.NewWriter(tarBuffer)
+	tarWriter := tar.NewWriter(gzipWriter)
+	for name, content := range files {
+		location := filepath.Join(distributionDirectory, filepath.FromSlash(name))
+		if err = os.MkdirAll(filepath.Dir(location), 0755); err != nil {
+			t.Fatalf("Test failed, error occurred while creating the distribution: %v", err)
+		}
+		if err = ioutil.WriteFile(location, []byte(content), 0644); err != nil {
+			t.Fatalf("Test failed, error occurred while creating the distribution: %v", err)
+		}
+		writer, err := zipWriter.Create("wso2am-2.1.0/" + name)
+		if err != nil {
+			t.Fatalf("Test failed, error occurred while creating the distribution: %v", err)
+		}
+		writer.Write([]byte(content))
+		// Entries of tarballs created with 'tar -czf wso2am-2.1.0.tar.gz ./wso2am-2.1.0' start with './'
+		tarWriter.WriteHeader(&tar.Header{Name: "./wso2am-2.1.0/" + name, Mode: 0644, Size: int64(len(content)),
+			Typeflag: tar.TypeReg})
+		tarWriter.Write([]byte(content))
+	}
+	zipWriter.Close()
+	tarWriter.Close()
+	gzipWriter.Close()
+	distributionZip := distributionDirectory + constant.ZIP_FILE_EXTENSION
+	if err = ioutil.WriteFile(distributionZip, buffer.Bytes(), 0644); err != nil {
+		t.Fatalf("Test failed, error occurred while writing the distribution: %v", err)
+	}
+	distributionTarGz := distributionDirectory + constant.TAR_GZ_FILE_EXTENSION
+	if err = ioutil.WriteFile(distributionTarGz, tarBuffer.Bytes(), 0644); err != nil {
+		t.Fatalf("Test failed, error occurred while writing the distribution: %v", err)
+	}
+
+	fsRoot, err := readDistributionFS(distributionFS)
+	if err != nil {
+		t.Fatalf("Test failed, unexpected error: %v", err)
+	}
+	for _, location := range []string{distributionZip, distributionTarGz, distributionDirectory} {
+		root, err := readDistribution(location)
+		if err != nil {
+			t.Fatalf("Test failed, unexpected error for '%s': %v", location, err)
+		}
+		for _, relativePath := range []string{"bin/wso2server.sh", "repository/components/plugins/a.jar"} {
+			expected := getNode(&fsRoot, strings.Split(relativePath, "/"))
+			actual := getNode(&root, strings.Split(relativePath, "/"))
+			if expected == nil || actual == nil || expected.hash != actual.hash {
+				t.Errorf("Test failed, '%s' of '%s' does not match the in-memory distribution", relativePath,
+					location)
+			}
+		}
+		// Non-updatable paths are not added to the tree
+		if PathExists(&root, "repository/logs/wso2carbon.log", false) {
+			t.Errorf("Test failed, ignored file found in '%s'", location)
+		}
+		// Checksums are only available in the zip headers, tarballs are read as zips
+		pluginNode := getNode(&root, strings.Split("repository/components/plugins/a.jar", "/"))
+		if pluginNode != nil && pluginNode.hasCRC32 != (location != distributionDirectory) {
+			t.Errorf("Test failed, unexpected checksum of '%s' in '%s'", pluginNode.relativeLocation, location)
+		}
+	}
+	// The tree does not depend on the number of workers used to hash the files
+	defer viper.Set(constant.DISTRIBUTION_WORKERS, util.DistributionWorkers)
+	for _, workers := range []int{1, 3} {
+		viper.Set(constant.DISTRIBUTION_WORKERS, workers)
+		root, err := readDistributionFS(distributionFS)
+		if err != nil {
+			t.Fatalf("Test failed, unexpected error with %d worker(s): %v", workers, err)
+		}
+		if !reflect.DeepEqual(getTreeNode(&root), getTreeNode(&fsRoot)) {
+			t.Errorf("Test failed, tree read with %d worker(s) does not match", workers)
+		}
+	}
+	// Files are hashed with sha256 if it is required
+	defer viper.Set(constant.HASH_ALGORITHM, util.HashAlgorithm)
+	viper.Set(constant.HASH_ALGORITHM, constant.CHECKSUM_ALGORITHM_SHA256)
+	root, err := readDistributionFS(distributionFS)
+	if err != nil {
+		t.Fatalf("Test failed, unexpected error: %v", err)
+	}
+	expected := fmt.Sprintf("%x", sha256.Sum256([]byte("a")))
+	plugin := getTreeNode(getNode(&root, strings.Split("repository/components/plugins/a.jar", "/")))
+	if plugin.SHA256 != expected || len(plugin.MD5) != 0 || plugin.getHash() != expected {
+		t.Errorf("Test failed, expected: %s, actual: %v", expected, plugin)
+	}
+}
+
+func TestGetDecisionHandler(t *testing.T) {
+	decision := make(chan bool, 1)
+	handler := getDecisionHandler(decision, true, "approved", "localhost:9090", "secret")
+	testCases := []struct {
+		host   string
+		origin string
+		token  string
+		status int
+	}{
+		{"localhost:9090", "", "", http.StatusForbidden},
+		{"localhost:9090", "https://evil.example.com", "secret", http.StatusForbidden},
+		{"evil.example.com", "", "secret", http.StatusForbidden},
+		{"localhost:9090", "", "wrong", http.StatusForbidden},
+		{"localhost:9090", "http://localhost:9090", "secret", http.StatusOK},
+	}
+	for _, testCase := range testCases {
+		request := httptest.NewRequest(http.MethodPost, "http://"+testCase.host+"/approve",
+			strings.NewReader(url.Values{"token": {testCase.token}}.Encode()))
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if len(testCase.origin) != 0 {
+			request.Header.Set("Origin", testCase.origin)
+		}
+		recorder := httptest.NewRecorder()
+		handler(recorder, request)
+		if recorder.Code != testCase.status {
+			t.Errorf("Test failed, expected: %d, actual: %d for %v", testCase.status, recorder.Code, testCase)
+		}
+		if testCase.status != http.StatusOK && len(decision) != 0 {
+			t.Errorf("Test failed, decision is recorded for %v", testCase)
+		}
+	}
+	if len(decision) != 1 || !<-decision {
+		t.Errorf("Test failed, decision is not recorded")
+	}
+}
@@ -0,0 +1,296 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package cmd
+
+import (
+	"archive/zip"
+	"crypto/md5"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/renstrom/dedent"
+	"github.com/spf13/cobra"
+	"github.com/wso2/update-creator-tool/constant"
+	"github.com/wso2/update-creator-tool/util"
+)
+
+// Values used to print help command.
+var (
+	extractCmdUse       = "extract <update_loc> <target_dir>"
+	extractCmdShortDesc = "Extract an update to a directory"
+	extractCmdLongDesc  = dedent.Dedent(`
+		This command will extract the given update to the given target directory. Entries which
+		resolve outside the target directory are rejected and the file permissions and modification
+		times stored in the update are restored. Only a part of the update can be extracted by giving
+		'--only descriptor|payload|resources'. The payload is extracted relative to the carbon.home
+		directory of the update, the other parts are extracted relative to the update directory.
+		When the payload is extracted, the placeholders of the templated files declared in the
+		update-descriptor3.yaml are substituted. Variables referred by the substitutions are given
+		with '--variable <name>=<value>'. 'carbon.home' is the target directory by default. The owners
+		and the groups recorded in the update-descriptor3.yaml are applied to the payload if
+		'--apply-ownership' is given, after mapping them with '--ownership-mapping <mapping_file>'.
+		If the distribution of the target directory is given with '--distribution', modified files of
+		which the content matches neither the distribution nor the update are detected as locally
+		customized files. The policy for them is given with '--keep-custom', '--overwrite' or
+		'--merge-prompt', which prompts for each customized file whether to keep it, overwrite it or
+		extract the file of the update next to it to merge. OS variants declared in the
+		update-descriptor3.yaml are only extracted if they are installed in the operating system given
+		with '--os' (the current operating system by default).`)
+)
+
+// extractCmd represents the extract command.
+var extractCmd = &cobra.Command{
+	Use:   extractCmdUse,
+	Short: extractCmdShortDesc,
+	Long:  extractCmdLongDesc,
+	Run:   initializeExtractCommand,
+}
+
+var extractedPart string
+var templateVariables []string
+var isOwnershipApplied bool
+var ownershipMappingPath string
+var extractDistributionPath string
+var targetOS string
+
+// This function will be called first and this will add flags to the command.
+func init() {
+	RootCmd.AddCommand(extractCmd)
+
+	extractCmd.Flags().BoolVarP(&isDebugLogsEnabled, "debug", "d", util.EnableDebugLogs, "Enable debug logs")
+	extractCmd.Flags().BoolVarP(&isTraceLogsEnabled, "trace", "t", util.EnableTraceLogs, "Enable trace logs")
+	extractCmd.Flags().StringVar(&extractedPart, "only", "", "Extract only the given part of the update, "+
+		"'descriptor', 'payload' or 'resources'")
+	extractCmd.Flags().StringVar(&decryptionKeyPath, "decryption-key", "", "OpenPGP secret key used to "+
+		"decrypt an encrypted update")
+	extractCmd.Flags().StringSliceVar(&templateVariables, "variable", []string{}, "Variable referred by the "+
+		"substitutions of the templated files, as <name>=<value>")
+	extractCmd.Flags().BoolVar(&isOwnershipApplied, "apply-ownership", false, "Apply the owners and the groups "+
+		"recorded in the update to the extracted payload")
+	extractCmd.Flags().StringVar(&ownershipMappingPath, "ownership-mapping", "", "File which maps the recorded "+
+		"owners and groups to the users and groups of this system")
+	extractCmd.Flags().StringVar(&extractDistributionPath, "distribution", "", "Distribution of the target "+
+		"directory, used to detect local customizations of the modified files")
+	extractCmd.Flags().StringVar(&targetOS, "os", runtime.GOOS, "Operating system of the target directory, "+
+		"used to select the OS variants of the payload")
+	addCustomizationPolicyFlags(extractCmd)
+}
+
+// This function will be called when the extract command is called.
+func initializeExtractCommand(cmd *cobra.Command, args []string) {
+	if len(args) != 2 {
+		util.HandleErrorAndExit(util.NewInputError(errors.New("invalid number of arguments. Run 'wum-uc extract " +
+			"--help' to view help")))
+	}
+	setLogLevel()
+	logger.Debug("[extract] command called")
+	extractUpdate(args[0], args[1], extractedPart, getCustomizationPolicy(extractDistributionPath))
+}
+
+// This function extracts the given part of the given update to the given target directory. Encrypted and tar.zst
+// updates are converted to a zip in a temporary directory before extracting. Locally customized files are detected
+// when the payload is extracted and the distribution is given.
+func extractUpdate(updateFilePath, targetDirectory, part, customizationPolicy string) {
+	exists, err := util.IsFileExists(updateFilePath)
+	util.HandleErrorAndExit(err, "")
+	if !exists {
+		util.HandleErrorAndExit(util.NewInputError(errors.New(fmt.Sprintf("Entered update file does not exist at '%s'.",
+			updateFilePath))))
+	}
+	updateZipPath := updateFilePath
+	if util.IsEncryptedFile(updateZipPath) {
+		updateZipPath = decryptUpdate(updateZipPath, decryptionKeyPath)
+		defer util.CleanUpDirectory(filepath.Dir(updateZipPath))
+	}
+	if util.IsTarZstFile(updateZipPath) {
+		updateZipPath = convertToUpdateZip(updateZipPath)
+		defer util.CleanUpDirectory(filepath.Dir(updateZipPath))
+	}
+	var customizedFiles map[string]string
+	var excludedFiles map[string]bool
+	var updateDescriptorV3 *util.UpdateDescriptorV3
+	if part == constant.EXTRACT_PAYLOAD {
+		updateDescriptorV3 = readUpdateDescriptorV3OfZip(updateZipPath)
+		excludedFiles = getExcludedOSVariants(updateDescriptorV3, targetOS)
+		if len(extractDistributionPath) != 0 {
+			customizedFiles = getCustomizedFiles(updateZipPath, targetDirectory, extractDistributionPath,
+				customizationPolicy, excludedFiles)
+		}
+	}
+	extractedFiles, err := util.ExtractUpdateZip(updateZipPath, targetDirectory, part, customizedFiles,
+		excludedFiles)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while extracting '%s' to '%s'.", updateFilePath,
+		targetDirectory))
+	util.PrintInfo(fmt.Sprintf("%d file(s) of '%s' extracted to '%s'.", extractedFiles, updateFilePath,
+		targetDirectory))
+	// Templated files are substituted and owners are applied only when the payload is extracted to a product
+	if part == constant.EXTRACT_PAYLOAD {
+		substituteTemplatedFiles(updateDescriptorV3, targetDirectory, customizedFiles, excludedFiles)
+		if isOwnershipApplied || len(ownershipMappingPath) != 0 {
+			applyFileOwnerships(updateDescriptorV3, targetDirectory, ownershipMappingPath, excludedFiles)
+		}
+	}
+}
+
+// This function returns the OS variants of the given update which are not installed in the given operating system.
+func getExcludedOSVariants(updateDescriptorV3 *util.UpdateDescriptorV3, osName string) map[string]bool {
+	err := util.ValidateOSVariants(updateDescriptorV3)
+	util.HandleErrorAndExit(err, fmt.Sprintf("'%s' is invalid.", constant.UPDATE_DESCRIPTOR_V3_FILE))
+	excludedFiles := util.GetExcludedOSVariants(updateDescriptorV3, osName)
+	if len(excludedFiles) != 0 {
+		util.PrintInfo(fmt.Sprintf("%d OS variant(s) which are not installed in '%s' are skipped.",
+			len(excludedFiles), osName))
+	}
+	return excludedFiles
+}
+
+// This function detects the modified files of the given update which are customized in the given target directory
+// and returns the policy applied to each of them. Extracting fails if customized files are found and the policy is
+// not given. Excluded files are not extracted, so they are not checked.
+func getCustomizedFiles(updateZipPath, targetDirectory, distributionPath, policy string,
+	excludedFiles map[string]bool) map[string]string {
+	hashLength := md5.Size * 2
+	updateChanges, err := util.ReadUpdateChanges(updateZipPath, hashLength)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while reading '%s'.", updateZipPath))
+	var modifiedFiles []string
+	for _, modifiedFile := range updateChanges.ModifiedFiles {
+		if !excludedFiles[modifiedFile] {
+			modifiedFiles = append(modifiedFiles, modifiedFile)
+		}
+	}
+	updateChanges.ModifiedFiles = modifiedFiles
+	targetHashes, err := util.ReadDirectoryHashes(targetDirectory, updateChanges.ModifiedFiles, hashLength)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while reading '%s'.", targetDirectory))
+	distributionHashes := readDistributionHashes(distributionPath, updateChanges.ModifiedFiles, hashLength)
+	report := util.SimulateUpdate(updateChanges, targetHashes, distributionHashes)
+	if len(report.ConflictingFiles) == 0 {
+		logger.Debug(fmt.Sprintf("No customized files found in %s", targetDirectory))
+		return nil
+	}
+	if len(policy) == 0 {
+		util.HandleErrorAndExit(errors.New(fmt.Sprintf("locally customized file(s) found in '%s':\n\t%s\nUse "+
+			"'--keep-custom', '--overwrite' or '--merge-prompt' to continue", targetDirectory,
+			strings.Join(report.ConflictingFiles, "\n\t"))))
+	}
+	customizedFiles := resolveCustomizedFiles(report.ConflictingFiles, policy)
+	printResolvedCustomizedFiles(report.ConflictingFiles, customizedFiles)
+	return customizedFiles
+}
+
+// This function reads the update-descriptor3.yaml in the root directory of the given update zip. An empty descriptor
+// is returned if the update does not have an update-descriptor3.yaml.
+func readUpdateDescriptorV3OfZip(updateZipPath string) *util.UpdateDescriptorV3 {
+	zipReader, err := zip.OpenReader(updateZipPath)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while reading '%s'.", updateZipPath))
+	defer zipReader.Close()
+	updateDescriptorV3 := util.UpdateDescriptorV3{}
+	for _, file := range zipReader.Reader.File {
+		// Update descriptors should be in the root directory of the update
+		if strings.Count(file.Name, "/") != 1 || getFileName(file.Name) != constant.UPDATE_DESCRIPTOR_V3_FILE {
+			continue
+		}
+		data, err := readZipEntry(file)
+		util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while reading '%s'.", file.Name))
+		err = util.UnmarshalUpdateDescriptor(data, file.Name, &updateDescriptorV3)
+		util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while reading '%s'.", file.Name))
+	}
+	return &updateDescriptorV3
+}
+
+// This function applies the owners and the groups recorded in the given update-descriptor3.yaml to the payload
+// extracted to the given target directory. Recorded owners and groups are mapped using the given mapping file.
+// Excluded files are not extracted, so their owners are not applied.
+func applyFileOwnerships(updateDescriptorV3 *util.UpdateDescriptorV3, targetDirectory, mappingPath string,
+	excludedFiles map[string]bool) {
+	var mapping *util.OwnershipMapping
+	if len(mappingPath) != 0 {
+		var err error
+		mapping, err = util.LoadOwnershipMapping(mappingPath)
+		util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while reading '%s'.", mappingPath))
+	}
+	appliedOwnerships := 0
+	for _, ownership := range updateDescriptorV3.FileOwnerships {
+		if excludedFiles[ownership.Path] {
+			continue
+		}
+		filePath := filepath.Join(targetDirectory, filepath.FromSlash(ownership.Path))
+		ownership = mapping.Map(ownership)
+		err := util.ApplyFileOwnership(filePath, ownership)
+		util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while changing the owner of '%s' to '%s:%s'.",
+			filePath, ownership.Owner, ownership.Group))
+		logger.Debug(fmt.Sprintf("Owner of %s changed to %s:%s", filePath, ownership.Owner, ownership.Group))
+		appliedOwnerships++
+	}
+	if appliedOwnerships != 0 {
+		util.PrintInfo(fmt.Sprintf("Owners of %d file(s) applied.", appliedOwnerships))
+	}
+}
+
+// This function substitutes the placeholders of the templated files of the given update in the payload extracted to
+// the given target directory. Kept customized files are not substituted and the files of the update extracted next to
+// the customized files to merge are substituted instead of them. Excluded files are not extracted, so they are not
+// substituted.
+func substituteTemplatedFiles(updateDescriptorV3 *util.UpdateDescriptorV3, targetDirectory string,
+	customizedFiles map[string]string, excludedFiles map[string]bool) {
+	absTargetDirectory, err := filepath.Abs(targetDirectory)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while reading '%s'.", targetDirectory))
+	variables := map[string]string{constant.CARBON_HOME: absTargetDirectory}
+	for _, variable := range templateVariables {
+		parts := strings.SplitN(variable, "=", 2)
+		if len(parts) != 2 || len(parts[0]) == 0 {
+			util.HandleErrorAndExit(errors.New(fmt.Sprintf("invalid variable '%s', expected <name>=<value>",
+				variable)))
+		}
+		variables[parts[0]] = parts[1]
+	}
+
+	substitutedFiles := 0
+	for _, templatedFile := range updateDescriptorV3.TemplatedFiles {
+		if excludedFiles[templatedFile.Path] {
+			continue
+		}
+		templatedFilePath := filepath.Join(targetDirectory, filepath.FromSlash(templatedFile.Path))
+		switch customizedFiles[templatedFile.Path] {
+		case constant.CUSTOMIZATION_POLICY_KEEP:
+			continue
+		case constant.CUSTOMIZATION_POLICY_MERGE:
+			templatedFilePath += constant.CUSTOMIZATION_MERGE_EXTENSION
+		}
+		fileInfo, err := os.Stat(templatedFilePath)
+		util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while reading '%s'.", templatedFilePath))
+		data, err := ioutil.ReadFile(templatedFilePath)
+		util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while reading '%s'.", templatedFilePath))
+		data, err = util.SubstitutePlaceholders(data, &templatedFile, variables)
+		util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while substituting the placeholders of '%s'.",
+			templatedFilePath))
+		err = ioutil.WriteFile(templatedFilePath, data, fileInfo.Mode())
+		util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while writing '%s'.", templatedFilePath))
+		logger.Debug(fmt.Sprintf("Placeholders of %s substituted", templatedFilePath))
+		substitutedFiles++
+	}
+	if substitutedFiles != 0 {
+		util.PrintInfo(fmt.Sprintf("Placeholders of %d templated file(s) substituted.", substitutedFiles))
+	}
+}
@@ -0,0 +1,141 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/renstrom/dedent"
+	"github.com/spf13/cobra"
+	"github.com/wso2/update-creator-tool/constant"
+	"github.com/wso2/update-creator-tool/util"
+)
+
+// Values used to print help command.
+var (
+	impactCmdUse       = "impact <update_zip> <profile_file>..."
+	impactCmdShortDesc = "Analyze the impact of an update on deployment profiles"
+	impactCmdLongDesc  = dedent.Dedent(`
+		This command will report which file changes of the given update affect each of the given
+		deployment profiles. A deployment profile is a yaml file which lists the paths of the
+		distribution used by a deployment (eg: a gateway only API Manager deployment), so that
+		support teams can tell whether an update is relevant to a customer. The impact is printed
+		as a table, or as JSON or CSV for other tools with '--format json|csv'.`)
+)
+
+// impactCmd represents the impact command.
+var impactCmd = &cobra.Command{
+	Use:   impactCmdUse,
+	Short: impactCmdShortDesc,
+	Long:  impactCmdLongDesc,
+	Run:   initializeImpactCommand,
+}
+
+var impactFormat string
+
+// This function will be called first and this will add flags to the command.
+func init() {
+	RootCmd.AddCommand(impactCmd)
+
+	impactCmd.Flags().BoolVarP(&isDebugLogsEnabled, "debug", "d", util.EnableDebugLogs, "Enable debug logs")
+	impactCmd.Flags().BoolVarP(&isTraceLogsEnabled, "trace", "t", util.EnableTraceLogs, "Enable trace logs")
+	impactCmd.Flags().StringVar(&impactFormat, "format", constant.OUTPUT_FORMAT_TABLE, "Format of the impact, "+
+		"'table', 'json' or 'csv'")
+}
+
+// This function will be called when the impact command is called.
+func initializeImpactCommand(cmd *cobra.Command, args []string) {
+	if len(args) < 2 {
+		util.HandleErrorAndExit(util.NewInputError(errors.New("invalid number of arguments. Run 'wum-uc impact " +
+			"--help' to view help")))
+	}
+	setLogLevel()
+	logger.Debug("[impact] command called")
+	analyzeImpact(args[0], args[1:], impactFormat)
+}
+
+// This function reports the impact of the given update on each of the given deployment profiles in the given format.
+func analyzeImpact(updateFilePath string, profilePaths []string, format string) {
+	if format != constant.OUTPUT_FORMAT_TABLE && format != constant.OUTPUT_FORMAT_JSON &&
+		format != constant.OUTPUT_FORMAT_CSV {
+		util.HandleErrorAndExit(errors.New(fmt.Sprintf("invalid format '%s', expected '%s', '%s' or '%s'", format,
+			constant.OUTPUT_FORMAT_TABLE, constant.OUTPUT_FORMAT_JSON, constant.OUTPUT_FORMAT_CSV)))
+	}
+	util.IsZipFile("update", updateFilePath)
+	updateFiles, err := util.ListUpdateFiles(updateFilePath)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while reading '%s'.", updateFilePath))
+	var impacts []*util.ProfileImpact
+	for _, profilePath := range profilePaths {
+		profile, err := util.LoadDeploymentProfile(profilePath)
+		util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while reading the deployment profile '%s'.",
+			profilePath))
+		impact := util.GetProfileImpact(updateFiles, profile)
+		logger.Debug(fmt.Sprintf("%d of %d files of %s affect %s", len(impact.AffectedFiles), len(updateFiles),
+			updateFilePath, profile.Name))
+		impacts = append(impacts, impact)
+	}
+
+	switch format {
+	case constant.OUTPUT_FORMAT_JSON:
+		data, err := json.MarshalIndent(impacts, "", "  ")
+		util.HandleErrorAndExit(err, "Error occurred while marshalling the impact.")
+		fmt.Println(string(data))
+	case constant.OUTPUT_FORMAT_CSV:
+		csvWriter := csv.NewWriter(os.Stdout)
+		csvWriter.Write([]string{"profile", "path", "classification", "affected"})
+		for _, impact := range impacts {
+			for _, updateFile := range impact.AffectedFiles {
+				csvWriter.Write([]string{impact.Profile, updateFile.Path, updateFile.Classification, "true"})
+			}
+			for _, updateFile := range impact.UnaffectedFiles {
+				csvWriter.Write([]string{impact.Profile, updateFile.Path, updateFile.Classification, "false"})
+			}
+		}
+		csvWriter.Flush()
+		util.HandleErrorAndExit(csvWriter.Error(), "Error occurred while writing the impact.")
+	default:
+		for _, impact := range impacts {
+			if !impact.IsRelevant {
+				util.PrintInBold(fmt.Sprintf("'%s' is not relevant to the '%s' profile. None of its %d file "+
+					"change(s) affect the profile.\n", updateFilePath, impact.Profile, len(updateFiles)))
+				continue
+			}
+			util.PrintInBold(fmt.Sprintf("'%s' is relevant to the '%s' profile. %d of its %d file change(s) "+
+				"affect the profile.\n", updateFilePath, impact.Profile, len(impact.AffectedFiles),
+				len(updateFiles)))
+			filesTable := tablewriter.NewWriter(os.Stdout)
+			filesTable.SetAlignment(tablewriter.ALIGN_LEFT)
+			filesTable.SetHeader([]string{"Path", "Classification", "Size"})
+			for _, updateFile := range impact.AffectedFiles {
+				size := ""
+				if updateFile.Classification != constant.FILE_CLASSIFICATION_REMOVED {
+					size = strconv.FormatUint(updateFile.Size, 10)
+				}
+				filesTable.Append([]string{updateFile.Path, updateFile.Classification, size})
+			}
+			filesTable.Render()
+		}
+	}
+}
@@ -0,0 +1,239 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/renstrom/dedent"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/wso2/update-creator-tool/constant"
+	"github.com/wso2/update-creator-tool/util"
+	"gopkg.in/yaml.v2"
+)
+
+// Values used to print help command.
+var (
+	indexCmdUse       = "index <dir>"
+	indexCmdShortDesc = "Generate the catalog of a directory of updates"
+	indexCmdLongDesc  = dedent.Dedent(`
+		This command will scan the given directory for update zips and generate a catalog which
+		contains the update number, the platform, the products, the checksums and the dependencies
+		of each update. The catalog is signed if a signing key is given. The catalog can be used by
+		'wum-uc validate --against-catalog' to detect duplicate and conflicting updates.`)
+)
+
+// indexCmd represents the index command.
+var indexCmd = &cobra.Command{
+	Use:   indexCmdUse,
+	Short: indexCmdShortDesc,
+	Long:  indexCmdLongDesc,
+	Run:   initializeIndexCommand,
+}
+
+var catalogOutputPath string
+var catalogSigningKeyPath string
+var isChunkManifestEnabled bool
+
+// This function will be called first and this will add flags to the command.
+func init() {
+	RootCmd.AddCommand(indexCmd)
+
+	indexCmd.Flags().BoolVarP(&isDebugLogsEnabled, "debug", "d", util.EnableDebugLogs, "Enable debug logs")
+	indexCmd.Flags().BoolVarP(&isTraceLogsEnabled, "trace", "t", util.EnableTraceLogs, "Enable trace logs")
+	indexCmd.Flags().StringVarP(&catalogOutputPath, "output", "o", "", "Location of the generated catalog "+
+		"(default <dir>/"+constant.CATALOG_FILE+")")
+	indexCmd.Flags().StringVar(&catalogSigningKeyPath, "signing-key", "", "RSA private key (PEM) used to sign "+
+		"the catalog")
+	indexCmd.Flags().BoolVar(&isChunkManifestEnabled, "chunk-manifests", false, "Write the chunk manifest of each "+
+		"update zip so that its chunks are verified when it is synced")
+}
+
+// This function will be called when the index command is called.
+func initializeIndexCommand(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		util.HandleErrorAndExit(util.NewInputError(errors.New("invalid number of arguments. Run 'wum-uc index " +
+			"--help' to view help")))
+	}
+	setLogLevel()
+	logger.Debug("[index] command called")
+	if len(catalogOutputPath) == 0 {
+		catalogOutputPath = filepath.Join(args[0], constant.CATALOG_FILE)
+	}
+	generateCatalog(args[0], catalogOutputPath, catalogSigningKeyPath)
+}
+
+// This function generates the catalog of the update zips in the given directory.
+func generateCatalog(updatesDirectory, catalogPath, signingKeyPath string) {
+	exists, err := util.IsDirectoryExists(updatesDirectory)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while reading '%s'.", updatesDirectory))
+	if !exists {
+		util.HandleErrorAndExit(errors.New(fmt.Sprintf("directory '%s' does not exist", updatesDirectory)))
+	}
+
+	var entries []util.CatalogEntry
+	filenameRegex := regexp.MustCompile(constant.FILENAME_REGEX)
+	err = filepath.Walk(updatesDirectory, func(location string, fileInfo os.FileInfo, err error) error {
+		if err != nil || fileInfo.IsDir() || !filenameRegex.MatchString(fileInfo.Name()) {
+			return err
+		}
+		logger.Debug(fmt.Sprintf("Adding %s to the catalog", location))
+		entry, err := util.NewCatalogEntry(location)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, *entry)
+		if isChunkManifestEnabled {
+			return writeChunkManifest(location)
+		}
+		return nil
+	})
+	util.HandleErrorAndExit(err, "Error occurred while reading the updates.")
+	if len(entries) == 0 {
+		util.HandleErrorAndExit(errors.New(fmt.Sprintf("no update zips found in '%s'", updatesDirectory)))
+	}
+	catalog := util.NewCatalog(entries)
+	// Record the preferred checksum algorithms so that the consumers of the catalog negotiate the algorithm of each
+	// update in the same order
+	catalog.ChecksumAlgorithms = viper.GetStringSlice(constant.UPDATE_CATALOG_CHECKSUM_ALGORITHMS)
+	err = util.ValidateChecksumAlgorithms(catalog.ChecksumAlgorithms)
+	util.HandleErrorAndExit(util.NewInputError(err), fmt.Sprintf("'%s' is invalid.",
+		constant.UPDATE_CATALOG_CHECKSUM_ALGORITHMS))
+	// Expired previews should be removed from the directory before the catalog is generated
+	expiredPreviews, err := util.GetExpiredPreviews(catalog, time.Now().UTC())
+	util.HandleErrorAndExit(err, "Error occurred while checking the previews.")
+	if len(expiredPreviews) != 0 {
+		for _, expiredPreview := range expiredPreviews {
+			util.PrintError(fmt.Sprintf("preview '%s' expired on %s.", expiredPreview.UpdateName,
+				expiredPreview.PreviewExpires))
+		}
+		util.HandleErrorAndExit(errors.New(fmt.Sprintf("%d expired preview(s) found in '%s'.",
+			len(expiredPreviews), updatesDirectory)))
+	}
+	writeCatalog(catalog, catalogPath, signingKeyPath)
+}
+
+// This function writes the chunk manifest of the update zip at the given location next to it.
+func writeChunkManifest(updateZipPath string) error {
+	chunkManifest, err := util.CreateChunkManifest(updateZipPath, viper.GetInt64(constant.DOWNLOAD_CHUNK_SIZE))
+	if err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(chunkManifest)
+	if err != nil {
+		return err
+	}
+	logger.Debug(fmt.Sprintf("Writing the chunk manifest of %s", updateZipPath))
+	return ioutil.WriteFile(updateZipPath+constant.CHUNK_MANIFEST_EXTENSION, data, 0644)
+}
+
+// This function writes the given catalog to the given location. The catalog is signed if a signing key is given.
+func writeCatalog(catalog *util.Catalog, catalogPath, signingKeyPath string) {
+	data, err := yaml.Marshal(catalog)
+	util.HandleErrorAndExit(err, "Error occurred while marshalling the catalog.")
+	err = ioutil.WriteFile(catalogPath, data, 0644)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while writing the catalog to '%s'.", catalogPath))
+	util.PrintInfo(fmt.Sprintf("Catalog of %d update(s) written to '%s'.", len(catalog.Updates), catalogPath))
+
+	if len(signingKeyPath) == 0 {
+		util.PrintWarning("Signing key not given. The catalog is not signed.")
+		return
+	}
+	signature, err := util.SignData(data, signingKeyPath)
+	util.HandleErrorAndExit(err, "Error occurred while signing the catalog.")
+	signaturePath := catalogPath + constant.CATALOG_SIGNATURE_EXTENSION
+	err = ioutil.WriteFile(signaturePath, []byte(signature+"\n"), 0644)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while writing the signature to '%s'.",
+		signaturePath))
+	util.PrintInfo(fmt.Sprintf("Catalog signature written to '%s'.", signaturePath))
+	// Record the signed catalog in the audit log
+	recordAuditEvent(constant.AUDIT_OPERATION_SIGN, catalogPath)
+}
+
+// This function loads the catalog at the given location. The signature of the catalog is verified if a public key is
+// given.
+func loadCatalog(catalogPath, publicKeyPath string) *util.Catalog {
+	catalog, data, err := util.LoadCatalog(catalogPath)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while reading the catalog '%s'.", catalogPath))
+	if len(publicKeyPath) != 0 {
+		signature, err := ioutil.ReadFile(catalogPath + constant.CATALOG_SIGNATURE_EXTENSION)
+		util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while reading the signature of '%s'.",
+			catalogPath))
+		err = util.VerifySignature(data, string(signature), publicKeyPath)
+		util.HandleErrorAndExit(err, fmt.Sprintf("Signature verification of '%s' failed.", catalogPath))
+		logger.Debug(fmt.Sprintf("Signature of %s verified", catalogPath))
+	} else {
+		util.PrintWarning(fmt.Sprintf("Catalog key not given. The signature of '%s' is not verified.",
+			catalogPath))
+	}
+	return catalog
+}
+
+// This function checks the given update zip against the given catalog for duplicate and conflicting updates. The
+// signature of the catalog is verified if a public key is given.
+func checkAgainstCatalog(updateFilePath, catalogPath, publicKeyPath string) {
+	catalog := loadCatalog(catalogPath, publicKeyPath)
+	entry, err := util.NewCatalogEntry(updateFilePath)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while reading '%s'.", updateFilePath))
+	isExpired, err := entry.IsExpiredPreview(time.Now().UTC())
+	util.HandleErrorAndExit(err, fmt.Sprintf("'%s' is invalid.", updateFilePath))
+	if isExpired {
+		util.HandleErrorAndExit(errors.New(fmt.Sprintf("preview '%s' expired on %s.", entry.UpdateName,
+			entry.PreviewExpires)))
+	}
+	expiredPreviews, err := util.GetExpiredPreviews(catalog, time.Now().UTC())
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while checking the previews in '%s'.", catalogPath))
+	for _, expiredPreview := range expiredPreviews {
+		util.PrintWarning(fmt.Sprintf("'%s' contains the preview '%s' which expired on %s.", catalogPath,
+			expiredPreview.UpdateName, expiredPreview.PreviewExpires))
+	}
+	duplicates, conflicts := util.FindCatalogConflicts(catalog, entry)
+	for _, conflict := range conflicts {
+		util.PrintWarning(fmt.Sprintf("'%s' changes the same files as '%s' which has a higher update number.",
+			entry.UpdateName, conflict.UpdateName))
+	}
+	if len(duplicates) != 0 {
+		for _, duplicate := range duplicates {
+			util.PrintError(fmt.Sprintf("update number '%s' of platform version '%s' is already used by '%s' "+
+				"(md5: %s, sha256: %s) in the catalog.", entry.UpdateNumber, entry.PlatformVersion,
+				duplicate.UpdateName, duplicate.MD5, duplicate.SHA256))
+		}
+		util.HandleErrorAndExit(errors.New(fmt.Sprintf("'%s' is a duplicate of %d update(s) in '%s'.",
+			entry.UpdateName, len(duplicates), catalogPath)))
+	}
+	overwrittenUpdates := util.FindOverwrittenUpdates(catalog, entry)
+	if len(overwrittenUpdates) != 0 {
+		for _, overwrittenUpdate := range overwrittenUpdates {
+			util.PrintError(fmt.Sprintf("'%s' changes %s which are changed by the prior update '%s'.",
+				entry.UpdateName, strings.Join(overwrittenUpdate.Files, ", "), overwrittenUpdate.UpdateName))
+		}
+		util.HandleErrorAndExit(errors.New(fmt.Sprintf("'%s' overwrites the changes of %d prior update(s) in "+
+			"'%s'. Add the prior updates to 'supersedes' in '%s' if they are superseded by this update.",
+			entry.UpdateName, len(overwrittenUpdates), catalogPath, constant.UPDATE_DESCRIPTOR_V3_FILE)))
+	}
+}
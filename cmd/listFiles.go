@@ -0,0 +1,139 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/renstrom/dedent"
+	"github.com/spf13/cobra"
+	"github.com/wso2/update-creator-tool/constant"
+	"github.com/wso2/update-creator-tool/util"
+)
+
+// Values used to print help command.
+var (
+	listFilesCmdUse       = "list-files <update_zip>"
+	listFilesCmdShortDesc = "List the files of an update"
+	listFilesCmdLongDesc  = dedent.Dedent(`
+		This command will list the files of the payload of the given update with their size and
+		sha256 hash. Each file is classified as added, modified or removed according to the update
+		descriptors, or as unlisted if the descriptors do not refer it. Files can be filtered with
+		'--only added|modified|removed|unlisted' and '--path-prefix <prefix>' (relative to
+		carbon.home). The files are printed as a table, or as JSON or CSV for other tools with
+		'--format json|csv'.`)
+)
+
+// listFilesCmd represents the list-files command.
+var listFilesCmd = &cobra.Command{
+	Use:   listFilesCmdUse,
+	Short: listFilesCmdShortDesc,
+	Long:  listFilesCmdLongDesc,
+	Run:   initializeListFilesCommand,
+}
+
+var listedClassification string
+var listedPathPrefix string
+var listedFilesFormat string
+
+// This function will be called first and this will add flags to the command.
+func init() {
+	RootCmd.AddCommand(listFilesCmd)
+
+	listFilesCmd.Flags().BoolVarP(&isDebugLogsEnabled, "debug", "d", util.EnableDebugLogs, "Enable debug logs")
+	listFilesCmd.Flags().BoolVarP(&isTraceLogsEnabled, "trace", "t", util.EnableTraceLogs, "Enable trace logs")
+	listFilesCmd.Flags().StringVar(&listedClassification, "only", "", "List only the files of the given "+
+		"classification, 'added', 'modified', 'removed' or 'unlisted'")
+	listFilesCmd.Flags().StringVar(&listedPathPrefix, "path-prefix", "", "List only the files of which the path "+
+		"relative to carbon.home starts with the given prefix")
+	listFilesCmd.Flags().StringVar(&listedFilesFormat, "format", constant.OUTPUT_FORMAT_TABLE, "Format of the "+
+		"listed files, 'table', 'json' or 'csv'")
+}
+
+// This function will be called when the list-files command is called.
+func initializeListFilesCommand(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		util.HandleErrorAndExit(util.NewInputError(errors.New("invalid number of arguments. Run 'wum-uc list-files " +
+			"--help' to view help")))
+	}
+	setLogLevel()
+	logger.Debug("[list-files] command called")
+	listUpdateFiles(args[0], listedClassification, listedPathPrefix, listedFilesFormat)
+}
+
+// This function lists the files of the given update which match the given classification and path prefix in the
+// given format.
+func listUpdateFiles(updateFilePath, classification, pathPrefix, format string) {
+	switch classification {
+	case "", constant.FILE_CLASSIFICATION_ADDED, constant.FILE_CLASSIFICATION_MODIFIED,
+		constant.FILE_CLASSIFICATION_REMOVED, constant.FILE_CLASSIFICATION_UNLISTED:
+	default:
+		util.HandleErrorAndExit(errors.New(fmt.Sprintf("invalid classification '%s', expected '%s', '%s', '%s' "+
+			"or '%s'", classification, constant.FILE_CLASSIFICATION_ADDED, constant.FILE_CLASSIFICATION_MODIFIED,
+			constant.FILE_CLASSIFICATION_REMOVED, constant.FILE_CLASSIFICATION_UNLISTED)))
+	}
+	if format != constant.OUTPUT_FORMAT_TABLE && format != constant.OUTPUT_FORMAT_JSON &&
+		format != constant.OUTPUT_FORMAT_CSV {
+		util.HandleErrorAndExit(errors.New(fmt.Sprintf("invalid format '%s', expected '%s', '%s' or '%s'", format,
+			constant.OUTPUT_FORMAT_TABLE, constant.OUTPUT_FORMAT_JSON, constant.OUTPUT_FORMAT_CSV)))
+	}
+	util.IsZipFile("update", updateFilePath)
+	updateFiles, err := util.ListUpdateFiles(updateFilePath)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while reading '%s'.", updateFilePath))
+	updateFiles = util.FilterUpdateFiles(updateFiles, classification, pathPrefix)
+	logger.Debug(fmt.Sprintf("%d files of %s listed", len(updateFiles), updateFilePath))
+
+	switch format {
+	case constant.OUTPUT_FORMAT_JSON:
+		// An empty list is printed instead of null when no files match the filters
+		if updateFiles == nil {
+			updateFiles = []util.UpdateFile{}
+		}
+		data, err := json.MarshalIndent(updateFiles, "", "  ")
+		util.HandleErrorAndExit(err, "Error occurred while marshalling the listed files.")
+		fmt.Println(string(data))
+	case constant.OUTPUT_FORMAT_CSV:
+		csvWriter := csv.NewWriter(os.Stdout)
+		csvWriter.Write([]string{"path", "classification", "size", "sha256"})
+		for _, updateFile := range updateFiles {
+			csvWriter.Write([]string{updateFile.Path, updateFile.Classification,
+				strconv.FormatUint(updateFile.Size, 10), updateFile.SHA256})
+		}
+		csvWriter.Flush()
+		util.HandleErrorAndExit(csvWriter.Error(), "Error occurred while writing the listed files.")
+	default:
+		filesTable := tablewriter.NewWriter(os.Stdout)
+		filesTable.SetAlignment(tablewriter.ALIGN_LEFT)
+		filesTable.SetHeader([]string{"Path", "Classification", "Size", "SHA256"})
+		for _, updateFile := range updateFiles {
+			size := ""
+			if updateFile.Classification != constant.FILE_CLASSIFICATION_REMOVED {
+				size = strconv.FormatUint(updateFile.Size, 10)
+			}
+			filesTable.Append([]string{updateFile.Path, updateFile.Classification, size, updateFile.SHA256})
+		}
+		filesTable.Render()
+	}
+}
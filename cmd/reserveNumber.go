@@ -0,0 +1,96 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/renstrom/dedent"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/wso2/update-creator-tool/constant"
+	"github.com/wso2/update-creator-tool/util"
+)
+
+// Values used to print help command.
+var (
+	reserveNumberCmdUse       = "reserve-number"
+	reserveNumberCmdShortDesc = "Reserve the next update number of a platform version in WUM"
+	reserveNumberCmdLongDesc  = dedent.Dedent(`
+		This command will reserve the next update number of the given platform version in WUM and
+		record the reservation locally until it expires. The reserved update number is used by
+		'wum-uc create --auto-number', so that teams creating updates of the same platform version
+		do not use the same update number.`)
+)
+
+// reserveNumberCmd represents the reserve-number command.
+var reserveNumberCmd = &cobra.Command{
+	Use:   reserveNumberCmdUse,
+	Short: reserveNumberCmdShortDesc,
+	Long:  reserveNumberCmdLongDesc,
+	Run:   initializeReserveNumberCommand,
+}
+
+var reservedPlatformVersion string
+
+// This function will be called first and this will add flags to the command.
+func init() {
+	RootCmd.AddCommand(reserveNumberCmd)
+
+	reserveNumberCmd.Flags().BoolVarP(&isDebugLogsEnabled, "debug", "d", util.EnableDebugLogs, "Enable debug logs")
+	reserveNumberCmd.Flags().BoolVarP(&isTraceLogsEnabled, "trace", "t", util.EnableTraceLogs, "Enable trace logs")
+	reserveNumberCmd.Flags().StringVar(&reservedPlatformVersion, "platform", "", "Platform version (eg: 4.4.0) "+
+		"of which the next update number is reserved")
+}
+
+// This function will be called when the reserve-number command is called.
+func initializeReserveNumberCommand(cmd *cobra.Command, args []string) {
+	if len(args) != 0 || len(reservedPlatformVersion) == 0 {
+		util.HandleErrorAndExit(util.NewInputError(errors.New("invalid arguments. Run 'wum-uc reserve-number --help' " +
+			"to view help")))
+	}
+	setLogLevel()
+	logger.Debug("[reserve-number] command called")
+	reserveUpdateNumber(reservedPlatformVersion)
+}
+
+// This function reserves the next update number of the given platform version in WUM and records the reservation in
+// the wum-uc home directory.
+func reserveUpdateNumber(platformVersion string) {
+	if !util.ValidatePlatformVersion(platformVersion) {
+		util.HandleErrorAndExit(errors.New(fmt.Sprintf("platform version '%s' is invalid. It should match '%s'",
+			platformVersion, constant.KERNEL_VERSION_REGEX)))
+	}
+	wumucConfig := util.GetWUMUCConfigs()
+	validity := time.Duration(viper.GetInt(constant.UPDATE_NUMBER_RESERVATION_VALIDITY)) * time.Hour
+	now := time.Now()
+	reservation, err := util.ReserveUpdateNumber(wumucConfig.ServerURL, wumucConfig.AccessToken, platformVersion,
+		wumucConfig.Username, validity, now)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while reserving an update number for platform "+
+		"version '%s'.", platformVersion))
+	err = util.AddUpdateNumberReservation(WUMUCHome, reservation, now)
+	util.HandleErrorAndExit(err, "Error occurred while recording the update number reservation.")
+	util.PrintInBold(fmt.Sprintf("Update number '%s' is reserved for platform version '%s' until %s.\n",
+		reservation.UpdateNumber, platformVersion, reservation.ExpiresAt))
+	util.PrintInfo("Run 'wum-uc create <update_dir> <dist_loc> --auto-number' to create the update with the " +
+		"reserved update number.")
+}
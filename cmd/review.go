@@ -0,0 +1,333 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package cmd
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/renstrom/dedent"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/wso2/update-creator-tool/constant"
+	"github.com/wso2/update-creator-tool/util"
+)
+
+// Values used to print help command.
+var (
+	reviewCmdUse       = "review"
+	reviewCmdShortDesc = "Review the pending update in the browser before packaging"
+	reviewCmdLongDesc  = dedent.Dedent(`
+		This command will serve a local web page which renders the placement manifest, the
+		update-descriptor3.yaml and the diffs of the modified text files of the pending update
+		(created by 'wum-uc create'). Approving the update resumes the packaging as
+		'wum-uc create --continue' does, aborting it cancels the update creation.`)
+)
+
+// reviewCmd represents the review command.
+var reviewCmd = &cobra.Command{
+	Use:   reviewCmdUse,
+	Short: reviewCmdShortDesc,
+	Long:  reviewCmdLongDesc,
+	Run:   initializeReviewCommand,
+}
+
+var reviewAddress string
+var isJarDiffEnabled bool
+
+// This struct holds the details of the pending update rendered in the review page.
+type reviewData struct {
+	UpdateName       string
+	DistributionPath string
+	Manifest         []manifestEntry
+	Descriptor       string
+	Diffs            []fileDiff
+	// Random token of the review session which should be submitted with the decision
+	Token string
+}
+
+// This struct represents a single file in the placement manifest.
+type manifestEntry struct {
+	Path   string
+	Md5sum string
+}
+
+// This struct holds the diff of a modified file against the distribution.
+type fileDiff struct {
+	Path    string
+	Lines   []util.DiffLine
+	Message string
+	Jar     *util.JarDiff
+}
+
+// This function will be called first and this will add flags to the command.
+func init() {
+	RootCmd.AddCommand(reviewCmd)
+
+	reviewCmd.Flags().BoolVarP(&isDebugLogsEnabled, "debug", "d", util.EnableDebugLogs, "Enable debug logs")
+	reviewCmd.Flags().BoolVarP(&isTraceLogsEnabled, "trace", "t", util.EnableTraceLogs, "Enable trace logs")
+	reviewCmd.Flags().StringVar(&reviewAddress, "address", constant.REVIEW_DEFAULT_ADDRESS, "Address to serve "+
+		"the review page")
+	reviewCmd.Flags().BoolVar(&isJarDiffEnabled, "jar-diff", false, "List the added, removed and changed class "+
+		"files of the modified jars")
+}
+
+// This function will be called when the review command is called.
+func initializeReviewCommand(cmd *cobra.Command, args []string) {
+	if len(args) != 0 {
+		util.HandleErrorAndExit(util.NewInputError(errors.New("invalid number of arguments. Run 'wum-uc review " +
+			"--help' to view help")))
+	}
+	setLogLevel()
+	logger.Debug("[review] command called")
+
+	err := util.InitEventSinks(viper.GetBool(constant.EVENTS_CONSOLE), viper.GetString(constant.EVENTS_FILE),
+		viper.GetStringSlice(constant.EVENTS_WEBHOOKS))
+	util.HandleErrorAndExit(err, "Error occurred while initializing the event sinks.")
+	defer util.CloseEventSinks()
+
+	wumucResumeFilePath := filepath.Join(WUMUCHome, constant.WUMUC_RESUME_FILE)
+	resumeFile := readResumeFile(wumucResumeFilePath)
+	if resumeFile.IsUpdateZipCreated {
+		util.HandleErrorAndExit(errors.New(fmt.Sprintf("'%s.zip' has already been created. Run 'wum-uc create "+
+			"--continue' to commit it to the update SVN repo.", resumeFile.UpdateName)))
+	}
+	data, err := getReviewData(&resumeFile)
+	util.HandleErrorAndExit(err, "Error occurred while reading the pending update.")
+
+	approved := reviewUpdate(data)
+	if approved {
+		util.PrintInfo(fmt.Sprintf("'%s' approved. Resuming the update creation.", resumeFile.UpdateName))
+		continueResumedUpdateCreation()
+	} else {
+		util.CleanUpDirectory(constant.TEMP_DIR)
+		util.CleanUpFile(wumucResumeFilePath)
+		util.PrintInfo(fmt.Sprintf("'%s' aborted. Please recreate the update using 'wum-uc create'.",
+			resumeFile.UpdateName))
+	}
+}
+
+// This function serves the review page and blocks until the update is approved or aborted. Decisions are only
+// accepted with the token of the review session, so other pages opened in the browser cannot approve the update.
+func reviewUpdate(data *reviewData) bool {
+	page := template.Must(template.New("review").Parse(reviewPageTemplate))
+	decision := make(chan bool, 1)
+	token := make([]byte, 32)
+	_, err := rand.Read(token)
+	util.HandleErrorAndExit(err, "Error occurred while generating the token of the review session.")
+	data.Token = hex.EncodeToString(token)
+
+	serveMux := http.NewServeMux()
+	serveMux.HandleFunc("/", func(writer http.ResponseWriter, request *http.Request) {
+		if err := page.Execute(writer, data); err != nil {
+			logger.Error(fmt.Sprintf("%v error occurred while rendering the review page", err))
+		}
+	})
+	serveMux.HandleFunc("/approve", getDecisionHandler(decision, true, "approved", reviewAddress, data.Token))
+	serveMux.HandleFunc("/abort", getDecisionHandler(decision, false, "aborted", reviewAddress, data.Token))
+	server := &http.Server{Addr: reviewAddress, Handler: serveMux}
+
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while serving the review page at '%s'.",
+				reviewAddress))
+		}
+	}()
+	util.PrintInBold(fmt.Sprintf("Review '%s' at http://%s and approve or abort it.", data.UpdateName,
+		reviewAddress))
+	approved := <-decision
+	server.Close()
+	return approved
+}
+
+// This function returns the handler which records the decision of the reviewer. Decisions are rejected unless they
+// are submitted from the review page served at the given address with the given token of the review session.
+func getDecisionHandler(decision chan bool, approved bool, message, address, token string) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		if request.Method != http.MethodPost {
+			http.Error(writer, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		origin := request.Header.Get("Origin")
+		if request.Host != address || (len(origin) != 0 && origin != "http://"+address) {
+			logger.Debug(fmt.Sprintf("Rejected the decision from %s (host: %s)", origin, request.Host))
+			http.Error(writer, "forbidden", http.StatusForbidden)
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(request.PostFormValue("token")), []byte(token)) != 1 {
+			logger.Debug("Rejected the decision without the token of the review session")
+			http.Error(writer, "forbidden", http.StatusForbidden)
+			return
+		}
+		fmt.Fprintf(writer, "Update %s. You can close this page and return to the terminal.", message)
+		select {
+		case decision <- approved:
+		default:
+		}
+	}
+}
+
+// This function collects the details of the pending update to be rendered in the review page.
+func getReviewData(resumeFile *ResumeFile) (*reviewData, error) {
+	data := reviewData{
+		UpdateName:       resumeFile.UpdateName,
+		DistributionPath: resumeFile.DistributionPath,
+	}
+	// Placement manifest
+	manifest, err := getPlacementManifest(resumeFile.ExplodedUpdateDirectoryPath, resumeFile.UpdateName)
+	if err != nil {
+		return nil, err
+	}
+	for filePath, md5sum := range manifest {
+		data.Manifest = append(data.Manifest, manifestEntry{Path: filePath, Md5sum: md5sum})
+	}
+	sort.Slice(data.Manifest, func(i, j int) bool {
+		return data.Manifest[i].Path < data.Manifest[j].Path
+	})
+
+	// Developer edited update-descriptor3.yaml
+	descriptor, err := ioutil.ReadFile(path.Join(resumeFile.ResourceDirectoryPath,
+		constant.UPDATE_DESCRIPTOR_V3_FILE))
+	if err != nil {
+		return nil, err
+	}
+	data.Descriptor = string(descriptor)
+
+	// Diffs of the modified files
+	data.Diffs, err = getModifiedFileDiffs(resumeFile)
+	return &data, err
+}
+
+// This function returns the diffs of the files in the pending update which modify files of the distribution.
+func getModifiedFileDiffs(resumeFile *ResumeFile) ([]fileDiff, error) {
+	var diffs []fileDiff
+	distributionFS, closer, err := util.OpenDistribution(resumeFile.DistributionPath)
+	if err != nil {
+		return nil, err
+	}
+	defer closer.Close()
+
+	carbonHome := filepath.Join(resumeFile.ExplodedUpdateDirectoryPath, getResumedPayloadDirectory(resumeFile))
+	err = filepath.Walk(carbonHome, func(absolutePath string, fileInfo os.FileInfo, err error) error {
+		if err != nil || fileInfo.IsDir() {
+			return err
+		}
+		relativePath, err := filepath.Rel(carbonHome, absolutePath)
+		if err != nil {
+			return err
+		}
+		relativePath = filepath.ToSlash(relativePath)
+		oldContent, found, err := util.ReadDistributionFile(distributionFS, relativePath)
+		if err == nil && !found {
+			return nil
+		}
+		diffs = append(diffs, getFileDiff(relativePath, oldContent, err, absolutePath))
+		return nil
+	})
+	return diffs, err
+}
+
+// This function returns the diff of the given content of the file in the distribution and the updated file. The
+// error occurred while reading the file in the distribution, if any, is reported in the diff.
+func getFileDiff(relativePath string, oldContent []byte, readErr error, updatedFilePath string) fileDiff {
+	diff := fileDiff{Path: relativePath}
+	if readErr != nil {
+		diff.Message = readErr.Error()
+		return diff
+	}
+	newContent, err := ioutil.ReadFile(updatedFilePath)
+	if err != nil {
+		diff.Message = err.Error()
+		return diff
+	}
+	if !util.IsTextContent(oldContent) || !util.IsTextContent(newContent) {
+		diff.Message = "Binary file modified."
+		if isJarDiffEnabled && strings.HasSuffix(relativePath, constant.JAR_FILE_EXTENSION) {
+			diff.Jar, err = util.GetJarDiff(oldContent, newContent)
+			if err != nil {
+				logger.Debug(fmt.Sprintf("%v error occurred while comparing the classes of %s", err, relativePath))
+			}
+		}
+		return diff
+	}
+	diff.Lines, err = util.GetLineDiff(string(oldContent), string(newContent))
+	if err != nil {
+		diff.Message = err.Error()
+	}
+	return diff
+}
+
+// Template of the review page.
+var reviewPageTemplate = strings.TrimSpace(`
+<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Review {{.UpdateName}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+td, th { border: 1px solid #ccc; padding: 4px 8px; text-align: left; font-family: monospace; }
+pre { background: #f6f8fa; padding: 1em; overflow: auto; }
+.added { background: #e6ffed; }
+.removed { background: #ffeef0; }
+form { display: inline; }
+button { font-size: 1em; padding: 0.5em 1.5em; margin-right: 1em; }
+</style>
+</head>
+<body>
+<h1>{{.UpdateName}}</h1>
+<p>Distribution: {{.DistributionPath}}</p>
+<h2>Placement manifest</h2>
+<table>
+<tr><th>Path</th><th>MD5</th></tr>
+{{range .Manifest}}<tr><td>{{.Path}}</td><td>{{.Md5sum}}</td></tr>
+{{end}}</table>
+<h2>update-descriptor3.yaml</h2>
+<pre>{{.Descriptor}}</pre>
+<h2>Modified files</h2>
+{{range .Diffs}}<h3>{{.Path}}</h3>
+{{if .Message}}<p>{{.Message}}</p>{{with .Jar}}<p>Version: {{.OldVersion}} &rarr; {{.NewVersion}}</p>
+<pre>{{range .AddedClasses}}<span class="added">+{{.}}</span>
+{{end}}{{range .RemovedClasses}}<span class="removed">-{{.}}</span>
+{{end}}{{range .ChangedClasses}}~{{.}}
+{{end}}</pre>{{end}}{{else}}<pre>{{range .Lines}}{{if eq .Type "+"}}<span class="added">+{{.Text}}</span>
+{{else if eq .Type "-"}}<span class="removed">-{{.Text}}</span>
+{{else}} {{.Text}}
+{{end}}{{end}}</pre>{{end}}
+{{else}}<p>No files of the distribution are modified.</p>
+{{end}}
+<form method="post" action="/approve"><input type="hidden" name="token" value="{{.Token}}">
+<button type="submit">Approve</button></form>
+<form method="post" action="/abort"><input type="hidden" name="token" value="{{.Token}}">
+<button type="submit">Abort</button></form>
+</body>
+</html>`)
@@ -0,0 +1,131 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/renstrom/dedent"
+	"github.com/spf13/cobra"
+	"github.com/wso2/update-creator-tool/constant"
+	"github.com/wso2/update-creator-tool/util"
+)
+
+// Values used to print help command.
+var (
+	rollbackCmdUse       = "rollback <rollback_zip> <dist_dir>"
+	rollbackCmdShortDesc = "Undo an update applied to an extracted distribution"
+	rollbackCmdLongDesc  = dedent.Dedent(`
+		This command will undo an update applied to the given extracted distribution with the apply
+		command, using the rollback zip generated while applying it. The rollback zip is written to
+		'updates/wum-uc-backups/<update_name>/<update_name>-rollback.zip' in the distribution and
+		contains the previous versions of the files replaced or removed by the update, along with a
+		reverse descriptor. Files modified or removed by the update are restored, files added by the
+		update are deleted and the backups of the update are removed from the distribution. Updates
+		applied after the given update should be rolled back first.`)
+)
+
+// rollbackCmd represents the rollback command.
+var rollbackCmd = &cobra.Command{
+	Use:   rollbackCmdUse,
+	Short: rollbackCmdShortDesc,
+	Long:  rollbackCmdLongDesc,
+	Run:   initializeRollbackCommand,
+}
+
+// This function will be called first and this will add flags to the command.
+func init() {
+	RootCmd.AddCommand(rollbackCmd)
+
+	rollbackCmd.Flags().BoolVarP(&isDebugLogsEnabled, "debug", "d", util.EnableDebugLogs, "Enable debug logs")
+	rollbackCmd.Flags().BoolVarP(&isTraceLogsEnabled, "trace", "t", util.EnableTraceLogs, "Enable trace logs")
+}
+
+// This function will be called when the rollback command is called.
+func initializeRollbackCommand(cmd *cobra.Command, args []string) {
+	if len(args) != 2 {
+		util.HandleErrorAndExit(util.NewInputError(errors.New("invalid number of arguments. Run 'wum-uc rollback " +
+			"--help' to view help")))
+	}
+	setLogLevel()
+	logger.Debug("[rollback] command called")
+	rollbackUpdate(args[0], args[1])
+}
+
+// This function rolls back the update of the given rollback zip from the given distribution directory.
+func rollbackUpdate(rollbackZipPath, distributionDirectory string) {
+	exists, err := util.IsFileExists(rollbackZipPath)
+	util.HandleErrorAndExit(err, "")
+	if !exists {
+		util.HandleErrorAndExit(util.NewInputError(errors.New(fmt.Sprintf("Entered rollback zip does not exist "+
+			"at '%s'.", rollbackZipPath))))
+	}
+	util.IsZipFile("rollback zip", rollbackZipPath)
+	exists, err = util.IsDirectoryExists(distributionDirectory)
+	util.HandleErrorAndExit(err, "")
+	if !exists {
+		util.HandleErrorAndExit(util.NewInputError(errors.New(fmt.Sprintf("Entered distribution directory does "+
+			"not exist at '%s'.", distributionDirectory))))
+	}
+	rollbackDescriptor, err := util.ReadRollbackDescriptor(rollbackZipPath)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while reading '%s'.", rollbackZipPath))
+	checkLaterAppliedUpdates(rollbackDescriptor, distributionDirectory)
+
+	rollbackDescriptor, err = util.RollbackUpdateZip(rollbackZipPath, distributionDirectory)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while rolling back '%s' from '%s'.", rollbackZipPath,
+		distributionDirectory))
+	util.PrintInfo(fmt.Sprintf("'%s' rolled back from '%s'. %d file(s) restored and %d file(s) deleted.",
+		rollbackDescriptor.UpdateName, distributionDirectory, len(rollbackDescriptor.AddedFiles)+
+			len(rollbackDescriptor.ModifiedFiles), len(rollbackDescriptor.RemovedFiles)))
+}
+
+// This function checks whether the update of the given reverse descriptor is recorded as applied to the given
+// distribution directory and whether other updates are applied after it. Rolling back an update before the updates
+// applied after it would restore files which are replaced by them, so rolling back fails in that case. Updates of
+// which the time of applying cannot be read are considered as applied after it.
+func checkLaterAppliedUpdates(rollbackDescriptor *util.RollbackDescriptor, distributionDirectory string) {
+	backupManifests, err := util.LoadBackupManifests(distributionDirectory)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while reading the applied updates of '%s'.",
+		distributionDirectory))
+	appliedAt, err := time.Parse(time.RFC3339Nano, rollbackDescriptor.AppliedAt)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Invalid time of applying '%s'.", rollbackDescriptor.UpdateName))
+	isApplied := false
+	var laterUpdates []string
+	for _, backupManifest := range backupManifests {
+		if backupManifest.UpdateName == rollbackDescriptor.UpdateName {
+			isApplied = true
+			continue
+		}
+		otherAppliedAt, err := time.Parse(time.RFC3339Nano, backupManifest.AppliedAt)
+		if err != nil || otherAppliedAt.After(appliedAt) {
+			laterUpdates = append(laterUpdates, backupManifest.UpdateName)
+		}
+	}
+	if !isApplied {
+		util.PrintWarning(fmt.Sprintf("'%s' is not recorded as applied to '%s' in '%s'.",
+			rollbackDescriptor.UpdateName, distributionDirectory, constant.APPLIED_UPDATES_BACKUP_DIRECTORY))
+	}
+	if len(laterUpdates) != 0 {
+		util.HandleErrorAndExit(util.NewInputError(errors.New(fmt.Sprintf("update(s) %v are applied to '%s' "+
+			"after '%s'. Roll them back first.", laterUpdates, distributionDirectory,
+			rollbackDescriptor.UpdateName))))
+	}
+}
@@ -0,0 +1,170 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/renstrom/dedent"
+	"github.com/spf13/cobra"
+	"github.com/wso2/update-creator-tool/constant"
+	"github.com/wso2/update-creator-tool/util"
+	"gopkg.in/yaml.v2"
+)
+
+// Values used to print help command.
+var (
+	rollupCmdUse       = "rollup <updates_dir> <update_number>"
+	rollupCmdShortDesc = "Create a cumulative update from the prior updates of a platform version"
+	rollupCmdLongDesc  = dedent.Dedent(`
+		This command will create a cumulative update with the given update number, which contains
+		the union of the changes of the prior updates in the given directory. Updates are applied in
+		the order of their update numbers, so the latest content of each file is used and the files
+		removed by a later update are not added. The update descriptors are merged and the rolled up
+		updates are recorded in 'supersedes' of the update-descriptor3.yaml of the cumulative update.
+		Resource files are taken from the latest update. The cumulative update zip is created in the
+		current directory.`)
+)
+
+// rollupCmd represents the rollup command.
+var rollupCmd = &cobra.Command{
+	Use:   rollupCmdUse,
+	Short: rollupCmdShortDesc,
+	Long:  rollupCmdLongDesc,
+	Run:   initializeRollupCommand,
+}
+
+var rollupPlatformVersion string
+
+// This function will be called first and this will add flags to the command.
+func init() {
+	RootCmd.AddCommand(rollupCmd)
+
+	rollupCmd.Flags().BoolVarP(&isDebugLogsEnabled, "debug", "d", util.EnableDebugLogs, "Enable debug logs")
+	rollupCmd.Flags().BoolVarP(&isTraceLogsEnabled, "trace", "t", util.EnableTraceLogs, "Enable trace logs")
+	rollupCmd.Flags().StringVar(&rollupPlatformVersion, "platform-version", "", "Platform version of the "+
+		"cumulative update, if the directory has updates of several platform versions")
+}
+
+// This function will be called when the rollup command is called.
+func initializeRollupCommand(cmd *cobra.Command, args []string) {
+	if len(args) != 2 {
+		util.HandleErrorAndExit(util.NewInputError(errors.New("invalid number of arguments. Run 'wum-uc rollup " +
+			"--help' to view help")))
+	}
+	setLogLevel()
+	logger.Debug("[rollup] command called")
+	err := util.ValidateUpdateNumberFormat(args[1])
+	util.HandleErrorAndExit(util.NewInputError(err), fmt.Sprintf("Invalid update number '%s'.", args[1]))
+	rollupUpdates(args[0], args[1], rollupPlatformVersion)
+}
+
+// This function creates a cumulative update with the given update number from the prior updates of the given platform
+// version in the given directory.
+func rollupUpdates(updatesDirectory, updateNumber, platformVersion string) {
+	exists, err := util.IsDirectoryExists(updatesDirectory)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while reading '%s'.", updatesDirectory))
+	if !exists {
+		util.HandleErrorAndExit(util.NewInputError(errors.New(fmt.Sprintf("directory '%s' does not exist",
+			updatesDirectory))))
+	}
+	updates, err := util.ReadRolledUpUpdates(updatesDirectory, platformVersion, updateNumber)
+	util.HandleErrorAndExit(util.NewInputError(err), fmt.Sprintf("Error occurred while reading the updates in '%s'.",
+		updatesDirectory))
+	if len(updates) == 0 {
+		util.HandleErrorAndExit(util.NewInputError(errors.New(fmt.Sprintf("no prior updates of update number "+
+			"'%s' found in '%s'", updateNumber, updatesDirectory))))
+	}
+	updateDescriptorV3, updateDescriptorV2, err := util.MergeRolledUpUpdates(updates, updateNumber)
+	util.HandleErrorAndExit(util.NewValidationError(err), "Error occurred while merging the updates.")
+	updateName := constant.UPDATE_NAME_PREFIX + "-" + updateDescriptorV3.PlatformVersion + "-" + updateNumber
+
+	tempDirectory, err := ioutil.TempDir("", "wum-uc-rollup")
+	util.HandleErrorAndExit(err, "Error occurred while creating a temporary directory.")
+	defer util.CleanUpDirectory(tempDirectory)
+	explodedUpdateDirectory := filepath.Join(tempDirectory, updateName)
+	payloadDirectory := util.GetPayloadDirectory(updateDescriptorV3)
+	carbonHome := filepath.Join(explodedUpdateDirectory, payloadDirectory)
+	for _, update := range updates {
+		logger.Debug(fmt.Sprintf("Rolling up %s", update.UpdateName))
+		_, err = util.ExtractUpdateZip(update.UpdateZipPath, carbonHome, constant.EXTRACT_PAYLOAD, nil, nil)
+		util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while extracting '%s'.", update.UpdateZipPath))
+		// Files removed by the update are removed from the payload added by the prior updates
+		products := append(update.UpdateDescriptorV3.CompatibleProducts,
+			update.UpdateDescriptorV3.PartiallyApplicableProducts...)
+		for _, product := range products {
+			for _, removedFile := range product.RemovedFiles {
+				err = os.RemoveAll(filepath.Join(carbonHome, filepath.FromSlash(removedFile)))
+				util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while removing '%s'.", removedFile))
+			}
+		}
+	}
+	latestUpdate := updates[len(updates)-1]
+	_, err = util.ExtractUpdateZip(latestUpdate.UpdateZipPath, explodedUpdateDirectory, constant.EXTRACT_RESOURCES,
+		nil, nil)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while extracting '%s'.", latestUpdate.UpdateZipPath))
+	writeRolledUpResourceFiles(explodedUpdateDirectory, updateDescriptorV3, updateDescriptorV2)
+
+	updateZipName := updateName + constant.ZIP_FILE_EXTENSION
+	var executableFiles []string
+	for _, executableFile := range updateDescriptorV3.ExecutableFiles {
+		executableFiles = append(executableFiles, path.Join(payloadDirectory, executableFile))
+	}
+	err = ZipFile(explodedUpdateDirectory, updateZipName, executableFiles...)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while creating '%s'.", updateZipName))
+	recordAuditEvent(constant.AUDIT_OPERATION_ROLLUP, updateZipName)
+	for _, update := range updates {
+		util.PrintInfo(fmt.Sprintf("'%s' rolled up.", update.UpdateName))
+	}
+	util.PrintInfo(fmt.Sprintf("Cumulative update '%s' created from %d update(s). Run 'wum-uc validate' to validate "+
+		"it against the distribution before releasing it.", updateZipName, len(updates)))
+}
+
+// This function writes the merged update descriptors and the merged instructions to the given exploded update
+// directory.
+func writeRolledUpResourceFiles(explodedUpdateDirectory string, updateDescriptorV3 *util.UpdateDescriptorV3,
+	updateDescriptorV2 *util.UpdateDescriptorV2) {
+	descriptors := map[string]interface{}{constant.UPDATE_DESCRIPTOR_V3_FILE: updateDescriptorV3}
+	if updateDescriptorV2 != nil {
+		descriptors[constant.UPDATE_DESCRIPTOR_V2_FILE] = updateDescriptorV2
+	} else {
+		// update-descriptor.yaml of the latest update is not valid for the cumulative update
+		err := os.RemoveAll(filepath.Join(explodedUpdateDirectory, constant.UPDATE_DESCRIPTOR_V2_FILE))
+		util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while removing '%s'.",
+			constant.UPDATE_DESCRIPTOR_V2_FILE))
+	}
+	for fileName, descriptor := range descriptors {
+		data, err := yaml.Marshal(descriptor)
+		util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while marshalling '%s'.", fileName))
+		err = ioutil.WriteFile(filepath.Join(explodedUpdateDirectory, fileName), data, 0600)
+		util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while writing '%s'.", fileName))
+	}
+	instructionsPath := filepath.Join(explodedUpdateDirectory, constant.INSTRUCTIONS_FILE)
+	exists, err := util.IsFileExists(instructionsPath)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while reading '%s'.", instructionsPath))
+	if exists {
+		err = ioutil.WriteFile(instructionsPath, []byte(updateDescriptorV3.Instructions+"\n"), 0600)
+		util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while writing '%s'.", instructionsPath))
+	}
+}
@@ -48,6 +48,9 @@ var (
 
 var cfgFile string
 
+// Whether wum-uc is run for the first time, ie: the config file did not exist when the command is called
+var isFirstRun bool
+
 // RootCmd represents the base command when called without any subcommands
 var RootCmd = &cobra.Command{
 	Use:   "wum-uc",
@@ -58,13 +61,25 @@ var RootCmd = &cobra.Command{
 // Execute adds all child commands to the root command sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
-	if err := RootCmd.Execute(); err != nil {
-		os.Exit(-1)
+	err := RootCmd.Execute()
+	util.PrintWarningSummary()
+	util.PrintStageTimings()
+	// Errors returned to cobra are caused by invalid commands or flags
+	if err != nil {
+		os.Exit(constant.EXIT_CODE_INPUT_ERROR)
 	}
 }
 
 func init() {
-	cobra.OnInitialize(setLogLevel, checkPrerequisites, initConfig, checkWUMUCVersion)
+	cobra.OnInitialize(setLogLevel, initValidationReport, checkPrerequisites, initConfig, checkWUMUCVersion)
+
+	RootCmd.PersistentFlags().Bool("strict", util.StrictModeEnabled, "Treat warnings as errors")
+	viper.BindPFlag(constant.STRICT_MODE, RootCmd.PersistentFlags().Lookup("strict"))
+	RootCmd.PersistentFlags().Bool("require-online", util.RequireOnline, "Exit if an online service (JIRA, WUM, "+
+		"etc) is unreachable instead of continuing in degraded mode")
+	viper.BindPFlag(constant.REQUIRE_ONLINE, RootCmd.PersistentFlags().Lookup("require-online"))
+	RootCmd.PersistentFlags().Bool("json-io", util.JSONIOEnabled, "Exchange messages and prompts as JSON objects")
+	viper.BindPFlag(constant.JSON_IO, RootCmd.PersistentFlags().Lookup("json-io"))
 }
 
 // This function checks the existence of prerequisite programs needed for running 'wum-uc' tool.
@@ -111,6 +126,10 @@ func initConfig() {
 		util.SetWUMUCLocalRepo(WUMUCHome)
 	}
 	viper.Set(constant.WUM_UC_HOME, WUMUCHome)
+	// The config file is created with the default values when it is loaded for the first time
+	configExists, err := util.IsFileExists(filepath.Join(WUMUCHome, constant.WUMUC_CONFIG_FILE))
+	util.HandleErrorAndExit(err, "Error occurred while reading the wum-uc config.")
+	isFirstRun = !configExists
 	util.LoadWUMUCConfig(WUMUCHome)
 
 	viper.SetConfigName("config") // name of config file (without extension)
@@ -124,21 +143,119 @@ func initConfig() {
 	} else {
 		logger.Debug("Config file not found.")
 	}
+	initLocale()
 
 	logger.Debug(fmt.Sprintf("PATH_SEPARATOR: %s", constant.PATH_SEPARATOR))
 	logger.Debug("Config Values: ---------------------------")
 	logger.Debug(fmt.Sprintf("%s: %s", constant.CHECK_MD5_DISABLED, viper.GetString(constant.CHECK_MD5_DISABLED)))
+	logger.Debug(fmt.Sprintf("%s: %v", constant.STRICT_MODE, viper.GetBool(constant.STRICT_MODE)))
+	logger.Debug(fmt.Sprintf("%s: %v", constant.REQUIRE_ONLINE, viper.GetBool(constant.REQUIRE_ONLINE)))
+	logger.Debug(fmt.Sprintf("%s: %v", constant.JSON_IO, viper.GetBool(constant.JSON_IO)))
 	logger.Debug(fmt.Sprintf("%s: %s", constant.RESOURCE_FILES_MANDATORY,
 		viper.GetStringSlice(constant.RESOURCE_FILES_MANDATORY)))
 	logger.Debug(fmt.Sprintf("%s: %s", constant.RESOURCE_FILES_OPTIONAL,
 		viper.GetStringSlice(constant.RESOURCE_FILES_OPTIONAL)))
 	logger.Debug(fmt.Sprintf("%s: %s", constant.RESOURCE_FILES_SKIP,
 		viper.GetStringSlice(constant.RESOURCE_FILES_SKIP)))
+	logger.Debug(fmt.Sprintf("%s: %v", constant.RESOURCE_FILES_PRODUCT_FAMILIES,
+		viper.GetStringMap(constant.RESOURCE_FILES_PRODUCT_FAMILIES)))
+	logger.Debug(fmt.Sprintf("%s: %s", constant.IGNORED_FILES_MATCHING,
+		viper.GetStringSlice(constant.IGNORED_FILES_MATCHING)))
+	logger.Debug(fmt.Sprintf("%s: %s", constant.IGNORED_FILES_ZIP, viper.GetStringSlice(constant.IGNORED_FILES_ZIP)))
 	logger.Debug(fmt.Sprintf("%s: %s", constant.PLATFORM_VERSIONS,
 		viper.GetStringMapString(constant.PLATFORM_VERSIONS)))
+	logger.Debug(fmt.Sprintf("%s: %v", constant.NESTED_ARCHIVES_DESCEND,
+		viper.GetBool(constant.NESTED_ARCHIVES_DESCEND)))
+	logger.Debug(fmt.Sprintf("%s: %s", constant.NESTED_ARCHIVES_PATTERNS,
+		viper.GetStringSlice(constant.NESTED_ARCHIVES_PATTERNS)))
+	logger.Debug(fmt.Sprintf("%s: %s", constant.UPDATE_NUMBER_PATTERN, viper.GetString(constant.UPDATE_NUMBER_PATTERN)))
+	logger.Debug(fmt.Sprintf("%s: %d - %d", constant.UPDATE_NUMBER, viper.GetInt(constant.UPDATE_NUMBER_MIN),
+		viper.GetInt(constant.UPDATE_NUMBER_MAX)))
+	logger.Debug(fmt.Sprintf("%s: %s", constant.UPDATE_NUMBER_UNIQUENESS_CHECK,
+		viper.GetString(constant.UPDATE_NUMBER_UNIQUENESS_CHECK)))
+	logger.Debug(fmt.Sprintf("%s: %d", constant.UPDATE_NUMBER_RESERVATION_VALIDITY,
+		viper.GetInt(constant.UPDATE_NUMBER_RESERVATION_VALIDITY)))
+	logger.Debug(fmt.Sprintf("%s: %s", constant.VALIDATORS, viper.GetStringSlice(constant.VALIDATORS)))
+	logger.Debug(fmt.Sprintf("%s: %s", constant.OPA_POLICIES, viper.GetStringSlice(constant.OPA_POLICIES)))
+	logger.Debug(fmt.Sprintf("%s: %s", constant.OPA_QUERY, viper.GetString(constant.OPA_QUERY)))
+	logger.Debug(fmt.Sprintf("%s: %d, %s: %d, %s: %v, %s: %s", constant.GUARDRAILS_MAX_CHANGED_FILES,
+		viper.GetInt(constant.GUARDRAILS_MAX_CHANGED_FILES), constant.GUARDRAILS_MAX_PAYLOAD_SIZE,
+		viper.GetInt64(constant.GUARDRAILS_MAX_PAYLOAD_SIZE), constant.GUARDRAILS_MAX_DISTRIBUTION_FRACTION,
+		viper.GetFloat64(constant.GUARDRAILS_MAX_DISTRIBUTION_FRACTION), constant.GUARDRAILS_ACTION,
+		viper.GetString(constant.GUARDRAILS_ACTION)))
+	logger.Debug(fmt.Sprintf("%s: %d, %s: %v, %s: %v", constant.SIZE_BUDGETS_MAX_UPDATE_SIZE,
+		viper.GetInt64(constant.SIZE_BUDGETS_MAX_UPDATE_SIZE), constant.SIZE_BUDGETS_PLATFORMS,
+		viper.GetStringMap(constant.SIZE_BUDGETS_PLATFORMS), constant.SIZE_BUDGETS_PRODUCTS,
+		viper.GetStringMap(constant.SIZE_BUDGETS_PRODUCTS)))
+	logger.Debug(fmt.Sprintf("%s: %s", constant.UPDATE_CATALOG_LOCATION,
+		viper.GetString(constant.UPDATE_CATALOG_LOCATION)))
+	logger.Debug(fmt.Sprintf("%s: %s", constant.UPDATE_CATALOG_CHECKSUM_ALGORITHMS,
+		viper.GetStringSlice(constant.UPDATE_CATALOG_CHECKSUM_ALGORITHMS)))
+	logger.Debug(fmt.Sprintf("%s: %s", constant.CHECKSUM_ALGORITHM, viper.GetString(constant.CHECKSUM_ALGORITHM)))
+	logger.Debug(fmt.Sprintf("%s: %s", constant.HASH_ALGORITHM, viper.GetString(constant.HASH_ALGORITHM)))
+	logger.Debug(fmt.Sprintf("%s: %d", constant.VALIDATION_WORKERS, viper.GetInt(constant.VALIDATION_WORKERS)))
+	logger.Debug(fmt.Sprintf("%s: %d", constant.DISTRIBUTION_WORKERS, viper.GetInt(constant.DISTRIBUTION_WORKERS)))
+	logger.Debug(fmt.Sprintf("%s: %d", constant.DISTRIBUTION_MAX_MEMORY,
+		viper.GetInt64(constant.DISTRIBUTION_MAX_MEMORY)))
+	logger.Debug(fmt.Sprintf("%s: %v", constant.EVENTS_CONSOLE, viper.GetBool(constant.EVENTS_CONSOLE)))
+	logger.Debug(fmt.Sprintf("%s: %s", constant.EVENTS_FILE, viper.GetString(constant.EVENTS_FILE)))
+	logger.Debug(fmt.Sprintf("%s: %s", constant.EVENTS_WEBHOOKS, viper.GetStringSlice(constant.EVENTS_WEBHOOKS)))
+	logger.Debug(fmt.Sprintf("%s: %v", constant.BINARY_DELTA_ENABLED, viper.GetBool(constant.BINARY_DELTA_ENABLED)))
+	logger.Debug(fmt.Sprintf("%s: %d", constant.BINARY_DELTA_MIN_SIZE, viper.GetInt(constant.BINARY_DELTA_MIN_SIZE)))
+	logger.Debug(fmt.Sprintf("%s: %s", constant.BINARY_DELTA_PATTERNS,
+		viper.GetStringSlice(constant.BINARY_DELTA_PATTERNS)))
+	logger.Debug(fmt.Sprintf("%s: %s", constant.UPDATE_FORMAT, viper.GetString(constant.UPDATE_FORMAT)))
+	logger.Debug(fmt.Sprintf("%s: %d", constant.DOWNLOAD_CHUNK_SIZE, viper.GetInt64(constant.DOWNLOAD_CHUNK_SIZE)))
+	logger.Debug(fmt.Sprintf("%s: %d", constant.DOWNLOAD_RETRIES, viper.GetInt(constant.DOWNLOAD_RETRIES)))
+	logger.Debug(fmt.Sprintf("%s: %d location(s)", constant.PUBLISH_LOCATIONS,
+		len(viper.GetStringSlice(constant.PUBLISH_LOCATIONS))))
+	logger.Debug(fmt.Sprintf("%s: %d", constant.APPROVALS_REQUIRED, viper.GetInt(constant.APPROVALS_REQUIRED)))
+	logger.Debug(fmt.Sprintf("%s: %d reviewer(s)", constant.APPROVALS_REVIEWER_KEYS,
+		len(viper.GetStringMapString(constant.APPROVALS_REVIEWER_KEYS))))
+	logger.Debug(fmt.Sprintf("%s: %s", constant.LOCALE, viper.GetString(constant.LOCALE)))
+	logger.Debug(fmt.Sprintf("%s: %v", constant.SCAN_ENABLED, viper.GetBool(constant.SCAN_ENABLED)))
+	logger.Debug(fmt.Sprintf("%s: %s", constant.SCAN_COMMAND, viper.GetString(constant.SCAN_COMMAND)))
+	logger.Debug(fmt.Sprintf("%s: %s", constant.SCAN_ICAP_URL, viper.GetString(constant.SCAN_ICAP_URL)))
+	logger.Debug(fmt.Sprintf("%s: %v", constant.SECRET_SCAN_ENABLED, viper.GetBool(constant.SECRET_SCAN_ENABLED)))
+	logger.Debug(fmt.Sprintf("%s: %s", constant.SECRET_SCAN_PATTERNS,
+		viper.GetStringSlice(constant.SECRET_SCAN_PATTERNS)))
+	logger.Debug(fmt.Sprintf("%s: %s", constant.SECRET_SCAN_ALLOWED_VALUES,
+		viper.GetStringSlice(constant.SECRET_SCAN_ALLOWED_VALUES)))
+	logger.Debug(fmt.Sprintf("%s: %v", constant.READ_ONLY_INPUTS, viper.GetBool(constant.READ_ONLY_INPUTS)))
+	logger.Debug(fmt.Sprintf("%s: %v", constant.LOGS_ENABLED, viper.GetBool(constant.LOGS_ENABLED)))
+	logger.Debug(fmt.Sprintf("%s: %s", constant.LOGS_LEVEL, viper.GetString(constant.LOGS_LEVEL)))
+	logger.Debug(fmt.Sprintf("%s: %d", constant.LOGS_MAX_FILE_SIZE, viper.GetInt(constant.LOGS_MAX_FILE_SIZE)))
+	logger.Debug(fmt.Sprintf("%s: %d", constant.LOGS_RETENTION, viper.GetInt(constant.LOGS_RETENTION)))
+	logger.Debug(fmt.Sprintf("%s: %s", constant.TEMPLATES_REPOSITORY,
+		util.RedactArtifactURI(viper.GetString(constant.TEMPLATES_REPOSITORY))))
+	logger.Debug(fmt.Sprintf("%s: %s", constant.TEMPLATES_BRANCH, viper.GetString(constant.TEMPLATES_BRANCH)))
+	logger.Debug(fmt.Sprintf("%s: %v", constant.PAYLOAD_DIRECTORIES,
+		viper.GetStringMapString(constant.PAYLOAD_DIRECTORIES)))
+	logger.Debug(fmt.Sprintf("%s: %v", constant.PROVENANCE_ENABLED, viper.GetBool(constant.PROVENANCE_ENABLED)))
+	logger.Debug(fmt.Sprintf("%s: %s", constant.PROVENANCE_SIGNING_KEY,
+		viper.GetString(constant.PROVENANCE_SIGNING_KEY)))
+	logger.Debug(fmt.Sprintf("%s: %s", constant.GPG_KEYRING, viper.GetString(constant.GPG_KEYRING)))
+	logger.Debug(fmt.Sprintf("%s: %s", constant.GPG_KEY_ID, viper.GetString(constant.GPG_KEY_ID)))
+	logger.Debug(fmt.Sprintf("%s: %s", constant.AUDIT_KEY, viper.GetString(constant.AUDIT_KEY)))
+	logger.Debug(fmt.Sprintf("%s: %v", constant.INSTRUCTIONS_CONFIG_PREFIXES,
+		viper.GetStringSlice(constant.INSTRUCTIONS_CONFIG_PREFIXES)))
+	logger.Debug(fmt.Sprintf("%s: %s", constant.LEGAL_FILES_LICENSE_URL,
+		viper.GetString(constant.LEGAL_FILES_LICENSE_URL)))
+	logger.Debug(fmt.Sprintf("%s: %s", constant.LEGAL_FILES_SECURITY_LICENSE_URL,
+		viper.GetString(constant.LEGAL_FILES_SECURITY_LICENSE_URL)))
 	logger.Debug("-----------------------------------------")
 }
 
+// This function sets the locale of the messages shown to the user. WUM_UC_LOCALE environment variable overrides the
+// configured locale. Logs and the keys in the reports are always in English.
+func initLocale() {
+	locale := util.ResolveLocale(viper.GetString(constant.LOCALE))
+	messagesDirectory := filepath.Join(WUMUCHome, constant.WUMUC_MESSAGES_DIRECTORY)
+	err := util.SetLocale(locale, messagesDirectory)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while loading the messages of locale '%s'.", locale))
+	logger.Debug(fmt.Sprintf("Locale: %s", util.GetLocale()))
+}
+
 //This function will set the log level
 func setLogLevel() {
 	//Setting default time format. This will be used in loggers. Otherwise complete date and time will be printed
@@ -156,15 +273,87 @@ func setLogLevel() {
 	} else {
 		logger.SetLevel(constant.DEFAULT_LOG_LEVEL)
 	}
+	// Write the logs to a file as well if enabled. This is done once as some commands set the log level again.
+	if viper.GetBool(constant.LOGS_ENABLED) && len(util.GetLogFilePath()) == 0 {
+		initLogFile()
+	}
 	logger.Debug("[LOG LEVEL]", logger.Level())
 }
 
+// This function writes the logs of the session to a time stamped file in the logs directory of the wum-uc home in
+// addition to the console.
+func initLogFile() {
+	fileLevel, err := util.GetLogLevel(viper.GetString(constant.LOGS_LEVEL))
+	util.HandleErrorAndExit(err, fmt.Sprintf("'%s' is invalid.", constant.LOGS_LEVEL))
+	logsDirectory := filepath.Join(WUMUCHome, constant.WUMUC_LOGS_DIRECTORY)
+	maxFileSize := viper.GetInt64(constant.LOGS_MAX_FILE_SIZE) * constant.BYTES_PER_MEGA_BYTE
+	logFilePath, err := util.InitLogFile(logsDirectory, fileLevel, logger.Level(), maxFileSize,
+		viper.GetInt(constant.LOGS_RETENTION))
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while initializing the log file in '%s'.",
+		logsDirectory))
+	logger.Debug(fmt.Sprintf("Logs of %v are written to %s", os.Args, logFilePath))
+}
+
 //This function will set the default values of the configurations
 func setDefaultValues() {
 	viper.SetDefault(constant.RESOURCE_FILES_MANDATORY, util.ResourceFiles_Mandatory)
 	viper.SetDefault(constant.RESOURCE_FILES_OPTIONAL, util.ResourceFiles_Optional)
 	viper.SetDefault(constant.RESOURCE_FILES_SKIP, util.ResourceFiles_Skip)
+	viper.SetDefault(constant.IGNORED_FILES_MATCHING, util.IgnoredFiles_Matching)
+	viper.SetDefault(constant.IGNORED_FILES_ZIP, util.IgnoredFiles_Zip)
 	viper.SetDefault(constant.PLATFORM_VERSIONS, util.PlatformVersions)
+	viper.SetDefault(constant.NESTED_ARCHIVES_PATTERNS, util.NestedArchivesPatterns)
+	viper.SetDefault(constant.UPDATE_NUMBER_PATTERN, util.UpdateNumberPattern)
+	viper.SetDefault(constant.UPDATE_NUMBER_MIN, util.UpdateNumberMin)
+	viper.SetDefault(constant.UPDATE_NUMBER_MAX, util.UpdateNumberMax)
+	viper.SetDefault(constant.UPDATE_NUMBER_RESERVATION_VALIDITY, util.UpdateNumberReservationValidity)
+	viper.SetDefault(constant.VALIDATORS, util.Validators)
+	viper.SetDefault(constant.OPA_EXECUTABLE, util.OPAExecutable)
+	viper.SetDefault(constant.OPA_POLICIES, util.OPAPolicies)
+	viper.SetDefault(constant.OPA_QUERY, util.OPAQuery)
+	viper.SetDefault(constant.EVENTS_WEBHOOKS, util.EventsWebhooks)
+	viper.SetDefault(constant.BINARY_DELTA_MIN_SIZE, util.BinaryDeltaMinSize)
+	viper.SetDefault(constant.BINARY_DELTA_PATTERNS, util.BinaryDeltaPatterns)
+	viper.SetDefault(constant.UPDATE_FORMAT, util.UpdateFormat)
+	viper.SetDefault(constant.DOWNLOAD_CHUNK_SIZE, util.DownloadChunkSize)
+	viper.SetDefault(constant.DOWNLOAD_RETRIES, util.DownloadRetries)
+	viper.SetDefault(constant.PUBLISH_LOCATIONS, util.PublishLocations)
+	viper.SetDefault(constant.APPROVALS_REQUIRED, util.ApprovalsRequired)
+	viper.SetDefault(constant.LOCALE, util.Locale)
+	viper.SetDefault(constant.SCAN_ENABLED, util.ScanEnabled)
+	viper.SetDefault(constant.SCAN_COMMAND, util.ScanCommand)
+	viper.SetDefault(constant.SCAN_ICAP_URL, util.ScanICAPURL)
+	viper.SetDefault(constant.SECRET_SCAN_ENABLED, util.SecretScanEnabled)
+	viper.SetDefault(constant.SECRET_SCAN_PATTERNS, util.SecretScanPatterns)
+	viper.SetDefault(constant.SECRET_SCAN_ALLOWED_VALUES, util.SecretScanAllowedValues)
+	viper.SetDefault(constant.READ_ONLY_INPUTS, util.ReadOnlyInputs)
+	viper.SetDefault(constant.LOGS_ENABLED, util.LogsEnabled)
+	viper.SetDefault(constant.LOGS_LEVEL, util.LogsLevel)
+	viper.SetDefault(constant.LOGS_MAX_FILE_SIZE, util.LogsMaxFileSize)
+	viper.SetDefault(constant.LOGS_RETENTION, util.LogsRetention)
+	viper.SetDefault(constant.TEMPLATES_REPOSITORY, util.TemplatesRepository)
+	viper.SetDefault(constant.TEMPLATES_BRANCH, util.TemplatesBranch)
+	viper.SetDefault(constant.PAYLOAD_DIRECTORIES, util.PayloadDirectories)
+	viper.SetDefault(constant.PROVENANCE_ENABLED, util.ProvenanceEnabled)
+	viper.SetDefault(constant.PROVENANCE_SIGNING_KEY, util.ProvenanceSigningKey)
+	viper.SetDefault(constant.GPG_KEYRING, util.GPGKeyring)
+	viper.SetDefault(constant.GPG_KEY_ID, util.GPGKeyID)
+	viper.SetDefault(constant.AUDIT_KEY, util.AuditKey)
+	viper.SetDefault(constant.INSTRUCTIONS_CONFIG_PREFIXES, util.InstructionsConfigPrefixes)
+	viper.SetDefault(constant.LEGAL_FILES_LICENSE_URL, util.LegalFilesLicenseURL)
+	viper.SetDefault(constant.LEGAL_FILES_SECURITY_LICENSE_URL, util.LegalFilesSecurityLicenseURL)
+	viper.SetDefault(constant.GUARDRAILS_MAX_CHANGED_FILES, util.GuardrailsMaxChangedFiles)
+	viper.SetDefault(constant.GUARDRAILS_MAX_PAYLOAD_SIZE, util.GuardrailsMaxPayloadSize)
+	viper.SetDefault(constant.GUARDRAILS_MAX_DISTRIBUTION_FRACTION, util.GuardrailsMaxDistributionFraction)
+	viper.SetDefault(constant.GUARDRAILS_ACTION, util.GuardrailsAction)
+	viper.SetDefault(constant.SIZE_BUDGETS_MAX_UPDATE_SIZE, util.SizeBudgetsMaxUpdateSize)
+	viper.SetDefault(constant.UPDATE_CATALOG_KEY, util.UpdateCatalogKey)
+	viper.SetDefault(constant.UPDATE_CATALOG_CHECKSUM_ALGORITHMS, util.UpdateCatalogChecksumAlgorithms)
+	viper.SetDefault(constant.CHECKSUM_ALGORITHM, util.ChecksumAlgorithm)
+	viper.SetDefault(constant.HASH_ALGORITHM, util.HashAlgorithm)
+	viper.SetDefault(constant.VALIDATION_WORKERS, util.ValidationWorkers)
+	viper.SetDefault(constant.DISTRIBUTION_WORKERS, util.DistributionWorkers)
+	viper.SetDefault(constant.DISTRIBUTION_MAX_MEMORY, util.DistributionMaxMemory)
 }
 
 // This function checks whether the current version of 'wum-uc' still being supported for creating wum updates.
@@ -215,9 +404,16 @@ func checkWithWUMUCAdmin() {
 	apiURL := util.GetWUMUCConfigs().VersionURL + "/" + constant.WUMUCADMIN_API_CONTEXT + "/" + constant.
 		VERSION + "/" + Version
 
-	response := util.InvokeGetRequest(apiURL)
 	versionResponse := util.VersionResponse{}
-	util.ProcessResponseFromServer(response, &versionResponse)
+	isOnline, _ := util.RunOnlineStep(constant.ONLINE_SERVICE_WUMUC_ADMIN, func() error {
+		response := util.InvokeGetRequest(apiURL)
+		util.ProcessResponseFromServer(response, &versionResponse)
+		return nil
+	})
+	// The version is checked again in the next run if the service is unreachable
+	if !isOnline {
+		return
+	}
 	// Exit if the current version is no longer supported for creating updates
 	if !versionResponse.IsCompatible {
 		util.HandleErrorAndExit(errors.New(fmt.Sprintf(versionResponse.
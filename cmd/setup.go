@@ -0,0 +1,168 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/renstrom/dedent"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/wso2/update-creator-tool/constant"
+	"github.com/wso2/update-creator-tool/util"
+	"gopkg.in/yaml.v2"
+)
+
+// Values used to print help command.
+var (
+	setupCmdUse       = "setup"
+	setupCmdShortDesc = "Configure wum-uc interactively"
+	setupCmdLongDesc  = dedent.Dedent(`
+		This command will run a short setup wizard which configures the platform versions, the
+		resource files of updates, the WUM server and the WSO2 credentials, and writes them to the
+		config.yaml in the wum-uc home. The wizard is also offered when wum-uc is run for the first
+		time. Press enter to keep the value shown in brackets.`)
+)
+
+// setupCmd represents the setup command.
+var setupCmd = &cobra.Command{
+	Use:   setupCmdUse,
+	Short: setupCmdShortDesc,
+	Long:  setupCmdLongDesc,
+	Run:   initializeSetupCommand,
+}
+
+// This function will be called first and this will add flags to the command.
+func init() {
+	RootCmd.AddCommand(setupCmd)
+	RootCmd.PersistentPreRun = offerSetupWizard
+
+	setupCmd.Flags().BoolVarP(&isDebugLogsEnabled, "debug", "d", util.EnableDebugLogs, "Enable debug logs")
+	setupCmd.Flags().BoolVarP(&isTraceLogsEnabled, "trace", "t", util.EnableTraceLogs, "Enable trace logs")
+}
+
+// This function will be called when the setup command is called.
+func initializeSetupCommand(cmd *cobra.Command, args []string) {
+	if len(args) != 0 {
+		util.HandleErrorAndExit(util.NewInputError(errors.New("invalid number of arguments. Run 'wum-uc setup " +
+			"--help' to view help")))
+	}
+	setLogLevel()
+	logger.Debug("[setup] command called")
+	runSetupWizard()
+}
+
+// This function offers the setup wizard when wum-uc is run for the first time. The wizard is not offered when the user
+// cannot be prompted (eg: in the JSON IO mode or in scripts), or when the setup or the init command is run.
+func offerSetupWizard(cmd *cobra.Command, args []string) {
+	if !isFirstRun || cmd == setupCmd || cmd == initCmd || !util.IsInteractive() {
+		return
+	}
+	for {
+		preference, err := util.PromptUser("wum-uc is not configured yet. Do you want to run the setup wizard now? " +
+			"[Y/n]: ")
+		util.HandleErrorAndExit(err, "Error occurred while getting input from the user.")
+		if len(preference) == 0 {
+			preference = "y"
+		}
+		switch util.ProcessUserPreference(preference) {
+		case constant.YES:
+			runSetupWizard()
+			return
+		case constant.NO:
+			util.PrintInfo("Default configuration is used. Run 'wum-uc setup' to configure wum-uc later.")
+			return
+		default:
+			util.PrintError("Invalid preference. Enter Y for Yes or N for No.")
+		}
+	}
+}
+
+// This function prompts for the configuration of wum-uc, writes it to the config file in the wum-uc home and reloads
+// it. Credentials are only prompted if the user wants to enter them now.
+func runSetupWizard() {
+	configPath := filepath.Join(WUMUCHome, constant.WUMUC_CONFIG_FILE)
+	wumucConfig := util.GetWUMUCConfigs()
+
+	var platformVersions map[string]string
+	for {
+		input := promptWithDefault("Platform versions as <platform_version>=<platform_name>, comma separated",
+			util.FormatPlatformVersions(viper.GetStringMapString(constant.PLATFORM_VERSIONS)))
+		var err error
+		if platformVersions, err = util.ParsePlatformVersions(input); err == nil {
+			break
+		}
+		util.PrintError(err.Error())
+	}
+	mandatoryResourceFiles := util.ParseFileList(promptWithDefault("Mandatory resource files, comma separated",
+		strings.Join(viper.GetStringSlice(constant.RESOURCE_FILES_MANDATORY), ",")))
+	optionalResourceFiles := util.ParseFileList(promptWithDefault("Optional resource files, comma separated",
+		strings.Join(viper.GetStringSlice(constant.RESOURCE_FILES_OPTIONAL), ",")))
+	serverURL := strings.TrimSuffix(promptWithDefault("WUM server URL", wumucConfig.ServerURL), "/")
+
+	err := util.SaveConfigValues(configPath, yaml.MapSlice{
+		{Key: constant.PLATFORM_VERSIONS, Value: platformVersions},
+		{Key: constant.RESOURCE_FILES_MANDATORY, Value: mandatoryResourceFiles},
+		{Key: constant.RESOURCE_FILES_OPTIONAL, Value: optionalResourceFiles},
+	})
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while writing '%s'.", configPath))
+	if serverURL != wumucConfig.ServerURL {
+		wumucConfig.ServerURL = serverURL
+		wumucConfig.TokenURL = serverURL + "/" + constant.TOKEN_API_CONTEXT
+		err = util.WriteConfigFile(wumucConfig, configPath)
+		util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while writing '%s'.", configPath))
+	}
+	if err = viper.ReadInConfig(); err != nil {
+		logger.Debug(fmt.Sprintf("Config file not reloaded: %v", err))
+	}
+	util.PrintInfo(fmt.Sprintf("Configuration written to '%s'.", configPath))
+
+	for {
+		preference, err := util.PromptUser("Do you want to enter your WSO2 credentials now? [Y/n]: ")
+		util.HandleErrorAndExit(err, "Error occurred while getting input from the user.")
+		if len(preference) == 0 {
+			preference = "y"
+		}
+		switch util.ProcessUserPreference(preference) {
+		case constant.YES:
+			util.Init("", nil)
+			util.PrintInfo("wum-uc is initialized with your WSO2 credentials.")
+			return
+		case constant.NO:
+			util.PrintInfo("Run 'wum-uc init' to enter your WSO2 credentials later.")
+			return
+		default:
+			util.PrintError("Invalid preference. Enter Y for Yes or N for No.")
+		}
+	}
+}
+
+// This function prompts for a value with the given message. The given default value is returned if the user does not
+// enter a value.
+func promptWithDefault(message, defaultValue string) string {
+	answer, err := util.PromptUser(fmt.Sprintf("%s [%s]: ", util.Localize(message), defaultValue))
+	util.HandleErrorAndExit(err, "Error occurred while getting input from the user.")
+	if len(strings.TrimSpace(answer)) == 0 {
+		return defaultValue
+	}
+	return strings.TrimSpace(answer)
+}
@@ -0,0 +1,157 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/renstrom/dedent"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/wso2/update-creator-tool/constant"
+	"github.com/wso2/update-creator-tool/util"
+	"golang.org/x/crypto/openpgp"
+)
+
+// Values used to print help command.
+var (
+	signCmdUse       = "sign <update_loc>"
+	signCmdShortDesc = "Sign an update with a GPG key"
+	signCmdLongDesc  = dedent.Dedent(`
+		This command will create a detached GPG signature of the given update zip in
+		'<update_loc>.asc' (or the file given with '--output <file>'), so that the consumers of
+		the update can verify who released it. The secret key is read from the exported key
+		given with '--key <file>' or from the keyring given with '--keyring <file>' (GPG.KEYRING
+		in the config), and it is selected with '--key-id <id>' (GPG.KEY_ID in the config) if
+		the keyring has more than one secret key. The passphrase of the key is read from the
+		WUM_UC_GPG_PASSPHRASE environment variable or prompted. Give '--embed' to embed the
+		signature in the update zip as well.`)
+)
+
+// signCmd represents the sign command.
+var signCmd = &cobra.Command{
+	Use:   signCmdUse,
+	Short: signCmdShortDesc,
+	Long:  signCmdLongDesc,
+	Run:   initializeSignCommand,
+}
+
+var gpgKeyPath string
+var signatureOutputPath string
+var isSignatureEmbedded bool
+
+// This function will be called first and this will add flags to the command.
+func init() {
+	RootCmd.AddCommand(signCmd)
+
+	signCmd.Flags().BoolVarP(&isDebugLogsEnabled, "debug", "d", util.EnableDebugLogs, "Enable debug logs")
+	signCmd.Flags().BoolVarP(&isTraceLogsEnabled, "trace", "t", util.EnableTraceLogs, "Enable trace logs")
+	signCmd.Flags().StringVar(&gpgKeyPath, "key", "", "Exported GPG secret key used to sign the update, "+
+		"instead of the keyring")
+	signCmd.Flags().StringVar(&signatureOutputPath, "output", "", "Write the detached signature to the given file "+
+		"instead of '<update_loc>.asc'")
+	signCmd.Flags().BoolVar(&isSignatureEmbedded, "embed", false, "Embed the signature in the update zip as well")
+
+	signCmd.Flags().String("keyring", util.GPGKeyring, "GPG keyring which has the secret key used to sign the "+
+		"update")
+	viper.BindPFlag(constant.GPG_KEYRING, signCmd.Flags().Lookup("keyring"))
+	signCmd.Flags().String("key-id", util.GPGKeyID, "Fingerprint, key id or user id of the secret key, if the "+
+		"keyring has more than one secret key")
+	viper.BindPFlag(constant.GPG_KEY_ID, signCmd.Flags().Lookup("key-id"))
+}
+
+// This function will be called when the sign command is called.
+func initializeSignCommand(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		util.HandleErrorAndExit(util.NewInputError(errors.New("invalid number of arguments. Run 'wum-uc sign " +
+			"--help' to view help")))
+	}
+	setLogLevel()
+	logger.Debug("[sign] command called")
+	keyringPath := gpgKeyPath
+	if len(keyringPath) == 0 {
+		keyringPath = viper.GetString(constant.GPG_KEYRING)
+	}
+	if len(keyringPath) == 0 {
+		util.HandleErrorAndExit(util.NewInputError(errors.New(fmt.Sprintf("GPG key is not given. Run 'wum-uc sign "+
+			"<update_loc> --key <file>' or set '%s' in the config", constant.GPG_KEYRING))))
+	}
+	outputPath := signatureOutputPath
+	if len(outputPath) == 0 {
+		outputPath = args[0] + constant.GPG_SIGNATURE_EXTENSION
+	}
+	signUpdate(args[0], keyringPath, viper.GetString(constant.GPG_KEY_ID), outputPath, isSignatureEmbedded)
+}
+
+// This function signs the given update with the GPG key read from the given keyring and writes the detached signature
+// to the given location. The signature is embedded in the update zip before the detached signature is created if
+// embed is true, so that the detached signature is valid for the update zip with the embedded signature.
+func signUpdate(updateFilePath, keyringPath, keyID, outputPath string, embed bool) {
+	exists, err := util.IsFileExists(updateFilePath)
+	util.HandleErrorAndExit(err, "")
+	if !exists {
+		util.HandleErrorAndExit(util.NewInputError(errors.New(fmt.Sprintf("Entered update file does not exist at '%s'.",
+			updateFilePath))))
+	}
+	if embed && !strings.HasSuffix(updateFilePath, constant.ZIP_FILE_EXTENSION) {
+		util.HandleErrorAndExit(util.NewInputError(errors.New(fmt.Sprintf("'--embed' requires an update zip, "+
+			"'%s' is not a zip", updateFilePath))))
+	}
+	entity := readGPGSigningKey(keyringPath, keyID)
+	if embed {
+		err = util.EmbedGPGSignature(updateFilePath, entity)
+		util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while embedding the signature in '%s'.",
+			updateFilePath))
+		logger.Debug(fmt.Sprintf("Signature embedded in %s", updateFilePath))
+	}
+	err = util.SignFileWithGPG(updateFilePath, outputPath, entity)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while signing '%s'.", updateFilePath))
+	// Record the signing in the audit log
+	recordAuditEvent(constant.AUDIT_OPERATION_SIGN, updateFilePath)
+	util.PrintInfo(fmt.Sprintf("'%s' signed with the GPG key %s. The signature is written to '%s'.",
+		updateFilePath, util.GetGPGKeyDescription(entity), outputPath))
+}
+
+// This function reads the GPG secret key with the given id from the given keyring and decrypts it with its passphrase
+// if it is encrypted.
+func readGPGSigningKey(keyringPath, keyID string) *openpgp.Entity {
+	entity, err := util.ReadGPGSigningKey(keyringPath, keyID)
+	util.HandleErrorAndExit(util.NewInputError(err), "Error occurred while reading the GPG key.")
+	if util.IsGPGKeyEncrypted(entity) {
+		passphrase, err := getGPGPassphrase(entity)
+		util.HandleErrorAndExit(err, "Error occurred while reading the passphrase.")
+		err = util.DecryptGPGKey(entity, passphrase)
+		util.HandleErrorAndExit(util.NewInputError(err))
+	}
+	return entity
+}
+
+// This function returns the passphrase of the given GPG key. The passphrase is read from the WUM_UC_GPG_PASSPHRASE
+// environment variable if it is set, or else it is prompted.
+func getGPGPassphrase(entity *openpgp.Entity) ([]byte, error) {
+	passphrase := []byte(os.Getenv(constant.WUM_UC_GPG_PASSPHRASE))
+	if len(passphrase) != 0 {
+		return passphrase, nil
+	}
+	return util.PromptPassword(fmt.Sprintf("Enter the passphrase of the GPG key %s: ",
+		util.GetGPGKeyDescription(entity)))
+}
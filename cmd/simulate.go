@@ -0,0 +1,227 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/renstrom/dedent"
+	"github.com/spf13/cobra"
+	"github.com/wso2/update-creator-tool/constant"
+	"github.com/wso2/update-creator-tool/util"
+)
+
+// Values used to print help command.
+var (
+	simulateCmdUse       = "simulate <update_zip> <state_manifest>"
+	simulateCmdShortDesc = "Simulate applying an update to a customer environment"
+	simulateCmdLongDesc  = dedent.Dedent(`
+		This command will simulate applying the given update to a customer environment of which the
+		files are listed in the given state manifest. The state manifest contains the md5 or sha256
+		hash and the path relative to carbon.home of each file, in the format of md5sum/sha256sum
+		(eg: generated in carbon.home with 'find . -type f | xargs sha256sum'). The files which
+		would be overwritten, the removed and modified files which are missing in the environment
+		and, if the distribution is given, the modified files which have local customizations are
+		reported. Nothing is modified. The policy for the customized files is given with
+		'--keep-custom', '--overwrite' or '--merge-prompt', which prompts for each customized file
+		whether to keep it, overwrite it or extract the file of the update next to it to merge.`)
+)
+
+// simulateCmd represents the simulate command.
+var simulateCmd = &cobra.Command{
+	Use:   simulateCmdUse,
+	Short: simulateCmdShortDesc,
+	Long:  simulateCmdLongDesc,
+	Run:   initializeSimulateCommand,
+}
+
+var simulationDistributionPath string
+var isCustomizationKept bool
+var isCustomizationOverwritten bool
+var isMergePrompted bool
+
+// This function will be called first and this will add flags to the command.
+func init() {
+	RootCmd.AddCommand(simulateCmd)
+
+	simulateCmd.Flags().BoolVarP(&isDebugLogsEnabled, "debug", "d", util.EnableDebugLogs, "Enable debug logs")
+	simulateCmd.Flags().BoolVarP(&isTraceLogsEnabled, "trace", "t", util.EnableTraceLogs, "Enable trace logs")
+	simulateCmd.Flags().StringVar(&simulationDistributionPath, "distribution", "", "Distribution of the "+
+		"environment, used to detect local customizations of the modified files")
+	addCustomizationPolicyFlags(simulateCmd)
+}
+
+// This function adds the flags of the policies for the locally customized files to the given command.
+func addCustomizationPolicyFlags(command *cobra.Command) {
+	command.Flags().BoolVar(&isCustomizationKept, "keep-custom", false, "Keep the locally customized files")
+	command.Flags().BoolVar(&isCustomizationOverwritten, "overwrite", false, "Overwrite the locally customized "+
+		"files")
+	command.Flags().BoolVar(&isMergePrompted, "merge-prompt", false, "Prompt whether to keep, overwrite or merge "+
+		"each locally customized file")
+}
+
+// This function will be called when the simulate command is called.
+func initializeSimulateCommand(cmd *cobra.Command, args []string) {
+	if len(args) != 2 {
+		util.HandleErrorAndExit(util.NewInputError(errors.New("invalid number of arguments. Run 'wum-uc simulate " +
+			"--help' to view help")))
+	}
+	setLogLevel()
+	logger.Debug("[simulate] command called")
+	customizationPolicy := getCustomizationPolicy(simulationDistributionPath)
+	simulateUpdate(args[0], args[1], simulationDistributionPath, customizationPolicy)
+}
+
+// This function returns the policy given for the locally customized files. An empty policy is returned if no policy is
+// given. Customized files can only be detected if the distribution is given.
+func getCustomizationPolicy(distributionPath string) string {
+	policy := ""
+	policies := 0
+	if isCustomizationKept {
+		policy = constant.CUSTOMIZATION_POLICY_KEEP
+		policies++
+	}
+	if isCustomizationOverwritten {
+		policy = constant.CUSTOMIZATION_POLICY_OVERWRITE
+		policies++
+	}
+	if isMergePrompted {
+		policy = constant.CUSTOMIZATION_POLICY_MERGE
+		policies++
+	}
+	if policies > 1 {
+		util.HandleErrorAndExit(util.NewInputError(errors.New("only one of '--keep-custom', '--overwrite' and " +
+			"'--merge-prompt' can be given")))
+	}
+	if policies == 1 && len(distributionPath) == 0 {
+		util.HandleErrorAndExit(util.NewInputError(errors.New("'--distribution' is required to detect the locally " +
+			"customized files")))
+	}
+	return policy
+}
+
+// This function applies the given policy to the given locally customized files and returns the policy applied to each
+// file. If the policy is merge, the user is prompted for the policy of each file.
+func resolveCustomizedFiles(customizedFiles []string, policy string) map[string]string {
+	resolvedFiles := make(map[string]string)
+	for _, customizedFile := range customizedFiles {
+		if policy != constant.CUSTOMIZATION_POLICY_MERGE {
+			resolvedFiles[customizedFile] = policy
+			continue
+		}
+	userInputLoop:
+		for {
+			preference, err := util.PromptUserWithOptions(fmt.Sprintf("'%s' is customized locally. Keep it (k), "+
+				"overwrite it (o) or extract the file of the update next to it as '%s%s' to merge (m)? [k/o/m]: ",
+				customizedFile, customizedFile, constant.CUSTOMIZATION_MERGE_EXTENSION), []string{"k", "o", "m"})
+			util.HandleErrorAndExit(err, "Error occurred while getting input from the user.")
+			switch strings.ToLower(preference) {
+			case "k":
+				resolvedFiles[customizedFile] = constant.CUSTOMIZATION_POLICY_KEEP
+				break userInputLoop
+			case "o":
+				resolvedFiles[customizedFile] = constant.CUSTOMIZATION_POLICY_OVERWRITE
+				break userInputLoop
+			case "m":
+				resolvedFiles[customizedFile] = constant.CUSTOMIZATION_POLICY_MERGE
+				break userInputLoop
+			default:
+				util.PrintError("Invalid preference. Enter K to keep, O to overwrite or M to merge.")
+			}
+		}
+	}
+	return resolvedFiles
+}
+
+// This function prints the given locally customized files grouped by the policy applied to them.
+func printResolvedCustomizedFiles(customizedFiles []string, resolvedFiles map[string]string) {
+	filesOfPolicies := make(map[string][]string)
+	for _, customizedFile := range customizedFiles {
+		policy := resolvedFiles[customizedFile]
+		filesOfPolicies[policy] = append(filesOfPolicies[policy], customizedFile)
+	}
+	printSimulatedFiles("Customized file(s) to keep", filesOfPolicies[constant.CUSTOMIZATION_POLICY_KEEP])
+	printSimulatedFiles(fmt.Sprintf("Customized file(s) to merge with the file of the update, extracted with '%s'",
+		constant.CUSTOMIZATION_MERGE_EXTENSION), filesOfPolicies[constant.CUSTOMIZATION_POLICY_MERGE])
+	if overwrittenFiles := filesOfPolicies[constant.CUSTOMIZATION_POLICY_OVERWRITE]; len(overwrittenFiles) != 0 {
+		util.PrintWarning(fmt.Sprintf("Customized file(s) to overwrite:\n\t%s",
+			strings.Join(overwrittenFiles, "\n\t")))
+	}
+}
+
+// This function returns the hashes of the given files in the distribution at the given location against their paths
+// relative to the distribution root. The distribution is read with the distribution source which provides it.
+func readDistributionHashes(distributionPath string, files []string, hashLength int) map[string]string {
+	distributionFS, closer, err := util.OpenDistribution(distributionPath)
+	util.HandleErrorAndExit(util.NewInputError(err), fmt.Sprintf("Error occurred while reading the %s '%s'.",
+		strings.ToLower(constant.DISTRIBUTION), distributionPath))
+	defer closer.Close()
+	distributionHashes, err := util.ReadDistributionHashes(distributionFS, files, hashLength)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while reading '%s'.", distributionPath))
+	return distributionHashes
+}
+
+// This function simulates applying the given update to the environment of the given state manifest and prints the
+// report.
+func simulateUpdate(updateFilePath, stateManifestPath, distributionPath, customizationPolicy string) {
+	util.IsZipFile("update", updateFilePath)
+	stateHashes, hashLength, err := util.ReadStateManifest(stateManifestPath)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while reading the state manifest '%s'.",
+		stateManifestPath))
+	logger.Debug(fmt.Sprintf("%d files found in %s", len(stateHashes), stateManifestPath))
+	updateChanges, err := util.ReadUpdateChanges(updateFilePath, hashLength)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while reading '%s'.", updateFilePath))
+
+	var distributionHashes map[string]string
+	if len(distributionPath) != 0 {
+		distributionHashes = readDistributionHashes(distributionPath, updateChanges.ModifiedFiles, hashLength)
+	}
+	report := util.SimulateUpdate(updateChanges, stateHashes, distributionHashes)
+
+	printSimulatedFiles("File(s) which would be overwritten", report.OverwrittenFiles)
+	printSimulatedFiles("File(s) which would be added", report.NewFiles)
+	printSimulatedFiles("File(s) which are already up to date", report.UpToDateFiles)
+	printSimulatedFiles("Modified file(s) which are not in the environment", report.MissingModifiedFiles)
+	if len(report.MissingRemovedFiles) != 0 {
+		util.PrintWarning(fmt.Sprintf("Removed file(s) which are not in the environment:\n\t%s",
+			strings.Join(report.MissingRemovedFiles, "\n\t")))
+	}
+	if len(distributionPath) == 0 {
+		util.PrintInfo("Distribution not given. Local customizations of the modified files are not detected.")
+	} else if len(report.ConflictingFiles) != 0 && len(customizationPolicy) != 0 {
+		resolvedFiles := resolveCustomizedFiles(report.ConflictingFiles, customizationPolicy)
+		printResolvedCustomizedFiles(report.ConflictingFiles, resolvedFiles)
+	} else if len(report.ConflictingFiles) != 0 {
+		util.PrintWarning(fmt.Sprintf("Modified file(s) which are customized in the environment and would be "+
+			"overwritten:\n\t%s", strings.Join(report.ConflictingFiles, "\n\t")))
+	}
+	util.PrintInfo(fmt.Sprintf("'%s' simulated against '%s'. %d overwritten, %d added, %d up to date, %d "+
+		"conflicting file(s).", updateChanges.UpdateName, stateManifestPath, len(report.OverwrittenFiles),
+		len(report.NewFiles), len(report.UpToDateFiles), len(report.ConflictingFiles)))
+}
+
+// This function prints the given files of the simulation report under the given title.
+func printSimulatedFiles(title string, files []string) {
+	if len(files) == 0 {
+		return
+	}
+	util.PrintInfo(fmt.Sprintf("%s:\n\t%s", title, strings.Join(files, "\n\t")))
+}
@@ -0,0 +1,180 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package cmd
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/renstrom/dedent"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/wso2/update-creator-tool/constant"
+	"github.com/wso2/update-creator-tool/util"
+	"gopkg.in/yaml.v2"
+)
+
+// Values used to print help command.
+var (
+	supersedeCmdUse       = "supersede <new_update> <old_update>..."
+	supersedeCmdShortDesc = "Mark prior updates as superseded by a new update"
+	supersedeCmdLongDesc  = dedent.Dedent(`
+		This command will record the given prior updates as superseded by the given new update. The
+		new update should be an update of the same platform with a higher update number and it should
+		change all the files changed by the superseded updates. The superseded updates are added to
+		'supersedes' in the update-descriptor3.yaml of the new update zip. If a catalog is given (or
+		configured), the entry of the new update in the catalog is updated as well.`)
+)
+
+// supersedeCmd represents the supersede command.
+var supersedeCmd = &cobra.Command{
+	Use:   supersedeCmdUse,
+	Short: supersedeCmdShortDesc,
+	Long:  supersedeCmdLongDesc,
+	Run:   initializeSupersedeCommand,
+}
+
+var supersedeCatalogPath string
+var supersedeCatalogSigningKeyPath string
+
+// This function will be called first and this will add flags to the command.
+func init() {
+	RootCmd.AddCommand(supersedeCmd)
+
+	supersedeCmd.Flags().BoolVarP(&isDebugLogsEnabled, "debug", "d", util.EnableDebugLogs, "Enable debug logs")
+	supersedeCmd.Flags().BoolVarP(&isTraceLogsEnabled, "trace", "t", util.EnableTraceLogs, "Enable trace logs")
+	supersedeCmd.Flags().StringVar(&supersedeCatalogPath, "catalog", "", "Catalog which should be updated "+
+		"(default "+constant.UPDATE_CATALOG_LOCATION+" in the config)")
+	supersedeCmd.Flags().StringVar(&supersedeCatalogSigningKeyPath, "signing-key", "", "RSA private key (PEM) "+
+		"used to sign the updated catalog")
+}
+
+// This function will be called when the supersede command is called.
+func initializeSupersedeCommand(cmd *cobra.Command, args []string) {
+	if len(args) < 2 {
+		util.HandleErrorAndExit(util.NewInputError(errors.New("invalid number of arguments. Run 'wum-uc supersede " +
+			"--help' to view help")))
+	}
+	setLogLevel()
+	logger.Debug("[supersede] command called")
+	if len(supersedeCatalogPath) == 0 {
+		supersedeCatalogPath = viper.GetString(constant.UPDATE_CATALOG_LOCATION)
+	}
+	supersede(args[0], args[1:], supersedeCatalogPath, supersedeCatalogSigningKeyPath)
+}
+
+// This function records the given prior updates as superseded by the given new update.
+func supersede(updateFilePath string, supersededUpdateFilePaths []string, catalogPath, signingKeyPath string) {
+	for _, filePath := range append([]string{updateFilePath}, supersededUpdateFilePaths...) {
+		util.IsZipFile("update", filePath)
+		exists, err := util.IsFileExists(filePath)
+		util.HandleErrorAndExit(err, "")
+		if !exists {
+			util.HandleErrorAndExit(util.NewInputError(errors.New(fmt.Sprintf("Entered update file does not exist at "+
+				"'%s'.", filePath))))
+		}
+	}
+	entry, err := util.NewCatalogEntry(updateFilePath)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while reading '%s'.", updateFilePath))
+
+	// Check whether the new update covers all the files of the superseded updates
+	var supersededUpdateNames []string
+	for _, supersededUpdateFilePath := range supersededUpdateFilePaths {
+		supersededEntry, err := util.NewCatalogEntry(supersededUpdateFilePath)
+		util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while reading '%s'.", supersededUpdateFilePath))
+		uncoveredFiles, err := util.CheckSupersession(entry, supersededEntry)
+		util.HandleErrorAndExit(err)
+		if len(uncoveredFiles) != 0 {
+			util.HandleErrorAndExit(errors.New(fmt.Sprintf("'%s' cannot supersede '%s' as it does not change %s.",
+				entry.UpdateName, supersededEntry.UpdateName, strings.Join(uncoveredFiles, ", "))))
+		}
+		supersededUpdateNames = append(supersededUpdateNames, supersededEntry.UpdateName)
+	}
+
+	recordSupersededUpdates(updateFilePath, entry.UpdateName, supersededUpdateNames)
+	util.PrintInfo(fmt.Sprintf("'%s' is recorded as superseding %s.", entry.UpdateName,
+		strings.Join(supersededUpdateNames, ", ")))
+
+	if len(catalogPath) == 0 {
+		util.PrintInfo("Catalog not given. No catalog is updated.")
+		return
+	}
+	updateCatalogEntry(updateFilePath, catalogPath, signingKeyPath)
+}
+
+// This function adds the given superseded updates to the update-descriptor3.yaml in the given update zip.
+func recordSupersededUpdates(updateFilePath, updateName string, supersededUpdateNames []string) {
+	descriptorEntryName := updateName + "/" + constant.UPDATE_DESCRIPTOR_V3_FILE
+	zipReader, err := zip.OpenReader(updateFilePath)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while reading '%s'.", updateFilePath))
+	var data []byte
+	for _, file := range zipReader.Reader.File {
+		if file.Name == descriptorEntryName {
+			data, err = readZipEntry(file)
+			break
+		}
+	}
+	zipReader.Close()
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while reading '%s' in '%s'.",
+		constant.UPDATE_DESCRIPTOR_V3_FILE, updateFilePath))
+	if data == nil {
+		util.HandleErrorAndExit(errors.New(fmt.Sprintf("'%s' not found in '%s'. Only updates with '%s' can "+
+			"supersede other updates.", constant.UPDATE_DESCRIPTOR_V3_FILE, updateFilePath,
+			constant.UPDATE_DESCRIPTOR_V3_FILE)))
+	}
+	updateDescriptorV3 := util.UpdateDescriptorV3{}
+	err = util.UnmarshalUpdateDescriptor(data, constant.UPDATE_DESCRIPTOR_V3_FILE, &updateDescriptorV3)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while unmarshalling '%s'.",
+		constant.UPDATE_DESCRIPTOR_V3_FILE))
+	util.AddSupersededUpdates(&updateDescriptorV3, supersededUpdateNames)
+	data, err = yaml.Marshal(&updateDescriptorV3)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while marshalling '%s'.",
+		constant.UPDATE_DESCRIPTOR_V3_FILE))
+
+	// The update zip is written to a temporary file first so that it is not corrupted if an error occurs
+	tempFile, err := ioutil.TempFile("", "wum-uc-supersede")
+	util.HandleErrorAndExit(err, "Error occurred while creating a temporary file.")
+	tempFile.Close()
+	defer os.Remove(tempFile.Name())
+	err = util.ReplaceZipEntry(updateFilePath, tempFile.Name(), descriptorEntryName, data)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while updating '%s'.", updateFilePath))
+	err = util.CopyFile(tempFile.Name(), updateFilePath)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while updating '%s'.", updateFilePath))
+}
+
+// This function replaces the entry of the given update in the given catalog with a new entry created from the update
+// zip. The entry is added if the update is not in the catalog. Dependencies of the entries are resolved again.
+func updateCatalogEntry(updateFilePath, catalogPath, signingKeyPath string) {
+	catalog, _, err := util.LoadCatalog(catalogPath)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while reading the catalog '%s'.", catalogPath))
+	entry, err := util.NewCatalogEntry(updateFilePath)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while reading '%s'.", updateFilePath))
+	var entries []util.CatalogEntry
+	for _, catalogEntry := range catalog.Updates {
+		if catalogEntry.UpdateName != entry.UpdateName {
+			entries = append(entries, catalogEntry)
+		}
+	}
+	entries = append(entries, *entry)
+	writeCatalog(util.NewCatalog(entries), catalogPath, signingKeyPath)
+}
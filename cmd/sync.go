@@ -0,0 +1,215 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/renstrom/dedent"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/wso2/update-creator-tool/constant"
+	"github.com/wso2/update-creator-tool/util"
+)
+
+// Values used to print help command.
+var (
+	syncCmdUse       = "sync <remote> <local_dir>"
+	syncCmdShortDesc = "Synchronize updates from a remote store to a local directory"
+	syncCmdLongDesc  = dedent.Dedent(`
+		This command will download the catalog and the update zips listed in it from the given remote
+		store (a directory, http(s)://, s3://, gs://, azblob:// or artifactory:// URI) to the given
+		local directory. The signature of the catalog is verified with the public key given with
+		'--catalog-key', which is required. Checksums of the downloaded update zips are verified
+		against the catalog and interrupted downloads are resumed. Updates which are no longer listed
+		in the catalog are removed from the local directory if '--prune' is given.`)
+)
+
+// syncCmd represents the sync command.
+var syncCmd = &cobra.Command{
+	Use:   syncCmdUse,
+	Short: syncCmdShortDesc,
+	Long:  syncCmdLongDesc,
+	Run:   initializeSyncCommand,
+}
+
+var syncCatalogPublicKeyPath string
+var isPruneEnabled bool
+
+// This function will be called first and this will add flags to the command.
+func init() {
+	RootCmd.AddCommand(syncCmd)
+
+	syncCmd.Flags().BoolVarP(&isDebugLogsEnabled, "debug", "d", util.EnableDebugLogs, "Enable debug logs")
+	syncCmd.Flags().BoolVarP(&isTraceLogsEnabled, "trace", "t", util.EnableTraceLogs, "Enable trace logs")
+	syncCmd.Flags().StringVar(&syncCatalogPublicKeyPath, "catalog-key", "", "RSA public key (PEM) used to "+
+		"verify the signature of the catalog (required)")
+	syncCmd.Flags().BoolVar(&isPruneEnabled, "prune", false, "Remove the updates which are not listed in the "+
+		"catalog from the local directory")
+}
+
+// This function will be called when the sync command is called.
+func initializeSyncCommand(cmd *cobra.Command, args []string) {
+	if len(args) != 2 {
+		util.HandleErrorAndExit(util.NewInputError(errors.New("invalid number of arguments. Run 'wum-uc sync --help' " +
+			"to view help")))
+	}
+	setLogLevel()
+	logger.Debug("[sync] command called")
+	syncUpdates(args[0], args[1], syncCatalogPublicKeyPath, isPruneEnabled)
+}
+
+// This function synchronizes the updates in the given remote store to the given local directory. The remote store
+// should contain the catalog and the update zips listed in it in the same directory. Update zips are downloaded to the
+// locations named by the catalog, so the catalog should be signed and its signature is always verified.
+func syncUpdates(remote, localDirectory, publicKeyPath string, prune bool) {
+	if len(publicKeyPath) == 0 {
+		util.HandleErrorAndExit(util.NewInputError(errors.New("catalog key is required to verify the signature of " +
+			"the catalog. Give it with '--catalog-key <public_key.pem>'")))
+	}
+	// Remote store can be any location supported by the storage backends
+	_, _, err := util.GetStorageBackend(remote)
+	util.HandleErrorAndExit(err)
+	err = util.CreateDirectory(localDirectory)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while creating '%s'.", localDirectory))
+
+	// Download the catalog to a temporary directory. It replaces the local catalog only if all the updates are
+	// synchronized successfully.
+	tempDirectory, err := ioutil.TempDir("", "wum-uc")
+	util.HandleErrorAndExit(err, "Error occurred while creating a temporary directory.")
+	defer util.CleanUpDirectory(tempDirectory)
+	catalogPath := filepath.Join(tempDirectory, constant.CATALOG_FILE)
+	signaturePath := catalogPath + constant.CATALOG_SIGNATURE_EXTENSION
+	catalogURL := util.JoinArtifactURI(remote, constant.CATALOG_FILE)
+	logger.Debug(fmt.Sprintf("Downloading the catalog from %s", catalogURL))
+	err = util.DownloadFile(catalogPath, catalogURL)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while downloading the catalog from '%s'.",
+		catalogURL))
+	catalog, data, err := util.LoadCatalog(catalogPath)
+	util.HandleErrorAndExit(err)
+	err = util.DownloadFile(signaturePath, util.AppendToArtifactURI(catalogURL, constant.CATALOG_SIGNATURE_EXTENSION))
+	util.HandleErrorAndExit(err, "Error occurred while downloading the signature of the catalog.")
+	signature, err := ioutil.ReadFile(signaturePath)
+	util.HandleErrorAndExit(err, "Error occurred while reading the signature of the catalog.")
+	err = util.VerifySignature(data, string(signature), publicKeyPath)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Signature verification of the catalog at '%s' failed.",
+		catalogURL))
+	logger.Debug(fmt.Sprintf("Signature of %s verified", catalogURL))
+
+	// Download the updates which are not available locally or which are changed
+	catalogUpdates := make(map[string]bool)
+	downloaded, upToDate := 0, 0
+	var failures []string
+	for _, entry := range catalog.Updates {
+		updateZipName := entry.UpdateName + constant.ZIP_FILE_EXTENSION
+		catalogUpdates[updateZipName] = true
+		updateZipPath, err := util.GetPathInRoot(localDirectory, updateZipName)
+		if err != nil {
+			util.PrintError(err.Error())
+			failures = append(failures, updateZipName)
+			continue
+		}
+		// Updates released before the catalog moved to a stronger checksum algorithm are verified with the
+		// algorithm they were released with
+		algorithm, err := util.NegotiateChecksumAlgorithm(catalog, &entry)
+		if err != nil {
+			util.PrintError(err.Error())
+			failures = append(failures, updateZipName)
+			continue
+		}
+		checksum := util.GetCatalogEntryChecksum(&entry, algorithm)
+		localChecksum, err := util.GetFileChecksum(updateZipPath, algorithm)
+		if err == nil && localChecksum == checksum {
+			logger.Debug(fmt.Sprintf("%s is up to date", updateZipName))
+			upToDate++
+			continue
+		}
+		util.PrintInfo(fmt.Sprintf("Downloading '%s' ...", updateZipName))
+		err = downloadCatalogEntry(util.JoinArtifactURI(remote, updateZipName), updateZipPath, algorithm, checksum)
+		if err != nil {
+			util.PrintError(err.Error())
+			failures = append(failures, updateZipName)
+			continue
+		}
+		downloaded++
+	}
+	if len(failures) != 0 {
+		util.HandleErrorAndExit(errors.New(fmt.Sprintf("%d update(s) could not be synchronized: %v. Run the "+
+			"command again to resume.", len(failures), failures)))
+	}
+
+	// Replace the local catalog
+	err = util.CopyFile(catalogPath, filepath.Join(localDirectory, constant.CATALOG_FILE))
+	util.HandleErrorAndExit(err, "Error occurred while copying the catalog.")
+	err = util.CopyFile(signaturePath, filepath.Join(localDirectory,
+		constant.CATALOG_FILE+constant.CATALOG_SIGNATURE_EXTENSION))
+	util.HandleErrorAndExit(err, "Error occurred while copying the signature of the catalog.")
+
+	pruned := 0
+	if prune {
+		pruned = pruneUpdates(localDirectory, catalogUpdates)
+	}
+	util.PrintInfo(fmt.Sprintf("'%s' synchronized. %d update(s) downloaded, %d update(s) up to date, %d "+
+		"update(s) pruned.", localDirectory, downloaded, upToDate, pruned))
+}
+
+// This function downloads the update zip of a catalog entry and verifies its checksum with the given algorithm. An
+// update zip with a mismatching checksum is removed.
+func downloadCatalogEntry(url, updateZipPath, algorithm, expectedChecksum string) error {
+	err := util.DownloadArtifactInChunks(updateZipPath, url, viper.GetInt64(constant.DOWNLOAD_CHUNK_SIZE),
+		viper.GetInt(constant.DOWNLOAD_RETRIES))
+	if err != nil {
+		return errors.New(fmt.Sprintf("error occurred while downloading '%s': %v", url, err))
+	}
+	checksum, err := util.GetFileChecksum(updateZipPath, algorithm)
+	if err != nil {
+		return err
+	}
+	if checksum != expectedChecksum {
+		util.CleanUpFile(updateZipPath)
+		return errors.New(fmt.Sprintf("%s of '%s' is '%s', expected '%s' by the catalog", algorithm, url, checksum,
+			expectedChecksum))
+	}
+	return nil
+}
+
+// This function removes the update zips in the given directory which are not listed in the catalog, and returns the
+// number of removed update zips.
+func pruneUpdates(localDirectory string, catalogUpdates map[string]bool) int {
+	files, err := ioutil.ReadDir(localDirectory)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while reading '%s'.", localDirectory))
+	filenameRegex := regexp.MustCompile(constant.FILENAME_REGEX)
+	pruned := 0
+	for _, file := range files {
+		if file.IsDir() || !filenameRegex.MatchString(file.Name()) || catalogUpdates[file.Name()] {
+			continue
+		}
+		logger.Debug(fmt.Sprintf("Removing %s as it is not listed in the catalog", file.Name()))
+		err = os.Remove(filepath.Join(localDirectory, file.Name()))
+		util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while removing '%s'.", file.Name()))
+		pruned++
+	}
+	return pruned
+}
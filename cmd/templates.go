@@ -0,0 +1,125 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/renstrom/dedent"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/wso2/update-creator-tool/constant"
+	"github.com/wso2/update-creator-tool/util"
+)
+
+// Values used to print help command.
+var (
+	templatesCmdUse       = "templates"
+	templatesCmdShortDesc = "Manage the template store of the team"
+	templatesCmdLongDesc  = dedent.Dedent(`
+		This command will manage the template store, which keeps the creation conventions of the
+		team consistent. The store is a Git repository (TEMPLATES.REPOSITORY in config.yaml) with
+		the following files.
+		  update-descriptor3.yaml  instructions used instead of the default in the generated descriptor
+		  policies/*.rego          policies evaluated in addition to OPA.POLICIES when validating
+		  destinations.yaml        destinations of the new files which are not in the distribution`)
+	templatesSyncCmdLongDesc = dedent.Dedent(`
+		This command will pull the template store from the configured Git repository and branch
+		into the templates directory of the wum-uc home. Local changes to the store are discarded.`)
+)
+
+// templatesCmd represents the templates command.
+var templatesCmd = &cobra.Command{
+	Use:   templatesCmdUse,
+	Short: templatesCmdShortDesc,
+	Long:  templatesCmdLongDesc,
+}
+
+var templatesSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Sync the template store from its Git repository",
+	Long:  templatesSyncCmdLongDesc,
+	Run:   initializeTemplatesSyncCommand,
+}
+
+var templatesRepository string
+var templatesBranch string
+
+// This function will be called first and this will add flags to the command.
+func init() {
+	RootCmd.AddCommand(templatesCmd)
+	templatesCmd.AddCommand(templatesSyncCmd)
+
+	templatesSyncCmd.Flags().BoolVarP(&isDebugLogsEnabled, "debug", "d", util.EnableDebugLogs, "Enable debug logs")
+	templatesSyncCmd.Flags().BoolVarP(&isTraceLogsEnabled, "trace", "t", util.EnableTraceLogs, "Enable trace logs")
+	templatesSyncCmd.Flags().StringVar(&templatesRepository, "repository", "", "Git repository of the template "+
+		"store, instead of the configured repository")
+	templatesSyncCmd.Flags().StringVar(&templatesBranch, "branch", "", "Branch of the template store, instead of "+
+		"the configured branch")
+}
+
+// This function will be called when the templates sync command is called.
+func initializeTemplatesSyncCommand(cmd *cobra.Command, args []string) {
+	if len(args) != 0 {
+		util.HandleErrorAndExit(util.NewInputError(errors.New("invalid number of arguments. Run 'wum-uc templates " +
+			"sync --help' to view help")))
+	}
+	setLogLevel()
+	logger.Debug("[templates sync] command called")
+	if len(templatesRepository) == 0 {
+		templatesRepository = viper.GetString(constant.TEMPLATES_REPOSITORY)
+	}
+	if len(templatesBranch) == 0 {
+		templatesBranch = viper.GetString(constant.TEMPLATES_BRANCH)
+	}
+	if len(templatesRepository) == 0 {
+		util.HandleErrorAndExit(util.NewInputError(errors.New(fmt.Sprintf("repository of the template store is "+
+			"not configured. Set '%s' in '%s' or use '--repository'", constant.TEMPLATES_REPOSITORY,
+			constant.WUMUC_CONFIG_FILE))))
+	}
+	syncTemplateStore(templatesRepository, templatesBranch)
+}
+
+// This function syncs the template store in the wum-uc home with the given branch of the given repository and prints
+// a summary of the synced store.
+func syncTemplateStore(repository, branch string) {
+	templatesDirectory := filepath.Join(WUMUCHome, constant.WUMUC_TEMPLATES_DIRECTORY)
+	commit, err := util.SyncTemplateStore(repository, branch, templatesDirectory)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while syncing the template store from '%s'.",
+		util.RedactArtifactURI(repository)))
+	// The synced store is read so that invalid templates are reported when syncing rather than when they are used
+	templateStore, err := util.LoadTemplateStore(templatesDirectory)
+	util.HandleErrorAndExit(util.NewValidationError(err), fmt.Sprintf("Error occurred while reading the template "+
+		"store synced to '%s'.", templatesDirectory))
+	util.PrintInfo(fmt.Sprintf("Template store synced to '%s' from '%s' (%s, %s).", templatesDirectory,
+		util.RedactArtifactURI(repository), branch, commit))
+	util.PrintInfo(fmt.Sprintf("Descriptor template: %v, policies: %d, destination mappings: %d",
+		templateStore.DescriptorTemplate != nil, len(templateStore.Policies), len(templateStore.Destinations)))
+}
+
+// This function reads the template store synced to the wum-uc home.
+func loadTemplateStore() *util.TemplateStore {
+	templatesDirectory := filepath.Join(WUMUCHome, constant.WUMUC_TEMPLATES_DIRECTORY)
+	templateStore, err := util.LoadTemplateStore(templatesDirectory)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while reading the template store in '%s'. Run "+
+		"'wum-uc templates sync' to sync it again.", templatesDirectory))
+	return templateStore
+}
@@ -0,0 +1,271 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/renstrom/dedent"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/wso2/update-creator-tool/constant"
+	"github.com/wso2/update-creator-tool/util"
+)
+
+// Values used to print help command.
+var (
+	treeCmdUse       = "tree <dist_loc>"
+	treeCmdShortDesc = "Print the file tree of a distribution"
+	treeCmdLongDesc  = dedent.Dedent(`
+		This command will print the tree of the given distribution which 'wum-uc create' uses to
+		match the files of an update, with the md5 hash (or the sha256 hash with '--hash sha256')
+		of each file. The tree can be limited to a directory with '--path <path>' (relative to
+		the distribution root). The tree is printed as text, or as JSON or CSV for scripting
+		destination mappings with '--format json|csv', and it is written to a file instead of the
+		standard output with '--output <file>'. The distribution can be a zip or a directory
+		which is only read (eg: a mounted ISO).`)
+)
+
+// treeCmd represents the tree command.
+var treeCmd = &cobra.Command{
+	Use:   treeCmdUse,
+	Short: treeCmdShortDesc,
+	Long:  treeCmdLongDesc,
+	Run:   initializeTreeCommand,
+}
+
+var treePath string
+var treeFormat string
+var treeOutputPath string
+var isTreeNestedArchivesEnabled bool
+var treeWorkers int
+var treeMaxMemory int64
+var treeHashAlgorithm string
+
+// This struct holds a node of the distribution tree which is exported by the tree command.
+type TreeNode struct {
+	Name      string      `json:"name"`
+	Path      string      `json:"path"`
+	IsDir     bool        `json:"is_dir"`
+	IsArchive bool        `json:"is_archive,omitempty"`
+	MD5       string      `json:"md5,omitempty"`
+	SHA256    string      `json:"sha256,omitempty"`
+	Children  []*TreeNode `json:"children,omitempty"`
+}
+
+// This function will be called first and this will add flags to the command.
+func init() {
+	RootCmd.AddCommand(treeCmd)
+
+	treeCmd.Flags().BoolVarP(&isDebugLogsEnabled, "debug", "d", util.EnableDebugLogs, "Enable debug logs")
+	treeCmd.Flags().BoolVarP(&isTraceLogsEnabled, "trace", "t", util.EnableTraceLogs, "Enable trace logs")
+	treeCmd.Flags().StringVar(&treePath, "path", "", "Print only the tree of the given directory relative to the "+
+		"distribution root")
+	treeCmd.Flags().StringVar(&treeFormat, "format", constant.OUTPUT_FORMAT_TREE, "Format of the tree, 'tree', "+
+		"'json' or 'csv'")
+	treeCmd.Flags().StringVar(&treeOutputPath, "output", "", "Write the tree to the given file instead of the "+
+		"standard output")
+	treeCmd.Flags().BoolVar(&isRerootEnabled, "reroot", false, "Normalize the distribution if its entries are not "+
+		"inside its root folder")
+	treeCmd.Flags().BoolVar(&isTreeNestedArchivesEnabled, "nested-archives", false, "Include the content of the "+
+		"nested archives (wars, cars, etc) in the tree")
+	treeCmd.Flags().IntVar(&treeWorkers, "workers", util.DistributionWorkers, "Number of workers used to hash the "+
+		"files of the distribution, a worker per CPU if not positive")
+	treeCmd.Flags().Int64Var(&treeMaxMemory, "max-memory", int64(util.DistributionMaxMemory), "Maximum memory (in "+
+		"MB) used to buffer a nested archive, larger archives are buffered in temporary files")
+	treeCmd.Flags().StringVar(&treeHashAlgorithm, "hash", util.HashAlgorithm, "Hash algorithm of the files, 'md5' "+
+		"or 'sha256'")
+}
+
+// This function will be called when the tree command is called.
+func initializeTreeCommand(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		util.HandleErrorAndExit(util.NewInputError(errors.New("invalid number of arguments. Run 'wum-uc tree --help' " +
+			"to view help")))
+	}
+	setLogLevel()
+	logger.Debug("[tree] command called")
+	// The flags are not bound to the config as they are already bound to the flags of the create command
+	if isTreeNestedArchivesEnabled {
+		viper.Set(constant.NESTED_ARCHIVES_DESCEND, true)
+	}
+	if cmd.Flags().Changed("workers") {
+		viper.Set(constant.DISTRIBUTION_WORKERS, treeWorkers)
+	}
+	if cmd.Flags().Changed("max-memory") {
+		viper.Set(constant.DISTRIBUTION_MAX_MEMORY, treeMaxMemory)
+	}
+	if cmd.Flags().Changed("hash") {
+		viper.Set(constant.HASH_ALGORITHM, treeHashAlgorithm)
+	}
+	err := util.ValidateChecksumAlgorithms([]string{viper.GetString(constant.HASH_ALGORITHM)})
+	util.HandleErrorAndExit(util.NewInputError(err))
+	printDistributionTree(args[0], treePath, treeFormat, treeOutputPath)
+}
+
+// This function prints the tree of the given distribution or the given directory of it in the given format.
+func printDistributionTree(distributionPath, directoryPath, format, outputPath string) {
+	if format != constant.OUTPUT_FORMAT_TREE && format != constant.OUTPUT_FORMAT_JSON &&
+		format != constant.OUTPUT_FORMAT_CSV {
+		util.HandleErrorAndExit(errors.New(fmt.Sprintf("invalid format '%s', expected '%s', '%s' or '%s'", format,
+			constant.OUTPUT_FORMAT_TREE, constant.OUTPUT_FORMAT_JSON, constant.OUTPUT_FORMAT_CSV)))
+	}
+	// Distributions given as remote URIs are downloaded to a temporary directory
+	if util.IsRemoteArtifact(distributionPath) {
+		artifactsDirectory, err := ioutil.TempDir("", "wum-uc")
+		util.HandleErrorAndExit(err, "Error occurred while creating a temporary directory.")
+		defer util.CleanUpDirectory(artifactsDirectory)
+		distributionPath = downloadArtifact(distributionPath, artifactsDirectory)
+	}
+	productName := util.GetDistributionName(distributionPath)
+	viper.Set(constant.PRODUCT_NAME, productName)
+	// Distributions which are not zips (eg: mounted ISOs) are read as they are
+	if zipPath, anomaly := checkDistribution(distributionPath); anomaly != nil {
+		distributionPath = rerootToTempDirectory(constant.DISTRIBUTION, zipPath, anomaly)
+		defer util.CleanUpDirectory(filepath.Dir(distributionPath))
+	}
+
+	rootNode, err := readDistribution(distributionPath)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while reading '%s'.", distributionPath))
+	treeRoot := &rootNode
+	directoryPath = strings.Trim(filepath.ToSlash(directoryPath), "/")
+	if len(directoryPath) != 0 {
+		treeRoot = getNode(&rootNode, strings.Split(directoryPath, "/"))
+		if treeRoot == nil || !treeRoot.isDir {
+			util.HandleErrorAndExit(errors.New(fmt.Sprintf("directory '%s' is not found in '%s'", directoryPath,
+				productName)))
+		}
+	}
+	tree := getTreeNode(treeRoot)
+
+	var writer io.Writer = os.Stdout
+	if len(outputPath) != 0 {
+		outputFile, err := os.Create(outputPath)
+		util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while creating '%s'.", outputPath))
+		defer outputFile.Close()
+		writer = outputFile
+	}
+	err = writeTree(writer, tree, format)
+	util.HandleErrorAndExit(err, "Error occurred while writing the tree.")
+	if len(outputPath) != 0 {
+		util.PrintInfo(fmt.Sprintf("Tree of '%s' written to '%s'.", productName, outputPath))
+	}
+}
+
+// This function converts the given node of the distribution tree and its child nodes to tree nodes. Child nodes are
+// sorted by the name so that the same tree is exported for the same distribution.
+func getTreeNode(distributionNode *node) *TreeNode {
+	treeNode := &TreeNode{
+		Name:      distributionNode.name,
+		Path:      distributionNode.relativeLocation,
+		IsDir:     distributionNode.isDir,
+		IsArchive: distributionNode.isArchive,
+	}
+	if viper.GetString(constant.HASH_ALGORITHM) == constant.CHECKSUM_ALGORITHM_SHA256 {
+		treeNode.SHA256 = distributionNode.hash
+	} else {
+		treeNode.MD5 = distributionNode.hash
+	}
+	// Root of the distribution does not have a name
+	if len(treeNode.Path) == 0 {
+		treeNode.IsDir = true
+	}
+	var names []string
+	for name := range distributionNode.childNodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		treeNode.Children = append(treeNode.Children, getTreeNode(distributionNode.childNodes[name]))
+	}
+	return treeNode
+}
+
+// This function writes the given tree to the given writer in the given format.
+func writeTree(writer io.Writer, tree *TreeNode, format string) error {
+	switch format {
+	case constant.OUTPUT_FORMAT_JSON:
+		data, err := json.MarshalIndent(tree, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(writer, string(data))
+		return err
+	case constant.OUTPUT_FORMAT_CSV:
+		csvWriter := csv.NewWriter(writer)
+		csvWriter.Write([]string{"path", "type", viper.GetString(constant.HASH_ALGORITHM)})
+		writeTreeNodeAsCSV(csvWriter, tree)
+		csvWriter.Flush()
+		return csvWriter.Error()
+	default:
+		return writeTreeNodeAsText(writer, tree, "")
+	}
+}
+
+// This function writes the child nodes of the given tree node as CSV records in depth first order.
+func writeTreeNodeAsCSV(csvWriter *csv.Writer, treeNode *TreeNode) {
+	for _, child := range treeNode.Children {
+		nodeType := "file"
+		if child.IsDir {
+			nodeType = "directory"
+		} else if child.IsArchive {
+			nodeType = "archive"
+		}
+		csvWriter.Write([]string{child.Path, nodeType, child.getHash()})
+		writeTreeNodeAsCSV(csvWriter, child)
+	}
+}
+
+// This function writes the child nodes of the given tree node as indented text. Directories end with '/' and files
+// are followed by their hash.
+func writeTreeNodeAsText(writer io.Writer, treeNode *TreeNode, indent string) error {
+	for _, child := range treeNode.Children {
+		line := indent + child.Name
+		if child.IsDir {
+			line += "/"
+		} else {
+			line += "  " + child.getHash()
+		}
+		if _, err := fmt.Fprintln(writer, line); err != nil {
+			return err
+		}
+		if err := writeTreeNodeAsText(writer, child, indent+"  "); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// This function returns the hash of the tree node, which is either the md5 or the sha256 hash.
+func (treeNode *TreeNode) getHash() string {
+	if len(treeNode.SHA256) != 0 {
+		return treeNode.SHA256
+	}
+	return treeNode.MD5
+}
@@ -16,13 +16,17 @@ package cmd
 
 import (
 	"archive/zip"
+	"bytes"
 	"crypto/md5"
 	"errors"
 	"fmt"
+	"io/fs"
 	"io/ioutil"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/renstrom/dedent"
@@ -30,18 +34,22 @@ import (
 	"github.com/spf13/viper"
 	"github.com/wso2/update-creator-tool/constant"
 	"github.com/wso2/update-creator-tool/util"
-	"gopkg.in/yaml.v2"
+	"golang.org/x/crypto/openpgp"
 )
 
 var (
-	validateCmdUse       = "validate <update_loc> <dist_loc>"
+	validateCmdUse       = "validate <update_loc> [<dist_loc>]"
 	validateCmdShortDesc = "Validate update zip"
 	validateCmdLongDesc  = dedent.Dedent(`
-		This command will validate the given update zip. Files will be
-		matched against the given distribution. This will also validate
+		This command will validate the given update zip (or tar.zst). Files will be
+		matched against the given distribution (zip, tar.gz or directory). This will also validate
 		the structure of the update-descriptor.yaml and update-descrjptor3.yaml files as well.
 		Please set LICENSE_MD5 environment variable to the expected
-		md5 value of the LICENSE.txt file.`)
+		md5 value of the LICENSE.txt file.
+		Instead of a distribution zip, the latest updated distribution in WUM can be used by giving
+		'--product <name> --version <version> [--channel <channel>]'.
+		A platform-wide update can be validated against the distributions of all its
+		products by giving '--dist-list <products.yaml>'.`)
 )
 
 // ValidateCmd represents the validate command
@@ -52,30 +60,163 @@ var validateCmd = &cobra.Command{
 	Run:   initializeValidateCommand,
 }
 
+var catalogPath string
+var catalogPublicKeyPath string
+var decryptionKeyPath string
+var baselineProductName string
+var baselineProductVersion string
+var baselineChannel string
+var productDistributions []string
+var isRerootEnabled bool
+var isProductChecksumsVerified bool
+var validateHashAlgorithm string
+var validateOutputFormat string
+var validateOutputPath string
+var validateReports []string
+
 // This function will be called first and this will add flags to the command.
 func init() {
 	RootCmd.AddCommand(validateCmd)
 
 	validateCmd.Flags().BoolVarP(&isDebugLogsEnabled, "debug", "d", util.EnableDebugLogs, "Enable debug logs")
 	validateCmd.Flags().BoolVarP(&isTraceLogsEnabled, "trace", "t", util.EnableTraceLogs, "Enable trace logs")
+	validateCmd.Flags().StringVar(&catalogPath, "against-catalog", "", "Check the update against the given "+
+		"catalog for duplicate and conflicting updates")
+	validateCmd.Flags().StringVar(&catalogPublicKeyPath, "catalog-key", "", "RSA public key (PEM) used to "+
+		"verify the signature of the catalog")
+	validateCmd.Flags().StringVar(&decryptionKeyPath, "decryption-key", "", "OpenPGP secret key used to "+
+		"decrypt an encrypted update")
+	validateCmd.Flags().StringVar(&baselineProductName, "product", "", "Validate against the latest updated "+
+		"distribution of the given product in WUM instead of a distribution zip")
+	validateCmd.Flags().StringVar(&baselineProductVersion, "version", "", "Version of the product given with "+
+		"'--product'")
+	validateCmd.Flags().StringVar(&baselineChannel, "channel", constant.DEFAULT_CHANNEL, "WUM channel of the "+
+		"product given with '--product'")
+	validateCmd.Flags().StringSliceVar(&productDistributions, "product-distribution", []string{}, "Distribution "+
+		"of a product which declares a payload root, as <product>-<version>=<dist_loc>")
+	validateCmd.Flags().BoolVar(&isRerootEnabled, "reroot", false, "Normalize the update and the distribution if "+
+		"their entries are not inside the expected root folder")
+	validateCmd.Flags().BoolVar(&isProductChecksumsVerified, "product-checksums", false, "Verify the payload "+
+		"files against the checksum manifests shipped by the product (eg: a.jar.md5 next to a.jar)")
+	validateCmd.Flags().StringVar(&distributionListPath, "dist-list", "", "Validate the platform-wide update "+
+		"against the distributions of all the products listed in the given file")
+	validateCmd.Flags().StringVar(&validateHashAlgorithm, "hash", util.HashAlgorithm, "Hash algorithm used to "+
+		"compare the files of the update with the files of the distribution, 'md5' or 'sha256'")
+	validateCmd.Flags().StringVar(&validateOutputFormat, "output", constant.OUTPUT_FORMAT_TEXT, "Format of the "+
+		"validation result, 'text' or 'json' (a report written to the stdout)")
+	validateCmd.Flags().StringVar(&validateOutputPath, "output-file", "", "Write the JSON report to the given file "+
+		"instead of the stdout")
+	validateCmd.Flags().StringSliceVar(&validateReports, "report", []string{}, "Write the validation report to a "+
+		"file as <format>=<file>, where the format is 'json' or 'junit'")
 }
 
 // This function will be called when the validate command is called.
 func initializeValidateCommand(cmd *cobra.Command, args []string) {
-	if len(args) != 2 {
-		util.HandleErrorAndExit(errors.New("invalid number of arguments. Run 'wum-uc validate --help' to " +
-			"view help"))
+	// The flag is not bound to the config as it is already bound to the flag of the create command
+	if cmd.Flags().Changed("hash") {
+		viper.Set(constant.HASH_ALGORITHM, validateHashAlgorithm)
+	}
+	err := util.ValidateChecksumAlgorithms([]string{viper.GetString(constant.HASH_ALGORITHM)})
+	util.HandleErrorAndExit(util.NewInputError(err))
+	distributionLocation := ""
+	if len(distributionListPath) != 0 {
+		if len(args) != 1 || len(baselineProductName) != 0 {
+			util.HandleErrorAndExit(util.NewInputError(errors.New("'--dist-list' requires the update location " +
+				"only and cannot be used with '--product'. Run 'wum-uc validate --help' to view help")))
+		}
+		var err error
+		listedDistributions, err = util.LoadDistributionList(distributionListPath)
+		util.HandleErrorAndExit(util.NewInputError(err), fmt.Sprintf("Error occurred while reading '%s'.",
+			distributionListPath))
+		// The update is compared with the first distribution as usual and then with the other distributions
+		distributionLocation = listedDistributions[0].Distribution
+	} else if len(baselineProductName) != 0 {
+		if len(args) != 1 || len(baselineProductVersion) == 0 {
+			util.HandleErrorAndExit(util.NewInputError(errors.New("'--product' requires '--version' and the update " +
+				"location only. Run 'wum-uc validate --help' to view help")))
+		}
+		if isProductChecksumsVerified {
+			util.HandleErrorAndExit(util.NewInputError(errors.New("'--product-checksums' requires a distribution " +
+				"instead of '--product'. Run 'wum-uc validate --help' to view help")))
+		}
+	} else if len(args) != 2 {
+		util.HandleErrorAndExit(util.NewInputError(errors.New("invalid number of arguments. Run 'wum-uc validate " +
+			"--help' to view help")))
+	} else {
+		distributionLocation = args[1]
+	}
+	setLogLevel()
+	updateFilePath := args[0]
+	// Updates and distributions given as remote URIs are downloaded to a temporary directory
+	if util.IsRemoteArtifact(updateFilePath) || util.IsRemoteArtifact(distributionLocation) ||
+		hasRemoteListedDistribution() {
+		artifactsDirectory, err := ioutil.TempDir("", "wum-uc")
+		util.HandleErrorAndExit(err, "Error occurred while creating a temporary directory.")
+		defer util.CleanUpDirectory(artifactsDirectory)
+		updateFilePath = downloadArtifact(updateFilePath, artifactsDirectory)
+		distributionLocation = downloadArtifact(distributionLocation, artifactsDirectory)
+		for index := 1; index < len(listedDistributions); index++ {
+			listedDistributions[index].Distribution = downloadArtifact(listedDistributions[index].Distribution,
+				artifactsDirectory)
+		}
+	}
+	// Encrypted updates are decrypted to a temporary directory before validating
+	if util.IsEncryptedFile(updateFilePath) {
+		updateFilePath = decryptUpdate(updateFilePath, decryptionKeyPath)
+		defer util.CleanUpDirectory(filepath.Dir(updateFilePath))
+	}
+	// tar.zst updates are validated using a zip with the same entries
+	if util.IsTarZstFile(updateFilePath) {
+		updateFilePath = convertToUpdateZip(updateFilePath)
+		defer util.CleanUpDirectory(filepath.Dir(updateFilePath))
+	}
+	// The catalog given in the config is used if the catalog is not given
+	if len(catalogPath) == 0 {
+		catalogPath = viper.GetString(constant.UPDATE_CATALOG_LOCATION)
+		catalogPublicKeyPath = viper.GetString(constant.UPDATE_CATALOG_KEY)
+	}
+	if len(catalogPath) != 0 {
+		checkAgainstCatalog(updateFilePath, catalogPath, catalogPublicKeyPath)
+	}
+	startValidation(updateFilePath, distributionLocation, true)
+}
+
+// This function starts collecting the report of the validate command if '--output json' or '--report' is given. It is
+// called before the prerequisites and the configs are checked, so that their messages are not printed to the stdout
+// which has the report.
+func initValidationReport() {
+	for _, report := range validateReports {
+		parts := strings.SplitN(report, "=", 2)
+		if len(parts) != 2 || !util.IsSupportedValidationReportFormat(parts[0]) || len(parts[1]) == 0 {
+			util.HandleErrorAndExit(util.NewInputError(errors.New(fmt.Sprintf("invalid report '%s', expected "+
+				"<format>=<file> where the format is '%s' or '%s'", report, constant.OUTPUT_FORMAT_JSON,
+				constant.OUTPUT_FORMAT_JUNIT))))
+		}
+		util.StartValidationReport(parts[0], parts[1])
+	}
+	switch validateOutputFormat {
+	case constant.OUTPUT_FORMAT_JSON:
+		util.StartValidationReport(constant.OUTPUT_FORMAT_JSON, validateOutputPath)
+	case constant.OUTPUT_FORMAT_TEXT:
+		if len(validateOutputPath) != 0 {
+			util.HandleErrorAndExit(util.NewInputError(errors.New("'--output-file' requires '--output json'. Run " +
+				"'wum-uc validate --help' to view help")))
+		}
+	default:
+		util.HandleErrorAndExit(util.NewInputError(errors.New(fmt.Sprintf("unsupported output format '%s', "+
+			"expected '%s' or '%s'", validateOutputFormat, constant.OUTPUT_FORMAT_TEXT,
+			constant.OUTPUT_FORMAT_JSON))))
 	}
-	startValidation(args[0], args[1])
 }
 
-// This function will start the validation process.
-func startValidation(updateFilePath, distributionLocation string) {
+// This function will start the validation process. Rego policies are evaluated over the update manifest only if
+// evaluatePolicies is true.
+func startValidation(updateFilePath, distributionLocation string, evaluatePolicies bool) {
 
 	// Sets the log level
 	setLogLevel()
 	logger.Debug("validate command called")
-	fmt.Println("Validating update ...")
+	util.PrintMessage("Validating update ...")
 
 	updateFileMap := make(map[string]bool)
 	distributionFileMap := make(map[string]bool)
@@ -87,107 +228,680 @@ func startValidation(updateFilePath, distributionLocation string) {
 	exists, err := util.IsFileExists(updateFilePath)
 	util.HandleErrorAndExit(err, "")
 	if !exists {
-		util.HandleErrorAndExit(errors.New(fmt.Sprintf("Entered update file does not exist at '%s'.",
-			updateFilePath)))
+		util.HandleErrorAndExit(util.NewInputError(errors.New(fmt.Sprintf("Entered update file does not exist at '%s'.",
+			updateFilePath))))
 	}
 
-	// Checks whether the given distribution is a zip file
-	util.IsZipFile(constant.DISTRIBUTION, distributionLocation)
-
-	// Sets the product name in viper configs
-	lastIndex := strings.LastIndex(distributionLocation, constant.PATH_SEPARATOR)
-	productName := strings.TrimSuffix(distributionLocation[lastIndex+1:], ".zip")
-	logger.Debug(fmt.Sprintf("Setting ProductName: %s", productName))
-	viper.Set(constant.PRODUCT_NAME, productName)
+	if len(distributionLocation) != 0 {
+		// Sets the product name in viper configs
+		productName := util.GetDistributionName(distributionLocation)
+		logger.Debug(fmt.Sprintf("Setting ProductName: %s", productName))
+		viper.Set(constant.PRODUCT_NAME, productName)
 
-	// Checks whether the distribution file exists
-	exists, err = util.IsFileExists(distributionLocation)
-	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while checking '%s'", distributionLocation))
-	if !exists {
-		util.HandleErrorAndExit(errors.New(fmt.Sprintf("Entered distribution file does not exist at '%s'.",
-			distributionLocation)))
+		// Checks whether the distribution can be read and whether the entries of a distribution zip are inside its
+		// root folder
+		if zipPath, anomaly := checkDistribution(distributionLocation); anomaly != nil {
+			distributionLocation = rerootToTempDirectory(constant.DISTRIBUTION, zipPath, anomaly)
+			defer util.CleanUpDirectory(filepath.Dir(distributionLocation))
+		}
+	} else {
+		// Validating against the latest updated distribution in WUM
+		productName := baselineProductName + "-" + baselineProductVersion
+		logger.Debug(fmt.Sprintf("Setting ProductName: %s", productName))
+		viper.Set(constant.PRODUCT_NAME, productName)
 	}
 
 	// Checks update filename
 	locationInfo, err := os.Stat(updateFilePath)
 	util.HandleErrorAndExit(err, "Error occurred while getting the information of update file")
-	match, err := regexp.MatchString(constant.FILENAME_REGEX, locationInfo.Name())
-	if !match {
-		util.HandleErrorAndExit(errors.New(fmt.Sprintf("Update filename '%s' does not match '%s' regular "+
-			"expression.", locationInfo.Name(), constant.FILENAME_REGEX)))
+	result := regexp.MustCompile(constant.FILENAME_REGEX).FindStringSubmatch(locationInfo.Name())
+	if len(result) == 0 {
+		util.HandleErrorAndExit(util.NewValidationError(errors.New(fmt.Sprintf("Update filename '%s' does not "+
+			"match '%s' regular expression.", locationInfo.Name(), constant.FILENAME_REGEX))))
 	}
+	// Checks the update number in the update filename
+	err = util.ValidateUpdateNumberFormat(result[1])
+	util.HandleErrorAndExit(util.NewValidationError(err), fmt.Sprintf("Update filename '%s' is invalid.",
+		locationInfo.Name()))
 
 	// Sets the update name in viper configs
 	updateName := strings.TrimSuffix(locationInfo.Name(), ".zip")
 	viper.Set(constant.UPDATE_NAME, updateName)
+	util.SetValidationReportUpdate(updateName, viper.GetString(constant.PRODUCT_NAME))
+
+	// Checks whether the entries of the update are inside the root folder named after the update
+	if anomaly := checkRootFolder(constant.UPDATE, updateFilePath, updateName, false); anomaly != nil {
+		updateFilePath = rerootToTempDirectory(constant.UPDATE, updateFilePath, anomaly)
+		defer util.CleanUpDirectory(filepath.Dir(updateFilePath))
+	}
 
 	// Reads the update zip file
-	updateFileMap, updateDescriptorV3, err := readUpdateZip(updateFilePath)
-	util.HandleErrorAndExit(err)
+	util.PublishStageStarted(constant.STAGE_READ_UPDATE)
+	updateFileMap, payloadRootFileMaps, updateDescriptorV3, err := readUpdateZip(updateFilePath)
+	util.HandleErrorAndExit(util.NewValidationError(err))
 	logger.Trace(fmt.Sprintf("updateFileMap: %v\n", updateFileMap))
+	util.AddValidationReportFiles(getSortedFilePaths(updateFileMap))
+	util.SetValidationReportDescriptorFiles(util.GetAddedFiles(updateDescriptorV3),
+		util.GetModifiedFiles(updateDescriptorV3), util.GetRemovedFiles(updateDescriptorV3))
+	util.PublishStageFinished(constant.STAGE_READ_UPDATE)
 
-	// Reads the distribution zip file
-	distributionFileMap, err = readDistributionZip(distributionLocation)
+	// Reads the distribution or the latest updated distribution in WUM
+	util.PublishStageStarted(constant.STAGE_READ_DISTRIBUTION)
+	var ignoredPaths []string
+	var baselineChecksums map[string]string
+	if len(distributionLocation) != 0 {
+		distributionFileMap, ignoredPaths, err = readDistributionFiles(distributionLocation)
+	} else {
+		distributionFileMap, baselineChecksums, err = readDistributionBaseline(baselineProductName,
+			baselineProductVersion, baselineChannel, viper.GetString(constant.HASH_ALGORITHM))
+	}
 	util.HandleErrorAndExit(err)
 	logger.Trace(fmt.Sprintf("distributionFileMap: %v\n", distributionFileMap))
+	// Checksums of the modified files are read from the distribution as WUM provides them for the baseline
+	if len(distributionLocation) != 0 && updateDescriptorV3.UpdateNumber != "" {
+		baselineChecksums, err = readDistributionChecksums(distributionLocation,
+			util.GetModifiedFiles(updateDescriptorV3), viper.GetString(constant.HASH_ALGORITHM))
+		util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while reading '%s'.", distributionLocation))
+	}
+	util.PublishStageFinished(constant.STAGE_READ_DISTRIBUTION)
 
+	util.PublishStageStarted(constant.STAGE_VALIDATE_UPDATE)
+	defer util.PublishStageFinished(constant.STAGE_VALIDATE_UPDATE)
 	// Compares the update with the provided distribution only if update-descriptor3.yaml exists
 	if updateDescriptorV3.UpdateNumber != "" {
-		err = compare(updateFileMap, distributionFileMap, updateDescriptorV3)
+		err = compare(updateFileMap, distributionFileMap, ignoredPaths, updateDescriptorV3)
+		util.HandleErrorAndExit(util.NewValidationError(err))
+		// Compares the payload roots of the products with their own distributions
+		validateProductPayloads(payloadRootFileMaps, updateDescriptorV3)
+		// Compares the update with the distributions of the other products of a platform-wide update
+		if len(listedDistributions) > 1 {
+			validateListedDistributions(updateFileMap, updateDescriptorV3)
+		}
+		// Compares each OS variant with the distribution
+		err = checkOSVariants(updateFileMap, distributionFileMap, updateDescriptorV3)
+		util.HandleErrorAndExit(util.NewValidationError(err))
+		// Checks whether the executable files are executable in the update
+		fileModes, err := getUpdateFileModes(updateFilePath, updateName,
+			util.GetPayloadDirectory(updateDescriptorV3))
+		util.HandleErrorAndExit(err)
+		err = checkExecutableFiles(fileModes, updateDescriptorV3)
+		util.HandleErrorAndExit(util.NewValidationError(err))
+		// Checks whether the payload files match the checksum manifests of the product after applying the update
+		if isProductChecksumsVerified && len(distributionLocation) != 0 {
+			err = checkProductChecksums(updateFilePath, distributionLocation)
+			util.HandleErrorAndExit(util.NewValidationError(err))
+		}
+		// Checks whether an update which only removes files has no payload and removes files of the distribution
+		if util.IsRemoveOnlyUpdate(updateDescriptorV3) {
+			err = checkRemoveOnlyUpdate(updateFileMap, payloadRootFileMaps, distributionFileMap, updateDescriptorV3)
+			util.HandleErrorAndExit(util.NewValidationError(err))
+		}
+	}
+	// Checks whether the updated files are already available in the distribution
+	if baselineChecksums != nil {
+		err = checkUnchangedFiles(updateFilePath, updateName, util.GetPayloadDirectory(updateDescriptorV3),
+			baselineChecksums, util.GetModifiedFiles(updateDescriptorV3), viper.GetString(constant.HASH_ALGORITHM))
+		util.HandleErrorAndExit(util.NewValidationError(err))
+	}
+	// Checks whether the payload ships secrets which are not waived in the update-descriptor3.yaml
+	if viper.GetBool(constant.SECRET_SCAN_ENABLED) {
+		err = checkSecrets(updateFilePath, updateName, updateDescriptorV3)
+		util.HandleErrorAndExit(util.NewValidationError(err))
+	}
+
+	// Runs the external validators against the update manifest
+	fileSizes, err := getUpdateFileSizes(updateFilePath, updateName, util.GetPayloadDirectory(updateDescriptorV3))
+	util.HandleErrorAndExit(err)
+	updateManifest := getUpdateManifest(updateName, result[1], updateFileMap, fileSizes, updateDescriptorV3)
+
+	// Checks the size and the scope of the update
+	checkGuardrails(updateManifest, updateFileMap, distributionFileMap)
+	checkUpdateSizeBudget(locationInfo.Name(), uint64(locationInfo.Size()), updateDescriptorV3)
+
+	failures := util.RunExternalValidators(viper.GetStringSlice(constant.VALIDATORS), updateManifest)
+	if len(failures) != 0 {
+		for _, failure := range failures {
+			util.PrintError(failure.Error())
+		}
+		util.HandleErrorAndExit(util.NewValidationError(errors.New(fmt.Sprintf("'%s' failed %d external "+
+			"validation(s).", updateName, len(failures)))))
+	}
+
+	// Evaluates the rego policies over the update manifest
+	if evaluatePolicies {
+		// Policies of the template store of the team are evaluated in addition to the configured policies
+		policies := append(viper.GetStringSlice(constant.OPA_POLICIES), loadTemplateStore().Policies...)
+		denials, err := util.EvaluatePolicies(viper.GetString(constant.OPA_EXECUTABLE), policies,
+			viper.GetString(constant.OPA_QUERY), updateManifest)
 		util.HandleErrorAndExit(err)
+		if len(denials) != 0 {
+			for _, denial := range denials {
+				util.PrintError(fmt.Sprintf("policy denied: %s", denial))
+			}
+			util.HandleErrorAndExit(util.NewValidationError(errors.New(fmt.Sprintf("'%s' was denied by %d policy "+
+				"rule(s).", updateName, len(denials)))))
+		}
 	}
-	fmt.Println("'" + updateName + "' validation successfully finished.")
+	// Reports the differences from the previous validation if the update is a respin
+	checkRespin(updateFilePath, updateName)
+	util.PrintMessage("'" + updateName + "' validation successfully finished.")
+	util.WriteValidationReport(constant.EXIT_CODE_SUCCESS)
 }
 
-// This function compares the files in the update and the provided distribution.
-func compare(updateFileMap, distributionFileMap map[string]bool, updateDescriptorV3 *util.UpdateDescriptorV3) error {
-	updateName := viper.GetString(constant.UPDATE_NAME)
+// This function compares the given update with the previous validation of the same update recorded in the validation
+// ledger and reports the differences if it is a respin (same update number, new content). The update is then
+// recorded in the validation ledger.
+func checkRespin(updateFilePath, updateName string) {
+	validatedUpdate, err := util.NewValidatedUpdate(updateFilePath, updateName)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while reading '%s'.", updateFilePath))
+	previousUpdate, err := util.LoadValidatedUpdate(WUMUCHome, updateName)
+	if err != nil {
+		// The respin report only helps the reviewers, so the validation does not fail if the ledger is unreadable
+		logger.Error(fmt.Sprintf("%v error occurred while reading the previous validation of %s", err, updateName))
+	} else if previousUpdate != nil && previousUpdate.SHA256 != validatedUpdate.SHA256 {
+		util.PrintInBold(fmt.Sprintf("'%s' is a respin of the update validated at %s (sha256: %s).\n", updateName,
+			previousUpdate.ValidatedAt, previousUpdate.SHA256))
+		diff := util.GetRespinDiff(previousUpdate, validatedUpdate)
+		if diff.IsEmpty() {
+			util.PrintInfo("Files of the respin are identical to the previous validation.")
+		} else {
+			var report bytes.Buffer
+			util.WriteRespinDiff(&report, diff)
+			util.PrintMessage(strings.TrimSuffix(report.String(), "\n"))
+			util.PrintInfo(fmt.Sprintf("%d file(s) added, %d file(s) removed and %d file(s) changed since the "+
+				"previous validation.", len(diff.AddedFiles), len(diff.RemovedFiles), len(diff.ChangedFiles)))
+		}
+	}
+	err = util.SaveValidatedUpdate(WUMUCHome, validatedUpdate)
+	if err != nil {
+		logger.Error(fmt.Sprintf("%v error occurred while recording the validation of %s in %s", err, updateName,
+			constant.WUMUC_VALIDATION_LEDGER_DIRECTORY))
+	}
+}
+
+// This function converts the given tar.zst update to a zip with the same name in a temporary directory and returns
+// the path of the zip.
+func convertToUpdateZip(updateFilePath string) string {
+	exists, err := util.IsFileExists(updateFilePath)
+	util.HandleErrorAndExit(err, "")
+	if !exists {
+		util.HandleErrorAndExit(util.NewInputError(errors.New(fmt.Sprintf("Entered update file does not exist at '%s'.",
+			updateFilePath))))
+	}
+	tempDirectory, err := ioutil.TempDir("", "wum-uc")
+	util.HandleErrorAndExit(err, "Error occurred while creating a temporary directory")
+	// Converted update may be an embargoed update decrypted earlier
+	util.RegisterTemporaryDirectory(tempDirectory)
+	updateZipPath := filepath.Join(tempDirectory, strings.TrimSuffix(filepath.Base(updateFilePath),
+		constant.TAR_ZST_FILE_EXTENSION)+constant.ZIP_FILE_EXTENSION)
+	logger.Debug(fmt.Sprintf("Converting %s to %s", updateFilePath, updateZipPath))
+	err = util.ConvertTarZstToZip(updateFilePath, updateZipPath)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while reading '%s'", updateFilePath))
+	return updateZipPath
+}
+
+// This function checks whether the entries of the given zip are inside a single root folder with the expected name
+// and returns the anomaly if they are not. If a root folder with a different name is allowed, such zips are accepted
+// as the paths relative to the root folder are still correct.
+func checkRootFolder(archiveType, zipPath, expectedRootFolder string,
+	isNameMismatchAllowed bool) *util.RootFolderAnomaly {
+	zipReader, err := zip.OpenReader(zipPath)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while reading '%s'.", zipPath))
+	defer zipReader.Close()
+	anomaly := util.CheckRootFolder(&zipReader.Reader, expectedRootFolder)
+	if anomaly == nil {
+		return nil
+	}
+	if isNameMismatchAllowed && anomaly.IsNameMismatch() {
+		util.PrintInfo(fmt.Sprintf("%s '%s': %s.", archiveType, zipPath, anomaly.Error()))
+		return nil
+	}
+	return anomaly
+}
+
+// This function checks whether the distribution at the given location can be read by one of the distribution sources.
+// If the distribution is provided by a zip, the anomaly is returned along with the zip if its entries are not inside
+// its root folder. The zip is the one converted from the distribution if it is a tarball, so that the zip is re-rooted
+// instead of the tarball. Distributions which are not zips (eg: directories) are read as they are.
+func checkDistribution(distributionLocation string) (string, *util.RootFolderAnomaly) {
+	err := util.CheckDistribution(distributionLocation)
+	util.HandleErrorAndExit(util.NewInputError(err), fmt.Sprintf("Error occurred while reading the %s '%s'.",
+		strings.ToLower(constant.DISTRIBUTION), distributionLocation))
+	zipPath, isZip, err := util.GetDistributionZip(distributionLocation)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while reading '%s'.", distributionLocation))
+	if !isZip {
+		return "", nil
+	}
+	return zipPath, checkRootFolder(constant.DISTRIBUTION, zipPath, util.GetDistributionName(distributionLocation),
+		true)
+}
+
+// This function re-roots the given zip to a temporary directory if '--reroot' is given and returns the path of the
+// re-rooted zip. Otherwise the validation fails with the given root folder anomaly.
+func rerootToTempDirectory(archiveType, zipPath string, anomaly *util.RootFolderAnomaly) string {
+	if !isRerootEnabled {
+		util.HandleErrorAndExit(util.NewValidationError(errors.New(fmt.Sprintf("%s '%s' is invalid, %s. Use "+
+			"'--reroot' to normalize it.", archiveType, zipPath, anomaly.Error()))))
+	}
+	tempDirectory, err := ioutil.TempDir("", "wum-uc")
+	util.HandleErrorAndExit(err, "Error occurred while creating a temporary directory")
+	rerootedZipPath := filepath.Join(tempDirectory, filepath.Base(zipPath))
+	logger.Debug(fmt.Sprintf("Re-rooting %s to %s", zipPath, rerootedZipPath))
+	err = util.RerootZip(zipPath, rerootedZipPath, anomaly)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while re-rooting '%s'", zipPath))
+	util.PrintInfo(fmt.Sprintf("%s '%s' re-rooted under '%s'.", archiveType, zipPath, anomaly.ExpectedRootFolder))
+	return rerootedZipPath
+}
+
+// This function decrypts the given encrypted update to a temporary directory using the given OpenPGP secret key and
+// returns the path of the decrypted update.
+func decryptUpdate(updateFilePath, secretKeyPath string) string {
+	return decryptToTemporaryDirectory(updateFilePath, secretKeyPath, nil)
+}
+
+// This function decrypts the given encrypted file to a temporary directory using the given OpenPGP secret key and
+// returns the path of the decrypted file. The file should be signed with one of the given trusted keys if any are
+// given.
+func decryptToTemporaryDirectory(filePath, secretKeyPath string, trustedKeys openpgp.EntityList) string {
+	if len(secretKeyPath) == 0 {
+		keyIds, err := util.ReadEncryptionKeyIds(filePath)
+		util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while reading '%s'", filePath))
+		util.HandleErrorAndExit(util.NewInputError(errors.New(fmt.Sprintf("'%s' is encrypted for the key(s) %s. "+
+			"Use '--decryption-key' to give the secret key of a recipient.", filePath,
+			strings.Join(keyIds, ", ")))))
+	}
+	keyring, err := util.ReadGPGKeyring(secretKeyPath)
+	util.HandleErrorAndExit(util.NewInputError(err), "Error occurred while reading the decryption key.")
+	tempDirectory, err := ioutil.TempDir("", "wum-uc")
+	util.HandleErrorAndExit(err, "Error occurred while creating a temporary directory")
+	// Decrypted update should not be left behind even if the command exits on an error
+	util.RegisterTemporaryDirectory(tempDirectory)
+	decryptedFilePath := filepath.Join(tempDirectory, strings.TrimSuffix(filepath.Base(filePath),
+		constant.ENCRYPTED_FILE_EXTENSION))
+	logger.Debug(fmt.Sprintf("Decrypting %s to %s", filePath, decryptedFilePath))
+	if len(trustedKeys) == 0 {
+		err = util.DecryptFile(filePath, decryptedFilePath, keyring, getGPGPassphrase)
+		util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while decrypting '%s'", filePath))
+		return decryptedFilePath
+	}
+	signer, err := util.DecryptSignedFile(filePath, decryptedFilePath, keyring, trustedKeys, getGPGPassphrase)
+	util.HandleErrorAndExit(util.NewValidationError(err), fmt.Sprintf("Error occurred while decrypting '%s'",
+		filePath))
+	logger.Debug(fmt.Sprintf("%s is signed with the key %s", filePath, util.GetGPGKeyDescription(signer)))
+	return decryptedFilePath
+}
+
+// This function checks the size and the scope of the update against the configured guardrails. Exceeded thresholds
+// are printed as warnings or fail the validation depending on the configured action.
+func checkGuardrails(updateManifest *util.UpdateManifest, updateFileMap, distributionFileMap map[string]bool) {
+	modifiedFiles := 0
 	for filePath := range updateFileMap {
-		logger.Debug(fmt.Sprintf("Searching: %s", filePath))
-		_, found := distributionFileMap[filePath]
+		if _, found := distributionFileMap[filePath]; found {
+			modifiedFiles++
+		}
+	}
+	violations := util.CheckGuardrails(updateManifest, modifiedFiles, len(distributionFileMap), &util.Guardrails{
+		MaxChangedFiles:         viper.GetInt(constant.GUARDRAILS_MAX_CHANGED_FILES),
+		MaxPayloadSize:          uint64(viper.GetInt64(constant.GUARDRAILS_MAX_PAYLOAD_SIZE)),
+		MaxDistributionFraction: viper.GetFloat64(constant.GUARDRAILS_MAX_DISTRIBUTION_FRACTION),
+	})
+	if len(violations) == 0 {
+		return
+	}
+	if viper.GetString(constant.GUARDRAILS_ACTION) == constant.GUARDRAILS_ACTION_FAIL {
+		for _, violation := range violations {
+			util.PrintError(violation)
+		}
+		util.HandleErrorAndExit(util.NewValidationError(errors.New(fmt.Sprintf("'%s' exceeded %d guardrail(s). "+
+			"Please check whether the correct distribution is used.", updateManifest.UpdateName, len(violations)))))
+	}
+	for _, violation := range violations {
+		util.PrintPolicyNotice(fmt.Sprintf("%s. Please check whether the correct distribution is used.", violation))
+	}
+}
+
+// This function reads the size budgets of the update zips from the configs.
+func getSizeBudgets() *util.SizeBudgets {
+	sizeBudgets := util.SizeBudgets{
+		MaxUpdateSize: uint64(viper.GetInt64(constant.SIZE_BUDGETS_MAX_UPDATE_SIZE)),
+		Platforms:     make(map[string]uint64),
+		Products:      make(map[string]uint64),
+	}
+	for platformName := range viper.GetStringMap(constant.SIZE_BUDGETS_PLATFORMS) {
+		key := constant.SIZE_BUDGETS_PLATFORMS + "." + platformName
+		sizeBudgets.Platforms[strings.ToLower(platformName)] = uint64(viper.GetInt64(key))
+	}
+	for productName := range viper.GetStringMap(constant.SIZE_BUDGETS_PRODUCTS) {
+		key := constant.SIZE_BUDGETS_PRODUCTS + "." + productName
+		sizeBudgets.Products[strings.ToLower(productName)] = uint64(viper.GetInt64(key))
+	}
+	return &sizeBudgets
+}
+
+// This function checks the given size of the update zip against the smallest size budget of the platform and the
+// products of the update. Oversized updates are reported with a warning, which fails the validation in the strict
+// mode.
+func checkUpdateSizeBudget(updateZipName string, updateZipSize uint64, updateDescriptorV3 *util.UpdateDescriptorV3) {
+	budget, budgetOf := getSizeBudgets().GetSizeBudget(updateDescriptorV3.PlatformName,
+		util.GetProductNames(updateDescriptorV3))
+	if budget == 0 || updateZipSize <= budget {
+		logger.Debug(fmt.Sprintf("Size of %s: %d bytes, size budget: %d bytes", updateZipName, updateZipSize,
+			budget))
+		return
+	}
+	util.PrintPolicyNotice(fmt.Sprintf("'%s' is %d bytes which exceeds the size budget of %d bytes of %s.",
+		updateZipName, updateZipSize, budget, budgetOf))
+}
+
+// This function generates the update manifest which is passed to the external validators.
+func getUpdateManifest(updateName, updateNumber string, updateFileMap map[string]bool, fileSizes map[string]uint64,
+	updateDescriptorV3 *util.UpdateDescriptorV3) *util.UpdateManifest {
+	files := make([]string, 0)
+	for filePath := range updateFileMap {
+		files = append(files, filePath)
+	}
+	sort.Strings(files)
+	updateManifest := util.UpdateManifest{
+		UpdateName:                  updateName,
+		UpdateNumber:                updateNumber,
+		PlatformVersion:             updateDescriptorV3.PlatformVersion,
+		PlatformName:                updateDescriptorV3.PlatformName,
+		Files:                       files,
+		FileSizes:                   fileSizes,
+		Description:                 updateDescriptorV3.Description,
+		Instructions:                updateDescriptorV3.Instructions,
+		BugFixes:                    updateDescriptorV3.BugFixes,
+		CompatibleProducts:          updateDescriptorV3.CompatibleProducts,
+		PartiallyApplicableProducts: updateDescriptorV3.PartiallyApplicableProducts,
+	}
+	return &updateManifest
+}
+
+// This function returns the uncompressed sizes of the files in the update zip against their paths relative to the
+// given payload directory (carbon.home).
+func getUpdateFileSizes(updateFilePath, updateName, payloadDirectory string) (map[string]uint64, error) {
+	fileSizes := make(map[string]uint64)
+	zipReader, err := zip.OpenReader(updateFilePath)
+	if err != nil {
+		return nil, err
+	}
+	defer zipReader.Close()
+	prefix := path.Join(updateName, payloadDirectory) + "/"
+	for _, file := range zipReader.Reader.File {
+		if file.FileInfo().IsDir() || !strings.HasPrefix(file.Name, prefix) {
+			continue
+		}
+		fileSizes[strings.TrimPrefix(file.Name, prefix)] = file.UncompressedSize64
+	}
+	return fileSizes, nil
+}
+
+// This function checks whether the payload files of the given update match the checksum manifests shipped by the
+// product in the given distribution (or updated by the update), as they would be after applying the update. A
+// mismatch means that the file is placed next to the manifest of another file (eg: in a wrong sibling directory) or
+// the update does not update the manifest.
+func checkProductChecksums(updateFilePath, distributionLocation string) error {
+	distributionFS, closer, err := util.OpenDistribution(distributionLocation)
+	if err != nil {
+		return errors.New(fmt.Sprintf("Error occurred while reading '%s': %v.", distributionLocation, err))
+	}
+	distributionChecksums, err := util.ReadDistributionChecksumManifests(distributionFS)
+	closer.Close()
+	if err != nil {
+		return errors.New(fmt.Sprintf("Error occurred while reading the checksum manifests of '%s': %v.",
+			distributionLocation, err))
+	}
+	logger.Debug(fmt.Sprintf("%d checksum manifests found in %s", len(distributionChecksums),
+		distributionLocation))
+	mismatches, err := util.VerifyProductChecksums(updateFilePath, distributionChecksums)
+	if err != nil {
+		return errors.New(fmt.Sprintf("Error occurred while verifying the checksums of '%s': %v.", updateFilePath,
+			err))
+	}
+	if len(mismatches) == 0 {
+		return nil
+	}
+	for _, mismatch := range mismatches {
+		util.PrintError(fmt.Sprintf("'%s' does not match '%s' (expected: %s, actual: %s).", mismatch.Path,
+			mismatch.Manifest, mismatch.Expected, mismatch.Actual))
+	}
+	return errors.New(fmt.Sprintf("%d payload file(s) do not match the checksum manifests of the product. Check "+
+		"whether the files are placed in the correct directories and ship the updated checksum manifests with "+
+		"the update.", len(mismatches)))
+}
+
+// This function returns the modes of the files in the payload of the given update zip against their paths relative to
+// the payload directory.
+func getUpdateFileModes(updateFilePath, updateName, payloadDirectory string) (map[string]os.FileMode, error) {
+	fileModes := make(map[string]os.FileMode)
+	zipReader, err := zip.OpenReader(updateFilePath)
+	if err != nil {
+		return nil, err
+	}
+	defer zipReader.Close()
+	prefix := updateName + "/" + payloadDirectory + "/"
+	for _, file := range zipReader.Reader.File {
+		name := strings.Replace(file.Name, "\\", "/", -1)
+		if file.FileInfo().IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		fileModes[strings.TrimPrefix(name, prefix)] = file.Mode()
+	}
+	return fileModes, nil
+}
+
+// This function checks whether each executable file declared in the update-descriptor3.yaml is in the update and is
+// executable. Executable files of the update which are not declared are reported, as they may not be executable when
+// the update is created again.
+func checkExecutableFiles(fileModes map[string]os.FileMode, updateDescriptorV3 *util.UpdateDescriptorV3) error {
+	err := util.ValidateExecutableFiles(updateDescriptorV3)
+	if err != nil {
+		return errors.New(fmt.Sprintf("'%s' is invalid: %v.", constant.UPDATE_DESCRIPTOR_V3_FILE, err))
+	}
+	for _, executableFile := range updateDescriptorV3.ExecutableFiles {
+		mode, found := fileModes[executableFile]
 		if !found {
-			logger.Debug(fmt.Sprintf("Added files of %s-%s: ", updateDescriptorV3.CompatibleProducts[0].ProductName,
-				updateDescriptorV3.CompatibleProducts[0].ProductVersion),
-				updateDescriptorV3.CompatibleProducts[0].AddedFiles)
-			isInAddedFiles := util.IsStringIsInSlice(filePath, updateDescriptorV3.CompatibleProducts[0].AddedFiles)
-			logger.Debug(fmt.Sprintf("isInAddedFiles of %s-%s: %v", updateDescriptorV3.CompatibleProducts[0].ProductName,
-				updateDescriptorV3.CompatibleProducts[0].ProductVersion, isInAddedFiles))
-			resourceFiles := getResourceFiles()
-			logger.Debug(fmt.Sprintf("resourceFiles: %v", resourceFiles))
-			fileName := strings.TrimPrefix(filePath, updateName+"/")
-			logger.Debug(fmt.Sprintf("fileName: %s", fileName))
-			_, foundInResources := resourceFiles[fileName]
-			logger.Debug(fmt.Sprintf("found in resources: %v", foundInResources))
-			if !isInAddedFiles && !foundInResources {
-				return errors.New(fmt.Sprintf("'%v' file not found in the distribution. If this is "+
-					"a new file, provide it as an 'added_files' during the update creation process.",
-					filePath))
-			} else if isInAddedFiles {
-				logger.Debug("'" + filePath + "' found in added files.")
+			return errors.New(fmt.Sprintf("Executable file '%s' is not found in the update.", executableFile))
+		}
+		if mode&constant.EXECUTE_PERMISSIONS == 0 {
+			return errors.New(fmt.Sprintf("Executable file '%s' is not executable in the update (%v). Recreate "+
+				"the update with 'wum-uc create --continue'.", executableFile, mode))
+		}
+		logger.Debug(fmt.Sprintf("Executable file %s is executable (%v)", executableFile, mode))
+	}
+	var undeclaredFiles []string
+	for filePath, mode := range fileModes {
+		if mode&constant.EXECUTE_PERMISSIONS != 0 &&
+			!util.IsStringIsInSlice(filePath, updateDescriptorV3.ExecutableFiles) {
+			undeclaredFiles = append(undeclaredFiles, filePath)
+		}
+	}
+	if len(undeclaredFiles) != 0 {
+		sort.Strings(undeclaredFiles)
+		util.PrintWarning(fmt.Sprintf("Following files are executable in the update but not declared in "+
+			"'executable_files' of '%s':\n\t%s", constant.UPDATE_DESCRIPTOR_V3_FILE,
+			strings.Join(undeclaredFiles, "\n\t")))
+	}
+	return nil
+}
+
+// This function compares the files in the update and the provided distribution.
+func compare(updateFileMap, distributionFileMap map[string]bool, ignoredPaths []string,
+	updateDescriptorV3 *util.UpdateDescriptorV3) error {
+	updateName := viper.GetString(constant.UPDATE_NAME)
+	addedFiles := make(map[string]bool)
+	for _, addedFile := range updateDescriptorV3.CompatibleProducts[0].AddedFiles {
+		addedFiles[addedFile] = true
+	}
+	logger.Debug(fmt.Sprintf("Added files of %s-%s: %v", updateDescriptorV3.CompatibleProducts[0].ProductName,
+		updateDescriptorV3.CompatibleProducts[0].ProductVersion, updateDescriptorV3.CompatibleProducts[0].AddedFiles))
+	resourceFiles := getResourceFiles(updateDescriptorV3)
+	logger.Debug(fmt.Sprintf("resourceFiles: %v", resourceFiles))
+	ignoredFiles := util.GetIgnoredFiles(constant.IGNORED_FILES_VALIDATE, constant.MATCHING)
+	logger.Debug(fmt.Sprintf("ignoredFiles: %v", ignoredFiles))
+
+	// Files are checked in parallel. They are sorted so that the same error is reported for the same update.
+	filePaths := getSortedFilePaths(updateFileMap)
+	errs := util.RunInParallel(len(filePaths), viper.GetInt(constant.VALIDATION_WORKERS), func(index int) error {
+		filePath := filePaths[index]
+		logger.Trace(fmt.Sprintf("Searching: %s", filePath))
+		if util.IsIgnoredPath(filePath, ignoredPaths) {
+			return errors.New(fmt.Sprintf("'%v' is declared as non-updatable in '%s' of the distribution.",
+				filePath, constant.WUM_IGNORE_FILE))
+		}
+		if _, found := distributionFileMap[filePath]; found {
+			if addedFiles[filePath] {
+				return errors.New(fmt.Sprintf("'%v' is declared in 'added_files' but it is found in the "+
+					"distribution. Provide it as a 'modified_files' during the update creation process.", filePath))
 			}
+			return nil
+		}
+		if util.IsIgnoredFile(path.Base(filePath), ignoredFiles) {
+			logger.Debug(fmt.Sprintf("'%s' is ignored for matching", filePath))
+			return nil
+		}
+		_, foundInResources := resourceFiles[strings.TrimPrefix(filePath, updateName+"/")]
+		if !addedFiles[filePath] && !foundInResources {
+			return errors.New(fmt.Sprintf("'%v' file not found in the distribution. If this is "+
+				"a new file, provide it as an 'added_files' during the update creation process.", filePath))
+		} else if addedFiles[filePath] {
+			logger.Trace("'" + filePath + "' found in added files.")
 		}
+		return nil
+	})
+	for index, err := range errs {
+		if err != nil {
+			util.RecordFileError(filePaths[index], err.Error())
+		}
+	}
+	return util.GetFirstError(errs)
+}
+
+// This function returns the paths of the given file map sorted in the ascending order.
+func getSortedFilePaths(fileMap map[string]bool) []string {
+	var filePaths []string
+	for filePath := range fileMap {
+		filePaths = append(filePaths, filePath)
+	}
+	sort.Strings(filePaths)
+	return filePaths
+}
+
+// This function checks whether an update which only removes files does not have payload files, and whether its removed
+// files are in the distribution.
+func checkRemoveOnlyUpdate(updateFileMap map[string]bool, payloadRootFileMaps map[string]map[string]bool,
+	distributionFileMap map[string]bool, updateDescriptorV3 *util.UpdateDescriptorV3) error {
+	updateName := viper.GetString(constant.UPDATE_NAME)
+	// Resource files are kept relative to the update root while the payload files are relative to the payload
+	// directory
+	for filePath := range updateFileMap {
+		if !strings.HasPrefix(filePath, updateName+"/") {
+			return errors.New(fmt.Sprintf("'%s' only removes files according to '%s', but '%s' is in its payload.",
+				updateName, constant.UPDATE_DESCRIPTOR_V3_FILE, filePath))
+		}
+	}
+	for payloadRoot, payloadRootFileMap := range payloadRootFileMaps {
+		if len(payloadRootFileMap) != 0 {
+			return errors.New(fmt.Sprintf("'%s' only removes files according to '%s', but '%s' has a payload.",
+				updateName, constant.UPDATE_DESCRIPTOR_V3_FILE, payloadRoot))
+		}
+	}
+	for _, removedFile := range util.GetRemovedFiles(updateDescriptorV3) {
+		if _, found := distributionFileMap[removedFile]; !found {
+			message := fmt.Sprintf("'%s' removed by '%s' is not found in the distribution.", removedFile,
+				updateName)
+			util.RecordFileError(removedFile, message)
+			return errors.New(message)
+		}
+	}
+	return nil
+}
+
+// This function returns a local file of the artifact at the given URI. Remote artifacts are downloaded to the given
+// directory.
+func downloadArtifact(uri, directory string) string {
+	if !util.IsRemoteArtifact(uri) {
+		return uri
+	}
+	util.PrintInfo(fmt.Sprintf("Downloading '%s' ...", util.RedactArtifactURI(uri)))
+	localPath, err := util.ResolveArtifact(uri, directory)
+	util.HandleErrorAndExit(err)
+	return localPath
+}
+
+// This function checks whether each OS variant declared in the update-descriptor3.yaml is in the update, and is either
+// a file of the distribution or an added file.
+func checkOSVariants(updateFileMap, distributionFileMap map[string]bool,
+	updateDescriptorV3 *util.UpdateDescriptorV3) error {
+	addedFiles := make(map[string]bool)
+	for _, addedFile := range updateDescriptorV3.CompatibleProducts[0].AddedFiles {
+		addedFiles[addedFile] = true
+	}
+	for _, variant := range updateDescriptorV3.OSVariants {
+		if _, found := updateFileMap[variant.Path]; !found {
+			return errors.New(fmt.Sprintf("OS variant '%s' is not found in the update.", variant.Path))
+		}
+		if _, found := distributionFileMap[variant.Path]; !found && !addedFiles[variant.Path] {
+			return errors.New(fmt.Sprintf("OS variant '%s' (%s) is not found in the distribution. If this is a "+
+				"new file, provide it as an 'added_files' during the update creation process.", variant.Path,
+				strings.Join(variant.OS, ", ")))
+		}
+		logger.Debug(fmt.Sprintf("OS variant %s of %v found", variant.Path, variant.OS))
 	}
 	return nil
 }
 
-// This function will read the update zip at the the given location.
-func readUpdateZip(filename string) (map[string]bool, *util.UpdateDescriptorV3, error) {
+// This function reports the divergences between the update-descriptor.yaml and the update-descriptor3.yaml of the
+// given update as they are maintained separately.
+func checkUpdateDescriptorsConsistency(updateName string, updateDescriptorV2 *util.UpdateDescriptorV2,
+	updateDescriptorV3 *util.UpdateDescriptorV3) {
+	divergences := util.CompareUpdateDescriptors(updateDescriptorV2, updateDescriptorV3)
+	if len(divergences) == 0 {
+		logger.Debug(fmt.Sprintf("%s and %s of %s are consistent", constant.UPDATE_DESCRIPTOR_V2_FILE,
+			constant.UPDATE_DESCRIPTOR_V3_FILE, updateName))
+		return
+	}
+	util.PrintWarning(fmt.Sprintf("'%s' and '%s' of '%s' diverge:\n\t%s", constant.UPDATE_DESCRIPTOR_V2_FILE,
+		constant.UPDATE_DESCRIPTOR_V3_FILE, updateName, strings.Join(divergences, "\n\t")))
+}
+
+// This function will read the update zip at the the given location. Files in carbon.home and files in the payload
+// roots of the products are returned separately, the latter grouped by the payload root.
+func readUpdateZip(filename string) (map[string]bool, map[string]map[string]bool, *util.UpdateDescriptorV3, error) {
 	fileMap := make(map[string]bool)
+	payloadRootFileMaps := make(map[string]map[string]bool)
 	updateDescriptorV2 := util.UpdateDescriptorV2{}
 	updateDescriptorV3 := util.UpdateDescriptorV3{}
 
 	isNotAContributionFileFound := false
 	isASecPatch := false
+	isUpdateDescriptorV2Found := false
 
 	// Create a reader out of the zip archive
 	zipReader, err := zip.OpenReader(filename)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 	defer zipReader.Close()
 
 	updateName := viper.GetString(constant.UPDATE_NAME)
 	logger.Debug("UpdateName:", updateName)
+	// Payload roots and resource files should be known before reading the files as update-descriptor3.yaml can be
+	// anywhere in the zip
+	zippedUpdateDescriptorV3, err := readZippedUpdateDescriptorV3(&zipReader.Reader, updateName)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	payloadRoots, err := readPayloadRoots(zippedUpdateDescriptorV3)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	payloadDirectory := util.GetPayloadDirectory(zippedUpdateDescriptorV3)
+	logger.Debug(fmt.Sprintf("Payload directory: %s", payloadDirectory))
+	resourceFiles := getResourceFiles(zippedUpdateDescriptorV3)
+	logger.Debug(fmt.Sprintf("resourceFiles: %v", resourceFiles))
+	foundResourceFiles := make(map[string]bool)
+	excludedFiles := util.GetIgnoredFiles(constant.IGNORED_FILES_VALIDATE, constant.ZIP)
 	// Iterate through each file/dir found in
 	for _, file := range zipReader.Reader.File {
 		name := getFileName(file.FileInfo().Name())
@@ -198,52 +912,67 @@ func readUpdateZip(filename string) (map[string]bool, *util.UpdateDescriptorV3,
 			if name != updateName {
 				logger.Debug("Checking:", name)
 				//Check
-				prefix := filepath.Join(updateName, constant.CARBON_HOME)
+				prefix := path.Join(updateName, payloadDirectory)
 				hasPrefix := strings.HasPrefix(file.Name, prefix)
+				if payloadRoot := getPayloadRootOfEntry(file.Name, updateName, payloadRoots); len(payloadRoot) != 0 {
+					hasPrefix = true
+				}
 				if !hasPrefix {
-					return nil, nil, errors.New("Unknown directory found: '" + file.Name + "'")
+					return nil, nil, nil, errors.New("Unknown directory found: '" + file.Name + "'")
 				}
 			}
 		} else {
 			//todo: check for ignored files .gitignore
 			logger.Debug(fmt.Sprintf("file.Name: %s", file.Name))
 			logger.Debug(fmt.Sprintf("file.FileInfo().Name(): %s", name))
-			fullPath := filepath.Join(updateName, name)
+			fullPath := path.Join(updateName, name)
 			logger.Debug(fmt.Sprintf("fullPath: %s", fullPath))
+			if util.IsIgnoredFile(name, excludedFiles) {
+				return nil, nil, nil, errors.New(fmt.Sprintf("'%s' should be excluded from the update zip (%s).",
+					file.Name, constant.IGNORED_FILES_ZIP))
+			}
+			// The GPG signature embedded by 'wum-uc sign' is not a part of the update
+			if util.IsEmbeddedSignatureEntry(updateName, file.Name) {
+				continue
+			}
+			if file.Name == updateName+"/"+name {
+				foundResourceFiles[name] = true
+			}
 			switch name {
 			case constant.UPDATE_DESCRIPTOR_V2_FILE:
 				data, err := validateFile(file, constant.UPDATE_DESCRIPTOR_V2_FILE, fullPath, updateName)
 				if err != nil {
-					return nil, nil, err
+					return nil, nil, nil, err
 				}
-				err = yaml.Unmarshal(data, &updateDescriptorV2)
+				err = util.UnmarshalUpdateDescriptor(data, constant.UPDATE_DESCRIPTOR_V2_FILE, &updateDescriptorV2)
 				if err != nil {
-					return nil, nil, err
+					return nil, nil, nil, err
 				}
 				//check
 				err = util.ValidateUpdateDescriptorV2(&updateDescriptorV2)
 				if err != nil {
-					return nil, nil, errors.New("'" + constant.UPDATE_DESCRIPTOR_V2_FILE +
+					return nil, nil, nil, errors.New("'" + constant.UPDATE_DESCRIPTOR_V2_FILE +
 						"' is invalid. " + err.Error())
 				}
+				isUpdateDescriptorV2Found = true
 			case constant.UPDATE_DESCRIPTOR_V3_FILE:
 				data, err := validateFile(file, constant.UPDATE_DESCRIPTOR_V3_FILE, fullPath, updateName)
 				if err != nil {
-					return nil, nil, err
+					return nil, nil, nil, err
 				}
-				err = yaml.Unmarshal(data, &updateDescriptorV3)
+				err = util.UnmarshalUpdateDescriptor(data, constant.UPDATE_DESCRIPTOR_V3_FILE, &updateDescriptorV3)
 				if err != nil {
-					return nil, nil, err
+					return nil, nil, nil, err
 				}
 				err = util.ValidateUpdateDescriptorV3(&updateDescriptorV3)
 				if err != nil {
-					return nil, nil, errors.New("'" + constant.UPDATE_DESCRIPTOR_V3_FILE +
+					return nil, nil, nil, errors.New("'" + constant.UPDATE_DESCRIPTOR_V3_FILE +
 						"' is invalid. " + err.Error())
 				}
 			case constant.LICENSE_FILE:
 				data, err := validateFile(file, constant.LICENSE_FILE, fullPath, updateName)
 				if err != nil {
-					return nil, nil, err
+					return nil, nil, nil, err
 				}
 				dataString := string(data)
 				if strings.Contains(dataString, "under Apache License 2.0") {
@@ -252,42 +981,255 @@ func readUpdateZip(filename string) (map[string]bool, *util.UpdateDescriptorV3,
 			case constant.INSTRUCTIONS_FILE:
 				_, err := validateFile(file, constant.INSTRUCTIONS_FILE, fullPath, updateName)
 				if err != nil {
-					return nil, nil, err
+					return nil, nil, nil, err
 				}
 			case constant.NOT_A_CONTRIBUTION_FILE:
 				isNotAContributionFileFound = true
 				_, err := validateFile(file, constant.NOT_A_CONTRIBUTION_FILE, fullPath, updateName)
 				if err != nil {
-					return nil, nil, err
+					return nil, nil, nil, err
 				}
 			default:
-				resourceFiles := getResourceFiles()
-				logger.Debug(fmt.Sprintf("resourceFiles: %v", resourceFiles))
-				prefix := filepath.Join(updateName, constant.CARBON_HOME)
+				prefix := path.Join(updateName, payloadDirectory)
 				logger.Debug(fmt.Sprintf("Checking prefix %s in %s", prefix, file.Name))
 				hasPrefix := strings.HasPrefix(file.Name, prefix)
 				_, foundInResources := resourceFiles[name]
 				logger.Debug(fmt.Sprintf("foundInResources: %v", foundInResources))
+				if payloadRoot := getPayloadRootOfEntry(file.Name, updateName, payloadRoots); len(payloadRoot) != 0 {
+					if payloadRootFileMaps[payloadRoot] == nil {
+						payloadRootFileMaps[payloadRoot] = make(map[string]bool)
+					}
+					relativePath := strings.TrimPrefix(file.Name, updateName+"/"+payloadRoot+"/")
+					payloadRootFileMaps[payloadRoot][relativePath] = false
+					continue
+				}
 				if !hasPrefix && !foundInResources {
-					return nil, nil, errors.New(fmt.Sprintf("Unknown file found: '%s'.", file.Name))
+					return nil, nil, nil, errors.New(fmt.Sprintf("Unknown file found: '%s'.", file.Name))
 				}
-				logger.Debug(fmt.Sprintf("Trimming: %s using %s", file.Name,
-					prefix+constant.PATH_SEPARATOR))
-				relativePath := strings.TrimPrefix(file.Name, prefix+constant.PATH_SEPARATOR)
+				logger.Debug(fmt.Sprintf("Trimming: %s using %s", file.Name, prefix+"/"))
+				relativePath := strings.TrimPrefix(file.Name, prefix+"/")
+				// Binary deltas replace the files they reconstruct
+				relativePath = strings.TrimSuffix(relativePath, constant.BINARY_DELTA_EXTENSION)
 				fileMap[relativePath] = false
 			}
 		}
 	}
-	if !isASecPatch && !isNotAContributionFileFound {
-		util.PrintWarning(fmt.Sprintf("This update is not a security update. But '%v' was not found. Please "+
+	// Check whether the mandatory resource files of the default and the product family resource files are found
+	for resourceFile, isMandatory := range resourceFiles {
+		if isMandatory && !foundResourceFiles[resourceFile] {
+			return nil, nil, nil, errors.New(fmt.Sprintf("Mandatory resource file '%s' was not found in the update.",
+				resourceFile))
+		}
+	}
+	if isUpdateDescriptorV2Found && len(updateDescriptorV3.UpdateNumber) != 0 {
+		checkUpdateDescriptorsConsistency(updateName, &updateDescriptorV2, &updateDescriptorV3)
+	}
+	if len(updateDescriptorV3.SecurityAdvisory) != 0 {
+		// Security updates which declare their advisory should follow the policy of the legal resource files
+		err = getLegalFiles(updateDescriptorV3.SecurityAdvisory).Check(foundResourceFiles[constant.LICENSE_FILE],
+			isNotAContributionFileFound)
+		if err != nil {
+			return nil, nil, nil, errors.New(fmt.Sprintf("Legal resource files of '%s' are invalid, %v.",
+				updateName, err))
+		}
+	} else if !isASecPatch && !isNotAContributionFileFound {
+		util.PrintPolicyNotice(fmt.Sprintf("This update is not a security update. But '%v' was not found. Please "+
 			"review and add '%v' file if necessary.", constant.NOT_A_CONTRIBUTION_FILE,
 			constant.NOT_A_CONTRIBUTION_FILE))
 	} else if isASecPatch && isNotAContributionFileFound {
-		util.PrintWarning(fmt.Sprintf("This update is a security update. But '%v' was found. Please review "+
+		util.PrintPolicyNotice(fmt.Sprintf("This update is a security update. But '%v' was found. Please review "+
 			"and remove '%v' file if necessary.", constant.NOT_A_CONTRIBUTION_FILE,
 			constant.NOT_A_CONTRIBUTION_FILE))
 	}
-	return fileMap, &updateDescriptorV3, nil
+	return fileMap, payloadRootFileMaps, &updateDescriptorV3, nil
+}
+
+// This function reads the update-descriptor3.yaml of the given update zip. Nil is returned if the update zip does not
+// have an update-descriptor3.yaml.
+func readZippedUpdateDescriptorV3(zipReader *zip.Reader, updateName string) (*util.UpdateDescriptorV3, error) {
+	updateDescriptorV3 := util.UpdateDescriptorV3{}
+	found, err := readZippedUpdateDescriptor(zipReader, updateName, constant.UPDATE_DESCRIPTOR_V3_FILE,
+		&updateDescriptorV3)
+	if err != nil || !found {
+		return nil, err
+	}
+	return &updateDescriptorV3, nil
+}
+
+// This function reads the update-descriptor.yaml of the given update zip. Nil is returned if the update zip does not
+// have an update-descriptor.yaml.
+func readZippedUpdateDescriptorV2(zipReader *zip.Reader, updateName string) (*util.UpdateDescriptorV2, error) {
+	updateDescriptorV2 := util.UpdateDescriptorV2{}
+	found, err := readZippedUpdateDescriptor(zipReader, updateName, constant.UPDATE_DESCRIPTOR_V2_FILE,
+		&updateDescriptorV2)
+	if err != nil || !found {
+		return nil, err
+	}
+	return &updateDescriptorV2, nil
+}
+
+// This function reads the update descriptor with the given name in the root folder of the given update zip to the
+// given descriptor. False is returned if the update zip does not have the update descriptor.
+func readZippedUpdateDescriptor(zipReader *zip.Reader, updateName, descriptorFile string,
+	descriptor interface{}) (bool, error) {
+	for _, file := range zipReader.File {
+		if file.Name != updateName+"/"+descriptorFile {
+			continue
+		}
+		zippedFile, err := file.Open()
+		if err != nil {
+			return false, err
+		}
+		data, err := ioutil.ReadAll(zippedFile)
+		zippedFile.Close()
+		if err != nil {
+			return false, err
+		}
+		return true, util.UnmarshalUpdateDescriptor(data, descriptorFile, descriptor)
+	}
+	return false, nil
+}
+
+// This function reads the payload roots declared in the given update-descriptor3.yaml.
+func readPayloadRoots(updateDescriptorV3 *util.UpdateDescriptorV3) ([]string, error) {
+	var payloadRoots []string
+	if updateDescriptorV3 == nil {
+		return payloadRoots, nil
+	}
+	if err := util.ValidatePayloadRoots(updateDescriptorV3); err != nil {
+		return nil, errors.New("'" + constant.UPDATE_DESCRIPTOR_V3_FILE + "' is invalid. " + err.Error())
+	}
+	for payloadRoot := range util.GetProductsByPayloadRoot(updateDescriptorV3) {
+		payloadRoots = append(payloadRoots, payloadRoot)
+	}
+	logger.Debug(fmt.Sprintf("Payload roots: %v", payloadRoots))
+	return payloadRoots, nil
+}
+
+// This function returns the payload root which contains the given zip entry or an empty string if the entry is not
+// in a payload root.
+func getPayloadRootOfEntry(entryName, updateName string, payloadRoots []string) string {
+	for _, payloadRoot := range payloadRoots {
+		if strings.HasPrefix(entryName, updateName+"/"+payloadRoot+"/") {
+			return payloadRoot
+		}
+	}
+	return ""
+}
+
+// This function compares the files in the payload root of each product with the distribution of the product given
+// with '--product-distribution'. Products of which the distribution is not given are skipped.
+func validateProductPayloads(payloadRootFileMaps map[string]map[string]bool,
+	updateDescriptorV3 *util.UpdateDescriptorV3) {
+	distributions := make(map[string]string)
+	for _, productDistribution := range productDistributions {
+		parts := strings.SplitN(productDistribution, "=", 2)
+		if len(parts) != 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+			util.HandleErrorAndExit(errors.New(fmt.Sprintf("invalid product distribution '%s', expected "+
+				"<product>-<version>=<dist_loc>", productDistribution)))
+		}
+		distributions[parts[0]] = parts[1]
+	}
+	for payloadRoot, products := range util.GetProductsByPayloadRoot(updateDescriptorV3) {
+		for _, product := range products {
+			productId := product.ProductName + "-" + product.ProductVersion
+			distributionLocation, found := distributions[productId]
+			if !found {
+				util.PrintInfo(fmt.Sprintf("Distribution of '%s' is not given. Files in '%s' are not compared "+
+					"with the distribution.", productId, payloadRoot))
+				continue
+			}
+			if zipPath, anomaly := checkDistribution(distributionLocation); anomaly != nil {
+				distributionLocation = rerootToTempDirectory(constant.DISTRIBUTION, zipPath, anomaly)
+				defer util.CleanUpDirectory(filepath.Dir(distributionLocation))
+			}
+			logger.Debug(fmt.Sprintf("Comparing %s with %s", payloadRoot, distributionLocation))
+			distributionFileMap, ignoredPaths, err := readDistributionFiles(distributionLocation)
+			util.HandleErrorAndExit(err)
+			// Added files of the product are used when comparing
+			productDescriptor := *updateDescriptorV3
+			productDescriptor.CompatibleProducts = []util.ProductChanges{product}
+			err = compare(payloadRootFileMaps[payloadRoot], distributionFileMap, ignoredPaths, &productDescriptor)
+			util.HandleErrorAndExit(err, fmt.Sprintf("Files in '%s' do not match the distribution of '%s'.",
+				payloadRoot, productId))
+		}
+	}
+}
+
+// This function checks whether any of the listed distributions is given as a remote URI.
+func hasRemoteListedDistribution() bool {
+	for _, listedDistribution := range listedDistributions {
+		if util.IsRemoteArtifact(listedDistribution.Distribution) {
+			return true
+		}
+	}
+	return false
+}
+
+// This function compares the update with the distributions of the listed products other than the first one, which is
+// compared as the distribution of the update. Only the files of the product are compared for the partially applicable
+// products.
+func validateListedDistributions(updateFileMap map[string]bool, updateDescriptorV3 *util.UpdateDescriptorV3) {
+	updateName := viper.GetString(constant.UPDATE_NAME)
+	for _, listedDistribution := range listedDistributions[1:] {
+		productId := listedDistribution.GetProductId()
+		product, isCompatible := getListedProduct(&listedDistribution, updateDescriptorV3)
+		if product == nil {
+			util.HandleErrorAndExit(util.NewValidationError(errors.New(fmt.Sprintf("'%s' is listed in the "+
+				"distribution list, but it is not a product of '%s' according to '%s'.", productId, updateName,
+				constant.UPDATE_DESCRIPTOR_V3_FILE))))
+		}
+		distributionLocation := listedDistribution.Distribution
+		if zipPath, anomaly := checkDistribution(distributionLocation); anomaly != nil {
+			distributionLocation = rerootToTempDirectory(constant.DISTRIBUTION, zipPath, anomaly)
+			defer util.CleanUpDirectory(filepath.Dir(distributionLocation))
+		}
+		logger.Debug(fmt.Sprintf("Comparing %s with %s", updateName, distributionLocation))
+		distributionFileMap, ignoredPaths, err := readDistributionFiles(distributionLocation)
+		util.HandleErrorAndExit(err)
+
+		productFileMap := updateFileMap
+		if !isCompatible {
+			productFiles := make(map[string]bool)
+			for _, filePath := range append(append([]string{}, product.AddedFiles...), product.ModifiedFiles...) {
+				productFiles[filePath] = true
+			}
+			// Resource files are kept relative to the update root while the payload files are relative to the
+			// payload directory
+			productFileMap = make(map[string]bool)
+			for filePath, isDir := range updateFileMap {
+				if strings.HasPrefix(filePath, updateName+"/") || productFiles[filePath] {
+					productFileMap[filePath] = isDir
+				}
+			}
+		}
+		// Added files of the product are used when comparing
+		productDescriptor := *updateDescriptorV3
+		productDescriptor.CompatibleProducts = []util.ProductChanges{*product}
+		err = compare(productFileMap, distributionFileMap, ignoredPaths, &productDescriptor)
+		util.HandleErrorAndExit(util.NewValidationError(err), fmt.Sprintf("'%s' does not match the distribution "+
+			"of '%s'.", updateName, productId))
+		util.PrintInfo(fmt.Sprintf("'%s' matches the distribution of '%s'.", updateName, productId))
+	}
+}
+
+// This function returns the changes of the product of the given listed distribution in the update-descriptor3.yaml
+// and whether the product is compatible. Nil is returned if the product is not in the update-descriptor3.yaml.
+func getListedProduct(listedDistribution *util.ListedDistribution,
+	updateDescriptorV3 *util.UpdateDescriptorV3) (*util.ProductChanges, bool) {
+	for index, product := range updateDescriptorV3.CompatibleProducts {
+		if product.ProductName == listedDistribution.ProductName &&
+			product.ProductVersion == listedDistribution.ProductVersion {
+			return &updateDescriptorV3.CompatibleProducts[index], true
+		}
+	}
+	for index, product := range updateDescriptorV3.PartiallyApplicableProducts {
+		if product.ProductName == listedDistribution.ProductName &&
+			product.ProductVersion == listedDistribution.ProductVersion {
+			return &updateDescriptorV3.PartiallyApplicableProducts[index], false
+		}
+	}
+	return nil, false
 }
 
 // This function will validate the provided file. If the word 'patch' is found, a warning message is printed.
@@ -342,36 +1284,240 @@ func validateFile(file *zip.File, fileName, fullPath, updateName string) ([]byte
 		for i, line := range allMatches {
 			util.PrintInfo(fmt.Sprintf("Matching Line #%d - %v\n", i+1, line[0]))
 		}
-		fmt.Println()
+		util.PrintMessage()
 	}
 
 	logger.Debug(fmt.Sprintf("Validating '%s' finished.", fileName))
 	return data, nil
 }
 
-// This function reads the product distribution at the given location.
-func readDistributionZip(filename string) (map[string]bool, error) {
+// This function fetches the metadata of the latest updated distribution of the given product from WUM and returns
+// the files of the distribution and their checksums of the given algorithm. An error is returned if WUM does not
+// provide the checksums of the algorithm.
+func readDistributionBaseline(productName, productVersion, channel, algorithm string) (map[string]bool,
+	map[string]string, error) {
+	util.PrintMessage(fmt.Sprintf("Fetching the latest updated distribution of %s-%s in the '%s' channel ...",
+		productName, productVersion, channel))
+	wumucConfig := util.GetWUMUCConfigs()
+	baseline, err := util.GetDistributionBaseline(wumucConfig.ServerURL, wumucConfig.AccessToken, productName,
+		productVersion, channel)
+	if err != nil {
+		return nil, nil, err
+	}
 	fileMap := make(map[string]bool)
-	// Create a reader out of the zip archive
-	zipReader, err := zip.OpenReader(filename)
+	checksums := make(map[string]string)
+	for _, file := range baseline.Files {
+		checksum := util.GetBaselineFileChecksum(&file, algorithm)
+		if len(checksum) == 0 {
+			return nil, nil, errors.New(fmt.Sprintf("WUM does not provide the %s checksum of '%s' in the latest "+
+				"updated distribution of %s-%s", algorithm, file.Path, productName, productVersion))
+		}
+		fileMap[file.Path] = false
+		checksums[file.Path] = checksum
+	}
+	logger.Debug(fmt.Sprintf("%d files found in the latest updated distribution", len(fileMap)))
+	return fileMap, checksums, nil
+}
+
+// This function checks whether the files in the given update are identical to the files in the distribution, as
+// customers already have those files. Files are compared using the given checksums of the distribution files of the
+// given algorithm. Identical modified files fail the validation as they are mislabeled in the update descriptor, and a
+// warning is printed for the other identical files.
+func checkUnchangedFiles(updateFilePath, updateName, payloadDirectory string, baselineChecksums map[string]string,
+	modifiedFiles []string, algorithm string) error {
+	zipReader, err := zip.OpenReader(updateFilePath)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer zipReader.Close()
+	prefix := path.Join(updateName, payloadDirectory) + "/"
+	var files []*zip.File
+	for _, file := range zipReader.Reader.File {
+		_, found := baselineChecksums[strings.TrimPrefix(file.Name, prefix)]
+		if !file.FileInfo().IsDir() && strings.HasPrefix(file.Name, prefix) && found {
+			files = append(files, file)
+		}
+	}
+	// Files are hashed in parallel and the warnings are printed in the order of the files in the update
+	isUnchanged := make([]bool, len(files))
+	errs := util.RunInParallel(len(files), viper.GetInt(constant.VALIDATION_WORKERS), func(index int) error {
+		data, err := readZipEntry(files[index])
+		if err != nil {
+			return err
+		}
+		checksum, err := util.GetChecksumOfReader(bytes.NewReader(data), algorithm)
+		if err != nil {
+			return err
+		}
+		relativePath := strings.TrimPrefix(files[index].Name, prefix)
+		isUnchanged[index] = checksum == baselineChecksums[relativePath]
+		return nil
+	})
+	if err = util.GetFirstError(errs); err != nil {
+		return err
+	}
+	unchangedModifiedFiles := 0
+	for index, file := range files {
+		if !isUnchanged[index] {
+			continue
+		}
+		relativePath := strings.TrimPrefix(file.Name, prefix)
+		if util.IsStringIsInSlice(relativePath, modifiedFiles) {
+			message := fmt.Sprintf("'%s' is declared in 'modified_files' but it is identical to the file in the "+
+				"distribution (%s: %s).", relativePath, algorithm, baselineChecksums[relativePath])
+			util.RecordFileError(relativePath, message)
+			util.PrintError(message)
+			unchangedModifiedFiles++
+		} else {
+			message := fmt.Sprintf("'%s' is identical to the file in the latest updated distribution.",
+				relativePath)
+			util.RecordFileWarning(relativePath, message)
+			util.PrintWarning(message)
+		}
+	}
+	if unchangedModifiedFiles != 0 {
+		return errors.New(fmt.Sprintf("%d modified file(s) are not modified by the update. Check whether the "+
+			"correct files are used, or remove them from the update.", unchangedModifiedFiles))
+	}
+	return nil
+}
 
-	productName := viper.GetString(constant.PRODUCT_NAME)
-	logger.Debug(fmt.Sprintf("productName: %s", productName))
-	// Iterate through each file/dir found in
+// This function returns the checksums of the given algorithm of the given files in the distribution at the given
+// location against their paths relative to the root folder of the distribution. The files are hashed in parallel and
+// files which are not in the distribution are skipped.
+func readDistributionChecksums(distributionLocation string, filePaths []string, algorithm string) (map[string]string,
+	error) {
+	distributionFS, closer, err := util.OpenDistribution(distributionLocation)
+	if err != nil {
+		return nil, err
+	}
+	defer closer.Close()
+	var files []string
+	for _, filePath := range filePaths {
+		fileInfo, err := fs.Stat(distributionFS, filePath)
+		if err == nil && !fileInfo.IsDir() && !util.IsStringIsInSlice(filePath, files) {
+			files = append(files, filePath)
+		}
+	}
+	checksums := make([]string, len(files))
+	errs := util.RunInParallel(len(files), viper.GetInt(constant.VALIDATION_WORKERS), func(index int) error {
+		distributionFile, err := distributionFS.Open(files[index])
+		if err != nil {
+			return err
+		}
+		defer distributionFile.Close()
+		checksums[index], err = util.GetChecksumOfReader(distributionFile, algorithm)
+		return err
+	})
+	if err = util.GetFirstError(errs); err != nil {
+		return nil, err
+	}
+	checksumMap := make(map[string]string)
+	for index, filePath := range files {
+		checksumMap[filePath] = checksums[index]
+	}
+	logger.Debug(fmt.Sprintf("%d of %d files found in %s", len(checksumMap), len(filePaths), distributionLocation))
+	return checksumMap, nil
+}
+
+// This function scans the payload files of the given update for secrets (keys, passwords, tokens). The payload files
+// are scanned in parallel. An error is returned if a secret is found in a file which is not waived in the given
+// update-descriptor3.yaml.
+func checkSecrets(updateFilePath, updateName string, updateDescriptorV3 *util.UpdateDescriptorV3) error {
+	rules, err := util.GetSecretRules(viper.GetStringSlice(constant.SECRET_SCAN_PATTERNS))
+	if err != nil {
+		return err
+	}
+	zipReader, err := zip.OpenReader(updateFilePath)
+	if err != nil {
+		return err
+	}
+	defer zipReader.Close()
+	prefix := updateName + "/"
+	var files []*zip.File
 	for _, file := range zipReader.Reader.File {
-		logger.Trace(file.Name)
+		// Files in the root of the update are resource files
+		if !file.FileInfo().IsDir() && strings.Contains(strings.TrimPrefix(file.Name, prefix), "/") {
+			files = append(files, file)
+		}
+	}
+	findings := make([][]util.SecretFinding, len(files))
+	errs := util.RunInParallel(len(files), viper.GetInt(constant.VALIDATION_WORKERS), func(index int) error {
+		data, err := readZipEntry(files[index])
+		if err != nil {
+			return err
+		}
+		filePath := util.GetSecretScanPath(strings.TrimPrefix(files[index].Name, prefix),
+			util.GetPayloadDirectory(updateDescriptorV3))
+		findings[index] = util.FindSecrets(filePath, data, rules,
+			viper.GetStringSlice(constant.SECRET_SCAN_ALLOWED_VALUES))
+		return nil
+	})
+	if err = util.GetFirstError(errs); err != nil {
+		return err
+	}
+	var allFindings []util.SecretFinding
+	for _, fileFindings := range findings {
+		allFindings = append(allFindings, fileFindings...)
+	}
+	return reportSecretFindings(allFindings, updateDescriptorV3.SecretWaivers)
+}
 
-		relativePath := util.GetRelativePath(file)
+// This function prints the given secrets found in the payload along with the justifications of the waived ones. An
+// error is returned if any of them is not waived.
+func reportSecretFindings(findings []util.SecretFinding, waivers []util.SecretWaiver) error {
+	blockingFindings, waivedFindings := util.ApplySecretWaivers(findings, waivers)
+	justifications := make(map[string]string)
+	for _, waiver := range waivers {
+		justifications[waiver.Path] = waiver.Justification
+	}
+	for _, finding := range waivedFindings {
+		util.PrintInfo(fmt.Sprintf("Secret waived: %s (%s)", finding, justifications[finding.Path]))
+	}
+	if len(blockingFindings) == 0 {
+		return nil
+	}
+	var messages []string
+	for _, finding := range blockingFindings {
+		messages = append(messages, finding.String())
+	}
+	return errors.New(fmt.Sprintf("%d secret(s) found in the payload:\n\t%s\nRemove them, or waive them by adding "+
+		"the files to 'secret_waivers' in '%s' with a justification.", len(blockingFindings),
+		strings.Join(messages, "\n\t"), constant.UPDATE_DESCRIPTOR_V3_FILE))
+}
 
-		if !file.FileInfo().IsDir() {
+// This function reads the product distribution at the given location with the distribution source which provides it.
+// Paths declared as non-updatable in the ignore manifest of the distribution are returned as well.
+func readDistributionFiles(location string) (map[string]bool, []string, error) {
+	fileMap := make(map[string]bool)
+	distributionFS, closer, err := util.OpenDistribution(location)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer closer.Close()
+	// Read the paths which are declared as non-updatable
+	ignoredPaths, err := util.ReadDistributionIgnoreManifest(distributionFS)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	productName := viper.GetString(constant.PRODUCT_NAME)
+	logger.Debug(fmt.Sprintf("productName: %s", productName))
+	// Iterate through each file found in the distribution
+	err = fs.WalkDir(distributionFS, ".", func(relativePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		logger.Trace(relativePath)
+		if !entry.IsDir() {
 			fileMap[relativePath] = false
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
 	}
-	return fileMap, nil
+	return fileMap, ignoredPaths, nil
 }
 
 // When reading zip files in windows, file.FileInfo().Name() does not return the filename correctly
@@ -0,0 +1,206 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package cmd
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/renstrom/dedent"
+	"github.com/spf13/cobra"
+	"github.com/wso2/update-creator-tool/constant"
+	"github.com/wso2/update-creator-tool/util"
+	"golang.org/x/crypto/openpgp"
+)
+
+// Values used to print help command.
+var (
+	verifyCmdUse       = "verify <update_loc>"
+	verifyCmdShortDesc = "Verify the GPG signature and the descriptors of an update"
+	verifyCmdLongDesc  = dedent.Dedent(`
+		This command will verify the given update before it is distributed. The detached GPG
+		signature in '<update_loc>.asc' (or the file given with '--signature <file>') and the
+		signature embedded by 'wum-uc sign --embed' are verified with the public keys given with
+		'--key <file>', and the files of the update zip are compared with the signed manifest.
+		The update descriptors of the update zip are validated and compared with each other as
+		well. The update is not modified.`)
+)
+
+// verifyCmd represents the verify command.
+var verifyCmd = &cobra.Command{
+	Use:   verifyCmdUse,
+	Short: verifyCmdShortDesc,
+	Long:  verifyCmdLongDesc,
+	Run:   initializeVerifyCommand,
+}
+
+var verifyKeyPath string
+var verifySignaturePath string
+
+// This function will be called first and this will add flags to the command.
+func init() {
+	RootCmd.AddCommand(verifyCmd)
+
+	verifyCmd.Flags().BoolVarP(&isDebugLogsEnabled, "debug", "d", util.EnableDebugLogs, "Enable debug logs")
+	verifyCmd.Flags().BoolVarP(&isTraceLogsEnabled, "trace", "t", util.EnableTraceLogs, "Enable trace logs")
+	verifyCmd.Flags().StringVar(&verifyKeyPath, "key", "", "Exported GPG public key or keyring used to verify "+
+		"the signatures")
+	verifyCmd.Flags().StringVar(&verifySignaturePath, "signature", "", "Detached signature of the update, "+
+		"instead of '<update_loc>.asc'")
+}
+
+// This function will be called when the verify command is called.
+func initializeVerifyCommand(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		util.HandleErrorAndExit(util.NewInputError(errors.New("invalid number of arguments. Run 'wum-uc verify " +
+			"--help' to view help")))
+	}
+	setLogLevel()
+	logger.Debug("[verify] command called")
+	if len(verifyKeyPath) == 0 {
+		util.HandleErrorAndExit(util.NewInputError(errors.New("GPG public key is not given. Run 'wum-uc verify " +
+			"<update_loc> --key <file>'")))
+	}
+	verifyUpdate(args[0], verifySignaturePath, verifyKeyPath)
+}
+
+// This function verifies the signatures and the update descriptors of the given update. The detached signature is
+// read from '<update_loc>.asc' if the signature path is empty.
+func verifyUpdate(updateFilePath, signaturePath, keyPath string) {
+	exists, err := util.IsFileExists(updateFilePath)
+	util.HandleErrorAndExit(err, "")
+	if !exists {
+		util.HandleErrorAndExit(util.NewInputError(errors.New(fmt.Sprintf("Entered update file does not exist at '%s'.",
+			updateFilePath))))
+	}
+	isSignatureGiven := len(signaturePath) != 0
+	if !isSignatureGiven {
+		signaturePath = updateFilePath + constant.GPG_SIGNATURE_EXTENSION
+	}
+	isSignatureFound, err := util.IsFileExists(signaturePath)
+	util.HandleErrorAndExit(err, "")
+	if isSignatureGiven && !isSignatureFound {
+		util.HandleErrorAndExit(util.NewInputError(errors.New(fmt.Sprintf("Entered signature does not exist at "+
+			"'%s'.", signaturePath))))
+	}
+	keyring, err := util.ReadGPGKeyring(keyPath)
+	util.HandleErrorAndExit(util.NewInputError(err), "Error occurred while reading the GPG public key.")
+
+	var failures []string
+	if isSignatureFound {
+		signer, err := util.VerifyGPGSignature(updateFilePath, signaturePath, keyring)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("detached signature '%s' is invalid: %v", signaturePath, err))
+		} else {
+			util.PrintInfo(fmt.Sprintf("Detached signature '%s' is valid. Signed with the GPG key %s.",
+				signaturePath, util.GetGPGKeyDescription(signer)))
+		}
+	}
+	isEmbeddedSignatureFound := false
+	if strings.HasSuffix(updateFilePath, constant.ZIP_FILE_EXTENSION) {
+		isEmbeddedSignatureFound, failures = verifyEmbeddedSignature(updateFilePath, keyring, failures)
+		failures = append(failures, verifyZippedUpdateDescriptors(updateFilePath)...)
+	} else {
+		logger.Debug(fmt.Sprintf("%s is not a zip, only the detached signature is verified", updateFilePath))
+	}
+	if !isSignatureFound && !isEmbeddedSignatureFound {
+		failures = append(failures, fmt.Sprintf("'%s' is not signed, neither '%s' nor an embedded signature is "+
+			"found", updateFilePath, signaturePath))
+	}
+
+	if len(failures) != 0 {
+		for _, failure := range failures {
+			util.PrintError(failure)
+		}
+		util.HandleErrorAndExit(util.NewValidationError(errors.New(fmt.Sprintf("'%s' failed the verification "+
+			"(%d problem(s)).", updateFilePath, len(failures)))))
+	}
+	util.PrintInfo(fmt.Sprintf("'%s' is verified.", updateFilePath))
+}
+
+// This function verifies the signature embedded in the given update zip and appends the problems found to the given
+// failures. Whether the update zip has an embedded signature is returned with the failures.
+func verifyEmbeddedSignature(updateFilePath string, keyring openpgp.EntityList, failures []string) (bool,
+	[]string) {
+	signer, differences, err := util.VerifyEmbeddedGPGSignature(updateFilePath, keyring)
+	if err != nil {
+		return true, append(failures, fmt.Sprintf("embedded signature of '%s' is invalid: %v", updateFilePath,
+			err))
+	}
+	if signer == nil {
+		logger.Debug(fmt.Sprintf("%s does not have an embedded signature", updateFilePath))
+		return false, failures
+	}
+	if len(differences) != 0 {
+		for _, difference := range differences {
+			failures = append(failures, fmt.Sprintf("embedded signature of '%s' does not match the zip: %s",
+				updateFilePath, difference))
+		}
+		return true, failures
+	}
+	util.PrintInfo(fmt.Sprintf("Embedded signature of '%s' is valid. Signed with the GPG key %s.", updateFilePath,
+		util.GetGPGKeyDescription(signer)))
+	return true, failures
+}
+
+// This function validates the update descriptors of the given update zip and checks whether they are consistent with
+// each other and with the root folder of the zip. The problems found are returned.
+func verifyZippedUpdateDescriptors(updateFilePath string) []string {
+	zipReader, err := zip.OpenReader(updateFilePath)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while reading '%s'.", updateFilePath))
+	defer zipReader.Close()
+
+	var failures []string
+	updateName := strings.TrimSuffix(filepath.Base(updateFilePath), constant.ZIP_FILE_EXTENSION)
+	if anomaly := util.CheckRootFolder(&zipReader.Reader, updateName); anomaly != nil {
+		failures = append(failures, fmt.Sprintf("'%s': %s", updateFilePath, anomaly.Error()))
+		if !anomaly.IsNameMismatch() {
+			return failures
+		}
+		// The descriptors are read from the root folder of the zip even if it is not named after the update
+		updateName = anomaly.RootFolders[0]
+	}
+	updateDescriptorV3, err := readZippedUpdateDescriptorV3(&zipReader.Reader, updateName)
+	if err != nil {
+		failures = append(failures, fmt.Sprintf("'%s' is invalid: %v", constant.UPDATE_DESCRIPTOR_V3_FILE, err))
+	} else if updateDescriptorV3 == nil {
+		failures = append(failures, fmt.Sprintf("'%s' is not found in '%s'", constant.UPDATE_DESCRIPTOR_V3_FILE,
+			updateFilePath))
+	} else if err = util.ValidateUpdateDescriptorV3(updateDescriptorV3); err != nil {
+		failures = append(failures, fmt.Sprintf("'%s' is invalid: %v", constant.UPDATE_DESCRIPTOR_V3_FILE, err))
+	}
+	updateDescriptorV2, err := readZippedUpdateDescriptorV2(&zipReader.Reader, updateName)
+	if err != nil {
+		failures = append(failures, fmt.Sprintf("'%s' is invalid: %v", constant.UPDATE_DESCRIPTOR_V2_FILE, err))
+	} else if updateDescriptorV2 != nil {
+		if err = util.ValidateUpdateDescriptorV2(updateDescriptorV2); err != nil {
+			failures = append(failures, fmt.Sprintf("'%s' is invalid: %v", constant.UPDATE_DESCRIPTOR_V2_FILE,
+				err))
+		}
+		if updateDescriptorV3 != nil {
+			for _, divergence := range util.CompareUpdateDescriptors(updateDescriptorV2, updateDescriptorV3) {
+				failures = append(failures, fmt.Sprintf("update descriptors diverge: %s", divergence))
+			}
+		}
+	}
+	return failures
+}
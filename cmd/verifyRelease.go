@@ -0,0 +1,95 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/renstrom/dedent"
+	"github.com/spf13/cobra"
+	"github.com/wso2/update-creator-tool/util"
+)
+
+// Values used to print help command.
+var (
+	verifyReleaseCmdUse       = "verify-release <update_zip> <catalog>"
+	verifyReleaseCmdShortDesc = "Verify a released update against its catalog entry"
+	verifyReleaseCmdLongDesc  = dedent.Dedent(`
+		This command will verify that the given released update still matches its entry in the given
+		catalog. The checksums of the update zip and the details read from its update descriptors
+		(update number, platform, products, changed files and superseded updates) are compared with
+		the catalog entry. The signature of the catalog is verified if a public key is given. Neither
+		the update nor the catalog is modified.`)
+)
+
+// verifyReleaseCmd represents the verify-release command.
+var verifyReleaseCmd = &cobra.Command{
+	Use:   verifyReleaseCmdUse,
+	Short: verifyReleaseCmdShortDesc,
+	Long:  verifyReleaseCmdLongDesc,
+	Run:   initializeVerifyReleaseCommand,
+}
+
+var releaseCatalogPublicKeyPath string
+
+// This function will be called first and this will add flags to the command.
+func init() {
+	RootCmd.AddCommand(verifyReleaseCmd)
+
+	verifyReleaseCmd.Flags().BoolVarP(&isDebugLogsEnabled, "debug", "d", util.EnableDebugLogs, "Enable debug logs")
+	verifyReleaseCmd.Flags().BoolVarP(&isTraceLogsEnabled, "trace", "t", util.EnableTraceLogs, "Enable trace logs")
+	verifyReleaseCmd.Flags().StringVar(&releaseCatalogPublicKeyPath, "catalog-key", "", "RSA public key (PEM) "+
+		"used to verify the signature of the catalog")
+}
+
+// This function will be called when the verify-release command is called.
+func initializeVerifyReleaseCommand(cmd *cobra.Command, args []string) {
+	if len(args) != 2 {
+		util.HandleErrorAndExit(util.NewInputError(errors.New("invalid number of arguments. Run 'wum-uc " +
+			"verify-release --help' to view help")))
+	}
+	setLogLevel()
+	logger.Debug("[verify-release] command called")
+	verifyRelease(args[0], args[1], releaseCatalogPublicKeyPath)
+}
+
+// This function verifies the given released update against its entry in the given catalog.
+func verifyRelease(updateFilePath, catalogPath, publicKeyPath string) {
+	exists, err := util.IsFileExists(updateFilePath)
+	util.HandleErrorAndExit(err, "")
+	if !exists {
+		util.HandleErrorAndExit(util.NewInputError(errors.New(fmt.Sprintf("Entered update file does not exist at '%s'.",
+			updateFilePath))))
+	}
+	catalog := loadCatalog(catalogPath, publicKeyPath)
+	entry, err := util.NewCatalogEntry(updateFilePath)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while reading '%s'.", updateFilePath))
+	mismatches, err := util.VerifyCatalogEntry(catalog, entry)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while verifying '%s'.", updateFilePath))
+	if len(mismatches) != 0 {
+		for _, mismatch := range mismatches {
+			util.PrintError(mismatch)
+		}
+		util.HandleErrorAndExit(util.NewValidationError(errors.New(fmt.Sprintf("'%s' does not match its entry in "+
+			"'%s' (%d mismatch(es)).", entry.UpdateName, catalogPath, len(mismatches)))))
+	}
+	util.PrintInfo(fmt.Sprintf("'%s' matches its entry in '%s'.", entry.UpdateName, catalogPath))
+}
@@ -0,0 +1,214 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/renstrom/dedent"
+	"github.com/spf13/cobra"
+	"github.com/wso2/update-creator-tool/constant"
+	"github.com/wso2/update-creator-tool/util"
+)
+
+// Values used to print help command.
+var (
+	workspaceCmdUse       = "workspace"
+	workspaceCmdShortDesc = "Manage in-progress updates"
+	workspaceCmdLongDesc  = dedent.Dedent(`
+		This command will manage workspaces which keep track of in-progress updates, their
+		distributions, the answers given for the prompts and their validation status. Run
+		'wum-uc create --workspace <name>' to create the update of a workspace.`)
+)
+
+// workspaceCmd represents the workspace command.
+var workspaceCmd = &cobra.Command{
+	Use:   workspaceCmdUse,
+	Short: workspaceCmdShortDesc,
+	Long:  workspaceCmdLongDesc,
+}
+
+var workspaceNewCmd = &cobra.Command{
+	Use:   "new <name> <update_dir> <dist_loc>",
+	Short: "Create a new workspace",
+	Run:   initializeWorkspaceNewCommand,
+}
+
+var workspaceListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all workspaces",
+	Run:   initializeWorkspaceListCommand,
+}
+
+var workspaceStatusCmd = &cobra.Command{
+	Use:   "status <name>",
+	Short: "Show the status of a workspace",
+	Run:   initializeWorkspaceStatusCommand,
+}
+
+var workspaceCleanCmd = &cobra.Command{
+	Use:   "clean <name>",
+	Short: "Remove a workspace",
+	Run:   initializeWorkspaceCleanCommand,
+}
+
+// Name of the workspace used by the create command
+var workspaceName string
+
+// This function will be called first and this will add flags to the command.
+func init() {
+	RootCmd.AddCommand(workspaceCmd)
+	workspaceCmd.AddCommand(workspaceNewCmd, workspaceListCmd, workspaceStatusCmd, workspaceCleanCmd)
+}
+
+// This function will be called when the workspace new command is called.
+func initializeWorkspaceNewCommand(cmd *cobra.Command, args []string) {
+	if len(args) != 3 {
+		util.HandleErrorAndExit(util.NewInputError(errors.New("invalid number of arguments. Run 'wum-uc workspace " +
+			"new --help' to view help")))
+	}
+	updateDirectory, err := filepath.Abs(args[1])
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while getting the absolute path of '%s'.", args[1]))
+	distribution, err := filepath.Abs(args[2])
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while getting the absolute path of '%s'.", args[2]))
+	err = util.CheckDistribution(distribution)
+	util.HandleErrorAndExit(util.NewInputError(err), fmt.Sprintf("Error occurred while reading the %s '%s'.",
+		strings.ToLower(constant.DISTRIBUTION), distribution))
+
+	_, err = util.CreateWorkspace(WUMUCHome, args[0], updateDirectory, distribution)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while creating the workspace '%s'.", args[0]))
+	util.PrintInfo(fmt.Sprintf("Workspace '%s' created. Run 'wum-uc create --workspace %s' to create the "+
+		"update.", args[0], args[0]))
+}
+
+// This function will be called when the workspace list command is called.
+func initializeWorkspaceListCommand(cmd *cobra.Command, args []string) {
+	workspaces, err := util.ListWorkspaces(WUMUCHome)
+	util.HandleErrorAndExit(err, "Error occurred while reading the workspaces.")
+	if len(workspaces) == 0 {
+		util.PrintInfo("No workspaces found. Run 'wum-uc workspace new' to create one.")
+		return
+	}
+	if util.IsJSONIOEnabled() {
+		for _, workspace := range workspaces {
+			util.PrintInfo(fmt.Sprintf("%s %s %s %s", workspace.Name, workspace.ValidationStatus,
+				workspace.UpdateDirectory, workspace.Distribution))
+		}
+		return
+	}
+	workspacesTable := tablewriter.NewWriter(os.Stdout)
+	workspacesTable.SetAlignment(tablewriter.ALIGN_LEFT)
+	workspacesTable.SetHeader([]string{"Name", "Update Directory", "Distribution", "Status", "Updated At"})
+	for _, workspace := range workspaces {
+		workspacesTable.Append([]string{workspace.Name, workspace.UpdateDirectory,
+			filepath.Base(workspace.Distribution), workspace.ValidationStatus, workspace.UpdatedAt})
+	}
+	workspacesTable.Render()
+}
+
+// This function will be called when the workspace status command is called.
+func initializeWorkspaceStatusCommand(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		util.HandleErrorAndExit(util.NewInputError(errors.New("invalid number of arguments. Run 'wum-uc workspace " +
+			"status --help' to view help")))
+	}
+	workspace, err := util.LoadWorkspace(WUMUCHome, args[0])
+	util.HandleErrorAndExit(err)
+	recordedAnswers, err := util.LoadRecordedAnswers(WUMUCHome, workspace.Name)
+	util.HandleErrorAndExit(err)
+
+	util.PrintInBold(fmt.Sprintf("Workspace '%s'", workspace.Name))
+	util.PrintMessage(fmt.Sprintf("\tUpdate directory  : %s", workspace.UpdateDirectory))
+	util.PrintMessage(fmt.Sprintf("\tDistribution      : %s", workspace.Distribution))
+	util.PrintMessage(fmt.Sprintf("\tValidation status : %s", workspace.ValidationStatus))
+	util.PrintMessage(fmt.Sprintf("\tRecorded answers  : %d", len(recordedAnswers.Answers)))
+	util.PrintMessage(fmt.Sprintf("\tCreated at        : %s", workspace.CreatedAt))
+	util.PrintMessage(fmt.Sprintf("\tUpdated at        : %s", workspace.UpdatedAt))
+	if isResumeStateOfWorkspace(workspace) {
+		util.PrintInfo("The update creation of this workspace is pending. Run 'wum-uc create --continue " +
+			"--workspace " + workspace.Name + "' to resume it.")
+	}
+}
+
+// This function will be called when the workspace clean command is called.
+func initializeWorkspaceCleanCommand(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		util.HandleErrorAndExit(util.NewInputError(errors.New("invalid number of arguments. Run 'wum-uc workspace " +
+			"clean --help' to view help")))
+	}
+	workspace, err := util.LoadWorkspace(WUMUCHome, args[0])
+	util.HandleErrorAndExit(err)
+	// Clean the pending update creation of the workspace as well
+	if isResumeStateOfWorkspace(workspace) {
+		util.CleanUpDirectory(constant.TEMP_DIR)
+		util.CleanUpFile(filepath.Join(WUMUCHome, constant.WUMUC_RESUME_FILE))
+	}
+	err = util.RemoveWorkspace(WUMUCHome, workspace.Name)
+	util.HandleErrorAndExit(err, fmt.Sprintf("Error occurred while removing the workspace '%s'.", workspace.Name))
+	util.PrintInfo(fmt.Sprintf("Workspace '%s' removed.", workspace.Name))
+}
+
+// This function checks whether the current resume state belongs to the given workspace.
+func isResumeStateOfWorkspace(workspace *util.Workspace) bool {
+	wumucResumeFilePath := filepath.Join(WUMUCHome, constant.WUMUC_RESUME_FILE)
+	exists, err := util.IsFileExists(wumucResumeFilePath)
+	if err != nil || !exists {
+		return false
+	}
+	resumeFile := readResumeFile(wumucResumeFilePath)
+	return getUpdateDirectoryPath(&resumeFile) == workspace.UpdateDirectory
+}
+
+// This function initializes the create command for the workspace given with the --workspace flag. Answers given for
+// the prompts are recorded in the workspace. The update directory and the distribution of the workspace are returned
+// as the arguments of the create command if no arguments are given.
+func initializeWorkspace(args []string) []string {
+	if len(workspaceName) == 0 {
+		return args
+	}
+	workspace, err := util.LoadWorkspace(WUMUCHome, workspaceName)
+	util.HandleErrorAndExit(err)
+	util.SetAnswerRecorder(func(prompt, answer string) {
+		if err := util.RecordAnswer(WUMUCHome, workspaceName, prompt, answer); err != nil {
+			logger.Debug(fmt.Sprintf("%v error occurred while recording the answer in workspace '%s'", err,
+				workspaceName))
+		}
+	})
+	if len(args) == 0 && !isContinueEnabled {
+		return []string{workspace.UpdateDirectory, workspace.Distribution}
+	}
+	return args
+}
+
+// This function sets the validation status of the workspace given with the --workspace flag.
+func setWorkspaceStatus(status string) {
+	if len(workspaceName) == 0 {
+		return
+	}
+	if err := util.SetWorkspaceStatus(WUMUCHome, workspaceName, status); err != nil {
+		logger.Error(fmt.Sprintf("%v error occurred while updating the status of workspace '%s'", err,
+			workspaceName))
+	}
+}
@@ -25,6 +25,10 @@ const (
 
 	PATH_SEPARATOR    = string(os.PathSeparator)
 	PLUGINS_DIRECTORY = "repository" + PATH_SEPARATOR + "components" + PATH_SEPARATOR + "plugins" + PATH_SEPARATOR
+	// Paths longer than this cannot be created in Windows unless they are given with the extended-length prefix
+	WINDOWS_MAX_PATH_LENGTH      = 248
+	WINDOWS_LONG_PATH_PREFIX     = `\\?\`
+	WINDOWS_UNC_LONG_PATH_PREFIX = `\\?\UNC\`
 
 	//constants to store resource file names
 	README_FILE               = "README.txt"
@@ -39,18 +43,24 @@ const (
 	TEMP_DIR = "temp"
 	//This is used to store carbon.home string
 	CARBON_HOME = "carbon.home"
+	//Update descriptor which is saved to the temp directory while matching the files, to resume an aborted session
+	PARTIAL_UPDATE_DESCRIPTOR_FILE = "partial-update-descriptor.yaml"
 	//Prefix of the update file and the root directory of the update zip
 	UPDATE_NAME_PREFIX = "WSO2-CARBON-UPDATE"
+	//Permissions which are added to the executable files of the update
+	EXECUTE_PERMISSIONS = 0111
 
 	//Constants to store configs in viper
 	DISTRIBUTION_ROOT = "DISTRIBUTION_ROOT"
 	UPDATE_ROOT       = "UPDATE_ROOT"
 	UPDATE_NAME       = "_UPDATE_NAME"
 	PRODUCT_NAME      = "_PRODUCT_NAME"
+	PAYLOAD_DIRECTORY = "_PAYLOAD_DIRECTORY"
 
 	UPDATE_NUMBER_REGEX  = "^\\d{4}$"
 	KERNEL_VERSION_REGEX = "^\\d+\\.\\d+\\.\\d+$"
-	FILENAME_REGEX       = "^WSO2-CARBON-UPDATE-\\d+\\.\\d+\\.\\d+-\\d{4}.zip$"
+	FILENAME_REGEX       = "^WSO2-CARBON-UPDATE-\\d+\\.\\d+\\.\\d+-([^.]+)\\.zip$"
+	UPDATE_NAME_REGEX    = "^WSO2-CARBON-UPDATE-\\d+\\.\\d+\\.\\d+-[^./\\\\]+$"
 	EMAIL_ADDRESS_REGEX  = "^[a-zA-Z0-9.!#$%&'*+/=?^_`{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$"
 
 	OTHER   = 0
@@ -59,6 +69,34 @@ const (
 	REENTER = 3
 
 	CHECK_MD5_DISABLED = "CHECK_MD5_DISABLED"
+	//warnings are treated as errors in strict mode
+	STRICT_MODE = "STRICT_MODE"
+	//steps which depend on unreachable online services are run in degraded mode unless online services are required
+	REQUIRE_ONLINE             = "REQUIRE_ONLINE"
+	ONLINE_SERVICE_JIRA        = "JIRA"
+	ONLINE_SERVICE_WUM         = "WUM"
+	ONLINE_SERVICE_WUMUC_ADMIN = "wum-uc admin service"
+	//messages and prompts are exchanged as JSON objects in JSON IO mode
+	JSON_IO          = "JSON_IO"
+	JSON_IO_PROMPT   = "prompt"
+	JSON_IO_PASSWORD = "password"
+	JSON_IO_MESSAGE  = "message"
+	JSON_IO_INFO     = "info"
+	JSON_IO_WARNING  = "warning"
+	JSON_IO_ERROR    = "error"
+	//summary of the warnings raised while running a command
+	JSON_IO_WARNING_SUMMARY = "warning-summary"
+	//time spent in each stage of a command
+	JSON_IO_STAGE_TIMINGS  = "stage-timings"
+	WARNING_GROUP_GENERAL  = "general"
+	WARNING_GROUP_POLICY   = "policy"
+	WARNING_GROUP_DEGRADED = "degraded mode"
+	//nested archives in the distribution (wars, cars, etc)
+	NESTED_ARCHIVES          = "NESTED_ARCHIVES"
+	DESCEND                  = "DESCEND"
+	PATTERNS                 = "PATTERNS"
+	NESTED_ARCHIVES_DESCEND  = NESTED_ARCHIVES + "." + DESCEND
+	NESTED_ARCHIVES_PATTERNS = NESTED_ARCHIVES + "." + PATTERNS
 	//resource_files
 	RESOURCE_FILES           = "RESOURCE_FILES"
 	MANDATORY                = "MANDATORY"
@@ -67,14 +105,390 @@ const (
 	RESOURCE_FILES_MANDATORY = RESOURCE_FILES + "." + MANDATORY
 	RESOURCE_FILES_OPTIONAL  = RESOURCE_FILES + "." + OPTIONAL
 	RESOURCE_FILES_SKIP      = RESOURCE_FILES + "." + SKIP
+	//resource files of product families which are added to the above resource files
+	RESOURCE_FILES_PRODUCT_FAMILIES = RESOURCE_FILES + ".PRODUCT_FAMILIES"
+	//payload directories of the product lines which do not use carbon.home, against their product families
+	PAYLOAD_DIRECTORIES = "PAYLOAD_DIRECTORIES"
+	//version of a distribution name (eg: -2.1.0 of wso2am-2.1.0)
+	DISTRIBUTION_VERSION_SUFFIX_REGEX = "-\\d.*$"
+	//files which are ignored when matching and files which are excluded from update zips. Commands can override them
+	//in their own sections (eg: IGNORED_FILES.CREATE.MATCHING)
+	IGNORED_FILES          = "IGNORED_FILES"
+	MATCHING               = "MATCHING"
+	ZIP                    = "ZIP"
+	IGNORED_FILES_MATCHING = IGNORED_FILES + "." + MATCHING
+	IGNORED_FILES_ZIP      = IGNORED_FILES + "." + ZIP
+	IGNORED_FILES_CREATE   = "CREATE"
+	IGNORED_FILES_VALIDATE = "VALIDATE"
 
 	PLATFORM_VERSIONS = "PLATFORM_VERSIONS"
 
+	//external validators which are run against the update manifest
+	VALIDATORS = "VALIDATORS"
+	//rego policies which are evaluated over the update manifest
+	OPA            = "OPA"
+	OPA_EXECUTABLE = OPA + ".EXECUTABLE"
+	OPA_POLICIES   = OPA + ".POLICIES"
+	OPA_QUERY      = OPA + ".QUERY"
+	//thresholds of the size and the scope of updates
+	GUARDRAILS                           = "GUARDRAILS"
+	GUARDRAILS_MAX_CHANGED_FILES         = GUARDRAILS + ".MAX_CHANGED_FILES"
+	GUARDRAILS_MAX_PAYLOAD_SIZE          = GUARDRAILS + ".MAX_PAYLOAD_SIZE"
+	GUARDRAILS_MAX_DISTRIBUTION_FRACTION = GUARDRAILS + ".MAX_DISTRIBUTION_FRACTION"
+	GUARDRAILS_ACTION                    = GUARDRAILS + ".ACTION"
+	GUARDRAILS_ACTION_WARN               = "warn"
+	GUARDRAILS_ACTION_FAIL               = "fail"
+	//maximum sizes of the update zips imposed by the delivery infrastructure
+	SIZE_BUDGETS                 = "SIZE_BUDGETS"
+	SIZE_BUDGETS_MAX_UPDATE_SIZE = SIZE_BUDGETS + ".MAX_UPDATE_SIZE"
+	SIZE_BUDGETS_PLATFORMS       = SIZE_BUDGETS + ".PLATFORMS"
+	SIZE_BUDGETS_PRODUCTS        = SIZE_BUDGETS + ".PRODUCTS"
+	//catalog of released updates which updates are checked against
+	UPDATE_CATALOG          = "UPDATE_CATALOG"
+	UPDATE_CATALOG_LOCATION = UPDATE_CATALOG + ".LOCATION"
+	UPDATE_CATALOG_KEY      = UPDATE_CATALOG + ".KEY"
+	//checksum algorithms of the artifacts in the order of preference, and the algorithm declared by new updates
+	UPDATE_CATALOG_CHECKSUM_ALGORITHMS = UPDATE_CATALOG + ".CHECKSUM_ALGORITHMS"
+	CHECKSUM_ALGORITHM                 = "CHECKSUM_ALGORITHM"
+	CHECKSUM_ALGORITHM_MD5             = "md5"
+	CHECKSUM_ALGORITHM_SHA256          = "sha256"
+	//checksum algorithm used to compare the files of updates with the files of distributions
+	HASH_ALGORITHM = "HASH_ALGORITHM"
+	//number of worker goroutines used to check the files of updates when validating
+	VALIDATION_WORKERS = "VALIDATION.WORKERS"
+	//number of worker goroutines used to hash the files of distributions when reading them
+	DISTRIBUTION_WORKERS = "DISTRIBUTION.WORKERS"
+	//maximum memory (in MB) used to buffer a nested archive of a distribution, larger archives are buffered in files
+	DISTRIBUTION_MAX_MEMORY = "DISTRIBUTION.MAX_MEMORY"
+	//events emitted during the update creation
+	EVENTS          = "EVENTS"
+	EVENTS_CONSOLE  = EVENTS + ".CONSOLE"
+	EVENTS_FILE     = EVENTS + ".FILE"
+	EVENTS_WEBHOOKS = EVENTS + ".WEBHOOKS"
+
+	EVENT_STAGE_STARTED              = "stage-started"
+	EVENT_STAGE_FINISHED             = "stage-finished"
+	EVENT_FILE_MATCHED               = "file-matched"
+	EVENT_FILE_COPIED                = "file-copied"
+	EVENT_WARNING                    = "warning"
+	EVENT_WEBHOOK_TIMEOUT_IN_SECONDS = 5
+
+	STAGE_READ_UPDATE        = "read-update"
+	STAGE_READ_DISTRIBUTION  = "read-distribution"
+	STAGE_MATCH_FILES        = "match-files"
+	STAGE_CREATE_DESCRIPTORS = "create-descriptors"
+	STAGE_CREATE_ZIP         = "create-zip"
+	STAGE_VALIDATE_ZIP       = "validate-zip"
+	STAGE_COMMIT             = "commit"
+	STAGE_COPY_FILES         = "copy-files"
+	STAGE_VALIDATE_UPDATE    = "validate-update"
+
+	//review
+	REVIEW_DEFAULT_ADDRESS    = "localhost:9090"
+	TEXT_CONTENT_SNIFF_LENGTH = 8000
+	MAX_DIFF_LINES            = 5000
+
+	//class level diffs of jars
+	JAR_FILE_EXTENSION   = ".jar"
+	CLASS_FILE_EXTENSION = ".class"
+	JAR_MANIFEST_FILE    = "META-INF/MANIFEST.MF"
+
+	//batch
+	BATCH_STATUS_PASSED = "PASSED"
+	BATCH_STATUS_FAILED = "FAILED"
+
+	//binary deltas of large modified files
+	BINARY_DELTA          = "BINARY_DELTA"
+	BINARY_DELTA_ENABLED  = BINARY_DELTA + ".ENABLED"
+	BINARY_DELTA_MIN_SIZE = BINARY_DELTA + ".MIN_SIZE"
+	BINARY_DELTA_PATTERNS = BINARY_DELTA + ".PATTERNS"
+
+	BINARY_DELTA_ALGORITHM           = "wumuc-delta-1"
+	BINARY_DELTA_MAGIC               = "WUMUCDELTA1\n"
+	BINARY_DELTA_EXTENSION           = ".wumucdelta"
+	BINARY_DELTA_BLOCK_SIZE          = 64
+	BINARY_DELTA_ORIGINALS_DIRECTORY = "binary-delta-originals"
+
+	//malware scan of the payload of updates before zipping
+	SCAN          = "SCAN"
+	SCAN_ENABLED  = SCAN + ".ENABLED"
+	SCAN_COMMAND  = SCAN + ".COMMAND"
+	SCAN_ICAP_URL = SCAN + ".ICAP_URL"
+
+	//scan of the payload of updates for secrets (keys, passwords, tokens)
+	SECRET_SCAN                = "SECRET_SCAN"
+	SECRET_SCAN_ENABLED        = SECRET_SCAN + ".ENABLED"
+	SECRET_SCAN_PATTERNS       = SECRET_SCAN + ".PATTERNS"
+	SECRET_SCAN_ALLOWED_VALUES = SECRET_SCAN + ".ALLOWED_VALUES"
+
+	SCAN_VERDICT_CLEAN      = "clean"
+	SCAN_VERDICT_INFECTED   = "infected"
+	ICAP_DEFAULT_PORT       = 1344
+	ICAP_TIMEOUT_IN_SECONDS = 60
+
+	//debug log files of the sessions
+	LOGS                 = "LOGS"
+	LOGS_ENABLED         = LOGS + ".ENABLED"
+	LOGS_LEVEL           = LOGS + ".LEVEL"
+	LOGS_MAX_FILE_SIZE   = LOGS + ".MAX_FILE_SIZE"
+	LOGS_RETENTION       = LOGS + ".RETENTION"
+	WUMUC_LOGS_DIRECTORY = "logs"
+	LOG_FILE_PREFIX      = "wum-uc-"
+	LOG_FILE_EXTENSION   = ".log"
+	LOG_FILE_TIME_FORMAT = "20060102-150405"
+	LOG_FILE_LAYOUT      = "%d [%p] %m"
+	BYTES_PER_MEGA_BYTE  = 1048576
+
+	//template store synced from a Git repository, with the descriptor template, the policies and the destination
+	//mappings shared by the team
+	TEMPLATES                            = "TEMPLATES"
+	TEMPLATES_REPOSITORY                 = TEMPLATES + ".REPOSITORY"
+	TEMPLATES_BRANCH                     = TEMPLATES + ".BRANCH"
+	WUMUC_TEMPLATES_DIRECTORY            = "templates"
+	TEMPLATE_STORE_DESCRIPTOR_TEMPLATE   = "update-descriptor3.yaml"
+	TEMPLATE_STORE_POLICIES_DIRECTORY    = "policies"
+	TEMPLATE_STORE_DESTINATIONS_FILE     = "destinations.yaml"
+	TEMPLATE_STORE_POLICY_FILE_EXTENSION = ".rego"
+	GIT_COMMAND                          = "git"
+	GIT_DIRECTORY                        = ".git"
+
+	//exit codes of wum-uc, which scripts can use to decide how to proceed
+	EXIT_CODE_SUCCESS            = 0
+	EXIT_CODE_INTERNAL_ERROR     = 1
+	EXIT_CODE_INPUT_ERROR        = 2
+	EXIT_CODE_VALIDATION_FAILURE = 3
+	EXIT_CODE_USER_ABORT         = 4
+	EXIT_CODE_NETWORK_ERROR      = 5
+
+	//provenance attestations of the created updates
+	PROVENANCE             = "PROVENANCE"
+	PROVENANCE_ENABLED     = PROVENANCE + ".ENABLED"
+	PROVENANCE_SIGNING_KEY = PROVENANCE + ".SIGNING_KEY"
+
+	PROVENANCE_FILE_EXTENSION = ".intoto.jsonl"
+	IN_TOTO_STATEMENT_TYPE    = "https://in-toto.io/Statement/v0.1"
+	IN_TOTO_PAYLOAD_TYPE      = "application/vnd.in-toto+json"
+	SLSA_PROVENANCE_TYPE      = "https://slsa.dev/provenance/v0.2"
+	PROVENANCE_BUILDER_ID     = "https://github.com/wso2/update-creator-tool/wum-uc"
+	PROVENANCE_BUILD_TYPE     = "https://github.com/wso2/update-creator-tool/create@v1"
+	PROVENANCE_DIGEST_SHA256  = "sha256"
+
+	//GPG signatures of the updates, WUM_UC_GPG_PASSPHRASE environment variable holds the passphrase of the key
+	GPG                     = "GPG"
+	GPG_KEYRING             = GPG + ".KEYRING"
+	GPG_KEY_ID              = GPG + ".KEY_ID"
+	WUM_UC_GPG_PASSPHRASE   = "WUM_UC_GPG_PASSPHRASE"
+	GPG_SIGNATURE_EXTENSION = ".asc"
+	EMBEDDED_SIGNATURE_FILE = "SIGNATURE.asc"
+	EMBEDDED_MANIFEST_FILE  = "MANIFEST.sha256"
+
+	//files of the update directory which are not copied to the update
+	SKIPPED_FILES_MANIFEST_SUFFIX = "-skipped-files.yaml"
+	SKIP_REASON_USER_DECLINED     = "declined-by-user"
+	SKIP_REASON_MD5_MATCHES       = "md5-matches"
+
+	//instructions generated for the updates which change config files
+	INSTRUCTIONS                      = "INSTRUCTIONS"
+	INSTRUCTIONS_CONFIG_PREFIXES      = INSTRUCTIONS + ".CONFIG_PREFIXES"
+	INSTRUCTIONS_SKELETON_PLACEHOLDER = "<Describe how to apply the configuration changes and remove this line>"
+
+	//formats of the update archive
+	UPDATE_FORMAT          = "UPDATE_FORMAT"
+	UPDATE_FORMAT_ZIP      = "zip"
+	UPDATE_FORMAT_TAR_ZST  = "tar.zst"
+	ZIP_FILE_EXTENSION     = ".zip"
+	TAR_ZST_FILE_EXTENSION = ".tar.zst"
+	TAR_GZ_FILE_EXTENSION  = ".tar.gz"
+	TGZ_FILE_EXTENSION     = ".tgz"
+
+	//paths declared as non-updatable by the distribution
+	WUM_IGNORE_FILE = "wum-ignore"
+
+	//sync
+	PARTIAL_FILE_EXTENSION = ".part"
+
+	//storage backends of the artifacts referred by URIs
+	STORAGE_SCHEME_FILE        = "file"
+	STORAGE_SCHEME_HTTP        = "http"
+	STORAGE_SCHEME_HTTPS       = "https"
+	STORAGE_SCHEME_S3          = "s3"
+	STORAGE_SCHEME_GS          = "gs"
+	STORAGE_SCHEME_AZBLOB      = "azblob"
+	STORAGE_SCHEME_ARTIFACTORY = "artifactory"
+	S3_BUCKET_URL_FORMAT       = "https://%s.s3.amazonaws.com/%s"
+	GS_BUCKET_URL_FORMAT       = "https://storage.googleapis.com/%s/%s"
+	AZBLOB_URL_FORMAT          = "https://%s.blob.core.windows.net/%s"
+	AZURE_STORAGE_SAS_TOKEN    = "AZURE_STORAGE_SAS_TOKEN"
+	HEADER_AZURE_BLOB_TYPE     = "x-ms-blob-type"
+	AZURE_BLOB_TYPE_BLOCK_BLOB = "BlockBlob"
+	ARTIFACTORY_CONTEXT        = "/artifactory"
+	WUMUC_ARTIFACTS_DIRECTORY  = "artifacts"
+	//locations to which the created updates are uploaded after committing them to the SVN
+	PUBLISH_LOCATIONS = "PUBLISH_LOCATIONS"
+
+	//chunked downloads
+	DOWNLOAD                        = "DOWNLOAD"
+	DOWNLOAD_CHUNK_SIZE             = DOWNLOAD + ".CHUNK_SIZE"
+	DOWNLOAD_RETRIES                = DOWNLOAD + ".RETRIES"
+	DOWNLOAD_RETRY_DELAY_IN_SECONDS = 2
+	CHUNK_MANIFEST_EXTENSION        = ".chunks"
+
+	//encrypted updates
+	ENCRYPTION_RECIPIENTS    = "ENCRYPTION.RECIPIENTS"
+	ENCRYPTED_FILE_EXTENSION = ".gpg"
+
+	//records of the validated updates which respins are compared against
+	WUMUC_VALIDATION_LEDGER_DIRECTORY = "validated-updates"
+	// maximum size of a resource file of which the content is recorded to show its diff in a respin
+	MAX_RESPIN_RESOURCE_SIZE = 1024 * 1024
+
+	//configuration bundles
+	CONFIG_BUNDLE_CONFIG_FILE     = "config.yaml"
+	CONFIG_BUNDLE_FILES_DIRECTORY = "files"
+	// keys of the config which refer to the files included in a bundle
+	CONFIG_BUNDLE_FILE_KEYS = OPA_POLICIES + "," + ENCRYPTION_RECIPIENTS + "," + UPDATE_CATALOG_KEY
+	// keys of the config which hold credentials, which are not included in a bundle
+	CONFIG_BUNDLE_SECRET_KEYS           = "AccessToken,RefreshToken"
+	WUMUC_CONFIG_BUNDLE_FILES_DIRECTORY = "config-bundle"
+	BACKUP_FILE_EXTENSION               = ".bak"
+
+	//workspaces
+	WUMUC_WORKSPACES_DIRECTORY = "workspaces"
+	WORKSPACE_FILE             = "workspace.yaml"
+	WORKSPACE_ANSWERS_FILE     = "answers.yaml"
+	WORKSPACE_NAME_REGEX       = "^[a-zA-Z0-9][a-zA-Z0-9._-]*$"
+	WORKSPACE_STATUS_NEW       = "new"
+	WORKSPACE_STATUS_PENDING   = "pending-packaging"
+	WORKSPACE_STATUS_VALIDATED = "validated"
+	WORKSPACE_STATUS_COMMITTED = "committed"
+
+	//hash chained audit log of the operations on updates
+	WUMUC_AUDIT_LOG_FILE     = "audit.log"
+	WUMUC_AUDIT_KEY_FILE     = "audit.key"
+	AUDIT_LOG_HEAD_EXTENSION = ".head"
+	AUDIT_LOG_GENESIS_HASH   = "0000000000000000000000000000000000000000000000000000000000000000"
+	AUDIT_KEY_SIZE           = 32
+	AUDIT                    = "AUDIT"
+	AUDIT_KEY                = AUDIT + ".KEY"
+	AUDIT_OPERATION_CREATE   = "create"
+	AUDIT_OPERATION_SIGN     = "sign"
+	AUDIT_OPERATION_PUBLISH  = "publish"
+	AUDIT_OPERATION_ROLLUP   = "rollup"
+	AUDIT_OPERATION_APPROVE  = "approve"
+
+	//approvals of the reviewers which are required to publish updates
+	APPROVALS                = "APPROVALS"
+	APPROVALS_REQUIRED       = APPROVALS + ".REQUIRED"
+	APPROVALS_REVIEWER_KEYS  = APPROVALS + ".REVIEWER_KEYS"
+	APPROVALS_FILE_EXTENSION = ".approvals"
+
+	//stale files removed by the clean command
+	CLEAN_CACHE           = "cache"
+	CLEAN_TEMP            = "temp"
+	CLEAN_LOGS            = "logs"
+	CLEAN_WORKSPACES      = "workspaces"
+	TEMP_DIRECTORY_PREFIX = "wum-uc"
+	HOURS_PER_DAY         = 24
+
+	//locale of the messages shown to the user, WUM_UC_LOCALE environment variable overrides the configured locale
+	LOCALE                   = "LOCALE"
+	WUM_UC_LOCALE            = "WUM_UC_LOCALE"
+	WUMUC_MESSAGES_DIRECTORY = "messages"
+
+	//distributions re-rooted or converted to zips during the update creation
+	WUMUC_REROOTED_DISTRIBUTIONS_DIRECTORY = "distributions"
+
+	//update directories and distributions are not modified in the read only mode, files generated for the update
+	//are written to the resources directory in the wum-uc home instead
+	READ_ONLY_INPUTS          = "READ_ONLY_INPUTS"
+	WUMUC_RESOURCES_DIRECTORY = "resources"
+
+	//parts of the update which can be extracted
+	EXTRACT_DESCRIPTOR = "descriptor"
+	EXTRACT_PAYLOAD    = "payload"
+	EXTRACT_RESOURCES  = "resources"
+
+	//operating systems of the OS specific payload files
+	OS_NAMES = "aix,darwin,freebsd,linux,solaris,windows"
+
+	//classifications of the files listed in an update
+	FILE_CLASSIFICATION_ADDED    = "added"
+	FILE_CLASSIFICATION_MODIFIED = "modified"
+	FILE_CLASSIFICATION_REMOVED  = "removed"
+	FILE_CLASSIFICATION_UNLISTED = "unlisted"
+
+	//formats of the listed files of an update and the distribution tree
+	OUTPUT_FORMAT_TABLE = "table"
+	OUTPUT_FORMAT_JSON  = "json"
+	OUTPUT_FORMAT_CSV   = "csv"
+	OUTPUT_FORMAT_TREE  = "tree"
+	OUTPUT_FORMAT_TEXT  = "text"
+	OUTPUT_FORMAT_JUNIT = "junit"
+
+	//statuses of the validation report of an update and the files of the update
+	VALIDATION_STATUS_PASSED = "passed"
+	VALIDATION_STATUS_FAILED = "failed"
+	FILE_STATUS_OK           = "ok"
+	FILE_STATUS_WARNING      = "warning"
+	FILE_STATUS_ERROR        = "error"
+
+	//policies for the locally customized files when an update is applied or simulated
+	CUSTOMIZATION_POLICY_KEEP      = "keep-custom"
+	CUSTOMIZATION_POLICY_OVERWRITE = "overwrite"
+	CUSTOMIZATION_POLICY_MERGE     = "merge"
+	CUSTOMIZATION_MERGE_EXTENSION  = ".update"
+
+	//backups of the files replaced by the updates applied to a distribution, relative to the distribution
+	APPLIED_UPDATES_BACKUP_DIRECTORY = "updates/wum-uc-backups"
+	BACKUP_MANIFEST_FILE             = "backup-manifest.yaml"
+	BACKUP_FILES_DIRECTORY           = "files"
+	ROLLBACK_ZIP_SUFFIX              = "-rollback.zip"
+	ROLLBACK_DESCRIPTOR_FILE         = "rollback-descriptor.yaml"
+
+	//journal of the updates applied transactionally, relative to the backup directory of the update
+	APPLY_JOURNAL_FILE       = "apply-journal.yaml"
+	APPLY_STAGING_DIRECTORY  = "staging"
+	APPLY_REPLACED_DIRECTORY = "replaced"
+	APPLY_STATE_STAGING      = "staging"
+	APPLY_STATE_COMMITTING   = "committing"
+
+	//catalog
+	CATALOG_FILE                = "catalog.yaml"
+	CATALOG_SIGNATURE_EXTENSION = ".sig"
+
+	//channels to which the updates are published
+	CHANNEL_RELEASE = "release"
+	CHANNEL_PREVIEW = "preview"
+	// layout of the expiry date of a preview update
+	PREVIEW_EXPIRY_DATE_LAYOUT = "2006-01-02"
+
+	//update_number
+	UPDATE_NUMBER                  = "UPDATE_NUMBER"
+	UPDATE_NUMBER_PATTERN          = UPDATE_NUMBER + ".PATTERN"
+	UPDATE_NUMBER_MIN              = UPDATE_NUMBER + ".MIN"
+	UPDATE_NUMBER_MAX              = UPDATE_NUMBER + ".MAX"
+	UPDATE_NUMBER_UNIQUENESS_CHECK = UPDATE_NUMBER + ".UNIQUENESS_CHECK"
+	UNIQUENESS_CHECK_LEDGER        = "ledger"
+	UNIQUENESS_CHECK_API           = "api"
+	//reservations of update numbers in WUM which are consumed by 'wum-uc create --auto-number'
+	UPDATE_NUMBER_RESERVATION_VALIDITY    = UPDATE_NUMBER + ".RESERVATION_VALIDITY"
+	UPDATE_NUMBER_RESERVATIONS_API        = "reservations"
+	WUMUC_UPDATE_NUMBER_RESERVATIONS_FILE = "update-number-reservations.yaml"
+
 	PATCH_ID_REGEX         = "WSO2-CARBON-PATCH-(\\d+\\.\\d+\\.\\d+)-(\\d{4})"
 	APPLIES_TO_REGEX       = "(?s)Applies To.*?:(.*)Associated JIRA|Applies To.*?:(.*)DESCRIPTION"
 	ASSOCIATED_JIRAS_REGEX = "https:\\/\\/wso2\\.org\\/jira\\/browse\\/([A-Z]*?-\\d+)"
 	DESCRIPTION_REGEX      = "(?s)DESCRIPTION\n-*\n(.*)INSTALLATION INSTRUCTIONS"
 
+	PLACEHOLDER_REGEX = "\\$\\{([a-zA-Z0-9._-]+)\\}"
+	// placeholders of environment variables in the descriptor fields (eg: ${env:BUILD_NUMBER})
+	ENV_PLACEHOLDER_REGEX = "\\$\\{env:([a-zA-Z_][a-zA-Z0-9_]*)\\}"
+
+	SECURITY_ADVISORY_REGEX             = "(?m)^Security Advisory\\s*:\\s*(WSO2-\\d{4}-\\d+)"
+	SECURITY_ADVISORY_UPDATE_REGEX      = "WSO2-CARBON-(?:PATCH|UPDATE)-(\\d+\\.\\d+\\.\\d+)-(\\d{4})"
+	SECURITY_ADVISORY_PRODUCTS_REGEX    = "(?m)^Affected Products\\s*:(.*)$"
+	SECURITY_ADVISORY_DESCRIPTION_REGEX = "(?s)DESCRIPTION\n-*\n(.*?)(?:IMPACT|SOLUTION)"
+
 	PATCH_REGEX = "(?m).*patch.*"
 
 	JIRA_API_URL = "https://wso2.org/jira/rest/api/latest/issue/"
@@ -93,12 +507,18 @@ const (
 	NOT_A_CONTRIBUTION_MD5          = "NOT_A_CONTRIBUTION_MD5"
 	NOT_A_CONTRIBUTION_MD5_URL      = "https://wso2.com/license/wso2-update/NOT_A_CONTRIBUTION.txt.md5"
 
+	//legal resource files of updates
+	LEGAL_FILES                      = "LEGAL_FILES"
+	LEGAL_FILES_LICENSE_URL          = LEGAL_FILES + ".LICENSE_URL"
+	LEGAL_FILES_SECURITY_LICENSE_URL = LEGAL_FILES + ".SECURITY_LICENSE_URL"
+
 	WUMUC_HOME_DIR_NAME                   = ".wum-uc"
 	WUM_UC_HOME                           = "WUM_UC_HOME"
 	WUMUC_RESUME_FILE                     = ".wum-uc-resume.yaml"
 	WUMUC_CACHE_DIRECTORY                 = ".cache"
 	WUMUC_UPDATE_CHECK_TIMESTAMP_FILENAME = "wum-uc-update"
 	WUMUC_UPDATE_CHECK_INTERVAL_IN_HOURS  = 24
+	WUMUC_UPDATE_NUMBER_LEDGER_FILE       = "update-number-ledger.yaml"
 
 	WUMUC_ADMIN_BASIC_AUTH_USERNAME        = "admin"
 	WUMUC_ADMIN_BASIC_AUTH_PASSWORD        = ""
@@ -120,8 +540,11 @@ const (
 	DONE_MSG                               = "Done!\n"
 	INVALID_EMAIL_ADDRESS                  = "Invalid email address"
 
-	FILES_API_CONTEXT   = "files"
-	DEFAULT_DESCRIPTION = `Description goes here
+	FILES_API_CONTEXT    = "files"
+	UPDATES_API_CONTEXT  = "updates"
+	PRODUCTS_API_CONTEXT = "products"
+	DEFAULT_CHANNEL      = "full"
+	DEFAULT_DESCRIPTION  = `Description goes here
 `
 
 	DEFAULT_INSTRUCTIONS = `Instructions goes here
@@ -140,11 +563,12 @@ const (
 	YOU_HAVENT_INITIALIZED_WUMUC_YET_MSG = "you haven't initialized wum-uc with your WSO2 credentials"
 	RUN_WUMUC_INIT_TO_CONTINUE_MSG       = "run 'wum-uc init' to continue"
 
-	HEADER_AUTHORIZATION               = "Authorization"
-	HEADER_CONTENT_TYPE                = "Content-Type"
-	HEADER_ACCEPT                      = "Accept"
-	HEADER_VALUE_APPLICATION_JSON      = "application/json"
-	HEADER_VALUE_X_WWW_FORM_URLENCODED = "application/x-www-form-urlencoded"
+	HEADER_AUTHORIZATION                  = "Authorization"
+	HEADER_CONTENT_TYPE                   = "Content-Type"
+	HEADER_ACCEPT                         = "Accept"
+	HEADER_VALUE_APPLICATION_JSON         = "application/json"
+	HEADER_VALUE_X_WWW_FORM_URLENCODED    = "application/x-www-form-urlencoded"
+	HEADER_VALUE_APPLICATION_OCTET_STREAM = "application/octet-stream"
 
 	SVN_UPDATE_REPO      = "https://svn.wso2.com/wso2/custom/projects/projects/carbon/"
 	SVN_COMMAND          = "svn"
@@ -154,6 +578,7 @@ const (
 	MOVE_COMMAND         = "move"
 	ADD_COMMAND          = "add"
 	SVN_UPDATES          = "updates"
+	SVN_PREVIEW_UPDATES  = "preview-updates"
 	SVN_UPDATE           = "update"
 	LIST_COMMAND         = "ls"
 	COMMIT_OPTION        = "-m"
@@ -0,0 +1,163 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/wso2/update-creator-tool/constant"
+)
+
+// struct which is used to store the changes of a released update which is virtually applied to a distribution
+type AppliedUpdate struct {
+	UpdateName      string
+	UpdateNumber    string
+	PlatformVersion string
+	// Checksums of the added and modified files. Keys are the paths of the files relative to carbon.home.
+	Files        map[string]string
+	RemovedFiles []string
+}
+
+// Read the released update zips in the given directory which are prior to the given update of the given platform
+// version. Updates are sorted by their update numbers, so they can be applied in the order they were released.
+// Checksums of the files are calculated using the given algorithm.
+func ReadAppliedUpdates(directory, platformVersion, updateNumber, algorithm string) ([]AppliedUpdate, error) {
+	updateZipPaths, err := filepath.Glob(filepath.Join(directory, "*"+constant.ZIP_FILE_EXTENSION))
+	if err != nil {
+		return nil, err
+	}
+	var appliedUpdates []AppliedUpdate
+	for _, updateZipPath := range updateZipPaths {
+		appliedUpdate, err := ReadAppliedUpdate(updateZipPath, algorithm)
+		if err != nil {
+			return nil, err
+		}
+		if appliedUpdate.PlatformVersion != platformVersion ||
+			compareUpdateNumbers(appliedUpdate.UpdateNumber, updateNumber) >= 0 {
+			logger.Debug(fmt.Sprintf("Skipping %s as it is not a prior update of %s-%s",
+				appliedUpdate.UpdateName, platformVersion, updateNumber))
+			continue
+		}
+		appliedUpdates = append(appliedUpdates, *appliedUpdate)
+	}
+	sort.Slice(appliedUpdates, func(i, j int) bool {
+		return compareUpdateNumbers(appliedUpdates[i].UpdateNumber, appliedUpdates[j].UpdateNumber) < 0
+	})
+	return appliedUpdates, nil
+}
+
+// Read the changes of the released update zip at the given location. Checksums of the files are calculated using the
+// given algorithm. MD5 sums of the files which are stored as binary deltas are taken from the update-descriptor3.yaml
+// as the deltas cannot be applied without the distribution. Checksums of those files are left empty if the algorithm
+// is not md5, so they never match the files of updates.
+func ReadAppliedUpdate(updateZipPath, algorithm string) (*AppliedUpdate, error) {
+	zipReader, err := zip.OpenReader(updateZipPath)
+	if err != nil {
+		return nil, err
+	}
+	defer zipReader.Close()
+
+	payloadDirectory, err := GetZippedPayloadDirectory(&zipReader.Reader)
+	if err != nil {
+		return nil, err
+	}
+	appliedUpdate := AppliedUpdate{Files: make(map[string]string)}
+	updateDescriptorV2 := UpdateDescriptorV2{}
+	updateDescriptorV3 := UpdateDescriptorV3{}
+	for _, file := range zipReader.Reader.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+		index := strings.Index(file.Name, "/")
+		if index == -1 {
+			continue
+		}
+		appliedUpdate.UpdateName = file.Name[:index]
+		relativePath := file.Name[index+1:]
+		var descriptor interface{}
+		switch {
+		case relativePath == constant.UPDATE_DESCRIPTOR_V2_FILE:
+			descriptor = &updateDescriptorV2
+		case relativePath == constant.UPDATE_DESCRIPTOR_V3_FILE:
+			descriptor = &updateDescriptorV3
+		case strings.HasPrefix(relativePath, payloadDirectory+"/"):
+		default:
+			continue
+		}
+		zippedFile, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		data, err := ioutil.ReadAll(zippedFile)
+		zippedFile.Close()
+		if err != nil {
+			return nil, err
+		}
+		if descriptor != nil {
+			if err = UnmarshalUpdateDescriptor(data, file.Name, descriptor); err != nil {
+				return nil, errors.New(fmt.Sprintf("unable to read '%s' in '%s': %v", file.Name, updateZipPath,
+					err))
+			}
+			continue
+		}
+		checksum, err := GetChecksumOfReader(bytes.NewReader(data), algorithm)
+		if err != nil {
+			return nil, err
+		}
+		appliedUpdate.Files[strings.TrimPrefix(relativePath, payloadDirectory+"/")] = checksum
+	}
+
+	if len(updateDescriptorV3.UpdateNumber) != 0 {
+		appliedUpdate.UpdateNumber = updateDescriptorV3.UpdateNumber
+		appliedUpdate.PlatformVersion = updateDescriptorV3.PlatformVersion
+		for _, binaryDelta := range updateDescriptorV3.BinaryDeltas {
+			delete(appliedUpdate.Files, binaryDelta.File+constant.BINARY_DELTA_EXTENSION)
+			appliedUpdate.Files[binaryDelta.File] = ""
+			if algorithm == constant.CHECKSUM_ALGORITHM_MD5 {
+				appliedUpdate.Files[binaryDelta.File] = binaryDelta.TargetMd5
+			}
+		}
+		removedFiles := make(map[string]bool)
+		products := append(updateDescriptorV3.CompatibleProducts, updateDescriptorV3.PartiallyApplicableProducts...)
+		for _, product := range products {
+			for _, removedFile := range product.RemovedFiles {
+				removedFiles[removedFile] = true
+			}
+		}
+		for removedFile := range removedFiles {
+			appliedUpdate.RemovedFiles = append(appliedUpdate.RemovedFiles, removedFile)
+		}
+		sort.Strings(appliedUpdate.RemovedFiles)
+	} else if len(updateDescriptorV2.UpdateNumber) != 0 {
+		appliedUpdate.UpdateNumber = updateDescriptorV2.UpdateNumber
+		appliedUpdate.PlatformVersion = updateDescriptorV2.PlatformVersion
+		appliedUpdate.RemovedFiles = updateDescriptorV2.FileChanges.RemovedFiles
+	} else {
+		return nil, errors.New(fmt.Sprintf("update descriptors not found in '%s'", updateZipPath))
+	}
+	return &appliedUpdate, nil
+}
@@ -0,0 +1,295 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+import (
+	"archive/zip"
+	"crypto/md5"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/wso2/update-creator-tool/constant"
+	"gopkg.in/yaml.v2"
+)
+
+// struct which is used to record an update which is being applied transactionally, so that the distribution can be
+// restored if applying the update is interrupted (eg: by a crash). The changes of the update are recorded in the same
+// way as in the backup manifest.
+type ApplyJournal struct {
+	BackupManifest `yaml:",inline"`
+	// Stage of applying the update, staging or committing
+	State string `yaml:"state"`
+}
+
+// Get the path of the journal of the given update in the given distribution directory.
+func getApplyJournalPath(distributionDirectory, updateName string) string {
+	return filepath.Join(GetBackupDirectory(distributionDirectory, updateName), constant.APPLY_JOURNAL_FILE)
+}
+
+// Save the given journal to the backup directory of its update in the given distribution directory. The journal is
+// written to a partial file first so that an interrupted write does not corrupt the journal.
+func saveApplyJournal(distributionDirectory string, applyJournal *ApplyJournal) error {
+	data, err := yaml.Marshal(applyJournal)
+	if err != nil {
+		return err
+	}
+	journalPath := getApplyJournalPath(distributionDirectory, applyJournal.UpdateName)
+	partialJournalPath := journalPath + constant.PARTIAL_FILE_EXTENSION
+	if err = ioutil.WriteFile(GetLongPath(partialJournalPath), data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(GetLongPath(partialJournalPath), GetLongPath(journalPath))
+}
+
+// Load the journals of the updates of which applying to the given distribution directory is interrupted.
+func LoadApplyJournals(distributionDirectory string) ([]ApplyJournal, error) {
+	journalPaths, err := filepath.Glob(filepath.Join(distributionDirectory,
+		filepath.FromSlash(constant.APPLIED_UPDATES_BACKUP_DIRECTORY), "*", constant.APPLY_JOURNAL_FILE))
+	if err != nil {
+		return nil, err
+	}
+	var applyJournals []ApplyJournal
+	for _, journalPath := range journalPaths {
+		data, err := ioutil.ReadFile(GetLongPath(journalPath))
+		if err != nil {
+			return nil, err
+		}
+		applyJournal := ApplyJournal{}
+		if err = yaml.Unmarshal(data, &applyJournal); err != nil {
+			return nil, errors.New(fmt.Sprintf("unable to read '%s': %v", journalPath, err))
+		}
+		if !isValidBackupUpdateName(applyJournal.UpdateName) ||
+			applyJournal.UpdateName != filepath.Base(filepath.Dir(journalPath)) {
+			return nil, errors.New(fmt.Sprintf("invalid update name '%s' in '%s'", applyJournal.UpdateName,
+				journalPath))
+		}
+		applyJournals = append(applyJournals, applyJournal)
+	}
+	return applyJournals, nil
+}
+
+// Apply the given payload files of the update recorded in the given backup manifest to the given distribution
+// directory transactionally. The files are staged in the backup directory of the update and verified before the
+// distribution is changed, and the staged files are then swapped with the files of the distribution by renaming them.
+// A journal is kept until the update is applied, so that the distribution can be restored with
+// RecoverInterruptedUpdates if applying is interrupted. The distribution is restored if applying fails.
+func applyUpdateZipTransactionally(distributionDirectory string, payloadFiles []*zip.File, payloadPaths []string,
+	payloadDeltas []*BinaryDelta, backupManifest *BackupManifest) error {
+	backupDirectory := GetBackupDirectory(distributionDirectory, backupManifest.UpdateName)
+	if err := os.MkdirAll(GetLongPath(backupDirectory), 0755); err != nil {
+		return err
+	}
+	applyJournal := ApplyJournal{BackupManifest: *backupManifest, State: constant.APPLY_STATE_STAGING}
+	err := saveApplyJournal(distributionDirectory, &applyJournal)
+	// Files are staged and verified before the distribution is changed, so the distribution is not changed if the
+	// update cannot be applied
+	if err == nil {
+		err = stageUpdateFiles(distributionDirectory, backupDirectory, payloadFiles, payloadPaths, payloadDeltas)
+	}
+	if err == nil {
+		err = createRollbackZip(distributionDirectory, backupManifest)
+	}
+	if err == nil {
+		applyJournal.State = constant.APPLY_STATE_COMMITTING
+		err = saveApplyJournal(distributionDirectory, &applyJournal)
+	}
+	if err != nil {
+		os.RemoveAll(GetLongPath(backupDirectory))
+		return err
+	}
+
+	if err = commitStagedFiles(distributionDirectory, backupManifest); err != nil {
+		if recoverErr := recoverUpdate(distributionDirectory, &applyJournal); recoverErr != nil {
+			return errors.New(fmt.Sprintf("%v, and the distribution could not be restored (%v). Run 'wum-uc "+
+				"apply --recover %s' to restore it", err, recoverErr, distributionDirectory))
+		}
+		return err
+	}
+	// Staged files and the files replaced by the update are no longer needed once the update is applied, as the
+	// replaced files are kept in the rollback zip
+	for _, directory := range []string{constant.APPLY_STAGING_DIRECTORY, constant.APPLY_REPLACED_DIRECTORY} {
+		if err = os.RemoveAll(GetLongPath(filepath.Join(backupDirectory, directory))); err != nil {
+			logger.Debug(fmt.Sprintf("Error occurred while removing %s of %s: %v", directory,
+				backupManifest.UpdateName, err))
+		}
+	}
+	return nil
+}
+
+// Stage the given payload files in the staging directory of the given backup directory and verify them. Binary deltas
+// are reconstructed from the files of the given distribution directory.
+func stageUpdateFiles(distributionDirectory, backupDirectory string, payloadFiles []*zip.File, payloadPaths []string,
+	payloadDeltas []*BinaryDelta) error {
+	stagingDirectory := filepath.Join(backupDirectory, constant.APPLY_STAGING_DIRECTORY)
+	for i, file := range payloadFiles {
+		stagedPath := filepath.Join(stagingDirectory, filepath.FromSlash(payloadPaths[i]))
+		var err error
+		if payloadDeltas[i] != nil {
+			logger.Trace(fmt.Sprintf("Reconstructing %s from %s", stagedPath, file.Name))
+			basePath := filepath.Join(distributionDirectory, filepath.FromSlash(payloadPaths[i]))
+			err = reconstructDistributionFile(file, basePath, stagedPath, payloadDeltas[i])
+		} else {
+			logger.Trace(fmt.Sprintf("Staging %s to %s", file.Name, stagedPath))
+			err = extractZipEntry(file, stagedPath)
+		}
+		if err == nil {
+			err = verifyStagedFile(file, stagedPath, payloadDeltas[i])
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Verify the given staged file against the checksum of the given zip entry, or the checksum of the target of the given
+// binary delta if the file is reconstructed from a binary delta.
+func verifyStagedFile(file *zip.File, stagedPath string, binaryDelta *BinaryDelta) error {
+	stagedFile, err := os.Open(GetLongPath(stagedPath))
+	if err != nil {
+		return err
+	}
+	defer stagedFile.Close()
+	if binaryDelta != nil {
+		hash := md5.New()
+		if _, err = io.Copy(hash, stagedFile); err != nil {
+			return err
+		}
+		if checksum := fmt.Sprintf("%x", hash.Sum(nil)); checksum != binaryDelta.TargetMd5 {
+			return errors.New(fmt.Sprintf("md5 of the staged '%s' is '%s', expected '%s'", binaryDelta.File,
+				checksum, binaryDelta.TargetMd5))
+		}
+		return nil
+	}
+	hash := crc32.NewIEEE()
+	if _, err = io.Copy(hash, stagedFile); err != nil {
+		return err
+	}
+	if checksum := hash.Sum32(); checksum != file.CRC32 {
+		return errors.New(fmt.Sprintf("CRC32 of the staged '%s' is '%08x', expected '%08x'", file.Name, checksum,
+			file.CRC32))
+	}
+	return nil
+}
+
+// Swap the staged files of the update recorded in the given backup manifest with the files of the given distribution
+// directory. Files of the distribution which are modified or removed by the update are moved to the replaced
+// directory before the staged files are moved to the distribution, so that they can be moved back if applying is
+// interrupted. The update is applied once the backup manifest is saved and the journal is removed.
+func commitStagedFiles(distributionDirectory string, backupManifest *BackupManifest) error {
+	backupDirectory := GetBackupDirectory(distributionDirectory, backupManifest.UpdateName)
+	replacedDirectory := filepath.Join(backupDirectory, constant.APPLY_REPLACED_DIRECTORY)
+	stagingDirectory := filepath.Join(backupDirectory, constant.APPLY_STAGING_DIRECTORY)
+	for _, relativePath := range append(append([]string{}, backupManifest.ModifiedFiles...),
+		backupManifest.RemovedFiles...) {
+		logger.Trace(fmt.Sprintf("Moving %s aside", relativePath))
+		if err := moveFile(filepath.Join(distributionDirectory, filepath.FromSlash(relativePath)),
+			filepath.Join(replacedDirectory, filepath.FromSlash(relativePath))); err != nil {
+			return err
+		}
+	}
+	for _, relativePath := range append(append([]string{}, backupManifest.AddedFiles...),
+		backupManifest.ModifiedFiles...) {
+		logger.Trace(fmt.Sprintf("Moving the staged %s to the distribution", relativePath))
+		if err := moveFile(filepath.Join(stagingDirectory, filepath.FromSlash(relativePath)),
+			filepath.Join(distributionDirectory, filepath.FromSlash(relativePath))); err != nil {
+			return err
+		}
+	}
+	if err := saveBackupManifest(distributionDirectory, backupManifest); err != nil {
+		return err
+	}
+	return os.Remove(GetLongPath(getApplyJournalPath(distributionDirectory, backupManifest.UpdateName)))
+}
+
+// Move the given file to the given target, creating the parent directories of the target.
+func moveFile(source, target string) error {
+	if err := os.MkdirAll(GetLongPath(filepath.Dir(target)), 0755); err != nil {
+		return err
+	}
+	return os.Rename(GetLongPath(source), GetLongPath(target))
+}
+
+// Restore the given distribution directory from the updates of which applying is interrupted, and return the names
+// of the updates.
+func RecoverInterruptedUpdates(distributionDirectory string) ([]string, error) {
+	distributionDirectory, err := filepath.Abs(distributionDirectory)
+	if err != nil {
+		return nil, err
+	}
+	applyJournals, err := LoadApplyJournals(distributionDirectory)
+	if err != nil {
+		return nil, err
+	}
+	var updateNames []string
+	for i := range applyJournals {
+		if err = recoverUpdate(distributionDirectory, &applyJournals[i]); err != nil {
+			return updateNames, errors.New(fmt.Sprintf("unable to recover '%s': %v", applyJournals[i].UpdateName,
+				err))
+		}
+		updateNames = append(updateNames, applyJournals[i].UpdateName)
+	}
+	return updateNames, nil
+}
+
+// Restore the given distribution directory from the update of the given journal. Files added by the update are
+// deleted and the files of the distribution which are moved aside are moved back. The backup directory of the update
+// is removed once the distribution is restored.
+func recoverUpdate(distributionDirectory string, applyJournal *ApplyJournal) error {
+	backupDirectory := GetBackupDirectory(distributionDirectory, applyJournal.UpdateName)
+	replacedDirectory := filepath.Join(backupDirectory, constant.APPLY_REPLACED_DIRECTORY)
+	// The distribution is not changed while the files are staged
+	if applyJournal.State == constant.APPLY_STATE_COMMITTING {
+		for _, relativePath := range applyJournal.AddedFiles {
+			filePath, err := getDistributionFilePath(distributionDirectory, relativePath)
+			if err != nil {
+				return err
+			}
+			logger.Trace(fmt.Sprintf("Deleting %s", filePath))
+			if err = os.Remove(GetLongPath(filePath)); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		for _, relativePath := range append(append([]string{}, applyJournal.ModifiedFiles...),
+			applyJournal.RemovedFiles...) {
+			filePath, err := getDistributionFilePath(distributionDirectory, relativePath)
+			if err != nil {
+				return err
+			}
+			replacedPath := filepath.Join(replacedDirectory, filepath.FromSlash(relativePath))
+			if _, err = os.Stat(GetLongPath(replacedPath)); os.IsNotExist(err) {
+				// The file is not moved aside, so it is not changed
+				continue
+			} else if err != nil {
+				return err
+			}
+			logger.Trace(fmt.Sprintf("Restoring %s", filePath))
+			if err = moveFile(replacedPath, filePath); err != nil {
+				return err
+			}
+		}
+	}
+	return os.RemoveAll(GetLongPath(backupDirectory))
+}
@@ -0,0 +1,345 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/wso2/update-creator-tool/constant"
+	"gopkg.in/yaml.v2"
+)
+
+// struct which is used to record the changes of an update applied to a distribution, so the update can be rolled
+// back. Paths of the files are relative to the distribution directory.
+type BackupManifest struct {
+	UpdateName      string `yaml:"update_name"`
+	UpdateNumber    string `yaml:"update_number"`
+	PlatformVersion string `yaml:"platform_version"`
+	AppliedAt       string `yaml:"applied_at"`
+	// Files of the update which did not exist in the distribution
+	AddedFiles []string `yaml:"added_files"`
+	// Files of the distribution overwritten by the update. They are backed up before applying the update.
+	ModifiedFiles []string `yaml:"modified_files"`
+	// Files of the distribution deleted by the update. They are backed up before applying the update.
+	RemovedFiles []string `yaml:"removed_files"`
+}
+
+// Get the directory in the given distribution directory to which the files replaced by the given update are backed up.
+func GetBackupDirectory(distributionDirectory, updateName string) string {
+	return filepath.Join(distributionDirectory, filepath.FromSlash(constant.APPLIED_UPDATES_BACKUP_DIRECTORY),
+		updateName)
+}
+
+// Check whether the given update name can be used as the name of a backup directory. Names with path separators or
+// leading dots could refer to a directory outside the backup directory of the distribution.
+func isValidBackupUpdateName(updateName string) bool {
+	return len(updateName) != 0 && !strings.ContainsAny(updateName, "/\\") && !strings.HasPrefix(updateName, ".")
+}
+
+// Load the backup manifest of the given update applied to the given distribution directory. Nil is returned if the
+// update is not applied to the distribution.
+func LoadBackupManifest(distributionDirectory, updateName string) (*BackupManifest, error) {
+	manifestPath := filepath.Join(GetBackupDirectory(distributionDirectory, updateName),
+		constant.BACKUP_MANIFEST_FILE)
+	data, err := ioutil.ReadFile(GetLongPath(manifestPath))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	backupManifest := BackupManifest{}
+	if err = yaml.Unmarshal(data, &backupManifest); err != nil {
+		return nil, errors.New(fmt.Sprintf("unable to read '%s': %v", manifestPath, err))
+	}
+	return &backupManifest, nil
+}
+
+// Load the backup manifests of all the updates applied to the given distribution directory.
+func LoadBackupManifests(distributionDirectory string) ([]BackupManifest, error) {
+	manifestPaths, err := filepath.Glob(filepath.Join(distributionDirectory,
+		filepath.FromSlash(constant.APPLIED_UPDATES_BACKUP_DIRECTORY), "*", constant.BACKUP_MANIFEST_FILE))
+	if err != nil {
+		return nil, err
+	}
+	var backupManifests []BackupManifest
+	for _, manifestPath := range manifestPaths {
+		backupManifest, err := LoadBackupManifest(distributionDirectory, filepath.Base(filepath.Dir(manifestPath)))
+		if err != nil {
+			return nil, err
+		}
+		if backupManifest != nil {
+			backupManifests = append(backupManifests, *backupManifest)
+		}
+	}
+	return backupManifests, nil
+}
+
+// Save the given backup manifest to the backup directory of its update in the given distribution directory.
+func saveBackupManifest(distributionDirectory string, backupManifest *BackupManifest) error {
+	backupDirectory := GetBackupDirectory(distributionDirectory, backupManifest.UpdateName)
+	if err := os.MkdirAll(GetLongPath(backupDirectory), 0755); err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(backupManifest)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(GetLongPath(filepath.Join(backupDirectory, constant.BACKUP_MANIFEST_FILE)), data, 0644)
+}
+
+// Apply the given update zip to the given distribution directory the same way wum-client applies it. Files of the
+// payload are copied to the distribution, binary deltas are reconstructed from the existing files and the removed
+// files declared in the given update-descriptor3.yaml are deleted. Files of the distribution which are overwritten or
+// deleted are backed up to a rollback zip and a backup manifest is recorded before the distribution is changed.
+// Locally customized files are kept, overwritten or the files of the update are extracted next to them to merge,
+// depending on the given policy of each customized file. Excluded files (eg: OS variants of other operating systems)
+// are not applied. Applying fails if the update is already applied or if applying an update to the distribution is
+// interrupted. If the update is applied transactionally, the distribution is not changed unless all the files of the
+// update are staged and verified, and the distribution is restored if applying fails. The backup manifest is returned
+// along with the error if a non-transactional update is partially applied.
+func ApplyUpdateZip(updateZipPath, distributionDirectory string, updateDescriptorV3 *UpdateDescriptorV3,
+	customizedFiles map[string]string, excludedFiles map[string]bool, isTransactional bool) (*BackupManifest,
+	error) {
+	zipReader, err := zip.OpenReader(updateZipPath)
+	if err != nil {
+		return nil, err
+	}
+	defer zipReader.Close()
+
+	distributionDirectory, err = filepath.Abs(distributionDirectory)
+	if err != nil {
+		return nil, err
+	}
+	payloadDirectory, err := GetZippedPayloadDirectory(&zipReader.Reader)
+	if err != nil {
+		return nil, err
+	}
+	binaryDeltas := make(map[string]*BinaryDelta)
+	for i := range updateDescriptorV3.BinaryDeltas {
+		binaryDelta := &updateDescriptorV3.BinaryDeltas[i]
+		binaryDeltas[binaryDelta.File+constant.BINARY_DELTA_EXTENSION] = binaryDelta
+	}
+	backupManifest := BackupManifest{
+		UpdateNumber:    updateDescriptorV3.UpdateNumber,
+		PlatformVersion: updateDescriptorV3.PlatformVersion,
+		AppliedAt:       time.Now().UTC().Format(time.RFC3339Nano),
+	}
+
+	// Payload files are applied to the paths of the files they reconstruct
+	var payloadFiles []*zip.File
+	var payloadPaths []string
+	var payloadDeltas []*BinaryDelta
+	for _, file := range zipReader.Reader.File {
+		if index := strings.Index(file.Name, "/"); index > 0 && len(backupManifest.UpdateName) == 0 {
+			backupManifest.UpdateName = file.Name[:index]
+		}
+		relativePath, selected := getExtractPath(file.Name, constant.EXTRACT_PAYLOAD, payloadDirectory)
+		if !selected || file.FileInfo().IsDir() {
+			continue
+		}
+		if excludedFiles[relativePath] {
+			logger.Debug(fmt.Sprintf("Excluded file %s not applied", relativePath))
+			continue
+		}
+		binaryDelta := binaryDeltas[relativePath]
+		if binaryDelta != nil {
+			relativePath = binaryDelta.File
+		}
+		policy := customizedFiles[relativePath]
+		if policy == constant.CUSTOMIZATION_POLICY_KEEP {
+			logger.Debug(fmt.Sprintf("Customized file %s kept", relativePath))
+			continue
+		}
+		// Binary deltas are reconstructed from the file of the distribution, which is not available if it is customized
+		if len(policy) != 0 && binaryDelta != nil {
+			return nil, errors.New(fmt.Sprintf("'%s' is customized locally and the update only ships a binary delta "+
+				"of it, which can only be applied to the file of the distribution. Keep the customized file or "+
+				"restore the file of the distribution before applying", relativePath))
+		}
+		if policy == constant.CUSTOMIZATION_POLICY_MERGE {
+			relativePath += constant.CUSTOMIZATION_MERGE_EXTENSION
+		}
+		payloadFiles = append(payloadFiles, file)
+		payloadPaths = append(payloadPaths, relativePath)
+		payloadDeltas = append(payloadDeltas, binaryDelta)
+	}
+	if len(backupManifest.UpdateName) == 0 {
+		return nil, errors.New(fmt.Sprintf("update directory not found in '%s'", updateZipPath))
+	}
+	if !isValidBackupUpdateName(backupManifest.UpdateName) {
+		return nil, errors.New(fmt.Sprintf("invalid update name '%s' in '%s'", backupManifest.UpdateName,
+			updateZipPath))
+	}
+	existingManifest, err := LoadBackupManifest(distributionDirectory, backupManifest.UpdateName)
+	if err != nil {
+		return nil, err
+	}
+	if existingManifest != nil {
+		return nil, errors.New(fmt.Sprintf("'%s' is already applied to '%s' at %s", backupManifest.UpdateName,
+			distributionDirectory, existingManifest.AppliedAt))
+	}
+	applyJournals, err := LoadApplyJournals(distributionDirectory)
+	if err != nil {
+		return nil, err
+	}
+	if len(applyJournals) != 0 {
+		return nil, errors.New(fmt.Sprintf("applying '%s' to '%s' is interrupted. Run 'wum-uc apply --recover %s' "+
+			"to restore the distribution first", applyJournals[0].UpdateName, distributionDirectory,
+			distributionDirectory))
+	}
+
+	for i, relativePath := range payloadPaths {
+		isExisting, err := isDistributionFile(distributionDirectory, relativePath)
+		if err != nil {
+			return nil, err
+		}
+		if !isExisting && payloadDeltas[i] != nil {
+			return nil, errors.New(fmt.Sprintf("base of the binary delta '%s' not found in '%s'", relativePath,
+				distributionDirectory))
+		}
+		if isExisting {
+			backupManifest.ModifiedFiles = append(backupManifest.ModifiedFiles, relativePath)
+		} else {
+			backupManifest.AddedFiles = append(backupManifest.AddedFiles, relativePath)
+		}
+	}
+	for _, removedFile := range GetRemovedFiles(updateDescriptorV3) {
+		if excludedFiles[removedFile] {
+			continue
+		}
+		isExisting, err := isDistributionFile(distributionDirectory, removedFile)
+		if err != nil {
+			return nil, err
+		}
+		if !isExisting {
+			logger.Debug(fmt.Sprintf("Removed file %s not found in %s", removedFile, distributionDirectory))
+			continue
+		}
+		backupManifest.RemovedFiles = append(backupManifest.RemovedFiles, removedFile)
+	}
+	if isTransactional {
+		if err = applyUpdateZipTransactionally(distributionDirectory, payloadFiles, payloadPaths, payloadDeltas,
+			&backupManifest); err != nil {
+			return nil, err
+		}
+		return &backupManifest, nil
+	}
+	// Files are backed up to the rollback zip before changing the distribution, so a failed update can be rolled back
+	backupDirectory := GetBackupDirectory(distributionDirectory, backupManifest.UpdateName)
+	if err = createRollbackZip(distributionDirectory, &backupManifest); err != nil {
+		os.RemoveAll(GetLongPath(backupDirectory))
+		return nil, err
+	}
+	if err = saveBackupManifest(distributionDirectory, &backupManifest); err != nil {
+		os.RemoveAll(GetLongPath(backupDirectory))
+		return nil, err
+	}
+
+	for i, file := range payloadFiles {
+		destination := filepath.Join(distributionDirectory, filepath.FromSlash(payloadPaths[i]))
+		if payloadDeltas[i] != nil {
+			logger.Trace(fmt.Sprintf("Reconstructing %s from %s", destination, file.Name))
+			err = reconstructDistributionFile(file, destination, destination, payloadDeltas[i])
+		} else {
+			logger.Trace(fmt.Sprintf("Applying %s to %s", file.Name, destination))
+			err = extractZipEntry(file, destination)
+		}
+		if err != nil {
+			return &backupManifest, err
+		}
+	}
+	for _, removedFile := range backupManifest.RemovedFiles {
+		destination := filepath.Join(distributionDirectory, filepath.FromSlash(removedFile))
+		logger.Trace(fmt.Sprintf("Deleting %s", destination))
+		if err = os.Remove(GetLongPath(destination)); err != nil {
+			return &backupManifest, err
+		}
+	}
+	return &backupManifest, nil
+}
+
+// Get the path of the given file in the given distribution directory. Files which resolve outside the distribution
+// directory are rejected.
+func getDistributionFilePath(distributionDirectory, relativePath string) (string, error) {
+	filePath := filepath.Join(distributionDirectory, filepath.FromSlash(relativePath))
+	if !strings.HasPrefix(filePath, distributionDirectory+string(os.PathSeparator)) {
+		return "", errors.New(fmt.Sprintf("'%s' resolves outside the distribution directory '%s'", relativePath,
+			distributionDirectory))
+	}
+	return filePath, nil
+}
+
+// Check whether the given file exists in the given distribution directory. Files which resolve outside the
+// distribution directory and directories are rejected.
+func isDistributionFile(distributionDirectory, relativePath string) (bool, error) {
+	filePath, err := getDistributionFilePath(distributionDirectory, relativePath)
+	if err != nil {
+		return false, err
+	}
+	fileInfo, err := os.Stat(GetLongPath(filePath))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	if fileInfo.IsDir() {
+		return false, errors.New(fmt.Sprintf("'%s' is a directory in the distribution '%s'", relativePath,
+			distributionDirectory))
+	}
+	return true, nil
+}
+
+// Reconstruct the given file of the distribution from its current content at the given base path and the given binary
+// delta entry of the update zip, and write it to the given destination with the permissions of the base.
+func reconstructDistributionFile(file *zip.File, basePath, destination string, binaryDelta *BinaryDelta) error {
+	fileInfo, err := os.Stat(GetLongPath(basePath))
+	if err != nil {
+		return err
+	}
+	base, err := ioutil.ReadFile(GetLongPath(basePath))
+	if err != nil {
+		return err
+	}
+	delta, err := readZipFile(file)
+	if err != nil {
+		return err
+	}
+	target, err := ReconstructFromDelta(base, delta, binaryDelta)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(GetLongPath(filepath.Dir(destination)), 0755); err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(GetLongPath(destination), target, fileInfo.Mode()); err != nil {
+		return err
+	}
+	modified := file.FileInfo().ModTime()
+	return os.Chtimes(GetLongPath(destination), modified, modified)
+}
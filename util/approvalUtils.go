@@ -0,0 +1,168 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/wso2/update-creator-tool/constant"
+	"gopkg.in/yaml.v2"
+)
+
+// struct which represents the approval of an update artifact by a reviewer. Approvals are stored next to the artifact
+// and are bound to its content by the sha256 checksum.
+type ApprovalRecord struct {
+	Reviewer   string `yaml:"reviewer"`
+	ApprovedAt string `yaml:"approved-at"`
+	SHA256     string `yaml:"sha256"`
+	Signature  string `yaml:"signature,omitempty"`
+}
+
+// Get the location of the approvals of the artifact at the given location.
+func GetApprovalsPath(artifactPath string) string {
+	return artifactPath + constant.APPROVALS_FILE_EXTENSION
+}
+
+// Load the approvals of the artifact at the given location. No approvals are returned if the artifact is not approved
+// yet.
+func LoadApprovals(artifactPath string) ([]ApprovalRecord, error) {
+	data, err := ioutil.ReadFile(GetApprovalsPath(artifactPath))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var approvals []ApprovalRecord
+	if err = yaml.Unmarshal(data, &approvals); err != nil {
+		return nil, errors.New(fmt.Sprintf("unable to read the approvals '%s': %v", GetApprovalsPath(artifactPath),
+			err))
+	}
+	return approvals, nil
+}
+
+// Approve the artifact at the given location as the given reviewer. The approval is signed with the RSA private key at
+// the given location. An existing approval of the reviewer is replaced.
+func ApproveArtifact(artifactPath, reviewer, signingKeyPath string) (*ApprovalRecord, error) {
+	reviewer = strings.TrimSpace(reviewer)
+	if len(reviewer) == 0 {
+		return nil, errors.New("reviewer is not given")
+	}
+	// Unsigned approvals are never counted, as anyone who can write the approvals could add them
+	if len(signingKeyPath) == 0 {
+		return nil, errors.New("signing key of the reviewer is not given")
+	}
+	approval := ApprovalRecord{Reviewer: reviewer, ApprovedAt: time.Now().UTC().Format(time.RFC3339)}
+	var err error
+	if _, approval.SHA256, err = getFileChecksums(artifactPath); err != nil {
+		return nil, err
+	}
+	if approval.Signature, err = SignData(getApprovalData(&approval), signingKeyPath); err != nil {
+		return nil, err
+	}
+	approvals, err := LoadApprovals(artifactPath)
+	if err != nil {
+		return nil, err
+	}
+	var updatedApprovals []ApprovalRecord
+	for _, existingApproval := range approvals {
+		if !strings.EqualFold(existingApproval.Reviewer, reviewer) {
+			updatedApprovals = append(updatedApprovals, existingApproval)
+		}
+	}
+	updatedApprovals = append(updatedApprovals, approval)
+	data, err := yaml.Marshal(updatedApprovals)
+	if err != nil {
+		return nil, err
+	}
+	if err = ioutil.WriteFile(GetApprovalsPath(artifactPath), data, 0644); err != nil {
+		return nil, err
+	}
+	return &approval, nil
+}
+
+// Get the data of the given approval which is signed by the reviewer.
+func getApprovalData(approval *ApprovalRecord) []byte {
+	return []byte(strings.Join([]string{approval.Reviewer, approval.SHA256, approval.ApprovedAt}, "\n"))
+}
+
+// Get the distinct reviewers who approved the current content of the artifact at the given location, along with the
+// reasons for not counting the other approvals. Approvals by the given author are not counted so that updates are
+// approved by someone other than the person who created them. Only the approvals of the reviewers whose public keys are
+// given (keyed by the reviewer) with valid signatures are counted, so no approvals are counted without the keys.
+func GetApprovers(artifactPath string, reviewerKeys map[string]string, author string) ([]string, []string, error) {
+	if len(reviewerKeys) == 0 {
+		return nil, nil, errors.New(fmt.Sprintf("public keys of the reviewers are not given in '%s'",
+			constant.APPROVALS_REVIEWER_KEYS))
+	}
+	approvals, err := LoadApprovals(artifactPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	_, artifactSHA256, err := getFileChecksums(artifactPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	keys := make(map[string]string)
+	for reviewer, key := range reviewerKeys {
+		keys[strings.ToLower(reviewer)] = key
+	}
+	var approvers, rejections []string
+	approved := make(map[string]bool)
+	for _, approval := range approvals {
+		reviewer := strings.ToLower(approval.Reviewer)
+		switch {
+		case approved[reviewer]:
+			continue
+		case approval.SHA256 != artifactSHA256:
+			rejections = append(rejections, fmt.Sprintf("approval of '%s' is for different content (sha256: %s).",
+				approval.Reviewer, approval.SHA256))
+			continue
+		case len(author) != 0 && reviewer == strings.ToLower(author):
+			rejections = append(rejections, fmt.Sprintf("approval of '%s' is not counted as '%s' is the author of "+
+				"the update.", approval.Reviewer, approval.Reviewer))
+			continue
+		}
+		key, found := keys[reviewer]
+		if !found {
+			rejections = append(rejections, fmt.Sprintf("approval of '%s' is not counted as '%s' is not listed in "+
+				"'%s'.", approval.Reviewer, approval.Reviewer, constant.APPROVALS_REVIEWER_KEYS))
+			continue
+		}
+		if len(approval.Signature) == 0 {
+			rejections = append(rejections, fmt.Sprintf("approval of '%s' is not counted as it is not signed.",
+				approval.Reviewer))
+			continue
+		}
+		if err = VerifySignature(getApprovalData(&approval), approval.Signature, key); err != nil {
+			rejections = append(rejections, fmt.Sprintf("signature of the approval of '%s' is invalid: %v.",
+				approval.Reviewer, err))
+			continue
+		}
+		approved[reviewer] = true
+		approvers = append(approvers, approval.Reviewer)
+	}
+	return approvers, rejections, nil
+}
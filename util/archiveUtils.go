@@ -0,0 +1,192 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/wso2/update-creator-tool/constant"
+)
+
+// Returns the file extension of the update archive of the given format.
+func GetUpdateArchiveExtension(format string) (string, error) {
+	switch format {
+	case constant.UPDATE_FORMAT_ZIP:
+		return constant.ZIP_FILE_EXTENSION, nil
+	case constant.UPDATE_FORMAT_TAR_ZST:
+		return constant.TAR_ZST_FILE_EXTENSION, nil
+	default:
+		return "", errors.New(fmt.Sprintf("unsupported update format '%s', supported formats are '%s' and '%s'",
+			format, constant.UPDATE_FORMAT_ZIP, constant.UPDATE_FORMAT_TAR_ZST))
+	}
+}
+
+// Checks whether the given file is a zstd compressed tarball.
+func IsTarZstFile(archiveFilePath string) bool {
+	return strings.HasSuffix(archiveFilePath, constant.TAR_ZST_FILE_EXTENSION)
+}
+
+// Creates a zstd compressed tarball of the given directory. Entry names are prefixed with the name of the directory
+// as same as in the update zip. The given executable files (paths relative to the directory) are marked as executable.
+func TarZstDirectory(source, target string, executableFiles ...string) error {
+	tarZstFile, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	defer tarZstFile.Close()
+
+	encoder, err := zstd.NewWriter(tarZstFile)
+	if err != nil {
+		return err
+	}
+	archive := tar.NewWriter(encoder)
+
+	baseDir := filepath.Base(source)
+	err = filepath.Walk(source, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(filepath.Join(baseDir, strings.TrimPrefix(path, source)))
+		if info.IsDir() {
+			header.Name += "/"
+		} else if IsStringIsInSlice(strings.TrimPrefix(header.Name, baseDir+"/"), executableFiles) {
+			header.Mode |= constant.EXECUTE_PERMISSIONS
+		}
+		if err = archive.WriteHeader(header); err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = io.Copy(archive, file)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if err = archive.Close(); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// Converts the given zstd compressed tarball to a zip file with the same entries, so that it can be read in the same
+// way as an update zip.
+func ConvertTarZstToZip(source, target string) error {
+	tarZstFile, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer tarZstFile.Close()
+
+	decoder, err := zstd.NewReader(tarZstFile)
+	if err != nil {
+		return err
+	}
+	defer decoder.Close()
+	return writeTarAsZip(tar.NewReader(decoder), target, zip.Deflate)
+}
+
+// Checks whether the given file is a gzip compressed tarball (.tar.gz or .tgz).
+func IsTarGzFile(archiveFilePath string) bool {
+	return strings.HasSuffix(archiveFilePath, constant.TAR_GZ_FILE_EXTENSION) ||
+		strings.HasSuffix(archiveFilePath, constant.TGZ_FILE_EXTENSION)
+}
+
+// Converts the given gzip compressed tarball to a zip file with the same entries, so that distributions shipped as
+// tarballs can be read in the same way as distribution zips. Entries are stored without compressing them again as the
+// zip is only read locally. Links and other special entries of the tarball are skipped.
+func ConvertTarGzToZip(source, target string) error {
+	tarGzFile, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer tarGzFile.Close()
+
+	decompressor, err := gzip.NewReader(tarGzFile)
+	if err != nil {
+		return err
+	}
+	defer decompressor.Close()
+	return writeTarAsZip(tar.NewReader(decompressor), target, zip.Store)
+}
+
+// Writes the entries of the given tarball to a zip file at the given location using the given compression method.
+func writeTarAsZip(tarReader *tar.Reader, target string, method uint16) error {
+	zipFile, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	defer zipFile.Close()
+	archive := zip.NewWriter(zipFile)
+
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg && header.Typeflag != tar.TypeDir {
+			logger.Debug(fmt.Sprintf("Skipping %s as it is not a file or a directory", header.Name))
+			continue
+		}
+		// Entries of tarballs created in the root folder (eg: tar -czf product.tar.gz ./product) start with './'
+		name := strings.TrimPrefix(header.Name, "./")
+		if len(name) == 0 {
+			continue
+		}
+		zipHeader, err := zip.FileInfoHeader(header.FileInfo())
+		if err != nil {
+			return err
+		}
+		zipHeader.Name = name
+		zipHeader.Method = method
+		writer, err := archive.CreateHeader(zipHeader)
+		if err != nil {
+			return err
+		}
+		if header.Typeflag == tar.TypeReg {
+			if _, err = io.Copy(writer, tarReader); err != nil {
+				return err
+			}
+		}
+	}
+	return archive.Close()
+}
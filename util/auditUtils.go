@@ -0,0 +1,264 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package util
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/wso2/update-creator-tool/constant"
+)
+
+// struct which represents a single record of the audit log. Each record contains the hash of the previous record, so
+// modifying, removing or reordering records breaks the chain. Hashes are keyed (HMAC-SHA256) with the audit key, so the
+// chain cannot be rewritten without the key.
+type AuditRecord struct {
+	Sequence     int    `json:"sequence"`
+	Time         string `json:"time"`
+	Operation    string `json:"operation"`
+	User         string `json:"user"`
+	Artifact     string `json:"artifact"`
+	SHA256       string `json:"sha256"`
+	PreviousHash string `json:"previous_hash"`
+	Hash         string `json:"hash"`
+}
+
+// struct which represents the head of the audit log, which is stored outside the log. It refers to the last record of
+// the log, so records removed from the end of the log are detected.
+type AuditLogHead struct {
+	Sequence int    `json:"sequence"`
+	Hash     string `json:"hash"`
+	MAC      string `json:"mac"`
+}
+
+// Read the audit key (hex encoded) at the given location. A new random key is created at the location if it does not
+// exist and create is true.
+func ReadAuditKey(keyPath string, create bool) ([]byte, error) {
+	data, err := ioutil.ReadFile(keyPath)
+	if os.IsNotExist(err) && create {
+		key := make([]byte, constant.AUDIT_KEY_SIZE)
+		if _, err = rand.Read(key); err != nil {
+			return nil, err
+		}
+		if err = os.MkdirAll(filepath.Dir(keyPath), 0700); err != nil {
+			return nil, err
+		}
+		if err = ioutil.WriteFile(keyPath, []byte(hex.EncodeToString(key)+"\n"), 0600); err != nil {
+			return nil, err
+		}
+		return key, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	key, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(key) < constant.AUDIT_KEY_SIZE {
+		return nil, errors.New(fmt.Sprintf("'%s' is not a valid audit key, expected %d hex encoded bytes", keyPath,
+			constant.AUDIT_KEY_SIZE))
+	}
+	return key, nil
+}
+
+// Append a record of the given operation on the given artifact to the audit log at the given location and update the
+// head of the audit log. Hashes are keyed with the given audit key. The audit log is created if it does not exist.
+func AppendAuditRecord(auditLogPath string, key []byte, operation, artifactPath string) error {
+	records, err := readAuditRecords(auditLogPath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	record := AuditRecord{
+		Sequence:     1,
+		Time:         time.Now().UTC().Format(time.RFC3339),
+		Operation:    operation,
+		User:         getCurrentUserName(),
+		Artifact:     filepath.Base(artifactPath),
+		PreviousHash: constant.AUDIT_LOG_GENESIS_HASH,
+	}
+	if len(records) != 0 {
+		record.Sequence = records[len(records)-1].Sequence + 1
+		record.PreviousHash = records[len(records)-1].Hash
+	}
+	_, record.SHA256, err = getFileChecksums(artifactPath)
+	if err != nil {
+		return err
+	}
+	if record.Hash, err = getAuditRecordHash(&record, key); err != nil {
+		return err
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(auditLogPath), 0700); err != nil {
+		return err
+	}
+	auditLog, err := os.OpenFile(auditLogPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err = auditLog.Write(append(data, '\n')); err != nil {
+		auditLog.Close()
+		return err
+	}
+	if err = auditLog.Close(); err != nil {
+		return err
+	}
+	return writeAuditLogHead(auditLogPath, key, &record)
+}
+
+// Verify the hash chain of the audit log at the given location with the given audit key, and verify that the last
+// record is the one referred by the head of the audit log. The number of verified records is returned. An error is
+// returned for the first record which is modified or out of order, or if records are removed from the end.
+func VerifyAuditLog(auditLogPath string, key []byte) (int, error) {
+	records, err := readAuditRecords(auditLogPath)
+	if err != nil {
+		return 0, err
+	}
+	previousHash := constant.AUDIT_LOG_GENESIS_HASH
+	for index, record := range records {
+		if record.Sequence != index+1 {
+			return index, errors.New(fmt.Sprintf("record %d has the sequence number %d, records are removed or "+
+				"reordered", index+1, record.Sequence))
+		}
+		if record.PreviousHash != previousHash {
+			return index, errors.New(fmt.Sprintf("record %d does not follow the previous record, records are "+
+				"removed or reordered", record.Sequence))
+		}
+		hash, err := getAuditRecordHash(&record, key)
+		if err != nil {
+			return index, err
+		}
+		if !hmac.Equal([]byte(record.Hash), []byte(hash)) {
+			return index, errors.New(fmt.Sprintf("hash of record %d does not match its content, the record is "+
+				"modified or the audit key is not the key the log is created with", record.Sequence))
+		}
+		previousHash = record.Hash
+	}
+	head, err := readAuditLogHead(auditLogPath, key)
+	if os.IsNotExist(err) && len(records) == 0 {
+		return 0, nil
+	}
+	if err != nil {
+		return len(records), err
+	}
+	if len(records) == 0 || head.Sequence != records[len(records)-1].Sequence ||
+		head.Hash != records[len(records)-1].Hash {
+		return len(records), errors.New(fmt.Sprintf("last record is not the record %d referred by the head of the "+
+			"audit log, records are removed from the end", head.Sequence))
+	}
+	return len(records), nil
+}
+
+// Write the head of the audit log at the given location which refers to the given record.
+func writeAuditLogHead(auditLogPath string, key []byte, record *AuditRecord) error {
+	head := AuditLogHead{Sequence: record.Sequence, Hash: record.Hash}
+	head.MAC = getAuditLogHeadMAC(&head, key)
+	data, err := json.Marshal(head)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(auditLogPath+constant.AUDIT_LOG_HEAD_EXTENSION, append(data, '\n'), 0600)
+}
+
+// Read the head of the audit log at the given location and verify it with the given audit key.
+func readAuditLogHead(auditLogPath string, key []byte) (*AuditLogHead, error) {
+	headPath := auditLogPath + constant.AUDIT_LOG_HEAD_EXTENSION
+	data, err := ioutil.ReadFile(headPath)
+	if err != nil {
+		return nil, err
+	}
+	head := AuditLogHead{}
+	if err = json.Unmarshal(data, &head); err != nil {
+		return nil, errors.New(fmt.Sprintf("'%s' is not a valid audit log head: %v", headPath, err))
+	}
+	if !hmac.Equal([]byte(head.MAC), []byte(getAuditLogHeadMAC(&head, key))) {
+		return nil, errors.New(fmt.Sprintf("'%s' is modified or not created with the audit key", headPath))
+	}
+	return &head, nil
+}
+
+// Get the MAC of the given head of the audit log, keyed with the given audit key.
+func getAuditLogHeadMAC(head *AuditLogHead, key []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(fmt.Sprintf("head\n%d\n%s", head.Sequence, head.Hash)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Read the records of the audit log at the given location.
+func readAuditRecords(auditLogPath string) ([]AuditRecord, error) {
+	auditLog, err := os.Open(auditLogPath)
+	if err != nil {
+		return nil, err
+	}
+	defer auditLog.Close()
+	var records []AuditRecord
+	reader := bufio.NewReader(auditLog)
+	for lineNumber := 1; ; lineNumber++ {
+		line, err := reader.ReadString('\n')
+		if len(strings.TrimSpace(line)) != 0 {
+			record := AuditRecord{}
+			if unmarshalErr := json.Unmarshal([]byte(line), &record); unmarshalErr != nil {
+				return nil, errors.New(fmt.Sprintf("line %d of '%s' is not a valid audit record: %v", lineNumber,
+					auditLogPath, unmarshalErr))
+			}
+			records = append(records, record)
+		}
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Get the hash of the given record which is the HMAC-SHA256 of the record without its hash, keyed with the given
+// audit key.
+func getAuditRecordHash(record *AuditRecord, key []byte) (string, error) {
+	unhashedRecord := *record
+	unhashedRecord.Hash = ""
+	data, err := json.Marshal(unhashedRecord)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// Get the name of the user who runs wum-uc.
+func getCurrentUserName() string {
+	if currentUser, err := user.Current(); err == nil {
+		return currentUser.Username
+	}
+	return os.Getenv("USER")
+}
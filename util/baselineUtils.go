@@ -0,0 +1,91 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/wso2/update-creator-tool/constant"
+)
+
+// struct which is used to read the metadata of the latest updated distribution of a product from WUM.
+type DistributionBaseline struct {
+	ProductName    string         `json:"product-name"`
+	ProductVersion string         `json:"product-version"`
+	Channel        string         `json:"channel"`
+	Files          []BaselineFile `json:"files"`
+}
+
+type BaselineFile struct {
+	Path      string `json:"path"`
+	Md5sum    string `json:"md5"`
+	Sha256sum string `json:"sha256,omitempty"`
+}
+
+// Get the checksum of the given algorithm of the given file in the distribution baseline. An empty string is returned
+// if WUM does not provide a checksum of the algorithm.
+func GetBaselineFileChecksum(file *BaselineFile, algorithm string) string {
+	switch algorithm {
+	case constant.CHECKSUM_ALGORITHM_MD5:
+		return file.Md5sum
+	case constant.CHECKSUM_ALGORITHM_SHA256:
+		return file.Sha256sum
+	default:
+		return ""
+	}
+}
+
+// Fetch the file list and the checksums of the latest updated distribution of the given product version in the given
+// channel from the given WUM server.
+func GetDistributionBaseline(serverURL, accessToken, productName, productVersion, channel string) (
+	*DistributionBaseline, error) {
+	apiURL := serverURL + "/" + constant.PRODUCTS_API_CONTEXT + "/" + constant.FILES_API_VERSION + "/" +
+		productName + "/" + productVersion + "/" + channel + "/" + constant.FILES_API_CONTEXT
+	request, err := http.NewRequest(http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Add(constant.HEADER_AUTHORIZATION, "Bearer "+accessToken)
+	request.Header.Add(constant.HEADER_ACCEPT, constant.HEADER_VALUE_APPLICATION_JSON)
+	// Not found responses are expected here, so the response is handled without handleErrorResponses()
+	response := SendRequest(request, time.Duration(constant.WUMUC_API_CALL_TIMEOUT*time.Minute))
+	defer response.Body.Close()
+	logger.Debug(fmt.Sprintf("Distribution baseline response status code: %d", response.StatusCode))
+	switch response.StatusCode {
+	case http.StatusOK:
+		baseline := DistributionBaseline{}
+		if err = json.NewDecoder(response.Body).Decode(&baseline); err != nil {
+			return nil, errors.New(constant.ERROR_READING_RESPONSE_MSG + ": " + err.Error())
+		}
+		return &baseline, nil
+	case http.StatusNotFound:
+		return nil, errors.New(fmt.Sprintf("'%s-%s' is not found in the '%s' channel of WUM.", productName,
+			productVersion, channel))
+	case http.StatusUnauthorized, http.StatusBadRequest:
+		return nil, errors.New(constant.INVALID_EXPIRED_REFRESH_TOKEN_MSG + ", " +
+			constant.RUN_WUMUC_INIT_TO_CONTINUE_MSG)
+	default:
+		return nil, errors.New(constant.UNABLE_TO_CONNECT_WUM_SERVERS)
+	}
+}
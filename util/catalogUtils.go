@@ -0,0 +1,460 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+import (
+	"archive/zip"
+	"crypto"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wso2/update-creator-tool/constant"
+	"gopkg.in/yaml.v2"
+)
+
+// struct which is used to read and write the update catalog of a directory of updates
+type Catalog struct {
+	GeneratedAt string `yaml:"generated-at"`
+	// Checksum algorithms of the updates in the order of preference. Updates which do not declare an algorithm are
+	// verified with the first algorithm of which they have a checksum.
+	ChecksumAlgorithms []string       `yaml:"checksum-algorithms,omitempty"`
+	Updates            []CatalogEntry `yaml:"updates"`
+}
+
+type CatalogEntry struct {
+	UpdateName      string   `yaml:"update-name"`
+	UpdateNumber    string   `yaml:"update-number"`
+	PlatformVersion string   `yaml:"platform-version"`
+	PlatformName    string   `yaml:"platform-name"`
+	Products        []string `yaml:"products,omitempty"`
+	Files           []string `yaml:"files,omitempty"`
+	MD5             string   `yaml:"md5"`
+	SHA256          string   `yaml:"sha256"`
+	// Checksum algorithm declared by the update, which is used to verify the update zip
+	ChecksumAlgorithm string `yaml:"checksum-algorithm,omitempty"`
+	// Updates of the same platform version with lower update numbers which change the same files
+	Dependencies []string `yaml:"dependencies,omitempty"`
+	// Updates which are superseded by this update
+	Supersedes []string `yaml:"supersedes,omitempty"`
+	// Channel to which the update is published, and the expiry date if it is a preview
+	Channel        string `yaml:"channel,omitempty"`
+	PreviewExpires string `yaml:"preview-expires,omitempty"`
+}
+
+// struct which is used to store a prior update of which the changes are overwritten by another update
+type OverwrittenUpdate struct {
+	UpdateName string
+	Files      []string
+}
+
+// Create the catalog entry of the update zip at the given location.
+func NewCatalogEntry(updateZipPath string) (*CatalogEntry, error) {
+	entry := CatalogEntry{}
+	var err error
+	entry.MD5, entry.SHA256, err = getFileChecksums(updateZipPath)
+	if err != nil {
+		return nil, err
+	}
+	zipReader, err := zip.OpenReader(updateZipPath)
+	if err != nil {
+		return nil, err
+	}
+	defer zipReader.Close()
+
+	updateDescriptorV2 := UpdateDescriptorV2{}
+	updateDescriptorV3 := UpdateDescriptorV3{}
+	for _, file := range zipReader.Reader.File {
+		// Update descriptors should be in the root directory of the update
+		if file.FileInfo().IsDir() || strings.Count(file.Name, "/") != 1 {
+			continue
+		}
+		var descriptor interface{}
+		switch file.FileInfo().Name() {
+		case constant.UPDATE_DESCRIPTOR_V2_FILE:
+			descriptor = &updateDescriptorV2
+		case constant.UPDATE_DESCRIPTOR_V3_FILE:
+			descriptor = &updateDescriptorV3
+		default:
+			continue
+		}
+		entry.UpdateName = strings.TrimSuffix(file.Name, "/"+file.FileInfo().Name())
+		zippedFile, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		data, err := ioutil.ReadAll(zippedFile)
+		zippedFile.Close()
+		if err != nil {
+			return nil, err
+		}
+		if err = yaml.Unmarshal(data, descriptor); err != nil {
+			return nil, errors.New(fmt.Sprintf("unable to read '%s' in '%s': %v", file.Name, updateZipPath, err))
+		}
+	}
+
+	files := make(map[string]bool)
+	if len(updateDescriptorV3.UpdateNumber) != 0 {
+		entry.UpdateNumber = updateDescriptorV3.UpdateNumber
+		entry.PlatformVersion = updateDescriptorV3.PlatformVersion
+		entry.PlatformName = updateDescriptorV3.PlatformName
+		entry.Supersedes = updateDescriptorV3.Supersedes
+		entry.ChecksumAlgorithm = updateDescriptorV3.ChecksumAlgorithm
+		entry.Channel = GetUpdateChannel(updateDescriptorV3.Preview)
+		if updateDescriptorV3.Preview != nil {
+			entry.PreviewExpires = updateDescriptorV3.Preview.Expires
+		}
+		products := append(updateDescriptorV3.CompatibleProducts, updateDescriptorV3.PartiallyApplicableProducts...)
+		for _, product := range products {
+			entry.Products = append(entry.Products, product.ProductName+"-"+product.ProductVersion)
+			for _, changedFiles := range [][]string{product.AddedFiles, product.ModifiedFiles, product.RemovedFiles} {
+				for _, changedFile := range changedFiles {
+					files[changedFile] = true
+				}
+			}
+		}
+	} else if len(updateDescriptorV2.UpdateNumber) != 0 {
+		entry.UpdateNumber = updateDescriptorV2.UpdateNumber
+		entry.PlatformVersion = updateDescriptorV2.PlatformVersion
+		entry.PlatformName = updateDescriptorV2.PlatformName
+		fileChanges := updateDescriptorV2.FileChanges
+		for _, changedFiles := range [][]string{fileChanges.AddedFiles, fileChanges.ModifiedFiles,
+			fileChanges.RemovedFiles} {
+			for _, changedFile := range changedFiles {
+				files[changedFile] = true
+			}
+		}
+	} else {
+		return nil, errors.New(fmt.Sprintf("update descriptors not found in '%s'", updateZipPath))
+	}
+	for changedFile := range files {
+		entry.Files = append(entry.Files, changedFile)
+	}
+	sort.Strings(entry.Products)
+	sort.Strings(entry.Files)
+	return &entry, nil
+}
+
+// Get the md5 and the sha256 checksums of the file at the given location.
+func getFileChecksums(location string) (string, string, error) {
+	file, err := os.Open(location)
+	if err != nil {
+		return "", "", err
+	}
+	defer file.Close()
+	md5Hash := md5.New()
+	sha256Hash := sha256.New()
+	if _, err = io.Copy(io.MultiWriter(md5Hash, sha256Hash), file); err != nil {
+		return "", "", err
+	}
+	return hex.EncodeToString(md5Hash.Sum(nil)), hex.EncodeToString(sha256Hash.Sum(nil)), nil
+}
+
+// Create a catalog from the given entries. Entries are sorted by the platform version and the update number, and
+// the dependencies of each entry are resolved.
+func NewCatalog(entries []CatalogEntry) *Catalog {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].PlatformVersion != entries[j].PlatformVersion {
+			return entries[i].PlatformVersion < entries[j].PlatformVersion
+		}
+		return compareUpdateNumbers(entries[i].UpdateNumber, entries[j].UpdateNumber) < 0
+	})
+	for i := range entries {
+		entries[i].Dependencies = nil
+		for j := 0; j < i; j++ {
+			if entries[j].PlatformVersion == entries[i].PlatformVersion &&
+				compareUpdateNumbers(entries[j].UpdateNumber, entries[i].UpdateNumber) < 0 &&
+				hasCommonFiles(entries[i].Files, entries[j].Files) {
+				entries[i].Dependencies = append(entries[i].Dependencies, entries[j].UpdateName)
+			}
+		}
+	}
+	return &Catalog{
+		GeneratedAt: time.Now().UTC().Format(time.RFC3339),
+		Updates:     entries,
+	}
+}
+
+// Compare the given update numbers numerically if possible.
+func compareUpdateNumbers(first, second string) int {
+	firstNumber, firstErr := strconv.Atoi(first)
+	secondNumber, secondErr := strconv.Atoi(second)
+	if firstErr == nil && secondErr == nil {
+		return firstNumber - secondNumber
+	}
+	return strings.Compare(first, second)
+}
+
+// Check whether the given sorted file lists have at least one common file.
+func hasCommonFiles(first, second []string) bool {
+	return len(getCommonFiles(first, second)) != 0
+}
+
+// Get the common files of the given sorted file lists.
+func getCommonFiles(first, second []string) []string {
+	var commonFiles []string
+	i, j := 0, 0
+	for i < len(first) && j < len(second) {
+		switch {
+		case first[i] == second[j]:
+			commonFiles = append(commonFiles, first[i])
+			i++
+			j++
+		case first[i] < second[j]:
+			i++
+		default:
+			j++
+		}
+	}
+	return commonFiles
+}
+
+// Find the catalog entries which are duplicates of the given entry (same update number for the same platform version
+// but different content) and the entries which conflict with the given entry (updates of the same platform version
+// with higher update numbers changing the same files). The content is compared using the checksum algorithm
+// negotiated for each catalog entry.
+func FindCatalogConflicts(catalog *Catalog, entry *CatalogEntry) (duplicates, conflicts []CatalogEntry) {
+	for i := range catalog.Updates {
+		catalogEntry := catalog.Updates[i]
+		if catalogEntry.PlatformVersion != entry.PlatformVersion || hasSameContent(catalog, &catalogEntry, entry) {
+			continue
+		}
+		comparison := compareUpdateNumbers(catalogEntry.UpdateNumber, entry.UpdateNumber)
+		if comparison == 0 {
+			duplicates = append(duplicates, catalogEntry)
+		} else if comparison > 0 && hasCommonFiles(catalogEntry.Files, entry.Files) {
+			conflicts = append(conflicts, catalogEntry)
+		}
+	}
+	return duplicates, conflicts
+}
+
+// Check whether the given entry has the same content as the given catalog entry. Entries are considered different if
+// a checksum algorithm cannot be negotiated for the catalog entry.
+func hasSameContent(catalog *Catalog, catalogEntry, entry *CatalogEntry) bool {
+	algorithm, err := NegotiateChecksumAlgorithm(catalog, catalogEntry)
+	if err != nil {
+		logger.Debug(fmt.Sprintf("%v error occurred while negotiating the checksum algorithm", err))
+		return false
+	}
+	return GetCatalogEntryChecksum(catalogEntry, algorithm) == GetCatalogEntryChecksum(entry, algorithm)
+}
+
+// Find the catalog entries which are prior updates (same platform version with lower update numbers) of the given
+// entry and of which the changed files are also changed by the given entry without the entry superseding them.
+// Prior updates which are already superseded by another update in the catalog are not considered.
+func FindOverwrittenUpdates(catalog *Catalog, entry *CatalogEntry) []OverwrittenUpdate {
+	supersededUpdates := make(map[string]bool)
+	for _, catalogEntry := range catalog.Updates {
+		for _, supersededUpdate := range catalogEntry.Supersedes {
+			supersededUpdates[supersededUpdate] = true
+		}
+	}
+	for _, supersededUpdate := range entry.Supersedes {
+		supersededUpdates[supersededUpdate] = true
+	}
+	var overwrittenUpdates []OverwrittenUpdate
+	for _, catalogEntry := range catalog.Updates {
+		if catalogEntry.PlatformVersion != entry.PlatformVersion || supersededUpdates[catalogEntry.UpdateName] ||
+			compareUpdateNumbers(catalogEntry.UpdateNumber, entry.UpdateNumber) >= 0 {
+			continue
+		}
+		if commonFiles := getCommonFiles(catalogEntry.Files, entry.Files); len(commonFiles) != 0 {
+			overwrittenUpdates = append(overwrittenUpdates, OverwrittenUpdate{
+				UpdateName: catalogEntry.UpdateName,
+				Files:      commonFiles,
+			})
+		}
+	}
+	return overwrittenUpdates
+}
+
+// Compare the given entry created from a released update with the entry of the same update in the catalog and
+// return the mismatches. The checksum of the update zip is compared using the checksum algorithm negotiated for the
+// catalog entry. An error is returned if the update is not in the catalog or if an algorithm cannot be negotiated.
+func VerifyCatalogEntry(catalog *Catalog, entry *CatalogEntry) ([]string, error) {
+	var catalogEntry *CatalogEntry
+	for i := range catalog.Updates {
+		if catalog.Updates[i].UpdateName == entry.UpdateName {
+			catalogEntry = &catalog.Updates[i]
+			break
+		}
+	}
+	if catalogEntry == nil {
+		return nil, errors.New(fmt.Sprintf("'%s' is not found in the catalog", entry.UpdateName))
+	}
+	algorithm, err := NegotiateChecksumAlgorithm(catalog, catalogEntry)
+	if err != nil {
+		return nil, err
+	}
+	logger.Debug(fmt.Sprintf("Using %s to verify %s", algorithm, entry.UpdateName))
+	var mismatches []string
+	fields := []struct {
+		name             string
+		actual, expected string
+	}{
+		{algorithm, GetCatalogEntryChecksum(entry, algorithm), GetCatalogEntryChecksum(catalogEntry, algorithm)},
+		{"checksum-algorithm", entry.ChecksumAlgorithm, catalogEntry.ChecksumAlgorithm},
+		{"update-number", entry.UpdateNumber, catalogEntry.UpdateNumber},
+		{"platform-name", entry.PlatformName, catalogEntry.PlatformName},
+		{"platform-version", entry.PlatformVersion, catalogEntry.PlatformVersion},
+		{"products", strings.Join(entry.Products, ", "), strings.Join(catalogEntry.Products, ", ")},
+		{"supersedes", strings.Join(entry.Supersedes, ", "), strings.Join(catalogEntry.Supersedes, ", ")},
+	}
+	for _, field := range fields {
+		if field.actual != field.expected {
+			mismatches = append(mismatches, fmt.Sprintf("%s: expected '%s', found '%s'", field.name,
+				field.expected, field.actual))
+		}
+	}
+	// Changed files are compared separately as there can be thousands of them
+	files := make(map[string]bool)
+	for _, file := range entry.Files {
+		files[file] = true
+	}
+	for _, file := range catalogEntry.Files {
+		if !files[file] {
+			mismatches = append(mismatches, fmt.Sprintf("files: '%s' is not in the update", file))
+		}
+		delete(files, file)
+	}
+	for _, file := range entry.Files {
+		if files[file] {
+			mismatches = append(mismatches, fmt.Sprintf("files: '%s' is not in the catalog", file))
+		}
+	}
+	return mismatches, nil
+}
+
+// Load the catalog at the given location.
+func LoadCatalog(catalogPath string) (*Catalog, []byte, error) {
+	data, err := ioutil.ReadFile(catalogPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	catalog := Catalog{}
+	if err = yaml.Unmarshal(data, &catalog); err != nil {
+		return nil, nil, errors.New(fmt.Sprintf("unable to read the catalog '%s': %v", catalogPath, err))
+	}
+	// Update names are used as file names, so names which could resolve to other locations are rejected
+	updateNameRegex := regexp.MustCompile(constant.UPDATE_NAME_REGEX)
+	for _, entry := range catalog.Updates {
+		if !updateNameRegex.MatchString(entry.UpdateName) {
+			return nil, nil, errors.New(fmt.Sprintf("invalid update name '%s' in the catalog '%s', expected to "+
+				"match '%s'", entry.UpdateName, catalogPath, constant.UPDATE_NAME_REGEX))
+		}
+	}
+	return &catalog, data, nil
+}
+
+// Sign the given data with the RSA private key (PEM encoded PKCS#1 or PKCS#8) at the given location. The base64
+// encoded signature is returned.
+func SignData(data []byte, privateKeyPath string) (string, error) {
+	privateKey, err := readRSAPrivateKey(privateKeyPath)
+	if err != nil {
+		return "", err
+	}
+	digest := sha256.Sum256(data)
+	signature, err := rsa.SignPKCS1v15(rand.Reader, privateKey, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(signature), nil
+}
+
+// Verify the given base64 encoded signature of the given data with the RSA public key (PEM encoded PKIX) at the given
+// location.
+func VerifySignature(data []byte, signature, publicKeyPath string) error {
+	publicKey, err := readRSAPublicKey(publicKeyPath)
+	if err != nil {
+		return err
+	}
+	signatureBytes, err := base64.StdEncoding.DecodeString(strings.TrimSpace(signature))
+	if err != nil {
+		return errors.New(fmt.Sprintf("invalid signature: %v", err))
+	}
+	digest := sha256.Sum256(data)
+	return rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, digest[:], signatureBytes)
+}
+
+// Read the RSA private key (PKCS1 or PKCS8) of the PEM file at the given location.
+func readRSAPrivateKey(privateKeyPath string) (*rsa.PrivateKey, error) {
+	block, err := readPEMBlock(privateKeyPath)
+	if err != nil {
+		return nil, err
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("unable to read the private key '%s': %v", privateKeyPath, err))
+	}
+	privateKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("'%s' is not an RSA private key", privateKeyPath))
+	}
+	return privateKey, nil
+}
+
+// Read the RSA public key (PKIX) of the PEM file at the given location.
+func readRSAPublicKey(publicKeyPath string) (*rsa.PublicKey, error) {
+	block, err := readPEMBlock(publicKeyPath)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("unable to read the public key '%s': %v", publicKeyPath, err))
+	}
+	publicKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("'%s' is not an RSA public key", publicKeyPath))
+	}
+	return publicKey, nil
+}
+
+// Read the first PEM block of the file at the given location.
+func readPEMBlock(location string) (*pem.Block, error) {
+	data, err := ioutil.ReadFile(location)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New(fmt.Sprintf("no PEM data found in '%s'", location))
+	}
+	return block, nil
+}
@@ -0,0 +1,141 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/wso2/update-creator-tool/constant"
+)
+
+// Checksum algorithms which can be used for the artifacts in the order of preference
+var supportedChecksumAlgorithms = []string{constant.CHECKSUM_ALGORITHM_SHA256, constant.CHECKSUM_ALGORITHM_MD5}
+
+// Hash functions of the supported checksum algorithms against the names of the algorithms
+var checksumHashes = map[string]func() hash.Hash{
+	constant.CHECKSUM_ALGORITHM_MD5:    md5.New,
+	constant.CHECKSUM_ALGORITHM_SHA256: sha256.New,
+}
+
+// Register the hash function of a checksum algorithm so that it can be used for the artifacts and for comparing the
+// files of updates with the files of distributions. Registered algorithms are preferred the least.
+func RegisterChecksumAlgorithm(algorithm string, newHash func() hash.Hash) {
+	if _, found := checksumHashes[algorithm]; !found {
+		supportedChecksumAlgorithms = append(supportedChecksumAlgorithms, algorithm)
+	}
+	checksumHashes[algorithm] = newHash
+}
+
+// Check whether the given checksum algorithm is supported.
+func IsSupportedChecksumAlgorithm(algorithm string) bool {
+	return IsStringIsInSlice(algorithm, supportedChecksumAlgorithms)
+}
+
+// Validate the given checksum algorithms. An error is returned if an algorithm is not supported.
+func ValidateChecksumAlgorithms(algorithms []string) error {
+	for _, algorithm := range algorithms {
+		if !IsSupportedChecksumAlgorithm(algorithm) {
+			return errors.New(fmt.Sprintf("unsupported checksum algorithm '%s'. Supported algorithms: %s", algorithm,
+				strings.Join(supportedChecksumAlgorithms, ", ")))
+		}
+	}
+	return nil
+}
+
+// Create a hash of the given checksum algorithm.
+func NewChecksumHash(algorithm string) (hash.Hash, error) {
+	newHash, found := checksumHashes[algorithm]
+	if !found {
+		return nil, ValidateChecksumAlgorithms([]string{algorithm})
+	}
+	return newHash(), nil
+}
+
+// Get the checksum of the content read from the given reader using the given algorithm. The content is streamed
+// through the hash, so it is not held in the memory.
+func GetChecksumOfReader(reader io.Reader, algorithm string) (string, error) {
+	checksumHash, err := NewChecksumHash(algorithm)
+	if err != nil {
+		return "", err
+	}
+	if _, err = io.Copy(checksumHash, reader); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(checksumHash.Sum(nil)), nil
+}
+
+// Get the checksum of the file at the given location using the given algorithm.
+func GetFileChecksum(location, algorithm string) (string, error) {
+	file, err := os.Open(location)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	return GetChecksumOfReader(file, algorithm)
+}
+
+// Get the checksum of the given algorithm recorded in the given catalog entry. An empty string is returned if the
+// entry does not have a checksum of the algorithm.
+func GetCatalogEntryChecksum(entry *CatalogEntry, algorithm string) string {
+	switch algorithm {
+	case constant.CHECKSUM_ALGORITHM_MD5:
+		return entry.MD5
+	case constant.CHECKSUM_ALGORITHM_SHA256:
+		return entry.SHA256
+	default:
+		return ""
+	}
+}
+
+// Negotiate the checksum algorithm of the artifact of the given catalog entry. The algorithm declared by the entry is
+// used if there is one. Otherwise the first algorithm in the preference of the catalog (sha256 and then md5 if the
+// catalog does not have a preference) of which the entry has a checksum is used. This allows the artifacts released
+// before a catalog moves to a stronger algorithm to be verified with the algorithm they were released with.
+func NegotiateChecksumAlgorithm(catalog *Catalog, entry *CatalogEntry) (string, error) {
+	if len(entry.ChecksumAlgorithm) != 0 {
+		if err := ValidateChecksumAlgorithms([]string{entry.ChecksumAlgorithm}); err != nil {
+			return "", errors.New(fmt.Sprintf("'%s' declares an %v", entry.UpdateName, err))
+		}
+		if len(GetCatalogEntryChecksum(entry, entry.ChecksumAlgorithm)) == 0 {
+			return "", errors.New(fmt.Sprintf("'%s' declares the checksum algorithm '%s' but does not have a %s "+
+				"checksum", entry.UpdateName, entry.ChecksumAlgorithm, entry.ChecksumAlgorithm))
+		}
+		return entry.ChecksumAlgorithm, nil
+	}
+	algorithms := supportedChecksumAlgorithms
+	if catalog != nil && len(catalog.ChecksumAlgorithms) != 0 {
+		algorithms = catalog.ChecksumAlgorithms
+	}
+	for _, algorithm := range algorithms {
+		if IsSupportedChecksumAlgorithm(algorithm) && len(GetCatalogEntryChecksum(entry, algorithm)) != 0 {
+			return algorithm, nil
+		}
+	}
+	return "", errors.New(fmt.Sprintf("'%s' does not have a checksum of the algorithms %s", entry.UpdateName,
+		strings.Join(algorithms, ", ")))
+}
@@ -0,0 +1,173 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/wso2/update-creator-tool/constant"
+)
+
+// struct which holds a stale file or directory which is removed by the clean command
+type StaleEntry struct {
+	Category     string
+	Path         string
+	Size         int64
+	LastModified time.Time
+}
+
+// Find the files and directories in the given directory which are not modified after the given time. Only the entries
+// of which the name starts with the given prefix are considered, and the given excluded paths are never returned.
+// Directories are considered modified when any file in them is modified. Nothing is returned if the directory does
+// not exist.
+func FindStaleEntries(category, directory, prefix string, modifiedBefore time.Time,
+	excludedPaths ...string) ([]StaleEntry, error) {
+	var staleEntries []StaleEntry
+	exists, err := IsDirectoryExists(directory)
+	if err != nil || !exists {
+		return staleEntries, err
+	}
+	fileInfos, err := ioutil.ReadDir(directory)
+	if err != nil {
+		return nil, err
+	}
+nextEntry:
+	for _, fileInfo := range fileInfos {
+		if !strings.HasPrefix(fileInfo.Name(), prefix) {
+			continue
+		}
+		entryPath := filepath.Join(directory, fileInfo.Name())
+		for _, excludedPath := range excludedPaths {
+			if len(excludedPath) != 0 && isSamePath(entryPath, excludedPath) {
+				continue nextEntry
+			}
+		}
+		size, lastModified, err := getDiskUsage(entryPath)
+		if err != nil {
+			return nil, err
+		}
+		if lastModified.Before(modifiedBefore) {
+			staleEntries = append(staleEntries, StaleEntry{
+				Category:     category,
+				Path:         entryPath,
+				Size:         size,
+				LastModified: lastModified,
+			})
+		}
+	}
+	return staleEntries, nil
+}
+
+// Find the workspaces which are not updated after the given time, ie: the updates of them are abandoned.
+func FindStaleWorkspaces(wumucHome string, updatedBefore time.Time) ([]StaleEntry, error) {
+	var staleEntries []StaleEntry
+	workspaces, err := ListWorkspaces(wumucHome)
+	if err != nil {
+		return nil, err
+	}
+	for _, workspace := range workspaces {
+		updatedAt, err := time.Parse(time.RFC3339, workspace.UpdatedAt)
+		if err != nil {
+			logger.Debug(fmt.Sprintf("Skipping workspace '%s' as its update time is invalid: %v", workspace.Name,
+				err))
+			continue
+		}
+		if !updatedAt.Before(updatedBefore) {
+			continue
+		}
+		workspaceDirectory := GetWorkspaceDirectory(wumucHome, workspace.Name)
+		size, _, err := getDiskUsage(workspaceDirectory)
+		if err != nil {
+			return nil, err
+		}
+		staleEntries = append(staleEntries, StaleEntry{
+			Category:     constant.CLEAN_WORKSPACES,
+			Path:         workspaceDirectory,
+			Size:         size,
+			LastModified: updatedAt,
+		})
+	}
+	return staleEntries, nil
+}
+
+// Remove the given stale entries and return the space reclaimed (in bytes) by removing them.
+func RemoveStaleEntries(staleEntries []StaleEntry) (int64, error) {
+	var reclaimedSpace int64
+	for _, staleEntry := range staleEntries {
+		logger.Debug(fmt.Sprintf("Removing %s", staleEntry.Path))
+		if err := os.RemoveAll(staleEntry.Path); err != nil {
+			return reclaimedSpace, err
+		}
+		reclaimedSpace += staleEntry.Size
+	}
+	return reclaimedSpace, nil
+}
+
+// Check whether the given paths refer to the same location.
+func isSamePath(path, otherPath string) bool {
+	absolutePath, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.Clean(path) == filepath.Clean(otherPath)
+	}
+	absoluteOtherPath, err := filepath.Abs(otherPath)
+	if err != nil {
+		return filepath.Clean(path) == filepath.Clean(otherPath)
+	}
+	return absolutePath == absoluteOtherPath
+}
+
+// Get the total size of the files at the given location and the latest modification time of them.
+func getDiskUsage(location string) (int64, time.Time, error) {
+	var size int64
+	var lastModified time.Time
+	err := filepath.Walk(location, func(path string, fileInfo os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !fileInfo.IsDir() {
+			size += fileInfo.Size()
+		}
+		if fileInfo.ModTime().After(lastModified) {
+			lastModified = fileInfo.ModTime()
+		}
+		return nil
+	})
+	return size, lastModified, err
+}
+
+// Format the given size in bytes in a human readable form (eg: 1.5 GB).
+func FormatSize(size int64) string {
+	units := []string{"B", "KB", "MB", "GB", "TB"}
+	value := float64(size)
+	unitIndex := 0
+	for value >= 1024 && unitIndex < len(units)-1 {
+		value /= 1024
+		unitIndex++
+	}
+	if unitIndex == 0 {
+		return fmt.Sprintf("%d %s", size, units[unitIndex])
+	}
+	return fmt.Sprintf("%.1f %s", value, units[unitIndex])
+}
@@ -0,0 +1,266 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/wso2/update-creator-tool/constant"
+	"gopkg.in/yaml.v2"
+)
+
+// Create a configuration bundle (zip) at the given location from the config file at the given location. The bundle
+// contains the config and the files referred by the keys in CONFIG_BUNDLE_FILE_KEYS, of which the paths are rewritten
+// relative to the bundle. Credentials (CONFIG_BUNDLE_SECRET_KEYS) are removed from the bundled config, and the other
+// files referred by the config (eg: signing keys) are kept as references. The locations of the bundled files are
+// returned.
+func ExportConfigBundle(configPath, bundlePath string) ([]string, error) {
+	config, err := readConfigFile(configPath)
+	if err != nil {
+		return nil, err
+	}
+	config = removeConfigSecrets(config)
+
+	bundledPaths := make(map[string]string)
+	var bundledFiles []string
+	err = mapConfigFiles(config, func(filePath string) (string, error) {
+		if bundledPath, found := bundledPaths[filePath]; found {
+			return bundledPath, nil
+		}
+		bundledPath := path.Join(constant.CONFIG_BUNDLE_FILES_DIRECTORY, strconv.Itoa(len(bundledFiles)),
+			filepath.Base(filePath))
+		bundledPaths[filePath] = bundledPath
+		bundledFiles = append(bundledFiles, filePath)
+		return bundledPath, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle, err := os.OpenFile(bundlePath, os.O_WRONLY|os.O_TRUNC|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	defer bundle.Close()
+	zipWriter := zip.NewWriter(bundle)
+	if err = writeZipEntry(zipWriter, constant.CONFIG_BUNDLE_CONFIG_FILE, data); err != nil {
+		return nil, err
+	}
+	for _, bundledFile := range bundledFiles {
+		data, err := ioutil.ReadFile(bundledFile)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("unable to read '%s' referred by the config: %v", bundledFile, err))
+		}
+		if err = writeZipEntry(zipWriter, bundledPaths[bundledFile], data); err != nil {
+			return nil, err
+		}
+	}
+	return bundledFiles, zipWriter.Close()
+}
+
+// Import the configuration bundle (zip) at the given location to the config file at the given location. The bundled
+// files are extracted to the given directory and the paths in the config are rewritten to them. Credentials in the
+// existing config file are kept so that they need not be provided again.
+func ImportConfigBundle(bundlePath, configPath, filesDirectory string) error {
+	zipReader, err := zip.OpenReader(bundlePath)
+	if err != nil {
+		return err
+	}
+	defer zipReader.Close()
+
+	var config yaml.MapSlice
+	bundledFiles := make(map[string]*zip.File)
+	for _, file := range zipReader.File {
+		if file.Name == constant.CONFIG_BUNDLE_CONFIG_FILE {
+			data, err := readZipFile(file)
+			if err != nil {
+				return err
+			}
+			if err = yaml.Unmarshal(data, &config); err != nil {
+				return errors.New(fmt.Sprintf("unable to read the bundled config: %v", err))
+			}
+		} else if !file.FileInfo().IsDir() {
+			bundledFiles[file.Name] = file
+		}
+	}
+	if config == nil {
+		return errors.New(fmt.Sprintf("'%s' not found in the bundle", constant.CONFIG_BUNDLE_CONFIG_FILE))
+	}
+
+	err = mapConfigFiles(config, func(bundledPath string) (string, error) {
+		file, found := bundledFiles[bundledPath]
+		if !found {
+			return "", errors.New(fmt.Sprintf("'%s' referred by the bundled config not found in the bundle",
+				bundledPath))
+		}
+		// Bundled paths are generated by the export, but are checked as the bundle may come from anywhere
+		destination := filepath.Join(filesDirectory, filepath.FromSlash(bundledPath))
+		if !strings.HasPrefix(destination, filepath.Clean(filesDirectory)+string(os.PathSeparator)) {
+			return "", errors.New(fmt.Sprintf("'%s' resolves outside '%s'", bundledPath, filesDirectory))
+		}
+		data, err := readZipFile(file)
+		if err != nil {
+			return "", err
+		}
+		if err = os.MkdirAll(filepath.Dir(destination), 0700); err != nil {
+			return "", err
+		}
+		return destination, ioutil.WriteFile(destination, data, 0600)
+	})
+	if err != nil {
+		return err
+	}
+
+	exists, err := IsFileExists(configPath)
+	if err != nil {
+		return err
+	}
+	if exists {
+		existingConfig, err := readConfigFile(configPath)
+		if err != nil {
+			return err
+		}
+		config = append(removeConfigSecrets(config), getConfigSecrets(existingConfig)...)
+	}
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(configPath, data, 0600)
+}
+
+// Read the config file at the given location preserving the order of the keys.
+func readConfigFile(configPath string) (yaml.MapSlice, error) {
+	data, err := ioutil.ReadFile(configPath)
+	if err != nil {
+		return nil, err
+	}
+	var config yaml.MapSlice
+	if err = yaml.Unmarshal(data, &config); err != nil {
+		return nil, errors.New(fmt.Sprintf("unable to read '%s': %v", configPath, err))
+	}
+	return config, nil
+}
+
+// Check whether the given top level key of the config holds a credential.
+func isConfigSecret(key interface{}) bool {
+	for _, secretKey := range strings.Split(constant.CONFIG_BUNDLE_SECRET_KEYS, ",") {
+		if strings.EqualFold(fmt.Sprint(key), secretKey) {
+			return true
+		}
+	}
+	return false
+}
+
+// Get the given config without the credentials.
+func removeConfigSecrets(config yaml.MapSlice) yaml.MapSlice {
+	var filteredConfig yaml.MapSlice
+	for _, item := range config {
+		if !isConfigSecret(item.Key) {
+			filteredConfig = append(filteredConfig, item)
+		}
+	}
+	return filteredConfig
+}
+
+// Get the credentials of the given config.
+func getConfigSecrets(config yaml.MapSlice) yaml.MapSlice {
+	var secrets yaml.MapSlice
+	for _, item := range config {
+		if isConfigSecret(item.Key) {
+			secrets = append(secrets, item)
+		}
+	}
+	return secrets
+}
+
+// Replace the paths of the files referred by the keys in CONFIG_BUNDLE_FILE_KEYS of the given config with the paths
+// returned by the given function. Keys are matched case insensitively as viper does. A key may hold a path, a list of
+// paths or a map of names to paths.
+func mapConfigFiles(config yaml.MapSlice, mapFile func(filePath string) (string, error)) error {
+	for _, fileKey := range strings.Split(constant.CONFIG_BUNDLE_FILE_KEYS, ",") {
+		item := getConfigItem(config, strings.Split(fileKey, "."))
+		if item == nil {
+			continue
+		}
+		var err error
+		switch value := item.Value.(type) {
+		case string:
+			item.Value, err = mapFile(value)
+		case []interface{}:
+			for index, filePath := range value {
+				if value[index], err = mapFile(fmt.Sprint(filePath)); err != nil {
+					break
+				}
+			}
+		case yaml.MapSlice:
+			for index := range value {
+				if value[index].Value, err = mapFile(fmt.Sprint(value[index].Value)); err != nil {
+					break
+				}
+			}
+		default:
+			err = errors.New(fmt.Sprintf("unexpected value '%v'", value))
+		}
+		if err != nil {
+			return errors.New(fmt.Sprintf("unable to bundle the files of '%s': %v", fileKey, err))
+		}
+	}
+	return nil
+}
+
+// Get the item of the given config at the given key path. Nil is returned if the key is not found.
+func getConfigItem(config yaml.MapSlice, keyPath []string) *yaml.MapItem {
+	for index := range config {
+		if !strings.EqualFold(fmt.Sprint(config[index].Key), keyPath[0]) {
+			continue
+		}
+		if len(keyPath) == 1 {
+			return &config[index]
+		}
+		if nestedConfig, ok := config[index].Value.(yaml.MapSlice); ok {
+			return getConfigItem(nestedConfig, keyPath[1:])
+		}
+		return nil
+	}
+	return nil
+}
+
+// Write an entry with the given name and data to the given zip.
+func writeZipEntry(zipWriter *zip.Writer, name string, data []byte) error {
+	writer, err := zipWriter.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(data)
+	return err
+}
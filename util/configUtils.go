@@ -95,28 +95,19 @@ func SetWUMUCLocalRepo(localRepoPath string) {
 	}
 }
 
-// Write wum-uc configuration to the config file.
+// Write wum-uc configuration to the config file. The other settings in the file (platform versions, resource files,
+// etc) are kept as they are.
 func WriteConfigFile(wumucConfig *WUMUCConfig, wumucConfigFilePath string) error {
 	logger.Debug(fmt.Sprintf("Writing wum-uc configs to %s file", wumucConfigFilePath))
 	data, err := yaml.Marshal(wumucConfig)
 	if err != nil {
 		return err
 	}
-	// Open a new file for writing only
-	file, err := os.OpenFile(
-		wumucConfigFilePath,
-		os.O_WRONLY|os.O_TRUNC|os.O_CREATE,
-		0600,
-	)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-	_, err = file.Write(data)
-	if err != nil {
+	var values yaml.MapSlice
+	if err = yaml.Unmarshal(data, &values); err != nil {
 		return err
 	}
-	return nil
+	return SaveConfigValues(wumucConfigFilePath, values)
 }
 
 // Validate wum-uc configurations
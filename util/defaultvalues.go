@@ -14,6 +14,8 @@
 
 package util
 
+import "github.com/wso2/update-creator-tool/constant"
+
 // Default values used in the application
 var (
 	EnableDebugLogs = false
@@ -21,12 +23,149 @@ var (
 	// We only check md5 if -m flag is not found. If -m is set, it's value by default is true. That means we don't
 	// want to check md5 if this value is true. By default we want to check. So that's why we have set
 	// CheckMd5Disabled to false here.
-	CheckMd5Disabled        = false
+	CheckMd5Disabled = false
+	// Warnings are only printed by default. If the strict mode is enabled, warnings will cause the tool to exit.
+	StrictModeEnabled = false
+	// Steps which depend on online services (JIRA, WUM, etc) continue in degraded mode with placeholders if the
+	// services are unreachable. If online services are required, the tool will exit instead.
+	RequireOnline = false
+	// Messages and prompts are printed in human readable form by default. If the JSON IO mode is enabled, they are
+	// exchanged as JSON objects (one per line) so that the interactive flows can be driven by scripts.
+	JSONIOEnabled           = false
 	ResourceFiles_Mandatory = []string{"LICENSE.txt"}
 	ResourceFiles_Optional  = []string{"update-descriptor.yaml", "update-descriptor3.yaml", "instructions.txt",
 		"NOT_A_CONTRIBUTION.txt"}
-	ResourceFiles_Skip = []string{"README.txt"}
-	PlatformVersions   = map[string]string{
+	// Deprecated. Files given here are ignored when matching. Use IGNORED_FILES.MATCHING instead.
+	ResourceFiles_Skip = []string{}
+	// Files which are not matched with the distribution. README.txt is read separately when creating updates.
+	IgnoredFiles_Matching = []string{"README.txt"}
+	// Files which are excluded from update zips. Names can be glob patterns (eg: *.orig).
+	IgnoredFiles_Zip = []string{}
+	// Descending into nested archives is disabled by default. If enabled, archives in the distribution which match
+	// one of the following patterns will be read and their content will be added to the distribution tree.
+	DescendNestedArchives  = false
+	NestedArchivesPatterns = []string{"*.war", "*.car"}
+	// Update numbers should match the following pattern and should be within the following range. Uniqueness of the
+	// update number is not checked by default. Supported values are 'ledger' and 'api'.
+	UpdateNumberPattern         = constant.UPDATE_NUMBER_REGEX
+	UpdateNumberMin             = 0
+	UpdateNumberMax             = 9999
+	UpdateNumberUniquenessCheck = ""
+	// Update numbers reserved in WUM are valid for the following number of hours, unless WUM gives their expiry.
+	UpdateNumberReservationValidity = 72
+	// External validators are executables which are invoked with the update manifest as JSON in the stdin. A non-zero
+	// exit code is treated as a validation failure. No validators are run by default.
+	Validators = []string{}
+	// Rego policies (files or directories) which are evaluated over the update manifest using the OPA executable when
+	// validating updates. The query should evaluate to the set of denial reasons. No policies are evaluated by default.
+	OPAExecutable = "opa"
+	OPAPolicies   = []string{}
+	OPAQuery      = "data.wum_uc.deny"
+	// Thresholds of the size and the scope of updates. Exceeding a threshold prints a warning by default, it fails the
+	// validation if the action is 'fail'. A zero value disables the threshold.
+	GuardrailsMaxChangedFiles         = 500
+	GuardrailsMaxPayloadSize          = 209715200
+	GuardrailsMaxDistributionFraction = 0.2
+	GuardrailsAction                  = constant.GUARDRAILS_ACTION_WARN
+	// Update zips are not limited in size by default. Budgets of platforms and products are configured under
+	// SIZE_BUDGETS.PLATFORMS and SIZE_BUDGETS.PRODUCTS, the smallest applicable budget is enforced.
+	SizeBudgetsMaxUpdateSize = 0
+	// Updates are not checked against a catalog of released updates by default. If a catalog is given, updates are
+	// checked for duplicates and for overwriting the changes of prior updates which they do not supersede.
+	UpdateCatalogLocation = ""
+	UpdateCatalogKey      = ""
+	// Catalogs prefer sha256 over md5. Updates do not declare the checksum algorithm by default, so the algorithm of
+	// each update in a catalog is negotiated using the preference of the catalog.
+	UpdateCatalogChecksumAlgorithms = []string{constant.CHECKSUM_ALGORITHM_SHA256, constant.CHECKSUM_ALGORITHM_MD5}
+	ChecksumAlgorithm               = ""
+	// Files of updates are compared with the files of distributions using md5 by default. sha256 can be required
+	// instead with the '--hash' flag.
+	HashAlgorithm = constant.CHECKSUM_ALGORITHM_MD5
+	// Files of updates are checked in parallel when validating. A worker is used per CPU if the number of workers is
+	// not positive.
+	ValidationWorkers = 0
+	// Files of distributions are hashed in parallel when reading them. A worker is used per CPU if the number of
+	// workers is not positive.
+	DistributionWorkers = 0
+	// Files of distributions are streamed through the hash. Nested archives, which should be read randomly, are kept
+	// in the memory if they are not larger than the following size (in MB) and in temporary files otherwise.
+	DistributionMaxMemory = 256
+	// Events emitted during the update creation are not sent anywhere by default. They can be printed to the console,
+	// appended to a JSON lines file and posted to webhooks.
+	EventsConsole  = false
+	EventsFile     = ""
+	EventsWebhooks = []string{}
+	// Binary deltas are disabled by default. If enabled, modified files which match one of the following patterns
+	// and are larger than the minimum size (in bytes) are stored as deltas against the distribution when the delta
+	// is smaller than the full file.
+	BinaryDeltaEnabled  = false
+	BinaryDeltaMinSize  = 1048576
+	BinaryDeltaPatterns = []string{"*.jar", "*.war"}
+	// Payloads of updates are not scanned for malware by default. If enabled, the payload is scanned with the given
+	// scanner command (clamscan convention) or the given ICAP service (icap://host[:port]/service) before zipping.
+	ScanEnabled = false
+	ScanCommand = "clamscan -r --no-summary"
+	ScanICAPURL = ""
+	// Payloads of updates are scanned for secrets by default. Patterns are regular expressions used in addition to the
+	// default rules (private keys, passwords, tokens, etc). Allowed values are the well known values of the samples,
+	// which are not reported as secrets.
+	SecretScanEnabled       = true
+	SecretScanPatterns      = []string{}
+	SecretScanAllowedValues = []string{"admin", "wso2carbon", "changeit", "password", "secret"}
+	// Logs are only printed to the console by default. If enabled, logs of each session are also written to a time
+	// stamped file in the logs directory of the wum-uc home, which is rotated when it exceeds the maximum size (in MB).
+	// Log files of the given number of latest sessions are retained.
+	LogsEnabled     = false
+	LogsLevel       = "debug"
+	LogsMaxFileSize = 10
+	LogsRetention   = 10
+	// Descriptor template, policies and destination mappings are synced from the following Git repository and branch
+	// by 'wum-uc templates sync'.
+	TemplatesRepository = ""
+	TemplatesBranch     = "master"
+	// Update directories are modified by default (eg: LICENSE.txt and the update descriptors are written to them). If
+	// the inputs are read only, the update directory and the distribution are never written.
+	ReadOnlyInputs = false
+	// Payload of updates is in the carbon.home directory by default. Product lines with different home layouts can
+	// declare their payload directory against their product family (platform name or product name).
+	PayloadDirectories = map[string]string{}
+	// A SLSA provenance attestation is generated for each created update. It is signed if a signing key (RSA private
+	// key in PEM) is given.
+	ProvenanceEnabled    = true
+	ProvenanceSigningKey = ""
+	// Updates are signed by 'wum-uc sign' with the GPG secret key in the following keyring (an exported secret key or a
+	// secring.gpg). The key is selected by its id if the keyring has more than one secret key.
+	GPGKeyring = ""
+	GPGKeyID   = ""
+	// Records of the audit log are keyed with the audit key in the wum-uc home directory (created when the first
+	// operation is recorded) unless the location of another key (32 hex encoded bytes) is given.
+	AuditKey = ""
+	// An instructions.txt skeleton with the diffs of the config files changed by an update is generated if the update
+	// changes files under the following prefixes (relative to carbon.home) and it has no instructions.txt.
+	InstructionsConfigPrefixes = []string{"repository/conf", "conf"}
+	// LICENSE.txt of updates is downloaded from the following locations. Security updates use the security license
+	// and do not have a NOT_A_CONTRIBUTION.txt. LICENSE_URL environment variable overrides both locations.
+	LegalFilesLicenseURL         = constant.LICENSE_DOWNLOAD_URL
+	LegalFilesSecurityLicenseURL = constant.LICENSE_DOWNLOAD_URL
+	// Large files (eg: update zips synced from remote stores) are downloaded in chunks of the following size (in
+	// bytes). A failed chunk is retried the following number of times before the download fails.
+	DownloadChunkSize = 8388608
+	DownloadRetries   = 5
+	// Created updates are uploaded to the following locations (artifact URIs) after committing them to the SVN.
+	PublishLocations = []string{}
+	// Approvals are not required to publish updates by default. If approvals are required, the public keys (PEM) of
+	// the reviewers should be configured under APPROVALS.REVIEWER_KEYS, as only the signed approvals of the listed
+	// reviewers are counted.
+	ApprovalsRequired = 0
+	// 'wum-uc clean' removes the caches, temporary directories, logs and workspaces which are not modified for the
+	// following number of days by default.
+	CleanOlderThanDays = 7
+	// Messages are shown in English by default. Messages of the built-in locales 'ja' and 'es' can be overridden (and
+	// other locales can be added) with <locale>.yaml files in the messages directory of the wum-uc home.
+	Locale = ""
+	// Updates are packaged as zip files by default. Supported formats are 'zip' and 'tar.zst'.
+	UpdateFormat     = constant.UPDATE_FORMAT_ZIP
+	PlatformVersions = map[string]string{
 		"4.2.0": "turing",
 		"4.3.0": "perlis",
 		"4.4.0": "wilkes",
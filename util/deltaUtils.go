@@ -0,0 +1,204 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/wso2/update-creator-tool/constant"
+)
+
+// struct which is used to record the metadata of a file stored as a binary delta in update-descriptor3.yaml
+type BinaryDelta struct {
+	File       string `yaml:"file"`
+	Algorithm  string `yaml:"algorithm"`
+	BaseMd5    string `yaml:"base_md5"`
+	TargetMd5  string `yaml:"target_md5"`
+	TargetSize int    `yaml:"target_size"`
+	DeltaSize  int    `yaml:"delta_size"`
+}
+
+// Operations of a binary delta. A delta is a sequence of operations which either copy a range of the base content or
+// insert new content.
+const (
+	deltaCopyOperation   = 'C'
+	deltaInsertOperation = 'I'
+)
+
+// Rolling checksum of a block which can be updated in constant time when the block is moved by one byte.
+type rollingChecksum struct {
+	a, b uint32
+}
+
+func newRollingChecksum(block []byte) rollingChecksum {
+	checksum := rollingChecksum{}
+	for i, value := range block {
+		checksum.a += uint32(value)
+		checksum.b += uint32(len(block)-i) * uint32(value)
+	}
+	return checksum
+}
+
+func (checksum *rollingChecksum) roll(out, in byte, blockSize int) {
+	checksum.a = checksum.a - uint32(out) + uint32(in)
+	checksum.b = checksum.b - uint32(blockSize)*uint32(out) + checksum.a
+}
+
+func (checksum *rollingChecksum) value() uint32 {
+	return checksum.a&0xffff | checksum.b<<16
+}
+
+// Create a binary delta which transforms the given base content to the given target content. Blocks of the base
+// content are indexed by their rolling checksums and the target content is scanned for matching blocks.
+func CreateDelta(base, target []byte) []byte {
+	blockSize := constant.BINARY_DELTA_BLOCK_SIZE
+	index := make(map[uint32][]int)
+	for offset := 0; offset+blockSize <= len(base); offset += blockSize {
+		checksum := newRollingChecksum(base[offset : offset+blockSize])
+		index[checksum.value()] = append(index[checksum.value()], offset)
+	}
+
+	delta := bytes.NewBufferString(constant.BINARY_DELTA_MAGIC)
+	insertStart := 0
+	position := 0
+	var checksum rollingChecksum
+	if len(target) >= blockSize {
+		checksum = newRollingChecksum(target[:blockSize])
+	}
+	for position+blockSize <= len(target) {
+		matchOffset := -1
+		for _, offset := range index[checksum.value()] {
+			if bytes.Equal(base[offset:offset+blockSize], target[position:position+blockSize]) {
+				matchOffset = offset
+				break
+			}
+		}
+		if matchOffset == -1 {
+			if position+blockSize < len(target) {
+				checksum.roll(target[position], target[position+blockSize], blockSize)
+			}
+			position++
+			continue
+		}
+		// Extend the match forwards and backwards
+		length := blockSize
+		for matchOffset+length < len(base) && position+length < len(target) &&
+			base[matchOffset+length] == target[position+length] {
+			length++
+		}
+		for matchOffset > 0 && position > insertStart && base[matchOffset-1] == target[position-1] {
+			matchOffset--
+			position--
+			length++
+		}
+		writeDeltaInsert(delta, target[insertStart:position])
+		writeDeltaCopy(delta, matchOffset, length)
+		position += length
+		insertStart = position
+		if position+blockSize <= len(target) {
+			checksum = newRollingChecksum(target[position : position+blockSize])
+		}
+	}
+	writeDeltaInsert(delta, target[insertStart:])
+	return delta.Bytes()
+}
+
+func writeDeltaCopy(delta *bytes.Buffer, offset, length int) {
+	buffer := make([]byte, binary.MaxVarintLen64)
+	delta.WriteByte(deltaCopyOperation)
+	delta.Write(buffer[:binary.PutUvarint(buffer, uint64(offset))])
+	delta.Write(buffer[:binary.PutUvarint(buffer, uint64(length))])
+}
+
+func writeDeltaInsert(delta *bytes.Buffer, data []byte) {
+	if len(data) == 0 {
+		return
+	}
+	buffer := make([]byte, binary.MaxVarintLen64)
+	delta.WriteByte(deltaInsertOperation)
+	delta.Write(buffer[:binary.PutUvarint(buffer, uint64(len(data)))])
+	delta.Write(data)
+}
+
+// Apply the given binary delta to the given base content and return the target content.
+func ApplyDelta(base, delta []byte) ([]byte, error) {
+	if !bytes.HasPrefix(delta, []byte(constant.BINARY_DELTA_MAGIC)) {
+		return nil, errors.New("invalid binary delta")
+	}
+	reader := bytes.NewReader(delta[len(constant.BINARY_DELTA_MAGIC):])
+	var target bytes.Buffer
+	for {
+		operation, err := reader.ReadByte()
+		if err != nil {
+			// End of the delta
+			return target.Bytes(), nil
+		}
+		switch operation {
+		case deltaCopyOperation:
+			offset, err := binary.ReadUvarint(reader)
+			if err != nil {
+				return nil, errors.New("invalid binary delta: " + err.Error())
+			}
+			length, err := binary.ReadUvarint(reader)
+			if err != nil {
+				return nil, errors.New("invalid binary delta: " + err.Error())
+			}
+			if offset+length > uint64(len(base)) {
+				return nil, errors.New("invalid binary delta: copy exceeds the base content")
+			}
+			target.Write(base[offset : offset+length])
+		case deltaInsertOperation:
+			length, err := binary.ReadUvarint(reader)
+			if err != nil {
+				return nil, errors.New("invalid binary delta: " + err.Error())
+			}
+			if length > uint64(reader.Len()) {
+				return nil, errors.New("invalid binary delta: insert exceeds the delta")
+			}
+			data := make([]byte, length)
+			reader.Read(data)
+			target.Write(data)
+		default:
+			return nil, errors.New(fmt.Sprintf("invalid binary delta: unknown operation '%c'", operation))
+		}
+	}
+}
+
+// Reconstruct the full file from the given base content and the binary delta, and verify it against the metadata
+// recorded in the update descriptor.
+func ReconstructFromDelta(base, delta []byte, binaryDelta *BinaryDelta) ([]byte, error) {
+	if baseMd5 := fmt.Sprintf("%x", md5.Sum(base)); baseMd5 != binaryDelta.BaseMd5 {
+		return nil, errors.New(fmt.Sprintf("md5 of the base of '%s' is '%s', expected '%s'", binaryDelta.File,
+			baseMd5, binaryDelta.BaseMd5))
+	}
+	target, err := ApplyDelta(base, delta)
+	if err != nil {
+		return nil, err
+	}
+	if targetMd5 := fmt.Sprintf("%x", md5.Sum(target)); targetMd5 != binaryDelta.TargetMd5 {
+		return nil, errors.New(fmt.Sprintf("md5 of the reconstructed '%s' is '%s', expected '%s'",
+			binaryDelta.File, targetMd5, binaryDelta.TargetMd5))
+	}
+	return target, nil
+}
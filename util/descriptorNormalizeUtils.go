@@ -0,0 +1,158 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	utf8BOM    = []byte{0xEF, 0xBB, 0xBF}
+	utf16LEBOM = []byte{0xFF, 0xFE}
+	utf16BEBOM = []byte{0xFE, 0xFF}
+)
+
+// Read the given update-descriptor.yaml or update-descriptor3.yaml content to the given descriptor, which should be a
+// *UpdateDescriptorV2 or a *UpdateDescriptorV3. Descriptors authored on Windows are normalized transparently, ie: byte
+// order marks are removed, UTF-16 content is decoded and the paths with backslashes or drive letters are converted to
+// the '/' separated paths relative to carbon.home. A warning is printed for each normalization.
+func UnmarshalUpdateDescriptor(data []byte, fileName string, descriptor interface{}) error {
+	data, err := normalizeDescriptorEncoding(data, fileName)
+	if err != nil {
+		return err
+	}
+	if err = yaml.Unmarshal(data, descriptor); err != nil {
+		return err
+	}
+	var paths []*string
+	switch updateDescriptor := descriptor.(type) {
+	case *UpdateDescriptorV2:
+		paths = getUpdateDescriptorV2Paths(updateDescriptor)
+	case *UpdateDescriptorV3:
+		paths = getUpdateDescriptorV3Paths(updateDescriptor)
+	}
+	normalizedCount, example := 0, ""
+	for _, filePath := range paths {
+		normalizedPath := NormalizeDescriptorPath(*filePath)
+		if normalizedPath == *filePath {
+			continue
+		}
+		if normalizedCount == 0 {
+			example = fmt.Sprintf("'%s' as '%s'", *filePath, normalizedPath)
+		}
+		normalizedCount++
+		*filePath = normalizedPath
+	}
+	if normalizedCount > 0 {
+		PrintWarning(fmt.Sprintf("%d path(s) in '%s' are in the Windows format. They are read as '/' separated paths "+
+			"relative to carbon.home (eg: %s).", normalizedCount, fileName, example))
+	}
+	return nil
+}
+
+// Convert the given path in an update descriptor to a '/' separated path relative to carbon.home by replacing the
+// backslashes and removing the drive letter (eg: 'C:\repository\conf\carbon.xml' is converted to
+// 'repository/conf/carbon.xml').
+func NormalizeDescriptorPath(filePath string) string {
+	normalizedPath := strings.Replace(filePath, "\\", "/", -1)
+	if len(normalizedPath) >= 2 && normalizedPath[1] == ':' &&
+		strings.ContainsRune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ", rune(normalizedPath[0])) {
+		normalizedPath = strings.TrimLeft(normalizedPath[2:], "/")
+	}
+	return normalizedPath
+}
+
+// Remove the byte order mark of the given descriptor content and decode it to UTF-8 if it is encoded in UTF-16
+func normalizeDescriptorEncoding(data []byte, fileName string) ([]byte, error) {
+	var byteOrder binary.ByteOrder
+	switch {
+	case bytes.HasPrefix(data, utf8BOM):
+		PrintWarning(fmt.Sprintf("'%s' starts with a UTF-8 byte order mark. It is ignored.", fileName))
+		return data[len(utf8BOM):], nil
+	case bytes.HasPrefix(data, utf16LEBOM):
+		byteOrder = binary.LittleEndian
+	case bytes.HasPrefix(data, utf16BEBOM):
+		byteOrder = binary.BigEndian
+	default:
+		return data, nil
+	}
+	content := data[len(utf16LEBOM):]
+	if len(content)%2 != 0 {
+		return nil, errors.New(fmt.Sprintf("'%s' is not a valid UTF-16 file.", fileName))
+	}
+	codeUnits := make([]uint16, len(content)/2)
+	for i := range codeUnits {
+		codeUnits[i] = byteOrder.Uint16(content[i*2:])
+	}
+	PrintWarning(fmt.Sprintf("'%s' is encoded in UTF-16. It is read as UTF-8.", fileName))
+	return []byte(string(utf16.Decode(codeUnits))), nil
+}
+
+// Get the paths of the files in the given update-descriptor.yaml
+func getUpdateDescriptorV2Paths(updateDescriptorV2 *UpdateDescriptorV2) []*string {
+	var paths []*string
+	paths = appendPathReferences(paths, updateDescriptorV2.FileChanges.AddedFiles)
+	paths = appendPathReferences(paths, updateDescriptorV2.FileChanges.ModifiedFiles)
+	return appendPathReferences(paths, updateDescriptorV2.FileChanges.RemovedFiles)
+}
+
+// Get the paths of the files in the given update-descriptor3.yaml
+func getUpdateDescriptorV3Paths(updateDescriptorV3 *UpdateDescriptorV3) []*string {
+	var paths []*string
+	for _, products := range [][]ProductChanges{updateDescriptorV3.CompatibleProducts,
+		updateDescriptorV3.PartiallyApplicableProducts} {
+		for _, product := range products {
+			paths = appendPathReferences(paths, product.AddedFiles)
+			paths = appendPathReferences(paths, product.ModifiedFiles)
+			paths = appendPathReferences(paths, product.RemovedFiles)
+		}
+	}
+	for i := range updateDescriptorV3.BinaryDeltas {
+		paths = append(paths, &updateDescriptorV3.BinaryDeltas[i].File)
+	}
+	for i := range updateDescriptorV3.TemplatedFiles {
+		paths = append(paths, &updateDescriptorV3.TemplatedFiles[i].Path)
+	}
+	for i := range updateDescriptorV3.FileOwnerships {
+		paths = append(paths, &updateDescriptorV3.FileOwnerships[i].Path)
+	}
+	for i := range updateDescriptorV3.OSVariants {
+		paths = append(paths, &updateDescriptorV3.OSVariants[i].Path)
+	}
+	for i := range updateDescriptorV3.SecretWaivers {
+		paths = append(paths, &updateDescriptorV3.SecretWaivers[i].Path)
+	}
+	return appendPathReferences(paths, updateDescriptorV3.ExecutableFiles)
+}
+
+// Append the references to the given paths, so that they can be normalized in place
+func appendPathReferences(paths []*string, filePaths []string) []*string {
+	for i := range filePaths {
+		paths = append(paths, &filePaths[i])
+	}
+	return paths
+}
@@ -0,0 +1,233 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package util
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+	"github.com/wso2/update-creator-tool/constant"
+	"gopkg.in/yaml.v2"
+)
+
+// struct which is used to save the update-descriptor.yaml while matching the files of an update, so that an aborted
+// session can be resumed
+type PartialUpdateDescriptor struct {
+	UpdateName         string `yaml:"update_name"`
+	UpdateDescriptorV2 `yaml:",inline"`
+	// Root level directories and files of the update directory of which the files are matched
+	MatchedEntries []string `yaml:"matched_entries"`
+	// Owners and groups of the copied files if they are recorded
+	FileOwnerships []FileOwnership `yaml:"file_ownerships,omitempty"`
+	// Files which are not copied to the update
+	SkippedFiles []SkippedFile `yaml:"skipped_files,omitempty"`
+}
+
+// struct which is used to record a file of the update directory which is not copied to the update, so that reviewers
+// can confirm that nothing important is left out of the update
+type SkippedFile struct {
+	Path   string `yaml:"path"`
+	Reason string `yaml:"reason"`
+	// Location in the distribution which the file was matched with, if any
+	Location string `yaml:"location,omitempty"`
+}
+
+// struct which is used to write the skipped-files.yaml next to the update zip
+type SkippedFilesManifest struct {
+	UpdateName   string        `yaml:"update_name"`
+	SkippedFiles []SkippedFile `yaml:"skipped_files"`
+}
+
+// Compare the update-descriptor.yaml and the update-descriptor3.yaml of the same update and return the divergences in
+// the update number, the platform and the changed files. Added and modified files are compared together as a file
+// added in update-descriptor.yaml can be modified for some products. Files of the products which declare payload
+// roots are not compared as they are not in the carbon.home described by update-descriptor.yaml.
+func CompareUpdateDescriptors(updateDescriptorV2 *UpdateDescriptorV2, updateDescriptorV3 *UpdateDescriptorV3) []string {
+	var divergences []string
+	compareField := func(field, valueV2, valueV3 string) {
+		if valueV2 != valueV3 {
+			divergences = append(divergences, fmt.Sprintf("'%s' is '%s' in %s but '%s' in %s", field, valueV2,
+				constant.UPDATE_DESCRIPTOR_V2_FILE, valueV3, constant.UPDATE_DESCRIPTOR_V3_FILE))
+		}
+	}
+	compareField("update_number", updateDescriptorV2.UpdateNumber, updateDescriptorV3.UpdateNumber)
+	compareField("platform_name", updateDescriptorV2.PlatformName, updateDescriptorV3.PlatformName)
+	compareField("platform_version", updateDescriptorV2.PlatformVersion, updateDescriptorV3.PlatformVersion)
+
+	changedFilesV2 := make(map[string]bool)
+	addToFileSet(changedFilesV2, updateDescriptorV2.FileChanges.AddedFiles)
+	addToFileSet(changedFilesV2, updateDescriptorV2.FileChanges.ModifiedFiles)
+	removedFilesV2 := make(map[string]bool)
+	addToFileSet(removedFilesV2, updateDescriptorV2.FileChanges.RemovedFiles)
+	changedFilesV3 := make(map[string]bool)
+	removedFilesV3 := make(map[string]bool)
+	products := append(append([]ProductChanges{}, updateDescriptorV3.CompatibleProducts...),
+		updateDescriptorV3.PartiallyApplicableProducts...)
+	for _, product := range products {
+		if len(product.PayloadRoot) != 0 {
+			continue
+		}
+		addToFileSet(changedFilesV3, product.AddedFiles)
+		addToFileSet(changedFilesV3, product.ModifiedFiles)
+		addToFileSet(removedFilesV3, product.RemovedFiles)
+	}
+	compareFiles := func(change string, filesV2, filesV3 map[string]bool) {
+		for _, file := range getSortedFiles(filesV2) {
+			if !filesV3[file] {
+				divergences = append(divergences, fmt.Sprintf("'%s' is %s in %s but not in %s", file, change,
+					constant.UPDATE_DESCRIPTOR_V2_FILE, constant.UPDATE_DESCRIPTOR_V3_FILE))
+			}
+		}
+		for _, file := range getSortedFiles(filesV3) {
+			if !filesV2[file] {
+				divergences = append(divergences, fmt.Sprintf("'%s' is %s in %s but not in %s", file, change,
+					constant.UPDATE_DESCRIPTOR_V3_FILE, constant.UPDATE_DESCRIPTOR_V2_FILE))
+			}
+		}
+	}
+	compareFiles("added or modified", changedFilesV2, changedFilesV3)
+	compareFiles("removed", removedFilesV2, removedFilesV3)
+	return divergences
+}
+
+// Check whether the given update only removes files. Such updates do not have a payload and the products in their
+// update-descriptor3.yaml only have removed files.
+func IsRemoveOnlyUpdate(updateDescriptorV3 *UpdateDescriptorV3) bool {
+	isFileRemoved := false
+	products := append(append([]ProductChanges{}, updateDescriptorV3.CompatibleProducts...),
+		updateDescriptorV3.PartiallyApplicableProducts...)
+	for _, product := range products {
+		if len(product.AddedFiles) != 0 || len(product.ModifiedFiles) != 0 {
+			return false
+		}
+		if len(product.RemovedFiles) != 0 {
+			isFileRemoved = true
+		}
+	}
+	return isFileRemoved
+}
+
+// Get the files removed by the products of the given update which do not declare payload roots, sorted by the path.
+func GetRemovedFiles(updateDescriptorV3 *UpdateDescriptorV3) []string {
+	removedFiles := make(map[string]bool)
+	products := append(append([]ProductChanges{}, updateDescriptorV3.CompatibleProducts...),
+		updateDescriptorV3.PartiallyApplicableProducts...)
+	for _, product := range products {
+		if len(product.PayloadRoot) != 0 {
+			continue
+		}
+		addToFileSet(removedFiles, product.RemovedFiles)
+	}
+	return getSortedFiles(removedFiles)
+}
+
+// Get the files added by the products of the given update which do not declare payload roots, sorted by the path.
+func GetAddedFiles(updateDescriptorV3 *UpdateDescriptorV3) []string {
+	addedFiles := make(map[string]bool)
+	products := append(append([]ProductChanges{}, updateDescriptorV3.CompatibleProducts...),
+		updateDescriptorV3.PartiallyApplicableProducts...)
+	for _, product := range products {
+		if len(product.PayloadRoot) != 0 {
+			continue
+		}
+		addToFileSet(addedFiles, product.AddedFiles)
+	}
+	return getSortedFiles(addedFiles)
+}
+
+// Get the files modified by the products of the given update which do not declare payload roots, sorted by the path.
+func GetModifiedFiles(updateDescriptorV3 *UpdateDescriptorV3) []string {
+	modifiedFiles := make(map[string]bool)
+	products := append(append([]ProductChanges{}, updateDescriptorV3.CompatibleProducts...),
+		updateDescriptorV3.PartiallyApplicableProducts...)
+	for _, product := range products {
+		if len(product.PayloadRoot) != 0 {
+			continue
+		}
+		addToFileSet(modifiedFiles, product.ModifiedFiles)
+	}
+	return getSortedFiles(modifiedFiles)
+}
+
+// Check whether the executable files declared in the given update-descriptor3.yaml are valid. Each file should be
+// declared once with a path relative to carbon.home, and should not be removed by the update.
+func ValidateExecutableFiles(updateDescriptorV3 *UpdateDescriptorV3) error {
+	removedFiles := make(map[string]bool)
+	addToFileSet(removedFiles, GetRemovedFiles(updateDescriptorV3))
+	executableFiles := make(map[string]bool)
+	for _, executableFile := range updateDescriptorV3.ExecutableFiles {
+		if len(executableFile) == 0 || path.IsAbs(executableFile) || path.Clean(executableFile) != executableFile ||
+			strings.HasPrefix(executableFile, "../") {
+			return errors.New(fmt.Sprintf("executable file '%s' should be a path relative to %s", executableFile,
+				constant.CARBON_HOME))
+		}
+		if executableFiles[executableFile] {
+			return errors.New(fmt.Sprintf("executable file '%s' is declared more than once", executableFile))
+		}
+		if removedFiles[executableFile] {
+			return errors.New(fmt.Sprintf("executable file '%s' is removed by the update", executableFile))
+		}
+		executableFiles[executableFile] = true
+	}
+	return nil
+}
+
+// Save the given partial update descriptor to the given directory.
+func SavePartialUpdateDescriptor(directory string, partialUpdateDescriptor *PartialUpdateDescriptor) error {
+	data, err := yaml.Marshal(partialUpdateDescriptor)
+	if err != nil {
+		return err
+	}
+	if err = CreateDirectory(directory); err != nil {
+		return err
+	}
+	return afero.WriteFile(FileSystem, filepath.Join(directory, constant.PARTIAL_UPDATE_DESCRIPTOR_FILE), data, 0600)
+}
+
+// Write the given skipped files manifest to the given location.
+func SaveSkippedFilesManifest(manifestPath string, manifest *SkippedFilesManifest) error {
+	data, err := yaml.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+	return afero.WriteFile(FileSystem, GetLongPath(manifestPath), data, 0644)
+}
+
+// Load the partial update descriptor saved to the given directory. Nil is returned if there is no partial update
+// descriptor.
+func LoadPartialUpdateDescriptor(directory string) (*PartialUpdateDescriptor, error) {
+	data, err := afero.ReadFile(FileSystem, filepath.Join(directory, constant.PARTIAL_UPDATE_DESCRIPTOR_FILE))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	partialUpdateDescriptor := PartialUpdateDescriptor{}
+	if err = yaml.Unmarshal(data, &partialUpdateDescriptor); err != nil {
+		return nil, errors.New(fmt.Sprintf("unable to read the partial update descriptor '%s': %v",
+			constant.PARTIAL_UPDATE_DESCRIPTOR_FILE, err))
+	}
+	return &partialUpdateDescriptor, nil
+}
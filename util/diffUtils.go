@@ -0,0 +1,94 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/wso2/update-creator-tool/constant"
+)
+
+// struct which represents a single line of a line based diff
+type DiffLine struct {
+	// One of ' ' (unchanged), '-' (removed) or '+' (added)
+	Type string
+	Text string
+}
+
+// Check whether the given content is text. Content is considered as binary if it contains a NUL byte within the
+// first few kilobytes.
+func IsTextContent(data []byte) bool {
+	if len(data) > constant.TEXT_CONTENT_SNIFF_LENGTH {
+		data = data[:constant.TEXT_CONTENT_SNIFF_LENGTH]
+	}
+	return bytes.IndexByte(data, 0) == -1
+}
+
+// Get the line based diff between the given old and new content using the longest common subsequence of lines.
+func GetLineDiff(oldContent, newContent string) ([]DiffLine, error) {
+	oldLines := strings.Split(strings.Replace(oldContent, "\r\n", "\n", -1), "\n")
+	newLines := strings.Split(strings.Replace(newContent, "\r\n", "\n", -1), "\n")
+	if len(oldLines) > constant.MAX_DIFF_LINES || len(newLines) > constant.MAX_DIFF_LINES {
+		return nil, errors.New(fmt.Sprintf("content is too large to diff, maximum number of lines is %d",
+			constant.MAX_DIFF_LINES))
+	}
+	// lcs[i][j] holds the length of the longest common subsequence of oldLines[i:] and newLines[j:]
+	lcs := make([][]int, len(oldLines)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(newLines)+1)
+	}
+	for i := len(oldLines) - 1; i >= 0; i-- {
+		for j := len(newLines) - 1; j >= 0; j-- {
+			if oldLines[i] == newLines[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	var diff []DiffLine
+	i, j := 0, 0
+	for i < len(oldLines) && j < len(newLines) {
+		switch {
+		case oldLines[i] == newLines[j]:
+			diff = append(diff, DiffLine{Type: " ", Text: oldLines[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			diff = append(diff, DiffLine{Type: "-", Text: oldLines[i]})
+			i++
+		default:
+			diff = append(diff, DiffLine{Type: "+", Text: newLines[j]})
+			j++
+		}
+	}
+	for ; i < len(oldLines); i++ {
+		diff = append(diff, DiffLine{Type: "-", Text: oldLines[i]})
+	}
+	for ; j < len(newLines); j++ {
+		diff = append(diff, DiffLine{Type: "+", Text: newLines[j]})
+	}
+	return diff, nil
+}
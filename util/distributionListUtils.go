@@ -0,0 +1,111 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"gopkg.in/yaml.v2"
+)
+
+// struct which is used to read the distribution list given with '--dist-list' to create and validate a platform-wide
+// update for several products
+type DistributionList struct {
+	Distributions []ListedDistribution `yaml:"distributions"`
+}
+
+// struct which is used to map a product to its distribution zip, given as a path or a URI
+type ListedDistribution struct {
+	ProductName    string `yaml:"product_name"`
+	ProductVersion string `yaml:"product_version"`
+	Distribution   string `yaml:"distribution"`
+}
+
+// Read the distribution list in the given location. Each product should be listed only once with its distribution.
+func LoadDistributionList(listPath string) ([]ListedDistribution, error) {
+	data, err := ioutil.ReadFile(listPath)
+	if err != nil {
+		return nil, err
+	}
+	distributionList := DistributionList{}
+	if err = yaml.Unmarshal(data, &distributionList); err != nil {
+		return nil, errors.New(fmt.Sprintf("invalid distribution list '%s': %v", listPath, err))
+	}
+	if len(distributionList.Distributions) == 0 {
+		return nil, errors.New(fmt.Sprintf("no distributions found in the distribution list '%s'", listPath))
+	}
+	listedProducts := make(map[string]bool)
+	for _, listedDistribution := range distributionList.Distributions {
+		if len(listedDistribution.ProductName) == 0 || len(listedDistribution.ProductVersion) == 0 ||
+			len(listedDistribution.Distribution) == 0 {
+			return nil, errors.New(fmt.Sprintf("'product_name', 'product_version' and 'distribution' are "+
+				"required for each distribution in '%s'", listPath))
+		}
+		productId := listedDistribution.GetProductId()
+		if listedProducts[productId] {
+			return nil, errors.New(fmt.Sprintf("'%s' is listed more than once in '%s'", productId, listPath))
+		}
+		listedProducts[productId] = true
+	}
+	return distributionList.Distributions, nil
+}
+
+// Return the id (<product>-<version>) of the product of the listed distribution.
+func (listedDistribution *ListedDistribution) GetProductId() string {
+	return listedDistribution.ProductName + "-" + listedDistribution.ProductVersion
+}
+
+// Get the changes of the given file changes of an update, matched against the first listed distribution, for the
+// product of the given listed distribution. The exists function reports whether a file is in the distribution of the
+// product. Files which are in the distribution are modified and the others are added. Removed files which are not in
+// the distribution are left out. The product is partially applicable (isCompatible is false) if any file modified or
+// removed by the update is not in its distribution.
+func GetListedProductChanges(listedDistribution *ListedDistribution, updateDescriptorV2 *UpdateDescriptorV2,
+	exists func(relativePath string) bool) (productChanges *PartialUpdatedProducts, isCompatible bool) {
+	productChanges = &PartialUpdatedProducts{
+		ProductName: listedDistribution.ProductName,
+		BaseVersion: listedDistribution.ProductVersion,
+	}
+	isCompatible = true
+	for _, addedFile := range updateDescriptorV2.FileChanges.AddedFiles {
+		if exists(addedFile) {
+			productChanges.ModifiedFiles = append(productChanges.ModifiedFiles, addedFile)
+		} else {
+			productChanges.AddedFiles = append(productChanges.AddedFiles, addedFile)
+		}
+	}
+	for _, modifiedFile := range updateDescriptorV2.FileChanges.ModifiedFiles {
+		if exists(modifiedFile) {
+			productChanges.ModifiedFiles = append(productChanges.ModifiedFiles, modifiedFile)
+		} else {
+			isCompatible = false
+		}
+	}
+	for _, removedFile := range updateDescriptorV2.FileChanges.RemovedFiles {
+		if exists(removedFile) {
+			productChanges.RemovedFiles = append(productChanges.RemovedFiles, removedFile)
+		} else {
+			isCompatible = false
+		}
+	}
+	return productChanges, isCompatible
+}
@@ -0,0 +1,366 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/viper"
+	"github.com/wso2/update-creator-tool/constant"
+)
+
+// Distribution sources provide the content of distributions as file systems rooted at the distribution root (the
+// directory which contains bin, repository, etc). The distribution tree is built by walking the file system, so new
+// kinds of distributions (mounted ISOs, container layers, embedded test fixtures, etc) only need a new source.
+type DistributionSource interface {
+	// Check whether the distribution at the given location is provided by this source.
+	Matches(location string) bool
+	// Open the distribution at the given location. The returned closer is closed once the distribution is read.
+	Open(location string) (fs.FS, io.Closer, error)
+}
+
+// Source which reads the distribution zips. The content of the root folder of the zip is provided.
+type zipDistributionSource struct{}
+
+func (source *zipDistributionSource) Matches(location string) bool {
+	return strings.HasSuffix(location, constant.ZIP_FILE_EXTENSION)
+}
+
+func (source *zipDistributionSource) Open(location string) (fs.FS, io.Closer, error) {
+	zipReader, err := zip.OpenReader(location)
+	if err != nil {
+		return nil, nil, err
+	}
+	distributionFS, err := fs.Sub(zipReader, getZipRootFolder(&zipReader.Reader))
+	if err != nil {
+		zipReader.Close()
+		return nil, nil, err
+	}
+	return distributionFS, zipReader, nil
+}
+
+// Source which reads the distributions available as directories (eg: a mounted ISO or a container layer). The
+// directory is the distribution root and is only read.
+type directoryDistributionSource struct{}
+
+func (source *directoryDistributionSource) Matches(location string) bool {
+	fileInfo, err := os.Stat(location)
+	return err == nil && fileInfo.IsDir()
+}
+
+func (source *directoryDistributionSource) Open(location string) (fs.FS, io.Closer, error) {
+	return os.DirFS(location), nopCloser{}, nil
+}
+
+// Source which reads the distribution tarballs (.tar.gz, .tgz). Tarballs can only be read sequentially, so the tarball
+// is converted to a zip which is read in the same way as a distribution zip. Converted zips are cached in the artifacts
+// directory of the wum-uc home. If the wum-uc home is not set, the tarball is converted to a temporary zip which is
+// removed once it is read.
+type tarGzDistributionSource struct{}
+
+func (source *tarGzDistributionSource) Matches(location string) bool {
+	return IsTarGzFile(location)
+}
+
+func (source *tarGzDistributionSource) Open(location string) (fs.FS, io.Closer, error) {
+	if len(viper.GetString(constant.WUM_UC_HOME)) != 0 {
+		zipPath, err := getConvertedDistributionZip(location)
+		if err != nil {
+			return nil, nil, err
+		}
+		return (&zipDistributionSource{}).Open(zipPath)
+	}
+	tempDirectory, err := ioutil.TempDir("", constant.TEMP_DIRECTORY_PREFIX)
+	if err != nil {
+		return nil, nil, err
+	}
+	zipPath, err := convertDistributionTarGz(location, tempDirectory)
+	if err != nil {
+		os.RemoveAll(tempDirectory)
+		return nil, nil, err
+	}
+	distributionFS, zipCloser, err := (&zipDistributionSource{}).Open(zipPath)
+	if err != nil {
+		os.RemoveAll(tempDirectory)
+		return nil, nil, err
+	}
+	return distributionFS, &tempDirectoryCloser{closer: zipCloser, directory: tempDirectory}, nil
+}
+
+// Get the zip converted from the given distribution tarball in the artifacts directory of the wum-uc home. Converted
+// zips are kept in a directory per tarball (keyed by the absolute path of the tarball) which has a directory per state
+// of the tarball (keyed by its size and modification time), so that tarballs with the same name do not share a zip.
+// The tarball is converted if it is not converted yet or if it is modified after the conversion, in which case the zip
+// converted from the previous state is removed.
+func getConvertedDistributionZip(location string) (string, error) {
+	absolutePath, err := filepath.Abs(location)
+	if err != nil {
+		return "", err
+	}
+	tarGzInfo, err := os.Stat(absolutePath)
+	if err != nil {
+		return "", err
+	}
+	tarballDirectory := filepath.Join(viper.GetString(constant.WUM_UC_HOME), constant.WUMUC_ARTIFACTS_DIRECTORY,
+		getCacheKey(absolutePath))
+	stateDirectory := filepath.Join(tarballDirectory, getCacheKey(fmt.Sprintf("%d:%d", tarGzInfo.Size(),
+		tarGzInfo.ModTime().UnixNano())))
+	zipPath := filepath.Join(stateDirectory, GetDistributionName(location)+constant.ZIP_FILE_EXTENSION)
+	if _, err = os.Stat(zipPath); err == nil {
+		logger.Debug(fmt.Sprintf("Using %s converted from %s", zipPath, location))
+		return zipPath, nil
+	}
+	if err = os.RemoveAll(tarballDirectory); err != nil {
+		return "", err
+	}
+	if err = CreateDirectory(stateDirectory); err != nil {
+		return "", err
+	}
+	return convertDistributionTarGz(location, stateDirectory)
+}
+
+// Get the key of the given value which is used as a directory name in the caches
+func getCacheKey(value string) string {
+	hash := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(hash[:])[:16]
+}
+
+// Convert the given distribution tarball to a zip with the same entries in the given directory and return the
+// location of the zip. The zip is written to a partial file first so that an interrupted conversion is not reused.
+func convertDistributionTarGz(location, directory string) (string, error) {
+	zipPath := filepath.Join(directory, GetDistributionName(location)+constant.ZIP_FILE_EXTENSION)
+	PrintInfo(fmt.Sprintf("Converting '%s' to a zip. Please wait...", location))
+	partialZipPath := zipPath + constant.PARTIAL_FILE_EXTENSION
+	err := ConvertTarGzToZip(location, partialZipPath)
+	if err == nil {
+		err = os.Rename(partialZipPath, zipPath)
+	}
+	if err != nil {
+		os.Remove(partialZipPath)
+		return "", err
+	}
+	logger.Debug(fmt.Sprintf("Converted %s to %s", location, zipPath))
+	return zipPath, nil
+}
+
+// Closer which removes the given temporary directory after closing the given closer
+type tempDirectoryCloser struct {
+	closer    io.Closer
+	directory string
+}
+
+func (closer *tempDirectoryCloser) Close() error {
+	err := closer.closer.Close()
+	if removeErr := os.RemoveAll(closer.directory); err == nil {
+		err = removeErr
+	}
+	return err
+}
+
+// Closer of the distributions which do not hold resources
+type nopCloser struct{}
+
+func (closer nopCloser) Close() error {
+	return nil
+}
+
+// Distribution sources in the order they are checked
+var distributionSources = []DistributionSource{&directoryDistributionSource{}, &zipDistributionSource{},
+	&tarGzDistributionSource{}}
+
+// Register the given distribution source. Registered sources are checked before the existing sources.
+func RegisterDistributionSource(source DistributionSource) {
+	distributionSources = append([]DistributionSource{source}, distributionSources...)
+}
+
+// Open the distribution at the given location with the first source which provides it.
+func OpenDistribution(location string) (fs.FS, io.Closer, error) {
+	for _, source := range distributionSources {
+		if source.Matches(location) {
+			return source.Open(location)
+		}
+	}
+	return nil, nil, errors.New(fmt.Sprintf("'%s' is not a supported distribution. Distribution must be a zip file, "+
+		"a tar.gz file or a directory.", location))
+}
+
+// Check whether the distribution at the given location can be opened by one of the distribution sources.
+func CheckDistribution(location string) error {
+	distributionFS, closer, err := OpenDistribution(location)
+	if err != nil {
+		return err
+	}
+	defer closer.Close()
+	_, err = fs.Stat(distributionFS, ".")
+	return err
+}
+
+// Get the zip which provides the distribution at the given location, which is the zip converted from the distribution
+// if it is a tarball. Whether the distribution is provided by a zip is returned as well, as other distributions (eg:
+// directories) are read as they are.
+func GetDistributionZip(location string) (string, bool, error) {
+	for _, source := range distributionSources {
+		if !source.Matches(location) {
+			continue
+		}
+		switch source.(type) {
+		case *zipDistributionSource:
+			return location, true, nil
+		case *tarGzDistributionSource:
+			zipPath, err := getConvertedDistributionZip(location)
+			return zipPath, err == nil, err
+		default:
+			return "", false, nil
+		}
+	}
+	return "", false, nil
+}
+
+// Get the root folder of the given zip. An empty string is returned if the entries are not inside a single root
+// folder, in which case the entries are relative to the root of the zip.
+func getZipRootFolder(zipReader *zip.Reader) string {
+	rootFolder := ""
+	for _, file := range zipReader.File {
+		if !strings.Contains(file.Name, "/") {
+			return "."
+		}
+		folder := strings.SplitN(file.Name, "/", 2)[0]
+		if len(rootFolder) != 0 && folder != rootFolder {
+			return "."
+		}
+		rootFolder = folder
+	}
+	if len(rootFolder) == 0 {
+		return "."
+	}
+	return rootFolder
+}
+
+// Read the paths which are declared as non-updatable in the ignore manifest of the given distribution. An empty list
+// is returned if the distribution does not have an ignore manifest.
+func ReadDistributionIgnoreManifest(distributionFS fs.FS) ([]string, error) {
+	data, err := fs.ReadFile(distributionFS, constant.WUM_IGNORE_FILE)
+	if errors.Is(err, fs.ErrNotExist) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	ignoredPaths := ParseIgnoreManifest(data)
+	logger.Debug(fmt.Sprintf("Paths ignored by %s: %v", constant.WUM_IGNORE_FILE, ignoredPaths))
+	return ignoredPaths, nil
+}
+
+// Read the given file of the distribution, of which the path is relative to the distribution root. Whether the file is
+// in the distribution is returned as well, as the files which are not in the distribution are not errors.
+func ReadDistributionFile(distributionFS fs.FS, relativePath string) ([]byte, bool, error) {
+	if !fs.ValidPath(relativePath) {
+		return nil, false, nil
+	}
+	fileInfo, err := fs.Stat(distributionFS, relativePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if fileInfo.IsDir() {
+		return nil, false, nil
+	}
+	data, err := fs.ReadFile(distributionFS, relativePath)
+	if err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+// Get the size and the CRC32 checksum of the given file if they are available without reading the file (eg: from the
+// headers of a zip entry).
+func GetDistributionFileChecksum(fileInfo fs.FileInfo) (size int64, crc32 uint32, found bool) {
+	if header, isZipEntry := fileInfo.Sys().(*zip.FileHeader); isZipEntry {
+		return int64(header.UncompressedSize64), header.CRC32, true
+	}
+	return 0, 0, false
+}
+
+// struct which holds content which is buffered to be read randomly (eg: a nested archive in a distribution). Content
+// is buffered in the memory unless it is larger than the allowed memory, in which case it is buffered in a temporary
+// file.
+type BufferedContent struct {
+	io.ReaderAt
+	Size     int64
+	tempFile *os.File
+}
+
+// Buffer the content of the given reader which has the given size (negative if not known). Content is only kept in
+// the memory if it is not larger than the given maximum memory (in bytes).
+func BufferContent(reader io.Reader, size, maxMemory int64) (*BufferedContent, error) {
+	if size >= 0 && size <= maxMemory {
+		data, err := ioutil.ReadAll(reader)
+		if err != nil {
+			return nil, err
+		}
+		return &BufferedContent{ReaderAt: bytes.NewReader(data), Size: int64(len(data))}, nil
+	}
+	tempFile, err := ioutil.TempFile("", constant.TEMP_DIRECTORY_PREFIX)
+	if err != nil {
+		return nil, err
+	}
+	content := &BufferedContent{ReaderAt: tempFile, tempFile: tempFile}
+	logger.Debug(fmt.Sprintf("Buffering content of %d bytes in %s", size, tempFile.Name()))
+	if content.Size, err = io.Copy(tempFile, reader); err != nil {
+		content.Close()
+		return nil, err
+	}
+	return content, nil
+}
+
+// Release the buffered content. The temporary file is removed if the content is buffered in a file.
+func (content *BufferedContent) Close() error {
+	if content.tempFile == nil {
+		return nil
+	}
+	content.tempFile.Close()
+	return os.Remove(content.tempFile.Name())
+}
+
+// Get the product name of the distribution at the given location, which is the name of the zip or the tarball without
+// the extension or the name of the directory.
+func GetDistributionName(location string) string {
+	name := filepath.Base(filepath.Clean(location))
+	for _, extension := range []string{constant.ZIP_FILE_EXTENSION, constant.TAR_GZ_FILE_EXTENSION,
+		constant.TGZ_FILE_EXTENSION} {
+		if strings.HasSuffix(name, extension) {
+			return strings.TrimSuffix(name, extension)
+		}
+	}
+	return name
+}
@@ -0,0 +1,289 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/wso2/update-creator-tool/constant"
+	"gopkg.in/yaml.v2"
+)
+
+// struct which is used to read and write the chunk manifest (<file>.chunks) published with a file. It contains the
+// sha256 checksum of each chunk of the file, so that each chunk can be verified when it is downloaded.
+type ChunkManifest struct {
+	Size      int64    `yaml:"size"`
+	ChunkSize int64    `yaml:"chunk_size"`
+	Chunks    []string `yaml:"chunks"`
+}
+
+// Delay before the first retry of a failed download. The delay is increased for each retry.
+var downloadRetryDelay = time.Duration(constant.DOWNLOAD_RETRY_DELAY_IN_SECONDS) * time.Second
+
+// Create the chunk manifest of the file at the given location.
+func CreateChunkManifest(location string, chunkSize int64) (*ChunkManifest, error) {
+	if chunkSize <= 0 {
+		return nil, errors.New(fmt.Sprintf("invalid chunk size %d", chunkSize))
+	}
+	file, err := os.Open(location)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	chunkManifest := ChunkManifest{ChunkSize: chunkSize}
+	chunk := make([]byte, chunkSize)
+	for {
+		length, err := io.ReadFull(file, chunk)
+		if length > 0 {
+			chunkManifest.Chunks = append(chunkManifest.Chunks, getChunkChecksum(chunk[:length]))
+			chunkManifest.Size += int64(length)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return &chunkManifest, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Downloads the file at the given url to the given location in chunks of the given size using range requests. Each
+// chunk is retried the given number of times, so a failure only restarts the failed chunk. If a chunk manifest is
+// published with the file, the chunk size is taken from it and each chunk is verified against its checksum. An
+// interrupted download is resumed from the last complete (and verified) chunk in the partial file. Files which are
+// not served with range support are downloaded with DownloadFileWithResume.
+func DownloadFileInChunks(file, url string, chunkSize int64, retries int) error {
+	var chunkManifest *ChunkManifest
+	chunkManifestURL := AppendToArtifactURI(url, constant.CHUNK_MANIFEST_EXTENSION)
+	err := retryDownload(retries, chunkManifestURL, func() error {
+		var err error
+		chunkManifest, err = getChunkManifest(chunkManifestURL)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	var size int64
+	if chunkManifest != nil {
+		size = chunkManifest.Size
+		chunkSize = chunkManifest.ChunkSize
+	} else {
+		err = retryDownload(retries, url, func() error {
+			var err error
+			size, err = getRangedContentLength(url)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if size < 0 || chunkSize <= 0 {
+		logger.Debug(fmt.Sprintf("Range requests are not supported for %s, downloading the whole file", url))
+		return retryDownload(retries, url, func() error {
+			return DownloadFileWithResume(file, url)
+		})
+	}
+
+	partialFile := file + constant.PARTIAL_FILE_EXTENSION
+	offset, err := getVerifiedChunksLength(partialFile, chunkSize, chunkManifest)
+	if err != nil {
+		return err
+	}
+	if offset > size {
+		offset = 0
+	}
+	out, err := os.OpenFile(partialFile, os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	// Incomplete and unverified chunks at the end of the partial file are downloaded again
+	if err = out.Truncate(offset); err != nil {
+		out.Close()
+		return err
+	}
+	if offset > 0 {
+		logger.Debug(fmt.Sprintf("Resuming the download of %s from %d bytes", url, offset))
+	}
+	for offset < size {
+		chunkIndex := int(offset / chunkSize)
+		end := offset + chunkSize
+		if end > size {
+			end = size
+		}
+		var chunk []byte
+		err = retryDownload(retries, fmt.Sprintf("chunk %d of %s", chunkIndex+1, url), func() error {
+			var err error
+			chunk, err = downloadChunk(url, offset, end)
+			if err != nil || chunkManifest == nil {
+				return err
+			}
+			if chunkIndex >= len(chunkManifest.Chunks) || getChunkChecksum(chunk) != chunkManifest.Chunks[chunkIndex] {
+				return errors.New(fmt.Sprintf("checksum of chunk %d of %s does not match its chunk manifest",
+					chunkIndex+1, url))
+			}
+			return nil
+		})
+		if err == nil {
+			_, err = out.WriteAt(chunk, offset)
+		}
+		if err != nil {
+			out.Close()
+			return err
+		}
+		logger.Trace(fmt.Sprintf("Chunk %d of %s downloaded", chunkIndex+1, url))
+		offset = end
+	}
+	if err = out.Close(); err != nil {
+		return err
+	}
+	return os.Rename(partialFile, file)
+}
+
+// Run the given download operation and retry it the given number of times if it fails. The delay between the
+// attempts is increased after each attempt.
+func retryDownload(retries int, description string, download func() error) error {
+	err := download()
+	for attempt := 1; err != nil && attempt <= retries; attempt++ {
+		logger.Debug(fmt.Sprintf("Downloading %s failed: %v. Retrying (%d/%d)", description, err, attempt,
+			retries))
+		time.Sleep(time.Duration(attempt) * downloadRetryDelay)
+		err = download()
+	}
+	return err
+}
+
+// Get the chunk manifest at the given url. Nil is returned if the chunk manifest is not published.
+func getChunkManifest(url string) (*ChunkManifest, error) {
+	response, err := http.Get(url)
+	if err != nil {
+		return nil, NewNetworkError(err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		logger.Debug(fmt.Sprintf("Chunk manifest not found at %s (%s)", url, response.Status))
+		return nil, nil
+	}
+	data, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	chunkManifest := ChunkManifest{}
+	if err = yaml.Unmarshal(data, &chunkManifest); err != nil {
+		return nil, errors.New(fmt.Sprintf("invalid chunk manifest at %s: %v", url, err))
+	}
+	if chunkManifest.ChunkSize <= 0 ||
+		int64(len(chunkManifest.Chunks)) != (chunkManifest.Size+chunkManifest.ChunkSize-1)/chunkManifest.ChunkSize {
+		return nil, errors.New(fmt.Sprintf("invalid chunk manifest at %s: chunks do not match the size", url))
+	}
+	return &chunkManifest, nil
+}
+
+// Get the length of the file at the given url if the server supports range requests for it. -1 is returned
+// otherwise.
+func getRangedContentLength(url string) (int64, error) {
+	response, err := http.Head(url)
+	if err != nil {
+		return -1, NewNetworkError(err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return -1, NewNetworkError(errors.New(fmt.Sprintf("Could not download the file from: %s (%s)", url,
+			response.Status)))
+	}
+	if response.Header.Get("Accept-Ranges") != "bytes" {
+		return -1, nil
+	}
+	return response.ContentLength, nil
+}
+
+// Download the bytes of the file at the given url from the given start offset up to the given end offset (exclusive).
+func downloadChunk(url string, start, end int64) ([]byte, error) {
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, end-1))
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return nil, NewNetworkError(err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusPartialContent {
+		return nil, NewNetworkError(errors.New(fmt.Sprintf("Could not download the bytes %d-%d from: %s (%s)",
+			start, end-1, url, response.Status)))
+	}
+	chunk, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, NewNetworkError(err)
+	}
+	if int64(len(chunk)) != end-start {
+		return nil, errors.New(fmt.Sprintf("received %d bytes instead of %d bytes from: %s", len(chunk),
+			end-start, url))
+	}
+	return chunk, nil
+}
+
+// Get the length of the complete chunks at the beginning of the given partial file. If a chunk manifest is given,
+// only the chunks up to the first chunk which does not match its checksum are counted.
+func getVerifiedChunksLength(partialFile string, chunkSize int64, chunkManifest *ChunkManifest) (int64, error) {
+	fileInfo, err := os.Stat(partialFile)
+	if os.IsNotExist(err) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	length := fileInfo.Size() - fileInfo.Size()%chunkSize
+	if chunkManifest == nil {
+		return length, nil
+	}
+	file, err := os.Open(partialFile)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	chunk := make([]byte, chunkSize)
+	var verifiedLength int64
+	for chunkIndex := 0; verifiedLength < length && chunkIndex < len(chunkManifest.Chunks); chunkIndex++ {
+		if _, err = io.ReadFull(file, chunk); err != nil {
+			return 0, err
+		}
+		if getChunkChecksum(chunk) != chunkManifest.Chunks[chunkIndex] {
+			logger.Debug(fmt.Sprintf("Chunk %d of %s does not match its chunk manifest", chunkIndex+1,
+				partialFile))
+			break
+		}
+		verifiedLength += chunkSize
+	}
+	return verifiedLength, nil
+}
+
+// Get the sha256 checksum of the given chunk.
+func getChunkChecksum(chunk []byte) string {
+	checksum := sha256.Sum256(chunk)
+	return hex.EncodeToString(checksum[:])
+}
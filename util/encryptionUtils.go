@@ -0,0 +1,234 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/wso2/update-creator-tool/constant"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/packet"
+	// Hash which is assumed for the recipient keys without hash preferences (eg: keys generated by Go) when encrypting
+	_ "golang.org/x/crypto/ripemd160"
+)
+
+// Checks whether the given file is an encrypted update.
+func IsEncryptedFile(filePath string) bool {
+	return strings.HasSuffix(filePath, constant.ENCRYPTED_FILE_EXTENSION)
+}
+
+// Encrypts the given file for the given recipients and writes it to the target as a binary OpenPGP message (RFC 4880),
+// so it can also be decrypted with standard tools (eg: 'gpg --decrypt'). recipients is a map of recipient names
+// against the locations of their OpenPGP public keys (armored or binary). The message is signed with the given signer
+// if it is not nil, so that the recipients can verify who encrypted it.
+func EncryptFile(source, target string, recipients map[string]string, signer *openpgp.Entity) error {
+	if len(recipients) == 0 {
+		return errors.New("no recipients given")
+	}
+	var names []string
+	for name := range recipients {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var keys openpgp.EntityList
+	for _, name := range names {
+		keyring, err := ReadGPGKeyring(recipients[name])
+		if err != nil {
+			return errors.New(fmt.Sprintf("unable to read the public key of '%s': %v", name, err))
+		}
+		keys = append(keys, keyring...)
+	}
+
+	sourceFile, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer sourceFile.Close()
+	targetFile, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer targetFile.Close()
+	hints := &openpgp.FileHints{IsBinary: true, FileName: filepath.Base(source)}
+	plaintext, err := openpgp.Encrypt(targetFile, keys, signer, hints, nil)
+	if err != nil {
+		return errors.New(fmt.Sprintf("unable to encrypt '%s': %v", source, err))
+	}
+	if _, err = io.Copy(plaintext, sourceFile); err != nil {
+		plaintext.Close()
+		return err
+	}
+	if err = plaintext.Close(); err != nil {
+		return err
+	}
+	return targetFile.Close()
+}
+
+// Reads the ids of the keys for which the given encrypted update is encrypted, as 16 hexadecimal digits.
+func ReadEncryptionKeyIds(source string) ([]string, error) {
+	keyIds, _, err := readEncryptionPackets(source)
+	return keyIds, err
+}
+
+// Reads the ids of the keys for which the given encrypted update is encrypted and whether the encrypted data is
+// integrity protected.
+func readEncryptionPackets(source string) ([]string, bool, error) {
+	file, err := os.Open(source)
+	if err != nil {
+		return nil, false, err
+	}
+	defer file.Close()
+	var keyIds []string
+	packets := packet.NewReader(file)
+	for {
+		p, err := packets.Next()
+		if err != nil {
+			return nil, false, errors.New(fmt.Sprintf("'%s' is not a valid encrypted update: %v", source, err))
+		}
+		switch p := p.(type) {
+		case *packet.EncryptedKey:
+			keyIds = append(keyIds, fmt.Sprintf("%016X", p.KeyId))
+		case *packet.SymmetricallyEncrypted:
+			// Encrypted keys precede the encrypted data
+			return keyIds, p.MDC, nil
+		}
+	}
+}
+
+// Decrypts the given encrypted update using the secret keys in the given keyring and writes it to the target. One of
+// the keys should be a recipient of the update. The passphrase of an encrypted secret key is requested with the given
+// function. Updates which are not integrity protected are rejected, and the target is removed if the integrity of the
+// decrypted update cannot be verified.
+func DecryptFile(source, target string, keyring openpgp.EntityList,
+	getPassphrase func(entity *openpgp.Entity) ([]byte, error)) error {
+	_, err := decryptFile(source, target, keyring, nil, getPassphrase)
+	return err
+}
+
+// Decrypts the given encrypted file as DecryptFile does and verifies that it is signed with one of the given trusted
+// keys. The target is removed if the signature cannot be verified. The trusted key which signed the file is returned.
+func DecryptSignedFile(source, target string, keyring, trustedKeys openpgp.EntityList,
+	getPassphrase func(entity *openpgp.Entity) ([]byte, error)) (*openpgp.Entity, error) {
+	if len(trustedKeys) == 0 {
+		return nil, errors.New("no trusted keys given to verify the signature")
+	}
+	return decryptFile(source, target, keyring, trustedKeys, getPassphrase)
+}
+
+// Decrypts the given encrypted file to the target. The file should be signed with one of the given trusted keys if
+// any are given, and the trusted key which signed it is returned.
+func decryptFile(source, target string, keyring, trustedKeys openpgp.EntityList,
+	getPassphrase func(entity *openpgp.Entity) ([]byte, error)) (*openpgp.Entity, error) {
+	// Updates could be modified without being detected if the encrypted data is not integrity protected
+	_, isIntegrityProtected, err := readEncryptionPackets(source)
+	if err != nil {
+		return nil, err
+	}
+	if !isIntegrityProtected {
+		return nil, errors.New(fmt.Sprintf("'%s' is not integrity protected", source))
+	}
+	sourceFile, err := os.Open(source)
+	if err != nil {
+		return nil, err
+	}
+	defer sourceFile.Close()
+	isPrompted := false
+	prompt := func(keys []openpgp.Key, symmetric bool) ([]byte, error) {
+		// Prompt is called again if the decrypted keys do not decrypt the update
+		if symmetric || isPrompted {
+			return nil, errors.New("no usable secret key found")
+		}
+		isPrompted = true
+		for _, key := range keys {
+			if key.PrivateKey == nil || !key.PrivateKey.Encrypted {
+				continue
+			}
+			passphrase, err := getPassphrase(key.Entity)
+			if err != nil {
+				return nil, err
+			}
+			if err = key.PrivateKey.Decrypt(passphrase); err != nil {
+				return nil, errors.New(fmt.Sprintf("unable to decrypt the GPG key %s: %v",
+					GetGPGKeyDescription(key.Entity), err))
+			}
+		}
+		return nil, nil
+	}
+	// Signer is looked up in the trusted keys as well, and is checked to be a trusted key after reading the message
+	message, err := openpgp.ReadMessage(sourceFile, append(append(openpgp.EntityList{}, keyring...),
+		trustedKeys...), prompt, nil)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("unable to decrypt '%s': %v", source, err))
+	}
+	if !message.IsEncrypted {
+		return nil, errors.New(fmt.Sprintf("'%s' is not encrypted", source))
+	}
+	logger.Debug(fmt.Sprintf("Decrypting %s with the key %s", source,
+		GetGPGKeyDescription(message.DecryptedWith.Entity)))
+	targetFile, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, err
+	}
+	// Integrity and the signature of the message are verified when the whole body is read
+	if _, err = io.Copy(targetFile, message.UnverifiedBody); err != nil {
+		targetFile.Close()
+		os.Remove(target)
+		return nil, errors.New(fmt.Sprintf("unable to decrypt '%s': %v", source, err))
+	}
+	if err = targetFile.Close(); err != nil || len(trustedKeys) == 0 {
+		return nil, err
+	}
+	signer, err := getTrustedSigner(message, trustedKeys)
+	if err != nil {
+		os.Remove(target)
+		return nil, errors.New(fmt.Sprintf("signature of '%s' is invalid: %v", source, err))
+	}
+	return signer, nil
+}
+
+// Get the trusted key which signed the given message of which the body is fully read. An error is returned if the
+// message is not signed, the signature is invalid or the signer is not one of the given trusted keys.
+func getTrustedSigner(message *openpgp.MessageDetails, trustedKeys openpgp.EntityList) (*openpgp.Entity, error) {
+	if !message.IsSigned {
+		return nil, errors.New("not signed")
+	}
+	if message.SignedBy == nil {
+		return nil, errors.New(fmt.Sprintf("signed with the unknown key %016X", message.SignedByKeyId))
+	}
+	if message.SignatureError != nil {
+		return nil, message.SignatureError
+	}
+	if message.Signature == nil && message.SignatureV3 == nil {
+		return nil, errors.New("signature not found")
+	}
+	signer := message.SignedBy.Entity
+	for _, trustedKey := range trustedKeys {
+		if trustedKey.PrimaryKey.Fingerprint == signer.PrimaryKey.Fingerprint {
+			return trustedKey, nil
+		}
+	}
+	return nil, errors.New(fmt.Sprintf("signed with the key %s which is not trusted", GetGPGKeyDescription(signer)))
+}
@@ -0,0 +1,96 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package util
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+
+	"github.com/wso2/update-creator-tool/constant"
+)
+
+// Replace the ${env:NAME} placeholders in the given text with the values of the environment variables. An error is
+// returned if a referred environment variable is not set.
+func ResolveEnvPlaceholders(text string) (string, error) {
+	var err error
+	envPlaceholderRegex := regexp.MustCompile(constant.ENV_PLACEHOLDER_REGEX)
+	resolvedText := envPlaceholderRegex.ReplaceAllStringFunc(text, func(placeholder string) string {
+		name := envPlaceholderRegex.FindStringSubmatch(placeholder)[1]
+		value, found := os.LookupEnv(name)
+		if !found {
+			if err == nil {
+				err = errors.New(fmt.Sprintf("environment variable '%s' referred by '%s' is not set", name,
+					placeholder))
+			}
+			return placeholder
+		}
+		return value
+	})
+	return resolvedText, err
+}
+
+// Replace the ${env:NAME} placeholders in the applies to, description and bug fixes of the given
+// update-descriptor.yaml.
+func ResolveUpdateDescriptorV2EnvPlaceholders(updateDescriptorV2 *UpdateDescriptorV2) error {
+	var err error
+	if updateDescriptorV2.AppliesTo, err = ResolveEnvPlaceholders(updateDescriptorV2.AppliesTo); err != nil {
+		return err
+	}
+	if updateDescriptorV2.Description, err = ResolveEnvPlaceholders(updateDescriptorV2.Description); err != nil {
+		return err
+	}
+	updateDescriptorV2.BugFixes, err = resolveBugFixesEnvPlaceholders(updateDescriptorV2.BugFixes)
+	return err
+}
+
+// Replace the ${env:NAME} placeholders in the description, instructions and bug fixes of the given
+// update-descriptor3.yaml.
+func ResolveUpdateDescriptorV3EnvPlaceholders(updateDescriptorV3 *UpdateDescriptorV3) error {
+	var err error
+	if updateDescriptorV3.Description, err = ResolveEnvPlaceholders(updateDescriptorV3.Description); err != nil {
+		return err
+	}
+	if updateDescriptorV3.Instructions, err = ResolveEnvPlaceholders(updateDescriptorV3.Instructions); err != nil {
+		return err
+	}
+	updateDescriptorV3.BugFixes, err = resolveBugFixesEnvPlaceholders(updateDescriptorV3.BugFixes)
+	return err
+}
+
+// Replace the ${env:NAME} placeholders in the keys and the values of the given bug fixes.
+func resolveBugFixesEnvPlaceholders(bugFixes map[string]string) (map[string]string, error) {
+	if bugFixes == nil {
+		return nil, nil
+	}
+	resolvedBugFixes := make(map[string]string)
+	for key, summary := range bugFixes {
+		resolvedKey, err := ResolveEnvPlaceholders(key)
+		if err != nil {
+			return nil, err
+		}
+		resolvedSummary, err := ResolveEnvPlaceholders(summary)
+		if err != nil {
+			return nil, err
+		}
+		resolvedBugFixes[resolvedKey] = resolvedSummary
+	}
+	return resolvedBugFixes, nil
+}
@@ -0,0 +1,181 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/wso2/update-creator-tool/constant"
+)
+
+// struct which represents a single event emitted during the update creation
+type Event struct {
+	Type    string            `json:"type"`
+	Stage   string            `json:"stage,omitempty"`
+	Message string            `json:"message,omitempty"`
+	Time    time.Time         `json:"time"`
+	Data    map[string]string `json:"data,omitempty"`
+}
+
+// Sinks receive all the events published to the event bus.
+type EventSink interface {
+	Send(event *Event) error
+	Close() error
+}
+
+// Sink which prints the events to the stderr
+type consoleEventSink struct{}
+
+func (sink *consoleEventSink) Send(event *Event) error {
+	_, err := fmt.Fprintln(os.Stderr, fmt.Sprintf("[EVENT] %s %s %s %s", event.Time.Format(time.RFC3339),
+		event.Type, event.Stage, event.Message))
+	return err
+}
+
+func (sink *consoleEventSink) Close() error {
+	return nil
+}
+
+// Sink which appends the events to a file as JSON lines
+type jsonLinesEventSink struct {
+	file *os.File
+}
+
+func (sink *jsonLinesEventSink) Send(event *Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = sink.file.Write(append(data, '\n'))
+	return err
+}
+
+func (sink *jsonLinesEventSink) Close() error {
+	return sink.file.Close()
+}
+
+// Sink which posts the events to a webhook as JSON
+type webhookEventSink struct {
+	url    string
+	client *http.Client
+}
+
+func (sink *webhookEventSink) Send(event *Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	response, err := sink.client.Post(sink.url, constant.HEADER_VALUE_APPLICATION_JSON, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		return errors.New(fmt.Sprintf("webhook '%s' responded with %s", sink.url, response.Status))
+	}
+	return nil
+}
+
+func (sink *webhookEventSink) Close() error {
+	return nil
+}
+
+// Sinks of the event bus
+var eventSinks []EventSink
+
+// Initialize the event bus with the given sinks. Events are printed to the console if console is true, appended to
+// the given file as JSON lines if file is not empty and posted to each of the given webhooks.
+func InitEventSinks(console bool, file string, webhooks []string) error {
+	CloseEventSinks()
+	if console {
+		eventSinks = append(eventSinks, &consoleEventSink{})
+	}
+	if len(file) != 0 {
+		eventsFile, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return err
+		}
+		eventSinks = append(eventSinks, &jsonLinesEventSink{file: eventsFile})
+	}
+	for _, webhook := range webhooks {
+		eventSinks = append(eventSinks, &webhookEventSink{
+			url:    webhook,
+			client: &http.Client{Timeout: time.Duration(constant.EVENT_WEBHOOK_TIMEOUT_IN_SECONDS) * time.Second},
+		})
+	}
+	return nil
+}
+
+// Add a custom sink to the event bus.
+func AddEventSink(sink EventSink) {
+	eventSinks = append(eventSinks, sink)
+}
+
+// Close all the sinks of the event bus.
+func CloseEventSinks() {
+	for _, sink := range eventSinks {
+		if err := sink.Close(); err != nil {
+			logger.Debug(fmt.Sprintf("%v error occurred while closing the event sink", err))
+		}
+	}
+	eventSinks = nil
+}
+
+// Publish an event to all the sinks of the event bus. Failures of the sinks are logged and do not affect the update
+// creation.
+func PublishEvent(eventType, stage, message string, data map[string]string) {
+	if len(eventSinks) == 0 {
+		return
+	}
+	event := Event{
+		Type:    eventType,
+		Stage:   stage,
+		Message: message,
+		Time:    time.Now(),
+		Data:    data,
+	}
+	for _, sink := range eventSinks {
+		if err := sink.Send(&event); err != nil {
+			logger.Debug(fmt.Sprintf("%v error occurred while sending the '%s' event", err, eventType))
+		}
+	}
+}
+
+// Publish a stage started event. Warnings raised until the stage is finished are grouped under the stage and the time
+// spent until the stage is finished is recorded.
+func PublishStageStarted(stage string) {
+	startStageTimer(stage)
+	PublishEvent(constant.EVENT_STAGE_STARTED, stage, "", nil)
+}
+
+// Publish a stage finished event along with the time spent in the stage.
+func PublishStageFinished(stage string) {
+	duration := stopStageTimer(stage)
+	PublishEvent(constant.EVENT_STAGE_FINISHED, stage, "", map[string]string{
+		"duration_ms": strconv.FormatInt(int64(duration/time.Millisecond), 10),
+	})
+}
@@ -0,0 +1,78 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+import (
+	"github.com/wso2/update-creator-tool/constant"
+)
+
+// Error which decides the exit code of wum-uc when it is handled by HandleErrorAndExit. Other errors are treated as
+// internal errors.
+type ExitCodeError struct {
+	ExitCode int
+	Err      error
+}
+
+func (exitCodeError *ExitCodeError) Error() string {
+	return exitCodeError.Err.Error()
+}
+
+// Mark the given error as an error in the arguments, flags or files given by the user.
+func NewInputError(err error) error {
+	return newExitCodeError(constant.EXIT_CODE_INPUT_ERROR, err)
+}
+
+// Mark the given error as a failure of the validations of an update.
+func NewValidationError(err error) error {
+	return newExitCodeError(constant.EXIT_CODE_VALIDATION_FAILURE, err)
+}
+
+// Mark the given error as the user aborting the command.
+func NewUserAbortError(err error) error {
+	return newExitCodeError(constant.EXIT_CODE_USER_ABORT, err)
+}
+
+// Mark the given error as an error in connecting to a remote server or downloading or uploading an artifact.
+func NewNetworkError(err error) error {
+	return newExitCodeError(constant.EXIT_CODE_NETWORK_ERROR, err)
+}
+
+// The exit code of an error is decided where it is marked first, so that the errors of the network calls made while
+// validating an update are not reported as validation failures.
+func newExitCodeError(exitCode int, err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, isMarked := err.(*ExitCodeError); isMarked {
+		return err
+	}
+	return &ExitCodeError{ExitCode: exitCode, Err: err}
+}
+
+// Get the exit code of wum-uc for the given error.
+func GetExitCode(err error) int {
+	if err == nil {
+		return constant.EXIT_CODE_SUCCESS
+	}
+	if exitCodeError, isMarked := err.(*ExitCodeError); isMarked {
+		return exitCodeError.ExitCode
+	}
+	return constant.EXIT_CODE_INTERNAL_ERROR
+}
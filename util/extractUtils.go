@@ -0,0 +1,177 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/wso2/update-creator-tool/constant"
+)
+
+// Extracts the entries of the given update zip into the given target directory and returns the number of extracted
+// files. If a part (descriptor, payload or resources) is given, only the entries of that part are extracted relative
+// to the update directory, except the payload which is extracted relative to the payload directory (carbon.home). Entries
+// which resolve outside the target directory are rejected. File permissions and modification times are restored.
+// Customized files map the paths relative to the target directory to the policy applied on them; the files to keep
+// are not extracted and the files to merge are extracted next to the existing file with the merge extension. Excluded
+// files (eg: OS variants of other operating systems) are not extracted.
+func ExtractUpdateZip(updateZipPath, targetDirectory, part string, customizedFiles map[string]string,
+	excludedFiles map[string]bool) (int, error) {
+	if len(part) != 0 && part != constant.EXTRACT_DESCRIPTOR && part != constant.EXTRACT_PAYLOAD &&
+		part != constant.EXTRACT_RESOURCES {
+		return 0, errors.New(fmt.Sprintf("invalid part '%s', expected '%s', '%s' or '%s'", part,
+			constant.EXTRACT_DESCRIPTOR, constant.EXTRACT_PAYLOAD, constant.EXTRACT_RESOURCES))
+	}
+	zipReader, err := zip.OpenReader(updateZipPath)
+	if err != nil {
+		return 0, err
+	}
+	defer zipReader.Close()
+
+	targetDirectory, err = filepath.Abs(targetDirectory)
+	if err != nil {
+		return 0, err
+	}
+	payloadDirectory, err := GetZippedPayloadDirectory(&zipReader.Reader)
+	if err != nil {
+		return 0, err
+	}
+	extractedFiles := 0
+	var extractedDirectories []*zip.File
+	for _, file := range zipReader.Reader.File {
+		relativePath, selected := getExtractPath(file.Name, part, payloadDirectory)
+		if !selected {
+			continue
+		}
+		destination := filepath.Join(targetDirectory, filepath.FromSlash(relativePath))
+		if destination != targetDirectory && !strings.HasPrefix(destination, targetDirectory+string(os.PathSeparator)) {
+			return extractedFiles, errors.New(fmt.Sprintf("'%s' resolves outside the target directory '%s'",
+				file.Name, targetDirectory))
+		}
+		if file.FileInfo().IsDir() {
+			if err = os.MkdirAll(GetLongPath(destination), 0755); err != nil {
+				return extractedFiles, err
+			}
+			extractedDirectories = append(extractedDirectories, file)
+			continue
+		}
+		if excludedFiles[relativePath] {
+			logger.Debug(fmt.Sprintf("Excluded file %s not extracted", destination))
+			continue
+		}
+		switch customizedFiles[relativePath] {
+		case constant.CUSTOMIZATION_POLICY_KEEP:
+			logger.Debug(fmt.Sprintf("Customized file %s kept", destination))
+			continue
+		case constant.CUSTOMIZATION_POLICY_MERGE:
+			destination += constant.CUSTOMIZATION_MERGE_EXTENSION
+		}
+		logger.Trace(fmt.Sprintf("Extracting %s to %s", file.Name, destination))
+		if err = extractZipEntry(file, destination); err != nil {
+			return extractedFiles, err
+		}
+		extractedFiles++
+	}
+	// Permissions of the directories are restored after extracting the files as they may not be writable
+	for _, directory := range extractedDirectories {
+		relativePath, _ := getExtractPath(directory.Name, part, payloadDirectory)
+		destination := filepath.Join(targetDirectory, filepath.FromSlash(relativePath))
+		if err = restoreFileInfo(directory, destination); err != nil {
+			return extractedFiles, err
+		}
+	}
+	return extractedFiles, nil
+}
+
+// Get the path of the given zip entry relative to the target directory and whether the entry belongs to the given
+// part of the update, of which the payload is in the given payload directory. All the entries are selected with their
+// original paths if the part is not given.
+func getExtractPath(name, part, payloadDirectory string) (string, bool) {
+	name = strings.TrimPrefix(strings.Replace(name, "\\", "/", -1), "/")
+	if len(part) == 0 {
+		return name, true
+	}
+	// Entries of the update are inside the update directory
+	index := strings.Index(name, "/")
+	if index == -1 {
+		return "", false
+	}
+	relativePath := strings.TrimSuffix(name[index+1:], "/")
+	if len(relativePath) == 0 {
+		return "", false
+	}
+	isPayload := relativePath == payloadDirectory || strings.HasPrefix(relativePath, payloadDirectory+"/")
+	switch part {
+	case constant.EXTRACT_PAYLOAD:
+		if !isPayload || relativePath == payloadDirectory {
+			return "", false
+		}
+		return strings.TrimPrefix(relativePath, payloadDirectory+"/"), true
+	case constant.EXTRACT_DESCRIPTOR:
+		return relativePath, relativePath == constant.UPDATE_DESCRIPTOR_V2_FILE ||
+			relativePath == constant.UPDATE_DESCRIPTOR_V3_FILE
+	default:
+		return relativePath, !isPayload && relativePath != constant.UPDATE_DESCRIPTOR_V2_FILE &&
+			relativePath != constant.UPDATE_DESCRIPTOR_V3_FILE
+	}
+}
+
+// Write the content of the given zip entry to the given destination and restore its permissions.
+func extractZipEntry(file *zip.File, destination string) error {
+	if err := os.MkdirAll(GetLongPath(filepath.Dir(destination)), 0755); err != nil {
+		return err
+	}
+	zippedFile, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer zippedFile.Close()
+	destinationFile, err := os.OpenFile(GetLongPath(destination), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(destinationFile, zippedFile); err != nil {
+		destinationFile.Close()
+		return err
+	}
+	if err = destinationFile.Close(); err != nil {
+		return err
+	}
+	return restoreFileInfo(file, destination)
+}
+
+// Restore the permissions and the modification time of the given zip entry. Permissions are only restored if they
+// are stored in the zip.
+func restoreFileInfo(file *zip.File, destination string) error {
+	destination = GetLongPath(destination)
+	if mode := file.Mode().Perm(); mode != 0 {
+		if err := os.Chmod(destination, mode); err != nil {
+			return err
+		}
+	}
+	modified := file.FileInfo().ModTime()
+	return os.Chtimes(destination, modified, modified)
+}
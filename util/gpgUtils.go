@@ -0,0 +1,408 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/wso2/update-creator-tool/constant"
+	"golang.org/x/crypto/openpgp"
+)
+
+// Read the GPG keys in the keyring (or the exported key) at the given location. Both armored and binary keyrings are
+// read.
+func ReadGPGKeyring(keyringPath string) (openpgp.EntityList, error) {
+	data, err := ioutil.ReadFile(keyringPath)
+	if err != nil {
+		return nil, err
+	}
+	var keyring openpgp.EntityList
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("-----BEGIN")) {
+		keyring, err = openpgp.ReadArmoredKeyRing(bytes.NewReader(data))
+	} else {
+		keyring, err = openpgp.ReadKeyRing(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("unable to read the GPG keyring '%s': %v", keyringPath, err))
+	}
+	return keyring, nil
+}
+
+// Read the GPG key used to sign updates from the keyring (or the exported secret key) at the given location. The key
+// is selected by the given key id (the fingerprint, the long or the short key id, or a part of a user id such as the
+// email) if the keyring has more than one secret key.
+func ReadGPGSigningKey(keyringPath, keyID string) (*openpgp.Entity, error) {
+	keyring, err := ReadGPGKeyring(keyringPath)
+	if err != nil {
+		return nil, err
+	}
+	var secretKeys []*openpgp.Entity
+	for _, entity := range keyring {
+		if entity.PrivateKey != nil && (len(keyID) == 0 || IsMatchingGPGKey(entity, keyID)) {
+			secretKeys = append(secretKeys, entity)
+		}
+	}
+	switch {
+	case len(secretKeys) == 1:
+		return secretKeys[0], nil
+	case len(secretKeys) == 0 && len(keyID) != 0:
+		return nil, errors.New(fmt.Sprintf("secret key '%s' is not found in '%s'", keyID, keyringPath))
+	case len(secretKeys) == 0:
+		return nil, errors.New(fmt.Sprintf("'%s' does not have a secret key", keyringPath))
+	default:
+		return nil, errors.New(fmt.Sprintf("'%s' has %d matching secret keys, select one with the key id",
+			keyringPath, len(secretKeys)))
+	}
+}
+
+// Check whether the given GPG key matches the given key id, which is the fingerprint, the long or the short key id
+// (with or without '0x') of the key or one of its subkeys, or a part of a user id of the key.
+func IsMatchingGPGKey(entity *openpgp.Entity, keyID string) bool {
+	id := strings.ToUpper(strings.TrimPrefix(strings.ToLower(strings.Replace(keyID, " ", "", -1)), "0x"))
+	fingerprints := []string{fmt.Sprintf("%X", entity.PrimaryKey.Fingerprint)}
+	for _, subkey := range entity.Subkeys {
+		fingerprints = append(fingerprints, fmt.Sprintf("%X", subkey.PublicKey.Fingerprint))
+	}
+	for _, fingerprint := range fingerprints {
+		// Key ids are the suffixes of the fingerprints
+		if len(id) >= 8 && strings.HasSuffix(fingerprint, id) {
+			return true
+		}
+	}
+	for name := range entity.Identities {
+		if strings.Contains(strings.ToLower(name), strings.ToLower(keyID)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Check whether the private keys of the given GPG key are encrypted with a passphrase.
+func IsGPGKeyEncrypted(entity *openpgp.Entity) bool {
+	if entity.PrivateKey != nil && entity.PrivateKey.Encrypted {
+		return true
+	}
+	for _, subkey := range entity.Subkeys {
+		if subkey.PrivateKey != nil && subkey.PrivateKey.Encrypted {
+			return true
+		}
+	}
+	return false
+}
+
+// Decrypt the private keys of the given GPG key with the given passphrase.
+func DecryptGPGKey(entity *openpgp.Entity, passphrase []byte) error {
+	if entity.PrivateKey != nil && entity.PrivateKey.Encrypted {
+		if err := entity.PrivateKey.Decrypt(passphrase); err != nil {
+			return errors.New(fmt.Sprintf("unable to decrypt the GPG key %s: %v", GetGPGKeyDescription(entity),
+				err))
+		}
+	}
+	for _, subkey := range entity.Subkeys {
+		if subkey.PrivateKey != nil && subkey.PrivateKey.Encrypted {
+			if err := subkey.PrivateKey.Decrypt(passphrase); err != nil {
+				return errors.New(fmt.Sprintf("unable to decrypt a subkey of the GPG key %s: %v",
+					GetGPGKeyDescription(entity), err))
+			}
+		}
+	}
+	return nil
+}
+
+// Get the description of the given GPG key which is shown to the user, which is the long key id followed by the user
+// ids of the key (eg: 6C7D1F0B3E9A2D45 (Release Team <release@wso2.com>)).
+func GetGPGKeyDescription(entity *openpgp.Entity) string {
+	var names []string
+	for name := range entity.Identities {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if len(names) == 0 {
+		return entity.PrimaryKey.KeyIdString()
+	}
+	return fmt.Sprintf("%s (%s)", entity.PrimaryKey.KeyIdString(), strings.Join(names, ", "))
+}
+
+// Write the armored detached GPG signature of the file at the given location to the target using the given key.
+func SignFileWithGPG(location, target string, entity *openpgp.Entity) error {
+	file, err := os.Open(location)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	signature := new(bytes.Buffer)
+	if err = openpgp.ArmoredDetachSign(signature, entity, file, nil); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(target, signature.Bytes(), 0644)
+}
+
+// Embed the armored GPG signature of the given update zip in it as <update_name>/SIGNATURE.asc using the given key.
+// Zip entries cannot sign the zip they are in, so the embedded signature is created over the manifest of the other
+// entries (see GetUpdateZipManifest), which is embedded as <update_name>/MANIFEST.sha256. A signature which is already
+// embedded in the zip is replaced.
+func EmbedGPGSignature(updateZipPath string, entity *openpgp.Entity) error {
+	zipReader, err := zip.OpenReader(updateZipPath)
+	if err != nil {
+		return err
+	}
+	defer zipReader.Close()
+	updateName, err := getUpdateZipRoot(&zipReader.Reader)
+	if err != nil {
+		return errors.New(fmt.Sprintf("'%s' is not a valid update zip: %v", updateZipPath, err))
+	}
+	manifest, err := GetUpdateZipManifest(&zipReader.Reader)
+	if err != nil {
+		return err
+	}
+	signature := new(bytes.Buffer)
+	if err = openpgp.ArmoredDetachSign(signature, entity, bytes.NewReader(manifest), nil); err != nil {
+		return err
+	}
+
+	// The zip is written to a temporary file next to it and then replaces it, so it is never left half written
+	tempFile, err := ioutil.TempFile(filepath.Dir(updateZipPath), constant.TEMP_DIRECTORY_PREFIX)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempFile.Name())
+	zipWriter := zip.NewWriter(tempFile)
+	for _, file := range zipReader.File {
+		if IsEmbeddedSignatureEntry(updateName, file.Name) {
+			continue
+		}
+		if err = zipWriter.Copy(file); err != nil {
+			tempFile.Close()
+			return err
+		}
+	}
+	entries := []struct {
+		name string
+		data []byte
+	}{
+		{constant.EMBEDDED_MANIFEST_FILE, manifest},
+		{constant.EMBEDDED_SIGNATURE_FILE, signature.Bytes()},
+	}
+	for _, entry := range entries {
+		writer, err := zipWriter.Create(path.Join(updateName, entry.name))
+		if err == nil {
+			_, err = writer.Write(entry.data)
+		}
+		if err != nil {
+			tempFile.Close()
+			return err
+		}
+	}
+	err = zipWriter.Close()
+	if closeErr := tempFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	zipReader.Close()
+	return os.Rename(tempFile.Name(), updateZipPath)
+}
+
+// Get the manifest of the entries of the given update zip which is signed by the embedded signature. Each line has
+// the sha256 checksum and the name of a file of the zip in the format of sha256sum. Files are sorted by their names
+// and the embedded signature and manifest are not in the manifest. Zips which have more than one entry with the same
+// name are rejected, as the entry which is extracted from such a zip depends on the tool used to extract it.
+func GetUpdateZipManifest(zipReader *zip.Reader) ([]byte, error) {
+	updateName, err := getUpdateZipRoot(zipReader)
+	if err != nil {
+		return nil, err
+	}
+	var lines []string
+	names := make(map[string]bool)
+	for _, file := range zipReader.File {
+		if names[file.Name] {
+			return nil, errors.New(fmt.Sprintf("'%s' is found more than once in the zip", file.Name))
+		}
+		names[file.Name] = true
+		if file.FileInfo().IsDir() || IsEmbeddedSignatureEntry(updateName, file.Name) {
+			continue
+		}
+		zippedFile, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		hash := sha256.New()
+		_, err = io.Copy(hash, zippedFile)
+		zippedFile.Close()
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, fmt.Sprintf("%s  %s\n", hex.EncodeToString(hash.Sum(nil)), file.Name))
+	}
+	sort.Slice(lines, func(i, j int) bool {
+		return lines[i][sha256.Size*2+2:] < lines[j][sha256.Size*2+2:]
+	})
+	return []byte(strings.Join(lines, "")), nil
+}
+
+// Check whether the given zip entry is the signature or the signed manifest embedded in the root folder of the update
+// zip of the given update.
+func IsEmbeddedSignatureEntry(updateName, name string) bool {
+	return name == path.Join(updateName, constant.EMBEDDED_SIGNATURE_FILE) ||
+		name == path.Join(updateName, constant.EMBEDDED_MANIFEST_FILE)
+}
+
+// Get the root folder of the given update zip, which is the name of the update. All the entries of an update zip
+// should be in its root folder.
+func getUpdateZipRoot(zipReader *zip.Reader) (string, error) {
+	if len(zipReader.File) == 0 {
+		return "", errors.New("zip is empty")
+	}
+	updateName := strings.SplitN(zipReader.File[0].Name, "/", 2)[0]
+	for _, file := range zipReader.File {
+		if !strings.HasPrefix(file.Name, updateName+"/") {
+			return "", errors.New(fmt.Sprintf("'%s' is not in the root folder '%s'", file.Name, updateName))
+		}
+	}
+	return updateName, nil
+}
+
+// Verify the detached GPG signature at the given signature path, armored or binary, of the file at the given location
+// with the keys in the given keyring. The key which created the signature is returned.
+func VerifyGPGSignature(location, signaturePath string, keyring openpgp.EntityList) (*openpgp.Entity, error) {
+	signature, err := ioutil.ReadFile(signaturePath)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(location)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return checkGPGSignature(keyring, file, signature)
+}
+
+// Verify the GPG signature embedded in the given update zip with the keys in the given keyring and compare the signed
+// manifest with the entries of the zip. The key which created the signature and the differences between the manifest
+// and the entries are returned. The returned key is nil if the zip does not have an embedded signature.
+func VerifyEmbeddedGPGSignature(updateZipPath string, keyring openpgp.EntityList) (*openpgp.Entity, []string,
+	error) {
+	zipReader, err := zip.OpenReader(updateZipPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer zipReader.Close()
+	updateName, err := getUpdateZipRoot(&zipReader.Reader)
+	if err != nil {
+		return nil, nil, errors.New(fmt.Sprintf("'%s' is not a valid update zip: %v", updateZipPath, err))
+	}
+	var signature, signedManifest []byte
+	for _, file := range zipReader.File {
+		if !IsEmbeddedSignatureEntry(updateName, file.Name) {
+			continue
+		}
+		data, err := readZipFile(file)
+		if err != nil {
+			return nil, nil, err
+		}
+		if path.Base(file.Name) == constant.EMBEDDED_SIGNATURE_FILE {
+			signature = data
+		} else {
+			signedManifest = data
+		}
+	}
+	if signature == nil {
+		return nil, nil, nil
+	}
+	if signedManifest == nil {
+		return nil, nil, errors.New(fmt.Sprintf("'%s' is not found next to the embedded signature",
+			constant.EMBEDDED_MANIFEST_FILE))
+	}
+	signer, err := checkGPGSignature(keyring, bytes.NewReader(signedManifest), signature)
+	if err != nil {
+		return nil, nil, err
+	}
+	signedChecksums, err := parseZipManifest(signedManifest)
+	if err != nil {
+		return nil, nil, errors.New(fmt.Sprintf("signed manifest is invalid: %v", err))
+	}
+	manifest, err := GetUpdateZipManifest(&zipReader.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	checksums, err := parseZipManifest(manifest)
+	if err != nil {
+		return nil, nil, err
+	}
+	return signer, compareZipManifests(signedChecksums, checksums), nil
+}
+
+// Check the armored or binary detached signature of the content of the given reader with the given keyring.
+func checkGPGSignature(keyring openpgp.EntityList, signed io.Reader, signature []byte) (*openpgp.Entity, error) {
+	if bytes.HasPrefix(bytes.TrimSpace(signature), []byte("-----BEGIN")) {
+		return openpgp.CheckArmoredDetachedSignature(keyring, signed, bytes.NewReader(signature))
+	}
+	return openpgp.CheckDetachedSignature(keyring, signed, bytes.NewReader(signature))
+}
+
+// Parse the given manifest of an update zip to a map of file names to their checksums. Manifests which have more than
+// one checksum for the same file are rejected.
+func parseZipManifest(manifest []byte) (map[string]string, error) {
+	checksums := make(map[string]string)
+	for _, line := range strings.Split(string(manifest), "\n") {
+		parts := strings.SplitN(line, "  ", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		if _, found := checksums[parts[1]]; found {
+			return nil, errors.New(fmt.Sprintf("'%s' is found more than once in the manifest", parts[1]))
+		}
+		checksums[parts[1]] = parts[0]
+	}
+	return checksums, nil
+}
+
+// Compare the signed manifest of an update zip with the manifest of its current entries and return the differences.
+func compareZipManifests(signed, current map[string]string) []string {
+	var differences []string
+	for name, checksum := range current {
+		signedChecksum, found := signed[name]
+		if !found {
+			differences = append(differences, fmt.Sprintf("'%s' is not in the signed manifest", name))
+		} else if signedChecksum != checksum {
+			differences = append(differences, fmt.Sprintf("'%s' is modified after signing", name))
+		}
+	}
+	for name := range signed {
+		if _, found := current[name]; !found {
+			differences = append(differences, fmt.Sprintf("'%s' is removed after signing", name))
+		}
+	}
+	sort.Strings(differences)
+	return differences
+}
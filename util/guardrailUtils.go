@@ -0,0 +1,61 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+import (
+	"fmt"
+)
+
+// struct which is used to store the thresholds which guard against updates that are effectively full re-releases
+// (eg: updates created against a wrong distribution). A zero value disables the corresponding threshold.
+type Guardrails struct {
+	MaxChangedFiles         int
+	MaxPayloadSize          uint64
+	MaxDistributionFraction float64
+}
+
+// Checks the given update manifest against the guardrails and returns the exceeded thresholds. modifiedFiles is the
+// number of files of the distribution modified by the update and distributionFiles is the number of files in the
+// distribution.
+func CheckGuardrails(updateManifest *UpdateManifest, modifiedFiles, distributionFiles int,
+	guardrails *Guardrails) []string {
+	var violations []string
+	if guardrails.MaxChangedFiles > 0 && len(updateManifest.Files) > guardrails.MaxChangedFiles {
+		violations = append(violations, fmt.Sprintf("update changes %d files which exceeds the maximum of %d",
+			len(updateManifest.Files), guardrails.MaxChangedFiles))
+	}
+	var payloadSize uint64
+	for _, size := range updateManifest.FileSizes {
+		payloadSize += size
+	}
+	if guardrails.MaxPayloadSize > 0 && payloadSize > guardrails.MaxPayloadSize {
+		violations = append(violations, fmt.Sprintf("payload of the update is %d bytes which exceeds the "+
+			"maximum of %d bytes", payloadSize, guardrails.MaxPayloadSize))
+	}
+	if guardrails.MaxDistributionFraction > 0 && distributionFiles > 0 {
+		fraction := float64(modifiedFiles) / float64(distributionFiles)
+		if fraction > guardrails.MaxDistributionFraction {
+			violations = append(violations, fmt.Sprintf("update modifies %.1f%% of the files in the "+
+				"distribution which exceeds the maximum of %.1f%%", fraction*100,
+				guardrails.MaxDistributionFraction*100))
+		}
+	}
+	return violations
+}
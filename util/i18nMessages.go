@@ -0,0 +1,158 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+// Built-in message catalogs of the supported locales. Keys are the English message formats which are used in the
+// code, without the leading and trailing whitespaces. Arguments are placed in the same order unless explicit argument
+// indexes (eg: %[2]s) are used in the translation.
+var builtInMessageCatalogs = map[string]map[string]string{
+	"ja": {
+		"Error occurred while getting input from the user.":    "ユーザーからの入力の取得中にエラーが発生しました。",
+		"Error occurred while reading '%s'.":                   "'%s' の読み込み中にエラーが発生しました。",
+		"Error occurred while writing '%s'.":                   "'%s' の書き込み中にエラーが発生しました。",
+		"Error occurred while creating '%s'.":                  "'%s' の作成中にエラーが発生しました。",
+		"Error occurred while removing '%s'.":                  "'%s' の削除中にエラーが発生しました。",
+		"Error occurred while creating a temporary directory.": "一時ディレクトリの作成中にエラーが発生しました。",
+		"'%s' is invalid.":                                     "'%s' は無効です。",
+		"Entered update file does not exist at '%s'.":          "指定されたアップデートファイルが '%s' に存在しません。",
+		"Invalid preference. Enter Y for Yes or N for No.": "無効な選択です。はいの場合は Y、いいえの場合は N を" +
+			"入力してください。",
+		"Invalid preference. Setting No as input.": "無効な選択です。いいえとして扱います。",
+		"Logs of this session are written to '%s'. Attach it when reporting the issue.": "このセッションのログは " +
+			"'%s' に書き込まれました。問題を報告する際に添付してください。",
+		"'%s'does not exists. Do you want to create '%s' directory?[Y/n]:": "'%s' は存在しません。ディレクトリ " +
+			"'%s' を作成しますか? [Y/n]:",
+		"Are the existing files in %s removed from this update? [y/n]:": "%s の既存のファイルはこのアップデートで" +
+			"削除されますか? [y/n]:",
+		"Do you want to re-root the distribution under '%s'? [Y/n]:": "ディストリビューションのルートを '%s' に" +
+			"変更しますか? [Y/n]:",
+		"Enter platform name for platform version : %s": "プラットフォームバージョン %s のプラットフォーム名を" +
+			"入力してください:",
+		"Enter 'update number':":           "'update number' を入力してください:",
+		"Enter your preference [1/2]:":     "選択肢を入力してください [1/2]:",
+		"Enter applies to:":                "適用対象 (applies to) を入力してください:",
+		"Enter the description:":           "説明を入力してください:",
+		"Enter JIRA_KEY/GITHUB ISSUE URL:": "JIRA_KEY/GITHUB ISSUE URL を入力してください:",
+		"Empty input detected, are you done with adding bug fixes? [y/n]:": "入力が空です。バグ修正の追加を" +
+			"終了しますか? [y/n]:",
+		"Enter JIRA_KEY_SUMMARY/GITHUB_ISSUE_SUMMARY for '%s':": "'%s' の JIRA_KEY_SUMMARY/GITHUB_ISSUE_SUMMARY を" +
+			"入力してください:",
+		"Do you want to add it as a new file? [Y/n]:": "新しいファイルとして追加しますか? [Y/n]:",
+		"Enter destination directory relative to PRODUCT_HOME:": "配置先のディレクトリを PRODUCT_HOME からの" +
+			"相対パスで入力してください:",
+		"Enter destination directory relative to PRODUCT_HOME [%s]:": "配置先のディレクトリを PRODUCT_HOME からの" +
+			"相対パスで入力してください [%s]:",
+		"Copy anyway? [y/n/R]:": "それでもコピーしますか? [y/n/R]:",
+		"Enter preference(s)[Multiple selections separated by commas, 0 to skip copying]:": "選択肢を入力してください " +
+			"[複数選択する場合はカンマ区切り、コピーしない場合は 0]:",
+		"Enter the path of a removed file relative to the PRODUCT_HOME, press enter when the path is added": "削除" +
+			"されたファイルのパスを PRODUCT_HOME からの相対パスで入力し、Enter キーを押してください",
+		"Empty input detected, are you done with adding inputs? [y/n]:": "入力が空です。入力の追加を終了しますか? " +
+			"[y/n]:",
+		"Enter password for %s for committing the update to the SVN:": "アップデートを SVN にコミットするための " +
+			"%s のパスワードを入力してください:",
+		"wum-uc is not configured yet. Do you want to run the setup wizard now? [Y/n]:": "wum-uc はまだ設定されて" +
+			"いません。セットアップウィザードを今すぐ実行しますか? [Y/n]:",
+		"Default configuration is used. Run 'wum-uc setup' to configure wum-uc later.": "デフォルトの設定を使用" +
+			"します。後で wum-uc を設定するには 'wum-uc setup' を実行してください。",
+		"Platform versions as <platform_version>=<platform_name>, comma separated": "プラットフォームバージョン " +
+			"(<platform_version>=<platform_name> の形式、カンマ区切り)",
+		"Mandatory resource files, comma separated": "必須のリソースファイル (カンマ区切り)",
+		"Optional resource files, comma separated":  "任意のリソースファイル (カンマ区切り)",
+		"WUM server URL":                                         "WUM サーバーの URL",
+		"Configuration written to '%s'.":                         "設定を '%s' に書き込みました。",
+		"Do you want to enter your WSO2 credentials now? [Y/n]:": "WSO2 の認証情報を今すぐ入力しますか? [Y/n]:",
+		"wum-uc is initialized with your WSO2 credentials.":      "wum-uc は WSO2 の認証情報で初期化されました。",
+		"Run 'wum-uc init' to enter your WSO2 credentials later.": "後で WSO2 の認証情報を入力するには " +
+			"'wum-uc init' を実行してください。",
+		"Please enter your WSO2 credentials to continue": "続行するには WSO2 の認証情報を入力してください",
+		"Invalid email address":                          "無効なメールアドレスです",
+		"Email:":                                         "メールアドレス:",
+		"Password for '%v':":                             "'%v' のパスワード:",
+	},
+	"es": {
+		"Error occurred while getting input from the user.": "Se produjo un error al obtener la entrada del usuario.",
+		"Error occurred while reading '%s'.":                "Se produjo un error al leer '%s'.",
+		"Error occurred while writing '%s'.":                "Se produjo un error al escribir '%s'.",
+		"Error occurred while creating '%s'.":               "Se produjo un error al crear '%s'.",
+		"Error occurred while removing '%s'.":               "Se produjo un error al eliminar '%s'.",
+		"Error occurred while creating a temporary directory.": "Se produjo un error al crear un directorio " +
+			"temporal.",
+		"'%s' is invalid.": "'%s' no es válido.",
+		"Entered update file does not exist at '%s'.": "El archivo de actualización indicado no existe en '%s'.",
+		"Invalid preference. Enter Y for Yes or N for No.": "Preferencia no válida. Introduzca Y para Sí o N " +
+			"para No.",
+		"Invalid preference. Setting No as input.": "Preferencia no válida. Se toma No como respuesta.",
+		"Logs of this session are written to '%s'. Attach it when reporting the issue.": "Los registros de esta " +
+			"sesión se escriben en '%s'. Adjúntelos al informar del problema.",
+		"'%s'does not exists. Do you want to create '%s' directory?[Y/n]:": "'%s' no existe. ¿Desea crear el " +
+			"directorio '%s'? [Y/n]:",
+		"Are the existing files in %s removed from this update? [y/n]:": "¿Esta actualización elimina los " +
+			"archivos existentes en %s? [y/n]:",
+		"Do you want to re-root the distribution under '%s'? [Y/n]:": "¿Desea cambiar la raíz de la distribución " +
+			"a '%s'? [Y/n]:",
+		"Enter platform name for platform version : %s": "Introduzca el nombre de la plataforma para la versión " +
+			"de plataforma %s:",
+		"Enter 'update number':":           "Introduzca el 'update number':",
+		"Enter your preference [1/2]:":     "Introduzca su preferencia [1/2]:",
+		"Enter applies to:":                "Introduzca a qué se aplica (applies to):",
+		"Enter the description:":           "Introduzca la descripción:",
+		"Enter JIRA_KEY/GITHUB ISSUE URL:": "Introduzca la JIRA_KEY/URL de la incidencia de GITHUB:",
+		"Empty input detected, are you done with adding bug fixes? [y/n]:": "Se detectó una entrada vacía, ¿ha " +
+			"terminado de añadir correcciones de errores? [y/n]:",
+		"Enter JIRA_KEY_SUMMARY/GITHUB_ISSUE_SUMMARY for '%s':": "Introduzca el " +
+			"JIRA_KEY_SUMMARY/GITHUB_ISSUE_SUMMARY de '%s':",
+		"Do you want to add it as a new file? [Y/n]:": "¿Desea añadirlo como un archivo nuevo? [Y/n]:",
+		"Enter destination directory relative to PRODUCT_HOME:": "Introduzca el directorio de destino relativo a " +
+			"PRODUCT_HOME:",
+		"Enter destination directory relative to PRODUCT_HOME [%s]:": "Introduzca el directorio de destino " +
+			"relativo a PRODUCT_HOME [%s]:",
+		"Copy anyway? [y/n/R]:": "¿Copiar de todos modos? [y/n/R]:",
+		"Enter preference(s)[Multiple selections separated by commas, 0 to skip copying]:": "Introduzca las " +
+			"preferencias [varias selecciones separadas por comas, 0 para no copiar]:",
+		"Enter the path of a removed file relative to the PRODUCT_HOME, press enter when the path is added": "" +
+			"Introduzca la ruta de un archivo eliminado relativa a PRODUCT_HOME y pulse Intro cuando la haya " +
+			"añadido",
+		"Empty input detected, are you done with adding inputs? [y/n]:": "Se detectó una entrada vacía, ¿ha " +
+			"terminado de añadir entradas? [y/n]:",
+		"Enter password for %s for committing the update to the SVN:": "Introduzca la contraseña de %s para " +
+			"confirmar la actualización en el SVN:",
+		"wum-uc is not configured yet. Do you want to run the setup wizard now? [Y/n]:": "wum-uc aún no está " +
+			"configurado. ¿Desea ejecutar ahora el asistente de configuración? [Y/n]:",
+		"Default configuration is used. Run 'wum-uc setup' to configure wum-uc later.": "Se usa la configuración " +
+			"predeterminada. Ejecute 'wum-uc setup' para configurar wum-uc más tarde.",
+		"Platform versions as <platform_version>=<platform_name>, comma separated": "Versiones de plataforma " +
+			"como <platform_version>=<platform_name>, separadas por comas",
+		"Mandatory resource files, comma separated": "Archivos de recursos obligatorios, separados por comas",
+		"Optional resource files, comma separated":  "Archivos de recursos opcionales, separados por comas",
+		"WUM server URL":                 "URL del servidor WUM",
+		"Configuration written to '%s'.": "Configuración escrita en '%s'.",
+		"Do you want to enter your WSO2 credentials now? [Y/n]:": "¿Desea introducir ahora sus credenciales de " +
+			"WSO2? [Y/n]:",
+		"wum-uc is initialized with your WSO2 credentials.": "wum-uc se ha inicializado con sus credenciales de " +
+			"WSO2.",
+		"Run 'wum-uc init' to enter your WSO2 credentials later.": "Ejecute 'wum-uc init' para introducir sus " +
+			"credenciales de WSO2 más tarde.",
+		"Please enter your WSO2 credentials to continue": "Introduzca sus credenciales de WSO2 para continuar",
+		"Invalid email address":                          "Dirección de correo electrónico no válida",
+		"Email:":                                         "Correo electrónico:",
+		"Password for '%v':":                             "Contraseña de '%v':",
+	},
+}
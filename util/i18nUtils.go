@@ -0,0 +1,236 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/wso2/update-creator-tool/constant"
+	"gopkg.in/yaml.v2"
+)
+
+// struct which is used to translate the messages which are formatted from a message format of the catalog
+type messageTranslation struct {
+	pattern     *regexp.Regexp
+	translation string
+}
+
+// Verbs in the message formats of the catalogs (eg: %s, %v, %[2]s). '%%' is a literal '%'.
+var formatVerbPattern = regexp.MustCompile(`%(\[(\d+)\])?[a-zA-Z%]`)
+
+var currentLocale string
+var exactTranslations = map[string]string{}
+var formattedTranslations []messageTranslation
+
+// Get the locale of the messages. WUM_UC_LOCALE environment variable overrides the given configured locale.
+func ResolveLocale(configuredLocale string) string {
+	if locale := os.Getenv(constant.WUM_UC_LOCALE); len(strings.TrimSpace(locale)) != 0 {
+		return locale
+	}
+	return configuredLocale
+}
+
+// Get the current locale of the messages. Empty string is returned if the messages are in English.
+func GetLocale() string {
+	return currentLocale
+}
+
+// Set the locale of the messages shown to the user. Locales are given as language codes with an optional region and
+// encoding (eg: ja, es_ES.UTF-8). Messages of the built-in catalog of the language are overridden by the messages in
+// the <locale>.yaml file in the given messages directory, if it exists. Messages which are not in the catalog are
+// shown in English.
+func SetLocale(locale, messagesDirectory string) error {
+	currentLocale = ""
+	exactTranslations = map[string]string{}
+	formattedTranslations = nil
+	language, region := parseLocale(locale)
+	if len(language) == 0 || language == "en" {
+		return nil
+	}
+	catalog := map[string]string{}
+	for format, translation := range builtInMessageCatalogs[language] {
+		catalog[format] = translation
+	}
+	isCatalogFound := len(catalog) != 0
+	// Messages of the region specific file (eg: es_mx.yaml) take precedence over the messages of the language
+	catalogFileNames := []string{language + ".yaml"}
+	if len(region) != 0 {
+		catalogFileNames = append(catalogFileNames, language+"_"+region+".yaml")
+	}
+	for _, catalogFileName := range catalogFileNames {
+		overrides, err := readMessageCatalog(filepath.Join(messagesDirectory, catalogFileName))
+		if err != nil {
+			return err
+		}
+		if overrides != nil {
+			isCatalogFound = true
+		}
+		for format, translation := range overrides {
+			catalog[format] = translation
+		}
+	}
+	if !isCatalogFound {
+		return errors.New(fmt.Sprintf("messages are not available for locale '%s', add them to '%s' in '%s'",
+			locale, catalogFileNames[len(catalogFileNames)-1], messagesDirectory))
+	}
+	for format, translation := range catalog {
+		if err := addTranslation(strings.TrimSpace(format), strings.TrimSpace(translation)); err != nil {
+			return err
+		}
+	}
+	// Longer formats are more specific, so they are matched first
+	sort.SliceStable(formattedTranslations, func(i, j int) bool {
+		return len(formattedTranslations[i].pattern.String()) > len(formattedTranslations[j].pattern.String())
+	})
+	currentLocale = strings.TrimSpace(locale)
+	return nil
+}
+
+// Get the language and the region of the given locale in lower case, ie: 'es_ES.UTF-8' gives 'es' and 'es'.
+func parseLocale(locale string) (string, string) {
+	locale = strings.ToLower(strings.TrimSpace(locale))
+	if index := strings.IndexAny(locale, ".@"); index != -1 {
+		locale = locale[:index]
+	}
+	if locale == "c" || locale == "posix" {
+		return "", ""
+	}
+	parts := strings.SplitN(strings.Replace(locale, "-", "_", -1), "_", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+	return parts[0], parts[1]
+}
+
+// Read the message catalog file at the given location. Catalog files map the English message formats to the
+// translated formats. Nil is returned if the file does not exist.
+func readMessageCatalog(catalogPath string) (map[string]string, error) {
+	exists, err := IsFileExists(catalogPath)
+	if err != nil || !exists {
+		return nil, err
+	}
+	data, err := ioutil.ReadFile(catalogPath)
+	if err != nil {
+		return nil, err
+	}
+	catalog := map[string]string{}
+	if err = yaml.Unmarshal(data, &catalog); err != nil {
+		return nil, errors.New(fmt.Sprintf("invalid message catalog '%s': %v", catalogPath, err))
+	}
+	return catalog, nil
+}
+
+// Add the translation of the given message format. Formats without verbs are matched exactly. Otherwise, a pattern
+// is created from the format so that the arguments of the formatted messages can be placed in the translation.
+func addTranslation(format, translation string) error {
+	verbs := formatVerbPattern.FindAllStringIndex(format, -1)
+	if len(verbs) == 0 || (len(verbs) == 1 && format[verbs[0][0]:verbs[0][1]] == "%%") {
+		exactTranslations[strings.Replace(format, "%%", "%", -1)] = strings.Replace(translation, "%%", "%", -1)
+		return nil
+	}
+	pattern := "(?s)^"
+	lastIndex := 0
+	for _, verb := range verbs {
+		pattern += regexp.QuoteMeta(format[lastIndex:verb[0]])
+		if format[verb[0]:verb[1]] == "%%" {
+			pattern += "%"
+		} else {
+			pattern += "(.*?)"
+		}
+		lastIndex = verb[1]
+	}
+	pattern += regexp.QuoteMeta(format[lastIndex:]) + "$"
+	compiledPattern, err := regexp.Compile(pattern)
+	if err != nil {
+		return errors.New(fmt.Sprintf("invalid message format '%s': %v", format, err))
+	}
+	formattedTranslations = append(formattedTranslations, messageTranslation{
+		pattern:     compiledPattern,
+		translation: translation,
+	})
+	return nil
+}
+
+// Localize the given message which is shown to the user. Messages which are not in the catalog of the current locale
+// are returned as they are. Leading and trailing whitespaces of the message are kept.
+func Localize(message string) string {
+	if len(currentLocale) == 0 {
+		return message
+	}
+	trimmedMessage := strings.TrimLeftFunc(message, unicode.IsSpace)
+	prefix := message[:len(message)-len(trimmedMessage)]
+	trimmedMessage = strings.TrimRightFunc(trimmedMessage, unicode.IsSpace)
+	suffix := message[len(prefix)+len(trimmedMessage):]
+	if translation, found := exactTranslations[trimmedMessage]; found {
+		return prefix + translation + suffix
+	}
+	for _, formattedTranslation := range formattedTranslations {
+		arguments := formattedTranslation.pattern.FindStringSubmatch(trimmedMessage)
+		if arguments != nil {
+			return prefix + formatTranslation(formattedTranslation.translation, arguments[1:]) + suffix
+		}
+	}
+	return message
+}
+
+// Place the given arguments in the verbs of the given translated format. Verbs with explicit argument indexes
+// (eg: %[2]s) can be used to change the order of the arguments.
+func formatTranslation(translation string, arguments []string) string {
+	argumentIndex := 0
+	return formatVerbPattern.ReplaceAllStringFunc(translation, func(verb string) string {
+		if verb == "%%" {
+			return "%"
+		}
+		if match := formatVerbPattern.FindStringSubmatch(verb); len(match[2]) != 0 {
+			argumentIndex, _ = strconv.Atoi(match[2])
+			argumentIndex--
+		}
+		if argumentIndex < 0 || argumentIndex >= len(arguments) {
+			return verb
+		}
+		argumentIndex++
+		return arguments[argumentIndex-1]
+	})
+}
+
+// Localize the string arguments passed to the print functions.
+func localizeArgs(args []interface{}) []interface{} {
+	if len(currentLocale) == 0 {
+		return args
+	}
+	localizedArgs := make([]interface{}, len(args))
+	for index, arg := range args {
+		if message, isString := arg.(string); isString {
+			localizedArgs[index] = Localize(message)
+		} else {
+			localizedArgs[index] = arg
+		}
+	}
+	return localizedArgs
+}
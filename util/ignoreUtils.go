@@ -0,0 +1,79 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+import (
+	"bufio"
+	"bytes"
+	"path"
+	"strings"
+
+	"github.com/spf13/viper"
+	"github.com/wso2/update-creator-tool/constant"
+)
+
+// Parses the given ignore manifest. Each line contains a path relative to the distribution root or a glob pattern.
+// Empty lines and lines starting with '#' are skipped.
+func ParseIgnoreManifest(data []byte) []string {
+	var ignoredPaths []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		ignoredPaths = append(ignoredPaths, strings.Trim(line, "/"))
+	}
+	return ignoredPaths
+}
+
+// Checks whether the given path relative to the distribution root is ignored. A path is ignored if it matches one of
+// the given ignored paths or if it is inside one of them.
+func IsIgnoredPath(relativePath string, ignoredPaths []string) bool {
+	relativePath = strings.Trim(relativePath, "/")
+	for _, ignoredPath := range ignoredPaths {
+		for currentPath := relativePath; currentPath != "." && currentPath != ""; currentPath = path.Dir(currentPath) {
+			if matched, _ := path.Match(ignoredPath, currentPath); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Get the names of the files of the given kind (MATCHING or ZIP) which are ignored by the given command. The list given
+// in the section of the command (eg: IGNORED_FILES.CREATE.MATCHING) overrides the list given for all the commands.
+func GetIgnoredFiles(command, kind string) []string {
+	commandKey := constant.IGNORED_FILES + "." + command + "." + kind
+	if viper.IsSet(commandKey) {
+		return viper.GetStringSlice(commandKey)
+	}
+	return viper.GetStringSlice(constant.IGNORED_FILES + "." + kind)
+}
+
+// Checks whether the given file name matches one of the given ignored file names or glob patterns.
+func IsIgnoredFile(name string, ignoredFiles []string) bool {
+	for _, ignoredFile := range ignoredFiles {
+		if matched, _ := path.Match(ignoredFile, name); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/wso2/update-creator-tool/constant"
+)
+
+// struct which is used to store a config file added or modified by an update. OldContent is nil for added files.
+type ConfigFileChange struct {
+	Path       string
+	OldContent []byte
+	NewContent []byte
+}
+
+// Check whether the given path relative to carbon.home is under one of the given config prefixes.
+func IsConfigFile(relativePath string, prefixes []string) bool {
+	relativePath = path.Clean(strings.Replace(relativePath, "\\", "/", -1))
+	for _, prefix := range prefixes {
+		prefix = strings.TrimSuffix(path.Clean(strings.Replace(prefix, "\\", "/", -1)), "/")
+		if relativePath == prefix || strings.HasPrefix(relativePath, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Get the skeleton of the instructions.txt which lists the given config file changes with their diffs. The skeleton
+// contains the INSTRUCTIONS_SKELETON_PLACEHOLDER which should be replaced by the developer.
+func GetInstructionsSkeleton(changes []ConfigFileChange) string {
+	var buffer bytes.Buffer
+	buffer.WriteString(constant.INSTRUCTIONS_SKELETON_PLACEHOLDER + "\n\n")
+	buffer.WriteString("This update changes the following configuration files. Apply the changes below to the " +
+		"customized configuration files.\n")
+	for index, change := range changes {
+		buffer.WriteString(fmt.Sprintf("\n%d. <CARBON_HOME>/%s\n", index+1, change.Path))
+		if change.OldContent == nil {
+			buffer.WriteString("\tNew file added by the update.\n")
+			continue
+		}
+		if !IsTextContent(change.OldContent) || !IsTextContent(change.NewContent) {
+			buffer.WriteString("\tBinary file modified.\n")
+			continue
+		}
+		diff, err := GetLineDiff(string(change.OldContent), string(change.NewContent))
+		if err != nil {
+			buffer.WriteString(fmt.Sprintf("\tDiff not available: %v\n", err))
+			continue
+		}
+		for _, line := range diff {
+			if line.Type != " " {
+				buffer.WriteString(fmt.Sprintf("\t%s %s\n", line.Type, line.Text))
+			}
+		}
+	}
+	return buffer.String()
+}
+
+// Check whether the given instructions still contain the placeholder of a generated skeleton.
+func IsInstructionsSkeleton(instructions []byte) bool {
+	return bytes.Contains(instructions, []byte(constant.INSTRUCTIONS_SKELETON_PLACEHOLDER))
+}
@@ -0,0 +1,115 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/wso2/update-creator-tool/constant"
+)
+
+// struct which is used to store the class level changes of a modified jar.
+type JarDiff struct {
+	AddedClasses   []string
+	RemovedClasses []string
+	ChangedClasses []string
+	OldVersion     string
+	NewVersion     string
+}
+
+// Compares the given versions of a jar and returns the added, removed and changed class files and the versions in the
+// manifests. Class files are compared using the CRC-32 checksums recorded in the jars.
+func GetJarDiff(oldContent, newContent []byte) (*JarDiff, error) {
+	oldClasses, oldVersion, err := readJar(oldContent)
+	if err != nil {
+		return nil, err
+	}
+	newClasses, newVersion, err := readJar(newContent)
+	if err != nil {
+		return nil, err
+	}
+	jarDiff := JarDiff{OldVersion: oldVersion, NewVersion: newVersion}
+	for name, newChecksum := range newClasses {
+		oldChecksum, found := oldClasses[name]
+		if !found {
+			jarDiff.AddedClasses = append(jarDiff.AddedClasses, name)
+		} else if oldChecksum != newChecksum {
+			jarDiff.ChangedClasses = append(jarDiff.ChangedClasses, name)
+		}
+	}
+	for name := range oldClasses {
+		if _, found := newClasses[name]; !found {
+			jarDiff.RemovedClasses = append(jarDiff.RemovedClasses, name)
+		}
+	}
+	sort.Strings(jarDiff.AddedClasses)
+	sort.Strings(jarDiff.RemovedClasses)
+	sort.Strings(jarDiff.ChangedClasses)
+	return &jarDiff, nil
+}
+
+// Reads the class files of the given jar against their checksums and the version in the manifest of the jar.
+func readJar(content []byte) (map[string]uint32, string, error) {
+	zipReader, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, "", err
+	}
+	classes := make(map[string]uint32)
+	version := ""
+	for _, file := range zipReader.File {
+		if strings.HasSuffix(file.Name, constant.CLASS_FILE_EXTENSION) {
+			classes[file.Name] = file.CRC32
+		} else if file.Name == constant.JAR_MANIFEST_FILE {
+			zippedFile, err := file.Open()
+			if err != nil {
+				return nil, "", err
+			}
+			data, err := ioutil.ReadAll(zippedFile)
+			zippedFile.Close()
+			if err != nil {
+				return nil, "", err
+			}
+			version = getManifestVersion(data)
+		}
+	}
+	return classes, version, nil
+}
+
+// Returns the version in the given jar manifest. Bundle-Version of OSGi bundles takes precedence over
+// Implementation-Version.
+func getManifestVersion(manifest []byte) string {
+	attributes := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(manifest))
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), ":", 2)
+		if len(parts) == 2 {
+			attributes[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+		}
+	}
+	if version, found := attributes["Bundle-Version"]; found {
+		return version
+	}
+	return attributes["Implementation-Version"]
+}
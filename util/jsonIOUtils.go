@@ -0,0 +1,138 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+
+	"github.com/spf13/viper"
+	"github.com/wso2/update-creator-tool/constant"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// struct which is written to the stdout as a single line when the JSON IO mode is enabled
+type JSONIOMessage struct {
+	Type     string        `json:"type"`
+	ID       int           `json:"id,omitempty"`
+	Message  string        `json:"message"`
+	Options  []string      `json:"options,omitempty"`
+	Warnings []Warning     `json:"warnings,omitempty"`
+	Timings  []StageTiming `json:"timings,omitempty"`
+}
+
+// struct which is read from the stdin as a single line when the JSON IO mode is enabled
+type JSONIOAnswer struct {
+	ID     int    `json:"id"`
+	Answer string `json:"answer"`
+}
+
+// Id of the last prompt written to the stdout. Answers should have the same id.
+var lastPromptID = 0
+
+// Check whether the JSON IO mode is enabled. In JSON IO mode all the messages are written to the stdout as JSON
+// objects (one per line) and the answers for the prompts are read from the stdin as JSON objects (one per line).
+func IsJSONIOEnabled() bool {
+	return viper.GetBool(constant.JSON_IO)
+}
+
+// Check whether the user can be prompted, ie: the JSON IO mode is disabled and the standard input is a terminal.
+func IsInteractive() bool {
+	return !IsJSONIOEnabled() && terminal.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// Write the given message to the stdout as a JSON object.
+func PrintJSONMessage(message *JSONIOMessage) {
+	data, err := json.Marshal(message)
+	if err != nil {
+		logger.Error(fmt.Sprintf("%v error occurred while marshalling %v", err, message))
+		return
+	}
+	fmt.Fprintln(os.Stdout, string(data))
+}
+
+// Get the message string from the given arguments which are passed to the print functions.
+func getMessage(args ...interface{}) string {
+	return strings.TrimSpace(fmt.Sprintln(args...))
+}
+
+// Prompt the user with the given message and return the user input.
+func PromptUser(message string) (string, error) {
+	return PromptUserWithOptions(message, nil)
+}
+
+// Prompt the user with the given message and return the user input. Options are only sent in the JSON IO mode.
+// Otherwise, they should be displayed to the user before calling this function. The message is localized when it is
+// shown, the English message is recorded with the answer.
+func PromptUserWithOptions(message string, options []string) (string, error) {
+	var answer string
+	var err error
+	if !IsJSONIOEnabled() {
+		PrintInBold(message)
+		answer, err = GetUserInput()
+	} else {
+		answer, err = getJSONAnswer(constant.JSON_IO_PROMPT, Localize(message), options)
+	}
+	if err == nil && answerRecorder != nil {
+		answerRecorder(message, strings.TrimSpace(answer))
+	}
+	return answer, err
+}
+
+// Prompt the user for a password with the given message.
+func PromptPassword(message string) ([]byte, error) {
+	if !IsJSONIOEnabled() {
+		fmt.Fprint(os.Stderr, Localize(message))
+		password, err := terminal.ReadPassword(int(syscall.Stdin))
+		fmt.Fprintln(os.Stderr)
+		return password, err
+	}
+	password, err := getJSONAnswer(constant.JSON_IO_PASSWORD, Localize(message), nil)
+	return []byte(password), err
+}
+
+// Write a prompt to the stdout and read the answer from the stdin.
+func getJSONAnswer(promptType, message string, options []string) (string, error) {
+	lastPromptID++
+	PrintJSONMessage(&JSONIOMessage{
+		Type:    promptType,
+		ID:      lastPromptID,
+		Message: strings.TrimSpace(message),
+		Options: options,
+	})
+	line, err := stdinReader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	answer := JSONIOAnswer{}
+	err = json.Unmarshal([]byte(line), &answer)
+	if err != nil {
+		return "", errors.New(fmt.Sprintf("invalid answer '%s': %v", strings.TrimSpace(line), err))
+	}
+	if answer.ID != lastPromptID {
+		return "", errors.New(fmt.Sprintf("answer id %d does not match the prompt id %d", answer.ID,
+			lastPromptID))
+	}
+	return strings.TrimSpace(answer.Answer), nil
+}
@@ -0,0 +1,138 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/wso2/update-creator-tool/constant"
+)
+
+// struct which represents the root element of a JUnit XML report
+type junitTestSuites struct {
+	XMLName    xml.Name         `xml:"testsuites"`
+	Name       string           `xml:"name,attr"`
+	Tests      int              `xml:"tests,attr"`
+	Failures   int              `xml:"failures,attr"`
+	TestSuites []junitTestSuite `xml:"testsuite"`
+}
+
+// struct which represents a test suite of a JUnit XML report
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+// struct which represents a test case of a JUnit XML report
+type junitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+// struct which represents the failure of a test case of a JUnit XML report
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// Write the given validation report as a JUnit XML report. Each file declared in the update descriptor is a test case
+// of its classification (added, modified or removed), which fails if an error is recorded for the file. Files which
+// are not declared are test cases only if an error is recorded for them. The other errors fail the test case of the
+// whole validation.
+func writeJUnitValidationReport(writer io.Writer, report *ValidationReport, descriptorFiles map[string][]string) error {
+	files := make(map[string]*ValidationReportFile)
+	for index := range report.Files {
+		files[report.Files[index].Path] = &report.Files[index]
+	}
+	suite := junitTestSuite{Name: report.UpdateName}
+	fileErrors := make(map[string]bool)
+	declaredFiles := make(map[string]bool)
+	for _, classification := range []string{constant.FILE_CLASSIFICATION_ADDED, constant.FILE_CLASSIFICATION_MODIFIED,
+		constant.FILE_CLASSIFICATION_REMOVED} {
+		for _, filePath := range descriptorFiles[classification] {
+			declaredFiles[filePath] = true
+			suite.addFileTestCase(classification, filePath, files[filePath], fileErrors)
+		}
+	}
+	for _, file := range report.Files {
+		if !declaredFiles[file.Path] && len(file.Errors) != 0 {
+			suite.addFileTestCase(constant.FILE_CLASSIFICATION_UNLISTED, file.Path, files[file.Path], fileErrors)
+		}
+	}
+
+	var otherErrors []string
+	for _, message := range report.Errors {
+		if !fileErrors[message] {
+			otherErrors = append(otherErrors, message)
+		}
+	}
+	if len(otherErrors) == 0 && report.Status == constant.VALIDATION_STATUS_FAILED && suite.Failures == 0 {
+		otherErrors = append(otherErrors, fmt.Sprintf("validation failed with the exit code %d", report.ExitCode))
+	}
+	validationTestCase := junitTestCase{ClassName: report.UpdateName, Name: "validation"}
+	if len(otherErrors) != 0 {
+		validationTestCase.Failure = &junitFailure{Message: otherErrors[0], Type: constant.VALIDATION_STATUS_FAILED,
+			Text: strings.Join(otherErrors, "\n")}
+		suite.Failures++
+	}
+	var warnings []string
+	for _, warning := range report.Warnings {
+		warnings = append(warnings, fmt.Sprintf("[%s] %s", warning.Group, warning.Message))
+	}
+	validationTestCase.SystemOut = strings.Join(warnings, "\n")
+	suite.TestCases = append(suite.TestCases, validationTestCase)
+	suite.Tests = len(suite.TestCases)
+
+	testSuites := junitTestSuites{Name: "wum-uc validate", Tests: suite.Tests, Failures: suite.Failures,
+		TestSuites: []junitTestSuite{suite}}
+	data, err := xml.MarshalIndent(testSuites, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(writer, xml.Header+string(data))
+	return err
+}
+
+// Add the test case of the given file of the given classification to the test suite. The test case fails if the
+// given result of the file has errors, and the errors are added to the given set of file errors.
+func (suite *junitTestSuite) addFileTestCase(classification, filePath string, file *ValidationReportFile,
+	fileErrors map[string]bool) {
+	testCase := junitTestCase{ClassName: suite.Name + "." + classification + "_files", Name: filePath}
+	if file != nil && len(file.Errors) != 0 {
+		testCase.Failure = &junitFailure{Message: file.Errors[0], Type: constant.VALIDATION_STATUS_FAILED,
+			Text: strings.Join(file.Errors, "\n")}
+		for _, message := range file.Errors {
+			fileErrors[message] = true
+		}
+		suite.Failures++
+	}
+	if file != nil {
+		testCase.SystemOut = strings.Join(file.Warnings, "\n")
+	}
+	suite.TestCases = append(suite.TestCases, testCase)
+}
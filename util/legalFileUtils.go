@@ -0,0 +1,60 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/wso2/update-creator-tool/constant"
+)
+
+// struct which is used to store the legal resource files required in an update
+type LegalFiles struct {
+	// Location the LICENSE.txt of the update is downloaded from
+	LicenseURL string
+	// Whether the NOT_A_CONTRIBUTION.txt is required in the update
+	IsNotAContributionRequired bool
+}
+
+// Get the legal resource files required in an update. Security updates (updates which declare a security advisory)
+// are licensed with the given security license and do not have a NOT_A_CONTRIBUTION.txt. Other updates are licensed
+// with the given license and have a NOT_A_CONTRIBUTION.txt.
+func GetLegalFiles(securityAdvisory, licenseURL, securityLicenseURL string) *LegalFiles {
+	if len(securityAdvisory) != 0 {
+		return &LegalFiles{LicenseURL: securityLicenseURL}
+	}
+	return &LegalFiles{LicenseURL: licenseURL, IsNotAContributionRequired: true}
+}
+
+// Check whether the legal resource files found in an update match the policy of the given legal files.
+func (legalFiles *LegalFiles) Check(isLicenseFound, isNotAContributionFound bool) error {
+	if !isLicenseFound {
+		return errors.New(fmt.Sprintf("'%s' is not found in the update", constant.LICENSE_FILE))
+	}
+	if legalFiles.IsNotAContributionRequired && !isNotAContributionFound {
+		return errors.New(fmt.Sprintf("'%s' is required as the update is not a security update",
+			constant.NOT_A_CONTRIBUTION_FILE))
+	}
+	if !legalFiles.IsNotAContributionRequired && isNotAContributionFound {
+		return errors.New(fmt.Sprintf("'%s' should not be in a security update", constant.NOT_A_CONTRIBUTION_FILE))
+	}
+	return nil
+}
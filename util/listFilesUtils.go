@@ -0,0 +1,107 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package util
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"sort"
+	"strings"
+
+	"github.com/wso2/update-creator-tool/constant"
+)
+
+// struct which is used to list a file of the payload of an update
+type UpdateFile struct {
+	// Path relative to carbon.home
+	Path string `json:"path"`
+	// Whether the file is added, modified or removed according to the update descriptors, or unlisted if the
+	// descriptors do not refer it
+	Classification string `json:"classification"`
+	Size           uint64 `json:"size"`
+	SHA256         string `json:"sha256"`
+}
+
+// List the files of the payload of the update zip at the given location, classified according to the update
+// descriptors. Binary deltas are listed with their own paths and the classification of the files they reconstruct.
+// Removed files are listed without a size and a hash. Files are sorted by their paths.
+func ListUpdateFiles(updateZipPath string) ([]UpdateFile, error) {
+	updateChanges, err := ReadUpdateChanges(updateZipPath, sha256.Size*2)
+	if err != nil {
+		return nil, err
+	}
+	classifications := make(map[string]string)
+	for _, addedFile := range updateChanges.AddedFiles {
+		classifications[addedFile] = constant.FILE_CLASSIFICATION_ADDED
+	}
+	for _, modifiedFile := range updateChanges.ModifiedFiles {
+		classifications[modifiedFile] = constant.FILE_CLASSIFICATION_MODIFIED
+	}
+
+	zipReader, err := zip.OpenReader(updateZipPath)
+	if err != nil {
+		return nil, err
+	}
+	defer zipReader.Close()
+	payloadDirectory, err := GetZippedPayloadDirectory(&zipReader.Reader)
+	if err != nil {
+		return nil, err
+	}
+	var updateFiles []UpdateFile
+	payloadPrefix := updateChanges.UpdateName + "/" + payloadDirectory + "/"
+	for _, file := range zipReader.Reader.File {
+		if file.FileInfo().IsDir() || !strings.HasPrefix(file.Name, payloadPrefix) {
+			continue
+		}
+		relativePath := strings.TrimPrefix(file.Name, payloadPrefix)
+		classification, found := classifications[strings.TrimSuffix(relativePath, constant.BINARY_DELTA_EXTENSION)]
+		if !found {
+			classification = constant.FILE_CLASSIFICATION_UNLISTED
+		}
+		fileHash, err := getZipEntryHash(file, sha256.Size*2)
+		if err != nil {
+			return nil, err
+		}
+		updateFiles = append(updateFiles, UpdateFile{Path: relativePath, Classification: classification,
+			Size: file.UncompressedSize64, SHA256: fileHash})
+	}
+	for _, removedFile := range updateChanges.RemovedFiles {
+		updateFiles = append(updateFiles, UpdateFile{Path: removedFile,
+			Classification: constant.FILE_CLASSIFICATION_REMOVED})
+	}
+	sort.Slice(updateFiles, func(i, j int) bool {
+		return updateFiles[i].Path < updateFiles[j].Path
+	})
+	return updateFiles, nil
+}
+
+// Filter the given files by the given classification and the given path prefix. Empty filters match all the files.
+func FilterUpdateFiles(updateFiles []UpdateFile, classification, pathPrefix string) []UpdateFile {
+	var filteredFiles []UpdateFile
+	for _, updateFile := range updateFiles {
+		if len(classification) != 0 && updateFile.Classification != classification {
+			continue
+		}
+		if !strings.HasPrefix(updateFile.Path, pathPrefix) {
+			continue
+		}
+		filteredFiles = append(filteredFiles, updateFile)
+	}
+	return filteredFiles
+}
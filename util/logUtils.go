@@ -0,0 +1,169 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ian-kent/go-log/appenders"
+	"github.com/ian-kent/go-log/layout"
+	"github.com/ian-kent/go-log/levels"
+	"github.com/wso2/update-creator-tool/constant"
+)
+
+// Log levels from the least verbose to the most verbose
+var logLevels = []levels.LogLevel{levels.FATAL, levels.ERROR, levels.WARN, levels.INFO, levels.DEBUG, levels.TRACE}
+
+// Log levels which can be given in the configs
+var logLevelNames = map[string]levels.LogLevel{
+	"error": levels.ERROR,
+	"warn":  levels.WARN,
+	"info":  levels.INFO,
+	"debug": levels.DEBUG,
+	"trace": levels.TRACE,
+}
+
+// Log file of the session and the appender which writes to it. These are set if the logs are written to files.
+var logFilePath string
+var logFileAppender appenders.Appender
+
+// Appender which only writes the logs which are at most as verbose as the given level, so that the console is not
+// flooded with the logs written to the log file.
+type levelFilterAppender struct {
+	appenders.Appender
+	level levels.LogLevel
+}
+
+func (appender *levelFilterAppender) Write(level levels.LogLevel, message string, args ...interface{}) {
+	if IsLogLevelEnabled(level, appender.level) {
+		appender.Appender.Write(level, message, args...)
+	}
+}
+
+// Get the log level of the given name.
+func GetLogLevel(name string) (levels.LogLevel, error) {
+	level, found := logLevelNames[strings.ToLower(name)]
+	if !found {
+		return level, errors.New(fmt.Sprintf("invalid log level '%s', expected 'error', 'warn', 'info', 'debug' "+
+			"or 'trace'", name))
+	}
+	return level, nil
+}
+
+// Check whether the logs of the given level are written when the given level is set.
+func IsLogLevelEnabled(level, setLevel levels.LogLevel) bool {
+	return getLogLevelIndex(level) <= getLogLevelIndex(setLevel)
+}
+
+// Get the more verbose of the given log levels.
+func GetVerboseLogLevel(level, otherLevel levels.LogLevel) levels.LogLevel {
+	if IsLogLevelEnabled(level, otherLevel) {
+		return otherLevel
+	}
+	return level
+}
+
+// Write the logs of the session to a time stamped file in the given directory in addition to the console. The logs
+// which are more verbose than the given console level are only written to the file. The file is rotated when it
+// exceeds the given size, and the log files of the sessions other than the given number of latest sessions are
+// removed. Returns the path of the log file.
+func InitLogFile(logsDirectory string, fileLevel, consoleLevel levels.LogLevel, maxFileSize int64,
+	retention int) (string, error) {
+	if err := CreateDirectory(logsDirectory); err != nil {
+		return "", err
+	}
+	logFilePath = filepath.Join(logsDirectory, constant.LOG_FILE_PREFIX+time.Now().Format(
+		constant.LOG_FILE_TIME_FORMAT)+constant.LOG_FILE_EXTENSION)
+	rollingFileAppender := appenders.RollingFile(logFilePath, true)
+	rollingFileAppender.MaxFileSize = maxFileSize
+	rollingFileAppender.MaxBackupIndex = retention
+	rollingFileAppender.SetLayout(layout.Pattern(constant.LOG_FILE_LAYOUT))
+	logFileAppender = rollingFileAppender
+
+	consoleAppender := logger.Appender()
+	logger.SetAppender(appenders.Multiple(consoleAppender.Layout(),
+		&levelFilterAppender{Appender: consoleAppender, level: consoleLevel}, logFileAppender))
+	logger.SetLevel(GetVerboseLogLevel(fileLevel, consoleLevel))
+	return logFilePath, RemoveOldLogFiles(logsDirectory, retention)
+}
+
+// Remove the log files of the sessions other than the given number of latest sessions from the given directory.
+// Rotated log files (eg: wum-uc-20180101-101010.log.1) belong to the session of the log file they are rotated from.
+func RemoveOldLogFiles(logsDirectory string, retention int) error {
+	fileInfos, err := ioutil.ReadDir(logsDirectory)
+	if err != nil {
+		return err
+	}
+	sessionFiles := make(map[string][]string)
+	var sessions []string
+	for _, fileInfo := range fileInfos {
+		name := fileInfo.Name()
+		index := strings.Index(name, constant.LOG_FILE_EXTENSION)
+		if fileInfo.IsDir() || !strings.HasPrefix(name, constant.LOG_FILE_PREFIX) || index == -1 {
+			continue
+		}
+		session := name[:index]
+		if _, found := sessionFiles[session]; !found {
+			sessions = append(sessions, session)
+		}
+		sessionFiles[session] = append(sessionFiles[session], name)
+	}
+	// Sessions are sorted from the latest as the log files are named with the time the session started
+	sort.Sort(sort.Reverse(sort.StringSlice(sessions)))
+	for index := retention; index < len(sessions); index++ {
+		for _, name := range sessionFiles[sessions[index]] {
+			logger.Debug(fmt.Sprintf("Removing old log file %s", name))
+			if err = os.Remove(filepath.Join(logsDirectory, name)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Write the given message only to the log file of the session, if the logs are written to a file. This is used to
+// record the messages which are already printed to the console (eg: errors).
+func WriteToLogFile(level levels.LogLevel, message string) {
+	if logFileAppender != nil {
+		logFileAppender.Write(level, message)
+	}
+}
+
+// Get the path of the log file of the session. An empty string is returned if the logs are not written to a file.
+func GetLogFilePath() string {
+	return logFilePath
+}
+
+// Get the position of the given log level from the least verbose level.
+func getLogLevelIndex(level levels.LogLevel) int {
+	for index, logLevel := range logLevels {
+		if logLevel == level {
+			return index
+		}
+	}
+	return len(logLevels)
+}
@@ -0,0 +1,86 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/viper"
+	"github.com/wso2/update-creator-tool/constant"
+)
+
+// struct which is used to abort the online step in progress when its service is unreachable
+type serviceUnreachableError struct {
+	err error
+}
+
+// Whether an online step which can be run in degraded mode is in progress
+var isOnlineStepRunning = false
+
+// Run the given step which depends on the given online service (JIRA, WUM, etc). If the service is unreachable, the
+// step is aborted, a warning is recorded in the warning summary and false is returned so that the caller can continue
+// in degraded mode with placeholders. Errors of the step are returned as they are, except network errors which are
+// treated as the service being unreachable. If online services are required (REQUIRE_ONLINE), the step is run as
+// usual and wum-uc exits if the service is unreachable.
+func RunOnlineStep(service string, step func() error) (isOnline bool, err error) {
+	if viper.GetBool(constant.REQUIRE_ONLINE) || isOnlineStepRunning {
+		return true, step()
+	}
+	isOnlineStepRunning = true
+	defer func() {
+		isOnlineStepRunning = false
+		recovered := recover()
+		if recovered == nil {
+			return
+		}
+		unreachable, isUnreachable := recovered.(*serviceUnreachableError)
+		if !isUnreachable {
+			panic(recovered)
+		}
+		isOnline, err = false, nil
+		warnDegradedMode(service, unreachable.err)
+	}()
+	err = step()
+	if err != nil && GetExitCode(err) == constant.EXIT_CODE_NETWORK_ERROR {
+		warnDegradedMode(service, err)
+		return false, nil
+	}
+	return true, err
+}
+
+// Abort the online step in progress, if any, as its service is unreachable. Nothing happens if no online step is in
+// progress, so that the caller can handle the error as usual.
+func abortOnlineStep(err error) {
+	if !isOnlineStepRunning {
+		return
+	}
+	if err == nil {
+		err = errors.New(constant.UNABLE_TO_CONNECT_WUM_SERVERS)
+	}
+	panic(&serviceUnreachableError{err: err})
+}
+
+// Print a warning about continuing without the given unreachable service and record it in the warning summary.
+func warnDegradedMode(service string, err error) {
+	logger.Debug(fmt.Sprintf("%s is unreachable: %v", service, err))
+	printWarningOfGroup(constant.WARNING_GROUP_DEGRADED, fmt.Sprintf("%s is unreachable, continuing in degraded "+
+		"mode. Run with '--require-online' to exit instead.", service))
+}
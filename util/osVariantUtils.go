@@ -0,0 +1,81 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/wso2/update-creator-tool/constant"
+)
+
+// struct which is used to declare a payload file which is only installed in the given operating systems (eg:
+// bin/wso2server.sh in linux and darwin, bin/wso2server.bat in windows). Payload files which are not declared are
+// installed in all operating systems.
+type OSVariant struct {
+	// Path of the file relative to carbon.home
+	Path string `yaml:"path"`
+	// Operating systems in which the file is installed, as GOOS values (eg: linux, darwin, windows)
+	OS []string `yaml:"os"`
+}
+
+// Check whether the OS variants declared in the given update-descriptor3.yaml are valid. Each file should be declared
+// once with at least one of the supported operating systems.
+func ValidateOSVariants(updateDescriptorV3 *UpdateDescriptorV3) error {
+	osNames := strings.Split(constant.OS_NAMES, ",")
+	declaredPaths := make(map[string]bool)
+	for _, variant := range updateDescriptorV3.OSVariants {
+		if len(variant.Path) == 0 {
+			return errors.New("path of an OS variant is empty")
+		}
+		if declaredPaths[variant.Path] {
+			return errors.New(fmt.Sprintf("OS variant '%s' is declared more than once", variant.Path))
+		}
+		declaredPaths[variant.Path] = true
+		if len(variant.OS) == 0 {
+			return errors.New(fmt.Sprintf("no operating systems declared for the OS variant '%s'", variant.Path))
+		}
+		for _, osName := range variant.OS {
+			if !IsStringIsInSlice(osName, osNames) {
+				return errors.New(fmt.Sprintf("unsupported operating system '%s' of the OS variant '%s', "+
+					"expected one of %s", osName, variant.Path, constant.OS_NAMES))
+			}
+		}
+	}
+	return nil
+}
+
+// Check whether the given OS variant is installed in the given operating system.
+func (variant *OSVariant) IsInstalledIn(osName string) bool {
+	return IsStringIsInSlice(osName, variant.OS)
+}
+
+// Get the paths (relative to carbon.home) of the OS variants of the given update which are not installed in the given
+// operating system.
+func GetExcludedOSVariants(updateDescriptorV3 *UpdateDescriptorV3, osName string) map[string]bool {
+	excludedFiles := make(map[string]bool)
+	for _, variant := range updateDescriptorV3.OSVariants {
+		if !variant.IsInstalledIn(osName) {
+			excludedFiles[variant.Path] = true
+		}
+	}
+	return excludedFiles
+}
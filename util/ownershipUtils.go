@@ -0,0 +1,97 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package util
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/user"
+	"strconv"
+
+	"gopkg.in/yaml.v2"
+)
+
+// struct which is used to store the owner and the group of a file of the update in the update-descriptor3.yaml
+type FileOwnership struct {
+	Path  string `yaml:"path"`
+	Owner string `yaml:"owner,omitempty"`
+	Group string `yaml:"group,omitempty"`
+}
+
+// struct which is used to read the mapping of the owners and the groups recorded in an update to the users and the
+// groups of the system the update is applied to
+type OwnershipMapping struct {
+	Owners map[string]string `yaml:"owners"`
+	Groups map[string]string `yaml:"groups"`
+}
+
+// Load the ownership mapping file at the given location.
+func LoadOwnershipMapping(mappingPath string) (*OwnershipMapping, error) {
+	data, err := ioutil.ReadFile(mappingPath)
+	if err != nil {
+		return nil, err
+	}
+	mapping := OwnershipMapping{}
+	if err = yaml.Unmarshal(data, &mapping); err != nil {
+		return nil, errors.New(fmt.Sprintf("unable to read the ownership mapping '%s': %v", mappingPath, err))
+	}
+	return &mapping, nil
+}
+
+// Map the owner and the group of the given file ownership to the users and the groups of the system. Owners and
+// groups which are not in the mapping are not changed.
+func (mapping *OwnershipMapping) Map(ownership FileOwnership) FileOwnership {
+	if mapping == nil {
+		return ownership
+	}
+	if owner, found := mapping.Owners[ownership.Owner]; found {
+		ownership.Owner = owner
+	}
+	if group, found := mapping.Groups[ownership.Group]; found {
+		ownership.Group = group
+	}
+	return ownership
+}
+
+// Change the owner and the group of the file at the given location to the ones of the given file ownership. An empty
+// owner or group is not changed.
+func ApplyFileOwnership(filePath string, ownership FileOwnership) error {
+	uid, gid := -1, -1
+	if len(ownership.Owner) != 0 {
+		owner, err := user.Lookup(ownership.Owner)
+		if err != nil {
+			return err
+		}
+		if uid, err = strconv.Atoi(owner.Uid); err != nil {
+			return errors.New(fmt.Sprintf("unsupported user id '%s' of '%s'", owner.Uid, ownership.Owner))
+		}
+	}
+	if len(ownership.Group) != 0 {
+		group, err := user.LookupGroup(ownership.Group)
+		if err != nil {
+			return err
+		}
+		if gid, err = strconv.Atoi(group.Gid); err != nil {
+			return errors.New(fmt.Sprintf("unsupported group id '%s' of '%s'", group.Gid, ownership.Group))
+		}
+	}
+	return os.Lchown(filePath, uid, gid)
+}
@@ -0,0 +1,56 @@
+//go:build !windows
+// +build !windows
+
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+	"syscall"
+)
+
+// Get the owner and the group of the file at the given location. Ids which do not have a name in the system are
+// returned as they are.
+func GetFileOwnership(filePath string) (FileOwnership, error) {
+	fileInfo, err := os.Lstat(filePath)
+	if err != nil {
+		return FileOwnership{}, err
+	}
+	stat, ok := fileInfo.Sys().(*syscall.Stat_t)
+	if !ok {
+		return FileOwnership{}, errors.New(fmt.Sprintf("unable to read the owner of '%s'", filePath))
+	}
+	ownership := FileOwnership{
+		Owner: strconv.FormatUint(uint64(stat.Uid), 10),
+		Group: strconv.FormatUint(uint64(stat.Gid), 10),
+	}
+	if owner, err := user.LookupId(ownership.Owner); err == nil {
+		ownership.Owner = owner.Username
+	}
+	if group, err := user.LookupGroupId(ownership.Group); err == nil {
+		ownership.Group = group.Name
+	}
+	return ownership, nil
+}
@@ -0,0 +1,61 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Get the location of the given relative path inside the given root directory. The path is canonicalized and an
+// error is returned if it points outside of the root directory, either through '..' elements, an absolute path or a
+// symbolic link which resolves to a location outside of the root directory.
+func GetPathInRoot(root, relativePath string) (string, error) {
+	root = getAbsolutePath(filepath.FromSlash(root))
+	location := filepath.Join(root, filepath.FromSlash(relativePath))
+	if filepath.IsAbs(filepath.FromSlash(relativePath)) || !IsPathInRoot(root, location) {
+		return "", errors.New(fmt.Sprintf("'%s' is outside of '%s'", relativePath, root))
+	}
+	// Symbolic links can only be resolved if the location exists
+	resolvedRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return location, nil
+	}
+	resolvedLocation, err := filepath.EvalSymlinks(location)
+	if err != nil {
+		return location, nil
+	}
+	if !IsPathInRoot(resolvedRoot, resolvedLocation) {
+		return "", errors.New(fmt.Sprintf("'%s' resolves to '%s' which is outside of '%s'", relativePath,
+			resolvedLocation, root))
+	}
+	return location, nil
+}
+
+// Check whether the given location is the given root directory or a location inside it.
+func IsPathInRoot(root, location string) bool {
+	relativePath, err := filepath.Rel(getAbsolutePath(root), getAbsolutePath(location))
+	if err != nil {
+		return false
+	}
+	return relativePath != ".." && !strings.HasPrefix(relativePath, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,48 @@
+//go:build windows
+// +build windows
+
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/wso2/update-creator-tool/constant"
+)
+
+// Get the location which can be used to access the given path in Windows even if it is longer than the maximum path
+// length. Long paths are converted to absolute paths with the extended-length prefix, which also disables the
+// processing of '/' separators and '..' elements. So the path is cleaned before adding the prefix.
+func GetLongPath(location string) string {
+	if len(location) == 0 || strings.HasPrefix(location, constant.WINDOWS_LONG_PATH_PREFIX) {
+		return location
+	}
+	absolutePath, err := filepath.Abs(location)
+	if err != nil || len(absolutePath) < constant.WINDOWS_MAX_PATH_LENGTH {
+		return location
+	}
+	// UNC paths (\\server\share\...) need a different prefix
+	if strings.HasPrefix(absolutePath, `\\`) {
+		return constant.WINDOWS_UNC_LONG_PATH_PREFIX + strings.TrimPrefix(absolutePath, `\\`)
+	}
+	return constant.WINDOWS_LONG_PATH_PREFIX + absolutePath
+}
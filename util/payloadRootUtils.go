@@ -0,0 +1,141 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/wso2/update-creator-tool/constant"
+	"gopkg.in/yaml.v2"
+)
+
+// Get the payload directory of the given update, which contains the files of the products which do not declare a
+// payload root. This is carbon.home unless the update descriptor declares the payload directory of its product line.
+func GetPayloadDirectory(updateDescriptorV3 *UpdateDescriptorV3) string {
+	if updateDescriptorV3 == nil {
+		return constant.CARBON_HOME
+	}
+	payloadDirectory := strings.Trim(updateDescriptorV3.PayloadDirectory, "/")
+	if len(payloadDirectory) == 0 {
+		return constant.CARBON_HOME
+	}
+	return payloadDirectory
+}
+
+// Get the payload directory of the product line of the given product families (eg: platform name, product name) from
+// the given payload directories of the product lines. carbon.home is returned if none of the product families declares
+// a payload directory.
+func GetProductLinePayloadDirectory(payloadDirectories map[string]string, productFamilies ...string) string {
+	for _, productFamily := range productFamilies {
+		payloadDirectory := strings.Trim(payloadDirectories[strings.ToLower(productFamily)], "/")
+		if len(productFamily) != 0 && len(payloadDirectory) != 0 {
+			return payloadDirectory
+		}
+	}
+	return constant.CARBON_HOME
+}
+
+// Get the product name of the given distribution name by removing its version (eg: wso2am of wso2am-2.1.0).
+func GetDistributionProductName(distributionName string) string {
+	return regexp.MustCompile(constant.DISTRIBUTION_VERSION_SUFFIX_REGEX).ReplaceAllString(distributionName, "")
+}
+
+// Get the payload directory of the update zip read by the given reader, according to its update-descriptor3.yaml.
+func GetZippedPayloadDirectory(zipReader *zip.Reader) (string, error) {
+	for _, file := range zipReader.File {
+		index := strings.Index(file.Name, "/")
+		if index == -1 || file.Name[index+1:] != constant.UPDATE_DESCRIPTOR_V3_FILE {
+			continue
+		}
+		data, err := readZipFile(file)
+		if err != nil {
+			return "", err
+		}
+		updateDescriptorV3 := UpdateDescriptorV3{}
+		if err = yaml.Unmarshal(data, &updateDescriptorV3); err != nil {
+			return "", errors.New(fmt.Sprintf("unable to read '%s': %v", file.Name, err))
+		}
+		return GetPayloadDirectory(&updateDescriptorV3), nil
+	}
+	return constant.CARBON_HOME, nil
+}
+
+// Get the directory of the update which contains the files of the given product. Files of products which do not
+// declare a payload root are in the given payload directory of the update.
+func GetPayloadRoot(productChanges *ProductChanges, payloadDirectory string) string {
+	payloadRoot := strings.Trim(productChanges.PayloadRoot, "/")
+	if len(payloadRoot) == 0 {
+		return payloadDirectory
+	}
+	return payloadRoot
+}
+
+// Get the products of the given update descriptor which declare a payload root other than the payload directory of
+// the update, grouped by their payload roots.
+func GetProductsByPayloadRoot(updateDescriptorV3 *UpdateDescriptorV3) map[string][]ProductChanges {
+	productsByPayloadRoot := make(map[string][]ProductChanges)
+	payloadDirectory := GetPayloadDirectory(updateDescriptorV3)
+	products := append(append([]ProductChanges{}, updateDescriptorV3.CompatibleProducts...),
+		updateDescriptorV3.PartiallyApplicableProducts...)
+	for _, product := range products {
+		if payloadRoot := GetPayloadRoot(&product, payloadDirectory); payloadRoot != payloadDirectory {
+			productsByPayloadRoot[payloadRoot] = append(productsByPayloadRoot[payloadRoot], product)
+		}
+	}
+	return productsByPayloadRoot
+}
+
+// Check whether the payload directory and the payload roots declared in the given update descriptor are directories
+// inside the update which do not overlap with each other.
+func ValidatePayloadRoots(updateDescriptorV3 *UpdateDescriptorV3) error {
+	payloadDirectory := GetPayloadDirectory(updateDescriptorV3)
+	if !isRelativePathInsideUpdate(payloadDirectory) {
+		return errors.New(fmt.Sprintf("'payload_directory' should be a relative path inside the update, found '%s'.",
+			payloadDirectory))
+	}
+	payloadRoots := []string{payloadDirectory}
+	for payloadRoot, products := range GetProductsByPayloadRoot(updateDescriptorV3) {
+		productId := products[0].ProductName + "-" + products[0].ProductVersion
+		if !isRelativePathInsideUpdate(payloadRoot) {
+			return errors.New(fmt.Sprintf("'payload_root' of '%s' should be a relative path inside the update, "+
+				"found '%s'.", productId, payloadRoot))
+		}
+		for _, otherPayloadRoot := range payloadRoots {
+			if strings.HasPrefix(payloadRoot+"/", otherPayloadRoot+"/") ||
+				strings.HasPrefix(otherPayloadRoot+"/", payloadRoot+"/") {
+				return errors.New(fmt.Sprintf("'payload_root' '%s' of '%s' overlaps with '%s'.", payloadRoot,
+					productId, otherPayloadRoot))
+			}
+		}
+		payloadRoots = append(payloadRoots, payloadRoot)
+	}
+	return nil
+}
+
+// Check whether the given path is a clean relative path which does not resolve outside the update.
+func isRelativePathInsideUpdate(relativePath string) bool {
+	return !path.IsAbs(relativePath) && path.Clean(relativePath) == relativePath && relativePath != ".." &&
+		!strings.HasPrefix(relativePath, "../")
+}
@@ -0,0 +1,107 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// struct which is used to read the output of 'opa eval --format json'
+type opaEvalOutput struct {
+	Result []struct {
+		Expressions []struct {
+			Value interface{} `json:"value"`
+		} `json:"expressions"`
+	} `json:"result"`
+}
+
+// Evaluate the given Rego policies over the given update manifest using the given OPA executable. The query should
+// evaluate to a set (or array) of denial reasons, an empty result means that the update is allowed by the policies.
+func EvaluatePolicies(opaExecutable string, policies []string, query string,
+	updateManifest *UpdateManifest) ([]string, error) {
+	var denials []string
+	if len(policies) == 0 {
+		return denials, nil
+	}
+	data, err := json.Marshal(updateManifest)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("error occurred while marshalling the update manifest: %v", err))
+	}
+	args := []string{"eval", "--format", "json", "--stdin-input"}
+	for _, policy := range policies {
+		args = append(args, "--data", policy)
+	}
+	args = append(args, query)
+	logger.Debug(fmt.Sprintf("Evaluating policies: %s %s", opaExecutable, strings.Join(args, " ")))
+
+	var stdOut, stdErr bytes.Buffer
+	command := exec.Command(opaExecutable, args...)
+	command.Stdin = bytes.NewReader(data)
+	command.Stdout = &stdOut
+	command.Stderr = &stdErr
+	if err = command.Run(); err != nil {
+		message := strings.TrimSpace(stdErr.String())
+		if len(message) == 0 {
+			message = strings.TrimSpace(stdOut.String())
+		}
+		return nil, errors.New(fmt.Sprintf("error occurred while evaluating the policies: %v %s", err, message))
+	}
+	logger.Trace(fmt.Sprintf("opa eval output: %s", stdOut.String()))
+	return getDenials(stdOut.Bytes())
+}
+
+// Get the denial reasons from the output of 'opa eval --format json'. Non-empty sets (or arrays), non-empty strings
+// and true are denials. Results of other types are reported as errors as they cannot be interpreted as denials.
+func getDenials(output []byte) ([]string, error) {
+	evalOutput := opaEvalOutput{}
+	if err := json.Unmarshal(output, &evalOutput); err != nil {
+		return nil, errors.New(fmt.Sprintf("error occurred while reading the policy evaluation result: %v", err))
+	}
+	var denials []string
+	for _, result := range evalOutput.Result {
+		for _, expression := range result.Expressions {
+			switch value := expression.Value.(type) {
+			case []interface{}:
+				for _, reason := range value {
+					denials = append(denials, fmt.Sprint(reason))
+				}
+			case string:
+				if len(value) > 0 {
+					denials = append(denials, value)
+				}
+			case bool:
+				if value {
+					denials = append(denials, "update denied by the policies")
+				}
+			case nil:
+				// Query is undefined, hence nothing is denied
+			default:
+				return nil, errors.New(fmt.Sprintf("policy query should evaluate to a set, an array, a string or a "+
+					"boolean, found '%v'", value))
+			}
+		}
+	}
+	return denials, nil
+}
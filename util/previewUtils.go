@@ -0,0 +1,89 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/wso2/update-creator-tool/constant"
+)
+
+// struct which is used to mark an update as a preview. Preview updates are published to the preview channel so that
+// they can be soaked by a limited set of customers before they are released, and are invalid after the expiry date.
+type Preview struct {
+	// Last date (YYYY-MM-DD, UTC) on which the preview is valid
+	Expires string `yaml:"expires"`
+}
+
+// Get the time at which the given preview expires, which is the end of its expiry date.
+func (preview *Preview) GetExpiry() (time.Time, error) {
+	if len(preview.Expires) == 0 {
+		return time.Time{}, errors.New("'expires' field of the preview not found")
+	}
+	expiryDate, err := time.Parse(constant.PREVIEW_EXPIRY_DATE_LAYOUT, preview.Expires)
+	if err != nil {
+		return time.Time{}, errors.New(fmt.Sprintf("'expires' field '%s' of the preview is not valid. It should "+
+			"be in the format YYYY-MM-DD", preview.Expires))
+	}
+	return expiryDate.AddDate(0, 0, 1), nil
+}
+
+// Check whether the given preview has expired at the given time.
+func (preview *Preview) IsExpired(now time.Time) (bool, error) {
+	expiry, err := preview.GetExpiry()
+	if err != nil {
+		return false, err
+	}
+	return !now.Before(expiry), nil
+}
+
+// Get the channel to which an update with the given preview is published.
+func GetUpdateChannel(preview *Preview) string {
+	if preview == nil {
+		return constant.CHANNEL_RELEASE
+	}
+	return constant.CHANNEL_PREVIEW
+}
+
+// Check whether the given catalog entry is a preview which has expired at the given time.
+func (entry *CatalogEntry) IsExpiredPreview(now time.Time) (bool, error) {
+	if entry.Channel != constant.CHANNEL_PREVIEW {
+		return false, nil
+	}
+	preview := Preview{Expires: entry.PreviewExpires}
+	return preview.IsExpired(now)
+}
+
+// Get the entries of the given catalog which are previews expired at the given time.
+func GetExpiredPreviews(catalog *Catalog, now time.Time) ([]CatalogEntry, error) {
+	var expiredPreviews []CatalogEntry
+	for _, entry := range catalog.Updates {
+		isExpired, err := entry.IsExpiredPreview(now)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("preview '%s' is invalid: %v", entry.UpdateName, err))
+		}
+		if isExpired {
+			expiredPreviews = append(expiredPreviews, entry)
+		}
+	}
+	return expiredPreviews, nil
+}
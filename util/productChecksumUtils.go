@@ -0,0 +1,181 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+import (
+	"archive/zip"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"sort"
+	"strings"
+)
+
+// Hash functions of the checksum manifests shipped by the products next to their files (eg: a.jar.md5 next to a.jar)
+// against the extensions of the manifests.
+var productChecksumHashes = map[string]func() hash.Hash{
+	".md5":    md5.New,
+	".sha1":   sha1.New,
+	".sha256": sha256.New,
+}
+
+// struct which is used to report a payload file of an update which does not match a checksum manifest of the product
+type ChecksumMismatch struct {
+	// Path of the file relative to carbon.home
+	Path string
+	// Path of the checksum manifest relative to carbon.home
+	Manifest string
+	Expected string
+	Actual   string
+}
+
+// Read the checksum manifests of the product in the given distribution. Returns the checksum in each manifest against
+// the path of the manifest relative to the distribution root.
+func ReadDistributionChecksumManifests(distributionFS fs.FS) (map[string]string, error) {
+	checksums := make(map[string]string)
+	err := fs.WalkDir(distributionFS, ".", func(relativePath string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || !isProductChecksumManifest(relativePath) {
+			return err
+		}
+		data, err := fs.ReadFile(distributionFS, relativePath)
+		if err != nil {
+			return err
+		}
+		checksums[relativePath], err = parseProductChecksum(relativePath, data)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return checksums, nil
+}
+
+// Verify the payload files of the given update against the checksum manifests of the product, as if the update is
+// applied to the distribution of which the checksum manifests are given. Checksum manifests in the update override the
+// manifests of the distribution. Binary deltas are not verified as they are not the final content of the files.
+func VerifyProductChecksums(updateZipPath string, distributionChecksums map[string]string) ([]ChecksumMismatch,
+	error) {
+	zipReader, err := zip.OpenReader(updateZipPath)
+	if err != nil {
+		return nil, err
+	}
+	defer zipReader.Close()
+	payloadDirectory, err := GetZippedPayloadDirectory(&zipReader.Reader)
+	if err != nil {
+		return nil, err
+	}
+	checksums := make(map[string]string)
+	for manifest, checksum := range distributionChecksums {
+		checksums[manifest] = checksum
+	}
+	payloadFiles := make(map[string]*zip.File)
+	for _, file := range zipReader.Reader.File {
+		relativePath := GetRelativePath(file)
+		if file.FileInfo().IsDir() || !strings.HasPrefix(relativePath, payloadDirectory+"/") {
+			continue
+		}
+		relativePath = strings.TrimPrefix(relativePath, payloadDirectory+"/")
+		if isProductChecksumManifest(relativePath) {
+			checksum, err := readProductChecksum(file)
+			if err != nil {
+				return nil, err
+			}
+			checksums[relativePath] = checksum
+		} else {
+			payloadFiles[relativePath] = file
+		}
+	}
+
+	var mismatches []ChecksumMismatch
+	for relativePath, file := range payloadFiles {
+		for extension, newHash := range productChecksumHashes {
+			expected, found := checksums[relativePath+extension]
+			if !found {
+				continue
+			}
+			actual, err := getZipEntryChecksum(file, newHash())
+			if err != nil {
+				return nil, err
+			}
+			logger.Debug(fmt.Sprintf("Checksum of %s: %s, %s: %s", relativePath, actual,
+				relativePath+extension, expected))
+			if actual != expected {
+				mismatches = append(mismatches, ChecksumMismatch{
+					Path:     relativePath,
+					Manifest: relativePath + extension,
+					Expected: expected,
+					Actual:   actual,
+				})
+			}
+		}
+	}
+	sort.Slice(mismatches, func(i, j int) bool {
+		return mismatches[i].Manifest < mismatches[j].Manifest
+	})
+	return mismatches, nil
+}
+
+// Check whether the given path is a checksum manifest of the product.
+func isProductChecksumManifest(relativePath string) bool {
+	for extension := range productChecksumHashes {
+		if strings.HasSuffix(relativePath, extension) {
+			return true
+		}
+	}
+	return false
+}
+
+// Read the checksum in the given checksum manifest. The manifest contains the checksum, optionally followed by the
+// name of the file as in the md5sum/sha256sum format.
+func readProductChecksum(file *zip.File) (string, error) {
+	data, err := readZipFile(file)
+	if err != nil {
+		return "", err
+	}
+	return parseProductChecksum(file.Name, data)
+}
+
+// Parse the checksum in the given content of the checksum manifest at the given location.
+func parseProductChecksum(manifestPath string, data []byte) (string, error) {
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return "", errors.New(fmt.Sprintf("checksum manifest '%s' is empty", manifestPath))
+	}
+	return strings.ToLower(fields[0]), nil
+}
+
+// Get the checksum of the content of the given zip entry with the given hash function.
+func getZipEntryChecksum(file *zip.File, checksumHash hash.Hash) (string, error) {
+	zippedFile, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer zippedFile.Close()
+	if _, err = io.Copy(checksumHash, zippedFile); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(checksumHash.Sum(nil)), nil
+}
@@ -0,0 +1,102 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/wso2/update-creator-tool/constant"
+	"gopkg.in/yaml.v2"
+)
+
+// struct which is used to read a deployment profile which describes the paths of the distribution used by a
+// deployment (eg: an API Manager deployment which only runs the gateway)
+type DeploymentProfile struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	// Paths relative to the distribution root or glob patterns used by the deployment. Files inside these paths are
+	// also used.
+	Paths []string `yaml:"paths"`
+	// Paths inside the used paths which are not used by the deployment
+	ExcludedPaths []string `yaml:"excluded_paths"`
+}
+
+// struct which is used to report the impact of an update on a deployment profile
+type ProfileImpact struct {
+	Profile string `json:"profile"`
+	// Whether at least one file change of the update affects the deployment profile
+	IsRelevant      bool         `json:"relevant"`
+	AffectedFiles   []UpdateFile `json:"affected_files"`
+	UnaffectedFiles []UpdateFile `json:"unaffected_files"`
+}
+
+// Read the deployment profile at the given location. The name of the file (without the extension) is used as the name
+// of the profile if it does not have a name.
+func LoadDeploymentProfile(profilePath string) (*DeploymentProfile, error) {
+	data, err := ioutil.ReadFile(profilePath)
+	if err != nil {
+		return nil, err
+	}
+	profile := DeploymentProfile{}
+	if err = yaml.Unmarshal(data, &profile); err != nil {
+		return nil, err
+	}
+	if len(profile.Name) == 0 {
+		profile.Name = strings.TrimSuffix(filepath.Base(profilePath), filepath.Ext(profilePath))
+	}
+	if len(profile.Paths) == 0 {
+		return nil, errors.New(fmt.Sprintf("deployment profile '%s' does not have any paths", profile.Name))
+	}
+	for index, usedPath := range profile.Paths {
+		profile.Paths[index] = strings.Trim(usedPath, "/")
+	}
+	for index, excludedPath := range profile.ExcludedPaths {
+		profile.ExcludedPaths[index] = strings.Trim(excludedPath, "/")
+	}
+	return &profile, nil
+}
+
+// Check whether the given path relative to the distribution root is used by the deployment profile.
+func (profile *DeploymentProfile) IsUsedPath(relativePath string) bool {
+	return IsIgnoredPath(relativePath, profile.Paths) && !IsIgnoredPath(relativePath, profile.ExcludedPaths)
+}
+
+// Get the impact of the given files of an update on the given deployment profile. Binary deltas affect the files they
+// reconstruct.
+func GetProfileImpact(updateFiles []UpdateFile, profile *DeploymentProfile) *ProfileImpact {
+	impact := ProfileImpact{
+		Profile:         profile.Name,
+		AffectedFiles:   []UpdateFile{},
+		UnaffectedFiles: []UpdateFile{},
+	}
+	for _, updateFile := range updateFiles {
+		if profile.IsUsedPath(strings.TrimSuffix(updateFile.Path, constant.BINARY_DELTA_EXTENSION)) {
+			impact.AffectedFiles = append(impact.AffectedFiles, updateFile)
+		} else {
+			impact.UnaffectedFiles = append(impact.UnaffectedFiles, updateFile)
+		}
+	}
+	impact.IsRelevant = len(impact.AffectedFiles) != 0
+	return &impact
+}
@@ -0,0 +1,168 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package util
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/wso2/update-creator-tool/constant"
+)
+
+// struct which is used to write an in-toto statement of which the predicate is a SLSA provenance
+type ProvenanceStatement struct {
+	Type          string              `json:"_type"`
+	Subject       []ProvenanceSubject `json:"subject"`
+	PredicateType string              `json:"predicateType"`
+	Predicate     ProvenancePredicate `json:"predicate"`
+}
+
+// struct which is used to store an artifact (subject or material) of a provenance with its digests
+type ProvenanceSubject struct {
+	Name   string            `json:"name,omitempty"`
+	URI    string            `json:"uri,omitempty"`
+	Digest map[string]string `json:"digest"`
+}
+
+type ProvenancePredicate struct {
+	Builder struct {
+		ID string `json:"id"`
+	} `json:"builder"`
+	BuildType  string `json:"buildType"`
+	Invocation struct {
+		Parameters map[string]interface{} `json:"parameters"`
+	} `json:"invocation"`
+	Metadata struct {
+		BuildStartedOn  string `json:"buildStartedOn,omitempty"`
+		BuildFinishedOn string `json:"buildFinishedOn"`
+		Completeness    struct {
+			Parameters  bool `json:"parameters"`
+			Environment bool `json:"environment"`
+			Materials   bool `json:"materials"`
+		} `json:"completeness"`
+		Reproducible bool `json:"reproducible"`
+	} `json:"metadata"`
+	Materials []ProvenanceSubject `json:"materials"`
+}
+
+// struct which is used to write a DSSE envelope of a provenance statement
+type ProvenanceEnvelope struct {
+	PayloadType string                `json:"payloadType"`
+	Payload     string                `json:"payload"`
+	Signatures  []ProvenanceSignature `json:"signatures"`
+}
+
+type ProvenanceSignature struct {
+	KeyID     string `json:"keyid"`
+	Signature string `json:"sig"`
+}
+
+// Create the provenance statement of the given artifact built by the given version of wum-uc with the given command
+// from the given distribution and the files of the given update directory.
+func NewProvenanceStatement(artifactPath, builderVersion string, buildCommand []string, buildStartedOn,
+	distributionPath, updateDirectory string) (*ProvenanceStatement, error) {
+	statement := ProvenanceStatement{Type: constant.IN_TOTO_STATEMENT_TYPE,
+		PredicateType: constant.SLSA_PROVENANCE_TYPE}
+	_, artifactSHA256, err := getFileChecksums(artifactPath)
+	if err != nil {
+		return nil, err
+	}
+	statement.Subject = []ProvenanceSubject{{Name: filepath.Base(artifactPath),
+		Digest: map[string]string{constant.PROVENANCE_DIGEST_SHA256: artifactSHA256}}}
+
+	predicate := &statement.Predicate
+	predicate.Builder.ID = constant.PROVENANCE_BUILDER_ID + "@" + builderVersion
+	predicate.BuildType = constant.PROVENANCE_BUILD_TYPE
+	predicate.Invocation.Parameters = map[string]interface{}{"command": buildCommand}
+	predicate.Metadata.BuildStartedOn = buildStartedOn
+	predicate.Metadata.BuildFinishedOn = time.Now().UTC().Format(time.RFC3339)
+	predicate.Metadata.Completeness.Parameters = len(buildCommand) != 0
+	predicate.Metadata.Completeness.Materials = true
+
+	_, distributionSHA256, err := getFileChecksums(distributionPath)
+	if err != nil {
+		return nil, err
+	}
+	predicate.Materials = append(predicate.Materials, ProvenanceSubject{URI: filepath.ToSlash(distributionPath),
+		Digest: map[string]string{constant.PROVENANCE_DIGEST_SHA256: distributionSHA256}})
+	updateDirectoryMaterials, err := getDirectoryMaterials(updateDirectory)
+	if err != nil {
+		return nil, err
+	}
+	predicate.Materials = append(predicate.Materials, updateDirectoryMaterials...)
+	return &statement, nil
+}
+
+// Get the given statement in a DSSE envelope as a single line. The envelope is signed with the RSA private key at the
+// given location if it is given.
+func GetProvenanceEnvelope(statement *ProvenanceStatement, signingKeyPath string) ([]byte, error) {
+	payload, err := json.Marshal(statement)
+	if err != nil {
+		return nil, err
+	}
+	envelope := ProvenanceEnvelope{PayloadType: constant.IN_TOTO_PAYLOAD_TYPE,
+		Payload: base64.StdEncoding.EncodeToString(payload), Signatures: []ProvenanceSignature{}}
+	if len(signingKeyPath) != 0 {
+		signature, err := SignData(getPreAuthEncoding(constant.IN_TOTO_PAYLOAD_TYPE, payload), signingKeyPath)
+		if err != nil {
+			return nil, err
+		}
+		envelope.Signatures = append(envelope.Signatures, ProvenanceSignature{Signature: signature})
+	}
+	data, err := json.Marshal(envelope)
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// Get the pre-authentication encoding of the given payload which is signed in a DSSE envelope.
+func getPreAuthEncoding(payloadType string, payload []byte) []byte {
+	return []byte(fmt.Sprintf("DSSEv1 %d %s %d %s", len(payloadType), payloadType, len(payload), payload))
+}
+
+// Get the files of the given directory with their sha256 digests as materials. URIs are relative to the directory.
+func getDirectoryMaterials(directory string) ([]ProvenanceSubject, error) {
+	var materials []ProvenanceSubject
+	err := filepath.Walk(directory, func(filePath string, fileInfo os.FileInfo, err error) error {
+		if err != nil || fileInfo.IsDir() {
+			return err
+		}
+		_, fileSHA256, err := getFileChecksums(filePath)
+		if err != nil {
+			return err
+		}
+		relativePath, err := filepath.Rel(directory, filePath)
+		if err != nil {
+			return err
+		}
+		materials = append(materials, ProvenanceSubject{URI: filepath.ToSlash(relativePath),
+			Digest: map[string]string{constant.PROVENANCE_DIGEST_SHA256: fileSHA256}})
+		return nil
+	})
+	sort.Slice(materials, func(i, j int) bool {
+		return materials[i].URI < materials[j].URI
+	})
+	return materials, err
+}
@@ -0,0 +1,137 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/wso2/update-creator-tool/constant"
+)
+
+// struct which is used to store the details of an update extracted from a legacy README.txt. Fields which are not
+// found in the README.txt are empty.
+type ReadMeDetails struct {
+	UpdateNumber    string
+	PlatformVersion string
+	AppliesTo       string
+	BugFixes        []string
+	Description     string
+	// Id of the security advisory (eg: WSO2-2018-0001) if the README.txt is a security advisory
+	SecurityAdvisory string
+}
+
+// Parser of a legacy README.txt format.
+type ReadMeParser interface {
+	// Name of the README.txt format
+	Name() string
+	// Check whether the given README.txt is in the format of the parser
+	CanParse(readMe string) bool
+	// Extract the details of the update from the given README.txt
+	Parse(readMe string) *ReadMeDetails
+}
+
+// Parsers of the supported README.txt formats in the order they are tried. Security advisories are tried first as
+// they may contain patch ids as well.
+var readMeParsers = []ReadMeParser{&securityAdvisoryReadMeParser{}, &patchReadMeParser{}}
+
+// Get the parser of the format of the given README.txt. nil is returned if the format is not supported.
+func GetReadMeParser(readMe string) ReadMeParser {
+	for _, parser := range readMeParsers {
+		if parser.CanParse(readMe) {
+			logger.Debug("README.txt format: " + parser.Name())
+			return parser
+		}
+	}
+	return nil
+}
+
+// Parser of the README.txt of WSO2 Carbon patches.
+type patchReadMeParser struct{}
+
+func (parser *patchReadMeParser) Name() string {
+	return "patch"
+}
+
+func (parser *patchReadMeParser) CanParse(readMe string) bool {
+	return regexp.MustCompile(constant.PATCH_ID_REGEX).MatchString(readMe)
+}
+
+func (parser *patchReadMeParser) Parse(readMe string) *ReadMeDetails {
+	details := ReadMeDetails{}
+	// Since the regex has 2 capturing groups, the result size will be 3 (because there is the full match)
+	if result := regexp.MustCompile(constant.PATCH_ID_REGEX).FindStringSubmatch(readMe); len(result) != 0 {
+		details.PlatformVersion = result[1]
+		details.UpdateNumber = result[2]
+	}
+	// Associated Jiras section might not appear. So the applies to section is in one of the capturing groups.
+	if result := regexp.MustCompile(constant.APPLIES_TO_REGEX).FindStringSubmatch(readMe); len(result) == 3 {
+		details.AppliesTo = ProcessString(strings.TrimSpace(result[1]+result[2]), ", ", true)
+	}
+	details.BugFixes = getJiraKeys(readMe)
+	if result := regexp.MustCompile(constant.DESCRIPTION_REGEX).FindStringSubmatch(readMe); len(result) != 0 {
+		details.Description = ProcessString(result[1], "\n", false)
+	}
+	return &details
+}
+
+// Parser of the README.txt written using the security advisory template.
+type securityAdvisoryReadMeParser struct{}
+
+func (parser *securityAdvisoryReadMeParser) Name() string {
+	return "security advisory"
+}
+
+func (parser *securityAdvisoryReadMeParser) CanParse(readMe string) bool {
+	return regexp.MustCompile(constant.SECURITY_ADVISORY_REGEX).MatchString(readMe)
+}
+
+func (parser *securityAdvisoryReadMeParser) Parse(readMe string) *ReadMeDetails {
+	details := ReadMeDetails{}
+	if result := regexp.MustCompile(constant.SECURITY_ADVISORY_REGEX).FindStringSubmatch(readMe); len(result) != 0 {
+		details.SecurityAdvisory = result[1]
+	}
+	result := regexp.MustCompile(constant.SECURITY_ADVISORY_UPDATE_REGEX).FindStringSubmatch(readMe)
+	if len(result) != 0 {
+		details.PlatformVersion = result[1]
+		details.UpdateNumber = result[2]
+	}
+	result = regexp.MustCompile(constant.SECURITY_ADVISORY_PRODUCTS_REGEX).FindStringSubmatch(readMe)
+	if len(result) != 0 {
+		details.AppliesTo = strings.TrimSpace(result[1])
+	}
+	details.BugFixes = getJiraKeys(readMe)
+	result = regexp.MustCompile(constant.SECURITY_ADVISORY_DESCRIPTION_REGEX).FindStringSubmatch(readMe)
+	if len(result) != 0 {
+		details.Description = ProcessString(result[1], "\n", false)
+	}
+	return &details
+}
+
+// Get the keys of the Jiras linked in the given README.txt in the order they appear.
+func getJiraKeys(readMe string) []string {
+	var jiraKeys []string
+	for _, match := range regexp.MustCompile(constant.ASSOCIATED_JIRAS_REGEX).FindAllStringSubmatch(readMe, -1) {
+		if !IsStringIsInSlice(match[1], jiraKeys) {
+			jiraKeys = append(jiraKeys, match[1])
+		}
+	}
+	return jiraKeys
+}
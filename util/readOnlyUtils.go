@@ -0,0 +1,61 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Inputs of the update creation (eg: the update directory and the distribution) which must not be modified. These are
+// set in the read only mode, so that the file utilities refuse to write into them.
+var readOnlyInputs []string
+
+// Set the given locations as read only inputs. Writing into these locations or anything inside them fails until
+// they are cleared by calling this function without any location.
+func SetReadOnlyInputs(locations ...string) {
+	readOnlyInputs = nil
+	for _, location := range locations {
+		readOnlyInputs = append(readOnlyInputs, getAbsolutePath(location))
+	}
+}
+
+// Check whether the given location can be written, ie: it is not a read only input or inside one of them.
+func CheckWritable(location string) error {
+	absLocation := getAbsolutePath(location)
+	for _, readOnlyInput := range readOnlyInputs {
+		if absLocation == readOnlyInput || strings.HasPrefix(absLocation, readOnlyInput+string(filepath.Separator)) {
+			return errors.New(fmt.Sprintf("'%s' cannot be written as '%s' is a read only input", location,
+				readOnlyInput))
+		}
+	}
+	return nil
+}
+
+// Get the cleaned absolute path of the given location. The cleaned location is returned if it cannot be resolved.
+func getAbsolutePath(location string) string {
+	absLocation, err := filepath.Abs(location)
+	if err != nil {
+		return filepath.Clean(location)
+	}
+	return absLocation
+}
@@ -0,0 +1,78 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package util
+
+import (
+	"strings"
+)
+
+// This struct holds the resource files which should be copied to an update.
+type ResourceFileSet struct {
+	Mandatory []string
+	Optional  []string
+}
+
+// GetProductFamilies returns the product families which the given update belongs to. These are the platform name and
+// the names of the compatible and partially applicable products.
+func GetProductFamilies(updateDescriptorV3 *UpdateDescriptorV3) []string {
+	var productFamilies []string
+	isAdded := make(map[string]bool)
+	addProductFamily := func(productFamily string) {
+		productFamily = strings.ToLower(productFamily)
+		if len(productFamily) != 0 && !isAdded[productFamily] {
+			isAdded[productFamily] = true
+			productFamilies = append(productFamilies, productFamily)
+		}
+	}
+	addProductFamily(updateDescriptorV3.PlatformName)
+	for _, productChanges := range updateDescriptorV3.CompatibleProducts {
+		addProductFamily(productChanges.ProductName)
+	}
+	for _, productChanges := range updateDescriptorV3.PartiallyApplicableProducts {
+		addProductFamily(productChanges.ProductName)
+	}
+	return productFamilies
+}
+
+// GetResourceFiles returns the resource files of the given update. Key is the file name and value is whether the file
+// is mandatory or not. Resource files of the product families which the update belongs to are added to the default
+// resource files and a file which is mandatory in any of these sets is mandatory.
+func GetResourceFiles(defaultResourceFiles ResourceFileSet, productFamilyResourceFiles map[string]ResourceFileSet,
+	updateDescriptorV3 *UpdateDescriptorV3) map[string]bool {
+	resourceFileSets := []ResourceFileSet{defaultResourceFiles}
+	if updateDescriptorV3 != nil {
+		for _, productFamily := range GetProductFamilies(updateDescriptorV3) {
+			if resourceFileSet, found := productFamilyResourceFiles[productFamily]; found {
+				resourceFileSets = append(resourceFileSets, resourceFileSet)
+			}
+		}
+	}
+	filesMap := make(map[string]bool)
+	for _, resourceFileSet := range resourceFileSets {
+		for _, file := range resourceFileSet.Optional {
+			if _, found := filesMap[file]; !found {
+				filesMap[file] = false
+			}
+		}
+		for _, file := range resourceFileSet.Mandatory {
+			filesMap[file] = true
+		}
+	}
+	return filesMap
+}
@@ -0,0 +1,185 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/wso2/update-creator-tool/constant"
+	"gopkg.in/yaml.v2"
+)
+
+// struct which is used to record a validated update in the validation ledger so that a respin of the update (same
+// update number, new content) can be compared against it.
+type ValidatedUpdate struct {
+	UpdateName  string `yaml:"update-name"`
+	SHA256      string `yaml:"sha256"`
+	ValidatedAt string `yaml:"validated-at"`
+	// sha256 checksums of the files of the update, relative to the update directory
+	Files map[string]string `yaml:"files"`
+	// Content of the text resource files in the update directory (eg: update-descriptor3.yaml, README.txt)
+	Resources map[string]string `yaml:"resources,omitempty"`
+}
+
+// struct which is used to store the differences of a respin from the previously validated update.
+type RespinDiff struct {
+	AddedFiles   []string
+	RemovedFiles []string
+	ChangedFiles []string
+	// Line diffs of the changed text resource files
+	ResourceDiffs map[string][]DiffLine
+}
+
+// Create the validation ledger record of the update zip at the given location.
+func NewValidatedUpdate(updateZipPath, updateName string) (*ValidatedUpdate, error) {
+	validatedUpdate := ValidatedUpdate{
+		UpdateName:  updateName,
+		ValidatedAt: time.Now().UTC().Format(time.RFC3339),
+		Files:       make(map[string]string),
+		Resources:   make(map[string]string),
+	}
+	var err error
+	if _, validatedUpdate.SHA256, err = getFileChecksums(updateZipPath); err != nil {
+		return nil, err
+	}
+	zipReader, err := zip.OpenReader(updateZipPath)
+	if err != nil {
+		return nil, err
+	}
+	defer zipReader.Close()
+	for _, file := range zipReader.Reader.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+		relativePath := strings.TrimPrefix(file.Name, updateName+"/")
+		data, err := readZipFile(file)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("unable to read '%s' in '%s': %v", file.Name, updateZipPath, err))
+		}
+		hash := sha256.Sum256(data)
+		validatedUpdate.Files[relativePath] = hex.EncodeToString(hash[:])
+		// Resource files are in the update directory, the payload is in its sub directories
+		if !strings.Contains(relativePath, "/") && len(data) <= constant.MAX_RESPIN_RESOURCE_SIZE &&
+			IsTextContent(data) {
+			validatedUpdate.Resources[relativePath] = string(data)
+		}
+	}
+	return &validatedUpdate, nil
+}
+
+// Get the differences of the given respin from the given previously validated update.
+func GetRespinDiff(previous, current *ValidatedUpdate) *RespinDiff {
+	diff := RespinDiff{ResourceDiffs: make(map[string][]DiffLine)}
+	for file, checksum := range current.Files {
+		previousChecksum, found := previous.Files[file]
+		if !found {
+			diff.AddedFiles = append(diff.AddedFiles, file)
+		} else if previousChecksum != checksum {
+			diff.ChangedFiles = append(diff.ChangedFiles, file)
+			previousContent, isPreviousText := previous.Resources[file]
+			content, isText := current.Resources[file]
+			if isPreviousText && isText {
+				if lines, err := GetLineDiff(previousContent, content); err == nil {
+					diff.ResourceDiffs[file] = lines
+				}
+			}
+		}
+	}
+	for file := range previous.Files {
+		if _, found := current.Files[file]; !found {
+			diff.RemovedFiles = append(diff.RemovedFiles, file)
+		}
+	}
+	sort.Strings(diff.AddedFiles)
+	sort.Strings(diff.RemovedFiles)
+	sort.Strings(diff.ChangedFiles)
+	return &diff
+}
+
+// Check whether the respin has no differences in the files of the update.
+func (diff *RespinDiff) IsEmpty() bool {
+	return len(diff.AddedFiles) == 0 && len(diff.RemovedFiles) == 0 && len(diff.ChangedFiles) == 0
+}
+
+// Load the record of the given update from the validation ledger in the wum-uc home directory. Nil is returned if
+// the update has not been validated before.
+func LoadValidatedUpdate(wumucHome, updateName string) (*ValidatedUpdate, error) {
+	recordPath := getValidatedUpdatePath(wumucHome, updateName)
+	exists, err := IsFileExists(recordPath)
+	if err != nil || !exists {
+		return nil, err
+	}
+	data, err := ioutil.ReadFile(recordPath)
+	if err != nil {
+		return nil, err
+	}
+	validatedUpdate := ValidatedUpdate{}
+	if err = yaml.Unmarshal(data, &validatedUpdate); err != nil {
+		return nil, errors.New(fmt.Sprintf("unable to read '%s': %v", recordPath, err))
+	}
+	return &validatedUpdate, nil
+}
+
+// Record the given validated update in the validation ledger in the wum-uc home directory, replacing the record of
+// the previous validation of the update.
+func SaveValidatedUpdate(wumucHome string, validatedUpdate *ValidatedUpdate) error {
+	data, err := yaml.Marshal(validatedUpdate)
+	if err != nil {
+		return err
+	}
+	recordPath := getValidatedUpdatePath(wumucHome, validatedUpdate.UpdateName)
+	if err = CreateDirectory(filepath.Dir(recordPath)); err != nil {
+		return err
+	}
+	return WriteFileToDestination(data, recordPath)
+}
+
+// Get the location of the validation ledger record of the given update.
+func getValidatedUpdatePath(wumucHome, updateName string) string {
+	return filepath.Join(wumucHome, constant.WUMUC_VALIDATION_LEDGER_DIRECTORY, updateName+".yaml")
+}
+
+// Write the given respin diff in a human readable form to the given writer.
+func WriteRespinDiff(writer io.Writer, diff *RespinDiff) {
+	for _, file := range diff.AddedFiles {
+		fmt.Fprintf(writer, "  + %s\n", file)
+	}
+	for _, file := range diff.RemovedFiles {
+		fmt.Fprintf(writer, "  - %s\n", file)
+	}
+	for _, file := range diff.ChangedFiles {
+		fmt.Fprintf(writer, "  M %s\n", file)
+		for _, line := range diff.ResourceDiffs[file] {
+			if line.Type != " " {
+				fmt.Fprintf(writer, "\t%s %s\n", line.Type, line.Text)
+			}
+		}
+	}
+}
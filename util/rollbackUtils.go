@@ -0,0 +1,258 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/wso2/update-creator-tool/constant"
+	"gopkg.in/yaml.v2"
+)
+
+// struct which is used to store the reverse changes of an applied update in its rollback zip. Rolling back restores
+// the added and modified files from the rollback zip and deletes the removed files. Paths of the files are relative to
+// the distribution directory.
+type RollbackDescriptor struct {
+	UpdateName      string `yaml:"update_name"`
+	UpdateNumber    string `yaml:"update_number"`
+	PlatformVersion string `yaml:"platform_version"`
+	AppliedAt       string `yaml:"applied_at"`
+	// Files removed by the update
+	AddedFiles []string `yaml:"added_files"`
+	// Files modified by the update
+	ModifiedFiles []string `yaml:"modified_files"`
+	// Files added by the update
+	RemovedFiles []string `yaml:"removed_files"`
+}
+
+// Get the path of the rollback zip of the given update applied to the given distribution directory.
+func GetRollbackZipPath(distributionDirectory, updateName string) string {
+	return filepath.Join(GetBackupDirectory(distributionDirectory, updateName), updateName+
+		constant.ROLLBACK_ZIP_SUFFIX)
+}
+
+// Create the rollback zip of the update recorded in the given backup manifest, before the update is applied to the
+// given distribution directory. The zip contains the current versions of the files which are modified or removed by
+// the update and the reverse descriptor of the update.
+func createRollbackZip(distributionDirectory string, backupManifest *BackupManifest) error {
+	rollbackZipPath := GetRollbackZipPath(distributionDirectory, backupManifest.UpdateName)
+	if err := os.MkdirAll(GetLongPath(filepath.Dir(rollbackZipPath)), 0755); err != nil {
+		return err
+	}
+	rollbackZipFile, err := os.Create(GetLongPath(rollbackZipPath))
+	if err != nil {
+		return err
+	}
+	defer rollbackZipFile.Close()
+	zipWriter := zip.NewWriter(rollbackZipFile)
+
+	rollbackDescriptor := RollbackDescriptor{
+		UpdateName:      backupManifest.UpdateName,
+		UpdateNumber:    backupManifest.UpdateNumber,
+		PlatformVersion: backupManifest.PlatformVersion,
+		AppliedAt:       backupManifest.AppliedAt,
+		AddedFiles:      backupManifest.RemovedFiles,
+		ModifiedFiles:   backupManifest.ModifiedFiles,
+		RemovedFiles:    backupManifest.AddedFiles,
+	}
+	data, err := yaml.Marshal(rollbackDescriptor)
+	if err != nil {
+		return err
+	}
+	entry, err := zipWriter.Create(backupManifest.UpdateName + "/" + constant.ROLLBACK_DESCRIPTOR_FILE)
+	if err != nil {
+		return err
+	}
+	if _, err = entry.Write(data); err != nil {
+		return err
+	}
+	for _, relativePath := range append(append([]string{}, backupManifest.ModifiedFiles...),
+		backupManifest.RemovedFiles...) {
+		if err = addRollbackZipEntry(zipWriter, distributionDirectory, backupManifest.UpdateName,
+			relativePath); err != nil {
+			return err
+		}
+	}
+	if err = zipWriter.Close(); err != nil {
+		return err
+	}
+	return rollbackZipFile.Close()
+}
+
+// Add the given file of the distribution to the files directory of the rollback zip written by the given writer. The
+// permissions and the modification time of the file are stored in the zip.
+func addRollbackZipEntry(zipWriter *zip.Writer, distributionDirectory, updateName, relativePath string) error {
+	source := filepath.Join(distributionDirectory, filepath.FromSlash(relativePath))
+	sourceInfo, err := os.Stat(GetLongPath(source))
+	if err != nil {
+		return err
+	}
+	header, err := zip.FileInfoHeader(sourceInfo)
+	if err != nil {
+		return err
+	}
+	header.Name = updateName + "/" + constant.BACKUP_FILES_DIRECTORY + "/" + relativePath
+	header.Method = zip.Deflate
+	logger.Trace(fmt.Sprintf("Backing up %s to %s", source, header.Name))
+	entry, err := zipWriter.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	sourceFile, err := os.Open(GetLongPath(source))
+	if err != nil {
+		return err
+	}
+	defer sourceFile.Close()
+	_, err = io.Copy(entry, sourceFile)
+	return err
+}
+
+// Read the reverse descriptor of the given rollback zip.
+func ReadRollbackDescriptor(rollbackZipPath string) (*RollbackDescriptor, error) {
+	zipReader, err := zip.OpenReader(rollbackZipPath)
+	if err != nil {
+		return nil, err
+	}
+	defer zipReader.Close()
+	rollbackDescriptor, _, err := readRollbackZip(&zipReader.Reader, rollbackZipPath)
+	return rollbackDescriptor, err
+}
+
+// Read the reverse descriptor and the backed up files of the rollback zip read by the given reader. Backed up files
+// are mapped by their paths relative to the distribution directory.
+func readRollbackZip(zipReader *zip.Reader, rollbackZipPath string) (*RollbackDescriptor, map[string]*zip.File,
+	error) {
+	var rollbackDescriptor *RollbackDescriptor
+	backedUpFiles := make(map[string]*zip.File)
+	for _, file := range zipReader.File {
+		parts := strings.SplitN(file.Name, "/", 2)
+		if len(parts) != 2 || file.FileInfo().IsDir() {
+			continue
+		}
+		if parts[1] == constant.ROLLBACK_DESCRIPTOR_FILE {
+			data, err := readZipFile(file)
+			if err != nil {
+				return nil, nil, err
+			}
+			rollbackDescriptor = &RollbackDescriptor{}
+			if err = yaml.Unmarshal(data, rollbackDescriptor); err != nil {
+				return nil, nil, errors.New(fmt.Sprintf("unable to read '%s': %v", file.Name, err))
+			}
+			continue
+		}
+		if strings.HasPrefix(parts[1], constant.BACKUP_FILES_DIRECTORY+"/") {
+			backedUpFiles[strings.TrimPrefix(parts[1], constant.BACKUP_FILES_DIRECTORY+"/")] = file
+		}
+	}
+	if rollbackDescriptor == nil {
+		return nil, nil, errors.New(fmt.Sprintf("'%s' not found in '%s'", constant.ROLLBACK_DESCRIPTOR_FILE,
+			rollbackZipPath))
+	}
+	return rollbackDescriptor, backedUpFiles, nil
+}
+
+// Roll back the update of the given rollback zip from the given distribution directory. Files removed or modified by
+// the update are restored with their permissions and modification times, files added by the update are deleted
+// along with the directories left empty, and the backups of the update in the distribution are removed. Rolling back
+// fails without changing the distribution if a file to restore is not found in the rollback zip.
+func RollbackUpdateZip(rollbackZipPath, distributionDirectory string) (*RollbackDescriptor, error) {
+	distributionDirectory, err := filepath.Abs(distributionDirectory)
+	if err != nil {
+		return nil, err
+	}
+	rollbackDescriptor, err := rollbackUpdate(rollbackZipPath, distributionDirectory)
+	if err != nil {
+		return nil, err
+	}
+	// Rollback zip may be in the backup directory, so it is removed after the zip is closed
+	backupDirectory := GetBackupDirectory(distributionDirectory, rollbackDescriptor.UpdateName)
+	logger.Debug(fmt.Sprintf("Removing %s", backupDirectory))
+	if err = os.RemoveAll(GetLongPath(backupDirectory)); err != nil {
+		return rollbackDescriptor, err
+	}
+	removeEmptyDirectories(filepath.Dir(backupDirectory), distributionDirectory)
+	return rollbackDescriptor, nil
+}
+
+// Restore the files of the given rollback zip to the given distribution directory and delete the files added by the
+// update.
+func rollbackUpdate(rollbackZipPath, distributionDirectory string) (*RollbackDescriptor, error) {
+	zipReader, err := zip.OpenReader(rollbackZipPath)
+	if err != nil {
+		return nil, err
+	}
+	defer zipReader.Close()
+	rollbackDescriptor, backedUpFiles, err := readRollbackZip(&zipReader.Reader, rollbackZipPath)
+	if err != nil {
+		return nil, err
+	}
+	if !isValidBackupUpdateName(rollbackDescriptor.UpdateName) {
+		return nil, errors.New(fmt.Sprintf("invalid update name '%s' in '%s'", rollbackDescriptor.UpdateName,
+			rollbackZipPath))
+	}
+
+	restoredFiles := append(append([]string{}, rollbackDescriptor.AddedFiles...),
+		rollbackDescriptor.ModifiedFiles...)
+	for _, relativePath := range append(append([]string{}, restoredFiles...), rollbackDescriptor.RemovedFiles...) {
+		if _, err = getDistributionFilePath(distributionDirectory, relativePath); err != nil {
+			return nil, err
+		}
+	}
+	for _, relativePath := range restoredFiles {
+		if backedUpFiles[relativePath] == nil {
+			return nil, errors.New(fmt.Sprintf("backup of '%s' not found in '%s'", relativePath, rollbackZipPath))
+		}
+	}
+
+	for _, relativePath := range restoredFiles {
+		destination := filepath.Join(distributionDirectory, filepath.FromSlash(relativePath))
+		logger.Trace(fmt.Sprintf("Restoring %s", destination))
+		if err = extractZipEntry(backedUpFiles[relativePath], destination); err != nil {
+			return nil, err
+		}
+	}
+	for _, relativePath := range rollbackDescriptor.RemovedFiles {
+		destination := filepath.Join(distributionDirectory, filepath.FromSlash(relativePath))
+		logger.Trace(fmt.Sprintf("Deleting %s", destination))
+		if err = os.Remove(GetLongPath(destination)); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		removeEmptyDirectories(filepath.Dir(destination), distributionDirectory)
+	}
+	return rollbackDescriptor, nil
+}
+
+// Remove the given directory and its parents up to the given root directory, as long as they are empty.
+func removeEmptyDirectories(directory, rootDirectory string) {
+	for directory != rootDirectory && strings.HasPrefix(directory, rootDirectory+string(os.PathSeparator)) {
+		// Directories which are not empty are not removed
+		if err := os.Remove(GetLongPath(directory)); err != nil {
+			return
+		}
+		logger.Trace(fmt.Sprintf("Removed empty directory %s", directory))
+		directory = filepath.Dir(directory)
+	}
+}
@@ -0,0 +1,346 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/wso2/update-creator-tool/constant"
+)
+
+// Kinds of the changes of a file, which are merged across the rolled up updates
+const (
+	fileAdded = iota
+	fileModified
+	fileRemoved
+)
+
+// struct which is used to store a released update which is rolled up into a cumulative update
+type RolledUpUpdate struct {
+	UpdateZipPath      string
+	UpdateName         string
+	UpdateDescriptorV3 *UpdateDescriptorV3
+	// update-descriptor.yaml is only available in backward compatible updates
+	UpdateDescriptorV2 *UpdateDescriptorV2
+}
+
+// struct which is used to merge the changes of the files of a product across the rolled up updates
+type fileChangeSet map[string]int
+
+// Read the released update zips in the given directory which are prior to the given update of the given platform
+// version, sorted by their update numbers. If the platform version is not given, all the updates in the directory
+// should be of the same platform version.
+func ReadRolledUpUpdates(directory, platformVersion, updateNumber string) ([]RolledUpUpdate, error) {
+	updateZipPaths, err := filepath.Glob(filepath.Join(directory, "*"+constant.ZIP_FILE_EXTENSION))
+	if err != nil {
+		return nil, err
+	}
+	var updates []RolledUpUpdate
+	platformVersions := make(map[string]bool)
+	for _, updateZipPath := range updateZipPaths {
+		update, err := ReadRolledUpUpdate(updateZipPath)
+		if err != nil {
+			return nil, err
+		}
+		if len(platformVersion) != 0 && update.UpdateDescriptorV3.PlatformVersion != platformVersion {
+			logger.Debug(fmt.Sprintf("Skipping %s as it is not an update of %s", update.UpdateName,
+				platformVersion))
+			continue
+		}
+		if compareUpdateNumbers(update.UpdateDescriptorV3.UpdateNumber, updateNumber) >= 0 {
+			logger.Debug(fmt.Sprintf("Skipping %s as it is not a prior update of %s", update.UpdateName,
+				updateNumber))
+			continue
+		}
+		platformVersions[update.UpdateDescriptorV3.PlatformVersion] = true
+		updates = append(updates, *update)
+	}
+	if len(platformVersions) > 1 {
+		var versions []string
+		for version := range platformVersions {
+			versions = append(versions, version)
+		}
+		sort.Strings(versions)
+		return nil, errors.New(fmt.Sprintf("updates of several platform versions (%s) found in '%s'. Give the "+
+			"platform version of the cumulative update", strings.Join(versions, ", "), directory))
+	}
+	sort.Slice(updates, func(i, j int) bool {
+		return compareUpdateNumbers(updates[i].UpdateDescriptorV3.UpdateNumber,
+			updates[j].UpdateDescriptorV3.UpdateNumber) < 0
+	})
+	return updates, nil
+}
+
+// Read the update descriptors of the released update zip at the given location. Only updates with an
+// update-descriptor3.yaml can be rolled up.
+func ReadRolledUpUpdate(updateZipPath string) (*RolledUpUpdate, error) {
+	zipReader, err := zip.OpenReader(updateZipPath)
+	if err != nil {
+		return nil, err
+	}
+	defer zipReader.Close()
+
+	update := RolledUpUpdate{UpdateZipPath: updateZipPath}
+	for _, file := range zipReader.Reader.File {
+		// Update descriptors should be in the root directory of the update
+		index := strings.Index(file.Name, "/")
+		if index == -1 || strings.Count(file.Name, "/") != 1 {
+			continue
+		}
+		var descriptor interface{}
+		switch file.Name[index+1:] {
+		case constant.UPDATE_DESCRIPTOR_V3_FILE:
+			update.UpdateDescriptorV3 = &UpdateDescriptorV3{}
+			descriptor = update.UpdateDescriptorV3
+		case constant.UPDATE_DESCRIPTOR_V2_FILE:
+			update.UpdateDescriptorV2 = &UpdateDescriptorV2{}
+			descriptor = update.UpdateDescriptorV2
+		default:
+			continue
+		}
+		update.UpdateName = file.Name[:index]
+		data, err := readZipFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if err = UnmarshalUpdateDescriptor(data, file.Name, descriptor); err != nil {
+			return nil, errors.New(fmt.Sprintf("unable to read '%s' in '%s': %v", file.Name, updateZipPath, err))
+		}
+	}
+	if update.UpdateDescriptorV3 == nil {
+		return nil, errors.New(fmt.Sprintf("'%s' not found in '%s'. Only updates with '%s' can be rolled up",
+			constant.UPDATE_DESCRIPTOR_V3_FILE, updateZipPath, constant.UPDATE_DESCRIPTOR_V3_FILE))
+	}
+	return &update, nil
+}
+
+// Merge the update descriptors of the given updates, sorted by their update numbers, into the update descriptors of
+// the cumulative update with the given update number. The changes of the files are merged in the order of the
+// updates, so a file added by an update and removed by a later update is not changed by the cumulative update.
+// update-descriptor.yaml is only merged if all the updates are backward compatible.
+func MergeRolledUpUpdates(updates []RolledUpUpdate, updateNumber string) (*UpdateDescriptorV3, *UpdateDescriptorV2,
+	error) {
+	if len(updates) == 0 {
+		return nil, nil, errors.New("no updates to roll up")
+	}
+	latestUpdate := updates[len(updates)-1].UpdateDescriptorV3
+	updateDescriptorV3 := &UpdateDescriptorV3{
+		UpdateNumber:     updateNumber,
+		PlatformVersion:  latestUpdate.PlatformVersion,
+		PlatformName:     latestUpdate.PlatformName,
+		BugFixes:         make(map[string]string),
+		PayloadDirectory: latestUpdate.PayloadDirectory,
+		// The checksum algorithm declared by the latest update is used for the cumulative update
+		ChecksumAlgorithm: latestUpdate.ChecksumAlgorithm,
+	}
+	var descriptions, instructions, securityAdvisories []string
+	var productIds []string
+	productChanges := make(map[string]fileChangeSet)
+	productTemplates := make(map[string]*ProductChanges)
+	compatibleUpdates := make(map[string]int)
+	payloadChanges := make(fileChangeSet)
+	for _, update := range updates {
+		descriptor := update.UpdateDescriptorV3
+		if GetPayloadDirectory(descriptor) != GetPayloadDirectory(latestUpdate) {
+			return nil, nil, errors.New(fmt.Sprintf("payload directory of '%s' (%s) is different from the "+
+				"payload directory of the latest update (%s)", update.UpdateName, GetPayloadDirectory(descriptor),
+				GetPayloadDirectory(latestUpdate)))
+		}
+		if len(descriptor.BinaryDeltas) != 0 {
+			return nil, nil, errors.New(fmt.Sprintf("'%s' stores files as binary deltas, which cannot be rolled up "+
+				"without the distribution", update.UpdateName))
+		}
+		descriptions = append(descriptions, fmt.Sprintf("%s: %s", update.UpdateName,
+			strings.TrimSpace(descriptor.Description)))
+		if len(strings.TrimSpace(descriptor.Instructions)) != 0 {
+			instructions = append(instructions, fmt.Sprintf("%s: %s", update.UpdateName,
+				strings.TrimSpace(descriptor.Instructions)))
+		}
+		if len(descriptor.SecurityAdvisory) != 0 && !IsStringIsInSlice(descriptor.SecurityAdvisory,
+			securityAdvisories) {
+			securityAdvisories = append(securityAdvisories, descriptor.SecurityAdvisory)
+		}
+		for jiraKey, summary := range descriptor.BugFixes {
+			updateDescriptorV3.BugFixes[jiraKey] = summary
+		}
+		AddSupersededUpdates(updateDescriptorV3, descriptor.Supersedes)
+		AddSupersededUpdates(updateDescriptorV3, []string{update.UpdateName})
+
+		products := append(append([]ProductChanges{}, descriptor.CompatibleProducts...),
+			descriptor.PartiallyApplicableProducts...)
+		for index, product := range products {
+			if len(strings.Trim(product.PayloadRoot, "/")) != 0 {
+				return nil, nil, errors.New(fmt.Sprintf("'%s' declares a payload root for %s-%s, which cannot be "+
+					"rolled up", update.UpdateName, product.ProductName, product.ProductVersion))
+			}
+			productId := product.ProductName + "-" + product.ProductVersion
+			if _, found := productChanges[productId]; !found {
+				productIds = append(productIds, productId)
+				productChanges[productId] = make(fileChangeSet)
+				productTemplates[productId] = &ProductChanges{ProductName: product.ProductName,
+					ProductVersion: product.ProductVersion}
+			}
+			productChanges[productId].apply(product.AddedFiles, product.ModifiedFiles, product.RemovedFiles)
+			payloadChanges.apply(product.AddedFiles, product.ModifiedFiles, product.RemovedFiles)
+			if index < len(descriptor.CompatibleProducts) {
+				compatibleUpdates[productId]++
+			}
+		}
+	}
+	updateDescriptorV3.Description = strings.Join(descriptions, "\n")
+	updateDescriptorV3.Instructions = strings.Join(instructions, "\n")
+	updateDescriptorV3.SecurityAdvisory = strings.Join(securityAdvisories, ", ")
+
+	// A product is only compatible with the cumulative update if it is compatible with all the rolled up updates
+	for _, productId := range productIds {
+		product := *productTemplates[productId]
+		product.AddedFiles, product.ModifiedFiles, product.RemovedFiles = productChanges[productId].getFiles()
+		if compatibleUpdates[productId] == len(updates) {
+			updateDescriptorV3.CompatibleProducts = append(updateDescriptorV3.CompatibleProducts, product)
+		} else {
+			updateDescriptorV3.PartiallyApplicableProducts = append(updateDescriptorV3.PartiallyApplicableProducts,
+				product)
+		}
+	}
+	mergeFileMetadata(updateDescriptorV3, updates, payloadChanges)
+	updateDescriptorV3.Md5sum = GenerateMd5sumForGeneratedContent(updateDescriptorV3)
+	return updateDescriptorV3, mergeRolledUpUpdateDescriptorsV2(updates, updateDescriptorV3), nil
+}
+
+// Merge the metadata of the files (templated files, ownerships, OS variants, executable files and secret waivers) of
+// the given updates into the given update descriptor. Metadata of later updates replace the ones of the prior updates,
+// and the metadata of the files which are not in the payload of the cumulative update are dropped.
+func mergeFileMetadata(updateDescriptorV3 *UpdateDescriptorV3, updates []RolledUpUpdate,
+	payloadChanges fileChangeSet) {
+	isInPayload := func(filePath string) bool {
+		change, found := payloadChanges[filePath]
+		return found && change != fileRemoved
+	}
+	templatedFiles := make(map[string]int)
+	fileOwnerships := make(map[string]int)
+	osVariants := make(map[string]int)
+	secretWaivers := make(map[string]int)
+	for _, update := range updates {
+		descriptor := update.UpdateDescriptorV3
+		for _, templatedFile := range descriptor.TemplatedFiles {
+			if index, found := templatedFiles[templatedFile.Path]; found {
+				updateDescriptorV3.TemplatedFiles[index] = templatedFile
+			} else if isInPayload(templatedFile.Path) {
+				templatedFiles[templatedFile.Path] = len(updateDescriptorV3.TemplatedFiles)
+				updateDescriptorV3.TemplatedFiles = append(updateDescriptorV3.TemplatedFiles, templatedFile)
+			}
+		}
+		for _, fileOwnership := range descriptor.FileOwnerships {
+			if index, found := fileOwnerships[fileOwnership.Path]; found {
+				updateDescriptorV3.FileOwnerships[index] = fileOwnership
+			} else if isInPayload(fileOwnership.Path) {
+				fileOwnerships[fileOwnership.Path] = len(updateDescriptorV3.FileOwnerships)
+				updateDescriptorV3.FileOwnerships = append(updateDescriptorV3.FileOwnerships, fileOwnership)
+			}
+		}
+		for _, osVariant := range descriptor.OSVariants {
+			if index, found := osVariants[osVariant.Path]; found {
+				updateDescriptorV3.OSVariants[index] = osVariant
+			} else if isInPayload(osVariant.Path) {
+				osVariants[osVariant.Path] = len(updateDescriptorV3.OSVariants)
+				updateDescriptorV3.OSVariants = append(updateDescriptorV3.OSVariants, osVariant)
+			}
+		}
+		for _, secretWaiver := range descriptor.SecretWaivers {
+			if index, found := secretWaivers[secretWaiver.Path]; found {
+				updateDescriptorV3.SecretWaivers[index] = secretWaiver
+			} else if isInPayload(secretWaiver.Path) {
+				secretWaivers[secretWaiver.Path] = len(updateDescriptorV3.SecretWaivers)
+				updateDescriptorV3.SecretWaivers = append(updateDescriptorV3.SecretWaivers, secretWaiver)
+			}
+		}
+		for _, executableFile := range descriptor.ExecutableFiles {
+			if isInPayload(executableFile) && !IsStringIsInSlice(executableFile, updateDescriptorV3.ExecutableFiles) {
+				updateDescriptorV3.ExecutableFiles = append(updateDescriptorV3.ExecutableFiles, executableFile)
+			}
+		}
+	}
+}
+
+// Merge the update-descriptor.yaml files of the given updates according to the given merged update-descriptor3.yaml.
+// Nil is returned if any of the updates is not backward compatible.
+func mergeRolledUpUpdateDescriptorsV2(updates []RolledUpUpdate,
+	updateDescriptorV3 *UpdateDescriptorV3) *UpdateDescriptorV2 {
+	fileChanges := make(fileChangeSet)
+	for _, update := range updates {
+		descriptor := update.UpdateDescriptorV2
+		if descriptor == nil {
+			return nil
+		}
+		fileChanges.apply(descriptor.FileChanges.AddedFiles, descriptor.FileChanges.ModifiedFiles,
+			descriptor.FileChanges.RemovedFiles)
+	}
+	latestUpdate := updates[len(updates)-1].UpdateDescriptorV2
+	updateDescriptorV2 := &UpdateDescriptorV2{
+		UpdateNumber:    updateDescriptorV3.UpdateNumber,
+		PlatformVersion: updateDescriptorV3.PlatformVersion,
+		PlatformName:    updateDescriptorV3.PlatformName,
+		AppliesTo:       latestUpdate.AppliesTo,
+		BugFixes:        updateDescriptorV3.BugFixes,
+		Description:     updateDescriptorV3.Description,
+	}
+	updateDescriptorV2.FileChanges.AddedFiles, updateDescriptorV2.FileChanges.ModifiedFiles,
+		updateDescriptorV2.FileChanges.RemovedFiles = fileChanges.getFiles()
+	return updateDescriptorV2
+}
+
+// Merge the given changes of a later update into the change set. A file added and later removed is dropped, a file
+// added and later modified stays added, and a file removed and later added again is modified.
+func (changeSet fileChangeSet) apply(addedFiles, modifiedFiles, removedFiles []string) {
+	// Lists are in the order of the kinds of the changes
+	for change, files := range [][]string{addedFiles, modifiedFiles, removedFiles} {
+		for _, file := range files {
+			previousChange, found := changeSet[file]
+			switch {
+			case !found:
+				changeSet[file] = change
+			case previousChange == fileAdded && change == fileRemoved:
+				delete(changeSet, file)
+			case previousChange == fileAdded:
+			case change == fileRemoved:
+				changeSet[file] = fileRemoved
+			default:
+				changeSet[file] = fileModified
+			}
+		}
+	}
+}
+
+// Get the sorted added, modified and removed files of the change set.
+func (changeSet fileChangeSet) getFiles() ([]string, []string, []string) {
+	files := [][]string{{}, {}, {}}
+	for file, change := range changeSet {
+		files[change] = append(files[change], file)
+	}
+	for _, changedFiles := range files {
+		sort.Strings(changedFiles)
+	}
+	return files[fileAdded], files[fileModified], files[fileRemoved]
+}
@@ -0,0 +1,136 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// struct which is used to describe an anomaly in the root folder of a zip. Updates and distributions are expected to
+// have all the entries inside a single root folder named after the update or the distribution.
+type RootFolderAnomaly struct {
+	ExpectedRootFolder string
+	RootFolders        []string
+	HasRootLevelFiles  bool
+}
+
+func (anomaly *RootFolderAnomaly) Error() string {
+	switch {
+	case anomaly.IsNameMismatch():
+		return fmt.Sprintf("root folder '%s' does not match the expected root folder '%s'", anomaly.RootFolders[0],
+			anomaly.ExpectedRootFolder)
+	case anomaly.HasRootLevelFiles || len(anomaly.RootFolders) == 0:
+		return fmt.Sprintf("entries are not inside a root folder, expected all the entries to be inside '%s'",
+			anomaly.ExpectedRootFolder)
+	default:
+		return fmt.Sprintf("found %d root folders (%s), expected all the entries to be inside '%s'",
+			len(anomaly.RootFolders), strings.Join(anomaly.RootFolders, ", "), anomaly.ExpectedRootFolder)
+	}
+}
+
+// Check whether the zip has a single root folder with an unexpected name. Paths relative to the root folder are
+// still correct in this case.
+func (anomaly *RootFolderAnomaly) IsNameMismatch() bool {
+	return len(anomaly.RootFolders) == 1 && !anomaly.HasRootLevelFiles
+}
+
+// Get the root folder of the entries of the given zip which should be removed when re-rooting. It is empty if the
+// entries are not inside a single root folder.
+func (anomaly *RootFolderAnomaly) getRootFolderToStrip() string {
+	if anomaly.IsNameMismatch() {
+		return anomaly.RootFolders[0]
+	}
+	return ""
+}
+
+// Check whether all the entries of the given zip are inside a single root folder with the expected name. nil is
+// returned if there are no anomalies.
+func CheckRootFolder(zipReader *zip.Reader, expectedRootFolder string) *RootFolderAnomaly {
+	anomaly := RootFolderAnomaly{ExpectedRootFolder: expectedRootFolder}
+	rootFolders := make(map[string]bool)
+	for _, file := range zipReader.File {
+		name := strings.TrimPrefix(file.Name, "/")
+		if index := strings.Index(name, "/"); index != -1 {
+			rootFolders[name[:index]] = true
+		} else if !file.FileInfo().IsDir() {
+			anomaly.HasRootLevelFiles = true
+		}
+	}
+	for rootFolder := range rootFolders {
+		anomaly.RootFolders = append(anomaly.RootFolders, rootFolder)
+	}
+	sort.Strings(anomaly.RootFolders)
+	if anomaly.IsNameMismatch() && anomaly.RootFolders[0] == expectedRootFolder {
+		return nil
+	}
+	return &anomaly
+}
+
+// Write the entries of the given zip to the destination zip inside the expected root folder of the given anomaly. If
+// the entries are inside a single root folder with an unexpected name, it is renamed to the expected root folder.
+func RerootZip(source, destination string, anomaly *RootFolderAnomaly) error {
+	zipReader, err := zip.OpenReader(source)
+	if err != nil {
+		return err
+	}
+	defer zipReader.Close()
+	destinationFile, err := os.Create(destination)
+	if err != nil {
+		return err
+	}
+	defer destinationFile.Close()
+	zipWriter := zip.NewWriter(destinationFile)
+
+	rootFolderToStrip := anomaly.getRootFolderToStrip()
+	for _, file := range zipReader.Reader.File {
+		name := strings.TrimPrefix(file.Name, "/")
+		if len(rootFolderToStrip) != 0 {
+			name = strings.TrimPrefix(name, rootFolderToStrip+"/")
+			if name == rootFolderToStrip {
+				continue
+			}
+		}
+		header := file.FileHeader
+		header.Name = anomaly.ExpectedRootFolder + "/" + name
+		logger.Trace(fmt.Sprintf("Re-rooting %s to %s", file.Name, header.Name))
+		writer, err := zipWriter.CreateHeader(&header)
+		if err != nil {
+			return err
+		}
+		if file.FileInfo().IsDir() {
+			continue
+		}
+		zippedFile, err := file.Open()
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(writer, zippedFile)
+		zippedFile.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return zipWriter.Close()
+}
@@ -0,0 +1,211 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package util
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/textproto"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wso2/update-creator-tool/constant"
+)
+
+// struct which is used to record the verdict of scanning the payload of an update in the update-descriptor3.yaml
+type ScanVerdict struct {
+	Scanner      string   `yaml:"scanner"`
+	Verdict      string   `yaml:"verdict"`
+	ScannedAt    string   `yaml:"scanned_at"`
+	ScannedFiles int      `yaml:"scanned_files"`
+	Findings     []string `yaml:"findings,omitempty"`
+}
+
+// Scan the files in the given directory with the given scanner command. The directory is given as the last argument
+// of the command. Following the convention of clamscan, exit code 0 means no threats are found and exit code 1 means
+// threats are found. The output of the scanner is recorded as the findings if threats are found. Other exit codes
+// are returned as errors.
+func ScanWithCommand(scannerCommand, directory string) (*ScanVerdict, error) {
+	commandArgs := strings.Fields(scannerCommand)
+	if len(commandArgs) == 0 {
+		return nil, errors.New("scanner command is empty")
+	}
+	scannedFiles, err := getScannedFiles(directory)
+	if err != nil {
+		return nil, err
+	}
+	var output bytes.Buffer
+	command := exec.Command(commandArgs[0], append(commandArgs[1:], directory)...)
+	command.Stdout = &output
+	command.Stderr = &output
+	logger.Debug(fmt.Sprintf("Running scanner: %s %s", scannerCommand, directory))
+	err = command.Run()
+	verdict := newScanVerdict(commandArgs[0], len(scannedFiles))
+	if err == nil {
+		return verdict, nil
+	}
+	if exitError, ok := err.(*exec.ExitError); ok && exitError.ExitCode() == 1 {
+		verdict.Verdict = constant.SCAN_VERDICT_INFECTED
+		for _, line := range strings.Split(strings.TrimSpace(output.String()), "\n") {
+			if len(strings.TrimSpace(line)) != 0 {
+				verdict.Findings = append(verdict.Findings, strings.TrimSpace(line))
+			}
+		}
+		return verdict, nil
+	}
+	message := strings.TrimSpace(output.String())
+	if len(message) == 0 {
+		return nil, err
+	}
+	return nil, errors.New(fmt.Sprintf("%v: %s", err, message))
+}
+
+// Scan the files in the given directory with the ICAP service at the given URL (icap://host[:port]/service). Each
+// file is sent in a RESPMOD request. A '204 No Content' response means no threats are found. Any other successful
+// response means the service modified or blocked the file, and the threat reported by the service (X-Infection-Found
+// or X-Virus-ID header) is recorded as a finding.
+func ScanWithICAP(icapURL, directory string) (*ScanVerdict, error) {
+	serviceURL, err := url.Parse(icapURL)
+	if err != nil {
+		return nil, err
+	}
+	if serviceURL.Scheme != "icap" {
+		return nil, errors.New(fmt.Sprintf("unsupported ICAP URL '%s', expected icap://host[:port]/service",
+			icapURL))
+	}
+	address := serviceURL.Host
+	if len(serviceURL.Port()) == 0 {
+		address = net.JoinHostPort(serviceURL.Hostname(), strconv.Itoa(constant.ICAP_DEFAULT_PORT))
+	}
+	scannedFiles, err := getScannedFiles(directory)
+	if err != nil {
+		return nil, err
+	}
+	verdict := newScanVerdict(icapURL, len(scannedFiles))
+	for _, scannedFile := range scannedFiles {
+		data, err := ioutil.ReadFile(filepath.Join(directory, scannedFile))
+		if err != nil {
+			return nil, err
+		}
+		finding, err := scanFileWithICAP(serviceURL, address, scannedFile, data)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("error occurred while scanning '%s': %v", scannedFile, err))
+		}
+		if len(finding) != 0 {
+			verdict.Verdict = constant.SCAN_VERDICT_INFECTED
+			verdict.Findings = append(verdict.Findings, fmt.Sprintf("%s: %s", scannedFile, finding))
+		}
+	}
+	return verdict, nil
+}
+
+// Send the given file to the ICAP service in a RESPMOD request. An empty string is returned if the service does not
+// modify the file. Otherwise the threat reported by the service is returned.
+func scanFileWithICAP(serviceURL *url.URL, address, name string, data []byte) (string, error) {
+	timeout := time.Duration(constant.ICAP_TIMEOUT_IN_SECONDS) * time.Second
+	connection, err := net.DialTimeout("tcp", address, timeout)
+	if err != nil {
+		return "", err
+	}
+	defer connection.Close()
+	connection.SetDeadline(time.Now().Add(timeout))
+
+	httpHeader := fmt.Sprintf("HTTP/1.1 200 OK\r\nContent-Type: application/octet-stream\r\n"+
+		"Content-Length: %d\r\nContent-Disposition: attachment; filename=\"%s\"\r\n\r\n", len(data),
+		filepath.Base(name))
+	var request bytes.Buffer
+	fmt.Fprintf(&request, "RESPMOD %s ICAP/1.0\r\n", serviceURL.String())
+	fmt.Fprintf(&request, "Host: %s\r\n", serviceURL.Host)
+	fmt.Fprintf(&request, "Allow: 204\r\n")
+	fmt.Fprintf(&request, "Encapsulated: res-hdr=0, res-body=%d\r\n\r\n", len(httpHeader))
+	request.WriteString(httpHeader)
+	if len(data) != 0 {
+		fmt.Fprintf(&request, "%x\r\n", len(data))
+		request.Write(data)
+		request.WriteString("\r\n")
+	}
+	request.WriteString("0\r\n\r\n")
+	if _, err = connection.Write(request.Bytes()); err != nil {
+		return "", err
+	}
+
+	reader := textproto.NewReader(bufio.NewReader(connection))
+	statusLine, err := reader.ReadLine()
+	if err != nil {
+		return "", err
+	}
+	statusParts := strings.SplitN(statusLine, " ", 3)
+	if len(statusParts) < 2 || !strings.HasPrefix(statusParts[0], "ICAP/") {
+		return "", errors.New(fmt.Sprintf("invalid ICAP response '%s'", statusLine))
+	}
+	header, err := reader.ReadMIMEHeader()
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	switch {
+	case statusParts[1] == "204":
+		return "", nil
+	case strings.HasPrefix(statusParts[1], "2"):
+		for _, threatHeader := range []string{"X-Infection-Found", "X-Virus-ID", "X-Violations-Found"} {
+			if threat := header.Get(threatHeader); len(threat) != 0 {
+				return threat, nil
+			}
+		}
+		return "modified by the ICAP service", nil
+	default:
+		return "", errors.New(fmt.Sprintf("ICAP service responded with '%s'", statusLine))
+	}
+}
+
+// Create a clean scan verdict of the given scanner.
+func newScanVerdict(scanner string, scannedFiles int) *ScanVerdict {
+	return &ScanVerdict{
+		Scanner:      scanner,
+		Verdict:      constant.SCAN_VERDICT_CLEAN,
+		ScannedAt:    time.Now().UTC().Format(time.RFC3339),
+		ScannedFiles: scannedFiles,
+	}
+}
+
+// Get the paths of the files in the given directory relative to the directory.
+func getScannedFiles(directory string) ([]string, error) {
+	var scannedFiles []string
+	err := filepath.Walk(directory, func(location string, fileInfo os.FileInfo, err error) error {
+		if err != nil || fileInfo.IsDir() {
+			return err
+		}
+		relativePath, err := filepath.Rel(directory, location)
+		if err != nil {
+			return err
+		}
+		scannedFiles = append(scannedFiles, filepath.ToSlash(relativePath))
+		return nil
+	})
+	return scannedFiles, err
+}
@@ -0,0 +1,188 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// struct which is used to detect a kind of secret in the payload files. If the pattern has a capturing group, it
+// captures the value of the secret, which is ignored if it is an allowed value or a placeholder.
+type SecretRule struct {
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+// struct which is used to report a secret found in a payload file
+type SecretFinding struct {
+	// Path of the file relative to carbon.home
+	Path string
+	Line int
+	Rule string
+}
+
+// struct which is used to read the waivers of the secrets in update-descriptor3.yaml. Secrets found in the file at
+// the given path are not blocking, as the justification is recorded (eg: a well known sample key).
+type SecretWaiver struct {
+	// Path of the file relative to carbon.home
+	Path          string `yaml:"path"`
+	Justification string `yaml:"justification"`
+}
+
+// Rules which are always used to scan for secrets, in addition to the configured patterns
+var defaultSecretRules = []SecretRule{
+	{"private key", regexp.MustCompile(`-----BEGIN ((RSA|EC|DSA|OPENSSH|ENCRYPTED|PGP) )?PRIVATE KEY( BLOCK)?-----`)},
+	{"AWS access key", regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"GitHub token", regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`)},
+	{"Slack token", regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`)},
+	{"password", regexp.MustCompile(`(?i)(?:password|passwd|pwd)["']?\s*[:=]\s*["']?([^\s"'<>,;]{4,})`)},
+	{"password", regexp.MustCompile(`(?i)<(?:password|passwd)>([^<]{4,})</`)},
+	{"token", regexp.MustCompile(`(?i)(?:secret|token|api[_-]?key|access[_-]?key)["']?\s*[:=]\s*["']?` +
+		`([A-Za-z0-9_\-+/=]{16,})`)},
+}
+
+// Get the rules to scan for secrets, ie: the default rules and the given regular expressions.
+func GetSecretRules(patterns []string) ([]SecretRule, error) {
+	rules := append([]SecretRule{}, defaultSecretRules...)
+	for _, pattern := range patterns {
+		compiledPattern, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("invalid secret pattern '%s': %v", pattern, err))
+		}
+		rules = append(rules, SecretRule{pattern, compiledPattern})
+	}
+	return rules, nil
+}
+
+// Find the secrets in the given file content with the given rules. Binary files are not scanned. Values captured by
+// the rules are ignored if they are one of the given allowed values (eg: default passwords of the samples) or a
+// placeholder (eg: ${admin.password}).
+func FindSecrets(filePath string, data []byte, rules []SecretRule, allowedValues []string) []SecretFinding {
+	var findings []SecretFinding
+	if isBinaryContent(data) {
+		return findings
+	}
+	for index, line := range strings.Split(string(data), "\n") {
+		for _, rule := range rules {
+			for _, match := range rule.Pattern.FindAllStringSubmatch(line, -1) {
+				if len(match) > 1 && isAllowedSecretValue(match[1], allowedValues) {
+					continue
+				}
+				findings = append(findings, SecretFinding{Path: filePath, Line: index + 1, Rule: rule.Name})
+				break
+			}
+		}
+	}
+	return findings
+}
+
+// Find the secrets in the payload files in the given directory, ie: the files in its sub directories. Paths of the
+// findings are relative to the given payload directory.
+func FindSecretsInDirectory(directory, payloadDirectory string, rules []SecretRule,
+	allowedValues []string) ([]SecretFinding, error) {
+	files, err := getScannedFiles(directory)
+	if err != nil {
+		return nil, err
+	}
+	var findings []SecretFinding
+	for _, file := range files {
+		// Files in the root of the update are resource files
+		if !strings.Contains(file, "/") {
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join(directory, filepath.FromSlash(file)))
+		if err != nil {
+			return nil, err
+		}
+		findings = append(findings, FindSecrets(GetSecretScanPath(file, payloadDirectory), data, rules,
+			allowedValues)...)
+	}
+	return findings, nil
+}
+
+// Get the path of the given file in the update, relative to the given payload directory if it is in the payload
+// directory.
+func GetSecretScanPath(relativePath, payloadDirectory string) string {
+	return strings.TrimPrefix(relativePath, payloadDirectory+"/")
+}
+
+// Check whether each of the given waivers has a path and a justification.
+func ValidateSecretWaivers(waivers []SecretWaiver) error {
+	for _, waiver := range waivers {
+		if len(strings.TrimSpace(waiver.Path)) == 0 {
+			return errors.New("'path' field not found in a secret waiver.")
+		}
+		if len(strings.TrimSpace(waiver.Justification)) == 0 {
+			return errors.New(fmt.Sprintf("'justification' field not found in the secret waiver of '%s'.",
+				waiver.Path))
+		}
+	}
+	return nil
+}
+
+// Split the given findings into the ones which block the update and the ones which are waived by the given waivers.
+func ApplySecretWaivers(findings []SecretFinding, waivers []SecretWaiver) ([]SecretFinding, []SecretFinding) {
+	waivedPaths := make(map[string]bool)
+	for _, waiver := range waivers {
+		waivedPaths[waiver.Path] = true
+	}
+	var blockingFindings, waivedFindings []SecretFinding
+	for _, finding := range findings {
+		if waivedPaths[finding.Path] {
+			waivedFindings = append(waivedFindings, finding)
+		} else {
+			blockingFindings = append(blockingFindings, finding)
+		}
+	}
+	return blockingFindings, waivedFindings
+}
+
+// Get the finding as <path>:<line>: <rule>. The value of the secret is not included so that it is not leaked to the
+// logs.
+func (finding SecretFinding) String() string {
+	return fmt.Sprintf("%s:%d: %s", finding.Path, finding.Line, finding.Rule)
+}
+
+// Check whether the given captured value is an allowed value or a placeholder.
+func isAllowedSecretValue(value string, allowedValues []string) bool {
+	if strings.HasPrefix(value, "$") || strings.HasPrefix(value, "{{") {
+		return true
+	}
+	for _, allowedValue := range allowedValues {
+		if strings.EqualFold(value, allowedValue) {
+			return true
+		}
+	}
+	return false
+}
+
+// Check whether the given content is binary, ie: it has a null byte in the first 8000 bytes as git considers.
+func isBinaryContent(data []byte) bool {
+	if len(data) > 8000 {
+		data = data[:8000]
+	}
+	return bytes.IndexByte(data, 0) != -1
+}
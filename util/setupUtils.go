@@ -0,0 +1,123 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Set the given values in the config file at the given location and write it. Keys of the values are viper keys, ie:
+// nested keys are separated by '.' (eg: RESOURCE_FILES.MANDATORY) and are matched case insensitively. The other
+// settings in the file are kept as they are. The file is created if it does not exist.
+func SaveConfigValues(configPath string, values yaml.MapSlice) error {
+	var config yaml.MapSlice
+	exists, err := IsFileExists(configPath)
+	if err != nil {
+		return err
+	}
+	if exists {
+		if config, err = readConfigFile(configPath); err != nil {
+			return err
+		}
+	}
+	for _, value := range values {
+		config = setConfigItem(config, strings.Split(fmt.Sprint(value.Key), "."), value.Value)
+	}
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		return err
+	}
+	// Config file holds the access tokens, so it should only be readable by the user
+	file, err := os.OpenFile(configPath, os.O_WRONLY|os.O_TRUNC|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.Write(data)
+	return err
+}
+
+// Set the item of the given config at the given key path to the given value. Missing parent items are created.
+func setConfigItem(config yaml.MapSlice, keyPath []string, value interface{}) yaml.MapSlice {
+	for index := range config {
+		if !strings.EqualFold(fmt.Sprint(config[index].Key), keyPath[0]) {
+			continue
+		}
+		if len(keyPath) == 1 {
+			config[index].Value = value
+		} else {
+			nestedConfig, _ := config[index].Value.(yaml.MapSlice)
+			config[index].Value = setConfigItem(nestedConfig, keyPath[1:], value)
+		}
+		return config
+	}
+	if len(keyPath) == 1 {
+		return append(config, yaml.MapItem{Key: keyPath[0], Value: value})
+	}
+	return append(config, yaml.MapItem{Key: keyPath[0], Value: setConfigItem(nil, keyPath[1:], value)})
+}
+
+// Read the platform versions given in the setup wizard as comma separated <platform_version>=<platform_name> pairs
+// (eg: 4.4.0=wilkes,5.0.0=hamming).
+func ParsePlatformVersions(input string) (map[string]string, error) {
+	platformVersions := make(map[string]string)
+	for _, pair := range strings.Split(input, ",") {
+		if len(strings.TrimSpace(pair)) == 0 {
+			continue
+		}
+		parts := strings.Split(pair, "=")
+		if len(parts) != 2 || len(strings.TrimSpace(parts[0])) == 0 || len(strings.TrimSpace(parts[1])) == 0 {
+			return nil, errors.New(fmt.Sprintf("invalid platform version '%s'. Platform versions should be given "+
+				"as <platform_version>=<platform_name>", strings.TrimSpace(pair)))
+		}
+		platformVersions[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+	if len(platformVersions) == 0 {
+		return nil, errors.New("no platform versions given")
+	}
+	return platformVersions, nil
+}
+
+// Get the given platform versions as comma separated <platform_version>=<platform_name> pairs, sorted by the version.
+func FormatPlatformVersions(platformVersions map[string]string) string {
+	var pairs []string
+	for platformVersion, platformName := range platformVersions {
+		pairs = append(pairs, platformVersion+"="+platformName)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// Read the comma separated list of files given in the setup wizard.
+func ParseFileList(input string) []string {
+	files := []string{}
+	for _, file := range strings.Split(input, ",") {
+		if len(strings.TrimSpace(file)) != 0 {
+			files = append(files, strings.TrimSpace(file))
+		}
+	}
+	return files
+}
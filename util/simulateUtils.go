@@ -0,0 +1,324 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package util
+
+import (
+	"archive/zip"
+	"bufio"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/wso2/update-creator-tool/constant"
+)
+
+// struct which is used to store the changes of an update which is simulated against a customer environment. Hashes of
+// the added and modified files are calculated with the algorithm of the state manifest.
+type UpdateChanges struct {
+	UpdateName    string
+	AddedFiles    []string
+	ModifiedFiles []string
+	RemovedFiles  []string
+	// Hashes of the added and modified files in the update. Keys are the paths relative to carbon.home.
+	Hashes map[string]string
+}
+
+// struct which is used to report the result of simulating an update against a customer environment
+type SimulationReport struct {
+	// Files in the environment which would be replaced by the update
+	OverwrittenFiles []string
+	// Files in the environment which already have the content of the update
+	UpToDateFiles []string
+	// Added files which are not in the environment
+	NewFiles []string
+	// Files removed by the update which are not in the environment
+	MissingRemovedFiles []string
+	// Files modified by the update which are not in the environment
+	MissingModifiedFiles []string
+	// Files modified by the update which are customized in the environment
+	ConflictingFiles []string
+}
+
+// Read the state manifest at the given location. Each line contains the hash (md5 or sha256) and the path of a file
+// relative to carbon.home in the format of md5sum/sha256sum (eg: generated with 'find . -type f | xargs sha256sum').
+// Empty lines and lines starting with '#' are skipped. Length of the hashes is returned with the hashes.
+func ReadStateManifest(location string) (map[string]string, int, error) {
+	file, err := os.Open(location)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer file.Close()
+	stateHashes := make(map[string]string)
+	hashLength := 0
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.SplitN(line, " ", 2)
+		if len(fields) != 2 {
+			return nil, 0, errors.New(fmt.Sprintf("line %d of '%s' should contain a hash and a path", lineNumber,
+				location))
+		}
+		fileHash := strings.ToLower(fields[0])
+		if hashLength == 0 {
+			hashLength = len(fileHash)
+		}
+		if _, err = newStateHash(len(fileHash)); err != nil || len(fileHash) != hashLength {
+			return nil, 0, errors.New(fmt.Sprintf("line %d of '%s' does not contain a valid md5 or sha256 hash",
+				lineNumber, location))
+		}
+		// md5sum marks the files read in binary mode with '*'
+		filePath := strings.TrimPrefix(strings.TrimLeft(fields[1], " *"), "./")
+		stateHashes[strings.Replace(filePath, "\\", "/", -1)] = fileHash
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, 0, err
+	}
+	if len(stateHashes) == 0 {
+		return nil, 0, errors.New(fmt.Sprintf("no files found in '%s'", location))
+	}
+	return stateHashes, hashLength, nil
+}
+
+// Read the changes of the update zip at the given location. Hashes of the added and modified files are calculated
+// with the algorithm of the given hash length. Files which are stored as binary deltas are not hashed as they cannot
+// be reconstructed without the distribution, except when md5 hashes are used as their md5 sums are recorded in the
+// update-descriptor3.yaml.
+func ReadUpdateChanges(updateZipPath string, hashLength int) (*UpdateChanges, error) {
+	zipReader, err := zip.OpenReader(updateZipPath)
+	if err != nil {
+		return nil, err
+	}
+	defer zipReader.Close()
+
+	payloadDirectory, err := GetZippedPayloadDirectory(&zipReader.Reader)
+	if err != nil {
+		return nil, err
+	}
+	updateChanges := UpdateChanges{Hashes: make(map[string]string)}
+	updateDescriptorV2 := UpdateDescriptorV2{}
+	updateDescriptorV3 := UpdateDescriptorV3{}
+	for _, file := range zipReader.Reader.File {
+		index := strings.Index(file.Name, "/")
+		if file.FileInfo().IsDir() || index == -1 {
+			continue
+		}
+		updateChanges.UpdateName = file.Name[:index]
+		relativePath := file.Name[index+1:]
+		var descriptor interface{}
+		switch relativePath {
+		case constant.UPDATE_DESCRIPTOR_V2_FILE:
+			descriptor = &updateDescriptorV2
+		case constant.UPDATE_DESCRIPTOR_V3_FILE:
+			descriptor = &updateDescriptorV3
+		default:
+			if strings.HasPrefix(relativePath, payloadDirectory+"/") {
+				fileHash, err := getZipEntryHash(file, hashLength)
+				if err != nil {
+					return nil, err
+				}
+				updateChanges.Hashes[strings.TrimPrefix(relativePath, payloadDirectory+"/")] = fileHash
+			}
+			continue
+		}
+		data, err := readZipFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if err = UnmarshalUpdateDescriptor(data, file.Name, descriptor); err != nil {
+			return nil, errors.New(fmt.Sprintf("unable to read '%s' in '%s': %v", file.Name, updateZipPath, err))
+		}
+	}
+
+	if len(updateDescriptorV3.UpdateNumber) != 0 {
+		for _, binaryDelta := range updateDescriptorV3.BinaryDeltas {
+			delete(updateChanges.Hashes, binaryDelta.File+constant.BINARY_DELTA_EXTENSION)
+			if hashLength == md5.Size*2 {
+				updateChanges.Hashes[binaryDelta.File] = binaryDelta.TargetMd5
+			}
+		}
+		addedFiles, modifiedFiles, removedFiles := make(map[string]bool), make(map[string]bool), make(map[string]bool)
+		products := append(updateDescriptorV3.CompatibleProducts, updateDescriptorV3.PartiallyApplicableProducts...)
+		for _, product := range products {
+			addToFileSet(addedFiles, product.AddedFiles)
+			addToFileSet(modifiedFiles, product.ModifiedFiles)
+			addToFileSet(removedFiles, product.RemovedFiles)
+		}
+		updateChanges.AddedFiles = getSortedFiles(addedFiles)
+		updateChanges.ModifiedFiles = getSortedFiles(modifiedFiles)
+		updateChanges.RemovedFiles = getSortedFiles(removedFiles)
+	} else if len(updateDescriptorV2.UpdateNumber) != 0 {
+		updateChanges.AddedFiles = updateDescriptorV2.FileChanges.AddedFiles
+		updateChanges.ModifiedFiles = updateDescriptorV2.FileChanges.ModifiedFiles
+		updateChanges.RemovedFiles = updateDescriptorV2.FileChanges.RemovedFiles
+	} else {
+		return nil, errors.New(fmt.Sprintf("update descriptors not found in '%s'", updateZipPath))
+	}
+	return &updateChanges, nil
+}
+
+// Read the hashes of the given files in the given distribution with the algorithm of the given hash length. Files which
+// are not in the distribution are ignored.
+func ReadDistributionHashes(distributionFS fs.FS, files []string, hashLength int) (map[string]string, error) {
+	requiredFiles := make(map[string]bool)
+	addToFileSet(requiredFiles, files)
+	distributionHashes := make(map[string]string)
+	for relativePath := range requiredFiles {
+		data, found, err := ReadDistributionFile(distributionFS, relativePath)
+		if err != nil {
+			return nil, err
+		} else if !found {
+			continue
+		}
+		fileHash, err := newStateHash(hashLength)
+		if err != nil {
+			return nil, err
+		}
+		fileHash.Write(data)
+		distributionHashes[relativePath] = hex.EncodeToString(fileHash.Sum(nil))
+	}
+	return distributionHashes, nil
+}
+
+// Read the hashes of the given files, relative to the given directory, with the algorithm of the given hash length.
+// Files which do not exist in the directory are ignored.
+func ReadDirectoryHashes(directory string, files []string, hashLength int) (map[string]string, error) {
+	directoryHashes := make(map[string]string)
+	for _, file := range files {
+		data, err := ioutil.ReadFile(filepath.Join(directory, filepath.FromSlash(file)))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		fileHash, err := newStateHash(hashLength)
+		if err != nil {
+			return nil, err
+		}
+		fileHash.Write(data)
+		directoryHashes[file] = hex.EncodeToString(fileHash.Sum(nil))
+	}
+	return directoryHashes, nil
+}
+
+// Simulate applying the given update changes to the environment with the given state. A modified file is reported as
+// conflicting if its hash in the environment differs from both the distribution and the update, which means it is
+// customized locally. Conflicts are not detected if the hashes of the distribution are not given.
+func SimulateUpdate(updateChanges *UpdateChanges, stateHashes, distributionHashes map[string]string) *SimulationReport {
+	report := SimulationReport{}
+	for _, addedFile := range updateChanges.AddedFiles {
+		stateHash, found := stateHashes[addedFile]
+		switch {
+		case !found:
+			report.NewFiles = append(report.NewFiles, addedFile)
+		case stateHash == updateChanges.Hashes[addedFile]:
+			report.UpToDateFiles = append(report.UpToDateFiles, addedFile)
+		default:
+			report.OverwrittenFiles = append(report.OverwrittenFiles, addedFile)
+		}
+	}
+	for _, modifiedFile := range updateChanges.ModifiedFiles {
+		stateHash, found := stateHashes[modifiedFile]
+		switch {
+		case !found:
+			report.MissingModifiedFiles = append(report.MissingModifiedFiles, modifiedFile)
+		case stateHash == updateChanges.Hashes[modifiedFile]:
+			report.UpToDateFiles = append(report.UpToDateFiles, modifiedFile)
+		default:
+			report.OverwrittenFiles = append(report.OverwrittenFiles, modifiedFile)
+			if distributionHash, found := distributionHashes[modifiedFile]; found && distributionHash != stateHash {
+				report.ConflictingFiles = append(report.ConflictingFiles, modifiedFile)
+			}
+		}
+	}
+	for _, removedFile := range updateChanges.RemovedFiles {
+		if _, found := stateHashes[removedFile]; !found {
+			report.MissingRemovedFiles = append(report.MissingRemovedFiles, removedFile)
+		}
+	}
+	return &report
+}
+
+// Get the hash of the given zip entry with the algorithm of the given hash length.
+func getZipEntryHash(file *zip.File, hashLength int) (string, error) {
+	fileHash, err := newStateHash(hashLength)
+	if err != nil {
+		return "", err
+	}
+	zippedFile, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer zippedFile.Close()
+	if _, err = io.Copy(fileHash, zippedFile); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(fileHash.Sum(nil)), nil
+}
+
+// Create the hash (md5 or sha256) of which the hex encoded sums have the given length.
+func newStateHash(hashLength int) (hash.Hash, error) {
+	switch hashLength {
+	case md5.Size * 2:
+		return md5.New(), nil
+	case sha256.Size * 2:
+		return sha256.New(), nil
+	default:
+		return nil, errors.New(fmt.Sprintf("unsupported hash length %d", hashLength))
+	}
+}
+
+// Read the content of the given zip entry.
+func readZipFile(file *zip.File) ([]byte, error) {
+	zippedFile, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer zippedFile.Close()
+	return ioutil.ReadAll(zippedFile)
+}
+
+// Add the given files to the given set of files.
+func addToFileSet(fileSet map[string]bool, files []string) {
+	for _, file := range files {
+		fileSet[file] = true
+	}
+}
+
+// Get the files in the given set of files in sorted order.
+func getSortedFiles(fileSet map[string]bool) []string {
+	var files []string
+	for file := range fileSet {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	return files
+}
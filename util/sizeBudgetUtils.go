@@ -0,0 +1,62 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package util
+
+import (
+	"fmt"
+	"strings"
+)
+
+// struct which is used to store the maximum sizes of the update zips imposed by the delivery infrastructure. A zero
+// value disables the corresponding budget.
+type SizeBudgets struct {
+	// Budget of all the updates
+	MaxUpdateSize uint64
+	// Budgets by the platform names and the product names in lower case
+	Platforms map[string]uint64
+	Products  map[string]uint64
+}
+
+// Get the smallest budget which applies to an update of the given platform and products, with a description of what
+// it is configured for. Zero is returned if no budget applies.
+func (sizeBudgets *SizeBudgets) GetSizeBudget(platformName string, productNames []string) (uint64, string) {
+	budget, budgetOf := sizeBudgets.MaxUpdateSize, "all updates"
+	applyBudget := func(candidate uint64, candidateOf string) {
+		if candidate > 0 && (budget == 0 || candidate < budget) {
+			budget, budgetOf = candidate, candidateOf
+		}
+	}
+	applyBudget(sizeBudgets.Platforms[strings.ToLower(platformName)], fmt.Sprintf("platform '%s'", platformName))
+	for _, productName := range productNames {
+		applyBudget(sizeBudgets.Products[strings.ToLower(productName)], fmt.Sprintf("product '%s'", productName))
+	}
+	return budget, budgetOf
+}
+
+// Get the names of the compatible and the partially applicable products of the given update.
+func GetProductNames(updateDescriptorV3 *UpdateDescriptorV3) []string {
+	var productNames []string
+	for _, product := range updateDescriptorV3.CompatibleProducts {
+		productNames = append(productNames, product.ProductName)
+	}
+	for _, product := range updateDescriptorV3.PartiallyApplicableProducts {
+		productNames = append(productNames, product.ProductName)
+	}
+	return productNames
+}
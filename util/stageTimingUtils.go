@@ -0,0 +1,181 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/wso2/update-creator-tool/constant"
+)
+
+// struct which holds the time spent in a single stage of a command
+type StageTiming struct {
+	Stage          string        `json:"stage" yaml:"stage"`
+	Parent         string        `json:"parent,omitempty" yaml:"parent,omitempty"`
+	Runs           int           `json:"runs" yaml:"runs"`
+	Duration       time.Duration `json:"-" yaml:"-"`
+	DurationMillis int64         `json:"duration_ms" yaml:"duration_ms"`
+}
+
+// struct which represents a stage which is currently in progress
+type runningStage struct {
+	stage     string
+	startTime time.Time
+}
+
+// Time at which the command was started. The stage timings are reported against the total time of the command.
+var commandStartTime = time.Now()
+
+// Stages which are currently in progress. Stages may be nested (eg: the validation of the created update).
+var runningStages []runningStage
+
+// Time spent in each stage so far in the order the stages were first started
+var stageTimings []*StageTiming
+
+// Start the timer of the given stage.
+func startStageTimer(stage string) {
+	runningStages = append(runningStages, runningStage{stage: stage, startTime: time.Now()})
+}
+
+// Stop the timer of the given stage and record the time spent in it. The time spent is returned. Nothing is recorded
+// if the stage was not started.
+func stopStageTimer(stage string) time.Duration {
+	for i := len(runningStages) - 1; i >= 0; i-- {
+		if runningStages[i].stage != stage {
+			continue
+		}
+		duration := time.Since(runningStages[i].startTime)
+		runningStages = append(runningStages[:i], runningStages[i+1:]...)
+		recordStageDuration(stage, getParentStage(i), duration)
+		return duration
+	}
+	return 0
+}
+
+// Get the stage which is running at the given depth of the running stages. An empty string is returned for the top
+// level stages.
+func getParentStage(depth int) string {
+	if depth == 0 || depth > len(runningStages) {
+		return ""
+	}
+	return runningStages[depth-1].stage
+}
+
+// Get the outermost stage which is currently in progress. An empty string is returned if no stage is in progress.
+func getOutermostStage() string {
+	if len(runningStages) == 0 {
+		return ""
+	}
+	return runningStages[0].stage
+}
+
+// Record the time spent in a step which is interleaved with other work of a stage (eg: copying files while the
+// files are matched). The step is reported under the stage which is currently in progress.
+func RecordStepDuration(step string, duration time.Duration) {
+	recordStageDuration(step, getParentStage(len(runningStages)), duration)
+}
+
+// Add the given duration to the timing of the given stage under the given parent stage.
+func recordStageDuration(stage, parent string, duration time.Duration) {
+	for _, timing := range stageTimings {
+		if timing.Stage == stage && timing.Parent == parent {
+			timing.Runs++
+			timing.Duration += duration
+			timing.DurationMillis = int64(timing.Duration / time.Millisecond)
+			return
+		}
+	}
+	stageTimings = append(stageTimings, &StageTiming{
+		Stage:          stage,
+		Parent:         parent,
+		Runs:           1,
+		Duration:       duration,
+		DurationMillis: int64(duration / time.Millisecond),
+	})
+}
+
+// Get the time spent in each stage so far.
+func GetStageTimings() []StageTiming {
+	timings := make([]StageTiming, 0, len(stageTimings))
+	for _, timing := range stageTimings {
+		timings = append(timings, *timing)
+	}
+	return timings
+}
+
+// Clear the time spent in each stage so far.
+func ClearStageTimings() {
+	stageTimings = nil
+	runningStages = nil
+}
+
+// Order the given timings so that nested stages follow their parent stage. The depth of each timing is returned
+// along with the ordered timings.
+func orderStageTimings(timings []StageTiming) ([]StageTiming, []int) {
+	var orderedTimings []StageTiming
+	var depths []int
+	var addChildren func(parent string, depth int)
+	addChildren = func(parent string, depth int) {
+		for _, timing := range timings {
+			if timing.Parent != parent || timing.Stage == parent {
+				continue
+			}
+			orderedTimings = append(orderedTimings, timing)
+			depths = append(depths, depth)
+			addChildren(timing.Stage, depth+1)
+		}
+	}
+	addChildren("", 0)
+	return orderedTimings, depths
+}
+
+// Print the time spent in each stage of the command along with the total time of the command. In JSON IO mode the
+// timings are written as a single JSON object. Timings are cleared once printed so that they are printed only once.
+// Nothing is printed if the command does not have stages.
+func PrintStageTimings() {
+	if len(stageTimings) == 0 {
+		return
+	}
+	defer ClearStageTimings()
+	total := time.Since(commandStartTime)
+	message := fmt.Sprintf("%s in total", total.Round(time.Millisecond))
+	if IsJSONIOEnabled() {
+		PrintJSONMessage(&JSONIOMessage{
+			Type:    constant.JSON_IO_STAGE_TIMINGS,
+			Message: message,
+			Timings: GetStageTimings(),
+		})
+		return
+	}
+	color.Set(color.FgCyan, color.Bold)
+	fmt.Println(fmt.Sprintf("\n[TIMINGS] %s", message))
+	color.Unset()
+	timings, depths := orderStageTimings(GetStageTimings())
+	for i, timing := range timings {
+		line := fmt.Sprintf("%*s- %s: %s (%.1f%%)", 2+depths[i]*2, "", timing.Stage,
+			timing.Duration.Round(time.Millisecond), 100*float64(timing.Duration)/float64(total))
+		if timing.Runs > 1 {
+			line += fmt.Sprintf(", %d runs", timing.Runs)
+		}
+		fmt.Println(line)
+	}
+}
@@ -0,0 +1,379 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+	"github.com/wso2/update-creator-tool/constant"
+)
+
+// Storage backends hold the artifacts (distributions, updates, catalogs, etc) referred by URIs. The backend of an
+// artifact is selected by the scheme of its URI, so all the commands accept the same URI forms.
+type StorageBackend interface {
+	// Get the location of the artifact at the given URI. It is an HTTP(S) URL for the remote backends and a file path
+	// for the local backend.
+	Resolve(uri *url.URL) (string, error)
+	// Download the artifact at the given URI to the given file.
+	Download(uri *url.URL, file string) error
+	// Upload the given file to the given URI.
+	Upload(file string, uri *url.URL) error
+}
+
+// Backend which keeps the artifacts in the local file system. Plain paths and file:// URIs are served by it.
+type localStorageBackend struct{}
+
+func (backend *localStorageBackend) Resolve(uri *url.URL) (string, error) {
+	return filepath.FromSlash(uri.Path), nil
+}
+
+func (backend *localStorageBackend) Download(uri *url.URL, file string) error {
+	location, _ := backend.Resolve(uri)
+	return CopyFile(location, file)
+}
+
+func (backend *localStorageBackend) Upload(file string, uri *url.URL) error {
+	location, _ := backend.Resolve(uri)
+	if err := CreateDirectory(filepath.Dir(location)); err != nil {
+		return err
+	}
+	return CopyFile(file, location)
+}
+
+// Backend which accesses the artifacts over HTTP(S). Object stores are accessed through their HTTPS endpoints, so the
+// credentials should be in the URI (eg: presigned URLs or user info) unless the artifacts are public.
+type httpStorageBackend struct {
+	resolveURL    func(uri *url.URL) (string, error)
+	uploadHeaders map[string]string
+}
+
+func (backend *httpStorageBackend) Resolve(uri *url.URL) (string, error) {
+	return backend.resolveURL(uri)
+}
+
+func (backend *httpStorageBackend) Download(uri *url.URL, file string) error {
+	location, err := backend.Resolve(uri)
+	if err != nil {
+		return err
+	}
+	response, err := http.Get(location)
+	if err != nil {
+		return NewNetworkError(err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return NewNetworkError(errors.New(fmt.Sprintf("Could not download the file from: %s",
+			getRedactedURI(uri))))
+	}
+	out, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	_, err = io.Copy(out, response.Body)
+	return err
+}
+
+func (backend *httpStorageBackend) Upload(file string, uri *url.URL) error {
+	location, err := backend.Resolve(uri)
+	if err != nil {
+		return err
+	}
+	in, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	fileInfo, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequest(http.MethodPut, location, in)
+	if err != nil {
+		return err
+	}
+	request.ContentLength = fileInfo.Size()
+	request.Header.Set(constant.HEADER_CONTENT_TYPE, constant.HEADER_VALUE_APPLICATION_OCTET_STREAM)
+	for header, value := range backend.uploadHeaders {
+		request.Header.Set(header, value)
+	}
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return NewNetworkError(err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		return NewNetworkError(errors.New(fmt.Sprintf("Could not upload the file to: %s (%s)",
+			getRedactedURI(uri), response.Status)))
+	}
+	return nil
+}
+
+// Storage backends by the URI scheme. The local backend is registered with an empty scheme.
+var storageBackends = map[string]StorageBackend{
+	"":                                  &localStorageBackend{},
+	constant.STORAGE_SCHEME_FILE:        &localStorageBackend{},
+	constant.STORAGE_SCHEME_HTTP:        &httpStorageBackend{resolveURL: resolveHTTPURL},
+	constant.STORAGE_SCHEME_HTTPS:       &httpStorageBackend{resolveURL: resolveHTTPURL},
+	constant.STORAGE_SCHEME_S3:          &httpStorageBackend{resolveURL: resolveS3URL},
+	constant.STORAGE_SCHEME_GS:          &httpStorageBackend{resolveURL: resolveGSURL},
+	constant.STORAGE_SCHEME_ARTIFACTORY: &httpStorageBackend{resolveURL: resolveArtifactoryURL},
+	constant.STORAGE_SCHEME_AZBLOB: &httpStorageBackend{resolveURL: resolveAzureBlobURL,
+		uploadHeaders: map[string]string{constant.HEADER_AZURE_BLOB_TYPE: constant.AZURE_BLOB_TYPE_BLOCK_BLOB}},
+}
+
+// Register the given backend for the URIs of the given scheme. An existing backend of the scheme is replaced.
+func RegisterStorageBackend(scheme string, backend StorageBackend) {
+	storageBackends[strings.ToLower(scheme)] = backend
+}
+
+// Get the storage backend of the given URI along with the parsed URI. Plain paths (including Windows paths with a
+// drive letter) are served by the local backend.
+func GetStorageBackend(uri string) (StorageBackend, *url.URL, error) {
+	parsedURI, err := url.Parse(uri)
+	if err != nil || len(parsedURI.Scheme) <= 1 {
+		return storageBackends[""], &url.URL{Path: filepath.ToSlash(uri)}, nil
+	}
+	backend, found := storageBackends[parsedURI.Scheme]
+	if !found {
+		return nil, nil, errors.New(fmt.Sprintf("unsupported URI '%s', supported schemes are %s",
+			getRedactedURI(parsedURI), strings.Join(getStorageSchemes(), ", ")))
+	}
+	return backend, parsedURI, nil
+}
+
+// Check whether the artifact at the given URI is not in the local file system.
+func IsRemoteArtifact(uri string) bool {
+	backend, _, err := GetStorageBackend(uri)
+	if err != nil {
+		return true
+	}
+	_, isLocal := backend.(*localStorageBackend)
+	return !isLocal
+}
+
+// Get the location of the artifact at the given URI. It is an HTTP(S) URL for the remote artifacts and a file path for
+// the local artifacts.
+func ResolveArtifactURL(uri string) (string, error) {
+	backend, parsedURI, err := GetStorageBackend(uri)
+	if err != nil {
+		return "", err
+	}
+	location, err := backend.Resolve(parsedURI)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(location, "/"), nil
+}
+
+// Get the URI of the artifact with the given name in the directory at the given URI. The name is joined to the path of
+// the URI, so the query of the URI (eg: the signature of a SAS token or a presigned URL) is kept as it is.
+func JoinArtifactURI(directoryURI, name string) string {
+	if !IsRemoteArtifact(directoryURI) {
+		return filepath.Join(directoryURI, name)
+	}
+	// URIs of the remote artifacts are valid URLs (see GetStorageBackend)
+	parsedURI, _ := url.Parse(directoryURI)
+	parsedURI.Path = path.Join("/", parsedURI.Path, name)
+	parsedURI.RawPath = ""
+	return parsedURI.String()
+}
+
+// Get the URI of the artifact which is named as the artifact at the given URI followed by the given suffix (eg: the
+// signature of a catalog). The query of the URI is kept as it is.
+func AppendToArtifactURI(uri, suffix string) string {
+	if !IsRemoteArtifact(uri) {
+		return uri + suffix
+	}
+	// URIs of the remote artifacts are valid URLs (see GetStorageBackend)
+	parsedURI, _ := url.Parse(uri)
+	parsedURI.Path += suffix
+	parsedURI.RawPath = ""
+	return parsedURI.String()
+}
+
+// Download the artifact at the given URI to the given file.
+func DownloadArtifact(file, uri string) error {
+	backend, parsedURI, err := GetStorageBackend(uri)
+	if err != nil {
+		return err
+	}
+	return backend.Download(parsedURI, file)
+}
+
+// Download the artifact at the given URI to the given file. Artifacts served over HTTP(S) are downloaded in chunks of
+// the given size, and each chunk is retried the given number of times (see DownloadFileInChunks).
+func DownloadArtifactInChunks(file, uri string, chunkSize int64, retries int) error {
+	backend, parsedURI, err := GetStorageBackend(uri)
+	if err != nil {
+		return err
+	}
+	if _, isHTTP := backend.(*httpStorageBackend); !isHTTP {
+		return backend.Download(parsedURI, file)
+	}
+	location, err := backend.Resolve(parsedURI)
+	if err != nil {
+		return err
+	}
+	return DownloadFileInChunks(file, location, chunkSize, retries)
+}
+
+// Upload the given file to the given URI.
+func UploadArtifact(file, uri string) error {
+	backend, parsedURI, err := GetStorageBackend(uri)
+	if err != nil {
+		return err
+	}
+	return backend.Upload(file, parsedURI)
+}
+
+// Get a local file of the artifact at the given URI. Remote artifacts are downloaded to the given directory, while the
+// path of a local artifact is returned as it is.
+func ResolveArtifact(uri, directory string) (string, error) {
+	if !IsRemoteArtifact(uri) {
+		backend, parsedURI, _ := GetStorageBackend(uri)
+		return backend.Resolve(parsedURI)
+	}
+	_, parsedURI, err := GetStorageBackend(uri)
+	if err != nil {
+		return "", err
+	}
+	name := path.Base(parsedURI.Path)
+	if len(name) == 0 || name == "/" || name == "." {
+		return "", errors.New(fmt.Sprintf("file name not found in '%s'", getRedactedURI(parsedURI)))
+	}
+	if err = CreateDirectory(directory); err != nil {
+		return "", err
+	}
+	file := filepath.Join(directory, name)
+	logger.Debug(fmt.Sprintf("Downloading %s to %s", getRedactedURI(parsedURI), file))
+	err = DownloadArtifactInChunks(file, uri, viper.GetInt64(constant.DOWNLOAD_CHUNK_SIZE),
+		viper.GetInt(constant.DOWNLOAD_RETRIES))
+	if err != nil {
+		return "", errors.New(fmt.Sprintf("error occurred while downloading '%s': %v", getRedactedURI(parsedURI),
+			err))
+	}
+	return file, nil
+}
+
+// Get the given artifact URI without the credentials in it so that it can be printed.
+func RedactArtifactURI(uri string) string {
+	if !IsRemoteArtifact(uri) {
+		return uri
+	}
+	parsedURI, err := url.Parse(uri)
+	if err != nil {
+		return uri
+	}
+	return getRedactedURI(parsedURI)
+}
+
+// Get the supported URI schemes, sorted by the name.
+func getStorageSchemes() []string {
+	var schemes []string
+	for scheme := range storageBackends {
+		if len(scheme) != 0 {
+			schemes = append(schemes, scheme)
+		}
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// Get the given URI without the credentials in its user info and query so that it can be printed.
+func getRedactedURI(uri *url.URL) string {
+	redactedURI := *uri
+	if redactedURI.User != nil {
+		redactedURI.User = url.User(redactedURI.User.Username())
+	}
+	redactedURI.RawQuery = ""
+	return redactedURI.String()
+}
+
+// Append the query of the given URI (eg: signature of a presigned URL) to the given URL.
+func appendQuery(location string, uri *url.URL) string {
+	if len(uri.RawQuery) == 0 {
+		return location
+	}
+	return location + "?" + uri.RawQuery
+}
+
+// Resolve http(s)://<host>/<path> URIs. They are used as they are.
+func resolveHTTPURL(uri *url.URL) (string, error) {
+	return uri.String(), nil
+}
+
+// Resolve s3://<bucket>/<key> URIs to the HTTPS endpoint of the bucket.
+func resolveS3URL(uri *url.URL) (string, error) {
+	if len(uri.Host) == 0 {
+		return "", errors.New(fmt.Sprintf("bucket name not found in '%s'", getRedactedURI(uri)))
+	}
+	return appendQuery(fmt.Sprintf(constant.S3_BUCKET_URL_FORMAT, uri.Host, strings.TrimPrefix(uri.Path, "/")),
+		uri), nil
+}
+
+// Resolve gs://<bucket>/<object> URIs to the HTTPS endpoint of Google Cloud Storage.
+func resolveGSURL(uri *url.URL) (string, error) {
+	if len(uri.Host) == 0 {
+		return "", errors.New(fmt.Sprintf("bucket name not found in '%s'", getRedactedURI(uri)))
+	}
+	return appendQuery(fmt.Sprintf(constant.GS_BUCKET_URL_FORMAT, uri.Host, strings.TrimPrefix(uri.Path, "/")),
+		uri), nil
+}
+
+// Resolve azblob://<account>/<container>/<blob> URIs to the HTTPS endpoint of the storage account. The SAS token in
+// the AZURE_STORAGE_SAS_TOKEN environment variable is used if the URI does not have a query.
+func resolveAzureBlobURL(uri *url.URL) (string, error) {
+	parts := strings.SplitN(strings.TrimPrefix(uri.Path, "/"), "/", 2)
+	if len(uri.Host) == 0 || len(parts[0]) == 0 {
+		return "", errors.New(fmt.Sprintf("storage account or container not found in '%s'", getRedactedURI(uri)))
+	}
+	location := fmt.Sprintf(constant.AZBLOB_URL_FORMAT, uri.Host, strings.TrimPrefix(uri.Path, "/"))
+	if sasToken := os.Getenv(constant.AZURE_STORAGE_SAS_TOKEN); len(uri.RawQuery) == 0 && len(sasToken) != 0 {
+		return location + "?" + strings.TrimPrefix(sasToken, "?"), nil
+	}
+	return appendQuery(location, uri), nil
+}
+
+// Resolve artifactory://[<user>:<token>@]<host>/<repository>/<path> URIs to the HTTPS endpoint of the Artifactory
+// repository. The user info is used for basic authentication.
+func resolveArtifactoryURL(uri *url.URL) (string, error) {
+	if len(uri.Host) == 0 || len(strings.Trim(uri.Path, "/")) == 0 {
+		return "", errors.New(fmt.Sprintf("host or repository not found in '%s'", getRedactedURI(uri)))
+	}
+	location := url.URL{
+		Scheme:   constant.STORAGE_SCHEME_HTTPS,
+		User:     uri.User,
+		Host:     uri.Host,
+		Path:     constant.ARTIFACTORY_CONTEXT + uri.Path,
+		RawQuery: uri.RawQuery,
+	}
+	return location.String(), nil
+}
@@ -0,0 +1,121 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+package util
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Check whether the update of the given catalog entry can supersede the update of the given superseded entry. The
+// superseded update should be a prior update of the same platform and all the files changed by it should be changed
+// by the superseding update as well. The files which are not covered by the superseding update are returned.
+func CheckSupersession(entry, supersededEntry *CatalogEntry) ([]string, error) {
+	if entry.UpdateName == supersededEntry.UpdateName {
+		return nil, errors.New(fmt.Sprintf("'%s' cannot supersede itself", entry.UpdateName))
+	}
+	if entry.PlatformName != supersededEntry.PlatformName ||
+		entry.PlatformVersion != supersededEntry.PlatformVersion {
+		return nil, errors.New(fmt.Sprintf("'%s' (%s-%s) and '%s' (%s-%s) are not updates of the same platform",
+			entry.UpdateName, entry.PlatformName, entry.PlatformVersion, supersededEntry.UpdateName,
+			supersededEntry.PlatformName, supersededEntry.PlatformVersion))
+	}
+	if compareUpdateNumbers(supersededEntry.UpdateNumber, entry.UpdateNumber) >= 0 {
+		return nil, errors.New(fmt.Sprintf("'%s' is not a prior update of '%s'", supersededEntry.UpdateName,
+			entry.UpdateName))
+	}
+	files := make(map[string]bool)
+	for _, file := range entry.Files {
+		files[file] = true
+	}
+	var uncoveredFiles []string
+	for _, file := range supersededEntry.Files {
+		if !files[file] {
+			uncoveredFiles = append(uncoveredFiles, file)
+		}
+	}
+	return uncoveredFiles, nil
+}
+
+// Add the given updates to the superseded updates of the given update descriptor. Updates which are already
+// superseded are not added again.
+func AddSupersededUpdates(updateDescriptorV3 *UpdateDescriptorV3, updateNames []string) {
+	isSuperseded := make(map[string]bool)
+	for _, updateName := range updateDescriptorV3.Supersedes {
+		isSuperseded[updateName] = true
+	}
+	for _, updateName := range updateNames {
+		if !isSuperseded[updateName] {
+			isSuperseded[updateName] = true
+			updateDescriptorV3.Supersedes = append(updateDescriptorV3.Supersedes, updateName)
+		}
+	}
+}
+
+// Write the entries of the given zip to the destination zip while replacing the content of the entry with the given
+// name with the given data.
+func ReplaceZipEntry(source, destination, entryName string, data []byte) error {
+	zipReader, err := zip.OpenReader(source)
+	if err != nil {
+		return err
+	}
+	defer zipReader.Close()
+	destinationFile, err := os.Create(destination)
+	if err != nil {
+		return err
+	}
+	defer destinationFile.Close()
+	zipWriter := zip.NewWriter(destinationFile)
+
+	isReplaced := false
+	for _, file := range zipReader.Reader.File {
+		header := file.FileHeader
+		writer, err := zipWriter.CreateHeader(&header)
+		if err != nil {
+			return err
+		}
+		if file.Name == entryName {
+			logger.Trace(fmt.Sprintf("Replacing %s", file.Name))
+			isReplaced = true
+			if _, err = writer.Write(data); err != nil {
+				return err
+			}
+			continue
+		}
+		if file.FileInfo().IsDir() {
+			continue
+		}
+		zippedFile, err := file.Open()
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(writer, zippedFile)
+		zippedFile.Close()
+		if err != nil {
+			return err
+		}
+	}
+	if !isReplaced {
+		return errors.New(fmt.Sprintf("'%s' not found in '%s'", entryName, source))
+	}
+	return zipWriter.Close()
+}
@@ -0,0 +1,79 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/wso2/update-creator-tool/constant"
+)
+
+// Downloads the file at the given url to the given location. The file is downloaded to a partial file first, and an
+// interrupted download is resumed from the partial file if the server supports range requests.
+func DownloadFileWithResume(file, url string) error {
+	partialFile := file + constant.PARTIAL_FILE_EXTENSION
+	var offset int64
+	if fileInfo, err := os.Stat(partialFile); err == nil {
+		offset = fileInfo.Size()
+	}
+
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	if offset > 0 {
+		request.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return NewNetworkError(err)
+	}
+	defer response.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY
+	switch response.StatusCode {
+	case http.StatusPartialContent:
+		logger.Debug(fmt.Sprintf("Resuming the download of %s from %d bytes", url, offset))
+		flags |= os.O_APPEND
+	case http.StatusOK:
+		// Server does not support range requests or there is nothing to resume
+		flags |= os.O_TRUNC
+	case http.StatusRequestedRangeNotSatisfiable:
+		// Partial file is already complete or is invalid. It is downloaded again if the checksum does not match
+		return os.Rename(partialFile, file)
+	default:
+		return NewNetworkError(errors.New(fmt.Sprintf("Could not download the file from: %s (%s)", url,
+			response.Status)))
+	}
+	out, err := os.OpenFile(partialFile, flags, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, response.Body)
+	out.Close()
+	if err != nil {
+		return err
+	}
+	return os.Rename(partialFile, file)
+}
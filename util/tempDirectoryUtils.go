@@ -0,0 +1,51 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Temporary directories which should not outlive the tool (eg: directories containing decrypted updates). They are
+// deleted when the tool exits, including the exits on errors which skip the deferred clean ups.
+var temporaryDirectories = struct {
+	sync.Mutex
+	paths []string
+}{}
+
+// Register the given temporary directory to be deleted when the tool exits.
+func RegisterTemporaryDirectory(path string) {
+	temporaryDirectories.Lock()
+	defer temporaryDirectories.Unlock()
+	logger.Debug(fmt.Sprintf("Registered temporary directory %s to be deleted on exit", path))
+	temporaryDirectories.paths = append(temporaryDirectories.paths, path)
+}
+
+// Delete the registered temporary directories. Directories which are already deleted are skipped.
+func CleanUpTemporaryDirectories() {
+	temporaryDirectories.Lock()
+	paths := temporaryDirectories.paths
+	temporaryDirectories.paths = nil
+	temporaryDirectories.Unlock()
+	for _, path := range paths {
+		CleanUpDirectory(path)
+	}
+}
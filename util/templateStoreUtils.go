@@ -0,0 +1,211 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/wso2/update-creator-tool/constant"
+	"gopkg.in/yaml.v2"
+)
+
+// struct which is used to read the template store synced from the Git repository of the team
+type TemplateStore struct {
+	// Update descriptor of which the values are used instead of the defaults in the generated
+	// update-descriptor3.yaml. Nil if the store does not have a descriptor template.
+	DescriptorTemplate *UpdateDescriptorV3
+	// Locations of the rego policies evaluated in addition to the configured policies
+	Policies []string
+	// Destinations of the new files which are not found in the distribution
+	Destinations []DestinationMapping
+}
+
+// struct which is used to map the new files to their destination directories relative to the PRODUCT_HOME
+type DestinationMapping struct {
+	// Glob pattern matched against the name of the file (eg: *.war)
+	Pattern     string `yaml:"pattern"`
+	Destination string `yaml:"destination"`
+}
+
+// struct which is used to read the destinations.yaml file of the template store
+type destinationMappings struct {
+	Destinations []DestinationMapping `yaml:"destinations"`
+}
+
+// Sync the template store in the given directory with the given branch of the given Git repository. The repository
+// is cloned if the directory is not a clone of it, otherwise the branch is fetched and the local changes are
+// discarded so that all the members of the team use the same templates. The synced commit is returned.
+func SyncTemplateStore(repository, branch, directory string) (string, error) {
+	isCloned, err := IsDirectoryExists(filepath.Join(directory, constant.GIT_DIRECTORY))
+	if err != nil {
+		return "", err
+	}
+	if isCloned {
+		remote, err := runGitCommand(directory, "remote", "get-url", "origin")
+		if err != nil || remote != repository {
+			logger.Debug(fmt.Sprintf("Template store in %s is not a clone of %s. Cloning again", directory,
+				RedactArtifactURI(repository)))
+			isCloned = false
+		}
+	}
+	if isCloned {
+		if _, err = runGitCommand(directory, "fetch", "--quiet", "--depth", "1", "origin", branch); err != nil {
+			return "", NewNetworkError(err)
+		}
+		if _, err = runGitCommand(directory, "reset", "--quiet", "--hard", "FETCH_HEAD"); err != nil {
+			return "", err
+		}
+		if _, err = runGitCommand(directory, "clean", "--quiet", "-d", "--force"); err != nil {
+			return "", err
+		}
+	} else {
+		if err = DeleteDirectory(directory); err != nil {
+			return "", err
+		}
+		if err = CreateDirectory(filepath.Dir(directory)); err != nil {
+			return "", err
+		}
+		_, err = runGitCommand("", "clone", "--quiet", "--depth", "1", "--branch", branch, repository, directory)
+		if err != nil {
+			return "", NewNetworkError(err)
+		}
+	}
+	return runGitCommand(directory, "rev-parse", "HEAD")
+}
+
+// Read the template store in the given directory. An empty store is returned if the store is not synced yet.
+func LoadTemplateStore(directory string) (*TemplateStore, error) {
+	templateStore := TemplateStore{}
+	descriptorTemplatePath := filepath.Join(directory, constant.TEMPLATE_STORE_DESCRIPTOR_TEMPLATE)
+	if exists, err := IsFileExists(descriptorTemplatePath); err != nil {
+		return nil, err
+	} else if exists {
+		data, err := ioutil.ReadFile(descriptorTemplatePath)
+		if err != nil {
+			return nil, err
+		}
+		templateStore.DescriptorTemplate = &UpdateDescriptorV3{}
+		if err = yaml.Unmarshal(data, templateStore.DescriptorTemplate); err != nil {
+			return nil, errors.New(fmt.Sprintf("invalid descriptor template '%s': %v", descriptorTemplatePath, err))
+		}
+	}
+
+	policiesDirectory := filepath.Join(directory, constant.TEMPLATE_STORE_POLICIES_DIRECTORY)
+	if exists, err := IsDirectoryExists(policiesDirectory); err != nil {
+		return nil, err
+	} else if exists {
+		fileInfos, err := ioutil.ReadDir(policiesDirectory)
+		if err != nil {
+			return nil, err
+		}
+		for _, fileInfo := range fileInfos {
+			if !fileInfo.IsDir() && filepath.Ext(fileInfo.Name()) == constant.TEMPLATE_STORE_POLICY_FILE_EXTENSION {
+				templateStore.Policies = append(templateStore.Policies, filepath.Join(policiesDirectory,
+					fileInfo.Name()))
+			}
+		}
+		sort.Strings(templateStore.Policies)
+	}
+
+	destinationsPath := filepath.Join(directory, constant.TEMPLATE_STORE_DESTINATIONS_FILE)
+	if exists, err := IsFileExists(destinationsPath); err != nil {
+		return nil, err
+	} else if exists {
+		data, err := ioutil.ReadFile(destinationsPath)
+		if err != nil {
+			return nil, err
+		}
+		mappings := destinationMappings{}
+		if err = yaml.Unmarshal(data, &mappings); err != nil {
+			return nil, errors.New(fmt.Sprintf("invalid destination mappings '%s': %v", destinationsPath, err))
+		}
+		for _, mapping := range mappings.Destinations {
+			if err = validateDestinationMapping(&mapping); err != nil {
+				return nil, errors.New(fmt.Sprintf("invalid destination mappings '%s': %v", destinationsPath, err))
+			}
+		}
+		templateStore.Destinations = mappings.Destinations
+	}
+	return &templateStore, nil
+}
+
+// Get the destination directory of the new file with the given name from the first destination mapping which
+// matches it.
+func (templateStore *TemplateStore) GetDestination(filename string) (string, bool) {
+	for _, mapping := range templateStore.Destinations {
+		if matched, _ := path.Match(mapping.Pattern, filename); matched {
+			return strings.Trim(path.Clean(mapping.Destination), "/"), true
+		}
+	}
+	return "", false
+}
+
+// Check whether the given destination mapping has a valid pattern and a destination inside the PRODUCT_HOME.
+func validateDestinationMapping(mapping *DestinationMapping) error {
+	if len(mapping.Pattern) == 0 {
+		return errors.New(fmt.Sprintf("pattern of the destination '%s' is empty", mapping.Destination))
+	}
+	if _, err := path.Match(mapping.Pattern, ""); err != nil {
+		return errors.New(fmt.Sprintf("invalid pattern '%s': %v", mapping.Pattern, err))
+	}
+	// Destinations are relative to the PRODUCT_HOME even if they start with '/', as in the prompt of the destination
+	if !IsPathInRoot(".", strings.Trim(mapping.Destination, "/")) {
+		return errors.New(fmt.Sprintf("destination '%s' of '%s' is outside of PRODUCT_HOME", mapping.Destination,
+			mapping.Pattern))
+	}
+	return nil
+}
+
+// Run the git command with the given arguments in the given directory and get its output. The output of the command
+// is returned as the error if it fails.
+func runGitCommand(directory string, args ...string) (string, error) {
+	if len(directory) != 0 {
+		args = append([]string{"-C", directory}, args...)
+	}
+	var output bytes.Buffer
+	command := exec.Command(constant.GIT_COMMAND, args...)
+	command.Stdout = &output
+	command.Stderr = &output
+	// Git should fail instead of prompting for the credentials
+	command.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	// Credentials in the repository URL are not logged
+	var redactedArgs []string
+	for _, arg := range args {
+		redactedArgs = append(redactedArgs, RedactArtifactURI(arg))
+	}
+	logger.Debug(fmt.Sprintf("Running: %s %s", constant.GIT_COMMAND, strings.Join(redactedArgs, " ")))
+	if err := command.Run(); err != nil {
+		message := strings.TrimSpace(output.String())
+		if len(message) == 0 {
+			return "", err
+		}
+		return "", errors.New(fmt.Sprintf("%v: %s", err, message))
+	}
+	return strings.TrimSpace(output.String()), nil
+}
@@ -0,0 +1,102 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/wso2/update-creator-tool/constant"
+)
+
+// struct which is used to declare a payload file of which the placeholders (eg: ${product.home}) are substituted
+// when the update is applied.
+type TemplatedFile struct {
+	// Path of the file relative to carbon.home
+	Path string `yaml:"path"`
+	// Placeholders in the file against the values they are substituted with. Values can refer to the variables
+	// given when applying the update, eg: ${carbon.home}/repository/logs
+	Substitutions map[string]string `yaml:"substitutions"`
+}
+
+// Get the names of the placeholders in the given content in the order they appear.
+func FindPlaceholders(data []byte) []string {
+	var placeholders []string
+	for _, match := range regexp.MustCompile(constant.PLACEHOLDER_REGEX).FindAllSubmatch(data, -1) {
+		if !IsStringIsInSlice(string(match[1]), placeholders) {
+			placeholders = append(placeholders, string(match[1]))
+		}
+	}
+	return placeholders
+}
+
+// Check whether the substitution rules of the given templated file match its content. Each rule should be used in
+// the content. Placeholders in the content without rules are not substituted as they may be resolved by the product
+// itself (eg: ${carbon.home} in config files).
+func ValidateTemplatedFile(data []byte, templatedFile *TemplatedFile) error {
+	if len(templatedFile.Substitutions) == 0 {
+		return errors.New(fmt.Sprintf("no substitutions declared for the templated file '%s'", templatedFile.Path))
+	}
+	placeholders := FindPlaceholders(data)
+	var unusedPlaceholders []string
+	for placeholder := range templatedFile.Substitutions {
+		if !IsStringIsInSlice(placeholder, placeholders) {
+			unusedPlaceholders = append(unusedPlaceholders, placeholder)
+		}
+	}
+	if len(unusedPlaceholders) != 0 {
+		sort.Strings(unusedPlaceholders)
+		return errors.New(fmt.Sprintf("placeholders %s are not found in the templated file '%s'",
+			strings.Join(unusedPlaceholders, ", "), templatedFile.Path))
+	}
+	return nil
+}
+
+// Substitute the placeholders of the given templated file content using its substitution rules. The variables
+// referred in the substituted values are resolved using the given variables.
+func SubstitutePlaceholders(data []byte, templatedFile *TemplatedFile, variables map[string]string) ([]byte, error) {
+	placeholderRegex := regexp.MustCompile(constant.PLACEHOLDER_REGEX)
+	substitutions := make(map[string]string)
+	for placeholder, value := range templatedFile.Substitutions {
+		var unresolvedVariables []string
+		substitutions[placeholder] = placeholderRegex.ReplaceAllStringFunc(value, func(reference string) string {
+			variable := placeholderRegex.FindStringSubmatch(reference)[1]
+			resolvedValue, found := variables[variable]
+			if !found {
+				unresolvedVariables = append(unresolvedVariables, variable)
+			}
+			return resolvedValue
+		})
+		if len(unresolvedVariables) != 0 {
+			return nil, errors.New(fmt.Sprintf("variables %s of the placeholder '%s' in '%s' are not given",
+				strings.Join(unresolvedVariables, ", "), placeholder, templatedFile.Path))
+		}
+	}
+	return placeholderRegex.ReplaceAllFunc(data, func(reference []byte) []byte {
+		value, found := substitutions[string(placeholderRegex.FindSubmatch(reference)[1])]
+		if !found {
+			return reference
+		}
+		return []byte(value)
+	}), nil
+}
@@ -0,0 +1,359 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/wso2/update-creator-tool/constant"
+	"gopkg.in/yaml.v2"
+)
+
+// struct which is used to read and write the update number ledger. The ledger keeps track of the update numbers used
+// in updates created by wum-uc in the current machine.
+type UpdateNumberLedger struct {
+	Entries []UpdateNumberLedgerEntry `yaml:"entries"`
+}
+
+type UpdateNumberLedgerEntry struct {
+	UpdateNumber          string `yaml:"update-number"`
+	PlatformVersion       string `yaml:"platform-version"`
+	UpdateName            string `yaml:"update-name"`
+	ResourceDirectoryPath string `yaml:"resource-directory-path"`
+	CreatedAt             string `yaml:"created-at"`
+}
+
+// struct which is used to read the update numbers reserved in WUM and to record them in the wum-uc home directory
+// until they are consumed by an update.
+type UpdateNumberReservation struct {
+	UpdateNumber    string `yaml:"update-number" json:"update-no"`
+	PlatformVersion string `yaml:"platform-version" json:"platform-version"`
+	ReservedBy      string `yaml:"reserved-by" json:"reserved-by"`
+	ReservedAt      string `yaml:"reserved-at" json:"reserved-at"`
+	ExpiresAt       string `yaml:"expires-at" json:"expires-at"`
+	// Update directory of the update which consumed the reservation
+	ConsumedBy string `yaml:"consumed-by,omitempty" json:"-"`
+}
+
+type UpdateNumberReservations struct {
+	Reservations []UpdateNumberReservation `yaml:"reservations"`
+}
+
+// struct which is used to request a reservation of the next update number of a platform version from WUM
+type updateNumberReservationRequest struct {
+	PlatformVersion string `json:"platform-version"`
+	ReservedBy      string `json:"reserved-by"`
+}
+
+// Validate the format of the given update number against the configured pattern and range.
+func ValidateUpdateNumberFormat(updateNumber string) error {
+	pattern := viper.GetString(constant.UPDATE_NUMBER_PATTERN)
+	if len(pattern) == 0 {
+		pattern = constant.UPDATE_NUMBER_REGEX
+	}
+	matches, err := regexp.MatchString(pattern, updateNumber)
+	if err != nil {
+		return err
+	}
+	if !matches {
+		return errors.New(fmt.Sprintf("'update_number' is not valid. It should match '%s'.", pattern))
+	}
+	number, err := strconv.Atoi(updateNumber)
+	if err != nil {
+		// Range can only be checked for numeric update numbers
+		logger.Debug(fmt.Sprintf("Update number '%s' is not numeric. Skipping range check.", updateNumber))
+		return nil
+	}
+	min := viper.GetInt(constant.UPDATE_NUMBER_MIN)
+	max := viper.GetInt(constant.UPDATE_NUMBER_MAX)
+	if number < min || (max > 0 && number > max) {
+		return errors.New(fmt.Sprintf("'update_number' is not valid. It should be within %d and %d.", min, max))
+	}
+	return nil
+}
+
+// Check whether the given update number has already been used for the given platform version. The check is done
+// against the local ledger or the WUM API depending on the configuration. Recreating an update from the same resource
+// directory is not considered as a reuse of the update number.
+func CheckUpdateNumberUniqueness(updateNumber, platformVersion, resourceDirectoryPath, wumucHome string) error {
+	uniquenessCheck := viper.GetString(constant.UPDATE_NUMBER_UNIQUENESS_CHECK)
+	logger.Debug(fmt.Sprintf("Update number uniqueness check: '%s'", uniquenessCheck))
+	switch uniquenessCheck {
+	case "":
+		return nil
+	case constant.UNIQUENESS_CHECK_LEDGER:
+		ledger, err := LoadUpdateNumberLedger(wumucHome)
+		if err != nil {
+			return err
+		}
+		for _, entry := range ledger.Entries {
+			if entry.UpdateNumber == updateNumber && entry.PlatformVersion == platformVersion &&
+				entry.ResourceDirectoryPath != resourceDirectoryPath {
+				return errors.New(fmt.Sprintf("update number '%s' has already been used for platform version "+
+					"'%s' by '%s' created from '%s'.", updateNumber, platformVersion, entry.UpdateName,
+					entry.ResourceDirectoryPath))
+			}
+		}
+		return nil
+	case constant.UNIQUENESS_CHECK_API:
+		return checkUpdateNumberUniquenessWithWUM(updateNumber, platformVersion)
+	default:
+		return errors.New(fmt.Sprintf("invalid value '%s' for %s. Supported values are '%s' and '%s'.",
+			uniquenessCheck, constant.UPDATE_NUMBER_UNIQUENESS_CHECK, constant.UNIQUENESS_CHECK_LEDGER,
+			constant.UNIQUENESS_CHECK_API))
+	}
+}
+
+// Check whether the given update number is already available in WUM for the given platform version.
+func checkUpdateNumberUniquenessWithWUM(updateNumber, platformVersion string) error {
+	apiURL := GetWUMUCConfigs().ServerURL + "/" + constant.UPDATES_API_CONTEXT + "/" + constant.
+		FILES_API_VERSION + "/" + platformVersion + "/" + updateNumber
+	request, err := http.NewRequest(http.MethodGet, apiURL, nil)
+	if err != nil {
+		return err
+	}
+	request.Header.Add(constant.HEADER_AUTHORIZATION, "Bearer "+GetWUMUCConfigs().AccessToken)
+	request.Header.Add(constant.HEADER_ACCEPT, constant.HEADER_VALUE_APPLICATION_JSON)
+	// Not found responses are expected here, so the response is handled without handleErrorResponses()
+	response := SendRequest(request, time.Duration(constant.WUMUC_API_CALL_TIMEOUT*time.Minute))
+	defer response.Body.Close()
+	logger.Debug(fmt.Sprintf("Update number check response status code: %d", response.StatusCode))
+	switch response.StatusCode {
+	case http.StatusOK:
+		return errors.New(fmt.Sprintf("update number '%s' has already been used for platform version '%s' in "+
+			"WUM.", updateNumber, platformVersion))
+	case http.StatusNotFound:
+		return nil
+	case http.StatusUnauthorized, http.StatusBadRequest:
+		return errors.New(constant.INVALID_EXPIRED_REFRESH_TOKEN_MSG + ", " + constant.RUN_WUMUC_INIT_TO_CONTINUE_MSG)
+	default:
+		return NewNetworkError(errors.New(constant.UNABLE_TO_CONNECT_WUM_SERVERS))
+	}
+}
+
+// Load the update number ledger from the wum-uc home directory. An empty ledger is returned if the ledger does not
+// exist.
+func LoadUpdateNumberLedger(wumucHome string) (*UpdateNumberLedger, error) {
+	ledger := UpdateNumberLedger{}
+	ledgerFilePath := filepath.Join(wumucHome, constant.WUMUC_UPDATE_NUMBER_LEDGER_FILE)
+	exists, err := IsFileExists(ledgerFilePath)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		logger.Debug(fmt.Sprintf("%s not found", ledgerFilePath))
+		return &ledger, nil
+	}
+	data, err := ioutil.ReadFile(ledgerFilePath)
+	if err != nil {
+		return nil, err
+	}
+	err = yaml.Unmarshal(data, &ledger)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("unable to read the update number ledger '%s': %v", ledgerFilePath, err))
+	}
+	return &ledger, nil
+}
+
+// Record the given entry in the update number ledger in the wum-uc home directory. If an entry already exists for
+// the same update created from the same resource directory, it will be replaced.
+func AddToUpdateNumberLedger(wumucHome string, newEntry UpdateNumberLedgerEntry) error {
+	ledger, err := LoadUpdateNumberLedger(wumucHome)
+	if err != nil {
+		return err
+	}
+	newEntry.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+	entries := []UpdateNumberLedgerEntry{}
+	for _, entry := range ledger.Entries {
+		if entry.UpdateName == newEntry.UpdateName && entry.ResourceDirectoryPath == newEntry.ResourceDirectoryPath {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	ledger.Entries = append(entries, newEntry)
+	data, err := yaml.Marshal(ledger)
+	if err != nil {
+		return err
+	}
+	return WriteFileToDestination(data, filepath.Join(wumucHome, constant.WUMUC_UPDATE_NUMBER_LEDGER_FILE))
+}
+
+// Reserve the next update number of the given platform version in WUM. Reservations are valid for the given duration
+// unless WUM gives their expiry.
+func ReserveUpdateNumber(serverURL, accessToken, platformVersion, reservedBy string, validity time.Duration,
+	now time.Time) (*UpdateNumberReservation, error) {
+	requestBody := new(bytes.Buffer)
+	err := json.NewEncoder(requestBody).Encode(updateNumberReservationRequest{PlatformVersion: platformVersion,
+		ReservedBy: reservedBy})
+	if err != nil {
+		return nil, err
+	}
+	apiURL := serverURL + "/" + constant.UPDATES_API_CONTEXT + "/" + constant.FILES_API_VERSION + "/" +
+		platformVersion + "/" + constant.UPDATE_NUMBER_RESERVATIONS_API
+	request, err := http.NewRequest(http.MethodPost, apiURL, requestBody)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Add(constant.HEADER_AUTHORIZATION, "Bearer "+accessToken)
+	request.Header.Add(constant.HEADER_CONTENT_TYPE, constant.HEADER_VALUE_APPLICATION_JSON)
+	request.Header.Add(constant.HEADER_ACCEPT, constant.HEADER_VALUE_APPLICATION_JSON)
+	response := SendRequest(request, time.Duration(constant.WUMUC_API_CALL_TIMEOUT*time.Minute))
+	defer response.Body.Close()
+	logger.Debug(fmt.Sprintf("Update number reservation response status code: %d", response.StatusCode))
+	switch response.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+		reservation := UpdateNumberReservation{}
+		if err = json.NewDecoder(response.Body).Decode(&reservation); err != nil {
+			return nil, errors.New(constant.ERROR_READING_RESPONSE_MSG + ": " + err.Error())
+		}
+		if len(reservation.UpdateNumber) == 0 {
+			return nil, errors.New(fmt.Sprintf("WUM did not reserve an update number for platform version '%s'.",
+				platformVersion))
+		}
+		if err = ValidateUpdateNumberFormat(reservation.UpdateNumber); err != nil {
+			return nil, errors.New(fmt.Sprintf("update number '%s' reserved in WUM is invalid. %v",
+				reservation.UpdateNumber, err))
+		}
+		reservation.PlatformVersion = platformVersion
+		if len(reservation.ReservedBy) == 0 {
+			reservation.ReservedBy = reservedBy
+		}
+		if len(reservation.ReservedAt) == 0 {
+			reservation.ReservedAt = now.UTC().Format(time.RFC3339)
+		}
+		if len(reservation.ExpiresAt) == 0 {
+			reservation.ExpiresAt = now.Add(validity).UTC().Format(time.RFC3339)
+		}
+		return &reservation, nil
+	case http.StatusNotFound:
+		return nil, errors.New(fmt.Sprintf("platform version '%s' is not found in WUM.", platformVersion))
+	case http.StatusUnauthorized, http.StatusBadRequest:
+		return nil, errors.New(constant.INVALID_EXPIRED_REFRESH_TOKEN_MSG + ", " +
+			constant.RUN_WUMUC_INIT_TO_CONTINUE_MSG)
+	default:
+		return nil, errors.New(constant.UNABLE_TO_CONNECT_WUM_SERVERS)
+	}
+}
+
+// Check whether the reservation has expired at the given time. Reservations of which the expiry cannot be read are
+// considered as expired.
+func (reservation *UpdateNumberReservation) IsExpired(now time.Time) bool {
+	expiresAt, err := time.Parse(time.RFC3339, reservation.ExpiresAt)
+	return err != nil || !now.Before(expiresAt)
+}
+
+// Load the update number reservations from the wum-uc home directory. No reservations are returned if the
+// reservations file does not exist.
+func LoadUpdateNumberReservations(wumucHome string) (*UpdateNumberReservations, error) {
+	reservations := UpdateNumberReservations{}
+	reservationsFilePath := filepath.Join(wumucHome, constant.WUMUC_UPDATE_NUMBER_RESERVATIONS_FILE)
+	exists, err := IsFileExists(reservationsFilePath)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		logger.Debug(fmt.Sprintf("%s not found", reservationsFilePath))
+		return &reservations, nil
+	}
+	data, err := ioutil.ReadFile(reservationsFilePath)
+	if err != nil {
+		return nil, err
+	}
+	if err = yaml.Unmarshal(data, &reservations); err != nil {
+		return nil, errors.New(fmt.Sprintf("unable to read the update number reservations '%s': %v",
+			reservationsFilePath, err))
+	}
+	return &reservations, nil
+}
+
+// Save the given update number reservations in the wum-uc home directory. Reservations which have expired at the given
+// time are removed.
+func saveUpdateNumberReservations(wumucHome string, reservations *UpdateNumberReservations, now time.Time) error {
+	validReservations := []UpdateNumberReservation{}
+	for _, reservation := range reservations.Reservations {
+		if reservation.IsExpired(now) {
+			logger.Debug(fmt.Sprintf("Reservation of %s-%s expired at %s", reservation.PlatformVersion,
+				reservation.UpdateNumber, reservation.ExpiresAt))
+			continue
+		}
+		validReservations = append(validReservations, reservation)
+	}
+	reservations.Reservations = validReservations
+	data, err := yaml.Marshal(reservations)
+	if err != nil {
+		return err
+	}
+	return WriteFileToDestination(data, filepath.Join(wumucHome, constant.WUMUC_UPDATE_NUMBER_RESERVATIONS_FILE))
+}
+
+// Record the given update number reservation in the wum-uc home directory.
+func AddUpdateNumberReservation(wumucHome string, reservation *UpdateNumberReservation, now time.Time) error {
+	reservations, err := LoadUpdateNumberReservations(wumucHome)
+	if err != nil {
+		return err
+	}
+	reservations.Reservations = append(reservations.Reservations, *reservation)
+	return saveUpdateNumberReservations(wumucHome, reservations, now)
+}
+
+// Consume an update number reserved for the given platform version for the update in the given update directory. The
+// reservation already consumed by the update is returned when the update is recreated, otherwise the oldest
+// reservation which is not consumed is consumed. An error is returned if there is no valid reservation.
+func ConsumeUpdateNumberReservation(wumucHome, platformVersion, updateDirectoryPath string, now time.Time) (
+	*UpdateNumberReservation, error) {
+	reservations, err := LoadUpdateNumberReservations(wumucHome)
+	if err != nil {
+		return nil, err
+	}
+	index := -1
+	for i, reservation := range reservations.Reservations {
+		if reservation.PlatformVersion != platformVersion || reservation.IsExpired(now) {
+			continue
+		}
+		if reservation.ConsumedBy == updateDirectoryPath {
+			index = i
+			break
+		}
+		if len(reservation.ConsumedBy) == 0 && index == -1 {
+			index = i
+		}
+	}
+	if index == -1 {
+		return nil, errors.New(fmt.Sprintf("no valid update number reservation found for platform version '%s'. "+
+			"Run 'wum-uc reserve-number --platform %s' to reserve an update number.", platformVersion,
+			platformVersion))
+	}
+	reservations.Reservations[index].ConsumedBy = updateDirectoryPath
+	reservation := reservations.Reservations[index]
+	if err = saveUpdateNumberReservations(wumucHome, reservations, now); err != nil {
+		return nil, err
+	}
+	return &reservation, nil
+}
@@ -20,6 +20,7 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"hash/crc32"
 	"io"
 	"io/ioutil"
 	"net/http"
@@ -34,12 +35,12 @@ import (
 	"archive/zip"
 	"bytes"
 	"github.com/fatih/color"
+	"github.com/ian-kent/go-log/levels"
 	"github.com/ian-kent/go-log/log"
 	"github.com/pkg/errors"
+	"github.com/spf13/afero"
 	"github.com/spf13/viper"
 	"github.com/wso2/update-creator-tool/constant"
-	"golang.org/x/crypto/ssh/terminal"
-	"gopkg.in/yaml.v2"
 	"net/url"
 	"regexp"
 	"sort"
@@ -47,6 +48,17 @@ import (
 
 var logger = log.Logger()
 
+// Reader which is used to read user inputs. A single reader is used because buffered data would be lost otherwise when
+// the inputs are piped.
+var stdinReader = bufio.NewReader(os.Stdin)
+
+// File system which is used to read the update directory, copy the update files and the resource files to the temp
+// directory, save the partial update descriptor and create the update zip. This is the OS file system by default and
+// can be replaced with an in-memory file system (afero.NewMemMapFs()) to drive these steps in tests. The distribution,
+// the user inputs, SVN and the validations of the update zip still use the OS, so the create command as a whole cannot
+// be run in memory.
+var FileSystem = afero.NewOsFs()
+
 // struct which is used to read update-descriptor.yaml
 type UpdateDescriptorV2 struct {
 	UpdateNumber    string            `yaml:"update_number"`
@@ -73,14 +85,27 @@ type UpdateDescriptorV3 struct {
 	BugFixes                    map[string]string `yaml:"bug_fixes"`
 	CompatibleProducts          []ProductChanges  `yaml:"compatible_products"`
 	PartiallyApplicableProducts []ProductChanges  `yaml:"partially_applicable_products"`
+	BinaryDeltas                []BinaryDelta     `yaml:"binary_deltas,omitempty"`
+	Supersedes                  []string          `yaml:"supersedes,omitempty"`
+	TemplatedFiles              []TemplatedFile   `yaml:"templated_files,omitempty"`
+	FileOwnerships              []FileOwnership   `yaml:"file_ownerships,omitempty"`
+	OSVariants                  []OSVariant       `yaml:"os_variants,omitempty"`
+	SecurityAdvisory            string            `yaml:"security_advisory,omitempty"`
+	Preview                     *Preview          `yaml:"preview,omitempty"`
+	Scan                        *ScanVerdict      `yaml:"scan,omitempty"`
+	SecretWaivers               []SecretWaiver    `yaml:"secret_waivers,omitempty"`
+	PayloadDirectory            string            `yaml:"payload_directory,omitempty"`
+	ExecutableFiles             []string          `yaml:"executable_files,omitempty"`
+	ChecksumAlgorithm           string            `yaml:"checksum_algorithm,omitempty"`
 }
 
 type ProductChanges struct {
-	ProductName    string   `yaml:"product_name"`
-	ProductVersion string   `yaml:"product_version"`
-	AddedFiles     []string `yaml:"added_files"`
-	RemovedFiles   []string `yaml:"removed_files"`
-	ModifiedFiles  []string `yaml:"modified_files"`
+	ProductName    string   `yaml:"product_name" json:"product_name"`
+	ProductVersion string   `yaml:"product_version" json:"product_version"`
+	AddedFiles     []string `yaml:"added_files" json:"added_files"`
+	RemovedFiles   []string `yaml:"removed_files" json:"removed_files"`
+	ModifiedFiles  []string `yaml:"modified_files" json:"modified_files"`
+	PayloadRoot    string   `yaml:"payload_root,omitempty" json:"payload_root,omitempty"`
 }
 
 type PartialUpdateFileRequest struct {
@@ -156,7 +181,7 @@ type JiraResponse struct {
 // This will return the md5 hash of the file in the given filepath
 func GetMD5(filepath string) (string, error) {
 	var result []byte
-	file, err := os.Open(filepath)
+	file, err := FileSystem.Open(filepath)
 	if err != nil {
 		return "", err
 	}
@@ -169,6 +194,31 @@ func GetMD5(filepath string) (string, error) {
 	return hex.EncodeToString(hash.Sum(result)), nil
 }
 
+// This will return the hash of the given checksum algorithm (md5, sha256) of the file in the given filepath
+func GetHash(filepath, algorithm string) (string, error) {
+	file, err := FileSystem.Open(filepath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	return GetChecksumOfReader(file, algorithm)
+}
+
+// This will return the CRC32 (IEEE) checksum of the file in the given filepath
+func GetCRC32(filepath string) (uint32, error) {
+	file, err := FileSystem.Open(filepath)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	hash := crc32.NewIEEE()
+	if _, err := io.Copy(hash, file); err != nil {
+		return 0, err
+	}
+	return hash.Sum32(), nil
+}
+
 // This function is used to delete the temporary directories
 func CleanUpDirectory(path string) {
 	logger.Debug(fmt.Sprintf("Deleting temporary files: %s", path))
@@ -193,12 +243,16 @@ func CleanUpDirectory(path string) {
 
 // This function is used to delete files.
 func CleanUpFile(path string) {
+	if err := CheckWritable(path); err != nil {
+		logger.Debug(fmt.Sprintf("Not deleting file %s: %v", path, err))
+		return
+	}
 	logger.Debug(fmt.Sprintf("Deleting file %s", path))
-	err := os.RemoveAll(path)
+	err := os.RemoveAll(GetLongPath(path))
 	if err != nil {
 		logger.Debug(fmt.Sprintf("Error occurred while deleting '%s' file: %v", path, err))
 		time.Sleep(time.Second * 1)
-		err = os.RemoveAll(path)
+		err = os.RemoveAll(GetLongPath(path))
 		if err != nil {
 			logger.Debug(fmt.Sprintf("Retry failed: %v", err))
 			PrintInfo(fmt.Sprintf("Deleting '%s' failed. Please delete this file manually.",
@@ -219,25 +273,33 @@ func HandleInterrupts(cleanupFunc func()) chan<- os.Signal {
 		<-c
 		PrintInfo("Keyboard interrupt received.")
 		cleanupFunc()
-		os.Exit(1)
+		CleanUpTemporaryDirectories()
+		PrintWarningSummary()
+		PrintStageTimings()
+		os.Exit(constant.EXIT_CODE_USER_ABORT)
 	}()
 	return c
 }
 
 // This function will create all directories in the given path if they do not exist
 func CreateDirectory(path string) error {
-	return os.MkdirAll(path, 0700)
+	if err := CheckWritable(path); err != nil {
+		return err
+	}
+	return FileSystem.MkdirAll(GetLongPath(path), 0700)
 }
 
 // This function will delete all directories in the given path
 func DeleteDirectory(path string) error {
-	return os.RemoveAll(path)
+	return FileSystem.RemoveAll(GetLongPath(path))
 }
 
 // This function will get user input
 func GetUserInput() (string, error) {
-	reader := bufio.NewReader(os.Stdin)
-	userInput, err := reader.ReadString('\n')
+	if IsJSONIOEnabled() {
+		return PromptUser("")
+	}
+	userInput, err := stdinReader.ReadString('\n')
 	if err != nil {
 		return "", err
 	}
@@ -292,7 +354,7 @@ func LoadUpdateDescriptor(filename, updateDirectoryPath string) (*UpdateDescript
 		return nil, err
 	}
 	//Un-marshal the update-descriptor file to updateDescriptor struct
-	err = yaml.Unmarshal(yamlFile, &updateDescriptor)
+	err = UnmarshalUpdateDescriptor(yamlFile, updateDescriptorPath, &updateDescriptor)
 	if err != nil {
 		return nil, err
 	}
@@ -305,18 +367,14 @@ func ValidateBasicDetailsOfUpdateDescriptorV2(updateDescriptorV2 *UpdateDescript
 	if len(updateDescriptorV2.UpdateNumber) == 0 {
 		return errors.New("'update_number' field not found.")
 	}
-	matches, err := regexp.MatchString(constant.UPDATE_NUMBER_REGEX, updateDescriptorV2.UpdateNumber)
+	err := ValidateUpdateNumberFormat(updateDescriptorV2.UpdateNumber)
 	if err != nil {
 		return err
 	}
-	if !matches {
-		return errors.New(fmt.Sprintf("'update_number' is not valid. It should match '%s'.",
-			constant.UPDATE_NUMBER_REGEX))
-	}
 	if len(updateDescriptorV2.PlatformVersion) == 0 {
 		return errors.New("'platform_version' field not found.")
 	}
-	matches, err = regexp.MatchString(constant.KERNEL_VERSION_REGEX, updateDescriptorV2.PlatformVersion)
+	matches, err := regexp.MatchString(constant.KERNEL_VERSION_REGEX, updateDescriptorV2.PlatformVersion)
 	if err != nil {
 		return err
 	}
@@ -345,13 +403,9 @@ func ValidateUpdateDescriptorV2(updateDescriptorV2 *UpdateDescriptorV2) error {
 	return nil
 }
 
-// Validate the given update number with regex
+// Validate the given update number with the configured pattern and range
 func ValidateUpdateNumber(updateNumber string) bool {
-	regex, err := regexp.Compile(constant.UPDATE_NUMBER_REGEX)
-	if err != nil {
-		HandleErrorAndExit(err)
-	}
-	return regex.MatchString(updateNumber)
+	return ValidateUpdateNumberFormat(updateNumber) == nil
 }
 
 // Validate the given platform version with regex
@@ -377,18 +431,14 @@ func ValidateUpdateDescriptorV3(updateDescriptorV3 *UpdateDescriptorV3) error {
 	if len(updateDescriptorV3.UpdateNumber) == 0 {
 		return errors.New("'update_number' field not found.")
 	}
-	matches, err := regexp.MatchString(constant.UPDATE_NUMBER_REGEX, updateDescriptorV3.UpdateNumber)
+	err := ValidateUpdateNumberFormat(updateDescriptorV3.UpdateNumber)
 	if err != nil {
 		return err
 	}
-	if !matches {
-		return errors.New(fmt.Sprintf("'update_number' is not valid. It should match '%s'.",
-			constant.UPDATE_NUMBER_REGEX))
-	}
 	if len(updateDescriptorV3.PlatformVersion) == 0 {
 		return errors.New("'platform_version' field not found.")
 	}
-	matches, err = regexp.MatchString(constant.KERNEL_VERSION_REGEX, updateDescriptorV3.PlatformVersion)
+	matches, err := regexp.MatchString(constant.KERNEL_VERSION_REGEX, updateDescriptorV3.PlatformVersion)
 	if err != nil {
 		return err
 	}
@@ -399,6 +449,25 @@ func ValidateUpdateDescriptorV3(updateDescriptorV3 *UpdateDescriptorV3) error {
 	if len(updateDescriptorV3.PlatformName) == 0 {
 		return errors.New("'platform_name' field not found.")
 	}
+	if err = ValidatePayloadRoots(updateDescriptorV3); err != nil {
+		return err
+	}
+	if err = ValidateOSVariants(updateDescriptorV3); err != nil {
+		return err
+	}
+	if err = ValidateSecretWaivers(updateDescriptorV3.SecretWaivers); err != nil {
+		return err
+	}
+	algorithm := updateDescriptorV3.ChecksumAlgorithm
+	if len(algorithm) != 0 && !IsSupportedChecksumAlgorithm(algorithm) {
+		return errors.New(fmt.Sprintf("'checksum_algorithm' field has the unsupported algorithm '%s'. Supported "+
+			"algorithms: %s", algorithm, strings.Join(supportedChecksumAlgorithms, ", ")))
+	}
+	if updateDescriptorV3.Preview != nil {
+		if _, err = updateDescriptorV3.Preview.GetExpiry(); err != nil {
+			return err
+		}
+	}
 
 	// Generate md5sum for the content generated by wum-uc tool
 	md5sum := GenerateMd5sumForGeneratedContent(updateDescriptorV3)
@@ -414,21 +483,24 @@ func ValidateUpdateDescriptorV3(updateDescriptorV3 *UpdateDescriptorV3) error {
 // Copies file source to destination
 func CopyFile(source string, dest string) (err error) {
 	logger.Debug(fmt.Sprintf("[CopyFile] Copying %s to %s.", source, dest))
-	sf, err := os.Open(source)
+	if err = CheckWritable(dest); err != nil {
+		return err
+	}
+	sf, err := FileSystem.Open(GetLongPath(source))
 	if err != nil {
 		return err
 	}
 	defer sf.Close()
-	df, err := os.Create(dest)
+	df, err := FileSystem.Create(GetLongPath(dest))
 	if err != nil {
 		return err
 	}
 	defer df.Close()
 	_, err = io.Copy(df, sf)
 	if err == nil {
-		si, err := os.Stat(source)
+		si, err := FileSystem.Stat(GetLongPath(source))
 		if err != nil {
-			return os.Chmod(dest, si.Mode())
+			return FileSystem.Chmod(GetLongPath(dest), si.Mode())
 		}
 	}
 	return
@@ -438,7 +510,7 @@ func CopyFile(source string, dest string) (err error) {
 func CopyDir(source string, dest string) (err error) {
 	logger.Debug(fmt.Sprintf("[CopyFile] Copying %s to %s.", source, dest))
 	// get properties of source dir
-	fi, err := os.Stat(source)
+	fi, err := FileSystem.Stat(GetLongPath(source))
 	if err != nil {
 		return err
 	}
@@ -446,18 +518,18 @@ func CopyDir(source string, dest string) (err error) {
 		return errors.New("Source is not a directory")
 	}
 	//Create the destination directory if it does not exist
-	_, err = os.Open(dest)
+	_, err = FileSystem.Stat(GetLongPath(dest))
 	if os.IsNotExist(err) {
 		// create dest dir
-		err = os.MkdirAll(dest, fi.Mode())
+		err = FileSystem.MkdirAll(GetLongPath(dest), fi.Mode())
 		if err != nil {
 			return err
 		}
 	}
-	entries, err := ioutil.ReadDir(source)
+	entries, err := afero.ReadDir(FileSystem, GetLongPath(source))
 	for _, entry := range entries {
-		sfp := source + "/" + entry.Name()
-		dfp := dest + "/" + entry.Name()
+		sfp := filepath.Join(source, entry.Name())
+		dfp := filepath.Join(dest, entry.Name())
 		if entry.IsDir() {
 			err = CopyDir(sfp, dfp)
 			if err != nil {
@@ -477,7 +549,7 @@ func CopyDir(source string, dest string) (err error) {
 // Check whether the given location contains a directory
 func IsDirectoryExists(location string) (bool, error) {
 	logger.Debug(fmt.Sprintf("Checking %s", location))
-	locationInfo, err := os.Stat(location)
+	locationInfo, err := FileSystem.Stat(GetLongPath(location))
 	if err != nil {
 		if os.IsNotExist(err) {
 			logger.Debug("Does not exist")
@@ -498,7 +570,7 @@ func IsDirectoryExists(location string) (bool, error) {
 
 // Check whether the given location contains a file
 func IsFileExists(location string) (bool, error) {
-	locationInfo, err := os.Stat(location)
+	locationInfo, err := FileSystem.Stat(GetLongPath(location))
 	if err != nil {
 		if os.IsNotExist(err) {
 			return false, nil
@@ -522,12 +594,28 @@ func HandleErrorAndExit(err error, customMessage ...interface{}) {
 		} else {
 			PrintError(append(customMessage, err.Error())...)
 		}
-		os.Exit(1)
+		// Errors are recorded in the log file so that it has the complete session
+		WriteToLogFile(levels.ERROR, getMessage(append(customMessage, err.Error())...))
+		if len(GetLogFilePath()) != 0 {
+			PrintInfo(fmt.Sprintf("Logs of this session are written to '%s'. Attach it when reporting the issue.",
+				GetLogFilePath()))
+		}
+		WriteValidationReport(GetExitCode(err))
+		CleanUpTemporaryDirectories()
+		PrintWarningSummary()
+		PrintStageTimings()
+		os.Exit(GetExitCode(err))
 	}
 }
 
 // This function is used to print error messages
 func PrintError(args ...interface{}) {
+	recordValidationReportError(getMessage(args...))
+	args = localizeArgs(args)
+	if IsJSONIOEnabled() {
+		PrintJSONMessage(&JSONIOMessage{Type: constant.JSON_IO_ERROR, Message: getMessage(args...)})
+		return
+	}
 	color.Set(color.FgRed, color.Bold)
 	fmt.Println(append(append([]interface{}{"\n[ERROR]"}, args...), "\n")...)
 	color.Unset()
@@ -535,13 +623,46 @@ func PrintError(args ...interface{}) {
 
 // This function is used to print error messages with a tab
 func PrintErrorWithTab(args ...interface{}) {
+	if IsJSONIOEnabled() {
+		PrintError(args...)
+		return
+	}
+	recordValidationReportError(getMessage(args...))
+	args = localizeArgs(args)
 	color.Set(color.FgRed, color.Bold)
 	fmt.Println(append(append([]interface{}{"\n\t[ERROR]"}, args...), "\n")...)
 	color.Unset()
 }
 
-// This function is used to print warning messages
+// This function is used to print warning messages. If the strict mode is enabled, the warning is printed as an error
+// and the tool will exit with a non-zero exit code.
 func PrintWarning(args ...interface{}) {
+	printWarningOfGroup("", args...)
+}
+
+// This function is used to print policy notices. They are printed as warnings and grouped separately in the warning
+// summary.
+func PrintPolicyNotice(args ...interface{}) {
+	printWarningOfGroup(constant.WARNING_GROUP_POLICY, args...)
+}
+
+// This function is used to print a warning and record it under the given group for the warning summary
+func printWarningOfGroup(group string, args ...interface{}) {
+	recordWarning(group, getMessage(args...))
+	WriteToLogFile(levels.WARN, getMessage(args...))
+	PublishEvent(constant.EVENT_WARNING, "", getMessage(args...), nil)
+	if viper.GetBool(constant.STRICT_MODE) {
+		PrintError(append([]interface{}{"[STRICT MODE]"}, args...)...)
+		WriteValidationReport(constant.EXIT_CODE_VALIDATION_FAILURE)
+		CleanUpTemporaryDirectories()
+		os.Exit(constant.EXIT_CODE_VALIDATION_FAILURE)
+	}
+	// Warnings are recorded in English for the summary and the logs, only the printed warning is localized
+	args = localizeArgs(args)
+	if IsJSONIOEnabled() {
+		PrintJSONMessage(&JSONIOMessage{Type: constant.JSON_IO_WARNING, Message: getMessage(args...)})
+		return
+	}
 	color.Set(color.FgRed, color.Bold)
 	fmt.Println(append([]interface{}{"[WARNING]"}, args...)...)
 	color.Unset()
@@ -549,16 +670,39 @@ func PrintWarning(args ...interface{}) {
 
 // This function is used to print info messages
 func PrintInfo(args ...interface{}) {
+	args = localizeArgs(args)
+	if IsJSONIOEnabled() {
+		PrintJSONMessage(&JSONIOMessage{Type: constant.JSON_IO_INFO, Message: getMessage(args...)})
+		return
+	}
 	fmt.Println(append([]interface{}{"[INFO]"}, args...)...)
 }
 
 // This function is used to print text in bold
 func PrintInBold(args ...interface{}) {
+	if IsJSONIOEnabled() {
+		PrintMessage(args...)
+		return
+	}
+	args = localizeArgs(args)
 	color.Set(color.Bold)
 	fmt.Print(args...)
 	color.Unset()
 }
 
+// This function is used to print general messages
+func PrintMessage(args ...interface{}) {
+	args = localizeArgs(args)
+	if IsJSONIOEnabled() {
+		message := getMessage(args...)
+		if len(message) != 0 {
+			PrintJSONMessage(&JSONIOMessage{Type: constant.JSON_IO_MESSAGE, Message: message})
+		}
+		return
+	}
+	fmt.Println(args...)
+}
+
 // This function will get the Jira summary associated with the given jira id. If an error occur, we just simply ignore
 // the error and return the default response.
 func GetJiraSummary(id string) string {
@@ -573,6 +717,11 @@ func GetJiraSummary(id string) string {
 	res, err := http.DefaultClient.Do(req)
 	if err != nil {
 		logger.Debug(fmt.Sprintf("Error occurred while requesting: %v", err))
+		abortOnlineStep(err)
+		if viper.GetBool(constant.REQUIRE_ONLINE) {
+			HandleErrorAndExit(NewNetworkError(err), fmt.Sprintf("Error occurred while getting the summary of "+
+				"'%s' from %s.", id, constant.ONLINE_SERVICE_JIRA))
+		}
 		return defaultResponse
 	}
 	defer res.Body.Close()
@@ -648,30 +797,13 @@ func GetRelativePath(file *zip.File) (relativePath string) {
 	return
 }
 
-// Download a file from given url to the given location.
+// Download a file from given url to the given location. The url can be any artifact URI supported by the storage
+// backends.
 func DownloadFile(file, url string) error {
-	// Get the data
-	resp, err := http.Get(url)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-	// Check if the response is successful
-	if resp.StatusCode != http.StatusOK {
-		return errors.New(fmt.Sprintf("Could not download the file from: %s", url))
-	}
-	// Create the file
-	out, err := os.Create(file)
-	if err != nil {
-		return err
-	}
-	defer out.Close()
-	// Write the body to file
-	_, err = io.Copy(out, resp.Body)
-	if err != nil {
+	if err := CheckWritable(file); err != nil {
 		return err
 	}
-	return nil
+	return DownloadArtifact(file, url)
 }
 
 // Download the content from given url as a byte array.
@@ -679,13 +811,13 @@ func GetContentFromUrl(url string) ([]byte, error) {
 	// Get the data
 	resp, err := http.Get(url)
 	if err != nil {
-		return []byte{}, err
+		return []byte{}, NewNetworkError(err)
 	}
 	defer resp.Body.Close()
 	// Check if the response is successful
 	if resp.StatusCode != http.StatusOK {
 		logger.Debug(fmt.Sprintf("Could not download the file from: %s", url))
-		return []byte{}, errors.New(fmt.Sprintf("Could not download the file from: %s", url))
+		return []byte{}, NewNetworkError(errors.New(fmt.Sprintf("Could not download the file from: %s", url)))
 	}
 	// Read content
 	respBytes, err := ioutil.ReadAll(resp.Body)
@@ -757,12 +889,15 @@ func InvokeGetRequest(url string) *http.Response {
 }
 
 func HandleUnableToConnectErrorAndExit(err error) {
+	// Online steps which can be run in degraded mode are aborted instead of exiting
+	abortOnlineStep(err)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "wum-uc: %v\n", "unable to connect to WUM servers")
 		logger.Error(err.Error())
 	}
 	fmt.Fprintf(os.Stderr, "wum-uc: %v\n", constant.UNABLE_TO_CONNECT_WUM_SERVERS)
-	os.Exit(1)
+	CleanUpTemporaryDirectories()
+	os.Exit(constant.EXIT_CODE_NETWORK_ERROR)
 }
 
 func makeAPICall(request *http.Request, isBasicAuth bool) *http.Response {
@@ -1025,12 +1160,10 @@ func getAccessTokenFromUserCreds(username string, attempt int, wumucConfig *WUMU
 // Prompt for the username and the password from the user.
 func getCredentials(username string) (bool, string, []byte) {
 	var password []byte
-	fmt.Fprintln(os.Stderr, constant.ENTER_YOUR_CREDENTIALS_MSG)
+	fmt.Fprintln(os.Stderr, Localize(constant.ENTER_YOUR_CREDENTIALS_MSG))
 
 	if username == "" {
-		reader := bufio.NewReader(os.Stdin)
-		fmt.Print("Email: ")
-		uName, err := reader.ReadString('\n')
+		uName, err := PromptUser("Email: ")
 		if err != nil {
 			HandleErrorAndExit(err, constant.UNABLE_TO_READ_YOUR_INPUT_MSG)
 		}
@@ -1038,23 +1171,21 @@ func getCredentials(username string) (bool, string, []byte) {
 		// Validate email address
 		validEmail := isValidateEmailAddress(username)
 		if !validEmail {
-			fmt.Fprintln(os.Stderr, constant.INVALID_EMAIL_ADDRESS)
+			fmt.Fprintln(os.Stderr, Localize(constant.INVALID_EMAIL_ADDRESS))
 			return validEmail, "", password
 		}
 	}
 	// Validate email address received from user input with -u flag
 	validEmail := isValidateEmailAddress(username)
 	if !validEmail {
-		fmt.Fprintln(os.Stderr, constant.INVALID_EMAIL_ADDRESS)
+		fmt.Fprintln(os.Stderr, Localize(constant.INVALID_EMAIL_ADDRESS))
 		return validEmail, "", password
 	}
 
-	fmt.Fprintf(os.Stderr, "Password for '%v': ", strings.TrimSpace(username))
-	password, err := terminal.ReadPassword(int(syscall.Stdin))
+	password, err := PromptPassword(fmt.Sprintf("Password for '%v': ", strings.TrimSpace(username)))
 	if err != nil {
 		HandleErrorAndExit(err, constant.UNABLE_TO_READ_YOUR_INPUT_MSG)
 	}
-	fmt.Fprintln(os.Stderr)
 	// As email already validated
 	return true, username, password
 }
@@ -1238,8 +1369,11 @@ func WriteUpdateDescriptorInDestination(data []byte, filePath, destination strin
 
 // Write the content passed as byte array to the destination file.
 func WriteFileToDestination(data []byte, filePath string) error {
+	if err := CheckWritable(filePath); err != nil {
+		return err
+	}
 	file, err := os.OpenFile(
-		filePath,
+		GetLongPath(filePath),
 		os.O_WRONLY|os.O_TRUNC|os.O_CREATE,
 		0600,
 	)
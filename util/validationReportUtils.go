@@ -0,0 +1,267 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/fatih/color"
+	"github.com/wso2/update-creator-tool/constant"
+)
+
+// struct which represents the result of validating a file of an update in the validation report
+type ValidationReportFile struct {
+	Path     string   `json:"path"`
+	Status   string   `json:"status"`
+	Errors   []string `json:"errors,omitempty"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// struct which has the counts of the validation report
+type ValidationReportSummary struct {
+	Files        int `json:"files"`
+	FilesOK      int `json:"files_ok"`
+	FilesWarning int `json:"files_with_warnings"`
+	FilesError   int `json:"files_with_errors"`
+	Errors       int `json:"errors"`
+	Warnings     int `json:"warnings"`
+}
+
+// struct which represents the machine readable report of validating an update
+type ValidationReport struct {
+	UpdateName   string                  `json:"update_name"`
+	Distribution string                  `json:"distribution,omitempty"`
+	Status       string                  `json:"status"`
+	ExitCode     int                     `json:"exit_code"`
+	Files        []ValidationReportFile  `json:"files"`
+	Errors       []string                `json:"errors"`
+	Warnings     []Warning               `json:"warnings"`
+	Summary      ValidationReportSummary `json:"summary"`
+}
+
+// struct which represents a requested output of the validation report
+type validationReportOutput struct {
+	format string
+	path   string
+	writer io.Writer
+}
+
+// struct which collects the results of the validation until the report is written
+type validationReportCollector struct {
+	report          ValidationReport
+	files           map[string]*ValidationReportFile
+	descriptorFiles map[string][]string
+	outputs         []validationReportOutput
+	mutex           sync.Mutex
+}
+
+// Collector of the validation report in progress. It is nil if a report is not requested.
+var validationReport *validationReportCollector
+
+// Writers of the supported formats of the validation report
+var validationReportWriters = map[string]func(io.Writer, *ValidationReport, map[string][]string) error{
+	constant.OUTPUT_FORMAT_JSON:  writeJSONValidationReport,
+	constant.OUTPUT_FORMAT_JUNIT: writeJUnitValidationReport,
+}
+
+// Check whether the given format of the validation report is supported.
+func IsSupportedValidationReportFormat(format string) bool {
+	_, found := validationReportWriters[format]
+	return found
+}
+
+// Start collecting the validation report which is written in the given format to the given file, or to the stdout if
+// the location is empty. This can be called more than once to write the report in several formats. As the stdout
+// only has the report in the latter case, the other messages are printed to the stderr.
+func StartValidationReport(format, location string) {
+	if validationReport == nil {
+		validationReport = &validationReportCollector{
+			files:           make(map[string]*ValidationReportFile),
+			descriptorFiles: make(map[string][]string),
+		}
+	}
+	output := validationReportOutput{format: format, path: location}
+	if len(location) == 0 {
+		output.writer = os.Stdout
+		os.Stdout = os.Stderr
+		color.Output = os.Stderr
+	}
+	validationReport.outputs = append(validationReport.outputs, output)
+}
+
+// Set the update and the distribution (or the product) it is validated against in the validation report.
+func SetValidationReportUpdate(updateName, distribution string) {
+	if validationReport == nil {
+		return
+	}
+	validationReport.report.UpdateName = updateName
+	validationReport.report.Distribution = distribution
+}
+
+// Add the given files of the update to the validation report. Their status is ok unless an error or a warning is
+// recorded for them.
+func AddValidationReportFiles(filePaths []string) {
+	if validationReport == nil {
+		return
+	}
+	validationReport.mutex.Lock()
+	defer validationReport.mutex.Unlock()
+	for _, filePath := range filePaths {
+		validationReport.getFile(filePath)
+	}
+}
+
+// Set the files declared in the update descriptor of the update against their classifications (added, modified or
+// removed) in the validation report. Each of them is a test case of the JUnit report.
+func SetValidationReportDescriptorFiles(addedFiles, modifiedFiles, removedFiles []string) {
+	if validationReport == nil {
+		return
+	}
+	validationReport.descriptorFiles[constant.FILE_CLASSIFICATION_ADDED] = addedFiles
+	validationReport.descriptorFiles[constant.FILE_CLASSIFICATION_MODIFIED] = modifiedFiles
+	validationReport.descriptorFiles[constant.FILE_CLASSIFICATION_REMOVED] = removedFiles
+}
+
+// Record an error of the given file of the update in the validation report. The error is printed separately.
+func RecordFileError(filePath, message string) {
+	if validationReport == nil {
+		return
+	}
+	validationReport.mutex.Lock()
+	defer validationReport.mutex.Unlock()
+	file := validationReport.getFile(filePath)
+	file.Errors = append(file.Errors, message)
+}
+
+// Record a warning of the given file of the update in the validation report. The warning is printed separately.
+func RecordFileWarning(filePath, message string) {
+	if validationReport == nil {
+		return
+	}
+	validationReport.mutex.Lock()
+	defer validationReport.mutex.Unlock()
+	file := validationReport.getFile(filePath)
+	file.Warnings = append(file.Warnings, message)
+}
+
+// Record the given error message in the validation report. All the printed errors are recorded.
+func recordValidationReportError(message string) {
+	if validationReport == nil {
+		return
+	}
+	validationReport.mutex.Lock()
+	defer validationReport.mutex.Unlock()
+	validationReport.report.Errors = append(validationReport.report.Errors, message)
+}
+
+// Write the validation report in the requested formats with the given exit code of the validation, along with the
+// warnings raised so far. The report is written only once, and nothing is written if a report is not requested.
+func WriteValidationReport(exitCode int) {
+	collector := validationReport
+	if collector == nil {
+		return
+	}
+	validationReport = nil
+	report := collector.getReport(exitCode)
+	for _, output := range collector.outputs {
+		err := writeValidationReportOutput(&output, report, collector.descriptorFiles)
+		if err != nil {
+			logger.Error(fmt.Sprintf("%v error occurred while writing the %s validation report", err,
+				output.format))
+		}
+	}
+}
+
+// Write the given validation report to the given output.
+func writeValidationReportOutput(output *validationReportOutput, report *ValidationReport,
+	descriptorFiles map[string][]string) error {
+	writer := output.writer
+	if writer == nil {
+		file, err := os.Create(output.path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		writer = file
+	}
+	return validationReportWriters[output.format](writer, report, descriptorFiles)
+}
+
+// Write the given validation report as an indented JSON object.
+func writeJSONValidationReport(writer io.Writer, report *ValidationReport, descriptorFiles map[string][]string) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(writer, string(data))
+	return err
+}
+
+// Get the file with the given path in the validation report, adding it if it is not found.
+func (collector *validationReportCollector) getFile(filePath string) *ValidationReportFile {
+	file, found := collector.files[filePath]
+	if !found {
+		file = &ValidationReportFile{Path: filePath}
+		collector.files[filePath] = file
+	}
+	return file
+}
+
+// Get the validation report with the given exit code. Files are sorted by the path and the statuses and the counts are
+// calculated from the collected results.
+func (collector *validationReportCollector) getReport(exitCode int) *ValidationReport {
+	report := collector.report
+	report.ExitCode = exitCode
+	report.Status = constant.VALIDATION_STATUS_PASSED
+	if exitCode != constant.EXIT_CODE_SUCCESS {
+		report.Status = constant.VALIDATION_STATUS_FAILED
+	}
+	report.Files = []ValidationReportFile{}
+	for _, file := range collector.files {
+		switch {
+		case len(file.Errors) != 0:
+			file.Status = constant.FILE_STATUS_ERROR
+			report.Summary.FilesError++
+		case len(file.Warnings) != 0:
+			file.Status = constant.FILE_STATUS_WARNING
+			report.Summary.FilesWarning++
+		default:
+			file.Status = constant.FILE_STATUS_OK
+			report.Summary.FilesOK++
+		}
+		report.Files = append(report.Files, *file)
+	}
+	sort.Slice(report.Files, func(i, j int) bool {
+		return report.Files[i].Path < report.Files[j].Path
+	})
+	if report.Errors == nil {
+		report.Errors = []string{}
+	}
+	report.Warnings = append([]Warning{}, GetWarnings()...)
+	report.Summary.Files = len(report.Files)
+	report.Summary.Errors = len(report.Errors)
+	report.Summary.Warnings = len(report.Warnings)
+	return &report
+}
@@ -0,0 +1,91 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// struct which is written to the stdin of the external validators as JSON
+type UpdateManifest struct {
+	UpdateName                  string            `json:"update_name"`
+	UpdateNumber                string            `json:"update_number"`
+	PlatformVersion             string            `json:"platform_version"`
+	PlatformName                string            `json:"platform_name"`
+	Files                       []string          `json:"files"`
+	FileSizes                   map[string]uint64 `json:"file_sizes"`
+	Description                 string            `json:"description,omitempty"`
+	Instructions                string            `json:"instructions,omitempty"`
+	BugFixes                    map[string]string `json:"bug_fixes,omitempty"`
+	CompatibleProducts          []ProductChanges  `json:"compatible_products,omitempty"`
+	PartiallyApplicableProducts []ProductChanges  `json:"partially_applicable_products,omitempty"`
+}
+
+// Run each of the given external validators with the given update manifest as JSON in the stdin. A validator is a
+// command line (executable followed by arguments). Validators exiting with a non-zero exit code are reported as
+// validation failures with the output of the validator as the reason.
+func RunExternalValidators(validators []string, updateManifest *UpdateManifest) []error {
+	var failures []error
+	if len(validators) == 0 {
+		return failures
+	}
+	data, err := json.Marshal(updateManifest)
+	if err != nil {
+		return append(failures, errors.New(fmt.Sprintf("error occurred while marshalling the update manifest: %v",
+			err)))
+	}
+	for _, validator := range validators {
+		commandArgs := strings.Fields(validator)
+		if len(commandArgs) == 0 {
+			continue
+		}
+		logger.Debug(fmt.Sprintf("Running external validator: %s", validator))
+		err := runExternalValidator(commandArgs, data)
+		if err != nil {
+			logger.Debug(fmt.Sprintf("External validator '%s' failed: %v", validator, err))
+			failures = append(failures, errors.New(fmt.Sprintf("validator '%s' failed: %v", validator, err)))
+		}
+	}
+	return failures
+}
+
+// Run a single external validator. Combined output of the validator is returned as the error message if the
+// validator exits with a non-zero exit code.
+func runExternalValidator(commandArgs []string, updateManifest []byte) error {
+	var output bytes.Buffer
+	command := exec.Command(commandArgs[0], commandArgs[1:]...)
+	command.Stdin = bytes.NewReader(updateManifest)
+	command.Stdout = &output
+	command.Stderr = &output
+	err := command.Run()
+	if err == nil {
+		return nil
+	}
+	message := strings.TrimSpace(output.String())
+	if len(message) == 0 {
+		return err
+	}
+	return errors.New(message)
+}
@@ -0,0 +1,109 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/wso2/update-creator-tool/constant"
+)
+
+// struct which represents a single warning or policy notice raised while running a command
+type Warning struct {
+	Group   string `json:"group" yaml:"group"`
+	Message string `json:"message" yaml:"message"`
+}
+
+// Warnings raised so far which are printed in the summary before the command exits
+var warnings []Warning
+
+// Record the given warning so that it is included in the warning summary. Warnings raised during a stage are grouped
+// under the outermost stage in progress. Warnings raised outside a stage are grouped under the general group unless a
+// group is given.
+func recordWarning(group, message string) {
+	if len(group) == 0 {
+		group = getOutermostStage()
+	}
+	if len(group) == 0 {
+		group = constant.WARNING_GROUP_GENERAL
+	}
+	warnings = append(warnings, Warning{Group: group, Message: message})
+}
+
+// Get the warnings raised so far.
+func GetWarnings() []Warning {
+	return warnings
+}
+
+// Clear the warnings raised so far.
+func ClearWarnings() {
+	warnings = nil
+}
+
+// Group the given warnings. Groups are returned in the order they were first raised along with the messages of each
+// group.
+func GroupWarnings(warnings []Warning) ([]string, map[string][]string) {
+	var groups []string
+	groupedWarnings := make(map[string][]string)
+	for _, warning := range warnings {
+		if _, found := groupedWarnings[warning.Group]; !found {
+			groups = append(groups, warning.Group)
+		}
+		groupedWarnings[warning.Group] = append(groupedWarnings[warning.Group], warning.Message)
+	}
+	return groups, groupedWarnings
+}
+
+// Print the warnings raised so far as a summary grouped by the stage or policy which raised them. In JSON IO mode the
+// summary is written as a single JSON object. Warnings are cleared once printed so that the summary is printed only
+// once.
+func PrintWarningSummary() {
+	if len(warnings) == 0 {
+		return
+	}
+	defer ClearWarnings()
+	if IsJSONIOEnabled() {
+		PrintJSONMessage(&JSONIOMessage{
+			Type:     constant.JSON_IO_WARNING_SUMMARY,
+			Message:  fmt.Sprintf("%d warning(s) raised", len(warnings)),
+			Warnings: warnings,
+		})
+		return
+	}
+	groups, groupedWarnings := GroupWarnings(warnings)
+	color.Set(color.FgYellow, color.Bold)
+	fmt.Println(fmt.Sprintf("\n[WARNING SUMMARY] %d warning(s) raised", len(warnings)))
+	color.Unset()
+	for _, group := range groups {
+		color.Set(color.FgYellow, color.Bold)
+		fmt.Println(fmt.Sprintf("  %s (%d):", group, len(groupedWarnings[group])))
+		color.Unset()
+		if group == constant.WARNING_GROUP_POLICY {
+			color.Set(color.FgRed, color.Bold)
+		} else {
+			color.Set(color.FgRed)
+		}
+		for _, message := range groupedWarnings[group] {
+			fmt.Println(fmt.Sprintf("    - %s", message))
+		}
+		color.Unset()
+	}
+}
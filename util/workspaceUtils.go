@@ -0,0 +1,215 @@
+/*
+ * Copyright (c) 2018, WSO2 Inc. (http://www.wso2.org) All Rights Reserved.
+ *
+ * WSO2 Inc. licenses this file to you under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package util
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/wso2/update-creator-tool/constant"
+	"gopkg.in/yaml.v2"
+)
+
+// struct which is used to read and write a workspace. A workspace keeps track of an in-progress update.
+type Workspace struct {
+	Name             string `yaml:"name"`
+	UpdateDirectory  string `yaml:"update-directory"`
+	Distribution     string `yaml:"distribution"`
+	ValidationStatus string `yaml:"validation-status"`
+	CreatedAt        string `yaml:"created-at"`
+	UpdatedAt        string `yaml:"updated-at"`
+}
+
+// struct which is used to read and write the answers recorded in a workspace. The format is compatible with the
+// answer files used in batch creation.
+type RecordedAnswers struct {
+	Answers []RecordedAnswer `yaml:"answers"`
+}
+
+type RecordedAnswer struct {
+	Prompt string `yaml:"prompt"`
+	Answer string `yaml:"answer"`
+}
+
+// Function which is called with each prompt and the answer given by the user
+var answerRecorder func(prompt, answer string)
+
+// Set the function which records the answers given by the user for the prompts.
+func SetAnswerRecorder(recorder func(prompt, answer string)) {
+	answerRecorder = recorder
+}
+
+// Get the directory of the workspace with the given name.
+func GetWorkspaceDirectory(wumucHome, name string) string {
+	return filepath.Join(wumucHome, constant.WUMUC_WORKSPACES_DIRECTORY, name)
+}
+
+// Create a new workspace with the given name.
+func CreateWorkspace(wumucHome, name, updateDirectory, distribution string) (*Workspace, error) {
+	if !regexp.MustCompile(constant.WORKSPACE_NAME_REGEX).MatchString(name) {
+		return nil, errors.New(fmt.Sprintf("workspace name '%s' is invalid. It should match '%s'.", name,
+			constant.WORKSPACE_NAME_REGEX))
+	}
+	workspaceDirectory := GetWorkspaceDirectory(wumucHome, name)
+	exists, err := IsDirectoryExists(workspaceDirectory)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, errors.New(fmt.Sprintf("workspace '%s' already exists", name))
+	}
+	if err = CreateDirectory(workspaceDirectory); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	workspace := Workspace{
+		Name:             name,
+		UpdateDirectory:  updateDirectory,
+		Distribution:     distribution,
+		ValidationStatus: constant.WORKSPACE_STATUS_NEW,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+	return &workspace, SaveWorkspace(wumucHome, &workspace)
+}
+
+// Load the workspace with the given name.
+func LoadWorkspace(wumucHome, name string) (*Workspace, error) {
+	workspaceFilePath := filepath.Join(GetWorkspaceDirectory(wumucHome, name), constant.WORKSPACE_FILE)
+	exists, err := IsFileExists(workspaceFilePath)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.New(fmt.Sprintf("workspace '%s' does not exist. Run 'wum-uc workspace new' to create "+
+			"it", name))
+	}
+	data, err := ioutil.ReadFile(workspaceFilePath)
+	if err != nil {
+		return nil, err
+	}
+	workspace := Workspace{}
+	if err = yaml.Unmarshal(data, &workspace); err != nil {
+		return nil, errors.New(fmt.Sprintf("unable to read the workspace '%s': %v", workspaceFilePath, err))
+	}
+	return &workspace, nil
+}
+
+// Save the given workspace.
+func SaveWorkspace(wumucHome string, workspace *Workspace) error {
+	data, err := yaml.Marshal(workspace)
+	if err != nil {
+		return err
+	}
+	return WriteFileToDestination(data, filepath.Join(GetWorkspaceDirectory(wumucHome, workspace.Name),
+		constant.WORKSPACE_FILE))
+}
+
+// Update the validation status of the workspace with the given name.
+func SetWorkspaceStatus(wumucHome, name, status string) error {
+	workspace, err := LoadWorkspace(wumucHome, name)
+	if err != nil {
+		return err
+	}
+	workspace.ValidationStatus = status
+	workspace.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	return SaveWorkspace(wumucHome, workspace)
+}
+
+// List all the workspaces sorted by the name.
+func ListWorkspaces(wumucHome string) ([]*Workspace, error) {
+	var workspaces []*Workspace
+	workspacesDirectory := filepath.Join(wumucHome, constant.WUMUC_WORKSPACES_DIRECTORY)
+	exists, err := IsDirectoryExists(workspacesDirectory)
+	if err != nil || !exists {
+		return workspaces, err
+	}
+	fileInfos, err := ioutil.ReadDir(workspacesDirectory)
+	if err != nil {
+		return nil, err
+	}
+	for _, fileInfo := range fileInfos {
+		if !fileInfo.IsDir() {
+			continue
+		}
+		workspace, err := LoadWorkspace(wumucHome, fileInfo.Name())
+		if err != nil {
+			logger.Debug(fmt.Sprintf("Skipping '%s': %v", fileInfo.Name(), err))
+			continue
+		}
+		workspaces = append(workspaces, workspace)
+	}
+	sort.Slice(workspaces, func(i, j int) bool {
+		return workspaces[i].Name < workspaces[j].Name
+	})
+	return workspaces, nil
+}
+
+// Remove the workspace with the given name.
+func RemoveWorkspace(wumucHome, name string) error {
+	if _, err := LoadWorkspace(wumucHome, name); err != nil {
+		return err
+	}
+	return os.RemoveAll(GetWorkspaceDirectory(wumucHome, name))
+}
+
+// Load the answers recorded in the workspace with the given name.
+func LoadRecordedAnswers(wumucHome, name string) (*RecordedAnswers, error) {
+	recordedAnswers := RecordedAnswers{}
+	answersFilePath := filepath.Join(GetWorkspaceDirectory(wumucHome, name), constant.WORKSPACE_ANSWERS_FILE)
+	exists, err := IsFileExists(answersFilePath)
+	if err != nil || !exists {
+		return &recordedAnswers, err
+	}
+	data, err := ioutil.ReadFile(answersFilePath)
+	if err != nil {
+		return nil, err
+	}
+	if err = yaml.Unmarshal(data, &recordedAnswers); err != nil {
+		return nil, errors.New(fmt.Sprintf("unable to read the recorded answers '%s': %v", answersFilePath, err))
+	}
+	return &recordedAnswers, nil
+}
+
+// Record the given answer in the workspace with the given name. The prompt is recorded as a regular expression which
+// only matches the given prompt so that the answers can be replayed in batch creation.
+func RecordAnswer(wumucHome, name, prompt, answer string) error {
+	recordedAnswers, err := LoadRecordedAnswers(wumucHome, name)
+	if err != nil {
+		return err
+	}
+	recordedAnswers.Answers = append(recordedAnswers.Answers, RecordedAnswer{
+		Prompt: "^" + regexp.QuoteMeta(strings.TrimSpace(prompt)) + "$",
+		Answer: answer,
+	})
+	data, err := yaml.Marshal(recordedAnswers)
+	if err != nil {
+		return err
+	}
+	return WriteFileToDestination(data, filepath.Join(GetWorkspaceDirectory(wumucHome, name),
+		constant.WORKSPACE_ANSWERS_FILE))
+}